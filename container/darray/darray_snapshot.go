@@ -0,0 +1,116 @@
+package darray
+
+import (
+	"github.com/osgochina/donkeygo/container/dsnapshot"
+	"github.com/osgochina/donkeygo/util/dconv"
+	"io"
+)
+
+// snapshotList writes the copied items of an array to `w` in the binary format of dsnapshot,
+// `value(i)` returns the item at index i and `option` specifies the codec of the items.
+// The caller copies the items with the read lock first, so writers are only blocked while copying.
+func snapshotList(w io.Writer, size int, value func(i int) interface{}, option []dsnapshot.Option) error {
+	return dsnapshot.WriteList(w, func(f func(value interface{}) bool) {
+		for i := 0; i < size; i++ {
+			if !f(value(i)) {
+				return
+			}
+		}
+	}, option...)
+}
+
+// Snapshot writes all the items of the array to `w`.
+func (that *Array) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	items := that.Slice()
+	return snapshotList(w, len(items), func(i int) interface{} { return items[i] }, option)
+}
+
+// Restore replaces the items of the array with the snapshot read from `r`.
+func (that *Array) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	items, err := dsnapshot.ReadList(r, option...)
+	if err != nil {
+		return err
+	}
+	that.SetArray(items)
+	return nil
+}
+
+// Snapshot writes all the items of the array to `w`.
+func (that *IntArray) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	items := that.Slice()
+	return snapshotList(w, len(items), func(i int) interface{} { return items[i] }, option)
+}
+
+// Restore replaces the items of the array with the snapshot read from `r`.
+func (that *IntArray) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	items, err := dsnapshot.ReadList(r, option...)
+	if err != nil {
+		return err
+	}
+	that.SetArray(dconv.Ints(items))
+	return nil
+}
+
+// Snapshot writes all the items of the array to `w`.
+func (a *StrArray) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	items := a.Slice()
+	return snapshotList(w, len(items), func(i int) interface{} { return items[i] }, option)
+}
+
+// Restore replaces the items of the array with the snapshot read from `r`.
+func (a *StrArray) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	items, err := dsnapshot.ReadList(r, option...)
+	if err != nil {
+		return err
+	}
+	a.SetArray(dconv.Strings(items))
+	return nil
+}
+
+// Snapshot writes all the items of the array to `w`.
+func (a *SortedArray) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	items := a.Slice()
+	return snapshotList(w, len(items), func(i int) interface{} { return items[i] }, option)
+}
+
+// Restore replaces the items of the array with the sorted items of the snapshot read from `r`.
+func (a *SortedArray) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	items, err := dsnapshot.ReadList(r, option...)
+	if err != nil {
+		return err
+	}
+	a.SetArray(items)
+	return nil
+}
+
+// Snapshot writes all the items of the array to `w`.
+func (that *SortedIntArray) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	items := that.Slice()
+	return snapshotList(w, len(items), func(i int) interface{} { return items[i] }, option)
+}
+
+// Restore replaces the items of the array with the sorted items of the snapshot read from `r`.
+func (that *SortedIntArray) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	items, err := dsnapshot.ReadList(r, option...)
+	if err != nil {
+		return err
+	}
+	that.SetArray(dconv.Ints(items))
+	return nil
+}
+
+// Snapshot writes all the items of the array to `w`.
+func (that *SortedStrArray) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	items := that.Slice()
+	return snapshotList(w, len(items), func(i int) interface{} { return items[i] }, option)
+}
+
+// Restore replaces the items of the array with the sorted items of the snapshot read from `r`.
+func (that *SortedStrArray) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	items, err := dsnapshot.ReadList(r, option...)
+	if err != nil {
+		return err
+	}
+	that.SetArray(dconv.Strings(items))
+	return nil
+}
@@ -7,14 +7,10 @@
 package darray
 
 import (
-	"bytes"
+	"encoding/json"
 	"fmt"
-	"github.com/osgochina/donkeygo/internal/json"
-	"github.com/osgochina/donkeygo/internal/rwmutex"
+	"github.com/osgochina/donkeygo/container/dgeneric"
 	"github.com/osgochina/donkeygo/util/dconv"
-	"github.com/osgochina/donkeygo/util/drand"
-	"math"
-	"sort"
 )
 
 // SortedIntArray is a golang sorted int array with rich features.
@@ -22,11 +18,9 @@ import (
 // setting it a custom comparator.
 // It contains a concurrent-safe/unsafe switch, which should be set
 // when its initialization and cannot be changed then.
+// It is a thin wrapper of dgeneric.SortedArray[int].
 type SortedIntArray struct {
-	mu         rwmutex.RWMutex
-	array      []int
-	unique     bool               // Whether enable unique feature(false)
-	comparator func(a, b int) int // Comparison function(it returns -1: a < b; 0: a == b; 1: a > b)
+	array *dgeneric.SortedArray[int]
 }
 
 // NewSortedIntArray creates and returns an empty sorted array.
@@ -39,9 +33,9 @@ func NewSortedIntArray(safe ...bool) *SortedIntArray {
 // NewSortedIntArrayComparator creates and returns an empty sorted array with specified comparator.
 // The parameter <safe> is used to specify whether using array in concurrent-safety which is false in default.
 func NewSortedIntArrayComparator(comparator func(a, b int) int, safe ...bool) *SortedIntArray {
-	array := NewSortedIntArray(safe...)
-	array.comparator = comparator
-	return array
+	return &SortedIntArray{
+		array: dgeneric.NewSortedArray(comparator, safe...),
+	}
 }
 
 // NewSortedIntArraySize create and returns an sorted array with given size and cap.
@@ -49,9 +43,7 @@ func NewSortedIntArrayComparator(comparator func(a, b int) int, safe ...bool) *S
 // which is false in default.
 func NewSortedIntArraySize(cap int, safe ...bool) *SortedIntArray {
 	return &SortedIntArray{
-		mu:         rwmutex.Create(safe...),
-		array:      make([]int, 0, cap),
-		comparator: defaultComparatorInt,
+		array: dgeneric.NewSortedArraySize(cap, defaultComparatorInt, safe...),
 	}
 }
 
@@ -74,27 +66,31 @@ func NewSortedIntArrayRange(start, end, step int, safe ...bool) *SortedIntArray
 // The parameter <safe> is used to specify whether using array in concurrent-safety,
 // which is false in default.
 func NewSortedIntArrayFrom(array []int, safe ...bool) *SortedIntArray {
-	a := NewSortedIntArraySize(0, safe...)
-	a.array = array
-	sort.Ints(a.array)
-	return a
+	return &SortedIntArray{
+		array: dgeneric.NewSortedArrayFrom(array, defaultComparatorInt, safe...),
+	}
 }
 
 // NewSortedIntArrayFromCopy creates and returns an sorted array from a copy of given slice <array>.
 // The parameter <safe> is used to specify whether using array in concurrent-safety,
 // which is false in default.
 func NewSortedIntArrayFromCopy(array []int, safe ...bool) *SortedIntArray {
-	newArray := make([]int, len(array))
-	copy(newArray, array)
-	return NewSortedIntArrayFrom(newArray, safe...)
+	return &SortedIntArray{
+		array: dgeneric.NewSortedArrayFromCopy(array, defaultComparatorInt, safe...),
+	}
+}
+
+// generic returns the underlying generic array, a zero value array is initialized on first use.
+func (that *SortedIntArray) generic() *dgeneric.SortedArray[int] {
+	if that.array == nil {
+		that.array = dgeneric.NewSortedArray(defaultComparatorInt)
+	}
+	return that.array
 }
 
 // SetArray sets the underlying slice array with the given <array>.
 func (that *SortedIntArray) SetArray(array []int) *SortedIntArray {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	that.array = array
-	quickSortInt(that.array, that.getComparator())
+	that.generic().SetArray(array)
 	return that
 }
 
@@ -102,9 +98,7 @@ func (that *SortedIntArray) SetArray(array []int) *SortedIntArray {
 // The parameter <reverse> controls whether sort
 // in increasing order(default) or decreasing order.
 func (that *SortedIntArray) Sort() *SortedIntArray {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	quickSortInt(that.array, that.getComparator())
+	that.generic().Sort()
 	return that
 }
 
@@ -116,173 +110,65 @@ func (that *SortedIntArray) Add(values ...int) *SortedIntArray {
 
 // Append adds one or multiple values to sorted array, the array always keeps sorted.
 func (that *SortedIntArray) Append(values ...int) *SortedIntArray {
-	if len(values) == 0 {
-		return that
-	}
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	for _, value := range values {
-		index, cmp := that.binSearch(value, false)
-		if that.unique && cmp == 0 {
-			continue
-		}
-		if index < 0 {
-			that.array = append(that.array, value)
-			continue
-		}
-		if cmp > 0 {
-			index++
-		}
-		rear := append([]int{}, that.array[index:]...)
-		that.array = append(that.array[0:index], value)
-		that.array = append(that.array, rear...)
-	}
+	that.generic().Append(values...)
 	return that
 }
 
 // Get returns the value by the specified index.
 // If the given <index> is out of range of the array, the <found> is false.
 func (that *SortedIntArray) Get(index int) (value int, found bool) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	if index < 0 || index >= len(that.array) {
-		return 0, false
-	}
-	return that.array[index], true
+	return that.generic().Get(index)
 }
 
 // Remove removes an item by index.
 // If the given <index> is out of range of the array, the <found> is false.
 func (that *SortedIntArray) Remove(index int) (value int, found bool) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	return that.doRemoveWithoutLock(index)
-}
-
-// doRemoveWithoutLock removes an item by index without lock.
-func (that *SortedIntArray) doRemoveWithoutLock(index int) (value int, found bool) {
-	if index < 0 || index >= len(that.array) {
-		return 0, false
-	}
-	// Determine array boundaries when deleting to improve deletion efficiency.
-	if index == 0 {
-		value := that.array[0]
-		that.array = that.array[1:]
-		return value, true
-	} else if index == len(that.array)-1 {
-		value := that.array[index]
-		that.array = that.array[:index]
-		return value, true
-	}
-	// If it is a non-boundary delete,
-	// it will involve the creation of an array,
-	// then the deletion is less efficient.
-	value = that.array[index]
-	that.array = append(that.array[:index], that.array[index+1:]...)
-	return value, true
+	return that.generic().Remove(index)
 }
 
 // RemoveValue removes an item by value.
 // It returns true if value is found in the array, or else false if not found.
 func (that *SortedIntArray) RemoveValue(value int) bool {
-	if i := that.Search(value); i != -1 {
-		_, found := that.Remove(i)
-		return found
-	}
-	return false
+	return that.generic().RemoveValue(value)
 }
 
 // PopLeft pops and returns an item from the beginning of array.
 // Note that if the array is empty, the <found> is false.
 func (that *SortedIntArray) PopLeft() (value int, found bool) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if len(that.array) == 0 {
-		return 0, false
-	}
-	value = that.array[0]
-	that.array = that.array[1:]
-	return value, true
+	return that.generic().PopLeft()
 }
 
 // PopRight pops and returns an item from the end of array.
 // Note that if the array is empty, the <found> is false.
 func (that *SortedIntArray) PopRight() (value int, found bool) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	index := len(that.array) - 1
-	if index < 0 {
-		return 0, false
-	}
-	value = that.array[index]
-	that.array = that.array[:index]
-	return value, true
+	return that.generic().PopRight()
 }
 
 // PopRand randomly pops and return an item out of array.
 // Note that if the array is empty, the <found> is false.
 func (that *SortedIntArray) PopRand() (value int, found bool) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	return that.doRemoveWithoutLock(drand.Intn(len(that.array)))
+	return that.generic().PopRand()
 }
 
 // PopRands randomly pops and returns <size> items out of array.
 // If the given <size> is greater than size of the array, it returns all elements of the array.
 // Note that if given <size> <= 0 or the array is empty, it returns nil.
 func (that *SortedIntArray) PopRands(size int) []int {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if size <= 0 || len(that.array) == 0 {
-		return nil
-	}
-	if size >= len(that.array) {
-		size = len(that.array)
-	}
-	array := make([]int, size)
-	for i := 0; i < size; i++ {
-		array[i], _ = that.doRemoveWithoutLock(drand.Intn(len(that.array)))
-	}
-	return array
+	return that.generic().PopRands(size)
 }
 
 // PopLefts pops and returns <size> items from the beginning of array.
 // If the given <size> is greater than size of the array, it returns all elements of the array.
 // Note that if given <size> <= 0 or the array is empty, it returns nil.
 func (that *SortedIntArray) PopLefts(size int) []int {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if size <= 0 || len(that.array) == 0 {
-		return nil
-	}
-	if size >= len(that.array) {
-		array := that.array
-		that.array = that.array[:0]
-		return array
-	}
-	value := that.array[0:size]
-	that.array = that.array[size:]
-	return value
+	return that.generic().PopLefts(size)
 }
 
 // PopRights pops and returns <size> items from the end of array.
 // If the given <size> is greater than size of the array, it returns all elements of the array.
 // Note that if given <size> <= 0 or the array is empty, it returns nil.
 func (that *SortedIntArray) PopRights(size int) []int {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if size <= 0 || len(that.array) == 0 {
-		return nil
-	}
-	index := len(that.array) - size
-	if index <= 0 {
-		array := that.array
-		that.array = that.array[:0]
-		return array
-	}
-	value := that.array[index:]
-	that.array = that.array[:index]
-	return value
+	return that.generic().PopRights(size)
 }
 
 // Range picks and returns items by range, like array[start:end].
@@ -293,26 +179,7 @@ func (that *SortedIntArray) PopRights(size int) []int {
 // If <end> is omitted, then the sequence will have everything from start up
 // until the end of the array.
 func (that *SortedIntArray) Range(start int, end ...int) []int {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	offsetEnd := len(that.array)
-	if len(end) > 0 && end[0] < offsetEnd {
-		offsetEnd = end[0]
-	}
-	if start > offsetEnd {
-		return nil
-	}
-	if start < 0 {
-		start = 0
-	}
-	array := ([]int)(nil)
-	if that.mu.IsSafe() {
-		array = make([]int, offsetEnd-start)
-		copy(array, that.array[start:offsetEnd])
-	} else {
-		array = that.array[start:offsetEnd]
-	}
-	return array
+	return that.generic().Range(start, end...)
 }
 
 // SubSlice returns a slice of elements from the array as specified
@@ -329,57 +196,21 @@ func (that *SortedIntArray) Range(start int, end ...int) []int {
 //
 // Any possibility crossing the left border of array, it will fail.
 func (that *SortedIntArray) SubSlice(offset int, length ...int) []int {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	size := len(that.array)
-	if len(length) > 0 {
-		size = length[0]
-	}
-	if offset > len(that.array) {
-		return nil
-	}
-	if offset < 0 {
-		offset = len(that.array) + offset
-		if offset < 0 {
-			return nil
-		}
-	}
-	if size < 0 {
-		offset += size
-		size = -size
-		if offset < 0 {
-			return nil
-		}
-	}
-	end := offset + size
-	if end > len(that.array) {
-		end = len(that.array)
-		size = len(that.array) - offset
-	}
-	if that.mu.IsSafe() {
-		s := make([]int, size)
-		copy(s, that.array[offset:])
-		return s
-	} else {
-		return that.array[offset:end]
-	}
+	return that.generic().SubSlice(offset, length...)
 }
 
 // Len returns the length of array.
 func (that *SortedIntArray) Len() int {
-	that.mu.RLock()
-	length := len(that.array)
-	that.mu.RUnlock()
-	return length
+	return that.generic().Len()
 }
 
 // Sum returns the sum of values in an array.
 func (that *SortedIntArray) Sum() (sum int) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	for _, v := range that.array {
-		sum += v
-	}
+	that.generic().RLockFunc(func(array []int) {
+		for _, v := range array {
+			sum += v
+		}
+	})
 	return
 }
 
@@ -387,26 +218,18 @@ func (that *SortedIntArray) Sum() (sum int) {
 // Note that, if it's in concurrent-safe usage, it returns a copy of underlying data,
 // or else a pointer to the underlying data.
 func (that *SortedIntArray) Slice() []int {
-	array := ([]int)(nil)
-	if that.mu.IsSafe() {
-		that.mu.RLock()
-		defer that.mu.RUnlock()
-		array = make([]int, len(that.array))
-		copy(array, that.array)
-	} else {
-		array = that.array
-	}
-	return array
+	return that.generic().Slice()
 }
 
 // Interfaces returns current array as []interface{}.
 func (that *SortedIntArray) Interfaces() []interface{} {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	array := make([]interface{}, len(that.array))
-	for k, v := range that.array {
-		array[k] = v
-	}
+	var array []interface{}
+	that.generic().RLockFunc(func(values []int) {
+		array = make([]interface{}, len(values))
+		for k, v := range values {
+			array[k] = v
+		}
+	})
 	return array
 }
 
@@ -418,109 +241,43 @@ func (that *SortedIntArray) Contains(value int) bool {
 // Search searches array by <value>, returns the index of <value>,
 // or returns -1 if not exists.
 func (that *SortedIntArray) Search(value int) (index int) {
-	if i, r := that.binSearch(value, true); r == 0 {
-		return i
-	}
-	return -1
-}
-
-// Binary search.
-// It returns the last compared index and the result.
-// If <result> equals to 0, it means the value at <index> is equals to <value>.
-// If <result> lesser than 0, it means the value at <index> is lesser than <value>.
-// If <result> greater than 0, it means the value at <index> is greater than <value>.
-func (that *SortedIntArray) binSearch(value int, lock bool) (index int, result int) {
-	if lock {
-		that.mu.RLock()
-		defer that.mu.RUnlock()
-	}
-	if len(that.array) == 0 {
-		return -1, -2
-	}
-	min := 0
-	max := len(that.array) - 1
-	mid := 0
-	cmp := -2
-	for min <= max {
-		mid = min + int((max-min)/2)
-		cmp = that.getComparator()(value, that.array[mid])
-		switch {
-		case cmp < 0:
-			max = mid - 1
-		case cmp > 0:
-			min = mid + 1
-		default:
-			return mid, cmp
-		}
-	}
-	return mid, cmp
+	return that.generic().Search(value)
 }
 
 // SetUnique sets unique mark to the array,
 // which means it does not contain any repeated items.
 // It also do unique check, remove all repeated items.
 func (that *SortedIntArray) SetUnique(unique bool) *SortedIntArray {
-	oldUnique := that.unique
-	that.unique = unique
-	if unique && oldUnique != unique {
-		that.Unique()
-	}
+	that.generic().SetUnique(unique)
 	return that
 }
 
 // Unique uniques the array, clear repeated items.
 func (that *SortedIntArray) Unique() *SortedIntArray {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if len(that.array) == 0 {
-		return that
-	}
-	i := 0
-	for {
-		if i == len(that.array)-1 {
-			break
-		}
-		if that.getComparator()(that.array[i], that.array[i+1]) == 0 {
-			that.array = append(that.array[:i+1], that.array[i+1+1:]...)
-		} else {
-			i++
-		}
-	}
+	that.generic().Unique()
 	return that
 }
 
 // Clone returns a new array, which is a copy of current array.
 func (that *SortedIntArray) Clone() (newArray *SortedIntArray) {
-	that.mu.RLock()
-	array := make([]int, len(that.array))
-	copy(array, that.array)
-	that.mu.RUnlock()
-	return NewSortedIntArrayFrom(array, that.mu.IsSafe())
+	return &SortedIntArray{array: that.generic().Clone()}
 }
 
 // Clear deletes all items of current array.
 func (that *SortedIntArray) Clear() *SortedIntArray {
-	that.mu.Lock()
-	if len(that.array) > 0 {
-		that.array = make([]int, 0)
-	}
-	that.mu.Unlock()
+	that.generic().Clear()
 	return that
 }
 
 // LockFunc locks writing by callback function <f>.
 func (that *SortedIntArray) LockFunc(f func(array []int)) *SortedIntArray {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	f(that.array)
+	that.generic().LockFunc(f)
 	return that
 }
 
 // RLockFunc locks reading by callback function <f>.
 func (that *SortedIntArray) RLockFunc(f func(array []int)) *SortedIntArray {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	f(that.array)
+	that.generic().RLockFunc(f)
 	return that
 }
 
@@ -536,74 +293,32 @@ func (that *SortedIntArray) Merge(array interface{}) *SortedIntArray {
 // the size of each array is determined by <size>.
 // The last chunk may contain less than size elements.
 func (that *SortedIntArray) Chunk(size int) [][]int {
-	if size < 1 {
-		return nil
-	}
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	length := len(that.array)
-	chunks := int(math.Ceil(float64(length) / float64(size)))
-	var n [][]int
-	for i, end := 0, 0; chunks > 0; chunks-- {
-		end = (i + 1) * size
-		if end > length {
-			end = length
-		}
-		n = append(n, that.array[i*size:end])
-		i++
-	}
-	return n
+	return that.generic().Chunk(size)
 }
 
 // Rand randomly returns one item from array(no deleting).
 func (that *SortedIntArray) Rand() (value int, found bool) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	if len(that.array) == 0 {
-		return 0, false
-	}
-	return that.array[drand.Intn(len(that.array))], true
+	return that.generic().Rand()
 }
 
 // Rands randomly returns <size> items from array(no deleting).
 func (that *SortedIntArray) Rands(size int) []int {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	if size <= 0 || len(that.array) == 0 {
-		return nil
-	}
-	array := make([]int, size)
-	for i := 0; i < size; i++ {
-		array[i] = that.array[drand.Intn(len(that.array))]
-	}
-	return array
+	return that.generic().Rands(size)
 }
 
 // Join joins array elements with a string <glue>.
 func (that *SortedIntArray) Join(glue string) string {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	if len(that.array) == 0 {
-		return ""
-	}
-	buffer := bytes.NewBuffer(nil)
-	for k, v := range that.array {
-		buffer.WriteString(dconv.String(v))
-		if k != len(that.array)-1 {
-			buffer.WriteString(glue)
-		}
-	}
-	return buffer.String()
+	return that.generic().Join(glue)
 }
 
 // CountValues counts the number of occurrences of all values in the array.
 func (that *SortedIntArray) CountValues() map[int]int {
 	m := make(map[int]int)
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	for _, v := range that.array {
-		m[v]++
-	}
+	that.generic().RLockFunc(func(array []int) {
+		for _, v := range array {
+			m[v]++
+		}
+	})
 	return m
 }
 
@@ -615,25 +330,13 @@ func (that *SortedIntArray) Iterator(f func(k int, v int) bool) {
 // IteratorAsc iterates the array readonly in ascending order with given callback function <f>.
 // If <f> returns true, then it continues iterating; or false to stop.
 func (that *SortedIntArray) IteratorAsc(f func(k int, v int) bool) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	for k, v := range that.array {
-		if !f(k, v) {
-			break
-		}
-	}
+	that.generic().IteratorAsc(f)
 }
 
 // IteratorDesc iterates the array readonly in descending order with given callback function <f>.
 // If <f> returns true, then it continues iterating; or false to stop.
 func (that *SortedIntArray) IteratorDesc(f func(k int, v int) bool) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	for i := len(that.array) - 1; i >= 0; i-- {
-		if !f(i, that.array[i]) {
-			break
-		}
-	}
+	that.generic().IteratorDesc(f)
 }
 
 // String returns current array as a string, which implements like json.Marshal does.
@@ -644,79 +347,39 @@ func (that *SortedIntArray) String() string {
 // MarshalJSON implements the interface MarshalJSON for json.Marshal.
 // Note that do not use pointer as its receiver here.
 func (that SortedIntArray) MarshalJSON() ([]byte, error) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	return json.Marshal(that.array)
+	if that.array == nil {
+		return json.Marshal([]int(nil))
+	}
+	return that.array.MarshalJSON()
 }
 
 // UnmarshalJSON implements the interface UnmarshalJSON for json.Unmarshal.
 func (that *SortedIntArray) UnmarshalJSON(b []byte) error {
-	if that.comparator == nil {
-		that.array = make([]int, 0)
-		that.comparator = defaultComparatorInt
-	}
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if err := json.UnmarshalUseNumber(b, &that.array); err != nil {
-		return err
-	}
-	if that.array != nil {
-		sort.Ints(that.array)
-	}
-	return nil
+	return that.generic().UnmarshalJSON(b)
 }
 
 // UnmarshalValue is an interface implement which sets any type of value for array.
-func (that *SortedIntArray) UnmarshalValue(value interface{}) (err error) {
-	if that.comparator == nil {
-		that.comparator = defaultComparatorInt
-	}
-	that.mu.Lock()
-	defer that.mu.Unlock()
+func (that *SortedIntArray) UnmarshalValue(value interface{}) error {
 	switch value.(type) {
 	case string, []byte:
-		err = json.UnmarshalUseNumber(dconv.Bytes(value), &that.array)
+		return that.generic().UnmarshalJSON(dconv.Bytes(value))
 	default:
-		that.array = dconv.SliceInt(value)
-	}
-	if that.array != nil {
-		sort.Ints(that.array)
+		that.generic().SetArray(dconv.SliceInt(value))
 	}
-	return err
+	return nil
 }
 
 // FilterEmpty removes all zero value of the array.
 func (that *SortedIntArray) FilterEmpty() *SortedIntArray {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	for i := 0; i < len(that.array); {
-		if that.array[i] == 0 {
-			that.array = append(that.array[:i], that.array[i+1:]...)
-		} else {
-			break
-		}
-	}
-	for i := len(that.array) - 1; i >= 0; {
-		if that.array[i] == 0 {
-			that.array = append(that.array[:i], that.array[i+1:]...)
-		} else {
-			break
-		}
-	}
+	that.generic().Filter(func(value int) bool {
+		return value == 0
+	})
 	return that
 }
 
 // Walk applies a user supplied function <f> to every item of array.
 func (that *SortedIntArray) Walk(f func(value int) int) *SortedIntArray {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-
-	// Keep the array always sorted.
-	defer quickSortInt(that.array, that.getComparator())
-
-	for i, v := range that.array {
-		that.array[i] = f(v)
-	}
+	that.generic().Walk(f)
 	return that
 }
 
@@ -724,12 +387,3 @@ func (that *SortedIntArray) Walk(f func(value int) int) *SortedIntArray {
 func (that *SortedIntArray) IsEmpty() bool {
 	return that.Len() == 0
 }
-
-// getComparator returns the comparator if it's previously set,
-// or else it returns a default comparator.
-func (that *SortedIntArray) getComparator() func(a, b int) int {
-	if that.comparator == nil {
-		return defaultComparatorInt
-	}
-	return that.comparator
-}
@@ -8,13 +8,10 @@ package darray
 
 import (
 	"bytes"
-	"github.com/osgochina/donkeygo/internal/json"
-	"github.com/osgochina/donkeygo/internal/rwmutex"
+	"encoding/json"
+	"github.com/osgochina/donkeygo/container/dgeneric"
 	"github.com/osgochina/donkeygo/text/dstr"
 	"github.com/osgochina/donkeygo/util/dconv"
-	"github.com/osgochina/donkeygo/util/drand"
-	"math"
-	"sort"
 	"strings"
 )
 
@@ -23,11 +20,9 @@ import (
 // setting it a custom comparator.
 // It contains a concurrent-safe/unsafe switch, which should be set
 // when its initialization and cannot be changed then.
+// It is a thin wrapper of dgeneric.SortedArray[string].
 type SortedStrArray struct {
-	mu         rwmutex.RWMutex
-	array      []string
-	unique     bool                  // Whether enable unique feature(false)
-	comparator func(a, b string) int // Comparison function(it returns -1: a < b; 0: a == b; 1: a > b)
+	array *dgeneric.SortedArray[string]
 }
 
 // NewSortedStrArray creates and returns an empty sorted array.
@@ -40,9 +35,9 @@ func NewSortedStrArray(safe ...bool) *SortedStrArray {
 // NewSortedStrArrayComparator creates and returns an empty sorted array with specified comparator.
 // The parameter <safe> is used to specify whether using array in concurrent-safety which is false in default.
 func NewSortedStrArrayComparator(comparator func(a, b string) int, safe ...bool) *SortedStrArray {
-	array := NewSortedStrArray(safe...)
-	array.comparator = comparator
-	return array
+	return &SortedStrArray{
+		array: dgeneric.NewSortedArray(comparator, safe...),
+	}
 }
 
 // NewSortedStrArraySize create and returns an sorted array with given size and cap.
@@ -50,9 +45,7 @@ func NewSortedStrArrayComparator(comparator func(a, b string) int, safe ...bool)
 // which is false in default.
 func NewSortedStrArraySize(cap int, safe ...bool) *SortedStrArray {
 	return &SortedStrArray{
-		mu:         rwmutex.Create(safe...),
-		array:      make([]string, 0, cap),
-		comparator: defaultComparatorStr,
+		array: dgeneric.NewSortedArraySize(cap, defaultComparatorStr, safe...),
 	}
 }
 
@@ -60,27 +53,31 @@ func NewSortedStrArraySize(cap int, safe ...bool) *SortedStrArray {
 // The parameter <safe> is used to specify whether using array in concurrent-safety,
 // which is false in default.
 func NewSortedStrArrayFrom(array []string, safe ...bool) *SortedStrArray {
-	a := NewSortedStrArraySize(0, safe...)
-	a.array = array
-	quickSortStr(a.array, a.getComparator())
-	return a
+	return &SortedStrArray{
+		array: dgeneric.NewSortedArrayFrom(array, defaultComparatorStr, safe...),
+	}
 }
 
 // NewSortedStrArrayFromCopy creates and returns an sorted array from a copy of given slice <array>.
 // The parameter <safe> is used to specify whether using array in concurrent-safety,
 // which is false in default.
 func NewSortedStrArrayFromCopy(array []string, safe ...bool) *SortedStrArray {
-	newArray := make([]string, len(array))
-	copy(newArray, array)
-	return NewSortedStrArrayFrom(newArray, safe...)
+	return &SortedStrArray{
+		array: dgeneric.NewSortedArrayFromCopy(array, defaultComparatorStr, safe...),
+	}
+}
+
+// generic returns the underlying generic array, a zero value array is initialized on first use.
+func (that *SortedStrArray) generic() *dgeneric.SortedArray[string] {
+	if that.array == nil {
+		that.array = dgeneric.NewSortedArray(defaultComparatorStr)
+	}
+	return that.array
 }
 
 // SetArray sets the underlying slice array with the given <array>.
 func (that *SortedStrArray) SetArray(array []string) *SortedStrArray {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	that.array = array
-	quickSortStr(that.array, that.getComparator())
+	that.generic().SetArray(array)
 	return that
 }
 
@@ -88,9 +85,7 @@ func (that *SortedStrArray) SetArray(array []string) *SortedStrArray {
 // The parameter <reverse> controls whether sort
 // in increasing order(default) or decreasing order.
 func (that *SortedStrArray) Sort() *SortedStrArray {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	quickSortStr(that.array, that.getComparator())
+	that.generic().Sort()
 	return that
 }
 
@@ -102,173 +97,65 @@ func (that *SortedStrArray) Add(values ...string) *SortedStrArray {
 
 // Append adds one or multiple values to sorted array, the array always keeps sorted.
 func (that *SortedStrArray) Append(values ...string) *SortedStrArray {
-	if len(values) == 0 {
-		return that
-	}
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	for _, value := range values {
-		index, cmp := that.binSearch(value, false)
-		if that.unique && cmp == 0 {
-			continue
-		}
-		if index < 0 {
-			that.array = append(that.array, value)
-			continue
-		}
-		if cmp > 0 {
-			index++
-		}
-		rear := append([]string{}, that.array[index:]...)
-		that.array = append(that.array[0:index], value)
-		that.array = append(that.array, rear...)
-	}
+	that.generic().Append(values...)
 	return that
 }
 
 // Get returns the value by the specified index.
 // If the given <index> is out of range of the array, the <found> is false.
 func (that *SortedStrArray) Get(index int) (value string, found bool) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	if index < 0 || index >= len(that.array) {
-		return "", false
-	}
-	return that.array[index], true
+	return that.generic().Get(index)
 }
 
 // Remove removes an item by index.
 // If the given <index> is out of range of the array, the <found> is false.
 func (that *SortedStrArray) Remove(index int) (value string, found bool) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	return that.doRemoveWithoutLock(index)
-}
-
-// doRemoveWithoutLock removes an item by index without lock.
-func (that *SortedStrArray) doRemoveWithoutLock(index int) (value string, found bool) {
-	if index < 0 || index >= len(that.array) {
-		return "", false
-	}
-	// Determine array boundaries when deleting to improve deletion efficiency.
-	if index == 0 {
-		value := that.array[0]
-		that.array = that.array[1:]
-		return value, true
-	} else if index == len(that.array)-1 {
-		value := that.array[index]
-		that.array = that.array[:index]
-		return value, true
-	}
-	// If it is a non-boundary delete,
-	// it will involve the creation of an array,
-	// then the deletion is less efficient.
-	value = that.array[index]
-	that.array = append(that.array[:index], that.array[index+1:]...)
-	return value, true
+	return that.generic().Remove(index)
 }
 
 // RemoveValue removes an item by value.
 // It returns true if value is found in the array, or else false if not found.
 func (that *SortedStrArray) RemoveValue(value string) bool {
-	if i := that.Search(value); i != -1 {
-		that.Remove(i)
-		return true
-	}
-	return false
+	return that.generic().RemoveValue(value)
 }
 
 // PopLeft pops and returns an item from the beginning of array.
 // Note that if the array is empty, the <found> is false.
 func (that *SortedStrArray) PopLeft() (value string, found bool) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if len(that.array) == 0 {
-		return "", false
-	}
-	value = that.array[0]
-	that.array = that.array[1:]
-	return value, true
+	return that.generic().PopLeft()
 }
 
 // PopRight pops and returns an item from the end of array.
 // Note that if the array is empty, the <found> is false.
 func (that *SortedStrArray) PopRight() (value string, found bool) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	index := len(that.array) - 1
-	if index < 0 {
-		return "", false
-	}
-	value = that.array[index]
-	that.array = that.array[:index]
-	return value, true
+	return that.generic().PopRight()
 }
 
 // PopRand randomly pops and return an item out of array.
 // Note that if the array is empty, the <found> is false.
 func (that *SortedStrArray) PopRand() (value string, found bool) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	return that.doRemoveWithoutLock(drand.Intn(len(that.array)))
+	return that.generic().PopRand()
 }
 
 // PopRands randomly pops and returns <size> items out of array.
 // If the given <size> is greater than size of the array, it returns all elements of the array.
 // Note that if given <size> <= 0 or the array is empty, it returns nil.
 func (that *SortedStrArray) PopRands(size int) []string {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if size <= 0 || len(that.array) == 0 {
-		return nil
-	}
-	if size >= len(that.array) {
-		size = len(that.array)
-	}
-	array := make([]string, size)
-	for i := 0; i < size; i++ {
-		array[i], _ = that.doRemoveWithoutLock(drand.Intn(len(that.array)))
-	}
-	return array
+	return that.generic().PopRands(size)
 }
 
 // PopLefts pops and returns <size> items from the beginning of array.
 // If the given <size> is greater than size of the array, it returns all elements of the array.
 // Note that if given <size> <= 0 or the array is empty, it returns nil.
 func (that *SortedStrArray) PopLefts(size int) []string {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if size <= 0 || len(that.array) == 0 {
-		return nil
-	}
-	if size >= len(that.array) {
-		array := that.array
-		that.array = that.array[:0]
-		return array
-	}
-	value := that.array[0:size]
-	that.array = that.array[size:]
-	return value
+	return that.generic().PopLefts(size)
 }
 
 // PopRights pops and returns <size> items from the end of array.
 // If the given <size> is greater than size of the array, it returns all elements of the array.
 // Note that if given <size> <= 0 or the array is empty, it returns nil.
 func (that *SortedStrArray) PopRights(size int) []string {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if size <= 0 || len(that.array) == 0 {
-		return nil
-	}
-	index := len(that.array) - size
-	if index <= 0 {
-		array := that.array
-		that.array = that.array[:0]
-		return array
-	}
-	value := that.array[index:]
-	that.array = that.array[:index]
-	return value
+	return that.generic().PopRights(size)
 }
 
 // Range picks and returns items by range, like array[start:end].
@@ -279,26 +166,7 @@ func (that *SortedStrArray) PopRights(size int) []string {
 // If <end> is omitted, then the sequence will have everything from start up
 // until the end of the array.
 func (that *SortedStrArray) Range(start int, end ...int) []string {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	offsetEnd := len(that.array)
-	if len(end) > 0 && end[0] < offsetEnd {
-		offsetEnd = end[0]
-	}
-	if start > offsetEnd {
-		return nil
-	}
-	if start < 0 {
-		start = 0
-	}
-	array := ([]string)(nil)
-	if that.mu.IsSafe() {
-		array = make([]string, offsetEnd-start)
-		copy(array, that.array[start:offsetEnd])
-	} else {
-		array = that.array[start:offsetEnd]
-	}
-	return array
+	return that.generic().Range(start, end...)
 }
 
 // SubSlice returns a slice of elements from the array as specified
@@ -315,84 +183,40 @@ func (that *SortedStrArray) Range(start int, end ...int) []string {
 //
 // Any possibility crossing the left border of array, it will fail.
 func (that *SortedStrArray) SubSlice(offset int, length ...int) []string {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	size := len(that.array)
-	if len(length) > 0 {
-		size = length[0]
-	}
-	if offset > len(that.array) {
-		return nil
-	}
-	if offset < 0 {
-		offset = len(that.array) + offset
-		if offset < 0 {
-			return nil
-		}
-	}
-	if size < 0 {
-		offset += size
-		size = -size
-		if offset < 0 {
-			return nil
-		}
-	}
-	end := offset + size
-	if end > len(that.array) {
-		end = len(that.array)
-		size = len(that.array) - offset
-	}
-	if that.mu.IsSafe() {
-		s := make([]string, size)
-		copy(s, that.array[offset:])
-		return s
-	} else {
-		return that.array[offset:end]
-	}
+	return that.generic().SubSlice(offset, length...)
 }
 
 // Sum returns the sum of values in an array.
 func (that *SortedStrArray) Sum() (sum int) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	for _, v := range that.array {
-		sum += dconv.Int(v)
-	}
+	that.generic().RLockFunc(func(array []string) {
+		for _, v := range array {
+			sum += dconv.Int(v)
+		}
+	})
 	return
 }
 
 // Len returns the length of array.
 func (that *SortedStrArray) Len() int {
-	that.mu.RLock()
-	length := len(that.array)
-	that.mu.RUnlock()
-	return length
+	return that.generic().Len()
 }
 
 // Slice returns the underlying data of array.
 // Note that, if it's in concurrent-safe usage, it returns a copy of underlying data,
 // or else a pointer to the underlying datthat.
 func (that *SortedStrArray) Slice() []string {
-	array := ([]string)(nil)
-	if that.mu.IsSafe() {
-		that.mu.RLock()
-		defer that.mu.RUnlock()
-		array = make([]string, len(that.array))
-		copy(array, that.array)
-	} else {
-		array = that.array
-	}
-	return array
+	return that.generic().Slice()
 }
 
 // Interfaces returns current array as []interface{}.
 func (that *SortedStrArray) Interfaces() []interface{} {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	array := make([]interface{}, len(that.array))
-	for k, v := range that.array {
-		array[k] = v
-	}
+	var array []interface{}
+	that.generic().RLockFunc(func(values []string) {
+		array = make([]interface{}, len(values))
+		for k, v := range values {
+			array[k] = v
+		}
+	})
 	return array
 }
 
@@ -404,125 +228,58 @@ func (that *SortedStrArray) Contains(value string) bool {
 // ContainsI checks whether a value exists in the array with case-insensitively.
 // Note that it internally iterates the whole array to do the comparison with case-insensitively.
 func (that *SortedStrArray) ContainsI(value string) bool {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	if len(that.array) == 0 {
-		return false
-	}
-	for _, v := range that.array {
-		if strings.EqualFold(v, value) {
-			return true
+	found := false
+	that.generic().RLockFunc(func(array []string) {
+		for _, v := range array {
+			if strings.EqualFold(v, value) {
+				found = true
+				return
+			}
 		}
-	}
-	return false
+	})
+	return found
 }
 
 // Search searches array by <value>, returns the index of <value>,
 // or returns -1 if not exists.
 func (that *SortedStrArray) Search(value string) (index int) {
-	if i, r := that.binSearch(value, true); r == 0 {
-		return i
-	}
-	return -1
-}
-
-// Binary search.
-// It returns the last compared index and the result.
-// If <result> equals to 0, it means the value at <index> is equals to <value>.
-// If <result> lesser than 0, it means the value at <index> is lesser than <value>.
-// If <result> greater than 0, it means the value at <index> is greater than <value>.
-func (that *SortedStrArray) binSearch(value string, lock bool) (index int, result int) {
-	if lock {
-		that.mu.RLock()
-		defer that.mu.RUnlock()
-	}
-	if len(that.array) == 0 {
-		return -1, -2
-	}
-	min := 0
-	max := len(that.array) - 1
-	mid := 0
-	cmp := -2
-	for min <= max {
-		mid = min + int((max-min)/2)
-		cmp = that.getComparator()(value, that.array[mid])
-		switch {
-		case cmp < 0:
-			max = mid - 1
-		case cmp > 0:
-			min = mid + 1
-		default:
-			return mid, cmp
-		}
-	}
-	return mid, cmp
+	return that.generic().Search(value)
 }
 
 // SetUnique sets unique mark to the array,
 // which means it does not contain any repeated items.
 // It also do unique check, remove all repeated items.
 func (that *SortedStrArray) SetUnique(unique bool) *SortedStrArray {
-	oldUnique := that.unique
-	that.unique = unique
-	if unique && oldUnique != unique {
-		that.Unique()
-	}
+	that.generic().SetUnique(unique)
 	return that
 }
 
 // Unique uniques the array, clear repeated items.
 func (that *SortedStrArray) Unique() *SortedStrArray {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if len(that.array) == 0 {
-		return that
-	}
-	i := 0
-	for {
-		if i == len(that.array)-1 {
-			break
-		}
-		if that.getComparator()(that.array[i], that.array[i+1]) == 0 {
-			that.array = append(that.array[:i+1], that.array[i+1+1:]...)
-		} else {
-			i++
-		}
-	}
+	that.generic().Unique()
 	return that
 }
 
 // Clone returns a new array, which is a copy of current array.
 func (that *SortedStrArray) Clone() (newArray *SortedStrArray) {
-	that.mu.RLock()
-	array := make([]string, len(that.array))
-	copy(array, that.array)
-	that.mu.RUnlock()
-	return NewSortedStrArrayFrom(array, that.mu.IsSafe())
+	return &SortedStrArray{array: that.generic().Clone()}
 }
 
 // Clear deletes all items of current array.
 func (that *SortedStrArray) Clear() *SortedStrArray {
-	that.mu.Lock()
-	if len(that.array) > 0 {
-		that.array = make([]string, 0)
-	}
-	that.mu.Unlock()
+	that.generic().Clear()
 	return that
 }
 
 // LockFunc locks writing by callback function <f>.
 func (that *SortedStrArray) LockFunc(f func(array []string)) *SortedStrArray {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	f(that.array)
+	that.generic().LockFunc(f)
 	return that
 }
 
 // RLockFunc locks reading by callback function <f>.
 func (that *SortedStrArray) RLockFunc(f func(array []string)) *SortedStrArray {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	f(that.array)
+	that.generic().RLockFunc(f)
 	return that
 }
 
@@ -538,74 +295,32 @@ func (that *SortedStrArray) Merge(array interface{}) *SortedStrArray {
 // the size of each array is determined by <size>.
 // The last chunk may contain less than size elements.
 func (that *SortedStrArray) Chunk(size int) [][]string {
-	if size < 1 {
-		return nil
-	}
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	length := len(that.array)
-	chunks := int(math.Ceil(float64(length) / float64(size)))
-	var n [][]string
-	for i, end := 0, 0; chunks > 0; chunks-- {
-		end = (i + 1) * size
-		if end > length {
-			end = length
-		}
-		n = append(n, that.array[i*size:end])
-		i++
-	}
-	return n
+	return that.generic().Chunk(size)
 }
 
 // Rand randomly returns one item from array(no deleting).
 func (that *SortedStrArray) Rand() (value string, found bool) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	if len(that.array) == 0 {
-		return "", false
-	}
-	return that.array[drand.Intn(len(that.array))], true
+	return that.generic().Rand()
 }
 
 // Rands randomly returns <size> items from array(no deleting).
 func (that *SortedStrArray) Rands(size int) []string {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	if size <= 0 || len(that.array) == 0 {
-		return nil
-	}
-	array := make([]string, size)
-	for i := 0; i < size; i++ {
-		array[i] = that.array[drand.Intn(len(that.array))]
-	}
-	return array
+	return that.generic().Rands(size)
 }
 
 // Join joins array elements with a string <glue>.
 func (that *SortedStrArray) Join(glue string) string {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	if len(that.array) == 0 {
-		return ""
-	}
-	buffer := bytes.NewBuffer(nil)
-	for k, v := range that.array {
-		buffer.WriteString(v)
-		if k != len(that.array)-1 {
-			buffer.WriteString(glue)
-		}
-	}
-	return buffer.String()
+	return that.generic().Join(glue)
 }
 
 // CountValues counts the number of occurrences of all values in the array.
 func (that *SortedStrArray) CountValues() map[string]int {
 	m := make(map[string]int)
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	for _, v := range that.array {
-		m[v]++
-	}
+	that.generic().RLockFunc(func(array []string) {
+		for _, v := range array {
+			m[v]++
+		}
+	})
 	return m
 }
 
@@ -617,39 +332,27 @@ func (that *SortedStrArray) Iterator(f func(k int, v string) bool) {
 // IteratorAsc iterates the array readonly in ascending order with given callback function <f>.
 // If <f> returns true, then it continues iterating; or false to stop.
 func (that *SortedStrArray) IteratorAsc(f func(k int, v string) bool) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	for k, v := range that.array {
-		if !f(k, v) {
-			break
-		}
-	}
+	that.generic().IteratorAsc(f)
 }
 
 // IteratorDesc iterates the array readonly in descending order with given callback function <f>.
 // If <f> returns true, then it continues iterating; or false to stop.
 func (that *SortedStrArray) IteratorDesc(f func(k int, v string) bool) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	for i := len(that.array) - 1; i >= 0; i-- {
-		if !f(i, that.array[i]) {
-			break
-		}
-	}
+	that.generic().IteratorDesc(f)
 }
 
 // String returns current array as a string, which implements like json.Marshal does.
 func (that *SortedStrArray) String() string {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
 	buffer := bytes.NewBuffer(nil)
 	buffer.WriteByte('[')
-	for k, v := range that.array {
-		buffer.WriteString(`"` + dstr.QuoteMeta(v, `"\`) + `"`)
-		if k != len(that.array)-1 {
-			buffer.WriteByte(',')
+	that.generic().RLockFunc(func(array []string) {
+		for k, v := range array {
+			buffer.WriteString(`"` + dstr.QuoteMeta(v, `"\`) + `"`)
+			if k != len(array)-1 {
+				buffer.WriteByte(',')
+			}
 		}
-	}
+	})
 	buffer.WriteByte(']')
 	return buffer.String()
 }
@@ -657,79 +360,39 @@ func (that *SortedStrArray) String() string {
 // MarshalJSON implements the interface MarshalJSON for json.Marshal.
 // Note that do not use pointer as its receiver here.
 func (that SortedStrArray) MarshalJSON() ([]byte, error) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	return json.Marshal(that.array)
+	if that.array == nil {
+		return json.Marshal([]string(nil))
+	}
+	return that.array.MarshalJSON()
 }
 
 // UnmarshalJSON implements the interface UnmarshalJSON for json.Unmarshal.
 func (that *SortedStrArray) UnmarshalJSON(b []byte) error {
-	if that.comparator == nil {
-		that.array = make([]string, 0)
-		that.comparator = defaultComparatorStr
-	}
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if err := json.UnmarshalUseNumber(b, &that.array); err != nil {
-		return err
-	}
-	if that.array != nil {
-		sort.Strings(that.array)
-	}
-	return nil
+	return that.generic().UnmarshalJSON(b)
 }
 
 // UnmarshalValue is an interface implement which sets any type of value for array.
-func (that *SortedStrArray) UnmarshalValue(value interface{}) (err error) {
-	if that.comparator == nil {
-		that.comparator = defaultComparatorStr
-	}
-	that.mu.Lock()
-	defer that.mu.Unlock()
+func (that *SortedStrArray) UnmarshalValue(value interface{}) error {
 	switch value.(type) {
 	case string, []byte:
-		err = json.UnmarshalUseNumber(dconv.Bytes(value), &that.array)
+		return that.generic().UnmarshalJSON(dconv.Bytes(value))
 	default:
-		that.array = dconv.SliceStr(value)
+		that.generic().SetArray(dconv.SliceStr(value))
 	}
-	if that.array != nil {
-		sort.Strings(that.array)
-	}
-	return err
+	return nil
 }
 
 // FilterEmpty removes all empty string value of the array.
 func (that *SortedStrArray) FilterEmpty() *SortedStrArray {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	for i := 0; i < len(that.array); {
-		if that.array[i] == "" {
-			that.array = append(that.array[:i], that.array[i+1:]...)
-		} else {
-			break
-		}
-	}
-	for i := len(that.array) - 1; i >= 0; {
-		if that.array[i] == "" {
-			that.array = append(that.array[:i], that.array[i+1:]...)
-		} else {
-			break
-		}
-	}
+	that.generic().Filter(func(value string) bool {
+		return value == ""
+	})
 	return that
 }
 
 // Walk applies a user supplied function <f> to every item of array.
 func (that *SortedStrArray) Walk(f func(value string) string) *SortedStrArray {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-
-	// Keep the array always sorted.
-	defer quickSortStr(that.array, that.getComparator())
-
-	for i, v := range that.array {
-		that.array[i] = f(v)
-	}
+	that.generic().Walk(f)
 	return that
 }
 
@@ -737,12 +400,3 @@ func (that *SortedStrArray) Walk(f func(value string) string) *SortedStrArray {
 func (that *SortedStrArray) IsEmpty() bool {
 	return that.Len() == 0
 }
-
-// getComparator returns the comparator if it's previously set,
-// or else it returns a default comparator.
-func (that *SortedStrArray) getComparator() func(a, b string) int {
-	if that.comparator == nil {
-		return defaultComparatorStr
-	}
-	return that.comparator
-}
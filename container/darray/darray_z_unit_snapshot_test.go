@@ -0,0 +1,42 @@
+package darray_test
+
+import (
+	"bytes"
+	"github.com/osgochina/donkeygo/container/darray"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"github.com/osgochina/donkeygo/util/dutil"
+	"testing"
+)
+
+func Test_Array_Snapshot(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var buf bytes.Buffer
+		a1 := darray.NewArrayFrom([]interface{}{3, "a", 1}, true)
+		t.AssertNil(a1.Snapshot(&buf))
+		a2 := darray.NewArray()
+		t.AssertNil(a2.Restore(&buf))
+		t.Assert(a2.Slice(), []interface{}{3, "a", 1})
+
+		buf.Reset()
+		a3 := darray.NewIntArrayFrom([]int{3, 1, 2}, true)
+		t.AssertNil(a3.Snapshot(&buf))
+		a4 := darray.NewSortedIntArray()
+		t.AssertNil(a4.Restore(bytes.NewReader(buf.Bytes())))
+		t.Assert(a4.Slice(), []int{1, 2, 3})
+		a5 := darray.NewStrArray()
+		t.AssertNil(a5.Restore(bytes.NewReader(buf.Bytes())))
+		t.Assert(a5.Slice(), []string{"3", "1", "2"})
+		a6 := darray.NewSortedArray(dutil.ComparatorInt)
+		t.AssertNil(a6.Restore(bytes.NewReader(buf.Bytes())))
+		t.Assert(a6.Slice(), []interface{}{1, 2, 3})
+		t.AssertNE(a6.Restore(bytes.NewReader(buf.Bytes()[:8])), nil)
+		t.Assert(a6.Len(), 3)
+
+		buf.Reset()
+		a7 := darray.NewSortedStrArrayFrom([]string{"b", "c", "a"})
+		t.AssertNil(a7.Snapshot(&buf))
+		a8 := darray.NewSortedStrArray()
+		t.AssertNil(a8.Restore(&buf))
+		t.Assert(a8.Slice(), []string{"a", "b", "c"})
+	})
+}
@@ -0,0 +1,144 @@
+// Package dgeneric 提供基于泛型的类型安全的容器，包括数组、有序数组、hash表、链表字典、树形字典和集合，
+// 方法与 darray、dmap、dset 中对应的容器保持一致，通过 safe 参数指定是否并发安全，默认不是并发安全的。
+//
+// darray 的 IntArray、StrArray、SortedIntArray、SortedStrArray，dmap 的 IntIntMap、IntStrMap、IntAnyMap、
+// StrIntMap、StrStrMap、StrAnyMap，以及 dset 的 IntSet、StrSet 都是对本包容器的简单封装。
+// 元素类型为 interface{} 的 darray.Array、dmap.AnyAnyMap、dset.Set 等容器仍然保持原来的实现，
+// 因为 go.mod 声明的语言版本是 go1.18，interface{} 不满足 comparable 约束，无法作为 Array、HashMap、Set 的类型参数。
+package dgeneric
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/osgochina/donkeygo/text/dstr"
+	"github.com/osgochina/donkeygo/util/dconv"
+)
+
+// Ordered 支持 < <= >= > 运算符的类型
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 |
+		~string
+}
+
+// Compare 比较两个可排序的值，a小于b返回-1，相等返回0，大于返回1，可以作为有序容器的比较方法
+func Compare[T Ordered](a, b T) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// 把元素列表转换成json数组格式的字符串，数字不加引号
+func sliceString[T any](values []T) string {
+	buffer := bytes.NewBuffer(nil)
+	buffer.WriteByte('[')
+	s := ""
+	for k, v := range values {
+		s = dconv.String(v)
+		if dstr.IsNumeric(s) {
+			buffer.WriteString(s)
+		} else {
+			buffer.WriteString(`"` + dstr.QuoteMeta(s, `"\`) + `"`)
+		}
+		if k != len(values)-1 {
+			buffer.WriteByte(',')
+		}
+	}
+	buffer.WriteByte(']')
+	return buffer.String()
+}
+
+// 把元素列表以glue分隔符链接起来
+func sliceJoin[T any](values []T, glue string) string {
+	buffer := bytes.NewBuffer(nil)
+	for k, v := range values {
+		buffer.WriteString(dconv.String(v))
+		if k != len(values)-1 {
+			buffer.WriteString(glue)
+		}
+	}
+	return buffer.String()
+}
+
+// 把切片按照size分片，返回二维切片
+func sliceChunk[T any](values []T, size int) [][]T {
+	if size < 1 {
+		return nil
+	}
+	var n [][]T
+	for start := 0; start < len(values); start += size {
+		end := start + size
+		if end > len(values) {
+			end = len(values)
+		}
+		n = append(n, values[start:end])
+	}
+	return n
+}
+
+// 截取切片中的某一段，safe为true时返回复制的切片
+func subSlice[T any](values []T, safe bool, offset int, length ...int) []T {
+	size := len(values)
+	if len(length) > 0 {
+		size = length[0]
+	}
+	if offset > len(values) {
+		return nil
+	}
+	if offset < 0 {
+		offset = len(values) + offset
+		if offset < 0 {
+			return nil
+		}
+	}
+	if size < 0 {
+		offset += size
+		size = -size
+		if offset < 0 {
+			return nil
+		}
+	}
+	end := offset + size
+	if end > len(values) {
+		end = len(values)
+	}
+	if safe {
+		s := make([]T, end-offset)
+		copy(s, values[offset:end])
+		return s
+	}
+	return values[offset:end]
+}
+
+// 按照iterate迭代的顺序把键值对序列化成json对象，key会被转换成字符串
+func marshalOrderedJSON[K any, V any](iterate func(f func(key K, value V) bool)) ([]byte, error) {
+	var err error
+	buffer := bytes.NewBuffer(nil)
+	buffer.WriteByte('{')
+	iterate(func(key K, value V) bool {
+		var kb, vb []byte
+		if kb, err = json.Marshal(dconv.String(key)); err != nil {
+			return false
+		}
+		if vb, err = json.Marshal(value); err != nil {
+			return false
+		}
+		if buffer.Len() > 1 {
+			buffer.WriteByte(',')
+		}
+		buffer.Write(kb)
+		buffer.WriteByte(':')
+		buffer.Write(vb)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
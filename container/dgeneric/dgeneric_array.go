@@ -0,0 +1,585 @@
+package dgeneric
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/osgochina/donkeygo/internal/rwmutex"
+	"github.com/osgochina/donkeygo/util/drand"
+	"sort"
+)
+
+// Array 泛型数组，元素类型为T
+type Array[T comparable] struct {
+	mu    rwmutex.RWMutex
+	array []T
+}
+
+// NewArray 创建数组，safe参数表示是否并发安全
+func NewArray[T comparable](safe ...bool) *Array[T] {
+	return NewArraySize[T](0, 0, safe...)
+}
+
+// NewArraySize 创建指定长度和容量的数组
+func NewArraySize[T comparable](size int, cap int, safe ...bool) *Array[T] {
+	return &Array[T]{
+		mu:    rwmutex.Create(safe...),
+		array: make([]T, size, cap),
+	}
+}
+
+// NewArrayFrom 使用切片创建数组，不会复制切片
+func NewArrayFrom[T comparable](array []T, safe ...bool) *Array[T] {
+	return &Array[T]{
+		mu:    rwmutex.Create(safe...),
+		array: array,
+	}
+}
+
+// NewArrayFromCopy 复制一份切片后创建数组
+func NewArrayFromCopy[T comparable](array []T, safe ...bool) *Array[T] {
+	newArray := make([]T, len(array))
+	copy(newArray, array)
+	return NewArrayFrom(newArray, safe...)
+}
+
+// At 获取指定index的值，index越界时返回零值
+func (that *Array[T]) At(index int) (value T) {
+	value, _ = that.Get(index)
+	return
+}
+
+// Get 获取指定index的值
+func (that *Array[T]) Get(index int) (value T, found bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	if index < 0 || index >= len(that.array) {
+		return value, false
+	}
+	return that.array[index], true
+}
+
+// Set 设置指定index对应的值
+func (that *Array[T]) Set(index int, value T) error {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if index < 0 || index >= len(that.array) {
+		return errors.New(fmt.Sprintf("index %d out of array range %d", index, len(that.array)))
+	}
+	that.array[index] = value
+	return nil
+}
+
+// SetArray 直接把切片赋值给数组
+func (that *Array[T]) SetArray(array []T) *Array[T] {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	that.array = array
+	return that
+}
+
+// Replace 把数组中的切片值替换成传入的切片
+func (that *Array[T]) Replace(array []T) *Array[T] {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	max := len(array)
+	if max > len(that.array) {
+		max = len(that.array)
+	}
+	for i := 0; i < max; i++ {
+		that.array[i] = array[i]
+	}
+	return that
+}
+
+// SortFunc 使用自定义的less方法排序
+func (that *Array[T]) SortFunc(less func(v1, v2 T) bool) *Array[T] {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	sort.Slice(that.array, func(i, j int) bool {
+		return less(that.array[i], that.array[j])
+	})
+	return that
+}
+
+// InsertBefore 在指定的index之前插入值
+func (that *Array[T]) InsertBefore(index int, value T) error {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if index < 0 || index >= len(that.array) {
+		return errors.New(fmt.Sprintf("index %d out of array range %d", index, len(that.array)))
+	}
+	rear := append([]T{}, that.array[index:]...)
+	that.array = append(that.array[0:index], value)
+	that.array = append(that.array, rear...)
+	return nil
+}
+
+// InsertAfter 在指定的index之后插入值
+func (that *Array[T]) InsertAfter(index int, value T) error {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if index < 0 || index >= len(that.array) {
+		return errors.New(fmt.Sprintf("index %d out of array range %d", index, len(that.array)))
+	}
+	rear := append([]T{}, that.array[index+1:]...)
+	that.array = append(that.array[0:index+1], value)
+	that.array = append(that.array, rear...)
+	return nil
+}
+
+// Remove 移除指定的index对应的值
+func (that *Array[T]) Remove(index int) (value T, found bool) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	return that.doRemoveWithoutLock(index)
+}
+
+// 从数组中移除指定index的值，并返回它
+func (that *Array[T]) doRemoveWithoutLock(index int) (value T, found bool) {
+	if index < 0 || index >= len(that.array) {
+		return value, false
+	}
+	value = that.array[index]
+	if index == 0 {
+		that.array = that.array[1:]
+	} else if index == len(that.array)-1 {
+		that.array = that.array[:index]
+	} else {
+		that.array = append(that.array[:index], that.array[index+1:]...)
+	}
+	return value, true
+}
+
+// RemoveValue 查找指定的值，并从数组中移除它
+func (that *Array[T]) RemoveValue(value T) bool {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if i := that.doSearchWithoutLock(value); i != -1 {
+		that.doRemoveWithoutLock(i)
+		return true
+	}
+	return false
+}
+
+// PushLeft 把值插入到数组的左边
+func (that *Array[T]) PushLeft(value ...T) *Array[T] {
+	that.mu.Lock()
+	that.array = append(value, that.array...)
+	that.mu.Unlock()
+	return that
+}
+
+// PushRight 把值插入到数组的右边
+func (that *Array[T]) PushRight(value ...T) *Array[T] {
+	that.mu.Lock()
+	that.array = append(that.array, value...)
+	that.mu.Unlock()
+	return that
+}
+
+// Append see PushRight
+func (that *Array[T]) Append(value ...T) *Array[T] {
+	return that.PushRight(value...)
+}
+
+// PopRand 随机从数组中拿出一个值
+func (that *Array[T]) PopRand() (value T, found bool) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if len(that.array) == 0 {
+		return value, false
+	}
+	return that.doRemoveWithoutLock(drand.Intn(len(that.array)))
+}
+
+// PopRands 随机从数组中拿出指定数量的值
+func (that *Array[T]) PopRands(size int) []T {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if size <= 0 || len(that.array) == 0 {
+		return nil
+	}
+	if size >= len(that.array) {
+		size = len(that.array)
+	}
+	array := make([]T, size)
+	for i := 0; i < size; i++ {
+		array[i], _ = that.doRemoveWithoutLock(drand.Intn(len(that.array)))
+	}
+	return array
+}
+
+// PopLeft 从左边拿出一个值
+func (that *Array[T]) PopLeft() (value T, found bool) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	return that.doRemoveWithoutLock(0)
+}
+
+// PopRight 从右边拿出一个值
+func (that *Array[T]) PopRight() (value T, found bool) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	return that.doRemoveWithoutLock(len(that.array) - 1)
+}
+
+// PopLefts 从左边拿出指定数量的值
+func (that *Array[T]) PopLefts(size int) []T {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if size <= 0 || len(that.array) == 0 {
+		return nil
+	}
+	if size >= len(that.array) {
+		array := that.array
+		that.array = that.array[:0:0]
+		return array
+	}
+	value := that.array[0:size:size]
+	that.array = that.array[size:]
+	return value
+}
+
+// PopRights 从右边拿出指定数量的值
+func (that *Array[T]) PopRights(size int) []T {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if size <= 0 || len(that.array) == 0 {
+		return nil
+	}
+	index := len(that.array) - size
+	if index <= 0 {
+		array := that.array
+		that.array = that.array[:0:0]
+		return array
+	}
+	value := that.array[index:]
+	that.array = that.array[:index:index]
+	return value
+}
+
+// Range 获取从start到end的index的值，并发安全时返回复制的切片
+func (that *Array[T]) Range(start int, end ...int) []T {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	offsetEnd := len(that.array)
+	if len(end) > 0 && end[0] < offsetEnd {
+		offsetEnd = end[0]
+	}
+	if start < 0 {
+		start = 0
+	}
+	if start > offsetEnd {
+		return nil
+	}
+	if that.mu.IsSafe() {
+		array := make([]T, offsetEnd-start)
+		copy(array, that.array[start:offsetEnd])
+		return array
+	}
+	return that.array[start:offsetEnd]
+}
+
+// SubSlice 截取数组中的某一段数组，并返回
+// 支持offset，length负数
+func (that *Array[T]) SubSlice(offset int, length ...int) []T {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return subSlice(that.array, that.mu.IsSafe(), offset, length...)
+}
+
+// Len 数组的长度
+func (that *Array[T]) Len() int {
+	that.mu.RLock()
+	length := len(that.array)
+	that.mu.RUnlock()
+	return length
+}
+
+// IsEmpty 判断数组是否为空
+func (that *Array[T]) IsEmpty() bool {
+	return that.Len() == 0
+}
+
+// Slice 把数组转换成切片返回，并发安全时返回复制的切片
+func (that *Array[T]) Slice() []T {
+	if that.mu.IsSafe() {
+		that.mu.RLock()
+		defer that.mu.RUnlock()
+		array := make([]T, len(that.array))
+		copy(array, that.array)
+		return array
+	}
+	return that.array
+}
+
+// Clone 复制数组
+func (that *Array[T]) Clone() *Array[T] {
+	that.mu.RLock()
+	array := make([]T, len(that.array))
+	copy(array, that.array)
+	that.mu.RUnlock()
+	return NewArrayFrom(array, that.mu.IsSafe())
+}
+
+// Clear 清空数组数据
+func (that *Array[T]) Clear() *Array[T] {
+	that.mu.Lock()
+	if len(that.array) > 0 {
+		that.array = make([]T, 0)
+	}
+	that.mu.Unlock()
+	return that
+}
+
+// Contains 判断值在数组是否存在
+func (that *Array[T]) Contains(value T) bool {
+	return that.Search(value) != -1
+}
+
+// Search 查找指定的值，不存在返回-1，存在返回对应的index
+func (that *Array[T]) Search(value T) int {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return that.doSearchWithoutLock(value)
+}
+
+func (that *Array[T]) doSearchWithoutLock(value T) int {
+	for index, v := range that.array {
+		if v == value {
+			return index
+		}
+	}
+	return -1
+}
+
+// Unique 去除数组中相同的值，保留第一次出现的值
+func (that *Array[T]) Unique() *Array[T] {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	seen := make(map[T]struct{}, len(that.array))
+	array := that.array[:0]
+	for _, v := range that.array {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		array = append(array, v)
+	}
+	that.array = array
+	return that
+}
+
+// LockFunc 加锁执行指定方法，把数组值作为参数传入
+func (that *Array[T]) LockFunc(f func(array []T)) *Array[T] {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	f(that.array)
+	return that
+}
+
+// RLockFunc 加读锁执行方法，把数组作为参数传入
+func (that *Array[T]) RLockFunc(f func(array []T)) *Array[T] {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	f(that.array)
+	return that
+}
+
+// Merge 把other数组的元素追加到数组中
+func (that *Array[T]) Merge(other *Array[T]) *Array[T] {
+	if other == that {
+		return that.Append(that.Slice()...)
+	}
+	return that.Append(other.Slice()...)
+}
+
+// Fill 使用指定的value填充数组，从startIndex开始，填充num个索引
+func (that *Array[T]) Fill(startIndex int, num int, value T) error {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if startIndex < 0 || startIndex > len(that.array) {
+		return errors.New(fmt.Sprintf("index %d out of array range %d", startIndex, len(that.array)))
+	}
+	for i := startIndex; i < startIndex+num; i++ {
+		if i > len(that.array)-1 {
+			that.array = append(that.array, value)
+		} else {
+			that.array[i] = value
+		}
+	}
+	return nil
+}
+
+// Chunk 分片，使用size个数目分片成多个数组，返回二维数组
+func (that *Array[T]) Chunk(size int) [][]T {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return sliceChunk(that.array, size)
+}
+
+// Pad 将数组用指定的值 val 填充到指定的长度
+// 如果size是正的，则数组在右边填充，如果size是负数，则在左边填充。
+// 如果size的绝对值小于或等于数组的长度那么不填充。
+func (that *Array[T]) Pad(size int, val T) *Array[T] {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if size == 0 || (size > 0 && size < len(that.array)) || (size < 0 && size > -len(that.array)) {
+		return that
+	}
+	n := size
+	if size < 0 {
+		n = -size
+	}
+	n -= len(that.array)
+	tmp := make([]T, n)
+	for i := 0; i < n; i++ {
+		tmp[i] = val
+	}
+	if size > 0 {
+		that.array = append(that.array, tmp...)
+	} else {
+		that.array = append(tmp, that.array...)
+	}
+	return that
+}
+
+// Rand 随机返回一个数组元素
+func (that *Array[T]) Rand() (value T, found bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	if len(that.array) == 0 {
+		return value, false
+	}
+	return that.array[drand.Intn(len(that.array))], true
+}
+
+// Rands 随机返回指定 size 的数组元素，元素可能重复
+func (that *Array[T]) Rands(size int) []T {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	if size <= 0 || len(that.array) == 0 {
+		return nil
+	}
+	array := make([]T, size)
+	for i := 0; i < size; i++ {
+		array[i] = that.array[drand.Intn(len(that.array))]
+	}
+	return array
+}
+
+// Shuffle 随机打乱数组中的元素
+func (that *Array[T]) Shuffle() *Array[T] {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	for i, v := range drand.Perm(len(that.array)) {
+		that.array[i], that.array[v] = that.array[v], that.array[i]
+	}
+	return that
+}
+
+// Reverse 反向排序数组
+func (that *Array[T]) Reverse() *Array[T] {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	for i, j := 0, len(that.array)-1; i < j; i, j = i+1, j-1 {
+		that.array[i], that.array[j] = that.array[j], that.array[i]
+	}
+	return that
+}
+
+// Join 把数组元素以 glue分隔符链接起来
+func (that *Array[T]) Join(glue string) string {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return sliceJoin(that.array, glue)
+}
+
+// CountValues 计算数组各元素的数量
+func (that *Array[T]) CountValues() map[T]int {
+	m := make(map[T]int)
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	for _, v := range that.array {
+		m[v]++
+	}
+	return m
+}
+
+// Iterator 迭代
+func (that *Array[T]) Iterator(f func(k int, v T) bool) {
+	that.IteratorAsc(f)
+}
+
+// IteratorAsc 顺序迭代
+func (that *Array[T]) IteratorAsc(f func(k int, v T) bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	for k, v := range that.array {
+		if !f(k, v) {
+			break
+		}
+	}
+}
+
+// IteratorDesc 反向迭代
+func (that *Array[T]) IteratorDesc(f func(k int, v T) bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	for i := len(that.array) - 1; i >= 0; i-- {
+		if !f(i, that.array[i]) {
+			break
+		}
+	}
+}
+
+// Walk 对数组中的每个元素都执行指定的 func，并使用返回值替换元素
+func (that *Array[T]) Walk(f func(value T) T) *Array[T] {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	for i, v := range that.array {
+		that.array[i] = f(v)
+	}
+	return that
+}
+
+// Filter 移除数组中f返回true的元素
+func (that *Array[T]) Filter(f func(value T) bool) *Array[T] {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	array := that.array[:0]
+	for _, v := range that.array {
+		if !f(v) {
+			array = append(array, v)
+		}
+	}
+	that.array = array
+	return that
+}
+
+// 把数组转换成字符串
+func (that *Array[T]) String() string {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return sliceString(that.array)
+}
+
+// MarshalJSON 序列化成json
+func (that *Array[T]) MarshalJSON() ([]byte, error) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return json.Marshal(that.array)
+}
+
+// UnmarshalJSON 把json序列化转成数组
+func (that *Array[T]) UnmarshalJSON(b []byte) error {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	var array []T
+	if err := json.Unmarshal(b, &array); err != nil {
+		return err
+	}
+	that.array = array
+	return nil
+}
@@ -0,0 +1,344 @@
+package dgeneric
+
+import (
+	"encoding/json"
+	"github.com/osgochina/donkeygo/internal/rwmutex"
+	"github.com/osgochina/donkeygo/util/dconv"
+)
+
+// HashMap 泛型hash字典表
+type HashMap[K comparable, V any] struct {
+	mu   rwmutex.RWMutex
+	data map[K]V
+}
+
+// NewHashMap 创建hash表，safe参数表示是否并发安全
+func NewHashMap[K comparable, V any](safe ...bool) *HashMap[K, V] {
+	return &HashMap[K, V]{
+		mu:   rwmutex.Create(safe...),
+		data: make(map[K]V),
+	}
+}
+
+// NewHashMapFrom 通过map创建hash表，不会复制传入的map
+func NewHashMapFrom[K comparable, V any](data map[K]V, safe ...bool) *HashMap[K, V] {
+	if data == nil {
+		data = make(map[K]V)
+	}
+	return &HashMap[K, V]{
+		mu:   rwmutex.Create(safe...),
+		data: data,
+	}
+}
+
+// Iterator 迭代hash表
+func (that *HashMap[K, V]) Iterator(f func(k K, v V) bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	for k, v := range that.data {
+		if !f(k, v) {
+			break
+		}
+	}
+}
+
+// Map 返回原始结构，并发安全时返回复制的map
+func (that *HashMap[K, V]) Map() map[K]V {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	if !that.mu.IsSafe() {
+		return that.data
+	}
+	return that.doCopyWithoutLock()
+}
+
+// MapCopy copy一份map数据
+func (that *HashMap[K, V]) MapCopy() map[K]V {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return that.doCopyWithoutLock()
+}
+
+func (that *HashMap[K, V]) doCopyWithoutLock() map[K]V {
+	data := make(map[K]V, len(that.data))
+	for k, v := range that.data {
+		data[k] = v
+	}
+	return data
+}
+
+// Clone clone一份数据。并返回新的对象指针
+func (that *HashMap[K, V]) Clone(safe ...bool) *HashMap[K, V] {
+	if len(safe) == 0 {
+		safe = []bool{that.mu.IsSafe()}
+	}
+	return NewHashMapFrom(that.MapCopy(), safe...)
+}
+
+// Set 设置key value
+func (that *HashMap[K, V]) Set(key K, value V) {
+	that.mu.Lock()
+	if that.data == nil {
+		that.data = make(map[K]V)
+	}
+	that.data[key] = value
+	that.mu.Unlock()
+}
+
+// Sets 批量设置
+func (that *HashMap[K, V]) Sets(data map[K]V) {
+	that.mu.Lock()
+	if that.data == nil {
+		that.data = make(map[K]V, len(data))
+	}
+	for k, v := range data {
+		that.data[k] = v
+	}
+	that.mu.Unlock()
+}
+
+// Search 通过key查找value
+func (that *HashMap[K, V]) Search(key K) (value V, found bool) {
+	that.mu.RLock()
+	value, found = that.data[key]
+	that.mu.RUnlock()
+	return
+}
+
+// Get 查找key，不存在时返回零值
+func (that *HashMap[K, V]) Get(key K) (value V) {
+	value, _ = that.Search(key)
+	return
+}
+
+// Pop 随机获取并移除一个字典key，value
+func (that *HashMap[K, V]) Pop() (key K, value V, found bool) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	for key, value = range that.data {
+		delete(that.data, key)
+		return key, value, true
+	}
+	return
+}
+
+// Pops 随机获取并移除指定size的字典值，-1表示获取全部
+func (that *HashMap[K, V]) Pops(size int) map[K]V {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if len(that.data) < size || size == -1 {
+		size = len(that.data)
+	}
+	if size <= 0 {
+		return nil
+	}
+	index := 0
+	newData := make(map[K]V, size)
+	for key, value := range that.data {
+		delete(that.data, key)
+		newData[key] = value
+		index++
+		if index == size {
+			break
+		}
+	}
+	return newData
+}
+
+// 加锁设置值，如果key已经存在，则直接返回已存在的值，否则调用f生成值并写入
+func (that *HashMap[K, V]) doSetWithLockCheck(key K, f func() V) (value V, set bool) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if that.data == nil {
+		that.data = make(map[K]V)
+	}
+	if v, ok := that.data[key]; ok {
+		return v, false
+	}
+	value = f()
+	that.data[key] = value
+	return value, true
+}
+
+// GetOrSet 查找key对应的值是否存在，如果未找到，则写入
+func (that *HashMap[K, V]) GetOrSet(key K, value V) V {
+	if v, ok := that.Search(key); ok {
+		return v
+	}
+	v, _ := that.doSetWithLockCheck(key, func() V { return value })
+	return v
+}
+
+// GetOrSetFunc 获取指定key的值，如果不存在，则通过方法f生成该值，并写入到map，然后返回
+// 生成新值的方法未使用到锁，不会造成阻塞
+func (that *HashMap[K, V]) GetOrSetFunc(key K, f func() V) V {
+	if v, ok := that.Search(key); ok {
+		return v
+	}
+	value := f()
+	v, _ := that.doSetWithLockCheck(key, func() V { return value })
+	return v
+}
+
+// GetOrSetFuncLock 与GetOrSetFunc的区别在于生成值的时候会加锁，传入的方法不能阻塞
+func (that *HashMap[K, V]) GetOrSetFuncLock(key K, f func() V) V {
+	if v, ok := that.Search(key); ok {
+		return v
+	}
+	v, _ := that.doSetWithLockCheck(key, f)
+	return v
+}
+
+// SetIfNotExist 如果map中不存在该key，则设置
+func (that *HashMap[K, V]) SetIfNotExist(key K, value V) bool {
+	_, set := that.doSetWithLockCheck(key, func() V { return value })
+	return set
+}
+
+// SetIfNotExistFunc 如果map中不存在key，则调用方法f生成，生成的时候未加锁
+func (that *HashMap[K, V]) SetIfNotExistFunc(key K, f func() V) bool {
+	if that.Contains(key) {
+		return false
+	}
+	value := f()
+	_, set := that.doSetWithLockCheck(key, func() V { return value })
+	return set
+}
+
+// SetIfNotExistFuncLock 如果map中不存在key，则调用方法f生成，生成的时候加锁
+func (that *HashMap[K, V]) SetIfNotExistFuncLock(key K, f func() V) bool {
+	_, set := that.doSetWithLockCheck(key, f)
+	return set
+}
+
+// Contains 判断传入的key是否存在于map中
+func (that *HashMap[K, V]) Contains(key K) bool {
+	that.mu.RLock()
+	_, ok := that.data[key]
+	that.mu.RUnlock()
+	return ok
+}
+
+// Remove 移除指定的key，并返回它对应的值
+func (that *HashMap[K, V]) Remove(key K) (value V) {
+	that.mu.Lock()
+	if v, ok := that.data[key]; ok {
+		value = v
+		delete(that.data, key)
+	}
+	that.mu.Unlock()
+	return
+}
+
+// Removes 批量删除key
+func (that *HashMap[K, V]) Removes(keys []K) {
+	that.mu.Lock()
+	for _, key := range keys {
+		delete(that.data, key)
+	}
+	that.mu.Unlock()
+}
+
+// Keys 返回map的所有key
+func (that *HashMap[K, V]) Keys() []K {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	keys := make([]K, 0, len(that.data))
+	for key := range that.data {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Values 返回map所有的值
+func (that *HashMap[K, V]) Values() []V {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	values := make([]V, 0, len(that.data))
+	for _, value := range that.data {
+		values = append(values, value)
+	}
+	return values
+}
+
+// Size 返回map的长度
+func (that *HashMap[K, V]) Size() int {
+	that.mu.RLock()
+	length := len(that.data)
+	that.mu.RUnlock()
+	return length
+}
+
+// IsEmpty 判断map是否为空
+func (that *HashMap[K, V]) IsEmpty() bool {
+	return that.Size() == 0
+}
+
+// Clear 清除map
+func (that *HashMap[K, V]) Clear() {
+	that.mu.Lock()
+	that.data = make(map[K]V)
+	that.mu.Unlock()
+}
+
+// Replace 替换map，传入nil时等同于清空
+func (that *HashMap[K, V]) Replace(data map[K]V) {
+	if data == nil {
+		data = make(map[K]V)
+	}
+	that.mu.Lock()
+	that.data = data
+	that.mu.Unlock()
+}
+
+// LockFunc 加锁执行方法操作数据
+func (that *HashMap[K, V]) LockFunc(f func(m map[K]V)) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	f(that.data)
+}
+
+// RLockFunc 加读锁执行方法操作数据
+func (that *HashMap[K, V]) RLockFunc(f func(m map[K]V)) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	f(that.data)
+}
+
+// Merge 合并两个map
+func (that *HashMap[K, V]) Merge(other *HashMap[K, V]) {
+	if other == that {
+		return
+	}
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+	if that.data == nil {
+		that.data = make(map[K]V, len(other.data))
+	}
+	for k, v := range other.data {
+		that.data[k] = v
+	}
+}
+
+// 转换成字符串
+func (that *HashMap[K, V]) String() string {
+	b, _ := that.MarshalJSON()
+	return dconv.UnsafeBytesToStr(b)
+}
+
+// MarshalJSON json序列化，key会被转换成字符串
+func (that *HashMap[K, V]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(dconv.Map(that.Map()))
+}
+
+// UnmarshalJSON json反序列化，K需要是json支持的字符串或者整数类型
+func (that *HashMap[K, V]) UnmarshalJSON(b []byte) error {
+	var data map[K]V
+	if err := json.Unmarshal(b, &data); err != nil {
+		return err
+	}
+	that.Sets(data)
+	return nil
+}
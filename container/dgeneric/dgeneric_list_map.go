@@ -0,0 +1,367 @@
+package dgeneric
+
+import (
+	"encoding/json"
+	"github.com/osgochina/donkeygo/internal/rwmutex"
+	"github.com/osgochina/donkeygo/util/dconv"
+)
+
+// ListMap 泛型链表字典，使用hash表存储数据，使用双向链表保持写入的顺序
+type ListMap[K comparable, V any] struct {
+	mu   rwmutex.RWMutex
+	data map[K]*listMapNode[K, V]
+	root listMapNode[K, V] // 链表的哨兵节点，root.next是第一个节点，root.prev是最后一个节点
+}
+
+// 链表节点
+type listMapNode[K comparable, V any] struct {
+	key        K
+	value      V
+	prev, next *listMapNode[K, V]
+}
+
+// NewListMap 创建链表字典，safe参数表示是否并发安全
+func NewListMap[K comparable, V any](safe ...bool) *ListMap[K, V] {
+	m := &ListMap[K, V]{
+		mu: rwmutex.Create(safe...),
+	}
+	m.init()
+	return m
+}
+
+// NewListMapFrom 通过map创建链表字典，顺序与map的迭代顺序一致
+func NewListMapFrom[K comparable, V any](data map[K]V, safe ...bool) *ListMap[K, V] {
+	m := NewListMap[K, V](safe...)
+	m.Sets(data)
+	return m
+}
+
+func (that *ListMap[K, V]) init() {
+	that.data = make(map[K]*listMapNode[K, V])
+	that.root.next = &that.root
+	that.root.prev = &that.root
+}
+
+// Iterator see IteratorAsc
+func (that *ListMap[K, V]) Iterator(f func(key K, value V) bool) {
+	that.IteratorAsc(f)
+}
+
+// IteratorAsc 按照写入的顺序迭代
+func (that *ListMap[K, V]) IteratorAsc(f func(key K, value V) bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	if that.data == nil {
+		return
+	}
+	for node := that.root.next; node != &that.root; node = node.next {
+		if !f(node.key, node.value) {
+			break
+		}
+	}
+}
+
+// IteratorDesc 按照写入的顺序反向迭代
+func (that *ListMap[K, V]) IteratorDesc(f func(key K, value V) bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	if that.data == nil {
+		return
+	}
+	for node := that.root.prev; node != &that.root; node = node.prev {
+		if !f(node.key, node.value) {
+			break
+		}
+	}
+}
+
+// Clone clone一份数据。并返回新的对象指针
+func (that *ListMap[K, V]) Clone(safe ...bool) *ListMap[K, V] {
+	if len(safe) == 0 {
+		safe = []bool{that.mu.IsSafe()}
+	}
+	m := NewListMap[K, V](safe...)
+	that.IteratorAsc(func(key K, value V) bool {
+		m.doSetWithoutLock(key, value)
+		return true
+	})
+	return m
+}
+
+// Clear 清除map
+func (that *ListMap[K, V]) Clear() {
+	that.mu.Lock()
+	that.init()
+	that.mu.Unlock()
+}
+
+// Replace 使用data替换map的数据
+func (that *ListMap[K, V]) Replace(data map[K]V) {
+	that.mu.Lock()
+	that.init()
+	for k, v := range data {
+		that.doSetWithoutLock(k, v)
+	}
+	that.mu.Unlock()
+}
+
+// Map 返回map数据的副本
+func (that *ListMap[K, V]) Map() map[K]V {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	data := make(map[K]V, len(that.data))
+	for k, node := range that.data {
+		data[k] = node.value
+	}
+	return data
+}
+
+// Set 设置key value，key已经存在时只更新值，不改变顺序
+func (that *ListMap[K, V]) Set(key K, value V) {
+	that.mu.Lock()
+	that.doSetWithoutLock(key, value)
+	that.mu.Unlock()
+}
+
+// Sets 批量设置
+func (that *ListMap[K, V]) Sets(data map[K]V) {
+	that.mu.Lock()
+	for k, v := range data {
+		that.doSetWithoutLock(k, v)
+	}
+	that.mu.Unlock()
+}
+
+func (that *ListMap[K, V]) doSetWithoutLock(key K, value V) {
+	if that.data == nil {
+		that.init()
+	}
+	if node, ok := that.data[key]; ok {
+		node.value = value
+		return
+	}
+	node := &listMapNode[K, V]{key: key, value: value, prev: that.root.prev, next: &that.root}
+	that.root.prev.next = node
+	that.root.prev = node
+	that.data[key] = node
+}
+
+// 从链表和map中移除节点
+func (that *ListMap[K, V]) doRemoveWithoutLock(node *listMapNode[K, V]) {
+	node.prev.next = node.next
+	node.next.prev = node.prev
+	node.prev, node.next = nil, nil
+	delete(that.data, node.key)
+}
+
+// Search 通过key查找value
+func (that *ListMap[K, V]) Search(key K) (value V, found bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	if node, ok := that.data[key]; ok {
+		return node.value, true
+	}
+	return
+}
+
+// Get 查找key，不存在时返回零值
+func (that *ListMap[K, V]) Get(key K) (value V) {
+	value, _ = that.Search(key)
+	return
+}
+
+// Pop 获取并移除第一个写入的key，value
+func (that *ListMap[K, V]) Pop() (key K, value V, found bool) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if len(that.data) == 0 {
+		return
+	}
+	node := that.root.next
+	that.doRemoveWithoutLock(node)
+	return node.key, node.value, true
+}
+
+// Pops 按照写入的顺序获取并移除指定size的字典值，-1表示获取全部
+func (that *ListMap[K, V]) Pops(size int) map[K]V {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if len(that.data) < size || size == -1 {
+		size = len(that.data)
+	}
+	if size <= 0 {
+		return nil
+	}
+	newData := make(map[K]V, size)
+	for i := 0; i < size; i++ {
+		node := that.root.next
+		that.doRemoveWithoutLock(node)
+		newData[node.key] = node.value
+	}
+	return newData
+}
+
+// 加锁设置值，如果key已经存在，则直接返回已存在的值，否则调用f生成值并写入
+func (that *ListMap[K, V]) doSetWithLockCheck(key K, f func() V) (value V, set bool) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if node, ok := that.data[key]; ok {
+		return node.value, false
+	}
+	value = f()
+	that.doSetWithoutLock(key, value)
+	return value, true
+}
+
+// GetOrSet 查找key对应的值是否存在，如果未找到，则写入
+func (that *ListMap[K, V]) GetOrSet(key K, value V) V {
+	v, _ := that.doSetWithLockCheck(key, func() V { return value })
+	return v
+}
+
+// GetOrSetFunc 获取指定key的值，如果不存在，则通过方法f生成该值，并写入到map，然后返回
+// 生成新值的方法未使用到锁，不会造成阻塞
+func (that *ListMap[K, V]) GetOrSetFunc(key K, f func() V) V {
+	if v, ok := that.Search(key); ok {
+		return v
+	}
+	value := f()
+	v, _ := that.doSetWithLockCheck(key, func() V { return value })
+	return v
+}
+
+// GetOrSetFuncLock 与GetOrSetFunc的区别在于生成值的时候会加锁，传入的方法不能阻塞
+func (that *ListMap[K, V]) GetOrSetFuncLock(key K, f func() V) V {
+	v, _ := that.doSetWithLockCheck(key, f)
+	return v
+}
+
+// SetIfNotExist 如果map中不存在该key，则设置
+func (that *ListMap[K, V]) SetIfNotExist(key K, value V) bool {
+	_, set := that.doSetWithLockCheck(key, func() V { return value })
+	return set
+}
+
+// SetIfNotExistFunc 如果map中不存在key，则调用方法f生成，生成的时候未加锁
+func (that *ListMap[K, V]) SetIfNotExistFunc(key K, f func() V) bool {
+	if that.Contains(key) {
+		return false
+	}
+	value := f()
+	_, set := that.doSetWithLockCheck(key, func() V { return value })
+	return set
+}
+
+// SetIfNotExistFuncLock 如果map中不存在key，则调用方法f生成，生成的时候加锁
+func (that *ListMap[K, V]) SetIfNotExistFuncLock(key K, f func() V) bool {
+	_, set := that.doSetWithLockCheck(key, f)
+	return set
+}
+
+// Contains 判断传入的key是否存在于map中
+func (that *ListMap[K, V]) Contains(key K) bool {
+	that.mu.RLock()
+	_, ok := that.data[key]
+	that.mu.RUnlock()
+	return ok
+}
+
+// Remove 移除指定的key，并返回它对应的值
+func (that *ListMap[K, V]) Remove(key K) (value V) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if node, ok := that.data[key]; ok {
+		that.doRemoveWithoutLock(node)
+		return node.value
+	}
+	return
+}
+
+// Removes 批量删除key
+func (that *ListMap[K, V]) Removes(keys []K) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	for _, key := range keys {
+		if node, ok := that.data[key]; ok {
+			that.doRemoveWithoutLock(node)
+		}
+	}
+}
+
+// Keys 按照写入的顺序返回所有key
+func (that *ListMap[K, V]) Keys() []K {
+	keys := make([]K, 0, that.Size())
+	that.IteratorAsc(func(key K, value V) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Values 按照写入的顺序返回所有值
+func (that *ListMap[K, V]) Values() []V {
+	values := make([]V, 0, that.Size())
+	that.IteratorAsc(func(key K, value V) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+// Size 返回map的长度
+func (that *ListMap[K, V]) Size() int {
+	that.mu.RLock()
+	length := len(that.data)
+	that.mu.RUnlock()
+	return length
+}
+
+// IsEmpty 判断map是否为空
+func (that *ListMap[K, V]) IsEmpty() bool {
+	return that.Size() == 0
+}
+
+// Merge 合并两个map，other中新的key追加到末尾
+func (that *ListMap[K, V]) Merge(other *ListMap[K, V]) {
+	if other == that {
+		return
+	}
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	other.IteratorAsc(func(key K, value V) bool {
+		that.doSetWithoutLock(key, value)
+		return true
+	})
+}
+
+// 转换成字符串
+func (that *ListMap[K, V]) String() string {
+	b, _ := that.MarshalJSON()
+	return dconv.UnsafeBytesToStr(b)
+}
+
+// MarshalJSON json序列化，按照写入的顺序输出，key会被转换成字符串
+func (that *ListMap[K, V]) MarshalJSON() ([]byte, error) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return marshalOrderedJSON(func(f func(key K, value V) bool) {
+		if that.data == nil {
+			return
+		}
+		for node := that.root.next; node != &that.root; node = node.next {
+			if !f(node.key, node.value) {
+				break
+			}
+		}
+	})
+}
+
+// UnmarshalJSON json反序列化，K需要是json支持的字符串或者整数类型，新写入key的顺序不保证与json中一致
+func (that *ListMap[K, V]) UnmarshalJSON(b []byte) error {
+	var data map[K]V
+	if err := json.Unmarshal(b, &data); err != nil {
+		return err
+	}
+	that.Sets(data)
+	return nil
+}
@@ -0,0 +1,322 @@
+package dgeneric
+
+import (
+	"encoding/json"
+	"github.com/osgochina/donkeygo/internal/rwmutex"
+)
+
+// Set 泛型集合，元素不重复
+type Set[T comparable] struct {
+	mu   rwmutex.RWMutex
+	data map[T]struct{}
+}
+
+// NewSet 创建集合，safe参数表示是否并发安全
+func NewSet[T comparable](safe ...bool) *Set[T] {
+	return &Set[T]{
+		mu:   rwmutex.Create(safe...),
+		data: make(map[T]struct{}),
+	}
+}
+
+// NewSetFrom 通过切片创建集合
+func NewSetFrom[T comparable](items []T, safe ...bool) *Set[T] {
+	s := NewSet[T](safe...)
+	for _, item := range items {
+		s.data[item] = struct{}{}
+	}
+	return s
+}
+
+// Iterator 迭代集合
+func (that *Set[T]) Iterator(f func(v T) bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	for k := range that.data {
+		if !f(k) {
+			break
+		}
+	}
+}
+
+// Add 添加元素到集合
+func (that *Set[T]) Add(items ...T) {
+	that.mu.Lock()
+	if that.data == nil {
+		that.data = make(map[T]struct{})
+	}
+	for _, v := range items {
+		that.data[v] = struct{}{}
+	}
+	that.mu.Unlock()
+}
+
+// AddIfNotExist 如果元素不存在，则添加，添加成功返回true
+func (that *Set[T]) AddIfNotExist(item T) bool {
+	return that.doAddWithLockCheck(item, nil)
+}
+
+// AddIfNotExistFunc 如果元素不存在并且方法f返回true，则添加，方法f在锁外执行
+func (that *Set[T]) AddIfNotExistFunc(item T, f func() bool) bool {
+	if that.Contains(item) || !f() {
+		return false
+	}
+	return that.doAddWithLockCheck(item, nil)
+}
+
+// AddIfNotExistFuncLock 如果元素不存在并且方法f返回true，则添加，方法f在锁内执行
+func (that *Set[T]) AddIfNotExistFuncLock(item T, f func() bool) bool {
+	return that.doAddWithLockCheck(item, f)
+}
+
+// 加锁添加元素，元素已经存在或者f返回false时不添加
+func (that *Set[T]) doAddWithLockCheck(item T, f func() bool) bool {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if that.data == nil {
+		that.data = make(map[T]struct{})
+	}
+	if _, ok := that.data[item]; ok {
+		return false
+	}
+	if f != nil && !f() {
+		return false
+	}
+	that.data[item] = struct{}{}
+	return true
+}
+
+// Contains 判断元素是否在集合中
+func (that *Set[T]) Contains(item T) bool {
+	that.mu.RLock()
+	_, ok := that.data[item]
+	that.mu.RUnlock()
+	return ok
+}
+
+// Remove 从集合中移除元素
+func (that *Set[T]) Remove(item T) {
+	that.mu.Lock()
+	delete(that.data, item)
+	that.mu.Unlock()
+}
+
+// Size 集合的长度
+func (that *Set[T]) Size() int {
+	that.mu.RLock()
+	l := len(that.data)
+	that.mu.RUnlock()
+	return l
+}
+
+// IsEmpty 判断集合是否为空
+func (that *Set[T]) IsEmpty() bool {
+	return that.Size() == 0
+}
+
+// Clear 清空集合
+func (that *Set[T]) Clear() {
+	that.mu.Lock()
+	that.data = make(map[T]struct{})
+	that.mu.Unlock()
+}
+
+// Slice 返回集合内的元素，以切片的形式返回
+func (that *Set[T]) Slice() []T {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	ret := make([]T, 0, len(that.data))
+	for item := range that.data {
+		ret = append(ret, item)
+	}
+	return ret
+}
+
+// Join 把集合的元素转换成字符串，然后以glue作为分隔符链接起来
+func (that *Set[T]) Join(glue string) string {
+	return sliceJoin(that.Slice(), glue)
+}
+
+// 把集合转换成字符串
+func (that *Set[T]) String() string {
+	return sliceString(that.Slice())
+}
+
+// LockFunc 加锁执行自定义方法
+func (that *Set[T]) LockFunc(f func(m map[T]struct{})) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	f(that.data)
+}
+
+// RLockFunc 加读锁执行自定义方法
+func (that *Set[T]) RLockFunc(f func(m map[T]struct{})) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	f(that.data)
+}
+
+// Equal 判断两个集合是否相等
+func (that *Set[T]) Equal(other *Set[T]) bool {
+	if that == other {
+		return true
+	}
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+	if len(that.data) != len(other.data) {
+		return false
+	}
+	for key := range that.data {
+		if _, ok := other.data[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// IsSubsetOf 检查当前集合是否为other集合的子集
+func (that *Set[T]) IsSubsetOf(other *Set[T]) bool {
+	if that == other {
+		return true
+	}
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+	for key := range that.data {
+		if _, ok := other.data[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Union 求多个集合的并集，返回新的集合
+func (that *Set[T]) Union(others ...*Set[T]) *Set[T] {
+	newSet := NewSetFrom(that.Slice(), that.mu.IsSafe())
+	for _, other := range others {
+		if other != that {
+			other.Iterator(func(v T) bool {
+				newSet.data[v] = struct{}{}
+				return true
+			})
+		}
+	}
+	return newSet
+}
+
+// Diff 求当前集合与其他集合的差集，返回新的集合
+func (that *Set[T]) Diff(others ...*Set[T]) *Set[T] {
+	newSet := NewSet[T](that.mu.IsSafe())
+	that.Iterator(func(v T) bool {
+		for _, other := range others {
+			if other == that || other.Contains(v) {
+				return true
+			}
+		}
+		newSet.data[v] = struct{}{}
+		return true
+	})
+	return newSet
+}
+
+// Intersect 求当前集合与其他集合的交集，返回新的集合
+func (that *Set[T]) Intersect(others ...*Set[T]) *Set[T] {
+	newSet := NewSet[T](that.mu.IsSafe())
+	that.Iterator(func(v T) bool {
+		for _, other := range others {
+			if other != that && !other.Contains(v) {
+				return true
+			}
+		}
+		newSet.data[v] = struct{}{}
+		return true
+	})
+	return newSet
+}
+
+// Complement 求当前集合在full集合中的补集，返回新的集合
+func (that *Set[T]) Complement(full *Set[T]) *Set[T] {
+	newSet := NewSet[T](that.mu.IsSafe())
+	if full == that {
+		return newSet
+	}
+	full.Iterator(func(v T) bool {
+		if !that.Contains(v) {
+			newSet.data[v] = struct{}{}
+		}
+		return true
+	})
+	return newSet
+}
+
+// Merge 把其他集合的元素合并到当前集合
+func (that *Set[T]) Merge(others ...*Set[T]) *Set[T] {
+	for _, other := range others {
+		if other != that {
+			that.Add(other.Slice()...)
+		}
+	}
+	return that
+}
+
+// Pop 从集合中取出一个元素
+func (that *Set[T]) Pop() (item T, found bool) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	for k := range that.data {
+		delete(that.data, k)
+		return k, true
+	}
+	return
+}
+
+// Pops 从集合中取出指定size的元素，-1表示取出全部
+func (that *Set[T]) Pops(size int) []T {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if size > len(that.data) || size == -1 {
+		size = len(that.data)
+	}
+	if size <= 0 {
+		return nil
+	}
+	array := make([]T, 0, size)
+	for k := range that.data {
+		delete(that.data, k)
+		array = append(array, k)
+		if len(array) == size {
+			break
+		}
+	}
+	return array
+}
+
+// Walk 针对集合中的每个元素执行一次f方法，并使用返回值替换元素
+func (that *Set[T]) Walk(f func(item T) T) *Set[T] {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	m := make(map[T]struct{}, len(that.data))
+	for k := range that.data {
+		m[f(k)] = struct{}{}
+	}
+	that.data = m
+	return that
+}
+
+// MarshalJSON 把集合格式化成json格式
+func (that *Set[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(that.Slice())
+}
+
+// UnmarshalJSON 把json格式转换成集合
+func (that *Set[T]) UnmarshalJSON(b []byte) error {
+	var array []T
+	if err := json.Unmarshal(b, &array); err != nil {
+		return err
+	}
+	that.Add(array...)
+	return nil
+}
@@ -0,0 +1,525 @@
+package dgeneric
+
+import (
+	"encoding/json"
+	"github.com/osgochina/donkeygo/internal/rwmutex"
+	"github.com/osgochina/donkeygo/util/drand"
+	"sort"
+)
+
+// SortedArray 泛型有序数组，元素总是按照comparator排序，
+// comparator在a小于b时返回负数，相等时返回0，大于时返回正数
+type SortedArray[T any] struct {
+	mu         rwmutex.RWMutex
+	array      []T
+	unique     bool
+	comparator func(a, b T) int
+}
+
+// NewSortedArray 使用比较方法创建有序数组，可排序的类型可以使用 Compare[T] 作为比较方法
+func NewSortedArray[T any](comparator func(a, b T) int, safe ...bool) *SortedArray[T] {
+	return NewSortedArraySize(0, comparator, safe...)
+}
+
+// NewSortedArraySize 创建指定容量的有序数组
+func NewSortedArraySize[T any](cap int, comparator func(a, b T) int, safe ...bool) *SortedArray[T] {
+	return &SortedArray[T]{
+		mu:         rwmutex.Create(safe...),
+		array:      make([]T, 0, cap),
+		comparator: comparator,
+	}
+}
+
+// NewSortedArrayFrom 使用切片创建有序数组，会对传入的切片排序，不会复制切片
+func NewSortedArrayFrom[T any](array []T, comparator func(a, b T) int, safe ...bool) *SortedArray[T] {
+	a := NewSortedArraySize(0, comparator, safe...)
+	a.array = array
+	sort.Slice(a.array, func(i, j int) bool {
+		return comparator(a.array[i], a.array[j]) < 0
+	})
+	return a
+}
+
+// NewSortedArrayFromCopy 复制一份切片后创建有序数组
+func NewSortedArrayFromCopy[T any](array []T, comparator func(a, b T) int, safe ...bool) *SortedArray[T] {
+	newArray := make([]T, len(array))
+	copy(newArray, array)
+	return NewSortedArrayFrom(newArray, comparator, safe...)
+}
+
+// At 获取指定index的值，index越界时返回零值
+func (that *SortedArray[T]) At(index int) (value T) {
+	value, _ = that.Get(index)
+	return
+}
+
+// SetArray 使用切片替换数组的数据，并重新排序
+func (that *SortedArray[T]) SetArray(array []T) *SortedArray[T] {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	that.array = array
+	sort.Slice(that.array, func(i, j int) bool {
+		return that.comparator(that.array[i], that.array[j]) < 0
+	})
+	if that.unique {
+		that.doUniqueWithoutLock()
+	}
+	return that
+}
+
+// Add see Append
+func (that *SortedArray[T]) Add(values ...T) *SortedArray[T] {
+	return that.Append(values...)
+}
+
+// Append 添加元素到有序数组，数组总是保持有序
+func (that *SortedArray[T]) Append(values ...T) *SortedArray[T] {
+	if len(values) == 0 {
+		return that
+	}
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	for _, value := range values {
+		index, cmp := that.binSearch(value)
+		if that.unique && cmp == 0 {
+			continue
+		}
+		if index < 0 {
+			that.array = append(that.array, value)
+			continue
+		}
+		if cmp > 0 {
+			index++
+		}
+		var zero T
+		that.array = append(that.array, zero)
+		copy(that.array[index+1:], that.array[index:])
+		that.array[index] = value
+	}
+	return that
+}
+
+// Get 获取指定index的值
+func (that *SortedArray[T]) Get(index int) (value T, found bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	if index < 0 || index >= len(that.array) {
+		return value, false
+	}
+	return that.array[index], true
+}
+
+// Remove 移除指定的index对应的值
+func (that *SortedArray[T]) Remove(index int) (value T, found bool) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	return that.doRemoveWithoutLock(index)
+}
+
+// 从数组中移除指定index的值，并返回它
+func (that *SortedArray[T]) doRemoveWithoutLock(index int) (value T, found bool) {
+	if index < 0 || index >= len(that.array) {
+		return value, false
+	}
+	value = that.array[index]
+	if index == 0 {
+		that.array = that.array[1:]
+	} else if index == len(that.array)-1 {
+		that.array = that.array[:index]
+	} else {
+		that.array = append(that.array[:index], that.array[index+1:]...)
+	}
+	return value, true
+}
+
+// RemoveValue 查找指定的值，并从数组中移除它
+func (that *SortedArray[T]) RemoveValue(value T) bool {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if i, r := that.binSearch(value); r == 0 {
+		that.doRemoveWithoutLock(i)
+		return true
+	}
+	return false
+}
+
+// PopLeft 拿出最小的值
+func (that *SortedArray[T]) PopLeft() (value T, found bool) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	return that.doRemoveWithoutLock(0)
+}
+
+// PopRight 拿出最大的值
+func (that *SortedArray[T]) PopRight() (value T, found bool) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	return that.doRemoveWithoutLock(len(that.array) - 1)
+}
+
+// PopRand 随机拿出一个值
+func (that *SortedArray[T]) PopRand() (value T, found bool) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if len(that.array) == 0 {
+		return value, false
+	}
+	return that.doRemoveWithoutLock(drand.Intn(len(that.array)))
+}
+
+// PopRands 随机拿出指定数量的值
+func (that *SortedArray[T]) PopRands(size int) []T {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if size <= 0 || len(that.array) == 0 {
+		return nil
+	}
+	if size >= len(that.array) {
+		size = len(that.array)
+	}
+	array := make([]T, size)
+	for i := 0; i < size; i++ {
+		array[i], _ = that.doRemoveWithoutLock(drand.Intn(len(that.array)))
+	}
+	return array
+}
+
+// PopLefts 从左边拿出指定数量的值
+func (that *SortedArray[T]) PopLefts(size int) []T {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if size <= 0 || len(that.array) == 0 {
+		return nil
+	}
+	if size >= len(that.array) {
+		array := that.array
+		that.array = that.array[:0:0]
+		return array
+	}
+	value := that.array[0:size:size]
+	that.array = that.array[size:]
+	return value
+}
+
+// PopRights 从右边拿出指定数量的值
+func (that *SortedArray[T]) PopRights(size int) []T {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if size <= 0 || len(that.array) == 0 {
+		return nil
+	}
+	index := len(that.array) - size
+	if index <= 0 {
+		array := that.array
+		that.array = that.array[:0:0]
+		return array
+	}
+	value := that.array[index:]
+	that.array = that.array[:index:index]
+	return value
+}
+
+// Range 获取从start到end的index的值，并发安全时返回复制的切片
+func (that *SortedArray[T]) Range(start int, end ...int) []T {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	offsetEnd := len(that.array)
+	if len(end) > 0 && end[0] < offsetEnd {
+		offsetEnd = end[0]
+	}
+	if start < 0 {
+		start = 0
+	}
+	if start > offsetEnd {
+		return nil
+	}
+	if that.mu.IsSafe() {
+		array := make([]T, offsetEnd-start)
+		copy(array, that.array[start:offsetEnd])
+		return array
+	}
+	return that.array[start:offsetEnd]
+}
+
+// SubSlice 截取数组中的某一段数组，并返回
+// 支持offset，length负数
+func (that *SortedArray[T]) SubSlice(offset int, length ...int) []T {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return subSlice(that.array, that.mu.IsSafe(), offset, length...)
+}
+
+// Len 数组的长度
+func (that *SortedArray[T]) Len() int {
+	that.mu.RLock()
+	length := len(that.array)
+	that.mu.RUnlock()
+	return length
+}
+
+// IsEmpty 判断数组是否为空
+func (that *SortedArray[T]) IsEmpty() bool {
+	return that.Len() == 0
+}
+
+// Slice 把数组转换成切片返回，并发安全时返回复制的切片
+func (that *SortedArray[T]) Slice() []T {
+	if that.mu.IsSafe() {
+		that.mu.RLock()
+		defer that.mu.RUnlock()
+		array := make([]T, len(that.array))
+		copy(array, that.array)
+		return array
+	}
+	return that.array
+}
+
+// Contains 判断值在数组是否存在
+func (that *SortedArray[T]) Contains(value T) bool {
+	return that.Search(value) != -1
+}
+
+// Search 使用二分查找指定的值，不存在返回-1，存在返回对应的index
+func (that *SortedArray[T]) Search(value T) int {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	if index, result := that.binSearch(value); result == 0 {
+		return index
+	}
+	return -1
+}
+
+// 二分查找，返回最后比较的index和比较结果，
+// 结果为0表示index对应的值等于value，小于0表示value小于index对应的值，大于0表示value大于index对应的值，
+// 数组为空时返回-1
+func (that *SortedArray[T]) binSearch(value T) (index int, result int) {
+	if len(that.array) == 0 {
+		return -1, -2
+	}
+	min := 0
+	max := len(that.array) - 1
+	mid := 0
+	cmp := -2
+	for min <= max {
+		mid = min + (max-min)/2
+		cmp = that.comparator(value, that.array[mid])
+		switch {
+		case cmp < 0:
+			max = mid - 1
+		case cmp > 0:
+			min = mid + 1
+		default:
+			return mid, cmp
+		}
+	}
+	return mid, cmp
+}
+
+// SetUnique 设置数组是否不包含重复元素，设置为true时会去除已有的重复元素
+func (that *SortedArray[T]) SetUnique(unique bool) *SortedArray[T] {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	oldUnique := that.unique
+	that.unique = unique
+	if unique && !oldUnique {
+		that.doUniqueWithoutLock()
+	}
+	return that
+}
+
+// Unique 去除数组中相同的值
+func (that *SortedArray[T]) Unique() *SortedArray[T] {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	that.doUniqueWithoutLock()
+	return that
+}
+
+func (that *SortedArray[T]) doUniqueWithoutLock() {
+	if len(that.array) == 0 {
+		return
+	}
+	array := that.array[:1]
+	for _, v := range that.array[1:] {
+		if that.comparator(array[len(array)-1], v) != 0 {
+			array = append(array, v)
+		}
+	}
+	that.array = array
+}
+
+// Clone 复制数组
+func (that *SortedArray[T]) Clone() *SortedArray[T] {
+	that.mu.RLock()
+	array := make([]T, len(that.array))
+	copy(array, that.array)
+	unique := that.unique
+	that.mu.RUnlock()
+	newArray := NewSortedArraySize(0, that.comparator, that.mu.IsSafe())
+	newArray.array = array
+	newArray.unique = unique
+	return newArray
+}
+
+// Clear 清空数组数据
+func (that *SortedArray[T]) Clear() *SortedArray[T] {
+	that.mu.Lock()
+	if len(that.array) > 0 {
+		that.array = make([]T, 0)
+	}
+	that.mu.Unlock()
+	return that
+}
+
+// LockFunc 加锁执行指定方法，把数组值作为参数传入，方法中不能改变元素的顺序
+func (that *SortedArray[T]) LockFunc(f func(array []T)) *SortedArray[T] {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	f(that.array)
+	return that
+}
+
+// RLockFunc 加读锁执行方法，把数组作为参数传入
+func (that *SortedArray[T]) RLockFunc(f func(array []T)) *SortedArray[T] {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	f(that.array)
+	return that
+}
+
+// Merge 把other数组的元素添加到数组中
+func (that *SortedArray[T]) Merge(other *SortedArray[T]) *SortedArray[T] {
+	return that.Append(other.Slice()...)
+}
+
+// Chunk 分片，使用size个数目分片成多个数组，返回二维数组
+func (that *SortedArray[T]) Chunk(size int) [][]T {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return sliceChunk(that.array, size)
+}
+
+// Rand 随机返回一个数组元素
+func (that *SortedArray[T]) Rand() (value T, found bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	if len(that.array) == 0 {
+		return value, false
+	}
+	return that.array[drand.Intn(len(that.array))], true
+}
+
+// Rands 随机返回指定 size 的数组元素，元素可能重复
+func (that *SortedArray[T]) Rands(size int) []T {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	if size <= 0 || len(that.array) == 0 {
+		return nil
+	}
+	array := make([]T, size)
+	for i := 0; i < size; i++ {
+		array[i] = that.array[drand.Intn(len(that.array))]
+	}
+	return array
+}
+
+// Join 把数组元素以 glue分隔符链接起来
+func (that *SortedArray[T]) Join(glue string) string {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return sliceJoin(that.array, glue)
+}
+
+// Iterator 迭代
+func (that *SortedArray[T]) Iterator(f func(k int, v T) bool) {
+	that.IteratorAsc(f)
+}
+
+// IteratorAsc 顺序迭代
+func (that *SortedArray[T]) IteratorAsc(f func(k int, v T) bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	for k, v := range that.array {
+		if !f(k, v) {
+			break
+		}
+	}
+}
+
+// IteratorDesc 反向迭代
+func (that *SortedArray[T]) IteratorDesc(f func(k int, v T) bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	for i := len(that.array) - 1; i >= 0; i-- {
+		if !f(i, that.array[i]) {
+			break
+		}
+	}
+}
+
+// Walk 对数组中的每个元素都执行指定的 func，并使用返回值替换元素后重新排序
+func (that *SortedArray[T]) Walk(f func(value T) T) *SortedArray[T] {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	for i, v := range that.array {
+		that.array[i] = f(v)
+	}
+	sort.Slice(that.array, func(i, j int) bool {
+		return that.comparator(that.array[i], that.array[j]) < 0
+	})
+	if that.unique {
+		that.doUniqueWithoutLock()
+	}
+	return that
+}
+
+// Filter 移除数组中f返回true的元素
+func (that *SortedArray[T]) Filter(f func(value T) bool) *SortedArray[T] {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	array := that.array[:0]
+	for _, v := range that.array {
+		if !f(v) {
+			array = append(array, v)
+		}
+	}
+	that.array = array
+	return that
+}
+
+// Sort 重新排序数组，用于LockFunc中修改了元素之后恢复顺序
+func (that *SortedArray[T]) Sort() *SortedArray[T] {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	sort.Slice(that.array, func(i, j int) bool {
+		return that.comparator(that.array[i], that.array[j]) < 0
+	})
+	if that.unique {
+		that.doUniqueWithoutLock()
+	}
+	return that
+}
+
+// 把数组转换成字符串
+func (that *SortedArray[T]) String() string {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return sliceString(that.array)
+}
+
+// MarshalJSON 序列化成json
+func (that *SortedArray[T]) MarshalJSON() ([]byte, error) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return json.Marshal(that.array)
+}
+
+// UnmarshalJSON 把json序列化转成数组，数组必须已经设置了比较方法
+func (that *SortedArray[T]) UnmarshalJSON(b []byte) error {
+	var array []T
+	if err := json.Unmarshal(b, &array); err != nil {
+		return err
+	}
+	that.SetArray(array)
+	return nil
+}
@@ -0,0 +1,315 @@
+package dgeneric
+
+import (
+	"github.com/osgochina/donkeygo/container/dtree"
+	"github.com/osgochina/donkeygo/internal/rwmutex"
+	"github.com/osgochina/donkeygo/util/dconv"
+)
+
+// TreeMap 泛型树形字典，基于红黑树实现，key总是按照comparator排序，
+// comparator在a小于b时返回负数，相等时返回0，大于时返回正数
+type TreeMap[K any, V any] struct {
+	mu         rwmutex.RWMutex
+	tree       *dtree.RedBlackTree // 不加锁的红黑树，由TreeMap的锁保护
+	comparator func(a, b K) int
+}
+
+// NewTreeMap 使用比较方法创建树形字典，可排序的类型可以使用 Compare[K] 作为比较方法
+func NewTreeMap[K any, V any](comparator func(a, b K) int, safe ...bool) *TreeMap[K, V] {
+	return &TreeMap[K, V]{
+		mu: rwmutex.Create(safe...),
+		tree: dtree.NewRedBlackTree(func(a, b interface{}) int {
+			return comparator(a.(K), b.(K))
+		}),
+		comparator: comparator,
+	}
+}
+
+// NewTreeMapFrom 使用比较方法和map创建树形字典
+func NewTreeMapFrom[K comparable, V any](comparator func(a, b K) int, data map[K]V, safe ...bool) *TreeMap[K, V] {
+	m := NewTreeMap[K, V](comparator, safe...)
+	for k, v := range data {
+		m.tree.Set(k, v)
+	}
+	return m
+}
+
+// 把红黑树中存储的值转换成V，值为nil时返回零值
+func treeValue[V any](v interface{}) (value V) {
+	if v != nil {
+		value = v.(V)
+	}
+	return
+}
+
+// Clone clone一份数据。并返回新的对象指针
+func (that *TreeMap[K, V]) Clone(safe ...bool) *TreeMap[K, V] {
+	if len(safe) == 0 {
+		safe = []bool{that.mu.IsSafe()}
+	}
+	m := NewTreeMap[K, V](that.comparator, safe...)
+	that.IteratorAsc(func(key K, value V) bool {
+		m.tree.Set(key, value)
+		return true
+	})
+	return m
+}
+
+// Set 设置key value
+func (that *TreeMap[K, V]) Set(key K, value V) {
+	that.mu.Lock()
+	that.tree.Set(key, value)
+	that.mu.Unlock()
+}
+
+// Search 通过key查找value
+func (that *TreeMap[K, V]) Search(key K) (value V, found bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	v, found := that.tree.Search(key)
+	return treeValue[V](v), found
+}
+
+// Get 查找key，不存在时返回零值
+func (that *TreeMap[K, V]) Get(key K) (value V) {
+	value, _ = that.Search(key)
+	return
+}
+
+// 加锁设置值，如果key已经存在，则直接返回已存在的值，否则调用f生成值并写入
+func (that *TreeMap[K, V]) doSetWithLockCheck(key K, f func() V) (value V, set bool) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if v, ok := that.tree.Search(key); ok {
+		return treeValue[V](v), false
+	}
+	value = f()
+	that.tree.Set(key, value)
+	return value, true
+}
+
+// GetOrSet 查找key对应的值是否存在，如果未找到，则写入
+func (that *TreeMap[K, V]) GetOrSet(key K, value V) V {
+	v, _ := that.doSetWithLockCheck(key, func() V { return value })
+	return v
+}
+
+// GetOrSetFunc 获取指定key的值，如果不存在，则通过方法f生成该值，并写入到map，然后返回
+// 生成新值的方法未使用到锁，不会造成阻塞
+func (that *TreeMap[K, V]) GetOrSetFunc(key K, f func() V) V {
+	if v, ok := that.Search(key); ok {
+		return v
+	}
+	value := f()
+	v, _ := that.doSetWithLockCheck(key, func() V { return value })
+	return v
+}
+
+// GetOrSetFuncLock 与GetOrSetFunc的区别在于生成值的时候会加锁，传入的方法不能阻塞
+func (that *TreeMap[K, V]) GetOrSetFuncLock(key K, f func() V) V {
+	v, _ := that.doSetWithLockCheck(key, f)
+	return v
+}
+
+// SetIfNotExist 如果map中不存在该key，则设置
+func (that *TreeMap[K, V]) SetIfNotExist(key K, value V) bool {
+	_, set := that.doSetWithLockCheck(key, func() V { return value })
+	return set
+}
+
+// SetIfNotExistFunc 如果map中不存在key，则调用方法f生成，生成的时候未加锁
+func (that *TreeMap[K, V]) SetIfNotExistFunc(key K, f func() V) bool {
+	if that.Contains(key) {
+		return false
+	}
+	value := f()
+	_, set := that.doSetWithLockCheck(key, func() V { return value })
+	return set
+}
+
+// SetIfNotExistFuncLock 如果map中不存在key，则调用方法f生成，生成的时候加锁
+func (that *TreeMap[K, V]) SetIfNotExistFuncLock(key K, f func() V) bool {
+	_, set := that.doSetWithLockCheck(key, f)
+	return set
+}
+
+// Contains 判断传入的key是否存在于map中
+func (that *TreeMap[K, V]) Contains(key K) bool {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return that.tree.Contains(key)
+}
+
+// Remove 移除指定的key，并返回它对应的值
+func (that *TreeMap[K, V]) Remove(key K) (value V) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	return treeValue[V](that.tree.Remove(key))
+}
+
+// Removes 批量删除key
+func (that *TreeMap[K, V]) Removes(keys []K) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	for _, key := range keys {
+		that.tree.Remove(key)
+	}
+}
+
+// Size 返回map的长度
+func (that *TreeMap[K, V]) Size() int {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return that.tree.Size()
+}
+
+// IsEmpty 判断map是否为空
+func (that *TreeMap[K, V]) IsEmpty() bool {
+	return that.Size() == 0
+}
+
+// Keys 按照升序返回所有key
+func (that *TreeMap[K, V]) Keys() []K {
+	keys := make([]K, 0, that.Size())
+	that.IteratorAsc(func(key K, value V) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Values 按照key的升序返回所有值
+func (that *TreeMap[K, V]) Values() []V {
+	values := make([]V, 0, that.Size())
+	that.IteratorAsc(func(key K, value V) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+// Left 返回最小的key和对应的值，map为空时found为false
+func (that *TreeMap[K, V]) Left() (key K, value V, found bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return treeNode[K, V](that.tree.Left())
+}
+
+// Right 返回最大的key和对应的值，map为空时found为false
+func (that *TreeMap[K, V]) Right() (key K, value V, found bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return treeNode[K, V](that.tree.Right())
+}
+
+// Floor 返回小于等于key的最大的key和对应的值，不存在时found为false
+func (that *TreeMap[K, V]) Floor(key K) (floorKey K, value V, found bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	node, _ := that.tree.Floor(key)
+	return treeNode[K, V](node)
+}
+
+// Ceiling 返回大于等于key的最小的key和对应的值，不存在时found为false
+func (that *TreeMap[K, V]) Ceiling(key K) (ceilingKey K, value V, found bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	node, _ := that.tree.Ceiling(key)
+	return treeNode[K, V](node)
+}
+
+// 获取红黑树节点的key和值
+func treeNode[K any, V any](node *dtree.RedBlackTreeNode) (key K, value V, found bool) {
+	if node == nil {
+		return
+	}
+	return node.Key.(K), treeValue[V](node.Value), true
+}
+
+// Iterator see IteratorAsc
+func (that *TreeMap[K, V]) Iterator(f func(key K, value V) bool) {
+	that.IteratorAsc(f)
+}
+
+// IteratorAsc 按照key的升序迭代
+func (that *TreeMap[K, V]) IteratorAsc(f func(key K, value V) bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	that.tree.IteratorAsc(func(key, value interface{}) bool {
+		return f(key.(K), treeValue[V](value))
+	})
+}
+
+// IteratorAscFrom 从key开始按照升序迭代，match为true时key必须存在才会迭代，
+// 否则从大于等于key的最小的key开始迭代
+func (that *TreeMap[K, V]) IteratorAscFrom(key K, match bool, f func(key K, value V) bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	node, found := that.tree.Ceiling(key)
+	if !found || (match && that.comparator(key, node.Key.(K)) != 0) {
+		return
+	}
+	that.tree.IteratorAscFrom(node.Key, true, func(key, value interface{}) bool {
+		return f(key.(K), treeValue[V](value))
+	})
+}
+
+// IteratorDesc 按照key的降序迭代
+func (that *TreeMap[K, V]) IteratorDesc(f func(key K, value V) bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	that.tree.IteratorDesc(func(key, value interface{}) bool {
+		return f(key.(K), treeValue[V](value))
+	})
+}
+
+// IteratorDescFrom 从key开始按照降序迭代，match为true时key必须存在才会迭代，
+// 否则从小于等于key的最大的key开始迭代
+func (that *TreeMap[K, V]) IteratorDescFrom(key K, match bool, f func(key K, value V) bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	node, found := that.tree.Floor(key)
+	if !found || (match && that.comparator(key, node.Key.(K)) != 0) {
+		return
+	}
+	that.tree.IteratorDescFrom(node.Key, true, func(key, value interface{}) bool {
+		return f(key.(K), treeValue[V](value))
+	})
+}
+
+// Clear 清除map
+func (that *TreeMap[K, V]) Clear() {
+	that.mu.Lock()
+	that.tree.Clear()
+	that.mu.Unlock()
+}
+
+// Merge 合并两个map
+func (that *TreeMap[K, V]) Merge(other *TreeMap[K, V]) {
+	if other == that {
+		return
+	}
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	other.IteratorAsc(func(key K, value V) bool {
+		that.tree.Set(key, value)
+		return true
+	})
+}
+
+// 转换成字符串
+func (that *TreeMap[K, V]) String() string {
+	b, _ := that.MarshalJSON()
+	return dconv.UnsafeBytesToStr(b)
+}
+
+// MarshalJSON json序列化，按照key的升序输出，key会被转换成字符串
+func (that *TreeMap[K, V]) MarshalJSON() ([]byte, error) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return marshalOrderedJSON(func(f func(key K, value V) bool) {
+		that.tree.IteratorAsc(func(key, value interface{}) bool {
+			return f(key.(K), treeValue[V](value))
+		})
+	})
+}
@@ -0,0 +1,112 @@
+package dgeneric_test
+
+import (
+	"encoding/json"
+	"github.com/osgochina/donkeygo/container/dgeneric"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"strings"
+	"testing"
+)
+
+func Test_Array_Basic(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		a := dgeneric.NewArrayFrom([]int{0, 1, 2, 3}, true)
+		t.Assert(a.Len(), 4)
+		t.Assert(a.At(2), 2)
+		t.Assert(a.At(9), 0)
+		v, found := a.Get(9)
+		t.Assert(v, 0)
+		t.Assert(found, false)
+		t.Assert(a.Search(3), 3)
+		t.Assert(a.Contains(4), false)
+
+		t.Assert(a.Set(0, 100), nil)
+		t.AssertNE(a.Set(4, 100), nil)
+		t.Assert(a.InsertBefore(0, -1), nil)
+		t.Assert(a.InsertAfter(4, 4), nil)
+		t.Assert(a.Slice(), []int{-1, 100, 1, 2, 3, 4})
+		v, found = a.Remove(1)
+		t.Assert(v, 100)
+		t.Assert(found, true)
+		t.Assert(a.RemoveValue(-1), true)
+		t.Assert(a.RemoveValue(-1), false)
+		t.Assert(a.Slice(), []int{1, 2, 3, 4})
+
+		a.PushLeft(0).PushRight(5)
+		v, _ = a.PopLeft()
+		t.Assert(v, 0)
+		v, _ = a.PopRight()
+		t.Assert(v, 5)
+		t.Assert(a.PopLefts(2), []int{1, 2})
+		t.Assert(a.PopRights(5), []int{3, 4})
+		_, found = a.PopLeft()
+		t.Assert(found, false)
+		_, found = a.PopRand()
+		t.Assert(found, false)
+		t.Assert(a.IsEmpty(), true)
+	})
+}
+
+func Test_Array_Range(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		a := dgeneric.NewArrayFrom([]string{"a", "b", "c", "d", "e"})
+		t.Assert(a.Range(1, 3), []string{"b", "c"})
+		t.Assert(a.Range(-1, 10), []string{"a", "b", "c", "d", "e"})
+		t.Assert(a.Range(6), nil)
+		t.Assert(a.SubSlice(1, 2), []string{"b", "c"})
+		t.Assert(a.SubSlice(-2), []string{"d", "e"})
+		t.Assert(a.SubSlice(-1, -2), []string{"c", "d"})
+		t.Assert(a.SubSlice(10), nil)
+		t.Assert(a.Chunk(2), [][]string{{"a", "b"}, {"c", "d"}, {"e"}})
+		t.Assert(a.Chunk(0), nil)
+		t.Assert(a.Join("-"), "a-b-c-d-e")
+	})
+}
+
+func Test_Array_Func(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		a := dgeneric.NewArrayFrom([]int{3, 1, 2, 3, 1})
+		t.Assert(a.CountValues(), map[int]int{1: 2, 2: 1, 3: 2})
+		t.Assert(a.Clone().Unique().Slice(), []int{3, 1, 2})
+		t.Assert(a.Clone().SortFunc(func(v1, v2 int) bool { return v1 < v2 }).Slice(), []int{1, 1, 2, 3, 3})
+		t.Assert(a.Clone().Reverse().Slice(), []int{1, 3, 2, 1, 3})
+		t.Assert(a.Clone().Filter(func(v int) bool { return v == 3 }).Slice(), []int{1, 2, 1})
+		t.Assert(a.Clone().Walk(func(v int) int { return v * 10 }).Slice(), []int{30, 10, 20, 30, 10})
+		t.Assert(dgeneric.NewArrayFrom([]int{1}).Pad(3, 0).Slice(), []int{1, 0, 0})
+		t.Assert(dgeneric.NewArrayFrom([]int{1}).Pad(-3, 0).Slice(), []int{0, 0, 1})
+		b := dgeneric.NewArray[int]()
+		t.Assert(b.Fill(0, 2, 7), nil)
+		t.Assert(b.Merge(b).Slice(), []int{7, 7, 7, 7})
+		t.Assert(a.Shuffle().Len(), 5)
+		t.Assert(len(a.Rands(10)), 10)
+
+		keys := make([]int, 0)
+		a.IteratorDesc(func(k int, v int) bool {
+			keys = append(keys, k)
+			return k > 3
+		})
+		t.Assert(keys, []int{4, 3})
+	})
+}
+
+func Test_Array_Json(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		a := dgeneric.NewArrayFrom([]string{"a", `b"`, "1"})
+		t.Assert(a.String(), `["a","b\"",1]`)
+		b, err := json.Marshal(a)
+		t.Assert(err, nil)
+		t.Assert(string(b), `["a","b\"","1"]`)
+
+		var c dgeneric.Array[string]
+		t.Assert(json.Unmarshal(b, &c), nil)
+		t.Assert(c.Slice(), a.Slice())
+		t.AssertNE(json.Unmarshal([]byte(`[1]`), &c), nil)
+
+		type user struct {
+			Tags *dgeneric.Array[string]
+		}
+		var u user
+		t.Assert(json.Unmarshal([]byte(`{"Tags":["x","y"]}`), &u), nil)
+		t.Assert(strings.Join(u.Tags.Slice(), ","), "x,y")
+	})
+}
@@ -0,0 +1,107 @@
+package dgeneric_test
+
+import (
+	"encoding/json"
+	"github.com/osgochina/donkeygo/container/dgeneric"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func Test_HashMap_Basic(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var m dgeneric.HashMap[string, int]
+		m.Set("a", 1)
+		t.Assert(m.Get("a"), 1)
+		t.Assert(m.Get("b"), 0)
+		_, found := m.Search("b")
+		t.Assert(found, false)
+		t.Assert(m.GetOrSet("b", 2), 2)
+		t.Assert(m.GetOrSet("b", 3), 2)
+		t.Assert(m.SetIfNotExist("b", 3), false)
+		t.Assert(m.SetIfNotExist("c", 3), true)
+		t.Assert(m.GetOrSetFunc("d", func() int { return 4 }), 4)
+		t.Assert(m.GetOrSetFuncLock("d", func() int { return 5 }), 4)
+		t.Assert(m.SetIfNotExistFunc("e", func() int { return 5 }), true)
+		t.Assert(m.SetIfNotExistFuncLock("e", func() int { return 6 }), false)
+		t.Assert(m.Size(), 5)
+
+		keys := m.Keys()
+		sort.Strings(keys)
+		t.Assert(keys, []string{"a", "b", "c", "d", "e"})
+		values := m.Values()
+		sort.Ints(values)
+		t.Assert(values, []int{1, 2, 3, 4, 5})
+
+		t.Assert(m.Remove("a"), 1)
+		t.Assert(m.Remove("a"), 0)
+		m.Removes([]string{"b", "c"})
+		t.Assert(m.Map(), map[string]int{"d": 4, "e": 5})
+		t.Assert(len(m.Pops(1)), 1)
+		_, _, found = m.Pop()
+		t.Assert(found, true)
+		_, _, found = m.Pop()
+		t.Assert(found, false)
+		t.Assert(m.IsEmpty(), true)
+	})
+}
+
+func Test_HashMap_Safe(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		m := dgeneric.NewHashMap[int, int](true)
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					m.Set(i*100+j, j)
+					m.GetOrSetFuncLock(j, func() int { return -1 })
+				}
+			}(i)
+		}
+		wg.Wait()
+		t.Assert(m.Size(), 1000)
+
+		c := m.Clone(false)
+		c.Clear()
+		t.Assert(m.Size(), 1000)
+		data := m.Map()
+		data[-1] = -1
+		t.Assert(m.Contains(-1), false)
+	})
+}
+
+func Test_HashMap_Merge(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		m1 := dgeneric.NewHashMapFrom(map[string]string{"a": "1"})
+		m2 := dgeneric.NewHashMapFrom(map[string]string{"a": "2", "b": "3"})
+		m1.Merge(m2)
+		m1.Merge(m1)
+		t.Assert(m1.Map(), map[string]string{"a": "2", "b": "3"})
+		m1.Replace(map[string]string{"c": "4"})
+		t.Assert(m1.MapCopy(), map[string]string{"c": "4"})
+		m1.LockFunc(func(m map[string]string) {
+			m["d"] = "5"
+		})
+		m1.RLockFunc(func(m map[string]string) {
+			t.Assert(len(m), 2)
+		})
+	})
+}
+
+func Test_HashMap_Json(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		m := dgeneric.NewHashMapFrom(map[int]string{1: "a", 2: "b"})
+		b, err := json.Marshal(m)
+		t.Assert(err, nil)
+		t.Assert(string(b), `{"1":"a","2":"b"}`)
+		t.Assert(m.String(), `{"1":"a","2":"b"}`)
+
+		var m2 dgeneric.HashMap[int, string]
+		t.Assert(json.Unmarshal(b, &m2), nil)
+		t.Assert(m2.Map(), m.Map())
+		t.AssertNE(json.Unmarshal([]byte(`{"x":"a"}`), &m2), nil)
+	})
+}
@@ -0,0 +1,78 @@
+package dgeneric_test
+
+import (
+	"encoding/json"
+	"github.com/osgochina/donkeygo/container/dgeneric"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"testing"
+)
+
+func Test_ListMap_Basic(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var m dgeneric.ListMap[string, int]
+		m.Set("c", 1)
+		m.Set("a", 2)
+		m.Set("b", 3)
+		m.Set("a", 4)
+		t.Assert(m.Keys(), []string{"c", "a", "b"})
+		t.Assert(m.Values(), []int{1, 4, 3})
+		t.Assert(m.Get("a"), 4)
+		t.Assert(m.GetOrSet("d", 5), 5)
+		t.Assert(m.SetIfNotExist("d", 6), false)
+		t.Assert(m.GetOrSetFunc("e", func() int { return 6 }), 6)
+		t.Assert(m.SetIfNotExistFuncLock("f", func() int { return 7 }), true)
+		t.Assert(m.Keys(), []string{"c", "a", "b", "d", "e", "f"})
+
+		t.Assert(m.Remove("a"), 4)
+		m.Removes([]string{"d", "x"})
+		t.Assert(m.Keys(), []string{"c", "b", "e", "f"})
+
+		key, value, found := m.Pop()
+		t.Assert(key, "c")
+		t.Assert(value, 1)
+		t.Assert(found, true)
+		t.Assert(m.Pops(2), map[string]int{"b": 3, "e": 6})
+		t.Assert(m.Size(), 1)
+		m.Clear()
+		_, _, found = m.Pop()
+		t.Assert(found, false)
+		t.Assert(m.IsEmpty(), true)
+	})
+}
+
+func Test_ListMap_Iterator(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		m := dgeneric.NewListMap[int, string](true)
+		for i := 0; i < 5; i++ {
+			m.Set(i, string(rune('a'+i)))
+		}
+		keys := make([]int, 0)
+		m.IteratorDesc(func(key int, value string) bool {
+			keys = append(keys, key)
+			return key > 3
+		})
+		t.Assert(keys, []int{4, 3})
+
+		c := m.Clone()
+		c.Merge(dgeneric.NewListMapFrom(map[int]string{9: "z", 0: "x"}))
+		t.Assert(c.Keys(), []int{0, 1, 2, 3, 4, 9})
+		t.Assert(c.Get(0), "x")
+		t.Assert(m.Get(0), "a")
+	})
+}
+
+func Test_ListMap_Json(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		m := dgeneric.NewListMap[string, int]()
+		m.Set("b", 1)
+		m.Set("a", 2)
+		b, err := json.Marshal(m)
+		t.Assert(err, nil)
+		t.Assert(string(b), `{"b":1,"a":2}`)
+		t.Assert(m.String(), `{"b":1,"a":2}`)
+
+		var m2 dgeneric.ListMap[string, int]
+		t.Assert(json.Unmarshal(b, &m2), nil)
+		t.Assert(m2.Map(), map[string]int{"a": 2, "b": 1})
+	})
+}
@@ -0,0 +1,73 @@
+package dgeneric_test
+
+import (
+	"encoding/json"
+	"github.com/osgochina/donkeygo/container/dgeneric"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"sort"
+	"testing"
+)
+
+func Test_Set_Basic(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var s dgeneric.Set[int]
+		s.Add(1, 2, 3)
+		t.Assert(s.Size(), 3)
+		t.Assert(s.Contains(2), true)
+		t.Assert(s.AddIfNotExist(2), false)
+		t.Assert(s.AddIfNotExist(4), true)
+		t.Assert(s.AddIfNotExistFunc(5, func() bool { return false }), false)
+		t.Assert(s.AddIfNotExistFuncLock(5, func() bool { return true }), true)
+		s.Remove(1)
+		items := s.Slice()
+		sort.Ints(items)
+		t.Assert(items, []int{2, 3, 4, 5})
+
+		s.Walk(func(item int) int { return item * 10 })
+		t.Assert(s.Contains(20), true)
+		item, found := s.Pop()
+		t.Assert(found, true)
+		t.Assert(s.Contains(item), false)
+		t.Assert(len(s.Pops(-1)), 3)
+		_, found = s.Pop()
+		t.Assert(found, false)
+		t.Assert(s.IsEmpty(), true)
+	})
+}
+
+func Test_Set_Operation(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		s1 := dgeneric.NewSetFrom([]string{"a", "b", "c"}, true)
+		s2 := dgeneric.NewSetFrom([]string{"b", "c", "d"}, true)
+		full := dgeneric.NewSetFrom([]string{"a", "b", "c", "d", "e"})
+
+		t.Assert(s1.Union(s2).Equal(dgeneric.NewSetFrom([]string{"a", "b", "c", "d"})), true)
+		t.Assert(s1.Diff(s2).Slice(), []string{"a"})
+		t.Assert(s1.Diff(s1).Size(), 0)
+		t.Assert(s1.Intersect(s2).Equal(dgeneric.NewSetFrom([]string{"b", "c"})), true)
+		t.Assert(s1.Intersect(s1).Equal(s1), true)
+		t.Assert(s1.Complement(full).Equal(dgeneric.NewSetFrom([]string{"d", "e"})), true)
+		t.Assert(s1.IsSubsetOf(full), true)
+		t.Assert(full.IsSubsetOf(s1), false)
+		t.Assert(s1.Equal(s2), false)
+
+		s1.Merge(s2, s1)
+		t.Assert(s1.Size(), 4)
+	})
+}
+
+func Test_Set_Json(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		s := dgeneric.NewSetFrom([]string{"a"})
+		t.Assert(s.String(), `["a"]`)
+		t.Assert(s.Join(","), "a")
+		b, err := json.Marshal(s)
+		t.Assert(err, nil)
+		t.Assert(string(b), `["a"]`)
+
+		var s2 dgeneric.Set[string]
+		t.Assert(json.Unmarshal([]byte(`["a","b","a"]`), &s2), nil)
+		t.Assert(s2.Size(), 2)
+		t.AssertNE(json.Unmarshal([]byte(`[1]`), &s2), nil)
+	})
+}
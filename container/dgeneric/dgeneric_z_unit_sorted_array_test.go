@@ -0,0 +1,100 @@
+package dgeneric_test
+
+import (
+	"encoding/json"
+	"github.com/osgochina/donkeygo/container/dgeneric"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"strings"
+	"testing"
+)
+
+func Test_SortedArray_Basic(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		a := dgeneric.NewSortedArrayFrom([]int{5, 3, 1, 3}, dgeneric.Compare[int], true)
+		t.Assert(a.Slice(), []int{1, 3, 3, 5})
+		a.Add(4, 0, 6)
+		t.Assert(a.Slice(), []int{0, 1, 3, 3, 4, 5, 6})
+		t.Assert(a.Search(4), 4)
+		t.Assert(a.Search(2), -1)
+		t.Assert(a.Contains(6), true)
+		t.Assert(a.At(0), 0)
+
+		a.SetUnique(true)
+		t.Assert(a.Slice(), []int{0, 1, 3, 4, 5, 6})
+		a.Add(3, 2)
+		t.Assert(a.Slice(), []int{0, 1, 2, 3, 4, 5, 6})
+
+		t.Assert(a.RemoveValue(2), true)
+		t.Assert(a.RemoveValue(2), false)
+		v, found := a.PopLeft()
+		t.Assert(v, 0)
+		t.Assert(found, true)
+		v, _ = a.PopRight()
+		t.Assert(v, 6)
+		t.Assert(a.PopLefts(2), []int{1, 3})
+		t.Assert(a.PopRights(1), []int{5})
+		t.Assert(a.Slice(), []int{4})
+		v, _ = a.Remove(0)
+		t.Assert(v, 4)
+		_, found = a.Remove(0)
+		t.Assert(found, false)
+		t.Assert(a.IsEmpty(), true)
+	})
+}
+
+func Test_SortedArray_Func(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		desc := func(a, b string) int { return strings.Compare(b, a) }
+		a := dgeneric.NewSortedArrayFromCopy([]string{"a", "c", "b"}, desc)
+		t.Assert(a.Slice(), []string{"c", "b", "a"})
+		t.Assert(a.Join(","), "c,b,a")
+		t.Assert(a.String(), `["c","b","a"]`)
+		t.Assert(a.Range(1), []string{"b", "a"})
+		t.Assert(a.SubSlice(0, 2), []string{"c", "b"})
+		t.Assert(a.Chunk(2), [][]string{{"c", "b"}, {"a"}})
+
+		b := a.Clone()
+		b.Merge(dgeneric.NewSortedArrayFrom([]string{"d"}, desc))
+		t.Assert(b.Slice(), []string{"d", "c", "b", "a"})
+		t.Assert(a.Len(), 3)
+
+		b.Walk(func(v string) string { return strings.Repeat(v, 2) })
+		t.Assert(b.Slice(), []string{"dd", "cc", "bb", "aa"})
+		b.Clear()
+		t.Assert(b.Len(), 0)
+	})
+}
+
+func Test_SortedArray_Json(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		a := dgeneric.NewSortedArrayFrom([]int{3, 1, 2}, dgeneric.Compare[int])
+		b, err := json.Marshal(a)
+		t.Assert(err, nil)
+		t.Assert(string(b), `[1,2,3]`)
+
+		c := dgeneric.NewSortedArray(dgeneric.Compare[int])
+		t.Assert(json.Unmarshal([]byte(`[9,8,7]`), c), nil)
+		t.Assert(c.Slice(), []int{7, 8, 9})
+	})
+}
+
+func Test_SortedArray_Filter(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		a := dgeneric.NewSortedArrayFrom([]int{-1, 0, 2, 0, 3}, dgeneric.Compare[int])
+		a.Filter(func(v int) bool { return v == 0 })
+		t.Assert(a.Slice(), []int{-1, 2, 3})
+		t.Assert(len(a.Rands(5)), 5)
+		t.Assert(a.Contains(a.Rands(1)[0]), true)
+
+		// LockFunc中修改了元素之后重新排序
+		a.LockFunc(func(array []int) {
+			array[0] = 10
+		})
+		a.Sort()
+		t.Assert(a.Slice(), []int{2, 3, 10})
+		t.Assert(len(a.PopRands(2)), 2)
+		t.Assert(a.Len(), 1)
+		t.Assert(len(a.PopRands(2)), 1)
+		t.Assert(a.PopRands(1), nil)
+	})
+}
@@ -0,0 +1,99 @@
+package dgeneric_test
+
+import (
+	"encoding/json"
+	"github.com/osgochina/donkeygo/container/dgeneric"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"testing"
+)
+
+func Test_TreeMap_Basic(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		m := dgeneric.NewTreeMapFrom(dgeneric.Compare[int], map[int]string{5: "e", 1: "a", 3: "c"}, true)
+		m.Set(2, "b")
+		t.Assert(m.Keys(), []int{1, 2, 3, 5})
+		t.Assert(m.Values(), []string{"a", "b", "c", "e"})
+		t.Assert(m.Get(3), "c")
+		t.Assert(m.Get(4), "")
+		t.Assert(m.GetOrSet(4, "d"), "d")
+		t.Assert(m.SetIfNotExist(4, "x"), false)
+		t.Assert(m.GetOrSetFuncLock(6, func() string { return "f" }), "f")
+		t.Assert(m.Size(), 6)
+
+		key, value, found := m.Left()
+		t.Assert(key, 1)
+		t.Assert(value, "a")
+		t.Assert(found, true)
+		key, _, _ = m.Right()
+		t.Assert(key, 6)
+
+		t.Assert(m.Remove(4), "d")
+		key, _, found = m.Floor(4)
+		t.Assert(key, 3)
+		t.Assert(found, true)
+		key, _, _ = m.Ceiling(4)
+		t.Assert(key, 5)
+		_, _, found = m.Ceiling(7)
+		t.Assert(found, false)
+
+		m.Removes([]int{1, 2, 3, 5, 6})
+		t.Assert(m.IsEmpty(), true)
+		_, _, found = m.Left()
+		t.Assert(found, false)
+	})
+}
+
+func Test_TreeMap_Iterator(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		m := dgeneric.NewTreeMap[int, int](dgeneric.Compare[int])
+		for i := 0; i < 10; i += 2 {
+			m.Set(i, i*10)
+		}
+		keys := make([]int, 0)
+		m.IteratorAscFrom(3, false, func(key, value int) bool {
+			keys = append(keys, key)
+			return true
+		})
+		t.Assert(keys, []int{4, 6, 8})
+
+		keys = keys[:0]
+		m.IteratorAscFrom(3, true, func(key, value int) bool {
+			keys = append(keys, key)
+			return true
+		})
+		t.Assert(keys, []int{})
+
+		keys = keys[:0]
+		m.IteratorDescFrom(5, false, func(key, value int) bool {
+			keys = append(keys, key)
+			return key > 2
+		})
+		t.Assert(keys, []int{4, 2})
+
+		keys = keys[:0]
+		m.IteratorDesc(func(key, value int) bool {
+			keys = append(keys, key)
+			return true
+		})
+		t.Assert(keys, []int{8, 6, 4, 2, 0})
+
+		c := m.Clone()
+		c.Merge(dgeneric.NewTreeMapFrom(dgeneric.Compare[int], map[int]int{1: 1}))
+		t.Assert(c.Size(), 6)
+		t.Assert(m.Size(), 5)
+		c.Clear()
+		t.Assert(c.Size(), 0)
+	})
+}
+
+func Test_TreeMap_Json(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		m := dgeneric.NewTreeMap[string, int](func(a, b string) int { return dgeneric.Compare(b, a) })
+		m.Set("a", 1)
+		m.Set("b", 2)
+		b, err := json.Marshal(m)
+		t.Assert(err, nil)
+		t.Assert(string(b), `{"b":2,"a":1}`)
+		t.Assert(m.String(), `{"b":2,"a":1}`)
+	})
+}
@@ -0,0 +1,483 @@
+// Package dlru 提供同步的有界缓存容器，支持LRU、LFU、ARC和2Q淘汰策略。
+//
+// 缓存的所有操作都是O(1)的时间复杂度，不依赖定时器和后台协程，淘汰在写入时同步完成，
+// 过期的条目在访问时或者调用DeleteExpired时清理。缓存容量可以按条目数和字节大小同时限制，
+// 条目被淘汰或者过期清理时会调用Option.OnEvict回调。
+package dlru
+
+import (
+	"container/list"
+	"fmt"
+	"github.com/osgochina/donkeygo/internal/rwmutex"
+	"time"
+)
+
+// Policy 淘汰策略
+type Policy int
+
+const (
+	PolicyLRU Policy = iota // 淘汰最近最少使用的条目
+	PolicyLFU               // 淘汰使用次数最少的条目，使用次数相同时淘汰最久未使用的
+	PolicyARC               // 自适应替换缓存，根据命中情况自动调整最近使用和经常使用两部分的比例
+	Policy2Q                // 首次写入的条目进入FIFO队列，再次访问后才进入LRU主队列，能抵抗批量扫描
+)
+
+// String 返回淘汰策略的名称
+func (p Policy) String() string {
+	switch p {
+	case PolicyLRU:
+		return "LRU"
+	case PolicyLFU:
+		return "LFU"
+	case PolicyARC:
+		return "ARC"
+	case Policy2Q:
+		return "2Q"
+	}
+	return fmt.Sprintf("Policy(%d)", int(p))
+}
+
+// EvictReason 条目被移出缓存的原因
+type EvictReason int
+
+const (
+	EvictReasonCapacity EvictReason = iota + 1 // 超出容量被淘汰
+	EvictReasonExpired                         // 过期被清理
+)
+
+// Option 缓存的选项
+type Option struct {
+	Policy   Policy        // 淘汰策略，默认LRU
+	Capacity int           // 最多缓存的条目数，小于等于0表示不限制
+	MaxBytes int64         // 最多缓存的字节数，小于等于0表示不限制
+	TTL      time.Duration // 条目默认的有效期，小于等于0表示不过期
+
+	// Sizer 计算条目的字节大小，仅在MaxBytes大于0时使用，
+	// 默认string和[]byte类型的value按长度计算，其他类型按1计算
+	Sizer func(key, value interface{}) int64
+
+	// OnEvict 条目被淘汰或者过期清理时的回调，回调在缓存的锁之外执行，可以在回调中操作缓存，
+	// 调用Remove、Clear主动删除的条目不会触发回调
+	OnEvict func(key, value interface{}, reason EvictReason)
+}
+
+// Cache 有界缓存
+type Cache struct {
+	mu     rwmutex.RWMutex
+	option Option
+	data   map[interface{}]*entry
+	policy policy
+	bytes  int64
+}
+
+// 缓存条目
+type entry struct {
+	key    interface{}
+	value  interface{}
+	size   int64
+	expire int64         // 过期时间的纳秒时间戳，0表示不过期
+	list   *list.List    // 条目所在的淘汰策略链表
+	elem   *list.Element // 条目在链表中的节点
+	bucket *list.Element // LFU策略中条目所在的频次桶
+}
+
+// 被移出缓存的条目，用于在锁外执行回调
+type evictedEntry struct {
+	key    interface{}
+	value  interface{}
+	reason EvictReason
+}
+
+// 淘汰策略的实现，所有方法都在缓存的写锁内调用
+type policy interface {
+	admit(key interface{}) // 新的key写入之前调用，ARC和2Q根据历史记录调整状态
+	add(e *entry)          // 新条目写入
+	access(e *entry)       // 条目被访问或者更新
+	remove(e *entry)       // 条目被删除或者过期，不记录历史
+	evict() *entry         // 选出并移除需要淘汰的条目
+	clear()
+}
+
+// New 使用option创建缓存，safe参数表示是否并发安全
+func New(option Option, safe ...bool) *Cache {
+	if option.Sizer == nil {
+		option.Sizer = defaultSizer
+	}
+	c := &Cache{
+		mu:     rwmutex.Create(safe...),
+		option: option,
+		data:   make(map[interface{}]*entry),
+	}
+	switch option.Policy {
+	case PolicyLFU:
+		c.policy = newLfuPolicy()
+	case PolicyARC:
+		c.policy = newArcPolicy(c.limit)
+	case Policy2Q:
+		c.policy = newTwoQueuePolicy(c.limit)
+	default:
+		c.option.Policy = PolicyLRU
+		c.policy = newLruPolicy()
+	}
+	return c
+}
+
+// NewLRU 创建最多缓存capacity个条目的LRU缓存
+func NewLRU(capacity int, safe ...bool) *Cache {
+	return New(Option{Policy: PolicyLRU, Capacity: capacity}, safe...)
+}
+
+// NewLFU 创建最多缓存capacity个条目的LFU缓存
+func NewLFU(capacity int, safe ...bool) *Cache {
+	return New(Option{Policy: PolicyLFU, Capacity: capacity}, safe...)
+}
+
+// NewARC 创建最多缓存capacity个条目的ARC缓存
+func NewARC(capacity int, safe ...bool) *Cache {
+	return New(Option{Policy: PolicyARC, Capacity: capacity}, safe...)
+}
+
+// New2Q 创建最多缓存capacity个条目的2Q缓存
+func New2Q(capacity int, safe ...bool) *Cache {
+	return New(Option{Policy: Policy2Q, Capacity: capacity}, safe...)
+}
+
+// 默认的条目大小计算方法
+func defaultSizer(key, value interface{}) int64 {
+	switch v := value.(type) {
+	case string:
+		return int64(len(v))
+	case []byte:
+		return int64(len(v))
+	}
+	return 1
+}
+
+// Policy 返回缓存的淘汰策略
+func (that *Cache) Policy() Policy {
+	return that.option.Policy
+}
+
+// Set 写入条目，ttl为该条目的有效期，不传时使用Option.TTL，小于等于0表示不过期，
+// 超出容量时按照淘汰策略淘汰条目，字节大小超过MaxBytes的条目不会被缓存
+func (that *Cache) Set(key interface{}, value interface{}, ttl ...time.Duration) {
+	that.mu.Lock()
+	evicted := that.doSet(key, value, that.getExpire(ttl))
+	that.mu.Unlock()
+	that.notify(evicted)
+}
+
+// SetIfNotExist 如果key不存在或者已过期则写入并返回true，否则返回false
+func (that *Cache) SetIfNotExist(key interface{}, value interface{}, ttl ...time.Duration) bool {
+	that.mu.Lock()
+	e, evicted := that.doSearch(key)
+	ok := e == nil
+	if ok {
+		evicted = append(evicted, that.doSet(key, value, that.getExpire(ttl))...)
+	}
+	that.mu.Unlock()
+	that.notify(evicted)
+	return ok
+}
+
+// Get 获取key对应的值，不存在或者已过期时返回nil
+func (that *Cache) Get(key interface{}) interface{} {
+	value, _ := that.Search(key)
+	return value
+}
+
+// Search 查找key对应的值，found表示是否存在，查找到的条目会被记录为一次访问
+func (that *Cache) Search(key interface{}) (value interface{}, found bool) {
+	that.mu.Lock()
+	e, evicted := that.doSearch(key)
+	if e != nil {
+		that.policy.access(e)
+		value, found = e.value, true
+	}
+	that.mu.Unlock()
+	that.notify(evicted)
+	return
+}
+
+// Peek 查找key对应的值，与Search的区别是不会记录为一次访问，也不会清理过期的条目
+func (that *Cache) Peek(key interface{}) (value interface{}, found bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	if e, ok := that.data[key]; ok && !e.isExpired(time.Now().UnixNano()) {
+		return e.value, true
+	}
+	return nil, false
+}
+
+// Contains 判断key是否存在并且未过期，不会记录为一次访问
+func (that *Cache) Contains(key interface{}) bool {
+	_, found := that.Peek(key)
+	return found
+}
+
+// GetOrSet 获取key对应的值，不存在时写入value并返回value
+func (that *Cache) GetOrSet(key interface{}, value interface{}, ttl ...time.Duration) interface{} {
+	that.mu.Lock()
+	e, evicted := that.doSearch(key)
+	if e != nil {
+		that.policy.access(e)
+		value = e.value
+	} else {
+		evicted = append(evicted, that.doSet(key, value, that.getExpire(ttl))...)
+	}
+	that.mu.Unlock()
+	that.notify(evicted)
+	return value
+}
+
+// GetOrSetFunc 获取key对应的值，不存在时写入f返回的值并返回，
+// f在锁之外执行，如果执行f期间其他协程写入了该key，则返回已写入的值
+func (that *Cache) GetOrSetFunc(key interface{}, f func() interface{}, ttl ...time.Duration) interface{} {
+	if v, ok := that.Search(key); ok {
+		return v
+	}
+	return that.GetOrSet(key, f(), ttl...)
+}
+
+// GetExpire 返回key剩余的有效期，不过期时返回0，不存在或者已过期时返回-1
+func (that *Cache) GetExpire(key interface{}) time.Duration {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	now := time.Now().UnixNano()
+	if e, ok := that.data[key]; ok && !e.isExpired(now) {
+		if e.expire == 0 {
+			return 0
+		}
+		return time.Duration(e.expire - now)
+	}
+	return -1
+}
+
+// Remove 删除key，并返回删除的值
+func (that *Cache) Remove(key interface{}) (value interface{}) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if e, ok := that.data[key]; ok {
+		that.policy.remove(e)
+		that.deleteEntry(e)
+		value = e.value
+	}
+	return
+}
+
+// Removes 批量删除key
+func (that *Cache) Removes(keys []interface{}) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	for _, key := range keys {
+		if e, ok := that.data[key]; ok {
+			that.policy.remove(e)
+			that.deleteEntry(e)
+		}
+	}
+}
+
+// DeleteExpired 清理所有过期的条目，并返回清理的数量
+func (that *Cache) DeleteExpired() int {
+	var (
+		now     = time.Now().UnixNano()
+		evicted []evictedEntry
+	)
+	that.mu.Lock()
+	for _, e := range that.data {
+		if e.isExpired(now) {
+			that.policy.remove(e)
+			that.deleteEntry(e)
+			evicted = append(evicted, evictedEntry{e.key, e.value, EvictReasonExpired})
+		}
+	}
+	that.mu.Unlock()
+	that.notify(evicted)
+	return len(evicted)
+}
+
+// Resize 修改缓存的最大条目数，小于等于0表示不限制，缩小容量时会立即淘汰多出的条目
+func (that *Cache) Resize(capacity int) {
+	that.mu.Lock()
+	that.option.Capacity = capacity
+	evicted := that.shrink(0, 0)
+	that.mu.Unlock()
+	that.notify(evicted)
+}
+
+// Capacity 返回缓存的最大条目数
+func (that *Cache) Capacity() int {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return that.option.Capacity
+}
+
+// Size 返回缓存的条目数，包括已过期但未清理的条目
+func (that *Cache) Size() int {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return len(that.data)
+}
+
+// Bytes 返回缓存条目的字节大小之和
+func (that *Cache) Bytes() int64 {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return that.bytes
+}
+
+// Keys 返回所有未过期的key，顺序不固定
+func (that *Cache) Keys() []interface{} {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	var (
+		now  = time.Now().UnixNano()
+		keys = make([]interface{}, 0, len(that.data))
+	)
+	for key, e := range that.data {
+		if !e.isExpired(now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Map 返回所有未过期条目的副本
+func (that *Cache) Map() map[interface{}]interface{} {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	var (
+		now  = time.Now().UnixNano()
+		data = make(map[interface{}]interface{}, len(that.data))
+	)
+	for key, e := range that.data {
+		if !e.isExpired(now) {
+			data[key] = e.value
+		}
+	}
+	return data
+}
+
+// Clear 清空缓存，包括ARC和2Q的历史记录
+func (that *Cache) Clear() {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	that.data = make(map[interface{}]*entry)
+	that.bytes = 0
+	that.policy.clear()
+}
+
+// 写入条目，返回被淘汰的条目
+func (that *Cache) doSet(key interface{}, value interface{}, expire int64) (evicted []evictedEntry) {
+	size := that.option.Sizer(key, value)
+	if e, ok := that.data[key]; ok {
+		that.bytes += size - e.size
+		e.value, e.size, e.expire = value, size, expire
+		that.policy.access(e)
+		if that.tooLarge(size) {
+			that.policy.remove(e)
+			that.deleteEntry(e)
+			return []evictedEntry{{key, value, EvictReasonCapacity}}
+		}
+		return that.shrink(0, 0)
+	}
+	if that.tooLarge(size) {
+		return []evictedEntry{{key, value, EvictReasonCapacity}}
+	}
+	that.policy.admit(key)
+	evicted = that.shrink(1, size)
+	e := &entry{
+		key:    key,
+		value:  value,
+		size:   size,
+		expire: expire,
+	}
+	that.data[key] = e
+	that.bytes += size
+	that.policy.add(e)
+	return evicted
+}
+
+// 查找未过期的条目，过期的条目会被清理
+func (that *Cache) doSearch(key interface{}) (*entry, []evictedEntry) {
+	e, ok := that.data[key]
+	if !ok {
+		return nil, nil
+	}
+	if e.isExpired(time.Now().UnixNano()) {
+		that.policy.remove(e)
+		that.deleteEntry(e)
+		return nil, []evictedEntry{{e.key, e.value, EvictReasonExpired}}
+	}
+	return e, nil
+}
+
+// 按照淘汰策略淘汰条目，直到能够再容纳count个总大小为size的条目
+func (that *Cache) shrink(count int, size int64) (evicted []evictedEntry) {
+	for that.overflow(count, size) {
+		e := that.policy.evict()
+		if e == nil {
+			break
+		}
+		that.deleteEntry(e)
+		evicted = append(evicted, evictedEntry{e.key, e.value, EvictReasonCapacity})
+	}
+	return
+}
+
+// 再写入count个总大小为size的条目是否会超出容量
+func (that *Cache) overflow(count int, size int64) bool {
+	if that.option.Capacity > 0 && len(that.data)+count > that.option.Capacity {
+		return true
+	}
+	return that.option.MaxBytes > 0 && that.bytes+size > that.option.MaxBytes
+}
+
+// 条目的大小是否超过了整个缓存的字节容量
+func (that *Cache) tooLarge(size int64) bool {
+	return that.option.MaxBytes > 0 && size > that.option.MaxBytes
+}
+
+// 从数据中删除条目，条目需要已经从淘汰策略中移除
+func (that *Cache) deleteEntry(e *entry) {
+	delete(that.data, e.key)
+	that.bytes -= e.size
+}
+
+// ARC和2Q使用的缓存容量，没有限制条目数时使用当前的条目数
+func (that *Cache) limit() int {
+	if that.option.Capacity > 0 {
+		return that.option.Capacity
+	}
+	if len(that.data) > 0 {
+		return len(that.data)
+	}
+	return 1
+}
+
+// 计算过期时间
+func (that *Cache) getExpire(ttl []time.Duration) int64 {
+	duration := that.option.TTL
+	if len(ttl) > 0 {
+		duration = ttl[0]
+	}
+	if duration <= 0 {
+		return 0
+	}
+	return time.Now().UnixNano() + int64(duration)
+}
+
+// 在锁外执行淘汰回调
+func (that *Cache) notify(evicted []evictedEntry) {
+	if that.option.OnEvict == nil {
+		return
+	}
+	for _, e := range evicted {
+		that.option.OnEvict(e.key, e.value, e.reason)
+	}
+}
+
+// 条目是否已过期
+func (e *entry) isExpired(now int64) bool {
+	return e.expire > 0 && e.expire <= now
+}
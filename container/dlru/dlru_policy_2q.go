@@ -0,0 +1,92 @@
+package dlru
+
+import "container/list"
+
+const (
+	twoQueueInRatio  = 0.25 // in队列占缓存容量的比例
+	twoQueueOutRatio = 0.50 // out历史记录占缓存容量的比例
+)
+
+// 2Q淘汰策略，首次写入的条目进入FIFO的in队列，再次被访问时进入LRU的main队列，
+// in队列超出目标大小时淘汰其中最早写入的条目，并把key记录到out历史记录中，out中的key再次写入时直接进入main队列，
+// 只访问一次的批量数据只会在in队列中流转，不会把main队列中的热点数据挤出去
+type twoQueuePolicy struct {
+	limit   func() int
+	in      *list.List
+	main    *list.List
+	out     *list.List
+	outKeys map[interface{}]*list.Element
+	hit     bool // 正在写入的key是否命中out历史记录
+}
+
+func newTwoQueuePolicy(limit func() int) *twoQueuePolicy {
+	return &twoQueuePolicy{
+		limit:   limit,
+		in:      list.New(),
+		main:    list.New(),
+		out:     list.New(),
+		outKeys: make(map[interface{}]*list.Element),
+	}
+}
+
+func (that *twoQueuePolicy) admit(key interface{}) {
+	that.hit = false
+	if elem, ok := that.outKeys[key]; ok {
+		that.out.Remove(elem)
+		delete(that.outKeys, key)
+		that.hit = true
+	}
+}
+
+func (that *twoQueuePolicy) add(e *entry) {
+	if that.hit {
+		e.list, e.elem = that.main, that.main.PushFront(e)
+	} else {
+		e.list, e.elem = that.in, that.in.PushFront(e)
+	}
+	that.hit = false
+}
+
+func (that *twoQueuePolicy) access(e *entry) {
+	if e.list == that.in {
+		that.in.Remove(e.elem)
+		e.list, e.elem = that.main, that.main.PushFront(e)
+		return
+	}
+	that.main.MoveToFront(e.elem)
+}
+
+func (that *twoQueuePolicy) remove(e *entry) {
+	e.list.Remove(e.elem)
+}
+
+func (that *twoQueuePolicy) evict() *entry {
+	if that.in.Len() > 0 && (that.in.Len() > that.size(twoQueueInRatio) || that.main.Len() == 0) {
+		e := that.in.Remove(that.in.Back()).(*entry)
+		that.outKeys[e.key] = that.out.PushFront(e.key)
+		for that.out.Len() > that.size(twoQueueOutRatio) {
+			delete(that.outKeys, that.out.Remove(that.out.Back()))
+		}
+		return e
+	}
+	if elem := that.main.Back(); elem != nil {
+		return that.main.Remove(elem).(*entry)
+	}
+	return nil
+}
+
+func (that *twoQueuePolicy) clear() {
+	that.in.Init()
+	that.main.Init()
+	that.out.Init()
+	that.outKeys = make(map[interface{}]*list.Element)
+	that.hit = false
+}
+
+// 按比例计算队列的目标大小
+func (that *twoQueuePolicy) size(ratio float64) int {
+	if size := int(float64(that.limit()) * ratio); size > 0 {
+		return size
+	}
+	return 1
+}
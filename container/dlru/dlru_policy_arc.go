@@ -0,0 +1,123 @@
+package dlru
+
+import "container/list"
+
+// ARC淘汰策略，t1保存只使用过一次的条目，t2保存使用过多次的条目，b1和b2分别保存从t1和t2淘汰的key，
+// 命中b1说明应该给t1更多的空间，命中b2说明应该给t2更多的空间，p是t1的目标大小
+type arcPolicy struct {
+	limit  func() int
+	p      int
+	t1     *list.List
+	t2     *list.List
+	b1     *list.List
+	b2     *list.List
+	b1Keys map[interface{}]*list.Element // b1中的key
+	b2Keys map[interface{}]*list.Element // b2中的key
+	hit    *list.List                    // 正在写入的key命中的历史记录链表，未命中时为nil
+}
+
+func newArcPolicy(limit func() int) *arcPolicy {
+	return &arcPolicy{
+		limit:  limit,
+		t1:     list.New(),
+		t2:     list.New(),
+		b1:     list.New(),
+		b2:     list.New(),
+		b1Keys: make(map[interface{}]*list.Element),
+		b2Keys: make(map[interface{}]*list.Element),
+	}
+}
+
+func (that *arcPolicy) admit(key interface{}) {
+	that.hit = nil
+	if elem, ok := that.b1Keys[key]; ok {
+		// 命中b1，增大t1的目标大小
+		delta := 1
+		if that.b2.Len() > that.b1.Len() {
+			delta = that.b2.Len() / that.b1.Len()
+		}
+		if that.p += delta; that.p > that.limit() {
+			that.p = that.limit()
+		}
+		that.b1.Remove(elem)
+		delete(that.b1Keys, key)
+		that.hit = that.b1
+	} else if elem, ok = that.b2Keys[key]; ok {
+		// 命中b2，减小t1的目标大小
+		delta := 1
+		if that.b1.Len() > that.b2.Len() {
+			delta = that.b1.Len() / that.b2.Len()
+		}
+		if that.p -= delta; that.p < 0 {
+			that.p = 0
+		}
+		that.b2.Remove(elem)
+		delete(that.b2Keys, key)
+		that.hit = that.b2
+	}
+}
+
+func (that *arcPolicy) add(e *entry) {
+	if that.hit != nil {
+		e.list, e.elem = that.t2, that.t2.PushFront(e)
+	} else {
+		e.list, e.elem = that.t1, that.t1.PushFront(e)
+	}
+	that.hit = nil
+	that.trim()
+}
+
+func (that *arcPolicy) access(e *entry) {
+	if e.list == that.t1 {
+		that.t1.Remove(e.elem)
+		e.list, e.elem = that.t2, that.t2.PushFront(e)
+		return
+	}
+	that.t2.MoveToFront(e.elem)
+}
+
+func (that *arcPolicy) remove(e *entry) {
+	e.list.Remove(e.elem)
+}
+
+func (that *arcPolicy) evict() *entry {
+	var (
+		t1Len  = that.t1.Len()
+		victim *list.List
+		ghost  *list.List
+		keys   map[interface{}]*list.Element
+	)
+	if t1Len > 0 && (t1Len > that.p || (that.hit == that.b2 && t1Len == that.p) || that.t2.Len() == 0) {
+		victim, ghost, keys = that.t1, that.b1, that.b1Keys
+	} else if that.t2.Len() > 0 {
+		victim, ghost, keys = that.t2, that.b2, that.b2Keys
+	} else {
+		return nil
+	}
+	e := victim.Remove(victim.Back()).(*entry)
+	keys[e.key] = ghost.PushFront(e.key)
+	that.trim()
+	return e
+}
+
+func (that *arcPolicy) clear() {
+	that.p = 0
+	that.t1.Init()
+	that.t2.Init()
+	that.b1.Init()
+	that.b2.Init()
+	that.b1Keys = make(map[interface{}]*list.Element)
+	that.b2Keys = make(map[interface{}]*list.Element)
+	that.hit = nil
+}
+
+// 限制历史记录的长度，t1和b1的总长度不超过缓存容量，所有链表的总长度不超过两倍的缓存容量
+func (that *arcPolicy) trim() {
+	c := that.limit()
+	for that.b1.Len() > 0 && that.t1.Len()+that.b1.Len() > c {
+		delete(that.b1Keys, that.b1.Remove(that.b1.Back()))
+	}
+	for that.b2.Len() > 0 && that.t1.Len()+that.t2.Len()+that.b1.Len()+that.b2.Len() > 2*c {
+		delete(that.b2Keys, that.b2.Remove(that.b2.Back()))
+	}
+}
@@ -0,0 +1,69 @@
+package dlru
+
+import "container/list"
+
+// LFU淘汰策略，条目按使用次数放入频次桶，频次桶按使用次数从小到大排列，
+// 每个桶内的链表头部是最近使用的条目，淘汰使用次数最少的桶中最久未使用的条目
+type lfuPolicy struct {
+	buckets *list.List
+}
+
+// 使用次数相同的条目
+type lfuBucket struct {
+	freq    uint64
+	entries *list.List
+}
+
+func newLfuPolicy() *lfuPolicy {
+	return &lfuPolicy{buckets: list.New()}
+}
+
+func (that *lfuPolicy) admit(key interface{}) {}
+
+func (that *lfuPolicy) add(e *entry) {
+	front := that.buckets.Front()
+	if front == nil || front.Value.(*lfuBucket).freq != 1 {
+		front = that.buckets.PushFront(&lfuBucket{freq: 1, entries: list.New()})
+	}
+	that.push(e, front)
+}
+
+func (that *lfuPolicy) access(e *entry) {
+	var (
+		current = e.bucket
+		freq    = current.Value.(*lfuBucket).freq + 1
+		next    = current.Next()
+	)
+	if next == nil || next.Value.(*lfuBucket).freq != freq {
+		next = that.buckets.InsertAfter(&lfuBucket{freq: freq, entries: list.New()}, current)
+	}
+	that.remove(e)
+	that.push(e, next)
+}
+
+func (that *lfuPolicy) remove(e *entry) {
+	e.list.Remove(e.elem)
+	if e.list.Len() == 0 {
+		that.buckets.Remove(e.bucket)
+	}
+}
+
+func (that *lfuPolicy) evict() *entry {
+	front := that.buckets.Front()
+	if front == nil {
+		return nil
+	}
+	e := front.Value.(*lfuBucket).entries.Back().Value.(*entry)
+	that.remove(e)
+	return e
+}
+
+func (that *lfuPolicy) clear() {
+	that.buckets.Init()
+}
+
+// 把条目放入频次桶
+func (that *lfuPolicy) push(e *entry, bucket *list.Element) {
+	entries := bucket.Value.(*lfuBucket).entries
+	e.bucket, e.list, e.elem = bucket, entries, entries.PushFront(e)
+}
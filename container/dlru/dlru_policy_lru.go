@@ -0,0 +1,37 @@
+package dlru
+
+import "container/list"
+
+// LRU淘汰策略，链表头部是最近使用的条目，淘汰尾部的条目
+type lruPolicy struct {
+	list *list.List
+}
+
+func newLruPolicy() *lruPolicy {
+	return &lruPolicy{list: list.New()}
+}
+
+func (that *lruPolicy) admit(key interface{}) {}
+
+func (that *lruPolicy) add(e *entry) {
+	e.list, e.elem = that.list, that.list.PushFront(e)
+}
+
+func (that *lruPolicy) access(e *entry) {
+	that.list.MoveToFront(e.elem)
+}
+
+func (that *lruPolicy) remove(e *entry) {
+	that.list.Remove(e.elem)
+}
+
+func (that *lruPolicy) evict() *entry {
+	if elem := that.list.Back(); elem != nil {
+		return that.list.Remove(elem).(*entry)
+	}
+	return nil
+}
+
+func (that *lruPolicy) clear() {
+	that.list.Init()
+}
@@ -0,0 +1,224 @@
+package dlru_test
+
+import (
+	"github.com/osgochina/donkeygo/container/dlru"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_LRU(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var evicted []interface{}
+		c := dlru.New(dlru.Option{
+			Capacity: 3,
+			OnEvict: func(key, value interface{}, reason dlru.EvictReason) {
+				t.Assert(reason, dlru.EvictReasonCapacity)
+				evicted = append(evicted, key)
+			},
+		})
+		t.Assert(c.Policy(), dlru.PolicyLRU)
+		c.Set(1, "a")
+		c.Set(2, "b")
+		c.Set(3, "c")
+		t.Assert(c.Get(1), "a")
+		c.Set(4, "d")
+		t.Assert(evicted, []interface{}{2})
+		t.Assert(c.Contains(2), false)
+
+		// Peek and Contains do not change the order.
+		v, ok := c.Peek(3)
+		t.Assert(v, "c")
+		t.Assert(ok, true)
+		c.Set(5, "e")
+		t.Assert(evicted, []interface{}{2, 3})
+
+		t.Assert(c.SetIfNotExist(1, "x"), false)
+		t.Assert(c.GetOrSet(6, "f"), "f")
+		t.Assert(c.GetOrSetFunc(6, func() interface{} { return "g" }), "f")
+		t.Assert(c.Size(), 3)
+		t.Assert(c.Remove(6), "f")
+		t.Assert(c.Remove(6), nil)
+		t.Assert(c.Map(), map[interface{}]interface{}{4: "d", 5: "e"})
+
+		c.Resize(1)
+		t.Assert(c.Size(), 1)
+		t.Assert(c.Keys(), []interface{}{5})
+		c.Clear()
+		t.Assert(c.Size(), 0)
+	})
+}
+
+func Test_LFU(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		c := dlru.NewLFU(3)
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Set("c", 3)
+		c.Get("a")
+		c.Get("a")
+		c.Get("b")
+		c.Get("c")
+		// b and c have the same frequency, the least recently used b is evicted.
+		c.Set("d", 4)
+		t.Assert(c.Contains("b"), false)
+		t.Assert(c.Contains("a"), true)
+		// The new item has the least frequency.
+		c.Set("e", 5)
+		t.Assert(c.Contains("d"), false)
+		t.Assert(c.Contains("c"), true)
+		c.Remove("a")
+		c.Set("f", 6)
+		c.Set("g", 7)
+		t.Assert(c.Size(), 3)
+		t.Assert(c.Contains("c"), true)
+	})
+}
+
+// The hot keys survive a scan of keys that are accessed only once.
+func Test_ScanResistant(t *testing.T) {
+	for _, policy := range []dlru.Policy{dlru.PolicyARC, dlru.Policy2Q} {
+		dtest.C(t, func(t *dtest.T) {
+			c := dlru.New(dlru.Option{Policy: policy, Capacity: 100})
+			for round := 0; round < 3; round++ {
+				for i := 0; i < 50; i++ {
+					if _, ok := c.Search(i); !ok {
+						c.Set(i, i)
+					}
+				}
+			}
+			for i := 1000; i < 2000; i++ {
+				c.Set(i, i)
+			}
+			hits := 0
+			for i := 0; i < 50; i++ {
+				if c.Contains(i) {
+					hits++
+				}
+			}
+			t.AssertGT(hits, 40)
+			t.Assert(c.Size(), 100)
+		})
+	}
+}
+
+func Test_TTL(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var expired []interface{}
+		c := dlru.New(dlru.Option{
+			Capacity: 10,
+			TTL:      50 * time.Millisecond,
+			OnEvict: func(key, value interface{}, reason dlru.EvictReason) {
+				t.Assert(reason, dlru.EvictReasonExpired)
+				expired = append(expired, key)
+			},
+		}, true)
+		c.Set(1, 1)
+		c.Set(2, 2, time.Hour)
+		c.Set(3, 3, 0)
+		c.Set(4, 4)
+		t.Assert(c.GetExpire(1) > 0, true)
+		t.Assert(c.GetExpire(3), time.Duration(0))
+		t.Assert(c.GetExpire(5), time.Duration(-1))
+		time.Sleep(80 * time.Millisecond)
+
+		t.Assert(c.Get(1), nil)
+		t.Assert(expired, []interface{}{1})
+		t.Assert(c.Contains(4), false)
+		t.Assert(c.Size(), 3)
+		t.Assert(c.DeleteExpired(), 1)
+		t.Assert(expired, []interface{}{1, 4})
+		t.Assert(c.Get(2), 2)
+		t.Assert(c.Get(3), 3)
+		t.Assert(c.SetIfNotExist(1, 10), true)
+		t.Assert(c.Get(1), 10)
+	})
+}
+
+func Test_MaxBytes(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var evicted []interface{}
+		c := dlru.New(dlru.Option{
+			Policy:   dlru.PolicyARC,
+			MaxBytes: 10,
+			OnEvict: func(key, value interface{}, reason dlru.EvictReason) {
+				evicted = append(evicted, key)
+			},
+		})
+		c.Set("a", "1234")
+		c.Set("b", "1234")
+		t.Assert(c.Bytes(), 8)
+		c.Set("c", "123")
+		t.Assert(evicted, []interface{}{"a"})
+		t.Assert(c.Bytes(), 7)
+		c.Set("b", "1")
+		t.Assert(c.Bytes(), 4)
+
+		// Items larger than the whole cache are not cached.
+		c.Set("d", "12345678901")
+		t.Assert(evicted, []interface{}{"a", "d"})
+		t.Assert(c.Contains("d"), false)
+		t.Assert(c.Size(), 2)
+
+		sized := dlru.New(dlru.Option{
+			Policy:   dlru.Policy2Q,
+			Capacity: 100,
+			MaxBytes: 100,
+			Sizer: func(key, value interface{}) int64 {
+				return int64(value.(int))
+			},
+		})
+		for i := 1; i <= 20; i++ {
+			sized.Set(i, i)
+		}
+		t.AssertLE(sized.Bytes(), 100)
+		t.Assert(sized.Contains(20), true)
+	})
+}
+
+func Test_OnEvict_Reentrant(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var c *dlru.Cache
+		c = dlru.New(dlru.Option{
+			Capacity: 1,
+			OnEvict: func(key, value interface{}, reason dlru.EvictReason) {
+				c.Contains(key)
+			},
+		}, true)
+		c.Set(1, 1)
+		c.Set(2, 2)
+		t.Assert(c.Keys(), []interface{}{2})
+	})
+}
+
+func Test_Concurrent(t *testing.T) {
+	for _, policy := range []dlru.Policy{dlru.PolicyLRU, dlru.PolicyLFU, dlru.PolicyARC, dlru.Policy2Q} {
+		dtest.C(t, func(t *dtest.T) {
+			c := dlru.New(dlru.Option{Policy: policy, Capacity: 64, TTL: time.Second}, true)
+			wg := sync.WaitGroup{}
+			for g := 0; g < 4; g++ {
+				wg.Add(1)
+				go func(g int) {
+					defer wg.Done()
+					for i := 0; i < 2000; i++ {
+						key := (i * (g + 1)) % 200
+						switch i % 4 {
+						case 0:
+							c.Set(key, i)
+						case 1:
+							c.Get(key)
+						case 2:
+							c.GetOrSet(key, i)
+						default:
+							c.Remove(key)
+						}
+					}
+				}(g)
+			}
+			wg.Wait()
+			t.AssertLE(c.Size(), 64)
+			t.Assert(len(c.Keys()), c.Size())
+		})
+	}
+}
@@ -2,185 +2,123 @@ package dmap
 
 import (
 	"encoding/json"
+	"github.com/osgochina/donkeygo/container/dgeneric"
 	"github.com/osgochina/donkeygo/container/dvar"
 	"github.com/osgochina/donkeygo/internal/empty"
-	"github.com/osgochina/donkeygo/internal/rwmutex"
 	"github.com/osgochina/donkeygo/util/dconv"
 )
 
+// IntAnyMap 基于dgeneric.HashMap[int, interface{}]实现的hash表，零值可以直接使用
 type IntAnyMap struct {
-	mu   rwmutex.RWMutex
-	data map[int]interface{}
+	data *dgeneric.HashMap[int, interface{}]
 }
 
 // NewIntAnyMap 创建key值为int类型的hash表
 func NewIntAnyMap(safe ...bool) *IntAnyMap {
 	return &IntAnyMap{
-		mu:   rwmutex.Create(safe...),
-		data: make(map[int]interface{}),
+		data: dgeneric.NewHashMap[int, interface{}](safe...),
 	}
 }
 
 // NewIntAnyMapFrom 通过基础数据格式创建map
 func NewIntAnyMapFrom(data map[int]interface{}, safe ...bool) *IntAnyMap {
 	return &IntAnyMap{
-		mu:   rwmutex.Create(safe...),
-		data: data,
+		data: dgeneric.NewHashMapFrom(data, safe...),
 	}
 }
 
+// generic 返回底层的泛型hash表，零值的map在第一次使用时初始化
+func (that *IntAnyMap) generic() *dgeneric.HashMap[int, interface{}] {
+	if that.data == nil {
+		that.data = dgeneric.NewHashMap[int, interface{}]()
+	}
+	return that.data
+}
+
 // Iterator 迭代map
 func (that *IntAnyMap) Iterator(f func(k int, v interface{}) bool) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	for k, v := range that.data {
-		if !f(k, v) {
-			break
-		}
-	}
+	that.generic().Iterator(f)
 }
 
 // Clone 返回一个新的map对象，内容是当前map对象的副本
 func (that *IntAnyMap) Clone() *IntAnyMap {
-	return NewIntAnyMapFrom(that.MapCopy(), that.mu.IsSafe())
+	return &IntAnyMap{data: that.generic().Clone()}
 }
 
 // Map 返回基础map类型
 func (that *IntAnyMap) Map() map[int]interface{} {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	if !that.mu.IsSafe() {
-		return that.data
-	}
-	data := make(map[int]interface{}, len(that.data))
-	for k, v := range that.data {
-		data[k] = v
-	}
-	return data
+	return that.generic().Map()
 }
 
 // MapStrAny 把key转换成字符串返回
 func (that *IntAnyMap) MapStrAny() map[string]interface{} {
-	that.mu.RLock()
-	data := make(map[string]interface{}, len(that.data))
-	for k, v := range that.data {
-		data[dconv.String(k)] = v
-	}
-	that.mu.RUnlock()
+	var data map[string]interface{}
+	that.generic().RLockFunc(func(m map[int]interface{}) {
+		data = make(map[string]interface{}, len(m))
+		for k, v := range m {
+			data[dconv.String(k)] = v
+		}
+	})
 	return data
 }
 
 // MapCopy 复制一份数据返回
 func (that *IntAnyMap) MapCopy() map[int]interface{} {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	data := make(map[int]interface{}, len(that.data))
-	for k, v := range that.data {
-		data[k] = v
-	}
-	return data
+	return that.generic().MapCopy()
 }
 
 // FilterEmpty 删除空值
 func (that *IntAnyMap) FilterEmpty() {
-	that.mu.Lock()
-	for k, v := range that.data {
-		if empty.IsEmpty(v) {
-			delete(that.data, k)
+	that.generic().LockFunc(func(m map[int]interface{}) {
+		for k, v := range m {
+			if empty.IsEmpty(v) {
+				delete(m, k)
+			}
 		}
-	}
-	that.mu.Unlock()
+	})
 }
 
 // FilterNil 删除nil值
 func (that *IntAnyMap) FilterNil() {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	for k, v := range that.data {
-		if empty.IsNil(v) {
-			delete(that.data, k)
+	that.generic().LockFunc(func(m map[int]interface{}) {
+		for k, v := range m {
+			if empty.IsNil(v) {
+				delete(m, k)
+			}
 		}
-	}
+	})
 }
 
 // Set 写入单个数据
 func (that *IntAnyMap) Set(key int, val interface{}) {
-	that.mu.Lock()
-	if that.data == nil {
-		that.data = make(map[int]interface{})
-	}
-	that.data[key] = val
-	that.mu.Unlock()
+	that.generic().Set(key, val)
 }
 
 // Sets 写入数组
 func (that *IntAnyMap) Sets(data map[int]interface{}) {
-	that.mu.Lock()
-	if that.data == nil {
-		that.data = data
-	} else {
-		for k, v := range data {
-			that.data[k] = v
-		}
-	}
-	that.mu.Unlock()
+	that.generic().Sets(data)
 }
 
 // Search 搜索数据
 func (that *IntAnyMap) Search(key int) (value interface{}, found bool) {
-	that.mu.RLock()
-	if that.data != nil {
-		value, found = that.data[key]
-	}
-	that.mu.RUnlock()
-	return
+	return that.generic().Search(key)
 }
 
 // Get returns the value by given <key>.
 func (that *IntAnyMap) Get(key int) (value interface{}) {
-	that.mu.RLock()
-	if that.data != nil {
-		value, _ = that.data[key]
-	}
-	that.mu.RUnlock()
-	return
+	return that.generic().Get(key)
 }
 
 // Pop retrieves and deletes an item from the map.
 func (that *IntAnyMap) Pop() (key int, value interface{}) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	for key, value = range that.data {
-		delete(that.data, key)
-		return
-	}
+	key, value, _ = that.generic().Pop()
 	return
 }
 
 // Pops retrieves and deletes <size> items from the map.
 // It returns all items if size == -1.
 func (that *IntAnyMap) Pops(size int) map[int]interface{} {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if size > len(that.data) || size == -1 {
-		size = len(that.data)
-	}
-	if size == 0 {
-		return nil
-	}
-	var (
-		index  = 0
-		newMap = make(map[int]interface{}, size)
-	)
-	for k, v := range that.data {
-		delete(that.data, k)
-		newMap[k] = v
-		index++
-		if index == size {
-			break
-		}
-	}
-	return newMap
+	return that.generic().Pops(size)
 }
 
 // doSetWithLockCheck checks whether value of the key exists with mutex.Lock,
@@ -192,22 +130,21 @@ func (that *IntAnyMap) Pops(size int) map[int]interface{} {
 // and its return value will be set to the map with <key>.
 //
 // It returns value with given <key>.
-func (that *IntAnyMap) doSetWithLockCheck(key int, value interface{}) interface{} {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if that.data == nil {
-		that.data = make(map[int]interface{})
-	}
-	if v, ok := that.data[key]; ok {
-		return v
-	}
-	if f, ok := value.(func() interface{}); ok {
-		value = f()
-	}
-	if value != nil {
-		that.data[key] = value
-	}
-	return value
+func (that *IntAnyMap) doSetWithLockCheck(key int, value interface{}) (result interface{}) {
+	that.generic().LockFunc(func(m map[int]interface{}) {
+		if v, ok := m[key]; ok {
+			result = v
+			return
+		}
+		if f, ok := value.(func() interface{}); ok {
+			value = f()
+		}
+		if value != nil {
+			m[key] = value
+		}
+		result = value
+	})
+	return
 }
 
 // GetOrSet returns the value by key,
@@ -302,76 +239,33 @@ func (that *IntAnyMap) SetIfNotExistFuncLock(key int, f func() interface{}) bool
 
 // Removes batch deletes values of the map by keys.
 func (that *IntAnyMap) Removes(keys []int) {
-	that.mu.Lock()
-	if that.data != nil {
-		for _, key := range keys {
-			delete(that.data, key)
-		}
-	}
-	that.mu.Unlock()
+	that.generic().Removes(keys)
 }
 
 // Remove deletes value from map by given <key>, and return this deleted value.
 func (that *IntAnyMap) Remove(key int) (value interface{}) {
-	that.mu.Lock()
-	if that.data != nil {
-		var ok bool
-		if value, ok = that.data[key]; ok {
-			delete(that.data, key)
-		}
-	}
-	that.mu.Unlock()
-	return
+	return that.generic().Remove(key)
 }
 
 // Keys returns all keys of the map as a slice.
 func (that *IntAnyMap) Keys() []int {
-	that.mu.RLock()
-	var (
-		keys  = make([]int, len(that.data))
-		index = 0
-	)
-	for key := range that.data {
-		keys[index] = key
-		index++
-	}
-	that.mu.RUnlock()
-	return keys
+	return that.generic().Keys()
 }
 
 // Values returns all values of the map as a slice.
 func (that *IntAnyMap) Values() []interface{} {
-	that.mu.RLock()
-	var (
-		values = make([]interface{}, len(that.data))
-		index  = 0
-	)
-	for _, value := range that.data {
-		values[index] = value
-		index++
-	}
-	that.mu.RUnlock()
-	return values
+	return that.generic().Values()
 }
 
 // Contains checks whether a key exists.
 // It returns true if the <key> exists, or else false.
 func (that *IntAnyMap) Contains(key int) bool {
-	var ok bool
-	that.mu.RLock()
-	if that.data != nil {
-		_, ok = that.data[key]
-	}
-	that.mu.RUnlock()
-	return ok
+	return that.generic().Contains(key)
 }
 
 // Size returns the size of the map.
 func (that *IntAnyMap) Size() int {
-	that.mu.RLock()
-	length := len(that.data)
-	that.mu.RUnlock()
-	return length
+	return that.generic().Size()
 }
 
 // IsEmpty checks whether the map is empty.
@@ -382,59 +276,44 @@ func (that *IntAnyMap) IsEmpty() bool {
 
 // Clear deletes all data of the map, it will remake a new underlying data map.
 func (that *IntAnyMap) Clear() {
-	that.mu.Lock()
-	that.data = make(map[int]interface{})
-	that.mu.Unlock()
+	that.generic().Clear()
 }
 
 // Replace the data of the map with given <data>.
 func (that *IntAnyMap) Replace(data map[int]interface{}) {
-	that.mu.Lock()
-	that.data = data
-	that.mu.Unlock()
+	that.generic().Replace(data)
 }
 
 // LockFunc locks writing with given callback function <f> within RWMutex.Lock.
 func (that *IntAnyMap) LockFunc(f func(m map[int]interface{})) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	f(that.data)
+	that.generic().LockFunc(f)
 }
 
 // RLockFunc locks reading with given callback function <f> within RWMutex.RLock.
 func (that *IntAnyMap) RLockFunc(f func(m map[int]interface{})) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	f(that.data)
+	that.generic().RLockFunc(f)
 }
 
 // Flip exchanges key-value of the map to value-key.
 func (that *IntAnyMap) Flip() {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	n := make(map[int]interface{}, len(that.data))
-	for k, v := range that.data {
-		n[dconv.Int(v)] = k
-	}
-	that.data = n
+	that.generic().LockFunc(func(m map[int]interface{}) {
+		n := make(map[int]interface{}, len(m))
+		for k, v := range m {
+			n[dconv.Int(v)] = k
+		}
+		for k := range m {
+			delete(m, k)
+		}
+		for k, v := range n {
+			m[k] = v
+		}
+	})
 }
 
 // Merge merges two hash maps.
 // The <other> map will be merged into the map <m>.
 func (that *IntAnyMap) Merge(other *IntAnyMap) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if that.data == nil {
-		that.data = other.MapCopy()
-		return
-	}
-	if other != that {
-		other.mu.RLock()
-		defer other.mu.RUnlock()
-	}
-	for k, v := range other.data {
-		that.data[k] = v
-	}
+	that.generic().Merge(other.generic())
 }
 
 // String returns the map as a string.
@@ -445,38 +324,37 @@ func (that *IntAnyMap) String() string {
 
 // MarshalJSON implements the interface MarshalJSON for json.Marshal.
 func (that *IntAnyMap) MarshalJSON() ([]byte, error) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	return json.Marshal(that.data)
+	var (
+		b   []byte
+		err error
+	)
+	that.generic().RLockFunc(func(m map[int]interface{}) {
+		b, err = json.Marshal(m)
+	})
+	return b, err
 }
 
 // UnmarshalJSON implements the interface UnmarshalJSON for json.Unmarshal.
 func (that *IntAnyMap) UnmarshalJSON(b []byte) error {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if that.data == nil {
-		that.data = make(map[int]interface{})
-	}
-	if err := json.Unmarshal(b, &that.data); err != nil {
+	var data map[int]interface{}
+	if err := json.Unmarshal(b, &data); err != nil {
 		return err
 	}
+	that.generic().Sets(data)
 	return nil
 }
 
 // UnmarshalValue is an interface implement which sets any type of value for map.
 func (that *IntAnyMap) UnmarshalValue(value interface{}) (err error) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if that.data == nil {
-		that.data = make(map[int]interface{})
-	}
 	switch value.(type) {
 	case string, []byte:
-		return json.Unmarshal(dconv.Bytes(value), &that.data)
+		return that.UnmarshalJSON(dconv.Bytes(value))
 	default:
+		data := make(map[int]interface{})
 		for k, v := range dconv.Map(value) {
-			that.data[dconv.Int(k)] = v
+			data[dconv.Int(k)] = v
 		}
+		that.generic().Sets(data)
 	}
 	return
 }
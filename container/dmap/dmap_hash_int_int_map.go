@@ -7,15 +7,15 @@
 package dmap
 
 import (
+	"github.com/osgochina/donkeygo/container/dgeneric"
 	"github.com/osgochina/donkeygo/internal/empty"
 	"github.com/osgochina/donkeygo/internal/json"
-	"github.com/osgochina/donkeygo/internal/rwmutex"
 	"github.com/osgochina/donkeygo/util/dconv"
 )
 
+// IntIntMap 基于dgeneric.HashMap[int, int]实现的hash表，零值可以直接使用
 type IntIntMap struct {
-	mu   rwmutex.RWMutex
-	data map[int]int
+	data *dgeneric.HashMap[int, int]
 }
 
 // NewIntIntMap returns an empty IntIntMap object.
@@ -23,8 +23,7 @@ type IntIntMap struct {
 // which is false in default.
 func NewIntIntMap(safe ...bool) *IntIntMap {
 	return &IntIntMap{
-		mu:   rwmutex.Create(safe...),
-		data: make(map[int]int),
+		data: dgeneric.NewHashMap[int, int](safe...),
 	}
 }
 
@@ -33,195 +32,108 @@ func NewIntIntMap(safe ...bool) *IntIntMap {
 // there might be some concurrent-safe issues when changing the map outside.
 func NewIntIntMapFrom(data map[int]int, safe ...bool) *IntIntMap {
 	return &IntIntMap{
-		mu:   rwmutex.Create(safe...),
-		data: data,
+		data: dgeneric.NewHashMapFrom(data, safe...),
 	}
 }
 
+// generic 返回底层的泛型hash表，零值的map在第一次使用时初始化
+func (that *IntIntMap) generic() *dgeneric.HashMap[int, int] {
+	if that.data == nil {
+		that.data = dgeneric.NewHashMap[int, int]()
+	}
+	return that.data
+}
+
 // Iterator iterates the hash map readonly with custom callback function <f>.
 // If <f> returns true, then it continues iterating; or false to stop.
 func (that *IntIntMap) Iterator(f func(k int, v int) bool) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	for k, v := range that.data {
-		if !f(k, v) {
-			break
-		}
-	}
+	that.generic().Iterator(f)
 }
 
 // Clone returns a new hash map with copy of current map data.
 func (that *IntIntMap) Clone() *IntIntMap {
-	return NewIntIntMapFrom(that.MapCopy(), that.mu.IsSafe())
+	return &IntIntMap{data: that.generic().Clone()}
 }
 
 // Map returns the underlying data map.
 // Note that, if it's in concurrent-safe usage, it returns a copy of underlying data,
 // or else a pointer to the underlying data.
 func (that *IntIntMap) Map() map[int]int {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	if !that.mu.IsSafe() {
-		return that.data
-	}
-	data := make(map[int]int, len(that.data))
-	for k, v := range that.data {
-		data[k] = v
-	}
-	return data
+	return that.generic().Map()
 }
 
 // MapStrAny returns a copy of the underlying data of the map as map[string]interface{}.
 func (that *IntIntMap) MapStrAny() map[string]interface{} {
-	that.mu.RLock()
-	data := make(map[string]interface{}, len(that.data))
-	for k, v := range that.data {
-		data[dconv.String(k)] = v
-	}
-	that.mu.RUnlock()
+	var data map[string]interface{}
+	that.generic().RLockFunc(func(m map[int]int) {
+		data = make(map[string]interface{}, len(m))
+		for k, v := range m {
+			data[dconv.String(k)] = v
+		}
+	})
 	return data
 }
 
 // MapCopy returns a copy of the underlying data of the hash map.
 func (that *IntIntMap) MapCopy() map[int]int {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	data := make(map[int]int, len(that.data))
-	for k, v := range that.data {
-		data[k] = v
-	}
-	return data
+	return that.generic().MapCopy()
 }
 
 // FilterEmpty deletes all key-value pair of which the value is empty.
 // Values like: 0, nil, false, "", len(slice/map/chan) == 0 are considered empty.
 func (that *IntIntMap) FilterEmpty() {
-	that.mu.Lock()
-	for k, v := range that.data {
-		if empty.IsEmpty(v) {
-			delete(that.data, k)
+	that.generic().LockFunc(func(m map[int]int) {
+		for k, v := range m {
+			if empty.IsEmpty(v) {
+				delete(m, k)
+			}
 		}
-	}
-	that.mu.Unlock()
+	})
 }
 
 // Set sets key-value to the hash map.
 func (that *IntIntMap) Set(key int, val int) {
-	that.mu.Lock()
-	if that.data == nil {
-		that.data = make(map[int]int)
-	}
-	that.data[key] = val
-	that.mu.Unlock()
+	that.generic().Set(key, val)
 }
 
 // Sets batch sets key-values to the hash map.
 func (that *IntIntMap) Sets(data map[int]int) {
-	that.mu.Lock()
-	if that.data == nil {
-		that.data = data
-	} else {
-		for k, v := range data {
-			that.data[k] = v
-		}
-	}
-	that.mu.Unlock()
+	that.generic().Sets(data)
 }
 
 // Search searches the map with given <key>.
 // Second return parameter <found> is true if key was found, otherwise false.
 func (that *IntIntMap) Search(key int) (value int, found bool) {
-	that.mu.RLock()
-	if that.data != nil {
-		value, found = that.data[key]
-	}
-	that.mu.RUnlock()
-	return
+	return that.generic().Search(key)
 }
 
 // Get returns the value by given <key>.
 func (that *IntIntMap) Get(key int) (value int) {
-	that.mu.RLock()
-	if that.data != nil {
-		value, _ = that.data[key]
-	}
-	that.mu.RUnlock()
-	return
+	return that.generic().Get(key)
 }
 
 // Pop retrieves and deletes an item from the map.
 func (that *IntIntMap) Pop() (key, value int) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	for key, value = range that.data {
-		delete(that.data, key)
-		return
-	}
+	key, value, _ = that.generic().Pop()
 	return
 }
 
 // Pops retrieves and deletes <size> items from the map.
 // It returns all items if size == -1.
 func (that *IntIntMap) Pops(size int) map[int]int {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if size > len(that.data) || size == -1 {
-		size = len(that.data)
-	}
-	if size == 0 {
-		return nil
-	}
-	var (
-		index  = 0
-		newMap = make(map[int]int, size)
-	)
-	for k, v := range that.data {
-		delete(that.data, k)
-		newMap[k] = v
-		index++
-		if index == size {
-			break
-		}
-	}
-	return newMap
-}
-
-// doSetWithLockCheck checks whether value of the key exists with mutex.Lock,
-// if not exists, set value to the map with given <key>,
-// or else just return the existing value.
-//
-// It returns value with given <key>.
-func (that *IntIntMap) doSetWithLockCheck(key int, value int) int {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if that.data == nil {
-		that.data = make(map[int]int)
-	}
-	if v, ok := that.data[key]; ok {
-		return v
-	}
-	that.data[key] = value
-	return value
+	return that.generic().Pops(size)
 }
 
 // GetOrSet returns the value by key,
 // or sets value with given <value> if it does not exist and then returns this value.
 func (that *IntIntMap) GetOrSet(key int, value int) int {
-	if v, ok := that.Search(key); !ok {
-		return that.doSetWithLockCheck(key, value)
-	} else {
-		return v
-	}
+	return that.generic().GetOrSet(key, value)
 }
 
 // GetOrSetFunc returns the value by key,
 // or sets value with returned value of callback function <f> if it does not exist and returns this value.
 func (that *IntIntMap) GetOrSetFunc(key int, f func() int) int {
-	if v, ok := that.Search(key); !ok {
-		return that.doSetWithLockCheck(key, f())
-	} else {
-		return v
-	}
+	return that.generic().GetOrSetFunc(key, f)
 }
 
 // GetOrSetFuncLock returns the value by key,
@@ -230,41 +142,19 @@ func (that *IntIntMap) GetOrSetFunc(key int, f func() int) int {
 // GetOrSetFuncLock differs with GetOrSetFunc function is that it executes function <f>
 // with mutex.Lock of the hash map.
 func (that *IntIntMap) GetOrSetFuncLock(key int, f func() int) int {
-	if v, ok := that.Search(key); !ok {
-		that.mu.Lock()
-		defer that.mu.Unlock()
-		if that.data == nil {
-			that.data = make(map[int]int)
-		}
-		if v, ok = that.data[key]; ok {
-			return v
-		}
-		v = f()
-		that.data[key] = v
-		return v
-	} else {
-		return v
-	}
+	return that.generic().GetOrSetFuncLock(key, f)
 }
 
 // SetIfNotExist sets <value> to the map if the <key> does not exist, and then returns true.
 // It returns false if <key> exists, and <value> would be ignored.
 func (that *IntIntMap) SetIfNotExist(key int, value int) bool {
-	if !that.Contains(key) {
-		that.doSetWithLockCheck(key, value)
-		return true
-	}
-	return false
+	return that.generic().SetIfNotExist(key, value)
 }
 
 // SetIfNotExistFunc sets value with return value of callback function <f>, and then returns true.
 // It returns false if <key> exists, and <value> would be ignored.
 func (that *IntIntMap) SetIfNotExistFunc(key int, f func() int) bool {
-	if !that.Contains(key) {
-		that.doSetWithLockCheck(key, f())
-		return true
-	}
-	return false
+	return that.generic().SetIfNotExistFunc(key, f)
 }
 
 // SetIfNotExistFuncLock sets value with return value of callback function <f>, and then returns true.
@@ -273,92 +163,38 @@ func (that *IntIntMap) SetIfNotExistFunc(key int, f func() int) bool {
 // SetIfNotExistFuncLock differs with SetIfNotExistFunc function is that
 // it executes function <f> with mutex.Lock of the hash map.
 func (that *IntIntMap) SetIfNotExistFuncLock(key int, f func() int) bool {
-	if !that.Contains(key) {
-		that.mu.Lock()
-		defer that.mu.Unlock()
-		if that.data == nil {
-			that.data = make(map[int]int)
-		}
-		if _, ok := that.data[key]; !ok {
-			that.data[key] = f()
-		}
-		return true
-	}
-	return false
+	return that.generic().SetIfNotExistFuncLock(key, f)
 }
 
 // Removes batch deletes values of the map by keys.
 func (that *IntIntMap) Removes(keys []int) {
-	that.mu.Lock()
-	if that.data != nil {
-		for _, key := range keys {
-			delete(that.data, key)
-		}
-	}
-	that.mu.Unlock()
+	that.generic().Removes(keys)
 }
 
 // Remove deletes value from map by given <key>, and return this deleted value.
 func (that *IntIntMap) Remove(key int) (value int) {
-	that.mu.Lock()
-	if that.data != nil {
-		var ok bool
-		if value, ok = that.data[key]; ok {
-			delete(that.data, key)
-		}
-	}
-	that.mu.Unlock()
-	return
+	return that.generic().Remove(key)
 }
 
 // Keys returns all keys of the map as a slice.
 func (that *IntIntMap) Keys() []int {
-	that.mu.RLock()
-	var (
-		keys  = make([]int, len(that.data))
-		index = 0
-	)
-	for key := range that.data {
-		keys[index] = key
-		index++
-	}
-	that.mu.RUnlock()
-	return keys
+	return that.generic().Keys()
 }
 
 // Values returns all values of the map as a slice.
 func (that *IntIntMap) Values() []int {
-	that.mu.RLock()
-	var (
-		values = make([]int, len(that.data))
-		index  = 0
-	)
-	for _, value := range that.data {
-		values[index] = value
-		index++
-	}
-	that.mu.RUnlock()
-	return values
+	return that.generic().Values()
 }
 
 // Contains checks whether a key exists.
 // It returns true if the <key> exists, or else false.
 func (that *IntIntMap) Contains(key int) bool {
-	var ok bool
-	that.mu.RLock()
-	if that.data != nil {
-		_, ok = that.data[key]
-	}
-	that.mu.RUnlock()
-	return ok
+	return that.generic().Contains(key)
 }
 
 // Size returns the size of the map.
 func (that *IntIntMap) Size() int {
-	that.mu.RLock()
-	length := len(that.data)
-	that.mu.RUnlock()
-	return length
+	return that.generic().Size()
 }
 
 // IsEmpty checks whether the map is empty.
@@ -369,59 +205,44 @@ func (that *IntIntMap) IsEmpty() bool {
 
 // Clear deletes all data of the map, it will remake a new underlying data map.
 func (that *IntIntMap) Clear() {
-	that.mu.Lock()
-	that.data = make(map[int]int)
-	that.mu.Unlock()
+	that.generic().Clear()
 }
 
 // Replace the data of the map with given <data>.
 func (that *IntIntMap) Replace(data map[int]int) {
-	that.mu.Lock()
-	that.data = data
-	that.mu.Unlock()
+	that.generic().Replace(data)
 }
 
 // LockFunc locks writing with given callback function <f> within RWMutex.Lock.
 func (that *IntIntMap) LockFunc(f func(m map[int]int)) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	f(that.data)
+	that.generic().LockFunc(f)
 }
 
 // RLockFunc locks reading with given callback function <f> within RWMutex.RLock.
 func (that *IntIntMap) RLockFunc(f func(m map[int]int)) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	f(that.data)
+	that.generic().RLockFunc(f)
 }
 
 // Flip exchanges key-value of the map to value-key.
 func (that *IntIntMap) Flip() {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	n := make(map[int]int, len(that.data))
-	for k, v := range that.data {
-		n[v] = k
-	}
-	that.data = n
+	that.generic().LockFunc(func(m map[int]int) {
+		n := make(map[int]int, len(m))
+		for k, v := range m {
+			n[v] = k
+		}
+		for k := range m {
+			delete(m, k)
+		}
+		for k, v := range n {
+			m[k] = v
+		}
+	})
 }
 
 // Merge merges two hash maps.
 // The <other> map will be merged into the map <m>.
 func (that *IntIntMap) Merge(other *IntIntMap) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if that.data == nil {
-		that.data = other.MapCopy()
-		return
-	}
-	if other != that {
-		other.mu.RLock()
-		defer other.mu.RUnlock()
-	}
-	for k, v := range other.data {
-		that.data[k] = v
-	}
+	that.generic().Merge(other.generic())
 }
 
 // String returns the map as a string.
@@ -432,38 +253,37 @@ func (that *IntIntMap) String() string {
 
 // MarshalJSON implements the interface MarshalJSON for json.Marshal.
 func (that *IntIntMap) MarshalJSON() ([]byte, error) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	return json.Marshal(that.data)
+	var (
+		b   []byte
+		err error
+	)
+	that.generic().RLockFunc(func(m map[int]int) {
+		b, err = json.Marshal(m)
+	})
+	return b, err
 }
 
 // UnmarshalJSON implements the interface UnmarshalJSON for json.Unmarshal.
 func (that *IntIntMap) UnmarshalJSON(b []byte) error {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if that.data == nil {
-		that.data = make(map[int]int)
-	}
-	if err := json.UnmarshalUseNumber(b, &that.data); err != nil {
+	var data map[int]int
+	if err := json.UnmarshalUseNumber(b, &data); err != nil {
 		return err
 	}
+	that.generic().Sets(data)
 	return nil
 }
 
 // UnmarshalValue is an interface implement which sets any type of value for map.
 func (that *IntIntMap) UnmarshalValue(value interface{}) (err error) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if that.data == nil {
-		that.data = make(map[int]int)
-	}
 	switch value.(type) {
 	case string, []byte:
-		return json.UnmarshalUseNumber(dconv.Bytes(value), &that.data)
+		return that.UnmarshalJSON(dconv.Bytes(value))
 	default:
+		data := make(map[int]int)
 		for k, v := range dconv.Map(value) {
-			that.data[dconv.Int(k)] = dconv.Int(v)
+			data[dconv.Int(k)] = dconv.Int(v)
 		}
+		that.generic().Sets(data)
 	}
 	return
 }
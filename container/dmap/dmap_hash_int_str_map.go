@@ -7,15 +7,15 @@
 package dmap
 
 import (
+	"github.com/osgochina/donkeygo/container/dgeneric"
 	"github.com/osgochina/donkeygo/internal/empty"
 	"github.com/osgochina/donkeygo/internal/json"
-	"github.com/osgochina/donkeygo/internal/rwmutex"
 	"github.com/osgochina/donkeygo/util/dconv"
 )
 
+// IntStrMap 基于dgeneric.HashMap[int, string]实现的hash表，零值可以直接使用
 type IntStrMap struct {
-	mu   rwmutex.RWMutex
-	data map[int]string
+	data *dgeneric.HashMap[int, string]
 }
 
 // NewIntStrMap returns an empty IntStrMap object.
@@ -23,8 +23,7 @@ type IntStrMap struct {
 // which is false in default.
 func NewIntStrMap(safe ...bool) *IntStrMap {
 	return &IntStrMap{
-		mu:   rwmutex.Create(safe...),
-		data: make(map[int]string),
+		data: dgeneric.NewHashMap[int, string](safe...),
 	}
 }
 
@@ -33,195 +32,108 @@ func NewIntStrMap(safe ...bool) *IntStrMap {
 // there might be some concurrent-safe issues when changing the map outside.
 func NewIntStrMapFrom(data map[int]string, safe ...bool) *IntStrMap {
 	return &IntStrMap{
-		mu:   rwmutex.Create(safe...),
-		data: data,
+		data: dgeneric.NewHashMapFrom(data, safe...),
 	}
 }
 
+// generic 返回底层的泛型hash表，零值的map在第一次使用时初始化
+func (that *IntStrMap) generic() *dgeneric.HashMap[int, string] {
+	if that.data == nil {
+		that.data = dgeneric.NewHashMap[int, string]()
+	}
+	return that.data
+}
+
 // Iterator iterates the hash map readonly with custom callback function <f>.
 // If <f> returns true, then it continues iterating; or false to stop.
 func (that *IntStrMap) Iterator(f func(k int, v string) bool) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	for k, v := range that.data {
-		if !f(k, v) {
-			break
-		}
-	}
+	that.generic().Iterator(f)
 }
 
 // Clone returns a new hash map with copy of current map data.
 func (that *IntStrMap) Clone() *IntStrMap {
-	return NewIntStrMapFrom(that.MapCopy(), that.mu.IsSafe())
+	return &IntStrMap{data: that.generic().Clone()}
 }
 
 // Map returns the underlying data map.
 // Note that, if it's in concurrent-safe usage, it returns a copy of underlying data,
 // or else a pointer to the underlying data.
 func (that *IntStrMap) Map() map[int]string {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	if !that.mu.IsSafe() {
-		return that.data
-	}
-	data := make(map[int]string, len(that.data))
-	for k, v := range that.data {
-		data[k] = v
-	}
-	return data
+	return that.generic().Map()
 }
 
 // MapStrAny returns a copy of the underlying data of the map as map[string]interface{}.
 func (that *IntStrMap) MapStrAny() map[string]interface{} {
-	that.mu.RLock()
-	data := make(map[string]interface{}, len(that.data))
-	for k, v := range that.data {
-		data[dconv.String(k)] = v
-	}
-	that.mu.RUnlock()
+	var data map[string]interface{}
+	that.generic().RLockFunc(func(m map[int]string) {
+		data = make(map[string]interface{}, len(m))
+		for k, v := range m {
+			data[dconv.String(k)] = v
+		}
+	})
 	return data
 }
 
 // MapCopy returns a copy of the underlying data of the hash map.
 func (that *IntStrMap) MapCopy() map[int]string {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	data := make(map[int]string, len(that.data))
-	for k, v := range that.data {
-		data[k] = v
-	}
-	return data
+	return that.generic().MapCopy()
 }
 
 // FilterEmpty deletes all key-value pair of which the value is empty.
 // Values like: 0, nil, false, "", len(slice/map/chan) == 0 are considered empty.
 func (that *IntStrMap) FilterEmpty() {
-	that.mu.Lock()
-	for k, v := range that.data {
-		if empty.IsEmpty(v) {
-			delete(that.data, k)
+	that.generic().LockFunc(func(m map[int]string) {
+		for k, v := range m {
+			if empty.IsEmpty(v) {
+				delete(m, k)
+			}
 		}
-	}
-	that.mu.Unlock()
+	})
 }
 
 // Set sets key-value to the hash map.
 func (that *IntStrMap) Set(key int, val string) {
-	that.mu.Lock()
-	if that.data == nil {
-		that.data = make(map[int]string)
-	}
-	that.data[key] = val
-	that.mu.Unlock()
+	that.generic().Set(key, val)
 }
 
 // Sets batch sets key-values to the hash map.
 func (that *IntStrMap) Sets(data map[int]string) {
-	that.mu.Lock()
-	if that.data == nil {
-		that.data = data
-	} else {
-		for k, v := range data {
-			that.data[k] = v
-		}
-	}
-	that.mu.Unlock()
+	that.generic().Sets(data)
 }
 
 // Search searches the map with given <key>.
 // Second return parameter <found> is true if key was found, otherwise false.
 func (that *IntStrMap) Search(key int) (value string, found bool) {
-	that.mu.RLock()
-	if that.data != nil {
-		value, found = that.data[key]
-	}
-	that.mu.RUnlock()
-	return
+	return that.generic().Search(key)
 }
 
 // Get returns the value by given <key>.
 func (that *IntStrMap) Get(key int) (value string) {
-	that.mu.RLock()
-	if that.data != nil {
-		value, _ = that.data[key]
-	}
-	that.mu.RUnlock()
-	return
+	return that.generic().Get(key)
 }
 
 // Pop retrieves and deletes an item from the map.
 func (that *IntStrMap) Pop() (key int, value string) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	for key, value = range that.data {
-		delete(that.data, key)
-		return
-	}
+	key, value, _ = that.generic().Pop()
 	return
 }
 
 // Pops retrieves and deletes <size> items from the map.
 // It returns all items if size == -1.
 func (that *IntStrMap) Pops(size int) map[int]string {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if size > len(that.data) || size == -1 {
-		size = len(that.data)
-	}
-	if size == 0 {
-		return nil
-	}
-	var (
-		index  = 0
-		newMap = make(map[int]string, size)
-	)
-	for k, v := range that.data {
-		delete(that.data, k)
-		newMap[k] = v
-		index++
-		if index == size {
-			break
-		}
-	}
-	return newMap
-}
-
-// doSetWithLockCheck checks whether value of the key exists with mutex.Lock,
-// if not exists, set value to the map with given <key>,
-// or else just return the existing value.
-//
-// It returns value with given <key>.
-func (that *IntStrMap) doSetWithLockCheck(key int, value string) string {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if that.data == nil {
-		that.data = make(map[int]string)
-	}
-	if v, ok := that.data[key]; ok {
-		return v
-	}
-	that.data[key] = value
-	return value
+	return that.generic().Pops(size)
 }
 
 // GetOrSet returns the value by key,
 // or sets value with given <value> if it does not exist and then returns this value.
 func (that *IntStrMap) GetOrSet(key int, value string) string {
-	if v, ok := that.Search(key); !ok {
-		return that.doSetWithLockCheck(key, value)
-	} else {
-		return v
-	}
+	return that.generic().GetOrSet(key, value)
 }
 
 // GetOrSetFunc returns the value by key,
 // or sets value with returned value of callback function <f> if it does not exist and returns this value.
 func (that *IntStrMap) GetOrSetFunc(key int, f func() string) string {
-	if v, ok := that.Search(key); !ok {
-		return that.doSetWithLockCheck(key, f())
-	} else {
-		return v
-	}
+	return that.generic().GetOrSetFunc(key, f)
 }
 
 // GetOrSetFuncLock returns the value by key,
@@ -230,41 +142,19 @@ func (that *IntStrMap) GetOrSetFunc(key int, f func() string) string {
 // GetOrSetFuncLock differs with GetOrSetFunc function is that it executes function <f>
 // with mutex.Lock of the hash map.
 func (that *IntStrMap) GetOrSetFuncLock(key int, f func() string) string {
-	if v, ok := that.Search(key); !ok {
-		that.mu.Lock()
-		defer that.mu.Unlock()
-		if that.data == nil {
-			that.data = make(map[int]string)
-		}
-		if v, ok = that.data[key]; ok {
-			return v
-		}
-		v = f()
-		that.data[key] = v
-		return v
-	} else {
-		return v
-	}
+	return that.generic().GetOrSetFuncLock(key, f)
 }
 
 // SetIfNotExist sets <value> to the map if the <key> does not exist, and then returns true.
 // It returns false if <key> exists, and <value> would be ignored.
 func (that *IntStrMap) SetIfNotExist(key int, value string) bool {
-	if !that.Contains(key) {
-		that.doSetWithLockCheck(key, value)
-		return true
-	}
-	return false
+	return that.generic().SetIfNotExist(key, value)
 }
 
 // SetIfNotExistFunc sets value with return value of callback function <f>, and then returns true.
 // It returns false if <key> exists, and <value> would be ignored.
 func (that *IntStrMap) SetIfNotExistFunc(key int, f func() string) bool {
-	if !that.Contains(key) {
-		that.doSetWithLockCheck(key, f())
-		return true
-	}
-	return false
+	return that.generic().SetIfNotExistFunc(key, f)
 }
 
 // SetIfNotExistFuncLock sets value with return value of callback function <f>, and then returns true.
@@ -273,92 +163,38 @@ func (that *IntStrMap) SetIfNotExistFunc(key int, f func() string) bool {
 // SetIfNotExistFuncLock differs with SetIfNotExistFunc function is that
 // it executes function <f> with mutex.Lock of the hash map.
 func (that *IntStrMap) SetIfNotExistFuncLock(key int, f func() string) bool {
-	if !that.Contains(key) {
-		that.mu.Lock()
-		defer that.mu.Unlock()
-		if that.data == nil {
-			that.data = make(map[int]string)
-		}
-		if _, ok := that.data[key]; !ok {
-			that.data[key] = f()
-		}
-		return true
-	}
-	return false
+	return that.generic().SetIfNotExistFuncLock(key, f)
 }
 
 // Removes batch deletes values of the map by keys.
 func (that *IntStrMap) Removes(keys []int) {
-	that.mu.Lock()
-	if that.data != nil {
-		for _, key := range keys {
-			delete(that.data, key)
-		}
-	}
-	that.mu.Unlock()
+	that.generic().Removes(keys)
 }
 
 // Remove deletes value from map by given <key>, and return this deleted value.
 func (that *IntStrMap) Remove(key int) (value string) {
-	that.mu.Lock()
-	if that.data != nil {
-		var ok bool
-		if value, ok = that.data[key]; ok {
-			delete(that.data, key)
-		}
-	}
-	that.mu.Unlock()
-	return
+	return that.generic().Remove(key)
 }
 
 // Keys returns all keys of the map as a slice.
 func (that *IntStrMap) Keys() []int {
-	that.mu.RLock()
-	var (
-		keys  = make([]int, len(that.data))
-		index = 0
-	)
-	for key := range that.data {
-		keys[index] = key
-		index++
-	}
-	that.mu.RUnlock()
-	return keys
+	return that.generic().Keys()
 }
 
 // Values returns all values of the map as a slice.
 func (that *IntStrMap) Values() []string {
-	that.mu.RLock()
-	var (
-		values = make([]string, len(that.data))
-		index  = 0
-	)
-	for _, value := range that.data {
-		values[index] = value
-		index++
-	}
-	that.mu.RUnlock()
-	return values
+	return that.generic().Values()
 }
 
 // Contains checks whether a key exists.
 // It returns true if the <key> exists, or else false.
 func (that *IntStrMap) Contains(key int) bool {
-	var ok bool
-	that.mu.RLock()
-	if that.data != nil {
-		_, ok = that.data[key]
-	}
-	that.mu.RUnlock()
-	return ok
+	return that.generic().Contains(key)
 }
 
 // Size returns the size of the map.
 func (that *IntStrMap) Size() int {
-	that.mu.RLock()
-	length := len(that.data)
-	that.mu.RUnlock()
-	return length
+	return that.generic().Size()
 }
 
 // IsEmpty checks whether the map is empty.
@@ -369,59 +205,44 @@ func (that *IntStrMap) IsEmpty() bool {
 
 // Clear deletes all data of the map, it will remake a new underlying data map.
 func (that *IntStrMap) Clear() {
-	that.mu.Lock()
-	that.data = make(map[int]string)
-	that.mu.Unlock()
+	that.generic().Clear()
 }
 
 // Replace the data of the map with given <data>.
 func (that *IntStrMap) Replace(data map[int]string) {
-	that.mu.Lock()
-	that.data = data
-	that.mu.Unlock()
+	that.generic().Replace(data)
 }
 
 // LockFunc locks writing with given callback function <f> within RWMutex.Lock.
 func (that *IntStrMap) LockFunc(f func(m map[int]string)) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	f(that.data)
+	that.generic().LockFunc(f)
 }
 
 // RLockFunc locks reading with given callback function <f> within RWMutex.RLock.
 func (that *IntStrMap) RLockFunc(f func(m map[int]string)) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	f(that.data)
+	that.generic().RLockFunc(f)
 }
 
 // Flip exchanges key-value of the map to value-key.
 func (that *IntStrMap) Flip() {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	n := make(map[int]string, len(that.data))
-	for k, v := range that.data {
-		n[dconv.Int(v)] = dconv.String(k)
-	}
-	that.data = n
+	that.generic().LockFunc(func(m map[int]string) {
+		n := make(map[int]string, len(m))
+		for k, v := range m {
+			n[dconv.Int(v)] = dconv.String(k)
+		}
+		for k := range m {
+			delete(m, k)
+		}
+		for k, v := range n {
+			m[k] = v
+		}
+	})
 }
 
 // Merge merges two hash maps.
 // The <other> map will be merged into the map <m>.
 func (that *IntStrMap) Merge(other *IntStrMap) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if that.data == nil {
-		that.data = other.MapCopy()
-		return
-	}
-	if other != that {
-		other.mu.RLock()
-		defer other.mu.RUnlock()
-	}
-	for k, v := range other.data {
-		that.data[k] = v
-	}
+	that.generic().Merge(other.generic())
 }
 
 // String returns the map as a string.
@@ -432,38 +253,37 @@ func (that *IntStrMap) String() string {
 
 // MarshalJSON implements the interface MarshalJSON for json.Marshal.
 func (that *IntStrMap) MarshalJSON() ([]byte, error) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	return json.Marshal(that.data)
+	var (
+		b   []byte
+		err error
+	)
+	that.generic().RLockFunc(func(m map[int]string) {
+		b, err = json.Marshal(m)
+	})
+	return b, err
 }
 
 // UnmarshalJSON implements the interface UnmarshalJSON for json.Unmarshal.
 func (that *IntStrMap) UnmarshalJSON(b []byte) error {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if that.data == nil {
-		that.data = make(map[int]string)
-	}
-	if err := json.UnmarshalUseNumber(b, &that.data); err != nil {
+	var data map[int]string
+	if err := json.UnmarshalUseNumber(b, &data); err != nil {
 		return err
 	}
+	that.generic().Sets(data)
 	return nil
 }
 
 // UnmarshalValue is an interface implement which sets any type of value for map.
 func (that *IntStrMap) UnmarshalValue(value interface{}) (err error) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if that.data == nil {
-		that.data = make(map[int]string)
-	}
 	switch value.(type) {
 	case string, []byte:
-		return json.UnmarshalUseNumber(dconv.Bytes(value), &that.data)
+		return that.UnmarshalJSON(dconv.Bytes(value))
 	default:
+		data := make(map[int]string)
 		for k, v := range dconv.Map(value) {
-			that.data[dconv.Int(k)] = dconv.String(v)
+			data[dconv.Int(k)] = dconv.String(v)
 		}
+		that.generic().Sets(data)
 	}
 	return
 }
@@ -8,15 +8,15 @@
 package dmap
 
 import (
+	"github.com/osgochina/donkeygo/container/dgeneric"
 	"github.com/osgochina/donkeygo/internal/empty"
 	"github.com/osgochina/donkeygo/internal/json"
-	"github.com/osgochina/donkeygo/internal/rwmutex"
 	"github.com/osgochina/donkeygo/util/dconv"
 )
 
+// StrIntMap 基于dgeneric.HashMap[string, int]实现的hash表，零值可以直接使用
 type StrIntMap struct {
-	mu   rwmutex.RWMutex
-	data map[string]int
+	data *dgeneric.HashMap[string, int]
 }
 
 // NewStrIntMap returns an empty StrIntMap object.
@@ -24,8 +24,7 @@ type StrIntMap struct {
 // which is false in default.
 func NewStrIntMap(safe ...bool) *StrIntMap {
 	return &StrIntMap{
-		mu:   rwmutex.Create(safe...),
-		data: make(map[string]int),
+		data: dgeneric.NewHashMap[string, int](safe...),
 	}
 }
 
@@ -34,197 +33,109 @@ func NewStrIntMap(safe ...bool) *StrIntMap {
 // there might be some concurrent-safe issues when changing the map outside.
 func NewStrIntMapFrom(data map[string]int, safe ...bool) *StrIntMap {
 	return &StrIntMap{
-		mu:   rwmutex.Create(safe...),
-		data: data,
+		data: dgeneric.NewHashMapFrom(data, safe...),
 	}
 }
 
+// generic 返回底层的泛型hash表，零值的map在第一次使用时初始化
+func (that *StrIntMap) generic() *dgeneric.HashMap[string, int] {
+	if that.data == nil {
+		that.data = dgeneric.NewHashMap[string, int]()
+	}
+	return that.data
+}
+
 // Iterator iterates the hash map readonly with custom callback function <f>.
 // If <f> returns true, then it continues iterating; or false to stop.
 func (that *StrIntMap) Iterator(f func(k string, v int) bool) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	for k, v := range that.data {
-		if !f(k, v) {
-			break
-		}
-	}
+	that.generic().Iterator(f)
 }
 
 // Clone returns a new hash map with copy of current map data.
 func (that *StrIntMap) Clone() *StrIntMap {
-	return NewStrIntMapFrom(that.MapCopy(), that.mu.IsSafe())
+	return &StrIntMap{data: that.generic().Clone()}
 }
 
 // Map returns the underlying data map.
 // Note that, if it's in concurrent-safe usage, it returns a copy of underlying data,
 // or else a pointer to the underlying data.
 func (that *StrIntMap) Map() map[string]int {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	if !that.mu.IsSafe() {
-		return that.data
-	}
-	data := make(map[string]int, len(that.data))
-	for k, v := range that.data {
-		data[k] = v
-	}
-	return data
+	return that.generic().Map()
 }
 
 // MapStrAny returns a copy of the underlying data of the map as map[string]interface{}.
 func (that *StrIntMap) MapStrAny() map[string]interface{} {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	data := make(map[string]interface{}, len(that.data))
-	for k, v := range that.data {
-		data[k] = v
-	}
+	var data map[string]interface{}
+	that.generic().RLockFunc(func(m map[string]int) {
+		data = make(map[string]interface{}, len(m))
+		for k, v := range m {
+			data[k] = v
+		}
+	})
 	return data
 }
 
 // MapCopy returns a copy of the underlying data of the hash map.
 func (that *StrIntMap) MapCopy() map[string]int {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	data := make(map[string]int, len(that.data))
-	for k, v := range that.data {
-		data[k] = v
-	}
-	return data
+	return that.generic().MapCopy()
 }
 
 // FilterEmpty deletes all key-value pair of which the value is empty.
 // Values like: 0, nil, false, "", len(slice/map/chan) == 0 are considered empty.
 func (that *StrIntMap) FilterEmpty() {
-	that.mu.Lock()
-	for k, v := range that.data {
-		if empty.IsEmpty(v) {
-			delete(that.data, k)
+	that.generic().LockFunc(func(m map[string]int) {
+		for k, v := range m {
+			if empty.IsEmpty(v) {
+				delete(m, k)
+			}
 		}
-	}
-	that.mu.Unlock()
+	})
 }
 
 // Set sets key-value to the hash map.
 func (that *StrIntMap) Set(key string, val int) {
-	that.mu.Lock()
-	if that.data == nil {
-		that.data = make(map[string]int)
-	}
-	that.data[key] = val
-	that.mu.Unlock()
+	that.generic().Set(key, val)
 }
 
 // Sets batch sets key-values to the hash map.
 func (that *StrIntMap) Sets(data map[string]int) {
-	that.mu.Lock()
-	if that.data == nil {
-		that.data = data
-	} else {
-		for k, v := range data {
-			that.data[k] = v
-		}
-	}
-	that.mu.Unlock()
+	that.generic().Sets(data)
 }
 
 // Search searches the map with given <key>.
 // Second return parameter <found> is true if key was found, otherwise false.
 func (that *StrIntMap) Search(key string) (value int, found bool) {
-	that.mu.RLock()
-	if that.data != nil {
-		value, found = that.data[key]
-	}
-	that.mu.RUnlock()
-	return
+	return that.generic().Search(key)
 }
 
 // Get returns the value by given <key>.
 func (that *StrIntMap) Get(key string) (value int) {
-	that.mu.RLock()
-	if that.data != nil {
-		value, _ = that.data[key]
-	}
-	that.mu.RUnlock()
-	return
+	return that.generic().Get(key)
 }
 
 // Pop retrieves and deletes an item from the map.
 func (that *StrIntMap) Pop() (key string, value int) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	for key, value = range that.data {
-		delete(that.data, key)
-		return
-	}
+	key, value, _ = that.generic().Pop()
 	return
 }
 
 // Pops retrieves and deletes <size> items from the map.
 // It returns all items if size == -1.
 func (that *StrIntMap) Pops(size int) map[string]int {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if size > len(that.data) || size == -1 {
-		size = len(that.data)
-	}
-	if size == 0 {
-		return nil
-	}
-	var (
-		index  = 0
-		newMap = make(map[string]int, size)
-	)
-	for k, v := range that.data {
-		delete(that.data, k)
-		newMap[k] = v
-		index++
-		if index == size {
-			break
-		}
-	}
-	return newMap
-}
-
-// doSetWithLockCheck checks whether value of the key exists with mutex.Lock,
-// if not exists, set value to the map with given <key>,
-// or else just return the existing value.
-//
-// It returns value with given <key>.
-func (that *StrIntMap) doSetWithLockCheck(key string, value int) int {
-	that.mu.Lock()
-	if that.data == nil {
-		that.data = make(map[string]int)
-	}
-	if v, ok := that.data[key]; ok {
-		that.mu.Unlock()
-		return v
-	}
-	that.data[key] = value
-	that.mu.Unlock()
-	return value
+	return that.generic().Pops(size)
 }
 
 // GetOrSet returns the value by key,
 // or sets value with given <value> if it does not exist and then returns this value.
 func (that *StrIntMap) GetOrSet(key string, value int) int {
-	if v, ok := that.Search(key); !ok {
-		return that.doSetWithLockCheck(key, value)
-	} else {
-		return v
-	}
+	return that.generic().GetOrSet(key, value)
 }
 
 // GetOrSetFunc returns the value by key,
 // or sets value with returned value of callback function <f> if it does not exist
 // and then returns this value.
 func (that *StrIntMap) GetOrSetFunc(key string, f func() int) int {
-	if v, ok := that.Search(key); !ok {
-		return that.doSetWithLockCheck(key, f())
-	} else {
-		return v
-	}
+	return that.generic().GetOrSetFunc(key, f)
 }
 
 // GetOrSetFuncLock returns the value by key,
@@ -234,41 +145,19 @@ func (that *StrIntMap) GetOrSetFunc(key string, f func() int) int {
 // GetOrSetFuncLock differs with GetOrSetFunc function is that it executes function <f>
 // with mutex.Lock of the hash map.
 func (that *StrIntMap) GetOrSetFuncLock(key string, f func() int) int {
-	if v, ok := that.Search(key); !ok {
-		that.mu.Lock()
-		defer that.mu.Unlock()
-		if that.data == nil {
-			that.data = make(map[string]int)
-		}
-		if v, ok = that.data[key]; ok {
-			return v
-		}
-		v = f()
-		that.data[key] = v
-		return v
-	} else {
-		return v
-	}
+	return that.generic().GetOrSetFuncLock(key, f)
 }
 
 // SetIfNotExist sets <value> to the map if the <key> does not exist, and then returns true.
 // It returns false if <key> exists, and <value> would be ignored.
 func (that *StrIntMap) SetIfNotExist(key string, value int) bool {
-	if !that.Contains(key) {
-		that.doSetWithLockCheck(key, value)
-		return true
-	}
-	return false
+	return that.generic().SetIfNotExist(key, value)
 }
 
 // SetIfNotExistFunc sets value with return value of callback function <f>, and then returns true.
 // It returns false if <key> exists, and <value> would be ignored.
 func (that *StrIntMap) SetIfNotExistFunc(key string, f func() int) bool {
-	if !that.Contains(key) {
-		that.doSetWithLockCheck(key, f())
-		return true
-	}
-	return false
+	return that.generic().SetIfNotExistFunc(key, f)
 }
 
 // SetIfNotExistFuncLock sets value with return value of callback function <f>, and then returns true.
@@ -277,92 +166,38 @@ func (that *StrIntMap) SetIfNotExistFunc(key string, f func() int) bool {
 // SetIfNotExistFuncLock differs with SetIfNotExistFunc function is that
 // it executes function <f> with mutex.Lock of the hash map.
 func (that *StrIntMap) SetIfNotExistFuncLock(key string, f func() int) bool {
-	if !that.Contains(key) {
-		that.mu.Lock()
-		defer that.mu.Unlock()
-		if that.data == nil {
-			that.data = make(map[string]int)
-		}
-		if _, ok := that.data[key]; !ok {
-			that.data[key] = f()
-		}
-		return true
-	}
-	return false
+	return that.generic().SetIfNotExistFuncLock(key, f)
 }
 
 // Removes batch deletes values of the map by keys.
 func (that *StrIntMap) Removes(keys []string) {
-	that.mu.Lock()
-	if that.data != nil {
-		for _, key := range keys {
-			delete(that.data, key)
-		}
-	}
-	that.mu.Unlock()
+	that.generic().Removes(keys)
 }
 
 // Remove deletes value from map by given <key>, and return this deleted value.
 func (that *StrIntMap) Remove(key string) (value int) {
-	that.mu.Lock()
-	if that.data != nil {
-		var ok bool
-		if value, ok = that.data[key]; ok {
-			delete(that.data, key)
-		}
-	}
-	that.mu.Unlock()
-	return
+	return that.generic().Remove(key)
 }
 
 // Keys returns all keys of the map as a slice.
 func (that *StrIntMap) Keys() []string {
-	that.mu.RLock()
-	var (
-		keys  = make([]string, len(that.data))
-		index = 0
-	)
-	for key := range that.data {
-		keys[index] = key
-		index++
-	}
-	that.mu.RUnlock()
-	return keys
+	return that.generic().Keys()
 }
 
 // Values returns all values of the map as a slice.
 func (that *StrIntMap) Values() []int {
-	that.mu.RLock()
-	var (
-		values = make([]int, len(that.data))
-		index  = 0
-	)
-	for _, value := range that.data {
-		values[index] = value
-		index++
-	}
-	that.mu.RUnlock()
-	return values
+	return that.generic().Values()
 }
 
 // Contains checks whether a key exists.
 // It returns true if the <key> exists, or else false.
 func (that *StrIntMap) Contains(key string) bool {
-	var ok bool
-	that.mu.RLock()
-	if that.data != nil {
-		_, ok = that.data[key]
-	}
-	that.mu.RUnlock()
-	return ok
+	return that.generic().Contains(key)
 }
 
 // Size returns the size of the map.
 func (that *StrIntMap) Size() int {
-	that.mu.RLock()
-	length := len(that.data)
-	that.mu.RUnlock()
-	return length
+	return that.generic().Size()
 }
 
 // IsEmpty checks whether the map is empty.
@@ -373,59 +208,44 @@ func (that *StrIntMap) IsEmpty() bool {
 
 // Clear deletes all data of the map, it will remake a new underlying data map.
 func (that *StrIntMap) Clear() {
-	that.mu.Lock()
-	that.data = make(map[string]int)
-	that.mu.Unlock()
+	that.generic().Clear()
 }
 
 // Replace the data of the map with given <data>.
 func (that *StrIntMap) Replace(data map[string]int) {
-	that.mu.Lock()
-	that.data = data
-	that.mu.Unlock()
+	that.generic().Replace(data)
 }
 
 // LockFunc locks writing with given callback function <f> within RWMutex.Lock.
 func (that *StrIntMap) LockFunc(f func(m map[string]int)) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	f(that.data)
+	that.generic().LockFunc(f)
 }
 
 // RLockFunc locks reading with given callback function <f> within RWMutex.RLock.
 func (that *StrIntMap) RLockFunc(f func(m map[string]int)) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	f(that.data)
+	that.generic().RLockFunc(f)
 }
 
 // Flip exchanges key-value of the map to value-key.
 func (that *StrIntMap) Flip() {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	n := make(map[string]int, len(that.data))
-	for k, v := range that.data {
-		n[dconv.String(v)] = dconv.Int(k)
-	}
-	that.data = n
+	that.generic().LockFunc(func(m map[string]int) {
+		n := make(map[string]int, len(m))
+		for k, v := range m {
+			n[dconv.String(v)] = dconv.Int(k)
+		}
+		for k := range m {
+			delete(m, k)
+		}
+		for k, v := range n {
+			m[k] = v
+		}
+	})
 }
 
 // Merge merges two hash maps.
 // The <other> map will be merged into the map <m>.
 func (that *StrIntMap) Merge(other *StrIntMap) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if that.data == nil {
-		that.data = other.MapCopy()
-		return
-	}
-	if other != that {
-		other.mu.RLock()
-		defer other.mu.RUnlock()
-	}
-	for k, v := range other.data {
-		that.data[k] = v
-	}
+	that.generic().Merge(other.generic())
 }
 
 // String returns the map as a string.
@@ -436,38 +256,37 @@ func (that *StrIntMap) String() string {
 
 // MarshalJSON implements the interface MarshalJSON for json.Marshal.
 func (that *StrIntMap) MarshalJSON() ([]byte, error) {
-	that.mu.RLock()
-	defer that.mu.RUnlock()
-	return json.Marshal(that.data)
+	var (
+		b   []byte
+		err error
+	)
+	that.generic().RLockFunc(func(m map[string]int) {
+		b, err = json.Marshal(m)
+	})
+	return b, err
 }
 
 // UnmarshalJSON implements the interface UnmarshalJSON for json.Unmarshal.
 func (that *StrIntMap) UnmarshalJSON(b []byte) error {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if that.data == nil {
-		that.data = make(map[string]int)
-	}
-	if err := json.UnmarshalUseNumber(b, &that.data); err != nil {
+	var data map[string]int
+	if err := json.UnmarshalUseNumber(b, &data); err != nil {
 		return err
 	}
+	that.generic().Sets(data)
 	return nil
 }
 
 // UnmarshalValue is an interface implement which sets any type of value for map.
 func (that *StrIntMap) UnmarshalValue(value interface{}) (err error) {
-	that.mu.Lock()
-	defer that.mu.Unlock()
-	if that.data == nil {
-		that.data = make(map[string]int)
-	}
 	switch value.(type) {
 	case string, []byte:
-		return json.UnmarshalUseNumber(dconv.Bytes(value), &that.data)
+		return that.UnmarshalJSON(dconv.Bytes(value))
 	default:
+		data := make(map[string]int)
 		for k, v := range dconv.Map(value) {
-			that.data[k] = dconv.Int(v)
+			data[k] = dconv.Int(v)
 		}
+		that.generic().Sets(data)
 	}
 	return
 }
@@ -0,0 +1,397 @@
+package dmap
+
+import (
+	"encoding/json"
+	"github.com/osgochina/donkeygo/encoding/dhash"
+	"github.com/osgochina/donkeygo/util/dconv"
+)
+
+const (
+	dDefaultShardCount = 32 // 默认的分片数量
+)
+
+// ShardedMapOption 分片hash表的选项
+type ShardedMapOption struct {
+	Shards int                     // 分片数量，会向上取整为2的幂，默认32
+	Hash   func(key []byte) uint32 // 计算非整数key的hash值的方法，可以使用dhash中的方法，默认dhash.BKDRHash
+}
+
+// ShardedMap 并发安全的分片hash表，key按照hash值分散到多个各自加锁的AnyAnyMap中，
+// 不同分片的读写互不阻塞，适合高并发写入的场景，方法与AnyAnyMap保持一致
+type ShardedMap struct {
+	shards []*AnyAnyMap
+	mask   uint32
+	hash   func(key []byte) uint32
+}
+
+// NewShardedMap 创建分片hash表
+func NewShardedMap(option ...ShardedMapOption) *ShardedMap {
+	var opt ShardedMapOption
+	if len(option) > 0 {
+		opt = option[0]
+	}
+	if opt.Shards <= 0 {
+		opt.Shards = dDefaultShardCount
+	}
+	if opt.Hash == nil {
+		opt.Hash = dhash.BKDRHash
+	}
+	count := 1
+	for count < opt.Shards {
+		count <<= 1
+	}
+	m := &ShardedMap{
+		shards: make([]*AnyAnyMap, count),
+		mask:   uint32(count - 1),
+		hash:   opt.Hash,
+	}
+	for i := range m.shards {
+		m.shards[i] = NewAnyAnyMap(true)
+	}
+	return m
+}
+
+// NewShardedMapFrom 通过map创建分片hash表，会复制传入的map
+func NewShardedMapFrom(data map[interface{}]interface{}, option ...ShardedMapOption) *ShardedMap {
+	m := NewShardedMap(option...)
+	m.Sets(data)
+	return m
+}
+
+// 获取key所在的分片
+func (that *ShardedMap) getShard(key interface{}) *AnyAnyMap {
+	return that.shards[that.shardIndex(key)]
+}
+
+// 计算key所在分片的下标，整数key直接混合计算，避免转换成字节切片带来的内存分配，
+// 其他类型的key转换成字符串后使用配置的hash方法计算
+func (that *ShardedMap) shardIndex(key interface{}) uint32 {
+	var h uint32
+	switch k := key.(type) {
+	case string:
+		h = that.hash(dconv.UnsafeStrToBytes(k))
+	case int:
+		h = mixUint64(uint64(k))
+	case int64:
+		h = mixUint64(uint64(k))
+	case int32:
+		h = mixUint64(uint64(k))
+	case uint:
+		h = mixUint64(uint64(k))
+	case uint64:
+		h = mixUint64(k)
+	case uint32:
+		h = mixUint64(uint64(k))
+	default:
+		h = that.hash(dconv.UnsafeStrToBytes(dconv.String(key)))
+	}
+	// 混合高位，避免hash值的低位分布不均匀
+	h ^= h >> 16
+	return h & that.mask
+}
+
+// 使用斐波那契散列把整数打散成32位hash值
+func mixUint64(v uint64) uint32 {
+	return uint32((v * 0x9E3779B97F4A7C15) >> 32)
+}
+
+// Shards 返回分片数量
+func (that *ShardedMap) Shards() int {
+	return len(that.shards)
+}
+
+// Iterator 迭代hash表，依次迭代每个分片，迭代某个分片时只对该分片加读锁
+func (that *ShardedMap) Iterator(f func(k interface{}, v interface{}) bool) {
+	for _, shard := range that.shards {
+		next := true
+		shard.Iterator(func(k interface{}, v interface{}) bool {
+			next = f(k, v)
+			return next
+		})
+		if !next {
+			return
+		}
+	}
+}
+
+// Map 返回所有数据的副本
+func (that *ShardedMap) Map() map[interface{}]interface{} {
+	return that.MapCopy()
+}
+
+// MapCopy copy一份map数据
+func (that *ShardedMap) MapCopy() map[interface{}]interface{} {
+	data := make(map[interface{}]interface{}, that.Size())
+	for _, shard := range that.shards {
+		shard.RLockFunc(func(m map[interface{}]interface{}) {
+			for k, v := range m {
+				data[k] = v
+			}
+		})
+	}
+	return data
+}
+
+// Clone clone一份数据。并返回新的对象指针，分片数量和hash方法保持不变
+func (that *ShardedMap) Clone() *ShardedMap {
+	return NewShardedMapFrom(that.MapCopy(), ShardedMapOption{Shards: len(that.shards), Hash: that.hash})
+}
+
+// FilterEmpty 清除空值
+func (that *ShardedMap) FilterEmpty() {
+	for _, shard := range that.shards {
+		shard.FilterEmpty()
+	}
+}
+
+// FilterNil 清除nil值
+func (that *ShardedMap) FilterNil() {
+	for _, shard := range that.shards {
+		shard.FilterNil()
+	}
+}
+
+// Set 设置key value
+func (that *ShardedMap) Set(key interface{}, value interface{}) {
+	that.getShard(key).Set(key, value)
+}
+
+// Sets 批量设置
+func (that *ShardedMap) Sets(data map[interface{}]interface{}) {
+	for k, v := range data {
+		that.getShard(k).Set(k, v)
+	}
+}
+
+// Search 通过key查找value
+func (that *ShardedMap) Search(key interface{}) (value interface{}, found bool) {
+	return that.getShard(key).Search(key)
+}
+
+// Get 查找key
+func (that *ShardedMap) Get(key interface{}) (value interface{}) {
+	return that.getShard(key).Get(key)
+}
+
+// Pop 随机获取并移除一个字典key，value
+func (that *ShardedMap) Pop() (key, value interface{}) {
+	for _, shard := range that.shards {
+		if pops := shard.Pops(1); len(pops) > 0 {
+			for key, value = range pops {
+				return
+			}
+		}
+	}
+	return
+}
+
+// Pops 随机获取并移除指定size的字典值，-1表示获取全部
+func (that *ShardedMap) Pops(size int) map[interface{}]interface{} {
+	if size == 0 {
+		return nil
+	}
+	var newData map[interface{}]interface{}
+	for _, shard := range that.shards {
+		n := -1
+		if size > 0 {
+			n = size - len(newData)
+		}
+		for k, v := range shard.Pops(n) {
+			if newData == nil {
+				newData = make(map[interface{}]interface{})
+			}
+			newData[k] = v
+		}
+		if size > 0 && len(newData) >= size {
+			break
+		}
+	}
+	return newData
+}
+
+// GetOrSet 查找key对应的值是否存在，如果未找到，则写入
+func (that *ShardedMap) GetOrSet(key interface{}, value interface{}) interface{} {
+	return that.getShard(key).GetOrSet(key, value)
+}
+
+// GetOrSetFunc 获取指定key的值，如果不存在，则通过方法f生成该值，并写入到map，然后返回
+// 生成新值的方法未使用到锁，不会造成阻塞
+func (that *ShardedMap) GetOrSetFunc(key interface{}, f func() interface{}) interface{} {
+	return that.getShard(key).GetOrSetFunc(key, f)
+}
+
+// GetOrSetFuncLock 与GetOrSetFunc的区别在于生成值的时候会对key所在的分片加锁，传入的方法不能阻塞
+func (that *ShardedMap) GetOrSetFuncLock(key interface{}, f func() interface{}) interface{} {
+	return that.getShard(key).GetOrSetFuncLock(key, f)
+}
+
+// SetIfNotExist 如果map中不存在该key，则设置
+func (that *ShardedMap) SetIfNotExist(key interface{}, value interface{}) bool {
+	return that.getShard(key).SetIfNotExist(key, value)
+}
+
+// SetIfNotExistFunc 如果map中不存在key，则调用方法f生成，生成的时候未加锁
+func (that *ShardedMap) SetIfNotExistFunc(key interface{}, f func() interface{}) bool {
+	return that.getShard(key).SetIfNotExistFunc(key, f)
+}
+
+// SetIfNotExistFuncLock 如果map中不存在key，则调用方法f生成，生成的时候对key所在的分片加锁
+func (that *ShardedMap) SetIfNotExistFuncLock(key interface{}, f func() interface{}) bool {
+	return that.getShard(key).SetIfNotExistFuncLock(key, f)
+}
+
+// Contains 判断传入的key是否存在于map中
+func (that *ShardedMap) Contains(key interface{}) bool {
+	return that.getShard(key).Contains(key)
+}
+
+// Remove 移除指定的key，并返回它对应的值
+func (that *ShardedMap) Remove(key interface{}) (value interface{}) {
+	return that.getShard(key).Remove(key)
+}
+
+// Removes 批量删除key
+func (that *ShardedMap) Removes(keys []interface{}) {
+	for _, key := range keys {
+		that.getShard(key).Remove(key)
+	}
+}
+
+// Keys 返回map的所有key
+func (that *ShardedMap) Keys() []interface{} {
+	keys := make([]interface{}, 0, that.Size())
+	for _, shard := range that.shards {
+		keys = append(keys, shard.Keys()...)
+	}
+	return keys
+}
+
+// Values 返回map所有的值
+func (that *ShardedMap) Values() []interface{} {
+	values := make([]interface{}, 0, that.Size())
+	for _, shard := range that.shards {
+		values = append(values, shard.Values()...)
+	}
+	return values
+}
+
+// Size 返回map的长度，各分片分别统计，并发写入时只是一个近似值
+func (that *ShardedMap) Size() int {
+	size := 0
+	for _, shard := range that.shards {
+		size += shard.Size()
+	}
+	return size
+}
+
+// IsEmpty 判断map是否为空
+func (that *ShardedMap) IsEmpty() bool {
+	for _, shard := range that.shards {
+		if !shard.IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+// Clear 清除map
+func (that *ShardedMap) Clear() {
+	for _, shard := range that.shards {
+		shard.Clear()
+	}
+}
+
+// Replace 使用data替换map的数据，会复制传入的map
+func (that *ShardedMap) Replace(data map[interface{}]interface{}) {
+	parts := make([]map[interface{}]interface{}, len(that.shards))
+	for i := range parts {
+		parts[i] = make(map[interface{}]interface{})
+	}
+	for k, v := range data {
+		parts[that.shardIndex(k)][k] = v
+	}
+	for i, shard := range that.shards {
+		shard.Replace(parts[i])
+	}
+}
+
+// LockFunc 依次对每个分片加锁执行方法，方法每次只能看到一个分片的数据，
+// 方法中写入的key如果不属于当前分片，会从当前分片移除，等所有分片执行完之后再写入它所属的分片
+func (that *ShardedMap) LockFunc(f func(m map[interface{}]interface{})) {
+	moved := make(map[interface{}]interface{})
+	for i, shard := range that.shards {
+		index := uint32(i)
+		shard.LockFunc(func(m map[interface{}]interface{}) {
+			f(m)
+			for k, v := range m {
+				if that.shardIndex(k) != index {
+					moved[k] = v
+					delete(m, k)
+				}
+			}
+		})
+	}
+	that.Sets(moved)
+}
+
+// RLockFunc 依次对每个分片加读锁执行方法，方法每次只能看到一个分片的数据，方法中不能修改传入的map
+func (that *ShardedMap) RLockFunc(f func(m map[interface{}]interface{})) {
+	for _, shard := range that.shards {
+		shard.RLockFunc(f)
+	}
+}
+
+// Flip 翻转key和value
+func (that *ShardedMap) Flip() {
+	data := that.MapCopy()
+	n := make(map[interface{}]interface{}, len(data))
+	for k, v := range data {
+		n[v] = k
+	}
+	that.Replace(n)
+}
+
+// Merge 合并两个map
+func (that *ShardedMap) Merge(other *ShardedMap) {
+	if other == that {
+		return
+	}
+	that.Sets(other.MapCopy())
+}
+
+// 转换成字符串
+func (that *ShardedMap) String() string {
+	b, _ := that.MarshalJSON()
+	return dconv.UnsafeBytesToStr(b)
+}
+
+// MarshalJSON json序列化
+func (that *ShardedMap) MarshalJSON() ([]byte, error) {
+	return json.Marshal(dconv.Map(that.MapCopy()))
+}
+
+// UnmarshalJSON json反序列化
+func (that *ShardedMap) UnmarshalJSON(b []byte) error {
+	if that.shards == nil {
+		*that = *NewShardedMap()
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return err
+	}
+	for k, v := range data {
+		that.getShard(k).Set(k, v)
+	}
+	return nil
+}
+
+// UnmarshalValue 把任意值转换成map
+func (that *ShardedMap) UnmarshalValue(value interface{}) (err error) {
+	if that.shards == nil {
+		*that = *NewShardedMap()
+	}
+	for k, v := range dconv.Map(value) {
+		that.getShard(k).Set(k, v)
+	}
+	return
+}
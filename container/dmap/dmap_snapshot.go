@@ -0,0 +1,157 @@
+package dmap
+
+import (
+	"github.com/osgochina/donkeygo/container/dsnapshot"
+	"github.com/osgochina/donkeygo/util/dconv"
+	"io"
+)
+
+// 把iterator迭代的数据以dsnapshot的二进制格式写入w，iterator需要迭代在读锁内复制出来的数据，
+// 这样写入的过程不会阻塞其他协程对map的读写
+func snapshotMap[K, V any](w io.Writer, iterator func(f func(key K, value V) bool), option []dsnapshot.Option) error {
+	return dsnapshot.WriteMap(w, func(f func(key, value interface{}) bool) {
+		iterator(func(key K, value V) bool {
+			return f(key, value)
+		})
+	}, option...)
+}
+
+// 从r读取快照，key和value分别使用key、value方法转换类型后调用set写入临时的map，
+// 整个快照读取并校验通过后才会调用replace，快照不完整或者校验失败时不会修改map
+func restoreMap[K, V any](r io.Reader, key func(interface{}) K, value func(interface{}) V,
+	set func(key K, value V), replace func(), option []dsnapshot.Option) error {
+	err := dsnapshot.ReadMap(r, func(k, v interface{}) {
+		set(key(k), value(v))
+	}, option...)
+	if err != nil {
+		return err
+	}
+	replace()
+	return nil
+}
+
+// 快照中的key和value不做转换
+func anyValue(v interface{}) interface{} {
+	return v
+}
+
+// Snapshot 把map的数据写入快照
+func (that *AnyAnyMap) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	return snapshotMap(w, that.Clone(false).Iterator, option)
+}
+
+// Restore 使用快照替换map的数据
+func (that *AnyAnyMap) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	data := NewAnyAnyMap()
+	return restoreMap(r, anyValue, anyValue, data.Set, func() { that.Replace(data.Map()) }, option)
+}
+
+// Snapshot 把map的数据写入快照
+func (that *IntAnyMap) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	return snapshotMap(w, that.Clone().Iterator, option)
+}
+
+// Restore 使用快照替换map的数据
+func (that *IntAnyMap) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	data := NewIntAnyMap()
+	return restoreMap(r, dconv.Int, anyValue, data.Set, func() { that.Replace(data.Map()) }, option)
+}
+
+// Snapshot 把map的数据写入快照
+func (that *IntIntMap) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	return snapshotMap(w, that.Clone().Iterator, option)
+}
+
+// Restore 使用快照替换map的数据
+func (that *IntIntMap) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	data := NewIntIntMap()
+	return restoreMap(r, dconv.Int, dconv.Int, data.Set, func() { that.Replace(data.Map()) }, option)
+}
+
+// Snapshot 把map的数据写入快照
+func (that *IntStrMap) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	return snapshotMap(w, that.Clone().Iterator, option)
+}
+
+// Restore 使用快照替换map的数据
+func (that *IntStrMap) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	data := NewIntStrMap()
+	return restoreMap(r, dconv.Int, dconv.String, data.Set, func() { that.Replace(data.Map()) }, option)
+}
+
+// Snapshot 把map的数据写入快照
+func (that *StrAnyMap) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	return snapshotMap(w, that.Clone().Iterator, option)
+}
+
+// Restore 使用快照替换map的数据
+func (that *StrAnyMap) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	data := NewStrAnyMap()
+	return restoreMap(r, dconv.String, anyValue, data.Set, func() { that.Replace(data.Map()) }, option)
+}
+
+// Snapshot 把map的数据写入快照
+func (that *StrIntMap) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	return snapshotMap(w, that.Clone().Iterator, option)
+}
+
+// Restore 使用快照替换map的数据
+func (that *StrIntMap) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	data := NewStrIntMap()
+	return restoreMap(r, dconv.String, dconv.Int, data.Set, func() { that.Replace(data.Map()) }, option)
+}
+
+// Snapshot 把map的数据写入快照
+func (that *StrStrMap) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	return snapshotMap(w, that.Clone().Iterator, option)
+}
+
+// Restore 使用快照替换map的数据
+func (that *StrStrMap) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	data := NewStrStrMap()
+	return restoreMap(r, dconv.String, dconv.String, data.Set, func() { that.Replace(data.Map()) }, option)
+}
+
+// Snapshot 按照写入顺序把map的数据写入快照
+func (m *ListMap) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	var keys, values []interface{}
+	m.Iterator(func(key, value interface{}) bool {
+		keys, values = append(keys, key), append(values, value)
+		return true
+	})
+	return dsnapshot.WriteMap(w, func(f func(key, value interface{}) bool) {
+		for i, key := range keys {
+			if !f(key, values[i]) {
+				return
+			}
+		}
+	}, option...)
+}
+
+// Restore 使用快照替换map的数据，并保持快照中的顺序
+func (m *ListMap) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	newMap := NewListMap()
+	if err := dsnapshot.ReadMap(r, newMap.Set, option...); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.data, m.list = newMap.data, newMap.list
+	m.mu.Unlock()
+	return nil
+}
+
+// Snapshot 把map的数据写入快照，数据会依次在各分片的读锁内复制
+func (that *ShardedMap) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	return snapshotMap(w, NewFrom(that.MapCopy()).Iterator, option)
+}
+
+// Restore 使用快照替换map的数据
+func (that *ShardedMap) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	data := NewAnyAnyMap()
+	return restoreMap(r, anyValue, anyValue, data.Set, func() {
+		if that.shards == nil {
+			*that = *NewShardedMap()
+		}
+		that.Replace(data.Map())
+	}, option)
+}
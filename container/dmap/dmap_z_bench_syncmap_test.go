@@ -16,6 +16,7 @@ import (
 
 var gm = dmap.NewIntIntMap(true)
 var sm = sync.Map{}
+var shm = dmap.NewShardedMap()
 
 func Benchmark_dmapSet(b *testing.B) {
 	b.RunParallel(func(pb *testing.PB) {
@@ -37,6 +38,16 @@ func Benchmark_SyncMapSet(b *testing.B) {
 	})
 }
 
+func Benchmark_ShardedMapSet(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			shm.Set(i, i)
+			i++
+		}
+	})
+}
+
 func Benchmark_dmapGet(b *testing.B) {
 	b.RunParallel(func(pb *testing.PB) {
 		i := 0
@@ -57,6 +68,16 @@ func Benchmark_SyncMapGet(b *testing.B) {
 	})
 }
 
+func Benchmark_ShardedMapGet(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			shm.Get(i)
+			i++
+		}
+	})
+}
+
 func Benchmark_dmapRemove(b *testing.B) {
 	b.RunParallel(func(pb *testing.PB) {
 		i := 0
@@ -76,3 +97,13 @@ func Benchmark_SyncMapRmove(b *testing.B) {
 		}
 	})
 }
+
+func Benchmark_ShardedMapRemove(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			shm.Remove(i)
+			i++
+		}
+	})
+}
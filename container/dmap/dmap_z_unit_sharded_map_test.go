@@ -0,0 +1,146 @@
+package dmap_test
+
+import (
+	"github.com/osgochina/donkeygo/container/dmap"
+	"github.com/osgochina/donkeygo/encoding/dhash"
+	"github.com/osgochina/donkeygo/internal/json"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"sync"
+	"testing"
+)
+
+func Test_ShardedMap_Basic(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		m := dmap.NewShardedMap(dmap.ShardedMapOption{Shards: 5, Hash: dhash.SDBMHash})
+		t.Assert(m.Shards(), 8)
+		m.Set("a", 1)
+		m.Set(2, "b")
+		t.Assert(m.Get("a"), 1)
+		t.Assert(m.Get(2), "b")
+		t.Assert(m.Get(3), nil)
+		t.Assert(m.GetOrSet("c", 3), 3)
+		t.Assert(m.GetOrSetFunc("c", func() interface{} { return 4 }), 3)
+		t.Assert(m.GetOrSetFuncLock("d", func() interface{} { return 4 }), 4)
+		t.Assert(m.SetIfNotExist("d", 5), false)
+		t.Assert(m.SetIfNotExistFunc("e", func() interface{} { return 5 }), true)
+		t.Assert(m.SetIfNotExistFuncLock("e", func() interface{} { return 6 }), false)
+		t.Assert(m.Size(), 5)
+		t.Assert(m.Contains("e"), true)
+		t.AssertIN("a", m.Keys())
+		t.AssertIN("b", m.Values())
+
+		t.Assert(m.Remove("a"), 1)
+		m.Removes([]interface{}{2, "c"})
+		t.Assert(m.Map(), map[interface{}]interface{}{"d": 4, "e": 5})
+
+		m.Flip()
+		t.Assert(m.Map(), map[interface{}]interface{}{4: "d", 5: "e"})
+		t.Assert(len(m.Pops(1)), 1)
+		k, v := m.Pop()
+		t.AssertNE(k, nil)
+		t.AssertNE(v, nil)
+		t.Assert(m.IsEmpty(), true)
+		t.Assert(m.Pops(-1), nil)
+	})
+}
+
+func Test_ShardedMap_Batch(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		data := make(map[interface{}]interface{})
+		for i := 0; i < 100; i++ {
+			data[i] = i
+		}
+		m := dmap.NewShardedMapFrom(data)
+		t.Assert(m.Size(), 100)
+		t.Assert(m.Clone().Map(), data)
+		t.Assert(len(m.Pops(30)), 30)
+		t.Assert(m.Size(), 70)
+
+		m.Replace(map[interface{}]interface{}{"a": "", "b": nil, "c": 1})
+		m.FilterNil()
+		t.Assert(m.Size(), 2)
+		m.FilterEmpty()
+		t.Assert(m.Map(), map[interface{}]interface{}{"c": 1})
+
+		other := dmap.NewShardedMapFrom(map[interface{}]interface{}{"d": 2})
+		m.Merge(other)
+		m.Merge(m)
+		t.Assert(m.Size(), 2)
+
+		count := 0
+		m.Iterator(func(k interface{}, v interface{}) bool {
+			count++
+			return false
+		})
+		t.Assert(count, 1)
+		m.Clear()
+		t.Assert(m.Size(), 0)
+	})
+}
+
+func Test_ShardedMap_Concurrent(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		m := dmap.NewShardedMap()
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					m.Set(i*1000+j, j)
+					m.GetOrSetFuncLock(j, func() interface{} { return j })
+				}
+			}(i)
+		}
+		wg.Wait()
+		t.Assert(m.Size(), 10000)
+	})
+}
+
+func Test_ShardedMap_Json(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		m := dmap.NewShardedMapFrom(map[interface{}]interface{}{"a": 1, "b": "2"})
+		b, err := json.Marshal(m)
+		t.Assert(err, nil)
+		t.Assert(string(b), `{"a":1,"b":"2"}`)
+		t.Assert(m.String(), `{"a":1,"b":"2"}`)
+
+		var data struct {
+			M *dmap.ShardedMap
+		}
+		t.Assert(json.UnmarshalUseNumber([]byte(`{"M":{"a":1,"b":"2"}}`), &data), nil)
+		t.Assert(data.M.Get("a"), 1)
+		t.Assert(data.M.Get("b"), "2")
+
+		var m2 dmap.ShardedMap
+		t.Assert(m2.UnmarshalValue(map[string]interface{}{"c": 3}), nil)
+		t.Assert(m2.Get("c"), 3)
+	})
+}
+
+func Test_ShardedMap_LockFunc(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		m := dmap.NewShardedMap(dmap.ShardedMapOption{Shards: 4})
+		for i := 0; i < 8; i++ {
+			m.Set(i, i)
+		}
+		// 在LockFunc中写入的key会被移动到它所属的分片
+		m.LockFunc(func(data map[interface{}]interface{}) {
+			keys := make([]interface{}, 0, len(data))
+			for k := range data {
+				keys = append(keys, k)
+			}
+			for _, k := range keys {
+				data[k.(int)+100] = data[k]
+				delete(data, k)
+			}
+		})
+		t.Assert(m.Size(), 8)
+		for i := 0; i < 8; i++ {
+			t.Assert(m.Contains(i), false)
+			t.Assert(m.Get(i+100), i)
+			t.Assert(m.Remove(i+100), i)
+		}
+		t.Assert(m.IsEmpty(), true)
+	})
+}
@@ -0,0 +1,58 @@
+package dmap_test
+
+import (
+	"bytes"
+	"github.com/osgochina/donkeygo/container/dmap"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"github.com/osgochina/donkeygo/util/dutil"
+	"testing"
+)
+
+func Test_Map_Snapshot(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var buf bytes.Buffer
+		m1 := dmap.NewIntStrMapFrom(map[int]string{1: "a", 2: "b"}, true)
+		t.AssertNil(m1.Snapshot(&buf))
+		m2 := dmap.NewIntStrMap()
+		m2.Set(3, "c")
+		t.AssertNil(m2.Restore(&buf))
+		t.Assert(m2.Map(), m1.Map())
+
+		// A corrupted snapshot does not change the map.
+		buf.Reset()
+		t.AssertNil(m1.Snapshot(&buf))
+		t.AssertNE(m2.Restore(bytes.NewReader(buf.Bytes()[:buf.Len()-2])), nil)
+		t.Assert(m2.Size(), 2)
+
+		buf.Reset()
+		m3 := dmap.NewAnyAnyMapFrom(map[interface{}]interface{}{"a": 1, 2: []byte("b")})
+		t.AssertNil(m3.Snapshot(&buf))
+		m4 := dmap.NewShardedMap()
+		t.AssertNil(m4.Restore(&buf))
+		t.Assert(m4.Get("a"), 1)
+		t.Assert(m4.Get(2), []byte("b"))
+
+		buf.Reset()
+		tree := dmap.NewTreeMap(dutil.ComparatorString, true)
+		tree.Sets(map[interface{}]interface{}{"x": 1, "y": 2})
+		t.AssertNil(tree.Snapshot(&buf))
+		m5 := dmap.NewStrIntMap()
+		t.AssertNil(m5.Restore(&buf))
+		t.Assert(m5.Map(), map[string]int{"x": 1, "y": 2})
+	})
+}
+
+func Test_ListMap_Snapshot(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var buf bytes.Buffer
+		m1 := dmap.NewListMap(true)
+		m1.Set("c", 3)
+		m1.Set("a", 1)
+		m1.Set("b", 2)
+		t.AssertNil(m1.Snapshot(&buf))
+		m2 := dmap.NewListMap()
+		t.AssertNil(m2.Restore(&buf))
+		t.Assert(m2.Keys(), []interface{}{"c", "a", "b"})
+		t.Assert(m2.Values(), []interface{}{3, 1, 2})
+	})
+}
@@ -1,18 +1,66 @@
 package dqueue
 
 import (
+	"context"
+	"errors"
 	"github.com/osgochina/donkeygo/container/dlist"
 	"github.com/osgochina/donkeygo/container/dtype"
 	"math"
+	"sync"
+	"time"
+)
+
+// IQueue 队列的通用接口，普通队列和优先级队列都实现了该接口
+type IQueue interface {
+	Push(v interface{})
+	PushE(v interface{}) error
+	TryPush(v interface{}) error
+	PushWithContext(ctx context.Context, v interface{}) error
+	Pop() interface{}
+	PopWithContext(ctx context.Context) (interface{}, error)
+	PopBatch(n int, maxWait time.Duration) []interface{}
+	Len() int
+	Size() int
+	Stats() Stats
+	Close()
+}
+
+var (
+	_ IQueue = (*Queue)(nil)
+	_ IQueue = (*PriorityQueue)(nil)
+)
+
+// Option 队列的选项
+type Option struct {
+	Limit       int  // 队列长度限制，大于0时为有界队列
+	NonBlocking bool // 有界队列已满时Push不阻塞，直接返回ErrQueueFull
+}
+
+// Stats 队列的统计信息
+type Stats struct {
+	Enqueued int64 // 入队的数据条数
+	Dequeued int64 // 通过Pop、PopWithContext和PopBatch出队的数据条数，直接从Queue.C中读取的数据不计入，从PriorityQueue.C中读取的数据计入
+	Dropped  int64 // 因为队列已满或已关闭没有入队的数据条数
+}
+
+var (
+	ErrQueueFull   = errors.New("queue is full")   // 有界队列已满
+	ErrQueueClosed = errors.New("queue is closed") // 队列已关闭
 )
 
 // Queue 队列
 type Queue struct {
-	limit  int              // 队列长度限制
-	list   *dlist.List      //存储数据的容器
-	closed *dtype.Bool      // 队列是否关闭
-	events chan struct{}    //队列数据写入时候触发该事件
-	C      chan interface{} // 读取数据的channel
+	limit       int              // 队列长度限制
+	nonBlocking bool             // 有界队列已满时是否直接返回错误
+	list        *dlist.List      //存储数据的容器
+	closed      *dtype.Bool      // 队列是否关闭
+	closeChan   chan struct{}    // 队列关闭时关闭该通道，唤醒阻塞的写入
+	pushMu      sync.RWMutex     // 保证关闭通道时没有正在进行的写入
+	events      chan struct{}    //队列数据写入时候触发该事件
+	enqueued    *dtype.Int64     // 入队的数据条数
+	dequeued    *dtype.Int64     // 出队的数据条数
+	dropped     *dtype.Int64     // 没有入队的数据条数
+	C           chan interface{} // 读取数据的channel
 }
 
 const (
@@ -23,12 +71,26 @@ const (
 // New 创建一个新的队列
 // 如果传入了limit则表示是一个固定大小的队列，底层直接使用标准库的channel驱动。
 func New(limit ...int) *Queue {
+	option := Option{}
+	if len(limit) > 0 {
+		option.Limit = limit[0]
+	}
+	return NewWithOption(option)
+}
+
+// NewWithOption 使用选项创建队列
+func NewWithOption(option Option) *Queue {
 	q := &Queue{
-		closed: dtype.NewBool(),
+		nonBlocking: option.NonBlocking,
+		closed:      dtype.NewBool(),
+		closeChan:   make(chan struct{}),
+		enqueued:    dtype.NewInt64(),
+		dequeued:    dtype.NewInt64(),
+		dropped:     dtype.NewInt64(),
 	}
 	// 如果是固定大小的队列，则直接使用底层channel通道处理
-	if len(limit) > 0 && limit[0] > 0 {
-		q.limit = limit[0]
+	if option.Limit > 0 {
+		q.limit = option.Limit
 		q.C = make(chan interface{}, q.limit)
 	} else {
 		q.list = dlist.New(true)
@@ -76,36 +138,146 @@ func (that *Queue) asyncLoopFromListToChannel() {
 	close(that.C)
 }
 
-// Push 入队
+// Push 入队，有界队列已满时阻塞等待，设置了NonBlocking时直接丢弃，队列已关闭时丢弃，需要知道是否入队时使用PushE
 func (that *Queue) Push(v interface{}) {
+	_ = that.PushE(v)
+}
+
+// PushE 入队，有界队列已满时阻塞等待，设置了NonBlocking时返回ErrQueueFull，队列已关闭时返回ErrQueueClosed
+func (that *Queue) PushE(v interface{}) error {
+	return that.push(context.Background(), v, that.nonBlocking)
+}
+
+// TryPush 入队，有界队列已满时不阻塞，直接返回ErrQueueFull
+func (that *Queue) TryPush(v interface{}) error {
+	return that.push(context.Background(), v, true)
+}
+
+// PushWithContext 入队，有界队列已满时阻塞等待直到<ctx>结束
+func (that *Queue) PushWithContext(ctx context.Context, v interface{}) error {
+	return that.push(ctx, v, that.nonBlocking)
+}
+
+// 入队，<nonBlocking>为true时有界队列已满直接返回ErrQueueFull
+func (that *Queue) push(ctx context.Context, v interface{}, nonBlocking bool) error {
+	that.pushMu.RLock()
+	defer that.pushMu.RUnlock()
+	if that.closed.Val() {
+		that.dropped.Add(1)
+		return ErrQueueClosed
+	}
 	if that.limit > 0 {
-		that.C <- v
-	} else {
-		that.list.PushBack(v)
-		//如果入队事件队列的channel大小小于默认队列大小，则继续发送入队事件，如果超出了，则不需要发送，说明消费端处理不过来
-		if len(that.events) < defaultQueueSize {
-			that.events <- struct{}{}
+		if nonBlocking {
+			select {
+			case that.C <- v:
+				that.enqueued.Add(1)
+				return nil
+			default:
+				that.dropped.Add(1)
+				return ErrQueueFull
+			}
 		}
+		select {
+		case that.C <- v:
+			that.enqueued.Add(1)
+			return nil
+		case <-that.closeChan:
+			that.dropped.Add(1)
+			return ErrQueueClosed
+		case <-ctx.Done():
+			that.dropped.Add(1)
+			return ctx.Err()
+		}
+	}
+	that.list.PushBack(v)
+	that.enqueued.Add(1)
+	//如果入队事件队列的channel大小小于默认队列大小，则继续发送入队事件，如果超出了，则不需要发送，说明消费端处理不过来
+	if len(that.events) < defaultQueueSize {
+		that.events <- struct{}{}
 	}
+	return nil
 }
 
 // Pop 出队消费
 func (that *Queue) Pop() interface{} {
-	return <-that.C
+	v, ok := <-that.C
+	if ok {
+		that.dequeued.Add(1)
+	}
+	return v
+}
+
+// PopWithContext 出队消费，队列为空时阻塞等待直到<ctx>结束，队列已关闭并且没有数据时返回ErrQueueClosed
+func (that *Queue) PopWithContext(ctx context.Context) (interface{}, error) {
+	select {
+	case v, ok := <-that.C:
+		if !ok {
+			return nil, ErrQueueClosed
+		}
+		that.dequeued.Add(1)
+		return v, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// PopBatch 批量出队，最多获取<n>条数据，获取到<n>条或者等待超过<maxWait>后返回，
+// <maxWait>小于等于0时只获取当前已有的数据
+func (that *Queue) PopBatch(n int, maxWait time.Duration) (items []interface{}) {
+	items = make([]interface{}, 0, n)
+	defer func() {
+		that.dequeued.Add(int64(len(items)))
+	}()
+	if maxWait <= 0 {
+		for len(items) < n {
+			select {
+			case v, ok := <-that.C:
+				if !ok {
+					return items
+				}
+				items = append(items, v)
+			default:
+				return items
+			}
+		}
+		return items
+	}
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+	for len(items) < n {
+		select {
+		case v, ok := <-that.C:
+			if !ok {
+				return items
+			}
+			items = append(items, v)
+		case <-timer.C:
+			return items
+		}
+	}
+	return items
 }
 
 // Close 关闭队列
 func (that *Queue) Close() {
-	that.closed.Set(true)
+	if !that.closed.Cas(false, true) {
+		return
+	}
+	// 唤醒阻塞的写入，并等待正在进行的写入结束
+	close(that.closeChan)
+	that.pushMu.Lock()
 	if that.events != nil {
 		close(that.events)
 	}
 	if that.limit > 0 {
 		close(that.C)
 	}
-	// 把通道中的最后数据都出队列，如果已关闭，返回页没问题
+	that.pushMu.Unlock()
+	// 把通道中的最后数据都出队列，如果已关闭，返回页没问题，这些数据没有被消费，计入丢弃的条数
 	for i := 0; i < defaultBatchSize; i++ {
-		that.Pop()
+		if _, ok := <-that.C; ok {
+			that.dropped.Add(1)
+		}
 	}
 }
 
@@ -122,3 +294,12 @@ func (that *Queue) Len() (length int) {
 func (that *Queue) Size() int {
 	return that.Len()
 }
+
+// Stats 获取队列的统计信息
+func (that *Queue) Stats() Stats {
+	return Stats{
+		Enqueued: that.enqueued.Val(),
+		Dequeued: that.dequeued.Val(),
+		Dropped:  that.dropped.Val(),
+	}
+}
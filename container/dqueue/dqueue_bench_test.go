@@ -18,6 +18,7 @@ var length = 1000000
 var qstatic = dqueue.New(length)
 var qdynamic = dqueue.New()
 var cany = make(chan interface{}, length)
+var ring = dqueue.NewRingQueue(length)
 
 func Benchmark_Dqueue_StaticPushAndPop(b *testing.B) {
 	b.N = bn
@@ -56,3 +57,33 @@ func Benchmark_Channel_PushAndPop(b *testing.B) {
 		<-cany
 	}
 }
+
+func Benchmark_RingQueue_PushAndPop(b *testing.B) {
+	b.N = bn
+	for i := 0; i < b.N; i++ {
+		_ = ring.Push(i)
+		ring.Pop()
+	}
+}
+
+func Benchmark_RingQueue_ParallelPushAndPop(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			_ = ring.Push(i)
+			ring.Pop()
+			i++
+		}
+	})
+}
+
+func Benchmark_Channel_ParallelPushAndPop(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			cany <- i
+			<-cany
+			i++
+		}
+	})
+}
@@ -0,0 +1,570 @@
+package dqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/osgochina/donkeygo/container/dtype"
+	"github.com/osgochina/donkeygo/internal/intlog"
+	"github.com/osgochina/donkeygo/internal/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDiskSegmentSize    = 64 * 1024 * 1024 // 默认的段文件大小
+	defaultDiskMaxMessageSize = 16 * 1024 * 1024 // 默认的单条数据最大字节数
+	diskRetryInterval         = time.Second      // 读取出错后重试的间隔
+)
+
+// DiskOption 磁盘队列的选项
+type DiskOption struct {
+	SegmentSize     int64         // 单个段文件的最大字节数，超过后写入新的段文件，默认64MB
+	MaxMessageSize  int           // 单条数据的最大字节数，默认16MB
+	SyncEvery       int           // 每写入多少条数据调用一次fsync，为0时不按条数同步
+	SyncInterval    time.Duration // 定时调用fsync的间隔，为0时不定时同步
+	CheckpointEvery int           // 每确认多少条数据保存一次读取位置，默认每次确认都保存
+	AckTimeout      time.Duration // 出队后超过该时间没有确认的数据会再次出队，为0时只在重新打开队列后再次出队
+}
+
+// DiskMessage 从磁盘队列中取出的数据
+type DiskMessage struct {
+	ID    uint64 // 数据的序号，用于确认
+	Data  []byte // 数据内容
+	queue *DiskQueue
+}
+
+// DiskQueue 持久化到磁盘的先进先出队列，数据追加写入分段的文件，通过读取位置文件记录确认的进度。
+// 出队的数据需要调用Ack确认，没有确认的数据在确认超时或者重新打开队列后会再次出队，保证至少消费一次。
+// 读取时遇到损坏的数据会跳过所在段文件剩余的部分，从下一个段文件继续读取。
+type DiskQueue struct {
+	mu           sync.Mutex
+	dir          string
+	option       DiskOption
+	writeFile    *os.File     // 当前写入的段文件
+	writePos     diskPosition // 写入位置，Seq为下一条写入数据的序号
+	unsynced     int          // 没有fsync的数据条数
+	readFile     *os.File     // 当前读取的段文件
+	readPos      diskPosition // 读取位置
+	ackPos       diskPosition // 第一条没有确认的数据的位置
+	inflight     map[uint64]*diskInflight
+	inflightSeqs []uint64 // 按照出队顺序排列的未确认数据
+	unsaved      int      // 没有保存读取位置的确认条数
+	removedBelow uint64   // 已经删除的段文件
+	notify       chan struct{}
+	closed       *dtype.Bool
+	closeChan    chan struct{}
+	done         chan struct{}
+	C            chan *DiskMessage // 读取数据的channel
+}
+
+// 已出队还没有确认的数据
+type diskInflight struct {
+	end      diskPosition // 下一条数据的位置
+	acked    bool
+	data     []byte    // 设置了AckTimeout时保存数据用于再次出队
+	deadline time.Time // 确认的截止时间，为零值时表示还没有写入C
+}
+
+// NewDisk 打开或创建<dir>目录中的磁盘队列，打开时会截断段文件末尾损坏的数据
+func NewDisk(dir string, option ...DiskOption) (*DiskQueue, error) {
+	q := &DiskQueue{
+		dir:       dir,
+		inflight:  make(map[uint64]*diskInflight),
+		notify:    make(chan struct{}, 1),
+		closed:    dtype.NewBool(),
+		closeChan: make(chan struct{}),
+		done:      make(chan struct{}),
+		C:         make(chan *DiskMessage),
+	}
+	if len(option) > 0 {
+		q.option = option[0]
+	}
+	if q.option.SegmentSize <= 0 {
+		q.option.SegmentSize = defaultDiskSegmentSize
+	}
+	if q.option.MaxMessageSize <= 0 {
+		q.option.MaxMessageSize = defaultDiskMaxMessageSize
+	}
+	if q.option.CheckpointEvery <= 0 {
+		q.option.CheckpointEvery = 1
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := q.open(); err != nil {
+		return nil, err
+	}
+	go q.readLoop()
+	if q.option.SyncInterval > 0 {
+		go q.syncLoop()
+	}
+	return q, nil
+}
+
+// 加载读取位置并恢复段文件
+func (that *DiskQueue) open() error {
+	segments, err := listSegments(that.dir)
+	if err != nil {
+		return err
+	}
+	if content, err := ioutil.ReadFile(filepath.Join(that.dir, diskCheckpoint)); err == nil {
+		if err = json.Unmarshal(content, &that.ackPos); err != nil {
+			return errors.New(fmt.Sprintf(`invalid checkpoint file in "%s": %s`, that.dir, err.Error()))
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	} else if len(segments) > 0 {
+		that.ackPos = diskPosition{Segment: segments[0], Seq: segments[0]}
+	}
+	// 删除已经确认的段文件，恢复剩下的段文件
+	var remain []uint64
+	for _, segment := range segments {
+		if segment < that.ackPos.Segment {
+			if err = os.Remove(segmentPath(that.dir, segment)); err != nil {
+				return err
+			}
+			continue
+		}
+		remain = append(remain, segment)
+	}
+	that.removedBelow = that.ackPos.Segment
+	that.writePos = diskPosition{Segment: that.ackPos.Seq, Seq: that.ackPos.Seq}
+	for i, segment := range remain {
+		count, size, err := recoverSegment(segmentPath(that.dir, segment), 0)
+		if err != nil {
+			return err
+		}
+		if segment == that.ackPos.Segment && that.ackPos.Offset > size {
+			intlog.Printf(context.TODO(), `checkpoint offset %d exceeds segment size %d, reset to %d`, that.ackPos.Offset, size, size)
+			that.ackPos.Offset = size
+		}
+		if i == len(remain)-1 {
+			that.writePos = diskPosition{Segment: segment, Offset: size, Seq: segment + count}
+		}
+	}
+	if that.ackPos.Seq > that.writePos.Seq {
+		that.ackPos.Seq = that.writePos.Seq
+	}
+	that.readPos = that.ackPos
+	that.writeFile, err = os.OpenFile(segmentPath(that.dir, that.writePos.Segment), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	return err
+}
+
+// Push 入队，<v>为[]byte或string时直接写入，其他类型编码为json后写入
+func (that *DiskQueue) Push(v interface{}) error {
+	var data []byte
+	switch value := v.(type) {
+	case []byte:
+		data = value
+	case string:
+		data = []byte(value)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		data = b
+	}
+	if len(data) > that.option.MaxMessageSize {
+		return errors.New(fmt.Sprintf(`message size %d exceeds the limit %d`, len(data), that.option.MaxMessageSize))
+	}
+	record := encodeRecord(data)
+
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if that.closed.Val() {
+		return ErrQueueClosed
+	}
+	// 当前段文件已满时写入新的段文件
+	if that.writePos.Offset > 0 && that.writePos.Offset+int64(len(record)) > that.option.SegmentSize {
+		if err := that.rollSegment(); err != nil {
+			return err
+		}
+	}
+	if _, err := that.writeFile.Write(record); err != nil {
+		// 写入了部分数据时截断，保证后续的数据可以正常读取
+		if e := that.writeFile.Truncate(that.writePos.Offset); e != nil {
+			intlog.Error(context.TODO(), e)
+		}
+		return err
+	}
+	that.writePos.Offset += int64(len(record))
+	that.writePos.Seq++
+	that.unsynced++
+	if that.option.SyncEvery > 0 && that.unsynced >= that.option.SyncEvery {
+		if err := that.sync(); err != nil {
+			return err
+		}
+	}
+	notify(that.notify)
+	return nil
+}
+
+// 切换到新的段文件
+func (that *DiskQueue) rollSegment() error {
+	if err := that.sync(); err != nil {
+		return err
+	}
+	if err := that.writeFile.Close(); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(segmentPath(that.dir, that.writePos.Seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	that.writeFile = file
+	that.writePos.Segment = that.writePos.Seq
+	that.writePos.Offset = 0
+	return nil
+}
+
+// 把写入的数据同步到磁盘
+func (that *DiskQueue) sync() error {
+	if that.unsynced == 0 {
+		return nil
+	}
+	that.unsynced = 0
+	return that.writeFile.Sync()
+}
+
+// Pop 出队，队列为空时阻塞等待，队列关闭后返回nil
+func (that *DiskQueue) Pop() *DiskMessage {
+	return <-that.C
+}
+
+// PopWithContext 出队，队列为空时阻塞等待直到<ctx>结束，队列关闭后返回ErrQueueClosed
+func (that *DiskQueue) PopWithContext(ctx context.Context) (*DiskMessage, error) {
+	select {
+	case msg, ok := <-that.C:
+		if !ok {
+			return nil, ErrQueueClosed
+		}
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Ack 确认数据已经处理完成，所有之前的数据都确认后更新读取位置并删除不再需要的段文件
+func (that *DiskQueue) Ack(id uint64) error {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	item, ok := that.inflight[id]
+	if !ok {
+		return errors.New(fmt.Sprintf(`message %d is not in flight`, id))
+	}
+	item.acked = true
+	for len(that.inflightSeqs) > 0 {
+		seq := that.inflightSeqs[0]
+		first := that.inflight[seq]
+		if !first.acked {
+			break
+		}
+		that.ackPos = first.end
+		that.inflightSeqs = that.inflightSeqs[1:]
+		delete(that.inflight, seq)
+		that.unsaved++
+	}
+	if that.unsaved >= that.option.CheckpointEvery {
+		return that.checkpoint()
+	}
+	return nil
+}
+
+// 保存读取位置，并删除已经确认的段文件
+func (that *DiskQueue) checkpoint() error {
+	if that.unsaved == 0 {
+		return nil
+	}
+	content, err := json.Marshal(that.ackPos)
+	if err != nil {
+		return err
+	}
+	var (
+		path    = filepath.Join(that.dir, diskCheckpoint)
+		tmpPath = path + ".tmp"
+	)
+	if err = writeFileSync(tmpPath, content); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	// 同步目录，保证重命名在崩溃后仍然有效
+	if err = syncDir(that.dir); err != nil {
+		return err
+	}
+	that.unsaved = 0
+	if that.ackPos.Segment > that.removedBelow {
+		segments, err := listSegments(that.dir)
+		if err != nil {
+			return err
+		}
+		for _, segment := range segments {
+			if segment >= that.ackPos.Segment {
+				break
+			}
+			if err = os.Remove(segmentPath(that.dir, segment)); err != nil {
+				return err
+			}
+		}
+		that.removedBelow = that.ackPos.Segment
+	}
+	return nil
+}
+
+// 后台读取数据写入C
+func (that *DiskQueue) readLoop() {
+	defer close(that.done)
+	for {
+		msg, err := that.next()
+		if err != nil {
+			intlog.Error(context.TODO(), err)
+		}
+		if msg == nil {
+			// 等待新的数据写入、确认超时或者出错后重试
+			wait := that.redeliverDelay()
+			if err != nil && (wait <= 0 || wait > diskRetryInterval) {
+				wait = diskRetryInterval
+			}
+			var (
+				timer   *time.Timer
+				timeout <-chan time.Time
+			)
+			if wait > 0 {
+				timer = time.NewTimer(wait)
+				timeout = timer.C
+			}
+			select {
+			case <-that.notify:
+			case <-timeout:
+			case <-that.closeChan:
+				return
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			continue
+		}
+		select {
+		case that.C <- msg:
+			that.delivered(msg.ID)
+		case <-that.closeChan:
+			return
+		}
+	}
+}
+
+// 数据写入C后开始计算确认超时
+func (that *DiskQueue) delivered(id uint64) {
+	if that.option.AckTimeout <= 0 {
+		return
+	}
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if item, ok := that.inflight[id]; ok && !item.acked {
+		item.deadline = time.Now().Add(that.option.AckTimeout)
+	}
+}
+
+// 取出第一条确认超时的数据，没有时返回nil
+func (that *DiskQueue) expired() *DiskMessage {
+	if that.option.AckTimeout <= 0 {
+		return nil
+	}
+	now := time.Now()
+	for _, seq := range that.inflightSeqs {
+		item := that.inflight[seq]
+		if item.acked || item.deadline.IsZero() || now.Before(item.deadline) {
+			continue
+		}
+		item.deadline = time.Time{}
+		return &DiskMessage{ID: seq, Data: item.data, queue: that}
+	}
+	return nil
+}
+
+// 距离最早的确认超时的时间，没有等待确认的数据时返回0
+func (that *DiskQueue) redeliverDelay() time.Duration {
+	if that.option.AckTimeout <= 0 {
+		return 0
+	}
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	var earliest time.Time
+	for _, item := range that.inflight {
+		if item.acked || item.deadline.IsZero() {
+			continue
+		}
+		if earliest.IsZero() || item.deadline.Before(earliest) {
+			earliest = item.deadline
+		}
+	}
+	if earliest.IsZero() {
+		return 0
+	}
+	if wait := time.Until(earliest); wait > 0 {
+		return wait
+	}
+	return time.Millisecond
+}
+
+// 读取下一条数据，确认超时的数据优先再次出队，没有新数据时返回nil
+func (that *DiskQueue) next() (*DiskMessage, error) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if msg := that.expired(); msg != nil {
+		return msg, nil
+	}
+	for that.readPos.Seq < that.writePos.Seq {
+		if that.readFile == nil {
+			file, err := os.Open(segmentPath(that.dir, that.readPos.Segment))
+			if err != nil {
+				return nil, err
+			}
+			that.readFile = file
+		}
+		data, next, err := readRecord(that.readFile, that.readPos.Offset)
+		if err == nil {
+			msg := &DiskMessage{ID: that.readPos.Seq, Data: data, queue: that}
+			that.readPos.Offset = next
+			that.readPos.Seq++
+			item := &diskInflight{end: that.readPos}
+			if that.option.AckTimeout > 0 {
+				item.data = data
+			}
+			that.inflight[msg.ID] = item
+			that.inflightSeqs = append(that.inflightSeqs, msg.ID)
+			return msg, nil
+		}
+		if that.readPos.Segment >= that.writePos.Segment {
+			// 正在写入的段文件中有损坏的数据，切换到新的段文件写入，跳过损坏的部分
+			intlog.Printf(context.TODO(), `skip corrupt records in segment %d from offset %d: %s`, that.readPos.Segment, that.readPos.Offset, err.Error())
+			if err = that.rollSegment(); err != nil {
+				return nil, err
+			}
+		}
+		// 当前段文件已经读完或者有损坏的数据，切换到下一个段文件
+		if err = that.nextReadSegment(); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// 切换到下一个读取的段文件
+func (that *DiskQueue) nextReadSegment() error {
+	_ = that.readFile.Close()
+	that.readFile = nil
+	segments, err := listSegments(that.dir)
+	if err != nil {
+		return err
+	}
+	for _, segment := range segments {
+		if segment > that.readPos.Segment {
+			that.readPos = diskPosition{Segment: segment, Seq: segment}
+			return nil
+		}
+	}
+	return io.ErrUnexpectedEOF
+}
+
+// 定时同步数据到磁盘
+func (that *DiskQueue) syncLoop() {
+	ticker := time.NewTicker(that.option.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			that.mu.Lock()
+			if err := that.sync(); err != nil {
+				intlog.Error(context.TODO(), err)
+			}
+			that.mu.Unlock()
+		case <-that.closeChan:
+			return
+		}
+	}
+}
+
+// Len 返回队列中没有确认的数据条数，包括已经出队还没有确认的数据
+func (that *DiskQueue) Len() int {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	length := int(that.writePos.Seq - that.ackPos.Seq)
+	for _, item := range that.inflight {
+		if item.acked {
+			length--
+		}
+	}
+	return length
+}
+
+// Size len的别名
+func (that *DiskQueue) Size() int {
+	return that.Len()
+}
+
+// Close 关闭队列，同步数据并保存读取位置，没有确认的数据在重新打开后会再次出队
+func (that *DiskQueue) Close() error {
+	if !that.closed.Cas(false, true) {
+		return nil
+	}
+	close(that.closeChan)
+	<-that.done
+	close(that.C)
+
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	err := that.sync()
+	if e := that.checkpoint(); e != nil && err == nil {
+		err = e
+	}
+	if e := that.writeFile.Close(); e != nil && err == nil {
+		err = e
+	}
+	if that.readFile != nil {
+		_ = that.readFile.Close()
+	}
+	return err
+}
+
+// 写入文件并同步到磁盘
+func writeFileSync(path string, content []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(content); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err = file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// 同步目录，保证目录中的文件创建和重命名写入磁盘
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = file.Sync()
+	if e := file.Close(); e != nil && err == nil {
+		err = e
+	}
+	return err
+}
+
+// Ack 确认数据已经处理完成
+func (that *DiskMessage) Ack() error {
+	return that.queue.Ack(that.ID)
+}
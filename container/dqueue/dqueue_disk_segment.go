@@ -0,0 +1,123 @@
+package dqueue
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	diskSegmentExt    = ".seg"       // 段文件的扩展名
+	diskCheckpoint    = "checkpoint" // 读取位置文件名
+	diskRecordHeadLen = 8            // 记录头的长度，4字节数据长度 + 4字节crc32校验和
+)
+
+var errCorruptRecord = errors.New("corrupt record")
+
+// 磁盘队列中的位置，segment为段文件中第一条数据的序号，offset为段文件中的字节偏移
+type diskPosition struct {
+	Segment uint64 `json:"segment"`
+	Offset  int64  `json:"offset"`
+	Seq     uint64 `json:"seq"` // 该位置的数据序号
+}
+
+// 获取段文件的路径
+func segmentPath(dir string, segment uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", segment, diskSegmentExt))
+}
+
+// 列出目录中的所有段文件，按照序号从小到大排序
+func listSegments(dir string) ([]uint64, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	segments := make([]uint64, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, diskSegmentExt) {
+			continue
+		}
+		segment, err := strconv.ParseUint(strings.TrimSuffix(name, diskSegmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i] < segments[j]
+	})
+	return segments, nil
+}
+
+// 编码一条记录
+func encodeRecord(data []byte) []byte {
+	record := make([]byte, diskRecordHeadLen+len(data))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(data))
+	copy(record[diskRecordHeadLen:], data)
+	return record
+}
+
+// 从<offset>读取一条记录，返回数据和下一条记录的偏移，
+// 数据不完整或者校验失败时返回errCorruptRecord，已经读到末尾时返回io.EOF。
+// 记录的长度只受段文件剩余字节数的限制，MaxMessageSize只在写入时检查，
+// 这样使用更小的MaxMessageSize重新打开队列时，已经写入的数据不会被当作损坏的记录
+func readRecord(file *os.File, offset int64) ([]byte, int64, error) {
+	head := make([]byte, diskRecordHeadLen)
+	n, err := file.ReadAt(head, offset)
+	if n == 0 && err == io.EOF {
+		return nil, offset, io.EOF
+	}
+	if n < diskRecordHeadLen {
+		return nil, offset, errCorruptRecord
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return nil, offset, err
+	}
+	size := int64(binary.BigEndian.Uint32(head[0:4]))
+	if size > info.Size()-offset-diskRecordHeadLen {
+		return nil, offset, errCorruptRecord
+	}
+	data := make([]byte, size)
+	if n, _ = file.ReadAt(data, offset+diskRecordHeadLen); int64(n) < size {
+		return nil, offset, errCorruptRecord
+	}
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(head[4:8]) {
+		return nil, offset, errCorruptRecord
+	}
+	return data, offset + diskRecordHeadLen + size, nil
+}
+
+// 检查段文件从<offset>开始的所有记录，截断末尾损坏的部分，返回有效记录的条数和文件大小
+func recoverSegment(path string, offset int64) (count uint64, size int64, err error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+	for {
+		_, next, err := readRecord(file, offset)
+		if err == io.EOF {
+			return count, offset, nil
+		}
+		if err != nil {
+			// 写入过程中崩溃导致的不完整记录，截断后继续使用
+			if err = file.Truncate(offset); err != nil {
+				return 0, 0, err
+			}
+			return count, offset, file.Sync()
+		}
+		count++
+		offset = next
+	}
+}
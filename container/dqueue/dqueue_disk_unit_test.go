@@ -0,0 +1,243 @@
+package dqueue_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/osgochina/donkeygo/container/dqueue"
+	"github.com/osgochina/donkeygo/os/dfile"
+	"github.com/osgochina/donkeygo/os/dtime"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func diskQueueSegments(dir string) []string {
+	files, _ := dfile.ScanDirFile(dir, "*.seg")
+	return files
+}
+
+func TestDiskQueue_PushPopAck(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		dir := dfile.TempDir(dtime.TimestampNanoStr())
+		defer dfile.Remove(dir)
+
+		q, err := dqueue.NewDisk(dir)
+		t.Assert(err, nil)
+		for i := 0; i < 10; i++ {
+			t.Assert(q.Push(fmt.Sprintf("msg-%d", i)), nil)
+		}
+		t.Assert(q.Push(map[string]int{"a": 1}), nil)
+		t.Assert(q.Len(), 11)
+		for i := 0; i < 10; i++ {
+			msg := q.Pop()
+			t.Assert(string(msg.Data), fmt.Sprintf("msg-%d", i))
+			t.Assert(msg.Ack(), nil)
+		}
+		msg := <-q.C
+		t.Assert(string(msg.Data), `{"a":1}`)
+		t.Assert(q.Len(), 1)
+		t.Assert(msg.Ack(), nil)
+		t.Assert(q.Len(), 0)
+		t.AssertNE(msg.Ack(), nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = q.PopWithContext(ctx)
+		t.Assert(err, context.DeadlineExceeded)
+
+		t.Assert(q.Close(), nil)
+		t.Assert(q.Pop(), nil)
+		t.Assert(q.Push(1), dqueue.ErrQueueClosed)
+	})
+}
+
+func TestDiskQueue_Redeliver(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		dir := dfile.TempDir(dtime.TimestampNanoStr())
+		defer dfile.Remove(dir)
+
+		q, err := dqueue.NewDisk(dir, dqueue.DiskOption{SyncEvery: 1})
+		t.Assert(err, nil)
+		for i := 0; i < 5; i++ {
+			t.Assert(q.Push(fmt.Sprintf("msg-%d", i)), nil)
+		}
+		// 确认第0条和第2条，第1条没有确认
+		m0, m1, m2 := q.Pop(), q.Pop(), q.Pop()
+		t.Assert(m0.Ack(), nil)
+		t.Assert(m2.Ack(), nil)
+		t.Assert(string(m1.Data), "msg-1")
+		t.Assert(q.Len(), 3)
+		t.Assert(q.Close(), nil)
+
+		q, err = dqueue.NewDisk(dir)
+		t.Assert(err, nil)
+		defer q.Close()
+		t.Assert(q.Len(), 4)
+		for i := 1; i < 5; i++ {
+			msg := q.Pop()
+			t.Assert(string(msg.Data), fmt.Sprintf("msg-%d", i))
+			t.Assert(msg.Ack(), nil)
+		}
+		t.Assert(q.Len(), 0)
+		t.Assert(q.Push("next"), nil)
+		t.Assert(string(q.Pop().Data), "next")
+	})
+}
+
+func TestDiskQueue_Segment(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		dir := dfile.TempDir(dtime.TimestampNanoStr())
+		defer dfile.Remove(dir)
+
+		q, err := dqueue.NewDisk(dir, dqueue.DiskOption{SegmentSize: 64})
+		t.Assert(err, nil)
+		defer q.Close()
+		for i := 0; i < 20; i++ {
+			t.Assert(q.Push(fmt.Sprintf("message-%02d", i)), nil)
+		}
+		t.Assert(len(diskQueueSegments(dir)) > 1, true)
+		for i := 0; i < 20; i++ {
+			msg := q.Pop()
+			t.Assert(string(msg.Data), fmt.Sprintf("message-%02d", i))
+			t.Assert(msg.Ack(), nil)
+		}
+		// 已经确认的段文件被删除
+		t.Assert(len(diskQueueSegments(dir)), 1)
+	})
+}
+
+func TestDiskQueue_Recover(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		dir := dfile.TempDir(dtime.TimestampNanoStr())
+		defer dfile.Remove(dir)
+
+		q, err := dqueue.NewDisk(dir)
+		t.Assert(err, nil)
+		for i := 0; i < 3; i++ {
+			t.Assert(q.Push(fmt.Sprintf("msg-%d", i)), nil)
+		}
+		t.Assert(q.Close(), nil)
+
+		// 模拟写入过程中崩溃留下的不完整记录
+		segments := diskQueueSegments(dir)
+		t.Assert(len(segments), 1)
+		size := dfile.Size(segments[0])
+		f, err := os.OpenFile(segments[0], os.O_WRONLY|os.O_APPEND, 0644)
+		t.Assert(err, nil)
+		_, err = f.Write([]byte{0, 0, 0, 100, 1, 2, 3})
+		t.Assert(err, nil)
+		t.Assert(f.Close(), nil)
+
+		q, err = dqueue.NewDisk(dir)
+		t.Assert(err, nil)
+		defer q.Close()
+		t.Assert(dfile.Size(segments[0]), size)
+		t.Assert(q.Len(), 3)
+		t.Assert(q.Push("msg-3"), nil)
+		for i := 0; i < 4; i++ {
+			msg := q.Pop()
+			t.Assert(string(msg.Data), fmt.Sprintf("msg-%d", i))
+			t.Assert(msg.Ack(), nil)
+		}
+	})
+}
+
+func TestDiskQueue_ReopenWithSmallerMaxMessageSize(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		dir := dfile.TempDir(dtime.TimestampNanoStr())
+		defer dfile.Remove(dir)
+
+		large := strings.Repeat("x", 1024)
+		q, err := dqueue.NewDisk(dir)
+		t.Assert(err, nil)
+		t.Assert(q.Push(large), nil)
+		t.Assert(q.Push("msg-1"), nil)
+		t.Assert(q.Close(), nil)
+
+		// 更小的MaxMessageSize只限制新写入的数据，已经写入的数据仍然可以消费
+		q, err = dqueue.NewDisk(dir, dqueue.DiskOption{MaxMessageSize: 64})
+		t.Assert(err, nil)
+		defer q.Close()
+		t.Assert(q.Len(), 2)
+		t.AssertNE(q.Push(large), nil)
+		msg := q.Pop()
+		t.Assert(string(msg.Data), large)
+		t.Assert(msg.Ack(), nil)
+		msg = q.Pop()
+		t.Assert(string(msg.Data), "msg-1")
+		t.Assert(msg.Ack(), nil)
+	})
+}
+
+func TestDiskQueue_CorruptCurrentSegment(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		dir := dfile.TempDir(dtime.TimestampNanoStr())
+		defer dfile.Remove(dir)
+
+		q, err := dqueue.NewDisk(dir)
+		t.Assert(err, nil)
+		defer q.Close()
+		for i := 0; i < 3; i++ {
+			t.Assert(q.Push(fmt.Sprintf("msg-%d", i)), nil)
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		// 破坏正在写入的段文件中第二条记录的数据，每条记录为8字节头加5字节数据
+		segments := diskQueueSegments(dir)
+		t.Assert(len(segments), 1)
+		f, err := os.OpenFile(segments[0], os.O_WRONLY, 0644)
+		t.Assert(err, nil)
+		_, err = f.WriteAt([]byte("x"), 13+8)
+		t.Assert(err, nil)
+		t.Assert(f.Close(), nil)
+
+		msg := q.Pop()
+		t.Assert(string(msg.Data), "msg-0")
+		t.Assert(msg.Ack(), nil)
+		// 损坏位置之后的数据都被跳过，新写入的数据在新的段文件中可以继续消费
+		time.Sleep(20 * time.Millisecond)
+		t.Assert(len(diskQueueSegments(dir)), 2)
+		t.Assert(q.Push("msg-3"), nil)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		msg, err = q.PopWithContext(ctx)
+		t.Assert(err, nil)
+		t.Assert(string(msg.Data), "msg-3")
+		t.Assert(msg.Ack(), nil)
+		t.Assert(len(diskQueueSegments(dir)), 1)
+	})
+}
+
+func TestDiskQueue_AckTimeout(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		dir := dfile.TempDir(dtime.TimestampNanoStr())
+		defer dfile.Remove(dir)
+
+		q, err := dqueue.NewDisk(dir, dqueue.DiskOption{AckTimeout: 100 * time.Millisecond})
+		t.Assert(err, nil)
+		defer q.Close()
+		t.Assert(q.Push("msg-0"), nil)
+		t.Assert(q.Push("msg-1"), nil)
+
+		msg := q.Pop()
+		t.Assert(string(msg.Data), "msg-0")
+		next := q.Pop()
+		t.Assert(string(next.Data), "msg-1")
+		t.Assert(next.Ack(), nil)
+
+		// 超时没有确认的数据再次出队
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		redelivered, err := q.PopWithContext(ctx)
+		t.Assert(err, nil)
+		t.Assert(time.Since(start) >= 50*time.Millisecond, true)
+		t.Assert(redelivered.ID, msg.ID)
+		t.Assert(string(redelivered.Data), "msg-0")
+		t.Assert(q.Len(), 1)
+		t.Assert(redelivered.Ack(), nil)
+		t.Assert(q.Len(), 0)
+	})
+}
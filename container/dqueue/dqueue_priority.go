@@ -0,0 +1,361 @@
+package dqueue
+
+import (
+	"container/heap"
+	"context"
+	"github.com/osgochina/donkeygo/container/dtype"
+	"sync"
+	"time"
+)
+
+// PriorityQueue 优先级队列，与Queue的接口相同，按照比较函数的顺序出队，
+// 比较函数返回值小于0的数据先出队，优先级相同的数据按照入队的顺序出队
+type PriorityQueue struct {
+	mu          sync.Mutex
+	items       *priorityHeap
+	limit       int
+	nonBlocking bool
+	closed      *dtype.Bool
+	closeChan   chan struct{}
+	notEmpty    chan struct{} // 有数据入队时发出通知
+	notFull     chan struct{} // 有数据出队时发出通知
+	pumpWake    chan struct{} // 唤醒向C发送数据的goroutine
+	preempt     chan struct{} // 要求向C发送数据的goroutine把正在发送的数据放回队列
+	sending     *priorityItem // 正在向C发送的数据
+	enqueued    *dtype.Int64
+	dequeued    *dtype.Int64
+	dropped     *dtype.Int64
+	C           chan interface{} // 读取数据的channel，按照优先级顺序发送，队列关闭后该通道会被关闭
+}
+
+// 队列中的数据，seq用于保证相同优先级的数据先进先出
+type priorityItem struct {
+	value interface{}
+	seq   uint64
+}
+
+// 实现heap.Interface的堆
+type priorityHeap struct {
+	items      []priorityItem
+	comparator func(a, b interface{}) int
+	seq        uint64
+}
+
+// NewPriority 创建优先级队列，<comparator>比较两个数据的优先级，可以传入选项创建有界队列
+func NewPriority(comparator func(a, b interface{}) int, option ...Option) *PriorityQueue {
+	q := &PriorityQueue{
+		items:     &priorityHeap{comparator: comparator},
+		closed:    dtype.NewBool(),
+		closeChan: make(chan struct{}),
+		notEmpty:  make(chan struct{}, 1),
+		notFull:   make(chan struct{}, 1),
+		pumpWake:  make(chan struct{}, 1),
+		preempt:   make(chan struct{}, 1),
+		enqueued:  dtype.NewInt64(),
+		dequeued:  dtype.NewInt64(),
+		dropped:   dtype.NewInt64(),
+		C:         make(chan interface{}),
+	}
+	if len(option) > 0 {
+		q.limit = option[0].Limit
+		q.nonBlocking = option[0].NonBlocking
+	}
+	go q.pumpLoop()
+	return q
+}
+
+// Push 入队，有界队列已满时阻塞等待，设置了NonBlocking时直接丢弃，队列已关闭时丢弃，需要知道是否入队时使用PushE
+func (that *PriorityQueue) Push(v interface{}) {
+	_ = that.PushE(v)
+}
+
+// PushE 入队，有界队列已满时阻塞等待，设置了NonBlocking时返回ErrQueueFull，队列已关闭时返回ErrQueueClosed
+func (that *PriorityQueue) PushE(v interface{}) error {
+	return that.push(context.Background(), v, that.nonBlocking)
+}
+
+// TryPush 入队，有界队列已满时不阻塞，直接返回ErrQueueFull
+func (that *PriorityQueue) TryPush(v interface{}) error {
+	return that.push(context.Background(), v, true)
+}
+
+// PushWithContext 入队，有界队列已满时阻塞等待直到<ctx>结束
+func (that *PriorityQueue) PushWithContext(ctx context.Context, v interface{}) error {
+	return that.push(ctx, v, that.nonBlocking)
+}
+
+// 入队，<nonBlocking>为true时有界队列已满直接返回ErrQueueFull
+func (that *PriorityQueue) push(ctx context.Context, v interface{}, nonBlocking bool) error {
+	for {
+		that.mu.Lock()
+		if that.closed.Val() {
+			that.mu.Unlock()
+			that.dropped.Add(1)
+			return ErrQueueClosed
+		}
+		if that.limit <= 0 || that.length() < that.limit {
+			heap.Push(that.items, v)
+			full := that.limit > 0 && that.length() >= that.limit
+			// 新数据的优先级高于正在向C发送的数据时，让其放回队列重新选择
+			if that.sending != nil && that.items.comparator(v, that.sending.value) < 0 {
+				notify(that.preempt)
+			}
+			that.mu.Unlock()
+			that.enqueued.Add(1)
+			notify(that.notEmpty)
+			notify(that.pumpWake)
+			// 还有空位时继续唤醒其他等待写入的goroutine
+			if !full {
+				notify(that.notFull)
+			}
+			return nil
+		}
+		that.mu.Unlock()
+		if nonBlocking {
+			that.dropped.Add(1)
+			return ErrQueueFull
+		}
+		select {
+		case <-that.notFull:
+		case <-that.closeChan:
+		case <-ctx.Done():
+			that.dropped.Add(1)
+			return ctx.Err()
+		}
+	}
+}
+
+// Pop 出队消费，队列为空时阻塞等待，队列已关闭并且没有数据时返回nil
+func (that *PriorityQueue) Pop() interface{} {
+	v, _ := that.PopWithContext(context.Background())
+	return v
+}
+
+// PopWithContext 出队消费，队列为空时阻塞等待直到<ctx>结束，队列已关闭并且没有数据时返回ErrQueueClosed
+func (that *PriorityQueue) PopWithContext(ctx context.Context) (interface{}, error) {
+	for {
+		if v, ok := that.tryPop(); ok {
+			return v, nil
+		}
+		if that.closed.Val() && that.Len() == 0 {
+			return nil, ErrQueueClosed
+		}
+		select {
+		case <-that.notEmpty:
+		case <-that.closeChan:
+		case <-ctx.Done():
+			// 没有取走的数据交还给向C发送数据的goroutine
+			notify(that.pumpWake)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// PopBatch 批量出队，最多获取<n>条数据，获取到<n>条或者等待超过<maxWait>后返回，
+// <maxWait>小于等于0时只获取当前已有的数据
+func (that *PriorityQueue) PopBatch(n int, maxWait time.Duration) []interface{} {
+	items := make([]interface{}, 0, n)
+	var timer *time.Timer
+	if maxWait > 0 {
+		timer = time.NewTimer(maxWait)
+		defer timer.Stop()
+	}
+	for len(items) < n {
+		if v, ok := that.tryPop(); ok {
+			items = append(items, v)
+			continue
+		}
+		if timer == nil || (that.closed.Val() && that.Len() == 0) {
+			notify(that.pumpWake)
+			return items
+		}
+		select {
+		case <-that.notEmpty:
+		case <-that.closeChan:
+		case <-timer.C:
+			notify(that.pumpWake)
+			return items
+		}
+	}
+	return items
+}
+
+// 取出优先级最高的数据，正在向C发送的数据优先级更高时，要求把它放回队列后再取
+func (that *PriorityQueue) tryPop() (interface{}, bool) {
+	that.mu.Lock()
+	if that.sending != nil && (that.items.Len() == 0 || that.items.less(*that.sending, that.items.items[0])) {
+		notify(that.preempt)
+		that.mu.Unlock()
+		return nil, false
+	}
+	if that.items.Len() == 0 {
+		that.mu.Unlock()
+		return nil, false
+	}
+	v := that.items.popItem().value
+	remain := that.items.Len()
+	that.mu.Unlock()
+	that.dequeued.Add(1)
+	notify(that.notFull)
+	// 还有数据时继续唤醒其他等待读取的goroutine
+	if remain > 0 {
+		notify(that.notEmpty)
+		notify(that.pumpWake)
+	}
+	return v, true
+}
+
+// 把优先级最高的数据发送到C，发送过程中有更高优先级的数据入队或者Pop等待数据时，把数据放回队列重新选择，
+// 队列关闭后关闭C，剩余的数据仍然可以通过Pop取出
+func (that *PriorityQueue) pumpLoop() {
+	defer close(that.C)
+	for {
+		that.mu.Lock()
+		if that.items.Len() == 0 {
+			that.mu.Unlock()
+			select {
+			case <-that.pumpWake:
+				continue
+			case <-that.closeChan:
+				return
+			}
+		}
+		// 放回请求只在sending不为空时发出，持有锁时清除残留的请求不会丢失新的请求
+		select {
+		case <-that.preempt:
+		default:
+		}
+		item := that.items.popItem()
+		that.sending = &item
+		that.mu.Unlock()
+		select {
+		case that.C <- item.value:
+			that.mu.Lock()
+			that.sending = nil
+			that.mu.Unlock()
+			that.dequeued.Add(1)
+			notify(that.notFull)
+			// 唤醒因为该数据等待的Pop
+			notify(that.notEmpty)
+		case <-that.preempt:
+			// 有更高优先级的数据入队时直接重新选择，否则是Pop在等待该数据，等Pop取走数据或者有新的数据入队后再继续发送
+			if that.putBack(item) {
+				continue
+			}
+			select {
+			case <-that.pumpWake:
+			case <-that.closeChan:
+				return
+			}
+		case <-that.closeChan:
+			that.putBack(item)
+			return
+		}
+	}
+}
+
+// 把正在发送的数据放回队列，保持原来的入队顺序，返回队列中是否有优先级更高的数据
+func (that *PriorityQueue) putBack(item priorityItem) bool {
+	that.mu.Lock()
+	that.sending = nil
+	heap.Push(that.items, item)
+	higher := that.items.items[0].seq != item.seq
+	if !higher {
+		// 清除放回之前残留的唤醒通知，等待Pop取走数据
+		select {
+		case <-that.pumpWake:
+		default:
+		}
+	}
+	that.mu.Unlock()
+	notify(that.notEmpty)
+	return higher
+}
+
+// Close 关闭队列，关闭后不能再入队，C会被关闭，已经入队的数据仍然可以通过Pop出队
+func (that *PriorityQueue) Close() {
+	if that.closed.Cas(false, true) {
+		close(that.closeChan)
+	}
+}
+
+// Len 返回队列长度，包括正在向C发送的数据
+func (that *PriorityQueue) Len() int {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	return that.length()
+}
+
+// 队列长度，调用时需要持有锁
+func (that *PriorityQueue) length() int {
+	if that.sending != nil {
+		return that.items.Len() + 1
+	}
+	return that.items.Len()
+}
+
+// Size len的别名
+func (that *PriorityQueue) Size() int {
+	return that.Len()
+}
+
+// Stats 获取队列的统计信息
+func (that *PriorityQueue) Stats() Stats {
+	return Stats{
+		Enqueued: that.enqueued.Val(),
+		Dequeued: that.dequeued.Val(),
+		Dropped:  that.dropped.Val(),
+	}
+}
+
+// 发送通知，已经有未处理的通知时不重复发送
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func (h *priorityHeap) Len() int {
+	return len(h.items)
+}
+
+func (h *priorityHeap) Less(i, j int) bool {
+	return h.less(h.items[i], h.items[j])
+}
+
+// 比较两个数据，优先级相同时序号小的在前
+func (h *priorityHeap) less(a, b priorityItem) bool {
+	if c := h.comparator(a.value, b.value); c != 0 {
+		return c < 0
+	}
+	return a.seq < b.seq
+}
+
+func (h *priorityHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+// Push 加入数据，放回的数据保持原来的序号
+func (h *priorityHeap) Push(x interface{}) {
+	if item, ok := x.(priorityItem); ok {
+		h.items = append(h.items, item)
+		return
+	}
+	h.seq++
+	h.items = append(h.items, priorityItem{value: x, seq: h.seq})
+}
+
+// Pop 取出最后一个数据，包括其序号
+func (h *priorityHeap) Pop() interface{} {
+	n := len(h.items) - 1
+	item := h.items[n]
+	h.items[n] = priorityItem{}
+	h.items = h.items[:n]
+	return item
+}
+
+// 取出优先级最高的数据，包括其序号
+func (h *priorityHeap) popItem() priorityItem {
+	return heap.Pop(h).(priorityItem)
+}
@@ -0,0 +1,197 @@
+package dqueue_test
+
+import (
+	"context"
+	"github.com/osgochina/donkeygo/container/dqueue"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 数值越大优先级越高
+func priorityComparator(a, b interface{}) int {
+	return b.(int) - a.(int)
+}
+
+type task struct {
+	priority int
+	name     string
+}
+
+func TestPriorityQueue_Basic(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		q := dqueue.NewPriority(priorityComparator)
+		for _, v := range []int{3, 1, 5, 2, 4} {
+			t.Assert(q.PushE(v), nil)
+		}
+		t.Assert(q.Len(), 5)
+		t.Assert(q.Size(), 5)
+		t.Assert(q.Pop(), 5)
+		t.Assert(q.PopBatch(10, 0), []interface{}{4, 3, 2, 1})
+		t.Assert(q.Stats(), dqueue.Stats{Enqueued: 5, Dequeued: 5})
+	})
+	// 优先级相同时先进先出
+	dtest.C(t, func(t *dtest.T) {
+		q := dqueue.NewPriority(func(a, b interface{}) int {
+			return b.(task).priority - a.(task).priority
+		})
+		q.Push(task{1, "a"})
+		q.Push(task{2, "b"})
+		q.Push(task{1, "c"})
+		q.Push(task{2, "d"})
+		names := ""
+		for q.Len() > 0 {
+			names += q.Pop().(task).name
+		}
+		t.Assert(names, "bdac")
+	})
+}
+
+func TestPriorityQueue_Bounded(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		q := dqueue.NewPriority(priorityComparator, dqueue.Option{Limit: 2, NonBlocking: true})
+		t.Assert(q.PushE(1), nil)
+		t.Assert(q.PushE(2), nil)
+		t.Assert(q.PushE(3), dqueue.ErrQueueFull)
+		t.Assert(q.Stats().Dropped, 1)
+	})
+	dtest.C(t, func(t *dtest.T) {
+		q := dqueue.NewPriority(priorityComparator, dqueue.Option{Limit: 1})
+		t.Assert(q.PushE(1), nil)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		t.Assert(q.PushWithContext(ctx, 2), context.DeadlineExceeded)
+
+		done := make(chan error, 1)
+		go func() {
+			done <- q.PushE(3)
+		}()
+		time.Sleep(50 * time.Millisecond)
+		t.Assert(q.Pop(), 1)
+		t.Assert(<-done, nil)
+		t.Assert(q.Pop(), 3)
+	})
+}
+
+func TestPriorityQueue_Pop(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		q := dqueue.NewPriority(priorityComparator)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := q.PopWithContext(ctx)
+		t.Assert(err, context.DeadlineExceeded)
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			q.Push(1)
+		}()
+		t.Assert(q.Pop(), 1)
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			q.Push(1)
+			q.Push(2)
+		}()
+		t.Assert(q.PopBatch(2, 3*time.Second), []interface{}{2, 1})
+	})
+	// 关闭后可以继续取出已有的数据
+	dtest.C(t, func(t *dtest.T) {
+		q := dqueue.NewPriority(priorityComparator)
+		q.Push(1)
+		q.Close()
+		t.Assert(q.PushE(2), dqueue.ErrQueueClosed)
+		t.Assert(q.Pop(), 1)
+		t.Assert(q.Pop(), nil)
+		_, err := q.PopWithContext(context.Background())
+		t.Assert(err, dqueue.ErrQueueClosed)
+	})
+}
+
+func TestPriorityQueue_Concurrent(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			q     = dqueue.NewPriority(priorityComparator, dqueue.Option{Limit: 10})
+			wg    sync.WaitGroup
+			total = 1000
+			sum   = make(chan int, 4)
+		)
+		for i := 0; i < 4; i++ {
+			go func() {
+				s := 0
+				for {
+					v, err := q.PopWithContext(context.Background())
+					if err != nil {
+						sum <- s
+						return
+					}
+					s += v.(int)
+				}
+			}()
+		}
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < total/4; j++ {
+					q.Push(1)
+				}
+			}()
+		}
+		wg.Wait()
+		for q.Len() > 0 {
+			time.Sleep(time.Millisecond)
+		}
+		q.Close()
+		result := 0
+		for i := 0; i < 4; i++ {
+			result += <-sum
+		}
+		t.Assert(result, total)
+		t.Assert(q.Stats(), dqueue.Stats{Enqueued: int64(total), Dequeued: int64(total)})
+	})
+}
+
+func TestPriorityQueue_C(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		q := dqueue.NewPriority(priorityComparator)
+		for _, v := range []int{3, 1, 5, 2, 4} {
+			q.Push(v)
+		}
+		t.Assert(q.Len(), 5)
+		result := make([]interface{}, 0, 5)
+		for i := 0; i < 5; i++ {
+			result = append(result, <-q.C)
+		}
+		t.Assert(result, []interface{}{5, 4, 3, 2, 1})
+		t.Assert(q.Len(), 0)
+		t.Assert(q.Stats(), dqueue.Stats{Enqueued: 5, Dequeued: 5})
+	})
+	// 正在发送的数据不影响Pop的顺序，高优先级的数据入队后先从C中读出
+	dtest.C(t, func(t *dtest.T) {
+		q := dqueue.NewPriority(priorityComparator)
+		q.Push(1)
+		time.Sleep(20 * time.Millisecond)
+		q.Push(2)
+		t.Assert(q.Pop(), 2)
+		t.Assert(q.Pop(), 1)
+		q.Push(1)
+		time.Sleep(20 * time.Millisecond)
+		q.Push(3)
+		time.Sleep(20 * time.Millisecond)
+		t.Assert(<-q.C, 3)
+		t.Assert(<-q.C, 1)
+	})
+	// 关闭后C被关闭，剩余的数据仍然可以通过Pop取出
+	dtest.C(t, func(t *dtest.T) {
+		q := dqueue.NewPriority(priorityComparator)
+		q.Push(1)
+		q.Push(2)
+		time.Sleep(20 * time.Millisecond)
+		q.Close()
+		_, ok := <-q.C
+		t.Assert(ok, false)
+		t.Assert(q.Len(), 2)
+		t.Assert(q.PopBatch(10, 0), []interface{}{2, 1})
+	})
+}
@@ -0,0 +1,98 @@
+package dqueue
+
+import (
+	"sync/atomic"
+)
+
+// RingQueue 基于环形缓冲区的无锁有界队列，支持多生产者多消费者并发读写，
+// 写满时Push返回ErrQueueFull，为空时Pop返回false，不会阻塞调用方
+type RingQueue struct {
+	_     [8]uint64 // 填充，避免head和tail与其他数据处于同一个缓存行
+	head  uint64    // 下一个读取的位置
+	_     [7]uint64
+	tail  uint64 // 下一个写入的位置
+	_     [7]uint64
+	mask  uint64
+	slots []ringSlot
+}
+
+// 环形缓冲区的槽位，seq表示槽位的状态：
+// seq等于写入位置时可以写入，seq等于写入位置+1时可以读取
+type ringSlot struct {
+	seq   uint64
+	value interface{}
+}
+
+// NewRingQueue 创建无锁环形队列，容量会向上取整为2的幂
+func NewRingQueue(capacity int) *RingQueue {
+	size := 2
+	for size < capacity {
+		size <<= 1
+	}
+	q := &RingQueue{
+		mask:  uint64(size - 1),
+		slots: make([]ringSlot, size),
+	}
+	for i := range q.slots {
+		q.slots[i].seq = uint64(i)
+	}
+	return q
+}
+
+// Push 写入数据，队列已满时返回ErrQueueFull
+func (that *RingQueue) Push(v interface{}) error {
+	pos := atomic.LoadUint64(&that.tail)
+	for {
+		slot := &that.slots[pos&that.mask]
+		seq := atomic.LoadUint64(&slot.seq)
+		switch diff := int64(seq - pos); {
+		case diff == 0:
+			if atomic.CompareAndSwapUint64(&that.tail, pos, pos+1) {
+				slot.value = v
+				atomic.StoreUint64(&slot.seq, pos+1)
+				return nil
+			}
+		case diff < 0:
+			// 槽位中的数据还没有被读取，队列已满
+			return ErrQueueFull
+		}
+		pos = atomic.LoadUint64(&that.tail)
+	}
+}
+
+// Pop 读取数据，队列为空时返回false
+func (that *RingQueue) Pop() (interface{}, bool) {
+	pos := atomic.LoadUint64(&that.head)
+	for {
+		slot := &that.slots[pos&that.mask]
+		seq := atomic.LoadUint64(&slot.seq)
+		switch diff := int64(seq - (pos + 1)); {
+		case diff == 0:
+			if atomic.CompareAndSwapUint64(&that.head, pos, pos+1) {
+				v := slot.value
+				slot.value = nil
+				atomic.StoreUint64(&slot.seq, pos+that.mask+1)
+				return v, true
+			}
+		case diff < 0:
+			// 槽位还没有写入数据，队列为空
+			return nil, false
+		}
+		pos = atomic.LoadUint64(&that.head)
+	}
+}
+
+// Len 队列中的数据条数，并发读写时只是一个近似值
+func (that *RingQueue) Len() int {
+	head := atomic.LoadUint64(&that.head)
+	tail := atomic.LoadUint64(&that.tail)
+	if tail < head {
+		return 0
+	}
+	return int(tail - head)
+}
+
+// Cap 队列的容量
+func (that *RingQueue) Cap() int {
+	return len(that.slots)
+}
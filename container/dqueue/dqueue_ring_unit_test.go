@@ -0,0 +1,104 @@
+package dqueue_test
+
+import (
+	"github.com/osgochina/donkeygo/container/dqueue"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestRingQueue_Basic(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		q := dqueue.NewRingQueue(3)
+		t.Assert(q.Cap(), 4)
+		for i := 0; i < 4; i++ {
+			t.Assert(q.Push(i), nil)
+		}
+		t.Assert(q.Push(4), dqueue.ErrQueueFull)
+		t.Assert(q.Len(), 4)
+		for i := 0; i < 4; i++ {
+			v, ok := q.Pop()
+			t.Assert(ok, true)
+			t.Assert(v, i)
+		}
+		_, ok := q.Pop()
+		t.Assert(ok, false)
+		t.Assert(q.Len(), 0)
+
+		// 环绕写入
+		for i := 0; i < 10; i++ {
+			t.Assert(q.Push(i), nil)
+			v, _ := q.Pop()
+			t.Assert(v, i)
+		}
+	})
+}
+
+func TestRingQueue_Concurrent(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			q         = dqueue.NewRingQueue(64)
+			producers = 4
+			consumers = 4
+			count     = 10000
+			wg        sync.WaitGroup
+			mu        sync.Mutex
+			seen      = make(map[int]int)
+		)
+		for p := 0; p < producers; p++ {
+			wg.Add(1)
+			go func(p int) {
+				defer wg.Done()
+				for i := 0; i < count; i++ {
+					for q.Push(p*count+i) != nil {
+						runtime.Gosched()
+					}
+				}
+			}(p)
+		}
+		results := make(chan int, producers*count)
+		done := make(chan struct{})
+		var cwg sync.WaitGroup
+		for c := 0; c < consumers; c++ {
+			cwg.Add(1)
+			go func() {
+				defer cwg.Done()
+				for {
+					if v, ok := q.Pop(); ok {
+						results <- v.(int)
+						continue
+					}
+					select {
+					case <-done:
+						// 生产者已经结束，取完剩余的数据后退出
+						for {
+							v, ok := q.Pop()
+							if !ok {
+								return
+							}
+							results <- v.(int)
+						}
+					default:
+						runtime.Gosched()
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		close(done)
+		cwg.Wait()
+		close(results)
+		for v := range results {
+			mu.Lock()
+			seen[v]++
+			mu.Unlock()
+		}
+		t.Assert(len(seen), producers*count)
+		for _, n := range seen {
+			if n != 1 {
+				t.Error("duplicated value")
+			}
+		}
+	})
+}
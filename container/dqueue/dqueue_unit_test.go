@@ -1,6 +1,7 @@
 package dqueue_test
 
 import (
+	"context"
 	"github.com/osgochina/donkeygo/container/dqueue"
 	"github.com/osgochina/donkeygo/test/dtest"
 	"testing"
@@ -54,3 +55,119 @@ func TestQueue_Close(t *testing.T) {
 		q1.Close()
 	})
 }
+
+func TestQueue_Bounded(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		q := dqueue.NewWithOption(dqueue.Option{Limit: 2, NonBlocking: true})
+		t.Assert(q.PushE(1), nil)
+		t.Assert(q.PushE(2), nil)
+		t.Assert(q.PushE(3), dqueue.ErrQueueFull)
+		t.Assert(q.Len(), 2)
+		t.Assert(q.Pop(), 1)
+		t.Assert(q.Stats(), dqueue.Stats{Enqueued: 2, Dequeued: 1, Dropped: 1})
+	})
+	dtest.C(t, func(t *dtest.T) {
+		q := dqueue.New(1)
+		t.Assert(q.PushE(1), nil)
+		done := make(chan error, 1)
+		go func() {
+			done <- q.PushE(2)
+		}()
+		select {
+		case <-done:
+			t.Error("push should block when queue is full")
+		case <-time.After(50 * time.Millisecond):
+		}
+		t.Assert(q.Pop(), 1)
+		t.Assert(<-done, nil)
+		t.Assert(q.Pop(), 2)
+	})
+	// 关闭时唤醒阻塞的写入
+	dtest.C(t, func(t *dtest.T) {
+		q := dqueue.New(1)
+		t.Assert(q.PushE(1), nil)
+		done := make(chan error, 1)
+		go func() {
+			done <- q.PushE(2)
+		}()
+		time.Sleep(50 * time.Millisecond)
+		q.Close()
+		t.Assert(<-done, dqueue.ErrQueueClosed)
+		t.Assert(q.PushE(3), dqueue.ErrQueueClosed)
+	})
+}
+
+func TestQueue_Context(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		q := dqueue.New(1)
+		t.Assert(q.PushE(1), nil)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		t.Assert(q.PushWithContext(ctx, 2), context.DeadlineExceeded)
+		t.Assert(q.Stats().Dropped, 1)
+
+		v, err := q.PopWithContext(context.Background())
+		t.Assert(err, nil)
+		t.Assert(v, 1)
+		ctx2, cancel2 := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel2()
+		v, err = q.PopWithContext(ctx2)
+		t.Assert(err, context.DeadlineExceeded)
+		t.Assert(v, nil)
+	})
+	dtest.C(t, func(t *dtest.T) {
+		q := dqueue.New(1)
+		q.Close()
+		_, err := q.PopWithContext(context.Background())
+		t.Assert(err, dqueue.ErrQueueClosed)
+	})
+}
+
+func TestQueue_PopBatch(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		q := dqueue.New()
+		for i := 0; i < 5; i++ {
+			t.Assert(q.PushE(i), nil)
+		}
+		t.Assert(q.PopBatch(3, time.Second), []interface{}{0, 1, 2})
+
+		start := time.Now()
+		t.Assert(q.PopBatch(10, 100*time.Millisecond), []interface{}{3, 4})
+		t.Assert(time.Since(start) >= 100*time.Millisecond, true)
+
+		t.Assert(len(q.PopBatch(10, 0)), 0)
+		t.Assert(q.Stats(), dqueue.Stats{Enqueued: 5, Dequeued: 5})
+	})
+	dtest.C(t, func(t *dtest.T) {
+		q := dqueue.New(10)
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			q.Push(1)
+			q.Push(2)
+		}()
+		t.Assert(q.PopBatch(2, 3*time.Second), []interface{}{1, 2})
+	})
+}
+
+func TestQueue_TryPush(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		q := dqueue.New(1)
+		t.Assert(q.TryPush(1), nil)
+		t.Assert(q.TryPush(2), dqueue.ErrQueueFull)
+		t.Assert(q.Pop(), 1)
+		q.Push(3)
+		t.Assert(q.Pop(), 3)
+		q.Close()
+		t.Assert(q.TryPush(4), dqueue.ErrQueueClosed)
+		t.Assert(q.Stats(), dqueue.Stats{Enqueued: 2, Dequeued: 2, Dropped: 2})
+	})
+	// 直接从C中读取的数据不计入出队条数
+	dtest.C(t, func(t *dtest.T) {
+		q := dqueue.New()
+		q.Push(1)
+		q.Push(2)
+		t.Assert(<-q.C, 1)
+		t.Assert(q.Pop(), 2)
+		t.Assert(q.Stats(), dqueue.Stats{Enqueued: 2, Dequeued: 1})
+	})
+}
@@ -1,187 +1,105 @@
 package dset
 
 import (
-	"bytes"
+	"github.com/osgochina/donkeygo/container/dgeneric"
 	"github.com/osgochina/donkeygo/internal/json"
-	"github.com/osgochina/donkeygo/internal/rwmutex"
 	"github.com/osgochina/donkeygo/util/dconv"
 )
 
+// IntSet 基于dgeneric.Set[int]实现的集合，零值可以直接使用
 type IntSet struct {
-	mu   rwmutex.RWMutex
-	data map[int]struct{}
+	data *dgeneric.Set[int]
 }
 
 // NewIntSet 创建一个int类型的集合
 func NewIntSet(safe ...bool) *IntSet {
 	return &IntSet{
-		mu:   rwmutex.Create(safe...),
-		data: make(map[int]struct{}),
+		data: dgeneric.NewSet[int](safe...),
 	}
 }
 
 // NewIntSetFrom 从int类型的切片创建一个int类型的集合
 func NewIntSetFrom(items []int, safe ...bool) *IntSet {
-	m := make(map[int]struct{})
-	for _, v := range items {
-		m[v] = struct{}{}
-	}
 	return &IntSet{
-		mu:   rwmutex.Create(safe...),
-		data: m,
+		data: dgeneric.NewSetFrom(items, safe...),
+	}
+}
+
+// generic 返回底层的泛型集合，零值的集合在第一次使用时初始化
+func (set *IntSet) generic() *dgeneric.Set[int] {
+	if set.data == nil {
+		set.data = dgeneric.NewSet[int]()
 	}
+	return set.data
+}
+
+// intSetGenerics 把IntSet切片转换成底层的泛型集合切片
+func intSetGenerics(sets []*IntSet) []*dgeneric.Set[int] {
+	array := make([]*dgeneric.Set[int], len(sets))
+	for i, s := range sets {
+		array[i] = s.generic()
+	}
+	return array
 }
 
 // Iterator 对这个集合迭代
 func (set *IntSet) Iterator(f func(v int) bool) {
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	for k, _ := range set.data {
-		if !f(k) {
-			break
-		}
-	}
+	set.generic().Iterator(f)
 }
 
 // Add 添加一个或多个int元素到集合
 func (set *IntSet) Add(item ...int) {
-	set.mu.Lock()
-	if set.data == nil {
-		set.data = make(map[int]struct{})
-	}
-	for _, v := range item {
-		set.data[v] = struct{}{}
-	}
-	set.mu.Unlock()
+	set.generic().Add(item...)
 }
 
 // AddIfNotExist 检查要添加的元素是否存在，不存在则添加，成功后返回true
 // 如果已存在这个元素，则返回false
 func (set *IntSet) AddIfNotExist(item int) bool {
-	if !set.Contains(item) {
-		set.mu.Lock()
-		defer set.mu.Unlock()
-		if set.data == nil {
-			set.data = make(map[int]struct{})
-		}
-		if _, ok := set.data[item]; !ok {
-			set.data[item] = struct{}{}
-			return true
-		}
-	}
-	return false
+	return set.generic().AddIfNotExist(item)
 }
 
 // AddIfNotExistFunc 检查要添加的元素是否存在，不存在则执行f方法，f方法返回成功则继续添加，成功后返回true
 // 如果已存在这个元素或f方法执行返回false，则返回false
 // 注意执行f方法不占用锁
 func (set *IntSet) AddIfNotExistFunc(item int, f func() bool) bool {
-	if !set.Contains(item) {
-		if f() {
-			set.mu.Lock()
-			defer set.mu.Unlock()
-			if set.data == nil {
-				set.data = make(map[int]struct{})
-			}
-			if _, ok := set.data[item]; !ok {
-				set.data[item] = struct{}{}
-				return true
-			}
-		}
-	}
-	return false
+	return set.generic().AddIfNotExistFunc(item, f)
 }
 
 // AddIfNotExistFuncLock 检查要添加的元素是否存在，不存在则执行f方法，f方法返回成功则继续添加，成功后返回true
 // 如果已存在这个元素或f方法执行返回false，则返回false
 // 注意执行f方法占用锁
 func (set *IntSet) AddIfNotExistFuncLock(item int, f func() bool) bool {
-	if !set.Contains(item) {
-		set.mu.Lock()
-		defer set.mu.Unlock()
-		if set.data == nil {
-			set.data = make(map[int]struct{})
-		}
-		if f() {
-			if _, ok := set.data[item]; !ok {
-				set.data[item] = struct{}{}
-				return true
-			}
-		}
-	}
-	return false
+	return set.generic().AddIfNotExistFuncLock(item, f)
 }
 
 // Contains 判断集合中是否存在元素item
 func (set *IntSet) Contains(item int) bool {
-	var ok bool
-	set.mu.RLock()
-	if set.data != nil {
-		_, ok = set.data[item]
-	}
-	set.mu.RUnlock()
-	return ok
+	return set.generic().Contains(item)
 }
 
 // Remove 从集合中删除元素item
 func (set *IntSet) Remove(item int) {
-	set.mu.Lock()
-	if set.data != nil {
-		delete(set.data, item)
-	}
-	set.mu.Unlock()
+	set.generic().Remove(item)
 }
 
 // Size returns the size of the set.
 func (set *IntSet) Size() int {
-	set.mu.RLock()
-	l := len(set.data)
-	set.mu.RUnlock()
-	return l
+	return set.generic().Size()
 }
 
 // Clear deletes all items of the set.
 func (set *IntSet) Clear() {
-	set.mu.Lock()
-	set.data = make(map[int]struct{})
-	set.mu.Unlock()
+	set.generic().Clear()
 }
 
 // Slice returns the a of items of the set as slice.
 func (set *IntSet) Slice() []int {
-	set.mu.RLock()
-	var (
-		i   = 0
-		ret = make([]int, len(set.data))
-	)
-	for k, _ := range set.data {
-		ret[i] = k
-		i++
-	}
-	set.mu.RUnlock()
-	return ret
+	return set.generic().Slice()
 }
 
 // Join 使用<glue>连接集合，并返回连接后的字符串
 func (set *IntSet) Join(glue string) string {
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	if len(set.data) == 0 {
-		return ""
-	}
-	var (
-		l      = len(set.data)
-		i      = 0
-		buffer = bytes.NewBuffer(nil)
-	)
-	for k, _ := range set.data {
-		buffer.WriteString(dconv.String(k))
-		if i != l-1 {
-			buffer.WriteString(glue)
-		}
-		i++
-	}
-	return buffer.String()
+	return set.generic().Join(glue)
 }
 
 // String returns items as a string, which implements like json.Marshal does.
@@ -191,125 +109,43 @@ func (set *IntSet) String() string {
 
 // LockFunc 加锁执行f
 func (set *IntSet) LockFunc(f func(m map[int]struct{})) {
-	set.mu.Lock()
-	defer set.mu.Unlock()
-	f(set.data)
+	set.generic().LockFunc(f)
 }
 
 // RLockFunc 加读锁执行f
 func (set *IntSet) RLockFunc(f func(m map[int]struct{})) {
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	f(set.data)
+	set.generic().RLockFunc(f)
 }
 
 // Equal 判断一个或多个集合是否相等
 func (set *IntSet) Equal(other *IntSet) bool {
-	if set == other {
-		return true
-	}
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	other.mu.RLock()
-	defer other.mu.RUnlock()
-	if len(set.data) != len(other.data) {
-		return false
-	}
-	for key := range set.data {
-		if _, ok := other.data[key]; !ok {
-			return false
-		}
-	}
-	return true
+	return set.generic().Equal(other.generic())
 }
 
 // IsSubsetOf 检查当前集合是否为other集合的子集
 func (set *IntSet) IsSubsetOf(other *IntSet) bool {
-	if set == other {
-		return true
-	}
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	other.mu.RLock()
-	defer other.mu.RUnlock()
-	for key := range set.data {
-		if _, ok := other.data[key]; !ok {
-			return false
-		}
-	}
-	return true
+	return set.generic().IsSubsetOf(other.generic())
 }
 
 // Union returns a new set which is the union of <set> and <other>.
 // Which means, all the items in <newSet> are in <set> or in <other>.
-//求多个集合的并集
+// 求多个集合的并集
 func (set *IntSet) Union(others ...*IntSet) (newSet *IntSet) {
-	newSet = NewIntSet()
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	for _, other := range others {
-		if set != other {
-			other.mu.RLock()
-		}
-		for k, v := range set.data {
-			newSet.data[k] = v
-		}
-		if set != other {
-			for k, v := range other.data {
-				newSet.data[k] = v
-			}
-		}
-		if set != other {
-			other.mu.RUnlock()
-		}
-	}
-
-	return
+	return &IntSet{data: set.generic().Union(intSetGenerics(others)...)}
 }
 
 // Diff returns a new set which is the difference set from <set> to <other>.
 // Which means, all the items in <newSet> are in <set> but not in <other>.
 // 求多个集合差集
 func (set *IntSet) Diff(others ...*IntSet) (newSet *IntSet) {
-	newSet = NewIntSet()
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	for _, other := range others {
-		if set == other {
-			continue
-		}
-		other.mu.RLock()
-		for k, v := range set.data {
-			if _, ok := other.data[k]; !ok {
-				newSet.data[k] = v
-			}
-		}
-		other.mu.RUnlock()
-	}
-	return
+	return &IntSet{data: set.generic().Diff(intSetGenerics(others)...)}
 }
 
 // Intersect returns a new set which is the intersection from <set> to <other>.
 // Which means, all the items in <newSet> are in <set> and also in <other>.
-//求多个集合的交集
+// 求多个集合的交集
 func (set *IntSet) Intersect(others ...*IntSet) (newSet *IntSet) {
-	newSet = NewIntSet()
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	for _, other := range others {
-		if set != other {
-			other.mu.RLock()
-		}
-		for k, v := range set.data {
-			if _, ok := other.data[k]; ok {
-				newSet.data[k] = v
-			}
-		}
-		if set != other {
-			other.mu.RUnlock()
-		}
-	}
-	return
+	return &IntSet{data: set.generic().Intersect(intSetGenerics(others)...)}
 }
 
 // Complement returns a new set which is the complement from <set> to <full>.
@@ -317,39 +153,15 @@ func (set *IntSet) Intersect(others ...*IntSet) (newSet *IntSet) {
 //
 // It returns the difference between <full> and <set>
 // if the given set <full> is not the full set of <set>.
-//求指定full集合对与当前集合的补集
+// 求指定full集合对与当前集合的补集
 func (set *IntSet) Complement(full *IntSet) (newSet *IntSet) {
-	newSet = NewIntSet()
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	if set != full {
-		full.mu.RLock()
-		defer full.mu.RUnlock()
-	}
-	for k, v := range full.data {
-		if _, ok := set.data[k]; !ok {
-			newSet.data[k] = v
-		}
-	}
-	return
+	return &IntSet{data: set.generic().Complement(full.generic())}
 }
 
 // Merge adds items from <others> sets into <set>.
 // 合并多个集合
 func (set *IntSet) Merge(others ...*IntSet) *IntSet {
-	set.mu.Lock()
-	defer set.mu.Unlock()
-	for _, other := range others {
-		if set != other {
-			other.mu.RLock()
-		}
-		for k, v := range other.data {
-			set.data[k] = v
-		}
-		if set != other {
-			other.mu.RUnlock()
-		}
-	}
+	set.generic().Merge(intSetGenerics(others)...)
 	return set
 }
 
@@ -358,90 +170,44 @@ func (set *IntSet) Merge(others ...*IntSet) *IntSet {
 // or you'd get a result that you unexpected.
 // 计算集合的和
 func (set *IntSet) Sum() (sum int) {
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	for k, _ := range set.data {
-		sum += k
-	}
+	set.generic().RLockFunc(func(m map[int]struct{}) {
+		for k := range m {
+			sum += k
+		}
+	})
 	return
 }
 
 // Pop randomly pops an item from set.
 func (set *IntSet) Pop() int {
-	set.mu.Lock()
-	defer set.mu.Unlock()
-	for k, _ := range set.data {
-		delete(set.data, k)
-		return k
-	}
-	return 0
+	item, _ := set.generic().Pop()
+	return item
 }
 
 // Pops randomly pops <size> items from set.
 // It returns all items if size == -1.
 func (set *IntSet) Pops(size int) []int {
-	set.mu.Lock()
-	defer set.mu.Unlock()
-	if size > len(set.data) || size == -1 {
-		size = len(set.data)
-	}
-	if size <= 0 {
-		return nil
-	}
-	index := 0
-	array := make([]int, size)
-	for k, _ := range set.data {
-		delete(set.data, k)
-		array[index] = k
-		index++
-		if index == size {
-			break
-		}
-	}
-	return array
+	return set.generic().Pops(size)
 }
 
 // Walk applies a user supplied function <f> to every item of set.
 func (set *IntSet) Walk(f func(item int) int) *IntSet {
-	set.mu.Lock()
-	defer set.mu.Unlock()
-	m := make(map[int]struct{}, len(set.data))
-	for k, v := range set.data {
-		m[f(k)] = v
-	}
-	set.data = m
+	set.generic().Walk(f)
 	return set
 }
 
 // MarshalJSON implements the interface MarshalJSON for json.Marshal.
 func (set *IntSet) MarshalJSON() ([]byte, error) {
-	return json.Marshal(set.Slice())
+	return set.generic().MarshalJSON()
 }
 
 // UnmarshalJSON implements the interface UnmarshalJSON for json.Unmarshal.
 func (set *IntSet) UnmarshalJSON(b []byte) error {
-	set.mu.Lock()
-	defer set.mu.Unlock()
-	if set.data == nil {
-		set.data = make(map[int]struct{})
-	}
-	var array []int
-	if err := json.UnmarshalUseNumber(b, &array); err != nil {
-		return err
-	}
-	for _, v := range array {
-		set.data[v] = struct{}{}
-	}
-	return nil
+	return set.generic().UnmarshalJSON(b)
 }
 
 // UnmarshalValue is an interface implement which sets any type of value for set.
 func (set *IntSet) UnmarshalValue(value interface{}) (err error) {
-	set.mu.Lock()
-	defer set.mu.Unlock()
-	if set.data == nil {
-		set.data = make(map[int]struct{})
-	}
 	var array []int
 	switch value.(type) {
 	case string, []byte:
@@ -449,8 +215,6 @@ func (set *IntSet) UnmarshalValue(value interface{}) (err error) {
 	default:
 		array = dconv.SliceInt(value)
 	}
-	for _, v := range array {
-		set.data[v] = struct{}{}
-	}
+	set.generic().Add(array...)
 	return
 }
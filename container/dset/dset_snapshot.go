@@ -0,0 +1,95 @@
+package dset
+
+import (
+	"github.com/osgochina/donkeygo/container/dsnapshot"
+	"github.com/osgochina/donkeygo/util/dconv"
+	"io"
+)
+
+// 把复制出来的集合数据以dsnapshot的二进制格式写入w，value(i)返回第i个元素，option用于指定元素的编解码器。
+// 调用方需要先在读锁内复制集合的元素，这样写入的过程不会阻塞其他协程对集合的读写
+func snapshotList(w io.Writer, size int, value func(i int) interface{}, option []dsnapshot.Option) error {
+	return dsnapshot.WriteList(w, func(f func(value interface{}) bool) {
+		for i := 0; i < size; i++ {
+			if !f(value(i)) {
+				return
+			}
+		}
+	}, option...)
+}
+
+// Snapshot 把集合的元素写入快照
+func (that *Set) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	items := that.Slice()
+	return snapshotList(w, len(items), func(i int) interface{} { return items[i] }, option)
+}
+
+// Restore 使用从r读取的快照替换集合的元素，快照不完整或者校验失败时不会修改集合
+func (that *Set) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	items, err := dsnapshot.ReadList(r, option...)
+	if err != nil {
+		return err
+	}
+	data := make(map[interface{}]struct{}, len(items))
+	for _, v := range items {
+		data[v] = struct{}{}
+	}
+	that.mu.Lock()
+	that.data = data
+	that.mu.Unlock()
+	return nil
+}
+
+// Snapshot 把集合的元素写入快照
+func (set *IntSet) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	items := set.Slice()
+	return snapshotList(w, len(items), func(i int) interface{} { return items[i] }, option)
+}
+
+// Restore 使用从r读取的快照替换集合的元素，快照不完整或者校验失败时不会修改集合
+func (set *IntSet) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	items, err := dsnapshot.ReadList(r, option...)
+	if err != nil {
+		return err
+	}
+	data := make(map[int]struct{}, len(items))
+	for _, v := range items {
+		data[dconv.Int(v)] = struct{}{}
+	}
+	set.generic().LockFunc(func(m map[int]struct{}) {
+		for k := range m {
+			delete(m, k)
+		}
+		for k := range data {
+			m[k] = struct{}{}
+		}
+	})
+	return nil
+}
+
+// Snapshot 把集合的元素写入快照
+func (set *StrSet) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	items := set.Slice()
+	return snapshotList(w, len(items), func(i int) interface{} { return items[i] }, option)
+}
+
+// Restore 使用从r读取的快照替换集合的元素，快照不完整或者校验失败时不会修改集合
+func (set *StrSet) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	items, err := dsnapshot.ReadList(r, option...)
+	if err != nil {
+		return err
+	}
+	data := make(map[string]struct{}, len(items))
+	for _, v := range items {
+		data[dconv.String(v)] = struct{}{}
+	}
+	set.generic().LockFunc(func(m map[string]struct{}) {
+		for k := range m {
+			delete(m, k)
+		}
+		for k := range data {
+			m[k] = struct{}{}
+		}
+	})
+	return nil
+}
@@ -2,16 +2,16 @@ package dset
 
 import (
 	"bytes"
+	"github.com/osgochina/donkeygo/container/dgeneric"
 	"github.com/osgochina/donkeygo/internal/json"
-	"github.com/osgochina/donkeygo/internal/rwmutex"
 	"github.com/osgochina/donkeygo/text/dstr"
 	"github.com/osgochina/donkeygo/util/dconv"
 	"strings"
 )
 
+// StrSet 基于dgeneric.Set[string]实现的集合，零值可以直接使用
 type StrSet struct {
-	mu   rwmutex.RWMutex
-	data map[string]struct{}
+	data *dgeneric.Set[string]
 }
 
 // NewStrSet create and returns a new set, which contains un-repeated items.
@@ -19,63 +19,50 @@ type StrSet struct {
 // which is false in default.
 func NewStrSet(safe ...bool) *StrSet {
 	return &StrSet{
-		mu:   rwmutex.Create(safe...),
-		data: make(map[string]struct{}),
+		data: dgeneric.NewSet[string](safe...),
 	}
 }
 
 // NewStrSetFrom returns a new set from <items>.
 func NewStrSetFrom(items []string, safe ...bool) *StrSet {
-	m := make(map[string]struct{})
-	for _, v := range items {
-		m[v] = struct{}{}
-	}
 	return &StrSet{
-		mu:   rwmutex.Create(safe...),
-		data: m,
+		data: dgeneric.NewSetFrom(items, safe...),
+	}
+}
+
+// generic 返回底层的泛型集合，零值的集合在第一次使用时初始化
+func (set *StrSet) generic() *dgeneric.Set[string] {
+	if set.data == nil {
+		set.data = dgeneric.NewSet[string]()
+	}
+	return set.data
+}
+
+// strSetGenerics 把StrSet切片转换成底层的泛型集合切片
+func strSetGenerics(sets []*StrSet) []*dgeneric.Set[string] {
+	array := make([]*dgeneric.Set[string], len(sets))
+	for i, s := range sets {
+		array[i] = s.generic()
 	}
+	return array
 }
 
 // Iterator iterates the set readonly with given callback function <f>,
 // if <f> returns true then continue iterating; or false to stop.
 func (set *StrSet) Iterator(f func(v string) bool) {
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	for k, _ := range set.data {
-		if !f(k) {
-			break
-		}
-	}
+	set.generic().Iterator(f)
 }
 
 // Add adds one or multiple items to the set.
 func (set *StrSet) Add(item ...string) {
-	set.mu.Lock()
-	if set.data == nil {
-		set.data = make(map[string]struct{})
-	}
-	for _, v := range item {
-		set.data[v] = struct{}{}
-	}
-	set.mu.Unlock()
+	set.generic().Add(item...)
 }
 
 // AddIfNotExist checks whether item exists in the set,
 // it adds the item to set and returns true if it does not exists in the set,
 // or else it does nothing and returns false.
 func (set *StrSet) AddIfNotExist(item string) bool {
-	if !set.Contains(item) {
-		set.mu.Lock()
-		defer set.mu.Unlock()
-		if set.data == nil {
-			set.data = make(map[string]struct{})
-		}
-		if _, ok := set.data[item]; !ok {
-			set.data[item] = struct{}{}
-			return true
-		}
-	}
-	return false
+	return set.generic().AddIfNotExist(item)
 }
 
 // AddIfNotExistFunc checks whether item exists in the set,
@@ -84,20 +71,7 @@ func (set *StrSet) AddIfNotExist(item string) bool {
 //
 // Note that, the function <f> is executed without writing lock.
 func (set *StrSet) AddIfNotExistFunc(item string, f func() bool) bool {
-	if !set.Contains(item) {
-		if f() {
-			set.mu.Lock()
-			defer set.mu.Unlock()
-			if set.data == nil {
-				set.data = make(map[string]struct{})
-			}
-			if _, ok := set.data[item]; !ok {
-				set.data[item] = struct{}{}
-				return true
-			}
-		}
-	}
-	return false
+	return set.generic().AddIfNotExistFunc(item, f)
 }
 
 // AddIfNotExistFuncLock checks whether item exists in the set,
@@ -106,245 +80,108 @@ func (set *StrSet) AddIfNotExistFunc(item string, f func() bool) bool {
 //
 // Note that, the function <f> is executed without writing lock.
 func (set *StrSet) AddIfNotExistFuncLock(item string, f func() bool) bool {
-	if !set.Contains(item) {
-		set.mu.Lock()
-		defer set.mu.Unlock()
-		if set.data == nil {
-			set.data = make(map[string]struct{})
-		}
-		if f() {
-			if _, ok := set.data[item]; !ok {
-				set.data[item] = struct{}{}
-				return true
-			}
-		}
-	}
-	return false
+	return set.generic().AddIfNotExistFuncLock(item, f)
 }
 
 // Contains checks whether the set contains <item>.
 func (set *StrSet) Contains(item string) bool {
-	var ok bool
-	set.mu.RLock()
-	if set.data != nil {
-		_, ok = set.data[item]
-	}
-	set.mu.RUnlock()
-	return ok
+	return set.generic().Contains(item)
 }
 
 // ContainsI checks whether a value exists in the set with case-insensitively.
 // Note that it internally iterates the whole set to do the comparison with case-insensitively.
 func (set *StrSet) ContainsI(item string) bool {
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	for k, _ := range set.data {
-		if strings.EqualFold(k, item) {
-			return true
+	found := false
+	set.generic().RLockFunc(func(m map[string]struct{}) {
+		for k := range m {
+			if strings.EqualFold(k, item) {
+				found = true
+				return
+			}
 		}
-	}
-	return false
+	})
+	return found
 }
 
 // Remove deletes <item> from set.
 func (set *StrSet) Remove(item string) {
-	set.mu.Lock()
-	if set.data != nil {
-		delete(set.data, item)
-	}
-	set.mu.Unlock()
+	set.generic().Remove(item)
 }
 
 // Size returns the size of the set.
 func (set *StrSet) Size() int {
-	set.mu.RLock()
-	l := len(set.data)
-	set.mu.RUnlock()
-	return l
+	return set.generic().Size()
 }
 
 // Clear deletes all items of the set.
 func (set *StrSet) Clear() {
-	set.mu.Lock()
-	set.data = make(map[string]struct{})
-	set.mu.Unlock()
+	set.generic().Clear()
 }
 
 // Slice returns the a of items of the set as slice.
 func (set *StrSet) Slice() []string {
-	set.mu.RLock()
-	var (
-		i   = 0
-		ret = make([]string, len(set.data))
-	)
-	for item := range set.data {
-		ret[i] = item
-		i++
-	}
-
-	set.mu.RUnlock()
-	return ret
+	return set.generic().Slice()
 }
 
 // Join joins items with a string <glue>.
 func (set *StrSet) Join(glue string) string {
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	if len(set.data) == 0 {
-		return ""
-	}
-	var (
-		l      = len(set.data)
-		i      = 0
-		buffer = bytes.NewBuffer(nil)
-	)
-	for k, _ := range set.data {
-		buffer.WriteString(k)
-		if i != l-1 {
-			buffer.WriteString(glue)
-		}
-		i++
-	}
-	return buffer.String()
+	return set.generic().Join(glue)
 }
 
 // String returns items as a string, which implements like json.Marshal does.
 func (set *StrSet) String() string {
-	set.mu.RLock()
-	defer set.mu.RUnlock()
 	var (
-		l      = len(set.data)
 		i      = 0
 		buffer = bytes.NewBuffer(nil)
 	)
-	for k, _ := range set.data {
-		buffer.WriteString(`"` + dstr.QuoteMeta(k, `"\`) + `"`)
-		if i != l-1 {
-			buffer.WriteByte(',')
+	set.generic().RLockFunc(func(m map[string]struct{}) {
+		for k := range m {
+			buffer.WriteString(`"` + dstr.QuoteMeta(k, `"\`) + `"`)
+			if i != len(m)-1 {
+				buffer.WriteByte(',')
+			}
+			i++
 		}
-		i++
-	}
+	})
 	return buffer.String()
 }
 
 // LockFunc locks writing with callback function <f>.
 func (set *StrSet) LockFunc(f func(m map[string]struct{})) {
-	set.mu.Lock()
-	defer set.mu.Unlock()
-	f(set.data)
+	set.generic().LockFunc(f)
 }
 
 // RLockFunc locks reading with callback function <f>.
 func (set *StrSet) RLockFunc(f func(m map[string]struct{})) {
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	f(set.data)
+	set.generic().RLockFunc(f)
 }
 
 // Equal checks whether the two sets equal.
 func (set *StrSet) Equal(other *StrSet) bool {
-	if set == other {
-		return true
-	}
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	other.mu.RLock()
-	defer other.mu.RUnlock()
-	if len(set.data) != len(other.data) {
-		return false
-	}
-	for key := range set.data {
-		if _, ok := other.data[key]; !ok {
-			return false
-		}
-	}
-	return true
+	return set.generic().Equal(other.generic())
 }
 
 // IsSubsetOf checks whether the current set is a sub-set of <other>.
 func (set *StrSet) IsSubsetOf(other *StrSet) bool {
-	if set == other {
-		return true
-	}
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	other.mu.RLock()
-	defer other.mu.RUnlock()
-	for key := range set.data {
-		if _, ok := other.data[key]; !ok {
-			return false
-		}
-	}
-	return true
+	return set.generic().IsSubsetOf(other.generic())
 }
 
 // Union returns a new set which is the union of <set> and <other>.
 // Which means, all the items in <newSet> are in <set> or in <other>.
 func (set *StrSet) Union(others ...*StrSet) (newSet *StrSet) {
-	newSet = NewStrSet()
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	for _, other := range others {
-		if set != other {
-			other.mu.RLock()
-		}
-		for k, v := range set.data {
-			newSet.data[k] = v
-		}
-		if set != other {
-			for k, v := range other.data {
-				newSet.data[k] = v
-			}
-		}
-		if set != other {
-			other.mu.RUnlock()
-		}
-	}
-
-	return
+	return &StrSet{data: set.generic().Union(strSetGenerics(others)...)}
 }
 
 // Diff returns a new set which is the difference set from <set> to <other>.
 // Which means, all the items in <newSet> are in <set> but not in <other>.
 func (set *StrSet) Diff(others ...*StrSet) (newSet *StrSet) {
-	newSet = NewStrSet()
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	for _, other := range others {
-		if set == other {
-			continue
-		}
-		other.mu.RLock()
-		for k, v := range set.data {
-			if _, ok := other.data[k]; !ok {
-				newSet.data[k] = v
-			}
-		}
-		other.mu.RUnlock()
-	}
-	return
+	return &StrSet{data: set.generic().Diff(strSetGenerics(others)...)}
 }
 
 // Intersect returns a new set which is the intersection from <set> to <other>.
 // Which means, all the items in <newSet> are in <set> and also in <other>.
 func (set *StrSet) Intersect(others ...*StrSet) (newSet *StrSet) {
-	newSet = NewStrSet()
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	for _, other := range others {
-		if set != other {
-			other.mu.RLock()
-		}
-		for k, v := range set.data {
-			if _, ok := other.data[k]; ok {
-				newSet.data[k] = v
-			}
-		}
-		if set != other {
-			other.mu.RUnlock()
-		}
-	}
-	return
+	return &StrSet{data: set.generic().Intersect(strSetGenerics(others)...)}
 }
 
 // Complement returns a new set which is the complement from <set> to <full>.
@@ -353,36 +190,12 @@ func (set *StrSet) Intersect(others ...*StrSet) (newSet *StrSet) {
 // It returns the difference between <full> and <set>
 // if the given set <full> is not the full set of <set>.
 func (set *StrSet) Complement(full *StrSet) (newSet *StrSet) {
-	newSet = NewStrSet()
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	if set != full {
-		full.mu.RLock()
-		defer full.mu.RUnlock()
-	}
-	for k, v := range full.data {
-		if _, ok := set.data[k]; !ok {
-			newSet.data[k] = v
-		}
-	}
-	return
+	return &StrSet{data: set.generic().Complement(full.generic())}
 }
 
 // Merge adds items from <others> sets into <set>.
 func (set *StrSet) Merge(others ...*StrSet) *StrSet {
-	set.mu.Lock()
-	defer set.mu.Unlock()
-	for _, other := range others {
-		if set != other {
-			other.mu.RLock()
-		}
-		for k, v := range other.data {
-			set.data[k] = v
-		}
-		if set != other {
-			other.mu.RUnlock()
-		}
-	}
+	set.generic().Merge(strSetGenerics(others)...)
 	return set
 }
 
@@ -390,90 +203,44 @@ func (set *StrSet) Merge(others ...*StrSet) *StrSet {
 // Note: The items should be converted to int type,
 // or you'd get a result that you unexpected.
 func (set *StrSet) Sum() (sum int) {
-	set.mu.RLock()
-	defer set.mu.RUnlock()
-	for k, _ := range set.data {
-		sum += dconv.Int(k)
-	}
+	set.generic().RLockFunc(func(m map[string]struct{}) {
+		for k := range m {
+			sum += dconv.Int(k)
+		}
+	})
 	return
 }
 
 // Pop randomly pops an item from set.
 func (set *StrSet) Pop() string {
-	set.mu.Lock()
-	defer set.mu.Unlock()
-	for k, _ := range set.data {
-		delete(set.data, k)
-		return k
-	}
-	return ""
+	item, _ := set.generic().Pop()
+	return item
 }
 
 // Pops randomly pops <size> items from set.
 // It returns all items if size == -1.
 func (set *StrSet) Pops(size int) []string {
-	set.mu.Lock()
-	defer set.mu.Unlock()
-	if size > len(set.data) || size == -1 {
-		size = len(set.data)
-	}
-	if size <= 0 {
-		return nil
-	}
-	index := 0
-	array := make([]string, size)
-	for k, _ := range set.data {
-		delete(set.data, k)
-		array[index] = k
-		index++
-		if index == size {
-			break
-		}
-	}
-	return array
+	return set.generic().Pops(size)
 }
 
 // Walk applies a user supplied function <f> to every item of set.
 func (set *StrSet) Walk(f func(item string) string) *StrSet {
-	set.mu.Lock()
-	defer set.mu.Unlock()
-	m := make(map[string]struct{}, len(set.data))
-	for k, v := range set.data {
-		m[f(k)] = v
-	}
-	set.data = m
+	set.generic().Walk(f)
 	return set
 }
 
 // MarshalJSON implements the interface MarshalJSON for json.Marshal.
 func (set *StrSet) MarshalJSON() ([]byte, error) {
-	return json.Marshal(set.Slice())
+	return set.generic().MarshalJSON()
 }
 
 // UnmarshalJSON implements the interface UnmarshalJSON for json.Unmarshal.
 func (set *StrSet) UnmarshalJSON(b []byte) error {
-	set.mu.Lock()
-	defer set.mu.Unlock()
-	if set.data == nil {
-		set.data = make(map[string]struct{})
-	}
-	var array []string
-	if err := json.UnmarshalUseNumber(b, &array); err != nil {
-		return err
-	}
-	for _, v := range array {
-		set.data[v] = struct{}{}
-	}
-	return nil
+	return set.generic().UnmarshalJSON(b)
 }
 
 // UnmarshalValue is an interface implement which sets any type of value for set.
 func (set *StrSet) UnmarshalValue(value interface{}) (err error) {
-	set.mu.Lock()
-	defer set.mu.Unlock()
-	if set.data == nil {
-		set.data = make(map[string]struct{})
-	}
 	var array []string
 	switch value.(type) {
 	case string, []byte:
@@ -481,8 +248,6 @@ func (set *StrSet) UnmarshalValue(value interface{}) (err error) {
 	default:
 		array = dconv.SliceStr(value)
 	}
-	for _, v := range array {
-		set.data[v] = struct{}{}
-	}
+	set.generic().Add(array...)
 	return
 }
@@ -0,0 +1,36 @@
+package dset_test
+
+import (
+	"bytes"
+	"github.com/osgochina/donkeygo/container/dset"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"testing"
+)
+
+func Test_Set_Snapshot(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var buf bytes.Buffer
+		s1 := dset.NewFrom([]interface{}{1, "a", 2.5}, true)
+		t.AssertNil(s1.Snapshot(&buf))
+		s2 := dset.New()
+		s2.Add("b")
+		t.AssertNil(s2.Restore(&buf))
+		t.Assert(s2.Size(), 3)
+		t.Assert(s2.Contains("a"), true)
+		t.Assert(s2.Contains(2.5), true)
+		t.Assert(s2.Contains("b"), false)
+
+		buf.Reset()
+		s3 := dset.NewIntSetFrom([]int{1, 2, 3}, true)
+		t.AssertNil(s3.Snapshot(&buf))
+		s4 := dset.NewIntSet()
+		t.AssertNil(s4.Restore(bytes.NewReader(buf.Bytes())))
+		t.Assert(s4.Size(), 3)
+		t.Assert(s4.Contains(2), true)
+		s5 := dset.NewStrSet()
+		t.AssertNil(s5.Restore(bytes.NewReader(buf.Bytes())))
+		t.Assert(s5.Contains("3"), true)
+		t.AssertNE(s5.Restore(bytes.NewReader(buf.Bytes()[:5])), nil)
+		t.Assert(s5.Size(), 3)
+	})
+}
@@ -0,0 +1,182 @@
+// Package dsketch 提供固定内存占用的概率数据结构，包括布隆过滤器、可扩展布隆过滤器、
+// 计数布隆过滤器、Count-Min Sketch、HyperLogLog以及Top-K高频元素统计。
+// 所有结构都使用dhash中的hash方法，支持合并以及二进制序列化，并且可以通过safe参数开启并发安全。
+package dsketch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/osgochina/donkeygo/encoding/dbinary"
+	"github.com/osgochina/donkeygo/encoding/dhash"
+	"github.com/osgochina/donkeygo/util/dconv"
+)
+
+// 序列化数据的版本号
+const binaryVersion byte = 1
+
+// 各结构序列化时写入的类型标识，反序列化时用于校验数据是否匹配
+const (
+	kindBloomFilter byte = iota + 1
+	kindScalableBloomFilter
+	kindCountingBloomFilter
+	kindCountMinSketch
+	kindHyperLogLog
+	kindTopK
+)
+
+// 把元素转换成字节切片，字符串和字节切片直接使用，其他类型先转换成字符串
+func itemBytes(item interface{}) []byte {
+	switch v := item.(type) {
+	case []byte:
+		return v
+	case string:
+		return dconv.UnsafeStrToBytes(v)
+	default:
+		return dconv.UnsafeStrToBytes(dconv.String(item))
+	}
+}
+
+// 计算元素的两个hash值，通过 h1 + i*h2 的方式模拟多个独立的hash方法
+func hashPair(b []byte) (uint64, uint64) {
+	return fmix64(dhash.BKDRHash64(b)), fmix64(dhash.SDBMHash64(b))
+}
+
+// dhash中的方法雪崩效应较弱，使用MurmurHash3的finalizer打散hash值的每一位
+func fmix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// 二进制编码器，写入的数据都使用小端字节序
+type encoder struct {
+	buf bytes.Buffer
+}
+
+// 创建编码器并写入类型标识和版本号
+func newEncoder(kind byte) *encoder {
+	e := &encoder{}
+	e.buf.WriteByte(kind)
+	e.buf.WriteByte(binaryVersion)
+	return e
+}
+
+func (that *encoder) uint8(v uint8) {
+	that.buf.WriteByte(v)
+}
+
+func (that *encoder) uint32(v uint32) {
+	that.buf.Write(dbinary.LeEncodeUint32(v))
+}
+
+func (that *encoder) uint64(v uint64) {
+	that.buf.Write(dbinary.LeEncodeUint64(v))
+}
+
+func (that *encoder) float64(v float64) {
+	that.buf.Write(dbinary.LeEncodeFloat64(v))
+}
+
+// 写入带长度前缀的字节切片
+func (that *encoder) bytes(b []byte) {
+	that.uint32(uint32(len(b)))
+	that.buf.Write(b)
+}
+
+func (that *encoder) Bytes() []byte {
+	return that.buf.Bytes()
+}
+
+// 二进制解码器，读取过程中出现的第一个错误会被记录下来，之后的读取都返回零值
+type decoder struct {
+	b   []byte
+	err error
+}
+
+// 创建解码器并校验类型标识和版本号
+func newDecoder(b []byte, kind byte) *decoder {
+	d := &decoder{b: b}
+	if len(b) < 2 {
+		d.err = errors.New("dsketch: binary data is too short")
+		return d
+	}
+	if b[0] != kind {
+		d.err = errors.New(fmt.Sprintf("dsketch: binary data kind %d does not match %d", b[0], kind))
+		return d
+	}
+	if b[1] != binaryVersion {
+		d.err = errors.New(fmt.Sprintf("dsketch: unsupported binary version %d", b[1]))
+		return d
+	}
+	d.b = b[2:]
+	return d
+}
+
+// 读取n个字节
+func (that *decoder) next(n int) []byte {
+	if that.err != nil {
+		return nil
+	}
+	if n < 0 || len(that.b) < n {
+		that.err = errors.New("dsketch: unexpected end of binary data")
+		return nil
+	}
+	b := that.b[:n]
+	that.b = that.b[n:]
+	return b
+}
+
+func (that *decoder) uint8() uint8 {
+	if b := that.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (that *decoder) uint32() uint32 {
+	if b := that.next(4); b != nil {
+		return dbinary.LeDecodeToUint32(b)
+	}
+	return 0
+}
+
+func (that *decoder) uint64() uint64 {
+	if b := that.next(8); b != nil {
+		return dbinary.LeDecodeToUint64(b)
+	}
+	return 0
+}
+
+func (that *decoder) float64() float64 {
+	if b := that.next(8); b != nil {
+		return dbinary.LeDecodeToFloat64(b)
+	}
+	return 0
+}
+
+// 读取带长度前缀的字节切片
+func (that *decoder) bytes() []byte {
+	return that.next(int(that.uint32()))
+}
+
+// 读取一个长度值，并校验剩余的数据至少能容纳size*n个字节，避免错误的数据导致申请过大的内存
+func (that *decoder) length(size int) int {
+	n := int(that.uint64())
+	if that.err == nil && (n < 0 || size > 0 && n > len(that.b)/size) {
+		that.err = errors.New("dsketch: invalid length in binary data")
+		return 0
+	}
+	return n
+}
+
+// 读取结束，存在多余的数据时也视为错误
+func (that *decoder) finish() error {
+	if that.err == nil && len(that.b) > 0 {
+		that.err = errors.New("dsketch: unexpected trailing binary data")
+	}
+	return that.err
+}
@@ -0,0 +1,220 @@
+package dsketch
+
+import (
+	"errors"
+	"fmt"
+	"github.com/osgochina/donkeygo/internal/rwmutex"
+	"math"
+)
+
+// BloomFilter 布隆过滤器，用于判断元素是否存在，
+// 返回不存在时元素一定不存在，返回存在时有一定概率误判
+type BloomFilter struct {
+	mu    rwmutex.RWMutex
+	bits  []uint64 // 位图
+	m     uint64   // 位图的长度
+	k     uint64   // 每个元素使用的hash次数
+	count uint64   // 已添加的元素数量
+}
+
+// NewBloomFilter 创建布隆过滤器，capacity为预计的元素数量，fpRate为期望的误判率，
+// 会根据两者计算位图长度和hash次数，safe参数表示是否并发安全
+func NewBloomFilter(capacity uint, fpRate float64, safe ...bool) *BloomFilter {
+	m, k := bloomEstimate(capacity, fpRate)
+	return NewBloomFilterSize(m, k, safe...)
+}
+
+// NewBloomFilterSize 通过位图长度m和hash次数k创建布隆过滤器
+func NewBloomFilterSize(m uint, k uint, safe ...bool) *BloomFilter {
+	if m < 1 {
+		m = 1
+	}
+	if k < 1 {
+		k = 1
+	}
+	return &BloomFilter{
+		mu:   rwmutex.Create(safe...),
+		bits: make([]uint64, (m+63)/64),
+		m:    uint64(m),
+		k:    uint64(k),
+	}
+}
+
+// 根据元素数量和误判率计算最优的位图长度和hash次数
+func bloomEstimate(capacity uint, fpRate float64) (m uint, k uint) {
+	if capacity < 1 {
+		capacity = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+	m = uint(math.Ceil(-float64(capacity) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	k = uint(math.Round(float64(m) / float64(capacity) * math.Ln2))
+	return m, k
+}
+
+// Add 添加元素
+func (that *BloomFilter) Add(item interface{}) {
+	that.mu.Lock()
+	that.doAdd(itemBytes(item))
+	that.mu.Unlock()
+}
+
+// AddIfNotExist 如果元素不存在，则添加，添加成功返回true，可以用于数据去重
+func (that *BloomFilter) AddIfNotExist(item interface{}) bool {
+	b := itemBytes(item)
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if that.doContains(b) {
+		return false
+	}
+	that.doAdd(b)
+	return true
+}
+
+// Contains 判断元素是否存在
+func (that *BloomFilter) Contains(item interface{}) bool {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return that.doContains(itemBytes(item))
+}
+
+func (that *BloomFilter) doAdd(b []byte) {
+	h1, h2 := hashPair(b)
+	for i := uint64(0); i < that.k; i++ {
+		pos := (h1 + i*h2) % that.m
+		that.bits[pos>>6] |= 1 << (pos & 63)
+	}
+	that.count++
+}
+
+func (that *BloomFilter) doContains(b []byte) bool {
+	h1, h2 := hashPair(b)
+	for i := uint64(0); i < that.k; i++ {
+		pos := (h1 + i*h2) % that.m
+		if that.bits[pos>>6]&(1<<(pos&63)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Count 已添加的元素数量，重复添加的元素会被重复计算
+func (that *BloomFilter) Count() uint64 {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return that.count
+}
+
+// Size 位图的长度
+func (that *BloomFilter) Size() uint {
+	return uint(that.m)
+}
+
+// HashCount 每个元素使用的hash次数
+func (that *BloomFilter) HashCount() uint {
+	return uint(that.k)
+}
+
+// FalsePositiveRate 根据已添加的元素数量估算当前的误判率
+func (that *BloomFilter) FalsePositiveRate() float64 {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return math.Pow(1-math.Exp(-float64(that.k*that.count)/float64(that.m)), float64(that.k))
+}
+
+// Clear 清空过滤器
+func (that *BloomFilter) Clear() {
+	that.mu.Lock()
+	for i := range that.bits {
+		that.bits[i] = 0
+	}
+	that.count = 0
+	that.mu.Unlock()
+}
+
+// Merge 合并其他过滤器，合并后的过滤器包含两者的所有元素，位图长度和hash次数必须一致
+func (that *BloomFilter) Merge(other *BloomFilter) error {
+	if other == that {
+		return nil
+	}
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+	if that.m != other.m || that.k != other.k {
+		return errors.New(fmt.Sprintf("dsketch: cannot merge bloom filter of m=%d k=%d into m=%d k=%d", other.m, other.k, that.m, that.k))
+	}
+	for i, w := range other.bits {
+		that.bits[i] |= w
+	}
+	that.count += other.count
+	return nil
+}
+
+// Clone 复制一份过滤器
+func (that *BloomFilter) Clone(safe ...bool) *BloomFilter {
+	if len(safe) == 0 {
+		safe = []bool{that.mu.IsSafe()}
+	}
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	bits := make([]uint64, len(that.bits))
+	copy(bits, that.bits)
+	return &BloomFilter{
+		mu:    rwmutex.Create(safe...),
+		bits:  bits,
+		m:     that.m,
+		k:     that.k,
+		count: that.count,
+	}
+}
+
+// MarshalBinary 二进制序列化
+func (that *BloomFilter) MarshalBinary() ([]byte, error) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	e := newEncoder(kindBloomFilter)
+	that.encode(e)
+	return e.Bytes(), nil
+}
+
+// UnmarshalBinary 二进制反序列化，会覆盖当前过滤器的数据
+func (that *BloomFilter) UnmarshalBinary(b []byte) error {
+	d := newDecoder(b, kindBloomFilter)
+	other := &BloomFilter{}
+	other.decode(d)
+	if err := d.finish(); err != nil {
+		return err
+	}
+	that.mu.Lock()
+	that.bits, that.m, that.k, that.count = other.bits, other.m, other.k, other.count
+	that.mu.Unlock()
+	return nil
+}
+
+func (that *BloomFilter) encode(e *encoder) {
+	e.uint64(that.m)
+	e.uint64(that.k)
+	e.uint64(that.count)
+	for _, w := range that.bits {
+		e.uint64(w)
+	}
+}
+
+func (that *BloomFilter) decode(d *decoder) {
+	that.m = d.uint64()
+	that.k = d.uint64()
+	that.count = d.uint64()
+	if d.err != nil {
+		return
+	}
+	if that.m < 1 || that.k < 1 || (that.m+63)/64 > uint64(len(d.b)/8) {
+		d.err = errors.New("dsketch: invalid bloom filter binary data")
+		return
+	}
+	that.bits = make([]uint64, (that.m+63)/64)
+	for i := range that.bits {
+		that.bits[i] = d.uint64()
+	}
+}
@@ -0,0 +1,185 @@
+package dsketch
+
+import (
+	"errors"
+	"fmt"
+	"github.com/osgochina/donkeygo/internal/rwmutex"
+	"math"
+)
+
+// CountMinSketch 使用固定内存估算元素出现的频次，估算值不会小于实际值，
+// 误差不超过 epsilon*Total() 的概率为 1-delta
+type CountMinSketch struct {
+	mu     rwmutex.RWMutex
+	width  uint64
+	depth  uint64
+	counts []uint64 // depth行width列的计数器
+	total  uint64   // 所有元素的频次之和
+}
+
+// NewCountMinSketch 通过列数width和行数depth创建CountMinSketch，safe参数表示是否并发安全
+func NewCountMinSketch(width uint, depth uint, safe ...bool) *CountMinSketch {
+	if width < 1 {
+		width = 1
+	}
+	if depth < 1 {
+		depth = 1
+	}
+	return &CountMinSketch{
+		mu:     rwmutex.Create(safe...),
+		width:  uint64(width),
+		depth:  uint64(depth),
+		counts: make([]uint64, width*depth),
+	}
+}
+
+// NewCountMinSketchWithEstimates 通过误差epsilon和置信度delta创建CountMinSketch，
+// 例如epsilon=0.001，delta=0.01表示有99%的概率估算误差不超过总频次的0.1%
+func NewCountMinSketchWithEstimates(epsilon, delta float64, safe ...bool) *CountMinSketch {
+	if epsilon <= 0 || epsilon >= 1 {
+		epsilon = 0.001
+	}
+	if delta <= 0 || delta >= 1 {
+		delta = 0.01
+	}
+	width := uint(math.Ceil(math.E / epsilon))
+	depth := uint(math.Ceil(math.Log(1 / delta)))
+	return NewCountMinSketch(width, depth, safe...)
+}
+
+// Add 增加元素的频次，不传count时增加1
+func (that *CountMinSketch) Add(item interface{}, count ...uint64) {
+	n := uint64(1)
+	if len(count) > 0 {
+		n = count[0]
+	}
+	h1, h2 := hashPair(itemBytes(item))
+	that.mu.Lock()
+	that.doAdd(h1, h2, n)
+	that.mu.Unlock()
+}
+
+// Estimate 估算元素的频次
+func (that *CountMinSketch) Estimate(item interface{}) uint64 {
+	h1, h2 := hashPair(itemBytes(item))
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return that.doEstimate(h1, h2)
+}
+
+func (that *CountMinSketch) doAdd(h1, h2 uint64, n uint64) {
+	for i := uint64(0); i < that.depth; i++ {
+		that.counts[i*that.width+(h1+i*h2)%that.width] += n
+	}
+	that.total += n
+}
+
+func (that *CountMinSketch) doEstimate(h1, h2 uint64) uint64 {
+	min := uint64(math.MaxUint64)
+	for i := uint64(0); i < that.depth; i++ {
+		if c := that.counts[i*that.width+(h1+i*h2)%that.width]; c < min {
+			min = c
+		}
+	}
+	return min
+}
+
+// Total 所有元素的频次之和
+func (that *CountMinSketch) Total() uint64 {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return that.total
+}
+
+// Width 列数
+func (that *CountMinSketch) Width() uint {
+	return uint(that.width)
+}
+
+// Depth 行数
+func (that *CountMinSketch) Depth() uint {
+	return uint(that.depth)
+}
+
+// Clear 清空计数
+func (that *CountMinSketch) Clear() {
+	that.mu.Lock()
+	for i := range that.counts {
+		that.counts[i] = 0
+	}
+	that.total = 0
+	that.mu.Unlock()
+}
+
+// Merge 合并其他CountMinSketch，对应的计数器相加，行数和列数必须一致
+func (that *CountMinSketch) Merge(other *CountMinSketch) error {
+	if other == that {
+		return nil
+	}
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+	return that.doMerge(other)
+}
+
+func (that *CountMinSketch) doMerge(other *CountMinSketch) error {
+	if that.width != other.width || that.depth != other.depth {
+		return errors.New(fmt.Sprintf("dsketch: cannot merge count-min sketch of %dx%d into %dx%d",
+			other.width, other.depth, that.width, that.depth))
+	}
+	for i, c := range other.counts {
+		that.counts[i] += c
+	}
+	that.total += other.total
+	return nil
+}
+
+// MarshalBinary 二进制序列化
+func (that *CountMinSketch) MarshalBinary() ([]byte, error) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	e := newEncoder(kindCountMinSketch)
+	that.encode(e)
+	return e.Bytes(), nil
+}
+
+// UnmarshalBinary 二进制反序列化，会覆盖当前的数据
+func (that *CountMinSketch) UnmarshalBinary(b []byte) error {
+	d := newDecoder(b, kindCountMinSketch)
+	other := &CountMinSketch{}
+	other.decode(d)
+	if err := d.finish(); err != nil {
+		return err
+	}
+	that.mu.Lock()
+	that.width, that.depth, that.counts, that.total = other.width, other.depth, other.counts, other.total
+	that.mu.Unlock()
+	return nil
+}
+
+func (that *CountMinSketch) encode(e *encoder) {
+	e.uint64(that.width)
+	e.uint64(that.depth)
+	e.uint64(that.total)
+	for _, c := range that.counts {
+		e.uint64(c)
+	}
+}
+
+func (that *CountMinSketch) decode(d *decoder) {
+	that.width = d.uint64()
+	that.depth = d.uint64()
+	that.total = d.uint64()
+	if d.err != nil {
+		return
+	}
+	if that.width < 1 || that.depth < 1 || that.width > uint64(len(d.b)/8)/that.depth {
+		d.err = errors.New("dsketch: invalid count-min sketch binary data")
+		return
+	}
+	that.counts = make([]uint64, that.width*that.depth)
+	for i := range that.counts {
+		that.counts[i] = d.uint64()
+	}
+}
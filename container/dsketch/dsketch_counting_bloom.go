@@ -0,0 +1,190 @@
+package dsketch
+
+import (
+	"errors"
+	"fmt"
+	"github.com/osgochina/donkeygo/internal/rwmutex"
+	"math"
+)
+
+// CountingBloomFilter 计数布隆过滤器，使用计数器代替位图，支持删除元素，
+// 计数器达到上限255后不再变化，删除也不会使其减少
+type CountingBloomFilter struct {
+	mu       rwmutex.RWMutex
+	counters []uint8
+	k        uint64 // 每个元素使用的hash次数
+	count    uint64 // 当前的元素数量
+}
+
+// NewCountingBloomFilter 创建计数布隆过滤器，capacity为预计的元素数量，fpRate为期望的误判率，
+// safe参数表示是否并发安全
+func NewCountingBloomFilter(capacity uint, fpRate float64, safe ...bool) *CountingBloomFilter {
+	m, k := bloomEstimate(capacity, fpRate)
+	return NewCountingBloomFilterSize(m, k, safe...)
+}
+
+// NewCountingBloomFilterSize 通过计数器数量m和hash次数k创建计数布隆过滤器
+func NewCountingBloomFilterSize(m uint, k uint, safe ...bool) *CountingBloomFilter {
+	if m < 1 {
+		m = 1
+	}
+	if k < 1 {
+		k = 1
+	}
+	return &CountingBloomFilter{
+		mu:       rwmutex.Create(safe...),
+		counters: make([]uint8, m),
+		k:        uint64(k),
+	}
+}
+
+// Add 添加元素
+func (that *CountingBloomFilter) Add(item interface{}) {
+	h1, h2 := hashPair(itemBytes(item))
+	m := uint64(len(that.counters))
+	that.mu.Lock()
+	for i := uint64(0); i < that.k; i++ {
+		pos := (h1 + i*h2) % m
+		if that.counters[pos] < math.MaxUint8 {
+			that.counters[pos]++
+		}
+	}
+	that.count++
+	that.mu.Unlock()
+}
+
+// Remove 删除元素，元素不存在时返回false，删除从未添加过的元素可能会导致其他元素被误删
+func (that *CountingBloomFilter) Remove(item interface{}) bool {
+	h1, h2 := hashPair(itemBytes(item))
+	m := uint64(len(that.counters))
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if !that.doContains(h1, h2) {
+		return false
+	}
+	for i := uint64(0); i < that.k; i++ {
+		pos := (h1 + i*h2) % m
+		if that.counters[pos] < math.MaxUint8 {
+			that.counters[pos]--
+		}
+	}
+	if that.count > 0 {
+		that.count--
+	}
+	return true
+}
+
+// Contains 判断元素是否存在
+func (that *CountingBloomFilter) Contains(item interface{}) bool {
+	h1, h2 := hashPair(itemBytes(item))
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return that.doContains(h1, h2)
+}
+
+func (that *CountingBloomFilter) doContains(h1, h2 uint64) bool {
+	m := uint64(len(that.counters))
+	for i := uint64(0); i < that.k; i++ {
+		if that.counters[(h1+i*h2)%m] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Estimate 估算元素被添加的次数，返回值不会小于实际次数
+func (that *CountingBloomFilter) Estimate(item interface{}) uint64 {
+	h1, h2 := hashPair(itemBytes(item))
+	m := uint64(len(that.counters))
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	min := uint8(math.MaxUint8)
+	for i := uint64(0); i < that.k; i++ {
+		if c := that.counters[(h1+i*h2)%m]; c < min {
+			min = c
+		}
+	}
+	return uint64(min)
+}
+
+// Count 当前的元素数量
+func (that *CountingBloomFilter) Count() uint64 {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return that.count
+}
+
+// Size 计数器的数量
+func (that *CountingBloomFilter) Size() uint {
+	return uint(len(that.counters))
+}
+
+// HashCount 每个元素使用的hash次数
+func (that *CountingBloomFilter) HashCount() uint {
+	return uint(that.k)
+}
+
+// Clear 清空过滤器
+func (that *CountingBloomFilter) Clear() {
+	that.mu.Lock()
+	for i := range that.counters {
+		that.counters[i] = 0
+	}
+	that.count = 0
+	that.mu.Unlock()
+}
+
+// Merge 合并其他过滤器，对应的计数器相加，计数器数量和hash次数必须一致
+func (that *CountingBloomFilter) Merge(other *CountingBloomFilter) error {
+	if other == that {
+		return nil
+	}
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+	if len(that.counters) != len(other.counters) || that.k != other.k {
+		return errors.New(fmt.Sprintf("dsketch: cannot merge counting bloom filter of m=%d k=%d into m=%d k=%d",
+			len(other.counters), other.k, len(that.counters), that.k))
+	}
+	for i, c := range other.counters {
+		if sum := uint(that.counters[i]) + uint(c); sum < math.MaxUint8 {
+			that.counters[i] = uint8(sum)
+		} else {
+			that.counters[i] = math.MaxUint8
+		}
+	}
+	that.count += other.count
+	return nil
+}
+
+// MarshalBinary 二进制序列化
+func (that *CountingBloomFilter) MarshalBinary() ([]byte, error) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	e := newEncoder(kindCountingBloomFilter)
+	e.uint64(that.k)
+	e.uint64(that.count)
+	e.uint64(uint64(len(that.counters)))
+	e.buf.Write(that.counters)
+	return e.Bytes(), nil
+}
+
+// UnmarshalBinary 二进制反序列化，会覆盖当前过滤器的数据
+func (that *CountingBloomFilter) UnmarshalBinary(b []byte) error {
+	d := newDecoder(b, kindCountingBloomFilter)
+	k := d.uint64()
+	count := d.uint64()
+	counters := d.next(d.length(1))
+	if err := d.finish(); err != nil {
+		return err
+	}
+	if k < 1 || len(counters) == 0 {
+		return errors.New("dsketch: invalid counting bloom filter binary data")
+	}
+	that.mu.Lock()
+	that.counters = append([]uint8(nil), counters...)
+	that.k, that.count = k, count
+	that.mu.Unlock()
+	return nil
+}
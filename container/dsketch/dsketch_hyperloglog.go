@@ -0,0 +1,146 @@
+package dsketch
+
+import (
+	"errors"
+	"fmt"
+	"github.com/osgochina/donkeygo/internal/rwmutex"
+	"math"
+	"math/bits"
+)
+
+const (
+	hllMinPrecision     = 4
+	hllMaxPrecision     = 18
+	hllDefaultPrecision = 14 // 16384个寄存器，占用16KB内存，标准误差约0.81%
+)
+
+// HyperLogLog 使用固定内存估算不重复元素的数量（基数），
+// 标准误差约为 1.04/sqrt(2^precision)
+type HyperLogLog struct {
+	mu        rwmutex.RWMutex
+	precision uint8
+	registers []uint8
+}
+
+// NewHyperLogLog 创建HyperLogLog，precision取值范围为4到18，超出范围时使用默认值14，
+// safe参数表示是否并发安全
+func NewHyperLogLog(precision uint8, safe ...bool) *HyperLogLog {
+	if precision < hllMinPrecision || precision > hllMaxPrecision {
+		precision = hllDefaultPrecision
+	}
+	return &HyperLogLog{
+		mu:        rwmutex.Create(safe...),
+		precision: precision,
+		registers: make([]uint8, 1<<precision),
+	}
+}
+
+// Add 添加元素
+func (that *HyperLogLog) Add(item interface{}) {
+	h, _ := hashPair(itemBytes(item))
+	// 高precision位作为寄存器下标，剩余的位中前导0的数量加1作为寄存器的候选值
+	index := h >> (64 - that.precision)
+	rank := uint8(bits.LeadingZeros64(h<<that.precision|1<<(that.precision-1))) + 1
+	that.mu.Lock()
+	if rank > that.registers[index] {
+		that.registers[index] = rank
+	}
+	that.mu.Unlock()
+}
+
+// Count 估算不重复元素的数量
+func (that *HyperLogLog) Count() uint64 {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	m := float64(len(that.registers))
+	sum := 0.0
+	zeros := 0
+	for _, r := range that.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := hllAlpha(len(that.registers)) * m * m / sum
+	// 基数较小时使用线性计数修正
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// 修正系数
+func hllAlpha(m int) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	default:
+		return 0.7213 / (1 + 1.079/float64(m))
+	}
+}
+
+// Precision 精度
+func (that *HyperLogLog) Precision() uint8 {
+	return that.precision
+}
+
+// Clear 清空数据
+func (that *HyperLogLog) Clear() {
+	that.mu.Lock()
+	for i := range that.registers {
+		that.registers[i] = 0
+	}
+	that.mu.Unlock()
+}
+
+// Merge 合并其他HyperLogLog，合并后估算的是两者元素并集的基数，精度必须一致
+func (that *HyperLogLog) Merge(other *HyperLogLog) error {
+	if other == that {
+		return nil
+	}
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+	if that.precision != other.precision {
+		return errors.New(fmt.Sprintf("dsketch: cannot merge hyperloglog of precision %d into %d", other.precision, that.precision))
+	}
+	for i, r := range other.registers {
+		if r > that.registers[i] {
+			that.registers[i] = r
+		}
+	}
+	return nil
+}
+
+// MarshalBinary 二进制序列化
+func (that *HyperLogLog) MarshalBinary() ([]byte, error) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	e := newEncoder(kindHyperLogLog)
+	e.uint8(that.precision)
+	e.buf.Write(that.registers)
+	return e.Bytes(), nil
+}
+
+// UnmarshalBinary 二进制反序列化，会覆盖当前的数据
+func (that *HyperLogLog) UnmarshalBinary(b []byte) error {
+	d := newDecoder(b, kindHyperLogLog)
+	precision := d.uint8()
+	if d.err == nil && (precision < hllMinPrecision || precision > hllMaxPrecision) {
+		return errors.New(fmt.Sprintf("dsketch: invalid hyperloglog precision %d", precision))
+	}
+	registers := d.next(1 << precision)
+	if err := d.finish(); err != nil {
+		return err
+	}
+	that.mu.Lock()
+	that.precision = precision
+	that.registers = append([]uint8(nil), registers...)
+	that.mu.Unlock()
+	return nil
+}
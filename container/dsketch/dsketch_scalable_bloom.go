@@ -0,0 +1,179 @@
+package dsketch
+
+import (
+	"errors"
+	"github.com/osgochina/donkeygo/internal/rwmutex"
+	"math"
+)
+
+const (
+	scalableGrowth    = 2   // 每新增一层，容量扩大的倍数
+	scalableTightness = 0.8 // 每新增一层，误判率缩小的比例，保证整体误判率收敛于fpRate/(1-0.8)以内
+)
+
+// ScalableBloomFilter 可扩展布隆过滤器，由多层布隆过滤器组成，
+// 当前层写满后自动创建一个容量更大、误判率更低的新层，不需要预先知道元素数量
+type ScalableBloomFilter struct {
+	mu       rwmutex.RWMutex
+	fpRate   float64        // 第一层的误判率
+	layers   []*BloomFilter // 各层过滤器，只有最后一层会写入
+	capacity []uint64       // 各层的容量
+}
+
+// NewScalableBloomFilter 创建可扩展布隆过滤器，capacity为第一层的容量，fpRate为第一层的误判率，
+// safe参数表示是否并发安全
+func NewScalableBloomFilter(capacity uint, fpRate float64, safe ...bool) *ScalableBloomFilter {
+	if capacity < 1 {
+		capacity = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+	f := &ScalableBloomFilter{
+		mu:     rwmutex.Create(safe...),
+		fpRate: fpRate,
+	}
+	f.addLayer(uint64(capacity))
+	return f
+}
+
+// 新增一层过滤器
+func (that *ScalableBloomFilter) addLayer(capacity uint64) {
+	fpRate := that.fpRate * math.Pow(scalableTightness, float64(len(that.layers)))
+	that.layers = append(that.layers, NewBloomFilter(uint(capacity), fpRate))
+	that.capacity = append(that.capacity, capacity)
+}
+
+// Add 添加元素，元素已经存在时不会重复写入
+func (that *ScalableBloomFilter) Add(item interface{}) {
+	that.AddIfNotExist(item)
+}
+
+// AddIfNotExist 如果元素不存在，则添加，添加成功返回true，可以用于数据去重
+func (that *ScalableBloomFilter) AddIfNotExist(item interface{}) bool {
+	b := itemBytes(item)
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if that.doContains(b) {
+		return false
+	}
+	last := len(that.layers) - 1
+	if that.layers[last].count >= that.capacity[last] {
+		that.addLayer(that.capacity[last] * scalableGrowth)
+		last++
+	}
+	that.layers[last].doAdd(b)
+	return true
+}
+
+// Contains 判断元素是否存在
+func (that *ScalableBloomFilter) Contains(item interface{}) bool {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return that.doContains(itemBytes(item))
+}
+
+func (that *ScalableBloomFilter) doContains(b []byte) bool {
+	// 越新的层元素越多，从后往前查找
+	for i := len(that.layers) - 1; i >= 0; i-- {
+		if that.layers[i].doContains(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// Count 已添加的元素数量
+func (that *ScalableBloomFilter) Count() uint64 {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	var count uint64
+	for _, layer := range that.layers {
+		count += layer.count
+	}
+	return count
+}
+
+// Layers 过滤器的层数
+func (that *ScalableBloomFilter) Layers() int {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return len(that.layers)
+}
+
+// FalsePositiveRate 估算当前的误判率
+func (that *ScalableBloomFilter) FalsePositiveRate() float64 {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	miss := 1.0
+	for _, layer := range that.layers {
+		miss *= 1 - layer.FalsePositiveRate()
+	}
+	return 1 - miss
+}
+
+// Clear 清空过滤器，只保留第一层
+func (that *ScalableBloomFilter) Clear() {
+	that.mu.Lock()
+	capacity := that.capacity[0]
+	that.layers, that.capacity = nil, nil
+	that.addLayer(capacity)
+	that.mu.Unlock()
+}
+
+// Merge 合并其他过滤器，other的各层会被复制并追加到当前过滤器，之后的写入仍然按照容量扩展
+func (that *ScalableBloomFilter) Merge(other *ScalableBloomFilter) error {
+	if other == that {
+		return nil
+	}
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+	for i, layer := range other.layers {
+		if layer.count == 0 {
+			continue
+		}
+		that.layers = append(that.layers, layer.Clone())
+		that.capacity = append(that.capacity, other.capacity[i])
+	}
+	return nil
+}
+
+// MarshalBinary 二进制序列化
+func (that *ScalableBloomFilter) MarshalBinary() ([]byte, error) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	e := newEncoder(kindScalableBloomFilter)
+	e.float64(that.fpRate)
+	e.uint64(uint64(len(that.layers)))
+	for i, layer := range that.layers {
+		e.uint64(that.capacity[i])
+		layer.encode(e)
+	}
+	return e.Bytes(), nil
+}
+
+// UnmarshalBinary 二进制反序列化，会覆盖当前过滤器的数据
+func (that *ScalableBloomFilter) UnmarshalBinary(b []byte) error {
+	d := newDecoder(b, kindScalableBloomFilter)
+	fpRate := d.float64()
+	n := d.length(1)
+	if d.err == nil && (n == 0 || fpRate <= 0 || fpRate >= 1) {
+		return errors.New("dsketch: invalid scalable bloom filter binary data")
+	}
+	layers := make([]*BloomFilter, n)
+	capacity := make([]uint64, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		capacity[i] = d.uint64()
+		layers[i] = &BloomFilter{}
+		layers[i].decode(d)
+	}
+	if err := d.finish(); err != nil {
+		return err
+	}
+	that.mu.Lock()
+	that.fpRate, that.layers, that.capacity = fpRate, layers, capacity
+	that.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,243 @@
+package dsketch
+
+import (
+	"container/heap"
+	"errors"
+	"github.com/osgochina/donkeygo/internal/rwmutex"
+	"github.com/osgochina/donkeygo/util/dconv"
+	"sort"
+)
+
+// TopKItem 高频元素及其估算的频次
+type TopKItem struct {
+	Key   string
+	Count uint64
+}
+
+// TopK 统计出现频次最高的k个元素，使用CountMinSketch估算频次，使用小顶堆维护当前的高频元素
+type TopK struct {
+	mu     rwmutex.RWMutex
+	k      int
+	sketch *CountMinSketch
+	heap   *topKHeap
+}
+
+// NewTopK 创建TopK，k为需要统计的元素数量，width和depth为内部CountMinSketch的列数和行数，
+// safe参数表示是否并发安全
+func NewTopK(k int, width uint, depth uint, safe ...bool) *TopK {
+	if k < 1 {
+		k = 1
+	}
+	return &TopK{
+		mu:     rwmutex.Create(safe...),
+		k:      k,
+		sketch: NewCountMinSketch(width, depth),
+		heap:   newTopKHeap(k),
+	}
+}
+
+// 元素统一转换成字符串作为key
+func topKKey(item interface{}) string {
+	switch v := item.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return dconv.String(item)
+	}
+}
+
+// Add 增加元素的频次，不传count时增加1
+func (that *TopK) Add(item interface{}, count ...uint64) {
+	n := uint64(1)
+	if len(count) > 0 {
+		n = count[0]
+	}
+	key := topKKey(item)
+	h1, h2 := hashPair(dconv.UnsafeStrToBytes(key))
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	that.sketch.doAdd(h1, h2, n)
+	that.doOffer(key, that.sketch.doEstimate(h1, h2))
+}
+
+// 使用元素最新的估算频次更新小顶堆
+func (that *TopK) doOffer(key string, estimate uint64) {
+	h := that.heap
+	if i, ok := h.index[key]; ok {
+		h.items[i].Count = estimate
+		heap.Fix(h, i)
+		return
+	}
+	if len(h.items) < that.k {
+		heap.Push(h, TopKItem{Key: key, Count: estimate})
+		return
+	}
+	if estimate > h.items[0].Count {
+		delete(h.index, h.items[0].Key)
+		h.items[0] = TopKItem{Key: key, Count: estimate}
+		h.index[key] = 0
+		heap.Fix(h, 0)
+	}
+}
+
+// Contains 判断元素当前是否在前k个高频元素中
+func (that *TopK) Contains(item interface{}) bool {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	_, ok := that.heap.index[topKKey(item)]
+	return ok
+}
+
+// Estimate 估算元素的频次
+func (that *TopK) Estimate(item interface{}) uint64 {
+	h1, h2 := hashPair(dconv.UnsafeStrToBytes(topKKey(item)))
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return that.sketch.doEstimate(h1, h2)
+}
+
+// List 返回前k个高频元素，按照频次从高到低排序
+func (that *TopK) List() []TopKItem {
+	that.mu.RLock()
+	items := make([]TopKItem, len(that.heap.items))
+	copy(items, that.heap.items)
+	that.mu.RUnlock()
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].Key < items[j].Key
+	})
+	return items
+}
+
+// K 需要统计的元素数量
+func (that *TopK) K() int {
+	return that.k
+}
+
+// Total 所有元素的频次之和
+func (that *TopK) Total() uint64 {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return that.sketch.total
+}
+
+// Clear 清空数据
+func (that *TopK) Clear() {
+	that.mu.Lock()
+	that.sketch.Clear()
+	that.heap = newTopKHeap(that.k)
+	that.mu.Unlock()
+}
+
+// Merge 合并其他TopK，内部CountMinSketch的行数和列数必须一致，
+// 合并后使用新的估算频次从两者的高频元素中重新选出前k个
+func (that *TopK) Merge(other *TopK) error {
+	if other == that {
+		return nil
+	}
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+	if err := that.sketch.doMerge(other.sketch); err != nil {
+		return err
+	}
+	candidates := make([]string, 0, len(that.heap.items)+len(other.heap.items))
+	for _, item := range that.heap.items {
+		candidates = append(candidates, item.Key)
+	}
+	for _, item := range other.heap.items {
+		if _, ok := that.heap.index[item.Key]; !ok {
+			candidates = append(candidates, item.Key)
+		}
+	}
+	that.heap = newTopKHeap(that.k)
+	for _, key := range candidates {
+		that.doOffer(key, that.sketch.doEstimate(hashPair(dconv.UnsafeStrToBytes(key))))
+	}
+	return nil
+}
+
+// MarshalBinary 二进制序列化
+func (that *TopK) MarshalBinary() ([]byte, error) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	e := newEncoder(kindTopK)
+	e.uint64(uint64(that.k))
+	that.sketch.encode(e)
+	e.uint64(uint64(len(that.heap.items)))
+	for _, item := range that.heap.items {
+		e.bytes(dconv.UnsafeStrToBytes(item.Key))
+		e.uint64(item.Count)
+	}
+	return e.Bytes(), nil
+}
+
+// UnmarshalBinary 二进制反序列化，会覆盖当前的数据
+func (that *TopK) UnmarshalBinary(b []byte) error {
+	d := newDecoder(b, kindTopK)
+	k := int(d.uint64())
+	sketch := &CountMinSketch{}
+	sketch.decode(d)
+	n := d.length(12)
+	if d.err == nil && (k < 1 || n > k) {
+		return errors.New("dsketch: invalid top-k binary data")
+	}
+	h := newTopKHeap(k)
+	for i := 0; i < n && d.err == nil; i++ {
+		key := string(d.bytes())
+		heap.Push(h, TopKItem{Key: key, Count: d.uint64()})
+	}
+	if err := d.finish(); err != nil {
+		return err
+	}
+	that.mu.Lock()
+	that.k, that.sketch, that.heap = k, sketch, h
+	that.mu.Unlock()
+	return nil
+}
+
+// 按照频次排序的小顶堆，index记录key在堆中的下标
+type topKHeap struct {
+	items []TopKItem
+	index map[string]int
+}
+
+func newTopKHeap(k int) *topKHeap {
+	return &topKHeap{
+		items: make([]TopKItem, 0, k),
+		index: make(map[string]int, k),
+	}
+}
+
+func (that *topKHeap) Len() int {
+	return len(that.items)
+}
+
+func (that *topKHeap) Less(i, j int) bool {
+	return that.items[i].Count < that.items[j].Count
+}
+
+func (that *topKHeap) Swap(i, j int) {
+	that.items[i], that.items[j] = that.items[j], that.items[i]
+	that.index[that.items[i].Key] = i
+	that.index[that.items[j].Key] = j
+}
+
+func (that *topKHeap) Push(x interface{}) {
+	item := x.(TopKItem)
+	that.index[item.Key] = len(that.items)
+	that.items = append(that.items, item)
+}
+
+func (that *topKHeap) Pop() interface{} {
+	last := len(that.items) - 1
+	item := that.items[last]
+	that.items = that.items[:last]
+	delete(that.index, item.Key)
+	return item
+}
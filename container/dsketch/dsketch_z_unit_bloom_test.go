@@ -0,0 +1,180 @@
+package dsketch_test
+
+import (
+	"fmt"
+	"github.com/osgochina/donkeygo/container/dsketch"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"sync"
+	"testing"
+)
+
+func Test_BloomFilter_Basic(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		f := dsketch.NewBloomFilter(1000, 0.01)
+		t.AssertGT(f.Size(), 0)
+		t.Assert(f.HashCount(), 7)
+		f.Add("a")
+		f.Add([]byte("b"))
+		f.Add(1)
+		t.Assert(f.Contains("a"), true)
+		t.Assert(f.Contains("b"), true)
+		t.Assert(f.Contains("1"), true)
+		t.Assert(f.Contains("c"), false)
+		t.Assert(f.AddIfNotExist("a"), false)
+		t.Assert(f.AddIfNotExist("c"), true)
+		t.Assert(f.Count(), 4)
+		f.Clear()
+		t.Assert(f.Contains("a"), false)
+		t.Assert(f.Count(), 0)
+	})
+}
+
+func Test_BloomFilter_FalsePositive(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		f := dsketch.NewBloomFilter(10000, 0.01)
+		for i := 0; i < 10000; i++ {
+			f.Add(fmt.Sprintf("item-%d", i))
+		}
+		for i := 0; i < 10000; i++ {
+			t.Assert(f.Contains(fmt.Sprintf("item-%d", i)), true)
+		}
+		fp := 0
+		for i := 0; i < 10000; i++ {
+			if f.Contains(fmt.Sprintf("other-%d", i)) {
+				fp++
+			}
+		}
+		t.AssertLT(fp, 200)
+		t.AssertLT(f.FalsePositiveRate(), 0.02)
+	})
+}
+
+func Test_BloomFilter_MergeBinary(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		f1 := dsketch.NewBloomFilter(100, 0.01)
+		f2 := dsketch.NewBloomFilter(100, 0.01)
+		f1.Add("a")
+		f2.Add("b")
+		t.AssertNil(f1.Merge(f2))
+		t.Assert(f1.Contains("a"), true)
+		t.Assert(f1.Contains("b"), true)
+		t.Assert(f1.Count(), 2)
+		t.AssertNE(f1.Merge(dsketch.NewBloomFilter(1000, 0.01)), nil)
+
+		b, err := f1.MarshalBinary()
+		t.AssertNil(err)
+		f3 := dsketch.NewBloomFilterSize(1, 1, true)
+		t.AssertNil(f3.UnmarshalBinary(b))
+		t.Assert(f3.Contains("a"), true)
+		t.Assert(f3.Contains("b"), true)
+		t.Assert(f3.Count(), 2)
+		t.Assert(f3.Size(), f1.Size())
+
+		t.AssertNE(f3.UnmarshalBinary(b[:len(b)-1]), nil)
+		t.AssertNE(f3.UnmarshalBinary(nil), nil)
+		t.AssertNE(dsketch.NewHyperLogLog(4).UnmarshalBinary(b), nil)
+	})
+}
+
+func Test_ScalableBloomFilter(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		f := dsketch.NewScalableBloomFilter(100, 0.01)
+		for i := 0; i < 1000; i++ {
+			f.Add(i)
+		}
+		t.AssertGT(f.Layers(), 3)
+		t.AssertLE(f.Count(), 1000)
+		t.AssertGT(f.Count(), 950)
+		for i := 0; i < 1000; i++ {
+			t.Assert(f.Contains(i), true)
+		}
+		fp := 0
+		for i := 1000; i < 11000; i++ {
+			if f.Contains(i) {
+				fp++
+			}
+		}
+		t.AssertLT(fp, 500)
+		t.AssertLT(f.FalsePositiveRate(), 0.05)
+		t.Assert(f.AddIfNotExist(1), false)
+
+		b, err := f.MarshalBinary()
+		t.AssertNil(err)
+		f2 := dsketch.NewScalableBloomFilter(1, 0.1)
+		t.AssertNil(f2.UnmarshalBinary(b))
+		t.Assert(f2.Layers(), f.Layers())
+		t.Assert(f2.Count(), f.Count())
+		t.Assert(f2.Contains(999), true)
+
+		f3 := dsketch.NewScalableBloomFilter(100, 0.01)
+		f3.Add("x")
+		t.AssertNil(f3.Merge(f))
+		t.Assert(f3.Contains("x"), true)
+		t.Assert(f3.Contains(500), true)
+		t.Assert(f3.Count(), f.Count()+1)
+
+		f.Clear()
+		t.Assert(f.Layers(), 1)
+		t.Assert(f.Contains(1), false)
+	})
+}
+
+func Test_ScalableBloomFilter_Concurrent(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		f := dsketch.NewScalableBloomFilter(100, 0.01, true)
+		wg := sync.WaitGroup{}
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 500; i++ {
+					f.Add(g*500 + i)
+					f.Contains(i)
+				}
+			}(g)
+		}
+		wg.Wait()
+		for i := 0; i < 2000; i++ {
+			t.Assert(f.Contains(i), true)
+		}
+	})
+}
+
+func Test_CountingBloomFilter(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		f := dsketch.NewCountingBloomFilter(1000, 0.01)
+		f.Add("a")
+		f.Add("a")
+		f.Add("b")
+		t.Assert(f.Count(), 3)
+		t.Assert(f.Contains("a"), true)
+		t.Assert(f.Estimate("a"), 2)
+		t.Assert(f.Remove("a"), true)
+		t.Assert(f.Contains("a"), true)
+		t.Assert(f.Remove("a"), true)
+		t.Assert(f.Contains("a"), false)
+		t.Assert(f.Remove("a"), false)
+		t.Assert(f.Contains("b"), true)
+		t.Assert(f.Count(), 1)
+
+		f2 := dsketch.NewCountingBloomFilter(1000, 0.01)
+		f2.Add("c")
+		t.AssertNil(f.Merge(f2))
+		t.Assert(f.Contains("c"), true)
+		t.AssertNE(f.Merge(dsketch.NewCountingBloomFilter(10, 0.01)), nil)
+
+		b, err := f.MarshalBinary()
+		t.AssertNil(err)
+		f3 := dsketch.NewCountingBloomFilterSize(1, 1)
+		t.AssertNil(f3.UnmarshalBinary(b))
+		t.Assert(f3.Size(), f.Size())
+		t.Assert(f3.HashCount(), f.HashCount())
+		t.Assert(f3.Count(), 2)
+		t.Assert(f3.Contains("b"), true)
+		t.Assert(f3.Contains("c"), true)
+
+		f.Clear()
+		t.Assert(f.Contains("b"), false)
+		t.Assert(f.Count(), 0)
+	})
+}
@@ -0,0 +1,167 @@
+package dsketch_test
+
+import (
+	"fmt"
+	"github.com/osgochina/donkeygo/container/dsketch"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"sync"
+	"testing"
+)
+
+func Test_CountMinSketch(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		s := dsketch.NewCountMinSketchWithEstimates(0.001, 0.01)
+		t.Assert(s.Width(), 2719)
+		t.Assert(s.Depth(), 5)
+		for i := 0; i < 1000; i++ {
+			s.Add(fmt.Sprintf("k%d", i%100))
+		}
+		s.Add("big", 500)
+		t.Assert(s.Total(), 1500)
+		for i := 0; i < 100; i++ {
+			t.AssertGE(s.Estimate(fmt.Sprintf("k%d", i)), 10)
+			t.AssertLE(s.Estimate(fmt.Sprintf("k%d", i)), 12)
+		}
+		t.AssertGE(s.Estimate("big"), 500)
+		t.AssertLE(s.Estimate("none"), 2)
+
+		s2 := dsketch.NewCountMinSketch(s.Width(), s.Depth())
+		s2.Add("big", 100)
+		t.AssertNil(s.Merge(s2))
+		t.AssertGE(s.Estimate("big"), 600)
+		t.Assert(s.Total(), 1600)
+		t.AssertNE(s.Merge(dsketch.NewCountMinSketch(10, 5)), nil)
+
+		b, err := s.MarshalBinary()
+		t.AssertNil(err)
+		s3 := dsketch.NewCountMinSketch(1, 1, true)
+		t.AssertNil(s3.UnmarshalBinary(b))
+		t.Assert(s3.Width(), s.Width())
+		t.Assert(s3.Total(), 1600)
+		t.Assert(s3.Estimate("big"), s.Estimate("big"))
+
+		s.Clear()
+		t.Assert(s.Total(), 0)
+		t.Assert(s.Estimate("big"), 0)
+	})
+}
+
+func Test_HyperLogLog(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		h := dsketch.NewHyperLogLog(0)
+		t.Assert(h.Precision(), 14)
+		t.Assert(h.Count(), 0)
+		for i := 0; i < 10; i++ {
+			h.Add("a")
+		}
+		t.Assert(h.Count(), 1)
+
+		for _, n := range []int{1000, 100000} {
+			h.Clear()
+			for i := 0; i < n; i++ {
+				h.Add(fmt.Sprintf("session-%d", i))
+				h.Add(fmt.Sprintf("session-%d", i))
+			}
+			diff := float64(h.Count())/float64(n) - 1
+			t.AssertLT(diff, 0.03)
+			t.AssertGT(diff, -0.03)
+		}
+	})
+}
+
+func Test_HyperLogLog_MergeBinary(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		h1 := dsketch.NewHyperLogLog(12)
+		h2 := dsketch.NewHyperLogLog(12, true)
+		for i := 0; i < 6000; i++ {
+			h1.Add(i)
+		}
+		for i := 4000; i < 10000; i++ {
+			h2.Add(i)
+		}
+		t.AssertNil(h1.Merge(h2))
+		t.AssertGT(h1.Count(), 9500)
+		t.AssertLT(h1.Count(), 10500)
+		t.AssertNE(h1.Merge(dsketch.NewHyperLogLog(10)), nil)
+
+		b, err := h1.MarshalBinary()
+		t.AssertNil(err)
+		t.Assert(len(b), 2+1+4096)
+		h3 := dsketch.NewHyperLogLog(4)
+		t.AssertNil(h3.UnmarshalBinary(b))
+		t.Assert(h3.Precision(), 12)
+		t.Assert(h3.Count(), h1.Count())
+		t.AssertNE(h3.UnmarshalBinary(b[:100]), nil)
+	})
+}
+
+func Test_HyperLogLog_Concurrent(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		h := dsketch.NewHyperLogLog(10, true)
+		wg := sync.WaitGroup{}
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 1000; i++ {
+					h.Add(i)
+					h.Count()
+				}
+			}()
+		}
+		wg.Wait()
+		t.AssertGT(h.Count(), 900)
+		t.AssertLT(h.Count(), 1100)
+	})
+}
+
+func Test_TopK(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		k := dsketch.NewTopK(3, 1000, 5)
+		t.Assert(k.K(), 3)
+		for i := 0; i < 100; i++ {
+			k.Add(fmt.Sprintf("noise-%d", i))
+		}
+		k.Add("a", 50)
+		k.Add("b", 40)
+		for i := 0; i < 30; i++ {
+			k.Add([]byte("c"))
+		}
+		k.Add("d", 20)
+		list := k.List()
+		t.Assert(len(list), 3)
+		t.Assert(list[0].Key, "a")
+		t.AssertGE(list[0].Count, 50)
+		t.Assert(list[1].Key, "b")
+		t.Assert(list[2].Key, "c")
+		t.Assert(k.Contains("a"), true)
+		t.Assert(k.Contains("d"), false)
+		t.AssertGE(k.Estimate("d"), 20)
+		t.Assert(k.Total(), 240)
+
+		k2 := dsketch.NewTopK(3, 1000, 5)
+		k2.Add("d", 100)
+		k2.Add("c", 5)
+		t.AssertNil(k.Merge(k2))
+		list = k.List()
+		t.Assert(list[0].Key, "d")
+		t.AssertGE(list[0].Count, 120)
+		t.Assert(list[1].Key, "a")
+		t.Assert(list[2].Key, "b")
+		t.AssertNE(k.Merge(dsketch.NewTopK(3, 10, 5)), nil)
+
+		b, err := k.MarshalBinary()
+		t.AssertNil(err)
+		k3 := dsketch.NewTopK(1, 1, 1)
+		t.AssertNil(k3.UnmarshalBinary(b))
+		t.Assert(k3.K(), 3)
+		t.Assert(k3.List(), k.List())
+		k3.Add("e", 1000)
+		t.Assert(k3.List()[0].Key, "e")
+		t.Assert(k3.Contains("b"), false)
+
+		k.Clear()
+		t.Assert(len(k.List()), 0)
+		t.Assert(k.Total(), 0)
+	})
+}
@@ -0,0 +1,383 @@
+// Package dsnapshot 提供容器快照的二进制格式，用于把树、map、集合、数组等容器的数据保存到io.Writer，
+// 以及从io.Reader还原，key和value的编解码器可以自定义。
+//
+// 快照格式：
+//
+//	magic(4字节) | 版本号(1字节) | 类型(1字节) | 记录... | 结束标识(1字节) | 记录数(uvarint) | crc32(4字节)
+//
+// 每条记录以1字节的记录标识开头，map类型的记录依次是key和value，list类型的记录只有value，
+// key和value都以uvarint长度前缀加编码后的字节保存，crc32校验覆盖从第一条记录到记录数的所有字节。
+package dsnapshot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// Kind 快照的类型
+type Kind byte
+
+const (
+	KindMap  Kind = 1 // 由key value组成的快照，例如树和map
+	KindList Kind = 2 // 只有value的快照，例如集合和数组
+)
+
+const (
+	magic         = "DKSN"
+	version  byte = 1
+	flagItem byte = 1 // 记录标识
+	flagEnd  byte = 0 // 结束标识
+
+	maxItemSize   = 1 << 30 // 单个key或value编码后的最大长度，避免错误的数据导致申请过大的内存
+	readChunkSize = 1 << 16 // 读取记录时预先申请的最大内存，超过的部分按照实际读到的数据增长
+)
+
+// Option 快照的选项
+type Option struct {
+	Key   Codec // key的编解码器，默认DefaultCodec
+	Value Codec // value的编解码器，默认DefaultCodec
+}
+
+// 合并选项，未设置的编解码器使用默认值
+func getOption(option []Option) Option {
+	var opt Option
+	if len(option) > 0 {
+		opt = option[0]
+	}
+	if opt.Key == nil {
+		opt.Key = DefaultCodec
+	}
+	if opt.Value == nil {
+		opt.Value = DefaultCodec
+	}
+	return opt
+}
+
+// Writer 快照写入器，写入的数据经过缓冲，必须调用Close结束写入
+type Writer struct {
+	kind   Kind
+	option Option
+	w      *bufio.Writer
+	crc    hash.Hash32
+	count  uint64
+	buf    [binary.MaxVarintLen64]byte
+	err    error
+}
+
+// NewWriter 创建快照写入器，并写入快照头
+func NewWriter(w io.Writer, kind Kind, option ...Option) (*Writer, error) {
+	sw := &Writer{
+		kind:   kind,
+		option: getOption(option),
+		w:      bufio.NewWriter(w),
+		crc:    crc32.NewIEEE(),
+	}
+	sw.w.WriteString(magic)
+	sw.w.WriteByte(version)
+	if err := sw.w.WriteByte(byte(kind)); err != nil {
+		return nil, err
+	}
+	return sw, nil
+}
+
+// WriteEntry 写入一条key value记录，只能用于KindMap类型的快照
+func (that *Writer) WriteEntry(key, value interface{}) error {
+	if that.kind != KindMap {
+		return errors.New("dsnapshot: WriteEntry is only available for map snapshot")
+	}
+	k, err := that.option.Key.Encode(key)
+	if err != nil {
+		return err
+	}
+	v, err := that.option.Value.Encode(value)
+	if err != nil {
+		return err
+	}
+	that.write([]byte{flagItem})
+	that.writeBytes(k)
+	that.writeBytes(v)
+	that.count++
+	return that.err
+}
+
+// WriteValue 写入一条value记录，只能用于KindList类型的快照
+func (that *Writer) WriteValue(value interface{}) error {
+	if that.kind != KindList {
+		return errors.New("dsnapshot: WriteValue is only available for list snapshot")
+	}
+	v, err := that.option.Value.Encode(value)
+	if err != nil {
+		return err
+	}
+	that.write([]byte{flagItem})
+	that.writeBytes(v)
+	that.count++
+	return that.err
+}
+
+// Close 写入结束标识、记录数和校验值，并把缓冲的数据写入底层的io.Writer，不会关闭底层的io.Writer
+func (that *Writer) Close() error {
+	that.write([]byte{flagEnd})
+	that.write(that.buf[:binary.PutUvarint(that.buf[:], that.count)])
+	if that.err != nil {
+		return that.err
+	}
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], that.crc.Sum32())
+	if _, err := that.w.Write(sum[:]); err != nil {
+		return err
+	}
+	return that.w.Flush()
+}
+
+// 写入带长度前缀的字节切片
+func (that *Writer) writeBytes(b []byte) {
+	that.write(that.buf[:binary.PutUvarint(that.buf[:], uint64(len(b)))])
+	that.write(b)
+}
+
+func (that *Writer) write(b []byte) {
+	if that.err != nil {
+		return
+	}
+	that.crc.Write(b)
+	_, that.err = that.w.Write(b)
+}
+
+// Reader 快照读取器
+type Reader struct {
+	kind   Kind
+	option Option
+	r      *bufio.Reader
+	crc    hash.Hash32
+	items  []rawItem // 校验通过的记录，还未解码
+	index  int
+	loaded bool
+	err    error
+}
+
+// 记录编码后的key和value
+type rawItem struct {
+	key   []byte
+	value []byte
+}
+
+// NewReader 创建快照读取器，并校验快照头，kind与快照的类型不一致时返回错误
+func NewReader(r io.Reader, kind Kind, option ...Option) (*Reader, error) {
+	sr := &Reader{
+		kind:   kind,
+		option: getOption(option),
+		r:      bufio.NewReader(r),
+		crc:    crc32.NewIEEE(),
+	}
+	header := make([]byte, len(magic)+2)
+	if _, err := io.ReadFull(sr.r, header); err != nil {
+		return nil, errors.New(fmt.Sprintf("dsnapshot: read header failed: %v", err))
+	}
+	if string(header[:len(magic)]) != magic {
+		return nil, errors.New("dsnapshot: invalid snapshot header")
+	}
+	if header[len(magic)] != version {
+		return nil, errors.New(fmt.Sprintf("dsnapshot: unsupported snapshot version %d", header[len(magic)]))
+	}
+	if Kind(header[len(magic)+1]) != kind {
+		return nil, errors.New(fmt.Sprintf("dsnapshot: snapshot kind %d does not match %d", header[len(magic)+1], kind))
+	}
+	return sr, nil
+}
+
+// Next 读取下一条记录，list类型的快照返回的key为nil，所有记录读取完毕时返回io.EOF。
+// 第一次调用时会读取整个快照并校验记录数和crc32，校验通过后才会解码记录
+func (that *Reader) Next() (key, value interface{}, err error) {
+	if !that.loaded {
+		that.loaded = true
+		that.err = that.load()
+	}
+	if that.err != nil {
+		return nil, nil, that.err
+	}
+	if that.index >= len(that.items) {
+		return nil, nil, io.EOF
+	}
+	item := that.items[that.index]
+	that.items[that.index] = rawItem{}
+	that.index++
+	if that.kind == KindMap {
+		if key, err = that.option.Key.Decode(item.key); err != nil {
+			return nil, nil, err
+		}
+	}
+	if value, err = that.option.Value.Decode(item.value); err != nil {
+		return nil, nil, err
+	}
+	return key, value, nil
+}
+
+// 读取所有记录编码后的数据，直到结束标识，然后校验记录数和crc32
+func (that *Reader) load() error {
+	for {
+		flag, err := that.ReadByte()
+		if err != nil {
+			return that.unexpected(err)
+		}
+		switch flag {
+		case flagItem:
+			var item rawItem
+			if that.kind == KindMap {
+				if item.key, err = that.readBytes(); err != nil {
+					return err
+				}
+			}
+			if item.value, err = that.readBytes(); err != nil {
+				return err
+			}
+			that.items = append(that.items, item)
+		case flagEnd:
+			return that.finish()
+		default:
+			return errors.New(fmt.Sprintf("dsnapshot: invalid record flag %d", flag))
+		}
+	}
+}
+
+// 读取带长度前缀的key或value，内存按照实际读到的数据分配，
+// 被截断或者长度前缀错误的快照不会按照长度前缀申请大块内存
+func (that *Reader) readBytes() ([]byte, error) {
+	size, err := binary.ReadUvarint(that)
+	if err != nil {
+		return nil, that.unexpected(err)
+	}
+	if size > maxItemSize {
+		return nil, errors.New(fmt.Sprintf("dsnapshot: item size %d is too large", size))
+	}
+	var buf bytes.Buffer
+	if size <= readChunkSize {
+		buf.Grow(int(size))
+	} else {
+		buf.Grow(readChunkSize)
+	}
+	if _, err = io.CopyN(&buf, that.r, int64(size)); err != nil {
+		return nil, that.unexpected(err)
+	}
+	that.crc.Write(buf.Bytes())
+	return buf.Bytes(), nil
+}
+
+// 校验记录数和crc32
+func (that *Reader) finish() error {
+	count, err := binary.ReadUvarint(that)
+	if err != nil {
+		return that.unexpected(err)
+	}
+	expect := that.crc.Sum32()
+	var sum [4]byte
+	if _, err = io.ReadFull(that.r, sum[:]); err != nil {
+		return that.unexpected(err)
+	}
+	if count != uint64(len(that.items)) {
+		return errors.New(fmt.Sprintf("dsnapshot: snapshot has %d records but %d were read", count, len(that.items)))
+	}
+	if binary.LittleEndian.Uint32(sum[:]) != expect {
+		return errors.New("dsnapshot: snapshot checksum mismatch")
+	}
+	return nil
+}
+
+// ReadByte 实现io.ByteReader，读取的字节会计入校验值
+func (that *Reader) ReadByte() (byte, error) {
+	b, err := that.r.ReadByte()
+	if err == nil {
+		that.crc.Write([]byte{b})
+	}
+	return b, err
+}
+
+// 快照在结束标识之前被截断
+func (that *Reader) unexpected(err error) error {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return errors.New(fmt.Sprintf("dsnapshot: read snapshot failed: %v", err))
+}
+
+// WriteMap 把iterator迭代的所有key value写入快照
+func WriteMap(w io.Writer, iterator func(f func(key, value interface{}) bool), option ...Option) error {
+	sw, err := NewWriter(w, KindMap, option...)
+	if err != nil {
+		return err
+	}
+	iterator(func(key, value interface{}) bool {
+		err = sw.WriteEntry(key, value)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	return sw.Close()
+}
+
+// ReadMap 读取快照中的所有key value，读取完毕并且校验通过后才会依次调用f，
+// 快照不完整时不会调用f
+func ReadMap(r io.Reader, f func(key, value interface{}), option ...Option) error {
+	sr, err := NewReader(r, KindMap, option...)
+	if err != nil {
+		return err
+	}
+	var keys, values []interface{}
+	for {
+		key, value, err := sr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	for i, key := range keys {
+		f(key, values[i])
+	}
+	return nil
+}
+
+// WriteList 把iterator迭代的所有value写入快照
+func WriteList(w io.Writer, iterator func(f func(value interface{}) bool), option ...Option) error {
+	sw, err := NewWriter(w, KindList, option...)
+	if err != nil {
+		return err
+	}
+	iterator(func(value interface{}) bool {
+		err = sw.WriteValue(value)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	return sw.Close()
+}
+
+// ReadList 读取快照中的所有value，读取完毕并且校验通过后返回
+func ReadList(r io.Reader, option ...Option) ([]interface{}, error) {
+	sr, err := NewReader(r, KindList, option...)
+	if err != nil {
+		return nil, err
+	}
+	var values []interface{}
+	for {
+		_, value, err := sr.Next()
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+}
@@ -0,0 +1,209 @@
+package dsnapshot
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/osgochina/donkeygo/internal/json"
+	"math"
+	"time"
+)
+
+// Codec 快照中key和value的编解码器
+type Codec interface {
+	Encode(v interface{}) ([]byte, error)
+	Decode(b []byte) (interface{}, error)
+}
+
+var (
+	// DefaultCodec 默认的编解码器，基础类型会带上类型标识以紧凑的二进制格式保存，还原后类型保持不变，
+	// 其他类型使用json保存，还原后是json解析得到的map、slice或json.Number等类型
+	DefaultCodec Codec = binaryCodec{}
+
+	// JsonCodec 使用json编解码，还原后的数字类型为json.Number
+	JsonCodec Codec = jsonCodec{}
+)
+
+// 默认编解码器使用的类型标识
+const (
+	tagNil byte = iota
+	tagBool
+	tagInt
+	tagInt8
+	tagInt16
+	tagInt32
+	tagInt64
+	tagUint
+	tagUint8
+	tagUint16
+	tagUint32
+	tagUint64
+	tagFloat32
+	tagFloat64
+	tagString
+	tagBytes
+	tagTime
+	tagJson = 0xff
+)
+
+type binaryCodec struct{}
+
+// Encode 编码，整数使用变长编码
+func (binaryCodec) Encode(v interface{}) ([]byte, error) {
+	switch value := v.(type) {
+	case nil:
+		return []byte{tagNil}, nil
+	case bool:
+		if value {
+			return []byte{tagBool, 1}, nil
+		}
+		return []byte{tagBool, 0}, nil
+	case int:
+		return withVarint(tagInt, int64(value)), nil
+	case int8:
+		return withVarint(tagInt8, int64(value)), nil
+	case int16:
+		return withVarint(tagInt16, int64(value)), nil
+	case int32:
+		return withVarint(tagInt32, int64(value)), nil
+	case int64:
+		return withVarint(tagInt64, value), nil
+	case uint:
+		return withUvarint(tagUint, uint64(value)), nil
+	case uint8:
+		return withUvarint(tagUint8, uint64(value)), nil
+	case uint16:
+		return withUvarint(tagUint16, uint64(value)), nil
+	case uint32:
+		return withUvarint(tagUint32, uint64(value)), nil
+	case uint64:
+		return withUvarint(tagUint64, value), nil
+	case float32:
+		return withFixed(tagFloat32, uint64(math.Float32bits(value)), 4), nil
+	case float64:
+		return withFixed(tagFloat64, math.Float64bits(value), 8), nil
+	case string:
+		return append([]byte{tagString}, value...), nil
+	case []byte:
+		return append([]byte{tagBytes}, value...), nil
+	case time.Time:
+		b, err := value.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{tagTime}, b...), nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{tagJson}, b...), nil
+	}
+}
+
+// Decode 解码
+func (binaryCodec) Decode(b []byte) (interface{}, error) {
+	if len(b) == 0 {
+		return nil, errors.New("dsnapshot: empty encoded value")
+	}
+	tag, data := b[0], b[1:]
+	switch tag {
+	case tagNil:
+		return nil, nil
+	case tagBool:
+		return len(data) > 0 && data[0] == 1, nil
+	case tagInt, tagInt8, tagInt16, tagInt32, tagInt64:
+		i, n := binary.Varint(data)
+		if n <= 0 {
+			return nil, errors.New(fmt.Sprintf("dsnapshot: invalid varint of tag %d", tag))
+		}
+		switch tag {
+		case tagInt:
+			return int(i), nil
+		case tagInt8:
+			return int8(i), nil
+		case tagInt16:
+			return int16(i), nil
+		case tagInt32:
+			return int32(i), nil
+		}
+		return i, nil
+	case tagUint, tagUint8, tagUint16, tagUint32, tagUint64:
+		u, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errors.New(fmt.Sprintf("dsnapshot: invalid uvarint of tag %d", tag))
+		}
+		switch tag {
+		case tagUint:
+			return uint(u), nil
+		case tagUint8:
+			return uint8(u), nil
+		case tagUint16:
+			return uint16(u), nil
+		case tagUint32:
+			return uint32(u), nil
+		}
+		return u, nil
+	case tagFloat32:
+		if len(data) != 4 {
+			return nil, errors.New("dsnapshot: invalid float32")
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(data)), nil
+	case tagFloat64:
+		if len(data) != 8 {
+			return nil, errors.New("dsnapshot: invalid float64")
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(data)), nil
+	case tagString:
+		return string(data), nil
+	case tagBytes:
+		return append([]byte{}, data...), nil
+	case tagTime:
+		var t time.Time
+		if err := t.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+		return t, nil
+	case tagJson:
+		return jsonCodec{}.Decode(data)
+	}
+	return nil, errors.New(fmt.Sprintf("dsnapshot: unknown value tag %d", tag))
+}
+
+// 写入类型标识以及变长编码的有符号整数
+func withVarint(tag byte, v int64) []byte {
+	b := make([]byte, 1+binary.MaxVarintLen64)
+	b[0] = tag
+	return b[:1+binary.PutVarint(b[1:], v)]
+}
+
+// 写入类型标识以及变长编码的无符号整数
+func withUvarint(tag byte, v uint64) []byte {
+	b := make([]byte, 1+binary.MaxVarintLen64)
+	b[0] = tag
+	return b[:1+binary.PutUvarint(b[1:], v)]
+}
+
+// 写入类型标识以及size个字节的小端整数
+func withFixed(tag byte, v uint64, size int) []byte {
+	b := make([]byte, 9)
+	b[0] = tag
+	binary.LittleEndian.PutUint64(b[1:], v)
+	return b[:1+size]
+}
+
+type jsonCodec struct{}
+
+// Encode 编码
+func (jsonCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Decode 解码
+func (jsonCodec) Decode(b []byte) (interface{}, error) {
+	var v interface{}
+	if err := json.UnmarshalUseNumber(b, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
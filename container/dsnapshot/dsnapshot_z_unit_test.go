@@ -0,0 +1,203 @@
+package dsnapshot_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/osgochina/donkeygo/container/dsnapshot"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"io"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func Test_Codec(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		now := time.Date(2021, 6, 1, 8, 30, 0, 500, time.UTC)
+		values := []interface{}{
+			nil, true, false, 1, -1, int8(-8), int16(16), int32(-32), int64(1 << 40),
+			uint(1), uint8(8), uint16(16), uint32(32), uint64(1 << 63),
+			float32(1.5), 3.25, "", "donkey", []byte("go"), now,
+		}
+		for _, v := range values {
+			b, err := dsnapshot.DefaultCodec.Encode(v)
+			t.AssertNil(err)
+			decoded, err := dsnapshot.DefaultCodec.Decode(b)
+			t.AssertNil(err)
+			t.AssertEQ(decoded, v)
+		}
+		b, err := dsnapshot.DefaultCodec.Encode(map[string]int{"a": 1})
+		t.AssertNil(err)
+		decoded, err := dsnapshot.DefaultCodec.Decode(b)
+		t.AssertNil(err)
+		t.Assert(decoded, map[string]interface{}{"a": 1})
+
+		_, err = dsnapshot.DefaultCodec.Decode(nil)
+		t.AssertNE(err, nil)
+		_, err = dsnapshot.DefaultCodec.Decode([]byte{200})
+		t.AssertNE(err, nil)
+	})
+}
+
+func Test_Map(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var buf bytes.Buffer
+		keys := []interface{}{"a", 2, 3.5}
+		values := []interface{}{1, "b", []byte("c")}
+		err := dsnapshot.WriteMap(&buf, func(f func(key, value interface{}) bool) {
+			for i, key := range keys {
+				if !f(key, values[i]) {
+					return
+				}
+			}
+		})
+		t.AssertNil(err)
+
+		var readKeys, readValues []interface{}
+		err = dsnapshot.ReadMap(bytes.NewReader(buf.Bytes()), func(key, value interface{}) {
+			readKeys, readValues = append(readKeys, key), append(readValues, value)
+		})
+		t.AssertNil(err)
+		t.Assert(readKeys, keys)
+		t.Assert(readValues, values)
+
+		// The kind of the snapshot is checked.
+		_, err = dsnapshot.ReadList(bytes.NewReader(buf.Bytes()))
+		t.AssertNE(err, nil)
+	})
+}
+
+func Test_List(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var buf bytes.Buffer
+		err := dsnapshot.WriteList(&buf, func(f func(value interface{}) bool) {
+			for i := 0; i < 100; i++ {
+				if !f(i) {
+					return
+				}
+			}
+		}, dsnapshot.Option{Value: dsnapshot.JsonCodec})
+		t.AssertNil(err)
+
+		values, err := dsnapshot.ReadList(bytes.NewReader(buf.Bytes()), dsnapshot.Option{Value: dsnapshot.JsonCodec})
+		t.AssertNil(err)
+		t.Assert(len(values), 100)
+		t.Assert(values[99], 99)
+
+		var empty bytes.Buffer
+		t.AssertNil(dsnapshot.WriteList(&empty, func(f func(value interface{}) bool) {}))
+		values, err = dsnapshot.ReadList(&empty)
+		t.AssertNil(err)
+		t.Assert(len(values), 0)
+	})
+}
+
+func Test_Reader(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var buf bytes.Buffer
+		w, err := dsnapshot.NewWriter(&buf, dsnapshot.KindMap)
+		t.AssertNil(err)
+		t.AssertNil(w.WriteEntry("k1", "v1"))
+		t.AssertNil(w.WriteEntry("k2", "v2"))
+		t.AssertNE(w.WriteValue("v3"), nil)
+		t.AssertNil(w.Close())
+
+		r, err := dsnapshot.NewReader(bytes.NewReader(buf.Bytes()), dsnapshot.KindMap)
+		t.AssertNil(err)
+		key, value, err := r.Next()
+		t.AssertNil(err)
+		t.Assert(key, "k1")
+		t.Assert(value, "v1")
+		_, _, err = r.Next()
+		t.AssertNil(err)
+		_, _, err = r.Next()
+		t.Assert(err, io.EOF)
+		_, _, err = r.Next()
+		t.Assert(err, io.EOF)
+	})
+}
+
+func Test_Corrupted(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var buf bytes.Buffer
+		err := dsnapshot.WriteList(&buf, func(f func(value interface{}) bool) {
+			f("donkey")
+			f("go")
+		})
+		t.AssertNil(err)
+		data := buf.Bytes()
+
+		// Truncated at every position.
+		for i := 0; i < len(data); i++ {
+			_, err = dsnapshot.ReadList(bytes.NewReader(data[:i]))
+			t.AssertNE(err, nil)
+		}
+
+		// A flipped byte in the records breaks the checksum.
+		broken := append([]byte{}, data...)
+		broken[9] ^= 0x01
+		_, err = dsnapshot.ReadList(bytes.NewReader(broken))
+		t.AssertNE(err, nil)
+
+		// Invalid header.
+		broken = append([]byte{}, data...)
+		broken[0] = 'X'
+		_, err = dsnapshot.ReadList(bytes.NewReader(broken))
+		t.AssertNE(err, nil)
+	})
+}
+
+// countCodec counts the calls of Decode.
+type countCodec struct {
+	decoded int
+}
+
+func (c *countCodec) Encode(v interface{}) ([]byte, error) {
+	return dsnapshot.DefaultCodec.Encode(v)
+}
+
+func (c *countCodec) Decode(b []byte) (interface{}, error) {
+	c.decoded++
+	return dsnapshot.DefaultCodec.Decode(b)
+}
+
+func Test_VerifyBeforeDecode(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var buf bytes.Buffer
+		err := dsnapshot.WriteList(&buf, func(f func(value interface{}) bool) {
+			f("donkey")
+			f("go")
+		})
+		t.AssertNil(err)
+
+		// Nothing is decoded if the checksum does not match.
+		broken := append([]byte{}, buf.Bytes()...)
+		broken[len(broken)-1] ^= 0x01
+		codec := &countCodec{}
+		_, err = dsnapshot.ReadList(bytes.NewReader(broken), dsnapshot.Option{Value: codec})
+		t.AssertNE(err, nil)
+		t.Assert(codec.decoded, 0)
+
+		values, err := dsnapshot.ReadList(bytes.NewReader(buf.Bytes()), dsnapshot.Option{Value: codec})
+		t.AssertNil(err)
+		t.Assert(values, []interface{}{"donkey", "go"})
+		t.Assert(codec.decoded, 2)
+	})
+}
+
+func Test_HugeItemSize(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		// A record claims 512MB but the snapshot ends after a few bytes.
+		size := make([]byte, binary.MaxVarintLen64)
+		data := []byte("DKSN\x01\x02\x01")
+		data = append(data, size[:binary.PutUvarint(size, 1<<29)]...)
+		data = append(data, "abc"...)
+
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		_, err := dsnapshot.ReadList(bytes.NewReader(data))
+		runtime.ReadMemStats(&after)
+		t.AssertNE(err, nil)
+		t.AssertLT(after.TotalAlloc-before.TotalAlloc, uint64(1<<20))
+	})
+}
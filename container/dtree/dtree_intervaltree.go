@@ -0,0 +1,356 @@
+package dtree
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/osgochina/donkeygo/internal/json"
+	"github.com/osgochina/donkeygo/internal/rwmutex"
+)
+
+// IntervalTree holds closed intervals [Start, End] in an AVL tree ordered by Start and then End.
+// Every node keeps the maximum End of its subtree, so that all intervals overlapping
+// a given range can be found in O(log n + k), where k is the number of results.
+type IntervalTree struct {
+	mu         rwmutex.RWMutex
+	root       *IntervalTreeNode
+	comparator func(v1, v2 interface{}) int
+	size       int
+}
+
+// IntervalTreeNode is a single element within the tree.
+type IntervalTreeNode struct {
+	Start  interface{}
+	End    interface{}
+	Value  interface{}
+	max    interface{} // Maximum End of the subtree.
+	height int
+	left   *IntervalTreeNode
+	right  *IntervalTreeNode
+}
+
+// NewIntervalTree instantiates an interval tree with the custom comparator of the interval bounds.
+// The parameter <safe> is used to specify whether using tree in concurrent-safety,
+// which is false in default.
+func NewIntervalTree(comparator func(v1, v2 interface{}) int, safe ...bool) *IntervalTree {
+	return &IntervalTree{
+		mu:         rwmutex.Create(safe...),
+		comparator: comparator,
+	}
+}
+
+// Clone returns a new tree with a copy of current tree.
+func (tree *IntervalTree) Clone() *IntervalTree {
+	newTree := NewIntervalTree(tree.comparator, tree.mu.IsSafe())
+	tree.Iterator(func(start, end, value interface{}) bool {
+		newTree.root, _ = newTree.insert(newTree.root, start, end, value)
+		return true
+	})
+	return newTree
+}
+
+// Set inserts interval [start, end] with <value> into the tree,
+// or updates the value if the same interval already exists.
+func (tree *IntervalTree) Set(start, end interface{}, value interface{}) {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+	start, end = tree.normalize(start, end)
+	tree.root, _ = tree.insert(tree.root, start, end, value)
+}
+
+// Get returns the value of interval [start, end] or nil if the interval is not found.
+func (tree *IntervalTree) Get(start, end interface{}) (value interface{}) {
+	value, _ = tree.Search(start, end)
+	return
+}
+
+// Search searches the tree with interval [start, end].
+// Second return parameter <found> is true if the interval was found, otherwise false.
+func (tree *IntervalTree) Search(start, end interface{}) (value interface{}, found bool) {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+	start, end = tree.normalize(start, end)
+	node := tree.root
+	for node != nil {
+		switch c := tree.compare(start, end, node); {
+		case c < 0:
+			node = node.left
+		case c > 0:
+			node = node.right
+		default:
+			return node.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Contains checks whether interval [start, end] exists in the tree.
+func (tree *IntervalTree) Contains(start, end interface{}) bool {
+	_, found := tree.Search(start, end)
+	return found
+}
+
+// Remove removes interval [start, end] from the tree and returns its value.
+func (tree *IntervalTree) Remove(start, end interface{}) (value interface{}) {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+	start, end = tree.normalize(start, end)
+	tree.root, value, _ = tree.delete(tree.root, start, end)
+	return
+}
+
+// Overlaps returns all intervals overlapping [start, end], ordered by their Start.
+func (tree *IntervalTree) Overlaps(start, end interface{}) []*IntervalTreeNode {
+	nodes := make([]*IntervalTreeNode, 0)
+	tree.IteratorOverlaps(start, end, func(s, e, value interface{}) bool {
+		nodes = append(nodes, &IntervalTreeNode{Start: s, End: e, Value: value})
+		return true
+	})
+	return nodes
+}
+
+// Stab returns all intervals containing the point <point>, ordered by their Start.
+func (tree *IntervalTree) Stab(point interface{}) []*IntervalTreeNode {
+	return tree.Overlaps(point, point)
+}
+
+// IteratorOverlaps iterates the intervals overlapping [start, end] readonly in ascending order
+// with given callback function <f>.
+// If <f> returns true, then it continues iterating; or false to stop.
+func (tree *IntervalTree) IteratorOverlaps(start, end interface{}, f func(start, end, value interface{}) bool) {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+	start, end = tree.normalize(start, end)
+	tree.doIteratorOverlaps(tree.root, start, end, f)
+}
+
+func (tree *IntervalTree) doIteratorOverlaps(node *IntervalTreeNode, start, end interface{}, f func(start, end, value interface{}) bool) bool {
+	// None of the intervals in the subtree ends after <start>.
+	if node == nil || tree.comparator(node.max, start) < 0 {
+		return true
+	}
+	if !tree.doIteratorOverlaps(node.left, start, end, f) {
+		return false
+	}
+	// The node and all intervals in the right subtree start after <end>.
+	if tree.comparator(node.Start, end) > 0 {
+		return true
+	}
+	if tree.comparator(node.End, start) >= 0 && !f(node.Start, node.End, node.Value) {
+		return false
+	}
+	return tree.doIteratorOverlaps(node.right, start, end, f)
+}
+
+// Iterator iterates all intervals readonly in ascending order with given callback function <f>.
+// If <f> returns true, then it continues iterating; or false to stop.
+func (tree *IntervalTree) Iterator(f func(start, end, value interface{}) bool) {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+	tree.doIterator(tree.root, f)
+}
+
+func (tree *IntervalTree) doIterator(node *IntervalTreeNode, f func(start, end, value interface{}) bool) bool {
+	if node == nil {
+		return true
+	}
+	return tree.doIterator(node.left, f) && f(node.Start, node.End, node.Value) && tree.doIterator(node.right, f)
+}
+
+// IsEmpty returns true if tree does not contain any nodes.
+func (tree *IntervalTree) IsEmpty() bool {
+	return tree.Size() == 0
+}
+
+// Size returns number of intervals in the tree.
+func (tree *IntervalTree) Size() int {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+	return tree.size
+}
+
+// Height returns the height of the tree.
+func (tree *IntervalTree) Height() int {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+	return tree.root.getHeight()
+}
+
+// Clear removes all nodes from the tree.
+func (tree *IntervalTree) Clear() {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+	tree.root = nil
+	tree.size = 0
+}
+
+// String returns a string representation of container.
+func (tree *IntervalTree) String() string {
+	var buffer bytes.Buffer
+	tree.Iterator(func(start, end, value interface{}) bool {
+		buffer.WriteString(fmt.Sprintf("[%v, %v]: %v\n", start, end, value))
+		return true
+	})
+	return buffer.String()
+}
+
+// Print prints the tree to stdout.
+func (tree *IntervalTree) Print() {
+	fmt.Println(tree.String())
+}
+
+// MarshalJSON implements the interface MarshalJSON for json.Marshal.
+func (tree *IntervalTree) MarshalJSON() ([]byte, error) {
+	items := make([]map[string]interface{}, 0, tree.Size())
+	tree.Iterator(func(start, end, value interface{}) bool {
+		items = append(items, map[string]interface{}{
+			"start": start,
+			"end":   end,
+			"value": value,
+		})
+		return true
+	})
+	return json.Marshal(items)
+}
+
+// normalize exchanges <start> and <end> if <start> is larger than <end>,
+// which is applied to all intervals passed to the tree.
+func (tree *IntervalTree) normalize(start, end interface{}) (interface{}, interface{}) {
+	if tree.getComparator()(start, end) > 0 {
+		return end, start
+	}
+	return start, end
+}
+
+// compare compares interval [start, end] with <node> by Start and then End.
+func (tree *IntervalTree) compare(start, end interface{}, node *IntervalTreeNode) int {
+	if c := tree.getComparator()(start, node.Start); c != 0 {
+		return c
+	}
+	return tree.getComparator()(end, node.End)
+}
+
+func (tree *IntervalTree) insert(node *IntervalTreeNode, start, end, value interface{}) (*IntervalTreeNode, bool) {
+	if node == nil {
+		tree.size++
+		return &IntervalTreeNode{Start: start, End: end, Value: value, max: end, height: 1}, true
+	}
+	var inserted bool
+	switch c := tree.compare(start, end, node); {
+	case c < 0:
+		node.left, inserted = tree.insert(node.left, start, end, value)
+	case c > 0:
+		node.right, inserted = tree.insert(node.right, start, end, value)
+	default:
+		node.Value = value
+		return node, false
+	}
+	return tree.rebalance(node), inserted
+}
+
+func (tree *IntervalTree) delete(node *IntervalTreeNode, start, end interface{}) (*IntervalTreeNode, interface{}, bool) {
+	if node == nil {
+		return nil, nil, false
+	}
+	var (
+		value   interface{}
+		deleted bool
+	)
+	switch c := tree.compare(start, end, node); {
+	case c < 0:
+		node.left, value, deleted = tree.delete(node.left, start, end)
+	case c > 0:
+		node.right, value, deleted = tree.delete(node.right, start, end)
+	default:
+		value, deleted = node.Value, true
+		if node.left == nil || node.right == nil {
+			tree.size--
+			if node.left != nil {
+				return node.left, value, true
+			}
+			return node.right, value, true
+		}
+		// Replace the node with its successor, and then delete the successor from the right subtree.
+		successor := node.right
+		for successor.left != nil {
+			successor = successor.left
+		}
+		node.Start, node.End, node.Value = successor.Start, successor.End, successor.Value
+		node.right, _, _ = tree.delete(node.right, successor.Start, successor.End)
+	}
+	if !deleted {
+		return node, nil, false
+	}
+	return tree.rebalance(node), value, true
+}
+
+// rebalance updates the augmented data of <node> and rotates it if it's unbalanced.
+func (tree *IntervalTree) rebalance(node *IntervalTreeNode) *IntervalTreeNode {
+	tree.update(node)
+	switch factor := node.left.getHeight() - node.right.getHeight(); {
+	case factor > 1:
+		if node.left.left.getHeight() < node.left.right.getHeight() {
+			node.left = tree.rotateLeft(node.left)
+		}
+		return tree.rotateRight(node)
+	case factor < -1:
+		if node.right.right.getHeight() < node.right.left.getHeight() {
+			node.right = tree.rotateRight(node.right)
+		}
+		return tree.rotateLeft(node)
+	}
+	return node
+}
+
+func (tree *IntervalTree) rotateLeft(node *IntervalTreeNode) *IntervalTreeNode {
+	right := node.right
+	node.right = right.left
+	right.left = node
+	tree.update(node)
+	tree.update(right)
+	return right
+}
+
+func (tree *IntervalTree) rotateRight(node *IntervalTreeNode) *IntervalTreeNode {
+	left := node.left
+	node.left = left.right
+	left.right = node
+	tree.update(node)
+	tree.update(left)
+	return left
+}
+
+// update recalculates the height and the maximum End of <node> from its children.
+func (tree *IntervalTree) update(node *IntervalTreeNode) {
+	node.height = 1
+	node.max = node.End
+	if left := node.left; left != nil {
+		node.height = left.height + 1
+		if tree.comparator(left.max, node.max) > 0 {
+			node.max = left.max
+		}
+	}
+	if right := node.right; right != nil {
+		if right.height+1 > node.height {
+			node.height = right.height + 1
+		}
+		if tree.comparator(right.max, node.max) > 0 {
+			node.max = right.max
+		}
+	}
+}
+
+func (node *IntervalTreeNode) getHeight() int {
+	if node == nil {
+		return 0
+	}
+	return node.height
+}
+
+// getComparator returns the comparator if it's previously set,
+// or else it panics.
+func (tree *IntervalTree) getComparator() func(a, b interface{}) int {
+	if tree.comparator == nil {
+		panic("comparator is missing for tree")
+	}
+	return tree.comparator
+}
@@ -27,6 +27,7 @@ type RedBlackTree struct {
 	root       *RedBlackTreeNode
 	size       int
 	comparator func(v1, v2 interface{}) int
+	cow        *redBlackTreeCow // Copy-on-write state of the snapshot in progress.
 }
 
 // RedBlackTreeNode is a single element within the tree.
@@ -65,6 +66,9 @@ func (tree *RedBlackTree) SetComparator(comparator func(a, b interface{}) int) {
 	tree.mu.Lock()
 	defer tree.mu.Unlock()
 	tree.comparator = comparator
+	if tree.cow != nil {
+		tree.cowFreeze()
+	}
 	if tree.size > 0 {
 		data := make(map[interface{}]interface{}, tree.size)
 		tree.doIteratorAsc(tree.leftNode(), func(key, value interface{}) bool {
@@ -105,6 +109,9 @@ func (tree *RedBlackTree) Sets(data map[interface{}]interface{}) {
 
 // doSet inserts key-value item into the tree without mutex.
 func (tree *RedBlackTree) doSet(key interface{}, value interface{}) {
+	if tree.cow != nil {
+		tree.cowSave(key)
+	}
 	insertedNode := (*RedBlackTreeNode)(nil)
 	if tree.root == nil {
 		// Assert key is of comparator's type for initial tree
@@ -275,6 +282,9 @@ func (tree *RedBlackTree) Contains(key interface{}) bool {
 
 // doRemove removes the node from the tree by <key> without mutex.
 func (tree *RedBlackTree) doRemove(key interface{}) (value interface{}) {
+	if tree.cow != nil {
+		tree.cowSave(key)
+	}
 	child := (*RedBlackTreeNode)(nil)
 	node, found := tree.doSearch(key)
 	if !found {
@@ -604,6 +614,9 @@ loop:
 func (tree *RedBlackTree) Clear() {
 	tree.mu.Lock()
 	defer tree.mu.Unlock()
+	if tree.cow != nil {
+		tree.cowFreeze()
+	}
 	tree.root = nil
 	tree.size = 0
 }
@@ -612,6 +625,9 @@ func (tree *RedBlackTree) Clear() {
 func (tree *RedBlackTree) Replace(data map[interface{}]interface{}) {
 	tree.mu.Lock()
 	defer tree.mu.Unlock()
+	if tree.cow != nil {
+		tree.cowFreeze()
+	}
 	tree.root = nil
 	tree.size = 0
 	for k, v := range data {
@@ -664,6 +680,9 @@ func (tree *RedBlackTree) Flip(comparator ...func(v1, v2 interface{}) int) {
 		return true
 	})
 	tree.mu.Lock()
+	if tree.cow != nil {
+		tree.cowFreeze()
+	}
 	tree.root = t.root
 	tree.size = t.size
 	tree.mu.Unlock()
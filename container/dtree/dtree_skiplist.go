@@ -0,0 +1,709 @@
+package dtree
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/osgochina/donkeygo/container/dvar"
+	"github.com/osgochina/donkeygo/internal/json"
+	"github.com/osgochina/donkeygo/internal/rwmutex"
+	"github.com/osgochina/donkeygo/util/dconv"
+	"github.com/osgochina/donkeygo/util/drand"
+)
+
+const (
+	skipListMaxLevel = 16 // Enough for 4^16 elements with probability 1/4.
+)
+
+// SkipList holds elements of the skip list, which keeps its keys ordered by the comparator.
+// Every level keeps the span between nodes, so that rank queries like Rank and GetByRank
+// cost O(log n) as well. Unlike the balanced trees, insertion and deletion never rebalance,
+// which keeps the time holding the write lock short.
+type SkipList struct {
+	mu         rwmutex.RWMutex
+	header     *SkipListNode
+	tail       *SkipListNode
+	comparator func(v1, v2 interface{}) int
+	size       int
+	level      int
+}
+
+// SkipListNode is a single element within the skip list.
+type SkipListNode struct {
+	Key      interface{}
+	Value    interface{}
+	backward *SkipListNode
+	levels   []skipListLevel
+}
+
+// skipListLevel is the forward pointer of a node on one level,
+// <span> is the number of nodes between the node and its forward node on level 0.
+type skipListLevel struct {
+	forward *SkipListNode
+	span    int
+}
+
+// NewSkipList instantiates a skip list with the custom key comparator.
+// The parameter <safe> is used to specify whether using skip list in concurrent-safety,
+// which is false in default.
+func NewSkipList(comparator func(v1, v2 interface{}) int, safe ...bool) *SkipList {
+	return &SkipList{
+		mu:         rwmutex.Create(safe...),
+		header:     newSkipListNode(skipListMaxLevel, nil, nil),
+		comparator: comparator,
+		level:      1,
+	}
+}
+
+// NewSkipListFrom instantiates a skip list with the custom key comparator and data map.
+// The parameter <safe> is used to specify whether using skip list in concurrent-safety,
+// which is false in default.
+func NewSkipListFrom(comparator func(v1, v2 interface{}) int, data map[interface{}]interface{}, safe ...bool) *SkipList {
+	list := NewSkipList(comparator, safe...)
+	for k, v := range data {
+		list.doSet(k, v)
+	}
+	return list
+}
+
+func newSkipListNode(level int, key, value interface{}) *SkipListNode {
+	return &SkipListNode{
+		Key:    key,
+		Value:  value,
+		levels: make([]skipListLevel, level),
+	}
+}
+
+// randomLevel returns a random level for the new node,
+// each level is promoted with a probability of 1/4.
+func randomLevel() int {
+	level := 1
+	for n := drand.Intn(1 << 30); level < skipListMaxLevel && n&3 == 0; n >>= 2 {
+		level++
+	}
+	return level
+}
+
+// Clone returns a new skip list with a copy of current skip list.
+func (list *SkipList) Clone() *SkipList {
+	newList := NewSkipList(list.comparator, list.mu.IsSafe())
+	newList.Sets(list.Map())
+	return newList
+}
+
+// Set inserts key-value item into the skip list.
+func (list *SkipList) Set(key interface{}, value interface{}) {
+	list.mu.Lock()
+	defer list.mu.Unlock()
+	list.doSet(key, value)
+}
+
+// Sets batch sets key-values to the skip list.
+func (list *SkipList) Sets(data map[interface{}]interface{}) {
+	list.mu.Lock()
+	defer list.mu.Unlock()
+	for k, v := range data {
+		list.doSet(k, v)
+	}
+}
+
+// doSet inserts key-value item into the skip list without mutex,
+// or updates the value if <key> already exists.
+func (list *SkipList) doSet(key interface{}, value interface{}) {
+	var (
+		update [skipListMaxLevel]*SkipListNode
+		rank   [skipListMaxLevel]int
+		node   = list.header
+	)
+	for i := list.level - 1; i >= 0; i-- {
+		if i < list.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for node.levels[i].forward != nil && list.getComparator()(node.levels[i].forward.Key, key) < 0 {
+			rank[i] += node.levels[i].span
+			node = node.levels[i].forward
+		}
+		update[i] = node
+	}
+	if next := node.levels[0].forward; next != nil && list.getComparator()(next.Key, key) == 0 {
+		next.Value = value
+		return
+	}
+	level := randomLevel()
+	if level > list.level {
+		for i := list.level; i < level; i++ {
+			rank[i] = 0
+			update[i] = list.header
+			update[i].levels[i].span = list.size
+		}
+		list.level = level
+	}
+	node = newSkipListNode(level, key, value)
+	for i := 0; i < level; i++ {
+		node.levels[i].forward = update[i].levels[i].forward
+		update[i].levels[i].forward = node
+		node.levels[i].span = update[i].levels[i].span - (rank[0] - rank[i])
+		update[i].levels[i].span = rank[0] - rank[i] + 1
+	}
+	for i := level; i < list.level; i++ {
+		update[i].levels[i].span++
+	}
+	if update[0] != list.header {
+		node.backward = update[0]
+	}
+	if node.levels[0].forward != nil {
+		node.levels[0].forward.backward = node
+	} else {
+		list.tail = node
+	}
+	list.size++
+}
+
+// Get searches the node in the skip list by <key> and returns its value or nil if key is not found.
+func (list *SkipList) Get(key interface{}) (value interface{}) {
+	value, _ = list.Search(key)
+	return
+}
+
+// doSetWithLockCheck checks whether value of the key exists with mutex.Lock,
+// if not exists, set value to the skip list with given <key>,
+// or else just return the existing value.
+//
+// When setting value, if <value> is type of <func() interface {}>,
+// it will be executed with mutex.Lock of the skip list,
+// and its return value will be set to the skip list with <key>.
+//
+// It returns value with given <key>.
+func (list *SkipList) doSetWithLockCheck(key interface{}, value interface{}) interface{} {
+	list.mu.Lock()
+	defer list.mu.Unlock()
+	if node := list.doSearch(key); node != nil {
+		return node.Value
+	}
+	if f, ok := value.(func() interface{}); ok {
+		value = f()
+	}
+	if value != nil {
+		list.doSet(key, value)
+	}
+	return value
+}
+
+// GetOrSet returns the value by key,
+// or sets value with given <value> if it does not exist and then returns this value.
+func (list *SkipList) GetOrSet(key interface{}, value interface{}) interface{} {
+	if v, ok := list.Search(key); !ok {
+		return list.doSetWithLockCheck(key, value)
+	} else {
+		return v
+	}
+}
+
+// GetOrSetFunc returns the value by key,
+// or sets value with returned value of callback function <f> if it does not exist
+// and then returns this value.
+func (list *SkipList) GetOrSetFunc(key interface{}, f func() interface{}) interface{} {
+	if v, ok := list.Search(key); !ok {
+		return list.doSetWithLockCheck(key, f())
+	} else {
+		return v
+	}
+}
+
+// GetOrSetFuncLock returns the value by key,
+// or sets value with returned value of callback function <f> if it does not exist
+// and then returns this value.
+//
+// GetOrSetFuncLock differs with GetOrSetFunc function is that it executes function <f>
+// with mutex.Lock of the skip list.
+func (list *SkipList) GetOrSetFuncLock(key interface{}, f func() interface{}) interface{} {
+	if v, ok := list.Search(key); !ok {
+		return list.doSetWithLockCheck(key, f)
+	} else {
+		return v
+	}
+}
+
+// GetVar returns a dvar.Var with the value by given <key>.
+// The returned dvar.Var is un-concurrent safe.
+func (list *SkipList) GetVar(key interface{}) *dvar.Var {
+	return dvar.New(list.Get(key))
+}
+
+// GetVarOrSet returns a dvar.Var with result from GetVarOrSet.
+// The returned dvar.Var is un-concurrent safe.
+func (list *SkipList) GetVarOrSet(key interface{}, value interface{}) *dvar.Var {
+	return dvar.New(list.GetOrSet(key, value))
+}
+
+// GetVarOrSetFunc returns a dvar.Var with result from GetOrSetFunc.
+// The returned dvar.Var is un-concurrent safe.
+func (list *SkipList) GetVarOrSetFunc(key interface{}, f func() interface{}) *dvar.Var {
+	return dvar.New(list.GetOrSetFunc(key, f))
+}
+
+// GetVarOrSetFuncLock returns a dvar.Var with result from GetOrSetFuncLock.
+// The returned dvar.Var is un-concurrent safe.
+func (list *SkipList) GetVarOrSetFuncLock(key interface{}, f func() interface{}) *dvar.Var {
+	return dvar.New(list.GetOrSetFuncLock(key, f))
+}
+
+// SetIfNotExist sets <value> to the skip list if the <key> does not exist, and then returns true.
+// It returns false if <key> exists, and <value> would be ignored.
+func (list *SkipList) SetIfNotExist(key interface{}, value interface{}) bool {
+	if !list.Contains(key) {
+		list.doSetWithLockCheck(key, value)
+		return true
+	}
+	return false
+}
+
+// SetIfNotExistFunc sets value with return value of callback function <f>, and then returns true.
+// It returns false if <key> exists, and <value> would be ignored.
+func (list *SkipList) SetIfNotExistFunc(key interface{}, f func() interface{}) bool {
+	if !list.Contains(key) {
+		list.doSetWithLockCheck(key, f())
+		return true
+	}
+	return false
+}
+
+// SetIfNotExistFuncLock sets value with return value of callback function <f>, and then returns true.
+// It returns false if <key> exists, and <value> would be ignored.
+//
+// SetIfNotExistFuncLock differs with SetIfNotExistFunc function is that
+// it executes function <f> with mutex.Lock of the skip list.
+func (list *SkipList) SetIfNotExistFuncLock(key interface{}, f func() interface{}) bool {
+	if !list.Contains(key) {
+		list.doSetWithLockCheck(key, f)
+		return true
+	}
+	return false
+}
+
+// Contains checks whether <key> exists in the skip list.
+func (list *SkipList) Contains(key interface{}) bool {
+	_, ok := list.Search(key)
+	return ok
+}
+
+// Search searches the skip list with given <key>.
+// Second return parameter <found> is true if key was found, otherwise false.
+func (list *SkipList) Search(key interface{}) (value interface{}, found bool) {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+	if node := list.doSearch(key); node != nil {
+		return node.Value, true
+	}
+	return nil, false
+}
+
+// doSearch searches the skip list with given <key> without mutex.
+// It returns the node if found or otherwise nil.
+func (list *SkipList) doSearch(key interface{}) *SkipListNode {
+	if node := list.doCeiling(key); node != nil && list.getComparator()(node.Key, key) == 0 {
+		return node
+	}
+	return nil
+}
+
+// doLower returns the last node whose key is smaller than <key>,
+// or the header if there's no such node.
+func (list *SkipList) doLower(key interface{}) *SkipListNode {
+	node := list.header
+	for i := list.level - 1; i >= 0; i-- {
+		for node.levels[i].forward != nil && list.getComparator()(node.levels[i].forward.Key, key) < 0 {
+			node = node.levels[i].forward
+		}
+	}
+	return node
+}
+
+// doCeiling returns the first node whose key is larger than or equal to <key>.
+func (list *SkipList) doCeiling(key interface{}) *SkipListNode {
+	return list.doLower(key).levels[0].forward
+}
+
+// doFloor returns the last node whose key is smaller than or equal to <key>.
+func (list *SkipList) doFloor(key interface{}) *SkipListNode {
+	node := list.doLower(key)
+	if next := node.levels[0].forward; next != nil && list.getComparator()(next.Key, key) == 0 {
+		return next
+	}
+	if node == list.header {
+		return nil
+	}
+	return node
+}
+
+// Remove removes the node from the skip list by <key>.
+// Key should adhere to the comparator's type assertion, otherwise method panics.
+func (list *SkipList) Remove(key interface{}) (value interface{}) {
+	list.mu.Lock()
+	defer list.mu.Unlock()
+	return list.doRemove(key)
+}
+
+// Removes batch deletes values of the skip list by <keys>.
+func (list *SkipList) Removes(keys []interface{}) {
+	list.mu.Lock()
+	defer list.mu.Unlock()
+	for _, key := range keys {
+		list.doRemove(key)
+	}
+}
+
+// doRemove removes the node from the skip list by <key> without mutex.
+func (list *SkipList) doRemove(key interface{}) (value interface{}) {
+	var (
+		update [skipListMaxLevel]*SkipListNode
+		node   = list.header
+	)
+	for i := list.level - 1; i >= 0; i-- {
+		for node.levels[i].forward != nil && list.getComparator()(node.levels[i].forward.Key, key) < 0 {
+			node = node.levels[i].forward
+		}
+		update[i] = node
+	}
+	node = node.levels[0].forward
+	if node == nil || list.getComparator()(node.Key, key) != 0 {
+		return nil
+	}
+	for i := 0; i < list.level; i++ {
+		if update[i].levels[i].forward == node {
+			update[i].levels[i].span += node.levels[i].span - 1
+			update[i].levels[i].forward = node.levels[i].forward
+		} else {
+			update[i].levels[i].span--
+		}
+	}
+	if next := node.levels[0].forward; next != nil {
+		next.backward = node.backward
+	} else {
+		list.tail = node.backward
+	}
+	for list.level > 1 && list.header.levels[list.level-1].forward == nil {
+		list.level--
+	}
+	list.size--
+	return node.Value
+}
+
+// IsEmpty returns true if skip list does not contain any nodes.
+func (list *SkipList) IsEmpty() bool {
+	return list.Size() == 0
+}
+
+// Size returns number of nodes in the skip list.
+func (list *SkipList) Size() int {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+	return list.size
+}
+
+// Keys returns all keys in asc order.
+func (list *SkipList) Keys() []interface{} {
+	keys := make([]interface{}, 0, list.Size())
+	list.IteratorAsc(func(key, value interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Values returns all values in asc order based on the key.
+func (list *SkipList) Values() []interface{} {
+	values := make([]interface{}, 0, list.Size())
+	list.IteratorAsc(func(key, value interface{}) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+// Map returns all key-value items as map.
+func (list *SkipList) Map() map[interface{}]interface{} {
+	m := make(map[interface{}]interface{}, list.Size())
+	list.IteratorAsc(func(key, value interface{}) bool {
+		m[key] = value
+		return true
+	})
+	return m
+}
+
+// MapStrAny returns all key-value items as map[string]interface{}.
+func (list *SkipList) MapStrAny() map[string]interface{} {
+	m := make(map[string]interface{}, list.Size())
+	list.IteratorAsc(func(key, value interface{}) bool {
+		m[dconv.String(key)] = value
+		return true
+	})
+	return m
+}
+
+// Clear removes all nodes from the skip list.
+func (list *SkipList) Clear() {
+	list.mu.Lock()
+	defer list.mu.Unlock()
+	list.doClear()
+}
+
+func (list *SkipList) doClear() {
+	list.header = newSkipListNode(skipListMaxLevel, nil, nil)
+	list.tail = nil
+	list.size = 0
+	list.level = 1
+}
+
+// Replace the data of the skip list with given <data>.
+func (list *SkipList) Replace(data map[interface{}]interface{}) {
+	list.mu.Lock()
+	defer list.mu.Unlock()
+	list.doClear()
+	for k, v := range data {
+		list.doSet(k, v)
+	}
+}
+
+// Left returns the minimum element of the skip list
+// or nil if the skip list is empty.
+func (list *SkipList) Left() *SkipListNode {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+	return list.export(list.header.levels[0].forward)
+}
+
+// Right returns the maximum element of the skip list
+// or nil if the skip list is empty.
+func (list *SkipList) Right() *SkipListNode {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+	return list.export(list.tail)
+}
+
+// Floor finds floor node of the input key, return the floor node or nil if no floor node is found.
+// Second return parameter is true if floor was found, otherwise false.
+//
+// Floor node is defined as the largest node that is smaller than or equal to the given node.
+func (list *SkipList) Floor(key interface{}) (floor *SkipListNode, found bool) {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+	if node := list.doFloor(key); node != nil {
+		return list.export(node), true
+	}
+	return nil, false
+}
+
+// Ceiling finds ceiling node of the input key, return the ceiling node or nil if no ceiling node is found.
+// Second return parameter is true if ceiling was found, otherwise false.
+//
+// Ceiling node is defined as the smallest node that is larger than or equal to the given node.
+func (list *SkipList) Ceiling(key interface{}) (ceiling *SkipListNode, found bool) {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+	if node := list.doCeiling(key); node != nil {
+		return list.export(node), true
+	}
+	return nil, false
+}
+
+// export returns a copy of <node> if the skip list is concurrent-safe,
+// so that the caller could not touch the internal links without mutex.
+func (list *SkipList) export(node *SkipListNode) *SkipListNode {
+	if node == nil || !list.mu.IsSafe() {
+		return node
+	}
+	return &SkipListNode{
+		Key:   node.Key,
+		Value: node.Value,
+	}
+}
+
+// Rank returns the 0-based position of <key> in ascending order,
+// or -1 if <key> is not found.
+func (list *SkipList) Rank(key interface{}) int {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+	rank := 0
+	node := list.header
+	for i := list.level - 1; i >= 0; i-- {
+		for node.levels[i].forward != nil && list.getComparator()(node.levels[i].forward.Key, key) <= 0 {
+			rank += node.levels[i].span
+			node = node.levels[i].forward
+		}
+		if node != list.header && list.getComparator()(node.Key, key) == 0 {
+			return rank - 1
+		}
+	}
+	return -1
+}
+
+// GetByRank returns the node at the 0-based position <rank> in ascending order,
+// or nil if <rank> is out of range.
+func (list *SkipList) GetByRank(rank int) *SkipListNode {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+	return list.export(list.doGetByRank(rank))
+}
+
+func (list *SkipList) doGetByRank(rank int) *SkipListNode {
+	if rank < 0 || rank >= list.size {
+		return nil
+	}
+	target := rank + 1
+	traversed := 0
+	node := list.header
+	for i := list.level - 1; i >= 0; i-- {
+		for node.levels[i].forward != nil && traversed+node.levels[i].span <= target {
+			traversed += node.levels[i].span
+			node = node.levels[i].forward
+		}
+		if traversed == target {
+			return node
+		}
+	}
+	return nil
+}
+
+// IteratorRange iterates the skip list readonly in ascending order with given callback function <f>,
+// only the items whose keys are between <min> and <max> (both inclusive) are iterated.
+// If <f> returns true, then it continues iterating; or false to stop.
+func (list *SkipList) IteratorRange(min, max interface{}, f func(key, value interface{}) bool) {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+	for node := list.doCeiling(min); node != nil && list.getComparator()(node.Key, max) <= 0; node = node.levels[0].forward {
+		if !f(node.Key, node.Value) {
+			return
+		}
+	}
+}
+
+// IteratorRangeDesc iterates the skip list readonly in descending order with given callback function <f>,
+// only the items whose keys are between <min> and <max> (both inclusive) are iterated.
+// If <f> returns true, then it continues iterating; or false to stop.
+func (list *SkipList) IteratorRangeDesc(min, max interface{}, f func(key, value interface{}) bool) {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+	for node := list.doFloor(max); node != nil && list.getComparator()(node.Key, min) >= 0; node = node.backward {
+		if !f(node.Key, node.Value) {
+			return
+		}
+	}
+}
+
+// IteratorRank iterates the skip list readonly in ascending order with given callback function <f>,
+// starting from the 0-based position <start> to <end> (both inclusive).
+// If <f> returns true, then it continues iterating; or false to stop.
+func (list *SkipList) IteratorRank(start, end int, f func(key, value interface{}) bool) {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+	if start < 0 {
+		start = 0
+	}
+	node := list.doGetByRank(start)
+	for i := start; node != nil && i <= end; i++ {
+		if !f(node.Key, node.Value) {
+			return
+		}
+		node = node.levels[0].forward
+	}
+}
+
+// String returns a string representation of container.
+func (list *SkipList) String() string {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+	var buffer bytes.Buffer
+	for node := list.header.levels[0].forward; node != nil; node = node.levels[0].forward {
+		buffer.WriteString(fmt.Sprintf("%v: %v\n", node.Key, node.Value))
+	}
+	return buffer.String()
+}
+
+// Print prints the skip list to stdout.
+func (list *SkipList) Print() {
+	fmt.Println(list.String())
+}
+
+// Iterator is alias of IteratorAsc.
+func (list *SkipList) Iterator(f func(key, value interface{}) bool) {
+	list.IteratorAsc(f)
+}
+
+// IteratorFrom is alias of IteratorAscFrom.
+func (list *SkipList) IteratorFrom(key interface{}, match bool, f func(key, value interface{}) bool) {
+	list.IteratorAscFrom(key, match, f)
+}
+
+// IteratorAsc iterates the skip list readonly in ascending order with given callback function <f>.
+// If <f> returns true, then it continues iterating; or false to stop.
+func (list *SkipList) IteratorAsc(f func(key, value interface{}) bool) {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+	list.doIteratorAsc(list.header.levels[0].forward, f)
+}
+
+// IteratorAscFrom iterates the skip list readonly in ascending order with given callback function <f>.
+// The parameter <key> specifies the start entry for iterating. The <match> specifies whether
+// starting iterating if the <key> is fully matched, or else starting from the ceiling node of <key>.
+// If <f> returns true, then it continues iterating; or false to stop.
+func (list *SkipList) IteratorAscFrom(key interface{}, match bool, f func(key, value interface{}) bool) {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+	node := list.doCeiling(key)
+	if match && (node == nil || list.getComparator()(node.Key, key) != 0) {
+		return
+	}
+	list.doIteratorAsc(node, f)
+}
+
+func (list *SkipList) doIteratorAsc(node *SkipListNode, f func(key, value interface{}) bool) {
+	for ; node != nil; node = node.levels[0].forward {
+		if !f(node.Key, node.Value) {
+			return
+		}
+	}
+}
+
+// IteratorDesc iterates the skip list readonly in descending order with given callback function <f>.
+// If <f> returns true, then it continues iterating; or false to stop.
+func (list *SkipList) IteratorDesc(f func(key, value interface{}) bool) {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+	list.doIteratorDesc(list.tail, f)
+}
+
+// IteratorDescFrom iterates the skip list readonly in descending order with given callback function <f>.
+// The parameter <key> specifies the start entry for iterating. The <match> specifies whether
+// starting iterating if the <key> is fully matched, or else starting from the floor node of <key>.
+// If <f> returns true, then it continues iterating; or false to stop.
+func (list *SkipList) IteratorDescFrom(key interface{}, match bool, f func(key, value interface{}) bool) {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+	node := list.doFloor(key)
+	if match && (node == nil || list.getComparator()(node.Key, key) != 0) {
+		return
+	}
+	list.doIteratorDesc(node, f)
+}
+
+func (list *SkipList) doIteratorDesc(node *SkipListNode, f func(key, value interface{}) bool) {
+	for ; node != nil; node = node.backward {
+		if !f(node.Key, node.Value) {
+			return
+		}
+	}
+}
+
+// MarshalJSON implements the interface MarshalJSON for json.Marshal.
+func (list *SkipList) MarshalJSON() ([]byte, error) {
+	return json.Marshal(dconv.Map(list.Map()))
+}
+
+// getComparator returns the comparator if it's previously set,
+// or else it panics.
+func (list *SkipList) getComparator() func(a, b interface{}) int {
+	if list.comparator == nil {
+		panic("comparator is missing for skip list")
+	}
+	return list.comparator
+}
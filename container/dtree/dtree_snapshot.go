@@ -0,0 +1,260 @@
+package dtree
+
+import (
+	"errors"
+	"github.com/osgochina/donkeygo/container/dsnapshot"
+	"io"
+)
+
+// snapshotBatchSize is the number of entries the incremental snapshot of RedBlackTree
+// reads from the tree each time it holds the read lock.
+const snapshotBatchSize = 1024
+
+// redBlackTreeCow is the copy-on-write state of a RedBlackTree while a snapshot is in progress.
+//
+// The snapshot walks the tree in ascending order in batches and remembers the last emitted key
+// as <cursor>. Before a writer changes a key after the cursor for the first time, the original
+// state of the key is saved into <saved>, so the snapshot still sees the data of the moment it started.
+type redBlackTreeCow struct {
+	saved     *RedBlackTree // Key -> *redBlackTreeCowEntry, unsafe, guarded by the tree mutex.
+	cursor    interface{}
+	hasCursor bool
+	frozen    bool // All remaining original entries have been saved, the live tree is no longer walked.
+}
+
+// redBlackTreeCowEntry is the original state of a key when the snapshot started.
+type redBlackTreeCowEntry struct {
+	value   interface{}
+	existed bool
+	emitted bool
+}
+
+// cowSave saves the original state of <key> before it's changed by a writer.
+// It must be called with the write lock held.
+func (tree *RedBlackTree) cowSave(key interface{}) {
+	cow := tree.cow
+	if cow.frozen || (cow.hasCursor && tree.getComparator()(key, cow.cursor) <= 0) {
+		return
+	}
+	if _, found := cow.saved.doSearch(key); found {
+		return
+	}
+	entry := &redBlackTreeCowEntry{}
+	if node, found := tree.doSearch(key); found {
+		entry.value, entry.existed = node.Value, true
+	}
+	cow.saved.doSet(key, entry)
+}
+
+// cowFreeze saves all the original entries that are not emitted yet,
+// it's called before the whole tree is replaced. It must be called with the write lock held.
+func (tree *RedBlackTree) cowFreeze() {
+	cow := tree.cow
+	if cow == nil || cow.frozen {
+		return
+	}
+	node := tree.leftNode()
+	if cow.hasCursor {
+		node = tree.doHigher(cow.cursor)
+	}
+	tree.doIteratorAsc(node, func(key, value interface{}) bool {
+		if _, found := cow.saved.doSearch(key); !found {
+			cow.saved.doSet(key, &redBlackTreeCowEntry{value: value, existed: true})
+		}
+		return true
+	})
+	cow.frozen = true
+}
+
+// doHigher returns the smallest node whose key is larger than <key>, or nil if there's no such node.
+func (tree *RedBlackTree) doHigher(key interface{}) (higher *RedBlackTreeNode) {
+	node := tree.root
+	for node != nil {
+		if tree.getComparator()(key, node.Key) < 0 {
+			higher = node
+			node = node.left
+		} else {
+			node = node.right
+		}
+	}
+	return
+}
+
+// Snapshot writes all the key-value items of the tree to <w> in the binary format of dsnapshot.
+// The parameter <option> specifies the codecs of the keys and values.
+//
+// The snapshot is incremental and copy-on-write: it only holds the read lock while reading
+// a small batch of entries, writers continue between the batches, and the first change of every
+// entry that's not written yet copies its original value aside. So the snapshot is consistent with
+// the moment it started without blocking writers or copying the whole tree.
+// Only one snapshot of the same tree could be in progress at the same time.
+func (tree *RedBlackTree) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	sw, err := dsnapshot.NewWriter(w, dsnapshot.KindMap, option...)
+	if err != nil {
+		return err
+	}
+	tree.mu.Lock()
+	if tree.cow != nil {
+		tree.mu.Unlock()
+		return errors.New("another snapshot of the tree is in progress")
+	}
+	cow := &redBlackTreeCow{saved: NewRedBlackTree(tree.getComparator())}
+	tree.cow = cow
+	tree.mu.Unlock()
+
+	var (
+		keys   = make([]interface{}, 0, snapshotBatchSize)
+		values = make([]interface{}, 0, snapshotBatchSize)
+		end    = false
+	)
+	for !end {
+		keys, values = keys[:0], values[:0]
+		tree.mu.RLock()
+		// Only this goroutine changes the cursor and the emitted marks, and writers access them
+		// with the write lock, so the read lock is enough here.
+		if cow.frozen {
+			end = true
+		} else {
+			node := tree.leftNode()
+			if cow.hasCursor {
+				node = tree.doHigher(cow.cursor)
+			}
+			end = true
+			tree.doIteratorAsc(node, func(key, value interface{}) bool {
+				if len(keys) == snapshotBatchSize {
+					end = false
+					return false
+				}
+				if saved, found := cow.saved.doSearch(key); found {
+					entry := saved.Value.(*redBlackTreeCowEntry)
+					entry.emitted = true
+					if entry.existed {
+						keys, values = append(keys, key), append(values, entry.value)
+					}
+				} else {
+					keys, values = append(keys, key), append(values, value)
+				}
+				cow.cursor, cow.hasCursor = key, true
+				return true
+			})
+		}
+		tree.mu.RUnlock()
+		for i, key := range keys {
+			if err == nil {
+				err = sw.WriteEntry(key, values[i])
+			}
+		}
+	}
+
+	// The entries that existed when the snapshot started but were removed before the walk reached them.
+	keys, values = keys[:0], values[:0]
+	tree.mu.Lock()
+	tree.cow = nil
+	tree.mu.Unlock()
+	cow.saved.doIteratorAsc(cow.saved.leftNode(), func(key, value interface{}) bool {
+		if entry := value.(*redBlackTreeCowEntry); entry.existed && !entry.emitted {
+			keys, values = append(keys, key), append(values, entry.value)
+		}
+		return true
+	})
+	for i, key := range keys {
+		if err == nil {
+			err = sw.WriteEntry(key, values[i])
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return sw.Close()
+}
+
+// Restore replaces the data of the tree with the snapshot read from <r>, the keys should adhere to the comparator.
+func (tree *RedBlackTree) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	newTree := NewRedBlackTree(tree.getComparator())
+	if err := dsnapshot.ReadMap(r, newTree.doSet, option...); err != nil {
+		return err
+	}
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+	if tree.cow != nil {
+		tree.cowFreeze()
+	}
+	tree.root = newTree.root
+	tree.size = newTree.size
+	return nil
+}
+
+// snapshotEntries copies the key-value items of a tree with <iterator> and writes them to <w>
+// in the binary format of dsnapshot, <option> specifies the codecs of the keys and values.
+// The items are copied with the read lock first, so writers are only blocked while copying.
+func snapshotEntries(w io.Writer, iterator func(f func(key, value interface{}) bool), option []dsnapshot.Option) error {
+	var keys, values []interface{}
+	iterator(func(key, value interface{}) bool {
+		keys, values = append(keys, key), append(values, value)
+		return true
+	})
+	return dsnapshot.WriteMap(w, func(f func(key, value interface{}) bool) {
+		for i, key := range keys {
+			if !f(key, values[i]) {
+				return
+			}
+		}
+	}, option...)
+}
+
+// Snapshot writes all the key-value items of the tree to <w>.
+func (tree *AVLTree) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	return snapshotEntries(w, tree.IteratorAsc, option)
+}
+
+// Restore replaces the data of the tree with the snapshot read from <r>, the keys should adhere to the comparator.
+func (tree *AVLTree) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	newTree := NewAVLTree(tree.getComparator())
+	err := dsnapshot.ReadMap(r, func(key, value interface{}) {
+		newTree.put(key, value, nil, &newTree.root)
+	}, option...)
+	if err != nil {
+		return err
+	}
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+	tree.root = newTree.root
+	tree.size = newTree.size
+	return nil
+}
+
+// Snapshot writes all the key-value items of the tree to <w>.
+func (tree *BTree) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	return snapshotEntries(w, tree.IteratorAsc, option)
+}
+
+// Restore replaces the data of the tree with the snapshot read from <r>, the keys should adhere to the comparator.
+func (tree *BTree) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	newTree := NewBTree(tree.m, tree.getComparator())
+	if err := dsnapshot.ReadMap(r, newTree.doSet, option...); err != nil {
+		return err
+	}
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+	tree.root = newTree.root
+	tree.size = newTree.size
+	return nil
+}
+
+// Snapshot writes all the key-value items of the skip list to <w>.
+func (list *SkipList) Snapshot(w io.Writer, option ...dsnapshot.Option) error {
+	return snapshotEntries(w, list.IteratorAsc, option)
+}
+
+// Restore replaces the data of the skip list with the snapshot read from <r>, the keys should adhere to the comparator.
+func (list *SkipList) Restore(r io.Reader, option ...dsnapshot.Option) error {
+	newList := NewSkipList(list.getComparator())
+	if err := dsnapshot.ReadMap(r, newList.doSet, option...); err != nil {
+		return err
+	}
+	list.mu.Lock()
+	defer list.mu.Unlock()
+	list.header, list.tail = newList.header, newList.tail
+	list.size, list.level = newList.size, newList.level
+	return nil
+}
@@ -0,0 +1,106 @@
+package dtree_test
+
+import (
+	"github.com/osgochina/donkeygo/container/dtree"
+	"github.com/osgochina/donkeygo/internal/json"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"github.com/osgochina/donkeygo/util/drand"
+	"github.com/osgochina/donkeygo/util/dutil"
+	"testing"
+	"time"
+)
+
+func Test_IntervalTree_Basic(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		tree := dtree.NewIntervalTree(dutil.ComparatorInt)
+		tree.Set(10, 20, "a")
+		tree.Set(15, 25, "b")
+		tree.Set(30, 40, "c")
+		tree.Set(5, 8, "d")
+		tree.Set(20, 10, "aa")
+		t.Assert(tree.Size(), 4)
+		t.Assert(tree.Get(10, 20), "aa")
+		t.Assert(tree.Contains(15, 25), true)
+		t.Assert(tree.Contains(15, 26), false)
+
+		starts := func(nodes []*dtree.IntervalTreeNode) []interface{} {
+			s := make([]interface{}, 0, len(nodes))
+			for _, n := range nodes {
+				s = append(s, n.Start)
+			}
+			return s
+		}
+		t.Assert(starts(tree.Overlaps(18, 22)), []interface{}{10, 15})
+		t.Assert(starts(tree.Overlaps(25, 30)), []interface{}{15, 30})
+		t.Assert(starts(tree.Overlaps(41, 50)), []interface{}{})
+		t.Assert(starts(tree.Stab(8)), []interface{}{5})
+		t.Assert(starts(tree.Stab(9)), []interface{}{})
+		t.Assert(tree.Overlaps(0, 100)[0].Value, "d")
+
+		count := 0
+		tree.IteratorOverlaps(0, 100, func(start, end, value interface{}) bool {
+			count++
+			return count < 2
+		})
+		t.Assert(count, 2)
+
+		t.Assert(tree.Remove(15, 25), "b")
+		t.Assert(tree.Remove(15, 25), nil)
+		t.Assert(starts(tree.Overlaps(18, 22)), []interface{}{10})
+
+		b, err := json.Marshal(tree)
+		t.AssertNil(err)
+		t.Assert(string(b), `[{"end":8,"start":5,"value":"d"},{"end":20,"start":10,"value":"aa"},{"end":40,"start":30,"value":"c"}]`)
+
+		clone := tree.Clone()
+		tree.Clear()
+		t.Assert(tree.IsEmpty(), true)
+		t.Assert(clone.Size(), 3)
+	})
+}
+
+func Test_IntervalTree_Time(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		tree := dtree.NewIntervalTree(dutil.ComparatorTime, true)
+		base := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		tree.Set(base, base.Add(time.Hour), "meeting")
+		tree.Set(base.Add(2*time.Hour), base.Add(3*time.Hour), "lunch")
+		nodes := tree.Overlaps(base.Add(30*time.Minute), base.Add(90*time.Minute))
+		t.Assert(len(nodes), 1)
+		t.Assert(nodes[0].Value, "meeting")
+		t.Assert(len(tree.Stab(base.Add(150*time.Minute))), 1)
+	})
+}
+
+func Test_IntervalTree_Random(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		type interval struct{ start, end int }
+		tree := dtree.NewIntervalTree(dutil.ComparatorInt)
+		intervals := make(map[interval]bool)
+		for i := 0; i < 1000; i++ {
+			start := drand.Intn(10000)
+			iv := interval{start, start + drand.Intn(100)}
+			intervals[iv] = true
+			tree.Set(iv.start, iv.end, i)
+		}
+		for iv := range intervals {
+			if drand.Intn(2) == 0 {
+				tree.Remove(iv.start, iv.end)
+				delete(intervals, iv)
+			}
+		}
+		t.Assert(tree.Size(), len(intervals))
+		t.AssertLE(tree.Height(), 15)
+		for i := 0; i < 100; i++ {
+			start := drand.Intn(10000)
+			end := start + drand.Intn(200)
+			expect := 0
+			for iv := range intervals {
+				if iv.start <= end && iv.end >= start {
+					expect++
+				}
+			}
+			t.Assert(len(tree.Overlaps(start, end)), expect)
+		}
+	})
+}
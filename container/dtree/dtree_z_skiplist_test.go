@@ -0,0 +1,203 @@
+package dtree_test
+
+import (
+	"github.com/osgochina/donkeygo/container/dtree"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"github.com/osgochina/donkeygo/util/drand"
+	"github.com/osgochina/donkeygo/util/dutil"
+	"sync"
+	"testing"
+)
+
+func Test_SkipList_Basic(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		m := dtree.NewSkipList(dutil.ComparatorString)
+		m.Set("key1", "val1")
+		t.Assert(m.Keys(), []interface{}{"key1"})
+		t.Assert(m.Get("key1"), "val1")
+		t.Assert(m.Size(), 1)
+		t.Assert(m.IsEmpty(), false)
+
+		t.Assert(m.GetOrSet("key2", "val2"), "val2")
+		t.Assert(m.SetIfNotExist("key2", "val2"), false)
+		t.Assert(m.SetIfNotExist("key3", "val3"), true)
+		t.Assert(m.GetOrSetFunc("key4", getValue), 3)
+		t.Assert(m.GetOrSetFuncLock("key4", getValue), 3)
+		t.Assert(m.SetIfNotExistFunc("key5", getValue), true)
+		t.Assert(m.SetIfNotExistFuncLock("key5", getValue), false)
+		t.Assert(m.GetVar("key5").Int(), 3)
+
+		t.Assert(m.Remove("key2"), "val2")
+		t.Assert(m.Remove("key2"), nil)
+		t.Assert(m.Contains("key2"), false)
+		t.Assert(m.Keys(), []interface{}{"key1", "key3", "key4", "key5"})
+		t.Assert(m.Values(), []interface{}{"val1", "val3", 3, 3})
+
+		m.Set("key1", "val11")
+		t.Assert(m.Get("key1"), "val11")
+		t.Assert(m.Size(), 4)
+
+		m2 := m.Clone()
+		t.Assert(m2.Map(), m.Map())
+
+		m.Clear()
+		t.Assert(m.Size(), 0)
+		t.Assert(m.IsEmpty(), true)
+		t.Assert(m.Left(), nil)
+		t.Assert(m.Right(), nil)
+
+		m3 := dtree.NewSkipListFrom(dutil.ComparatorString, map[interface{}]interface{}{"b": 2, "a": 1})
+		t.Assert(m3.Keys(), []interface{}{"a", "b"})
+		m3.Replace(map[interface{}]interface{}{"c": 3})
+		t.Assert(m3.Map(), map[interface{}]interface{}{"c": 3})
+		t.Assert(m3.MapStrAny(), map[string]interface{}{"c": 3})
+	})
+}
+
+func Test_SkipList_Iterator(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		m := dtree.NewSkipList(dutil.ComparatorInt)
+		for _, k := range []int{5, 1, 9, 3, 7} {
+			m.Set(k, k*10)
+		}
+		keys := make([]interface{}, 0)
+		m.Iterator(func(key, value interface{}) bool {
+			keys = append(keys, key)
+			return true
+		})
+		t.Assert(keys, []interface{}{1, 3, 5, 7, 9})
+
+		keys = keys[:0]
+		m.IteratorDesc(func(key, value interface{}) bool {
+			keys = append(keys, key)
+			return len(keys) < 3
+		})
+		t.Assert(keys, []interface{}{9, 7, 5})
+
+		keys = keys[:0]
+		m.IteratorFrom(4, true, func(key, value interface{}) bool {
+			keys = append(keys, key)
+			return true
+		})
+		t.Assert(len(keys), 0)
+
+		m.IteratorFrom(4, false, func(key, value interface{}) bool {
+			keys = append(keys, key)
+			return true
+		})
+		t.Assert(keys, []interface{}{5, 7, 9})
+
+		keys = keys[:0]
+		m.IteratorDescFrom(4, false, func(key, value interface{}) bool {
+			keys = append(keys, key)
+			return true
+		})
+		t.Assert(keys, []interface{}{3, 1})
+
+		keys = keys[:0]
+		m.IteratorDescFrom(7, true, func(key, value interface{}) bool {
+			keys = append(keys, key)
+			return true
+		})
+		t.Assert(keys, []interface{}{7, 5, 3, 1})
+
+		t.Assert(m.Left().Key, 1)
+		t.Assert(m.Right().Key, 9)
+		floor, found := m.Floor(6)
+		t.Assert(found, true)
+		t.Assert(floor.Key, 5)
+		_, found = m.Floor(0)
+		t.Assert(found, false)
+		ceiling, found := m.Ceiling(6)
+		t.Assert(found, true)
+		t.Assert(ceiling.Value, 70)
+		_, found = m.Ceiling(10)
+		t.Assert(found, false)
+	})
+}
+
+func Test_SkipList_Rank(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		m := dtree.NewSkipList(dutil.ComparatorInt)
+		for _, k := range []int{50, 10, 40, 20, 30} {
+			m.Set(k, k)
+		}
+		t.Assert(m.Rank(10), 0)
+		t.Assert(m.Rank(30), 2)
+		t.Assert(m.Rank(50), 4)
+		t.Assert(m.Rank(35), -1)
+		t.Assert(m.GetByRank(0).Key, 10)
+		t.Assert(m.GetByRank(3).Key, 40)
+		t.Assert(m.GetByRank(5), nil)
+		t.Assert(m.GetByRank(-1), nil)
+
+		m.Remove(20)
+		t.Assert(m.Rank(30), 1)
+		t.Assert(m.GetByRank(1).Key, 30)
+
+		keys := make([]interface{}, 0)
+		m.IteratorRange(15, 45, func(key, value interface{}) bool {
+			keys = append(keys, key)
+			return true
+		})
+		t.Assert(keys, []interface{}{30, 40})
+
+		keys = keys[:0]
+		m.IteratorRangeDesc(10, 40, func(key, value interface{}) bool {
+			keys = append(keys, key)
+			return true
+		})
+		t.Assert(keys, []interface{}{40, 30, 10})
+
+		keys = keys[:0]
+		m.IteratorRank(1, 2, func(key, value interface{}) bool {
+			keys = append(keys, key)
+			return true
+		})
+		t.Assert(keys, []interface{}{30, 40})
+	})
+}
+
+func Test_SkipList_RandomRank(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		m := dtree.NewSkipList(dutil.ComparatorInt)
+		for _, k := range drand.Perm(2000) {
+			m.Set(k, k)
+		}
+		for _, k := range drand.Perm(2000)[:1000] {
+			m.Remove(k)
+		}
+		t.Assert(m.Size(), 1000)
+		rank := 0
+		m.Iterator(func(key, value interface{}) bool {
+			if m.Rank(key) != rank || m.GetByRank(rank).Key != key {
+				t.Error(key, rank)
+				return false
+			}
+			rank++
+			return true
+		})
+		t.Assert(rank, 1000)
+	})
+}
+
+func Test_SkipList_Concurrent(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		m := dtree.NewSkipList(dutil.ComparatorInt, true)
+		wg := sync.WaitGroup{}
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 250; i++ {
+					m.Set(g*250+i, i)
+					m.Rank(i)
+				}
+			}(g)
+		}
+		wg.Wait()
+		t.Assert(m.Size(), 1000)
+		t.Assert(m.Rank(999), 999)
+		t.Assert(m.GetByRank(500).Key, 500)
+	})
+}
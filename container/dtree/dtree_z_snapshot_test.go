@@ -0,0 +1,197 @@
+package dtree_test
+
+import (
+	"bytes"
+	"github.com/osgochina/donkeygo/container/dtree"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"github.com/osgochina/donkeygo/util/dutil"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// hookWriter calls hook before every write, so the tree can be changed while the snapshot is in progress.
+type hookWriter struct {
+	bytes.Buffer
+	hook func()
+}
+
+func (w *hookWriter) Write(p []byte) (int, error) {
+	w.hook()
+	return w.Buffer.Write(p)
+}
+
+func Test_RedBlackTree_Snapshot(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		tree := dtree.NewRedBlackTree(dutil.ComparatorInt, true)
+		for i := 0; i < 10000; i++ {
+			tree.Set(i, i*10)
+		}
+		var buf bytes.Buffer
+		t.AssertNil(tree.Snapshot(&buf))
+
+		restored := dtree.NewRedBlackTree(dutil.ComparatorInt, true)
+		restored.Set(-1, -1)
+		t.AssertNil(restored.Restore(bytes.NewReader(buf.Bytes())))
+		t.Assert(restored.Size(), 10000)
+		t.Assert(restored.Get(-1), nil)
+		t.Assert(restored.Get(9999), 99990)
+		t.Assert(restored.Left().Key, 0)
+
+		// A corrupted snapshot does not change the tree.
+		t.AssertNE(restored.Restore(bytes.NewReader(buf.Bytes()[:buf.Len()-1])), nil)
+		t.Assert(restored.Size(), 10000)
+	})
+}
+
+func Test_RedBlackTree_Snapshot_CopyOnWrite(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		tree := dtree.NewRedBlackTree(dutil.ComparatorInt, true)
+		for i := 0; i < 10000; i++ {
+			tree.Set(i, i)
+		}
+		writes := 0
+		w := &hookWriter{}
+		w.hook = func() {
+			writes++
+			if writes != 1 {
+				return
+			}
+			// The snapshot has only walked the first batch.
+			for i := 0; i < 10000; i += 2 {
+				tree.Set(i, -i)
+			}
+			for i := 1; i < 10000; i += 4 {
+				tree.Remove(i)
+			}
+			for i := 10000; i < 11000; i++ {
+				tree.Set(i, i)
+			}
+			t.AssertNE(tree.Snapshot(&bytes.Buffer{}), nil)
+		}
+		t.AssertNil(tree.Snapshot(w))
+		t.AssertGT(writes, 1)
+		t.Assert(tree.Get(2), -2)
+		t.Assert(tree.Contains(5), false)
+		t.Assert(tree.Size(), 8500)
+
+		restored := dtree.NewRedBlackTree(dutil.ComparatorInt)
+		t.AssertNil(restored.Restore(bytes.NewReader(w.Bytes())))
+		t.Assert(restored.Size(), 10000)
+		for i := 0; i < 10000; i++ {
+			if restored.Get(i) != i {
+				t.Fatal("unexpected value of key", i, restored.Get(i))
+			}
+		}
+
+		// The cow state is released, another snapshot sees the latest data.
+		var buf bytes.Buffer
+		t.AssertNil(tree.Snapshot(&buf))
+		t.AssertNil(restored.Restore(&buf))
+		t.Assert(restored.Size(), 8500)
+		t.Assert(restored.Get(10500), 10500)
+	})
+}
+
+func Test_RedBlackTree_Snapshot_Clear(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		tree := dtree.NewRedBlackTree(dutil.ComparatorInt, true)
+		for i := 0; i < 5000; i++ {
+			tree.Set(i, i)
+		}
+		cleared := false
+		w := &hookWriter{}
+		w.hook = func() {
+			if !cleared {
+				cleared = true
+				tree.Clear()
+				tree.Set(1, "new")
+			}
+		}
+		t.AssertNil(tree.Snapshot(w))
+		t.Assert(tree.Size(), 1)
+
+		restored := dtree.NewRedBlackTree(dutil.ComparatorInt)
+		t.AssertNil(restored.Restore(bytes.NewReader(w.Bytes())))
+		t.Assert(restored.Size(), 5000)
+		t.Assert(restored.Get(1), 1)
+		t.Assert(restored.Get(4999), 4999)
+	})
+}
+
+func Test_RedBlackTree_Snapshot_Concurrent(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		tree := dtree.NewRedBlackTree(dutil.ComparatorInt, true)
+		for i := 0; i < 5000; i++ {
+			tree.Set(i, i)
+		}
+		var (
+			wg      sync.WaitGroup
+			stop    = make(chan struct{})
+			started = false
+			w       = &hookWriter{}
+		)
+		// The writer starts after the snapshot began, and keeps changing the tree until it finished.
+		w.hook = func() {
+			if started {
+				return
+			}
+			started = true
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; ; i++ {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					tree.Set(i%6000, -i)
+					tree.Remove((i * 7) % 5000)
+					runtime.Gosched()
+				}
+			}()
+		}
+		err := tree.Snapshot(w)
+		close(stop)
+		wg.Wait()
+		t.AssertNil(err)
+
+		restored := dtree.NewRedBlackTree(dutil.ComparatorInt)
+		t.AssertNil(restored.Restore(&w.Buffer))
+		t.Assert(restored.Size(), 5000)
+		t.Assert(restored.Get(4321), 4321)
+	})
+}
+
+func Test_Trees_Snapshot(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		avl := dtree.NewAVLTree(dutil.ComparatorString, true)
+		avl.Sets(map[interface{}]interface{}{"a": 1, "b": 2, "c": 3})
+		var buf bytes.Buffer
+		t.AssertNil(avl.Snapshot(&buf))
+		avl2 := dtree.NewAVLTree(dutil.ComparatorString)
+		t.AssertNil(avl2.Restore(&buf))
+		t.Assert(avl2.Map(), avl.Map())
+
+		btree := dtree.NewBTree(3, dutil.ComparatorInt)
+		list := dtree.NewSkipList(dutil.ComparatorInt)
+		for i := 0; i < 100; i++ {
+			btree.Set(i, i)
+			list.Set(i, i)
+		}
+		buf.Reset()
+		t.AssertNil(btree.Snapshot(&buf))
+		btree2 := dtree.NewBTree(3, dutil.ComparatorInt)
+		t.AssertNil(btree2.Restore(&buf))
+		t.Assert(btree2.Size(), 100)
+		t.Assert(btree2.Right().Key, 99)
+
+		buf.Reset()
+		t.AssertNil(list.Snapshot(&buf))
+		list2 := dtree.NewSkipList(dutil.ComparatorInt)
+		t.AssertNil(list2.Restore(&buf))
+		t.Assert(list2.Size(), 100)
+		t.Assert(list2.Rank(50), 50)
+	})
+}
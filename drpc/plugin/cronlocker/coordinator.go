@@ -0,0 +1,150 @@
+// Package cronlocker 通过drpc调用协调者实现dcron的分布式锁
+package cronlocker
+
+import (
+	"github.com/osgochina/donkeygo/drpc"
+	"sync"
+	"time"
+)
+
+const (
+	// 协调者服务的路由前缀
+	lockerServicePrefix = "/cronlocker"
+	// 协调者对象记录在会话的数据key
+	coordinatorSwapKey = "cronLockerCoordinatorSwapKey"
+)
+
+// Coordinator 分布式锁的协调者插件，在内存中维护所有租约
+type Coordinator interface {
+	// Name 插件的名字
+	Name() string
+	// AfterNewEndpoint 注册租约相关的CALL处理程序
+	AfterNewEndpoint(endpoint drpc.EarlyEndpoint) error
+	// AfterAccept 把协调者对象记录到会话中
+	AfterAccept(sess drpc.EarlySession) *drpc.Status
+}
+
+var (
+	_ drpc.AfterNewEndpointPlugin = Coordinator(nil)
+	_ drpc.AfterAcceptPlugin      = Coordinator(nil)
+)
+
+// LockArgs 租约请求参数
+type LockArgs struct {
+	Key   string `json:"key"`   // 租约名
+	Owner string `json:"owner"` // 持有者标识
+	TTL   int64  `json:"ttl"`   // 租约时长，单位毫秒
+}
+
+// 租约
+type lease struct {
+	owner  string
+	expire time.Time
+}
+
+// NewCoordinator 创建协调者插件
+func NewCoordinator() Coordinator {
+	return &coordinator{
+		leases: make(map[string]lease),
+	}
+}
+
+type coordinator struct {
+	mu     sync.Mutex
+	leases map[string]lease
+}
+
+func (that *coordinator) Name() string {
+	return "cron-locker-coordinator"
+}
+
+// AfterNewEndpoint 端点创建后注册租约服务
+func (that *coordinator) AfterNewEndpoint(endpoint drpc.EarlyEndpoint) error {
+	group := endpoint.SubRoute(lockerServicePrefix)
+	group.RouteCallFunc((*lockerCall).lock)
+	group.RouteCallFunc((*lockerCall).renew)
+	group.RouteCallFunc((*lockerCall).unlock)
+	return nil
+}
+
+// AfterAccept 接收到连接后把协调者记录到会话中，供处理程序使用
+func (that *coordinator) AfterAccept(sess drpc.EarlySession) *drpc.Status {
+	sess.Swap().Set(coordinatorSwapKey, that)
+	return nil
+}
+
+// 获取或续约租约，租约空闲、过期或属于自己时成功
+func (that *coordinator) lock(args *LockArgs) bool {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	now := time.Now()
+	if l, ok := that.leases[args.Key]; ok && l.owner != args.Owner && now.Before(l.expire) {
+		return false
+	}
+	that.leases[args.Key] = lease{
+		owner:  args.Owner,
+		expire: now.Add(time.Duration(args.TTL) * time.Millisecond),
+	}
+	return true
+}
+
+// 续约，租约不属于自己时失败
+func (that *coordinator) renew(args *LockArgs) bool {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	l, ok := that.leases[args.Key]
+	if !ok || l.owner != args.Owner || time.Now().After(l.expire) {
+		return false
+	}
+	l.expire = time.Now().Add(time.Duration(args.TTL) * time.Millisecond)
+	that.leases[args.Key] = l
+	return true
+}
+
+// 释放租约，租约不属于自己时什么都不做
+func (that *coordinator) unlock(args *LockArgs) bool {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if l, ok := that.leases[args.Key]; ok && l.owner == args.Owner {
+		delete(that.leases, args.Key)
+		return true
+	}
+	return false
+}
+
+// 租约服务的CALL处理程序
+type lockerCall struct {
+	drpc.CallCtx
+}
+
+func (that *lockerCall) lock(args *LockArgs) (bool, *drpc.Status) {
+	c, stat := that.coordinator()
+	if stat != nil {
+		return false, stat
+	}
+	return c.lock(args), nil
+}
+
+func (that *lockerCall) renew(args *LockArgs) (bool, *drpc.Status) {
+	c, stat := that.coordinator()
+	if stat != nil {
+		return false, stat
+	}
+	return c.renew(args), nil
+}
+
+func (that *lockerCall) unlock(args *LockArgs) (bool, *drpc.Status) {
+	c, stat := that.coordinator()
+	if stat != nil {
+		return false, stat
+	}
+	return c.unlock(args), nil
+}
+
+// 从会话中获取协调者
+func (that *lockerCall) coordinator() (*coordinator, *drpc.Status) {
+	if v := that.Session().Swap().Get(coordinatorSwapKey); v != nil {
+		return v.(*coordinator), nil
+	}
+	return nil, drpc.NewStatus(drpc.CodeInternalServerError, "cron locker coordinator not found")
+}
@@ -0,0 +1,47 @@
+package cronlocker_test
+
+import (
+	"github.com/osgochina/donkeygo/drpc"
+	"github.com/osgochina/donkeygo/drpc/plugin/cronlocker"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"testing"
+	"time"
+)
+
+func TestLocker(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		svr := drpc.NewEndpoint(drpc.EndpointConfig{
+			ListenPort: 9191,
+		}, cronlocker.NewCoordinator())
+		go svr.ListenAndServe()
+		defer svr.Close()
+		time.Sleep(500 * time.Millisecond)
+
+		cli := drpc.NewEndpoint(drpc.EndpointConfig{})
+		defer cli.Close()
+		sess1, stat := cli.Dial(":9191")
+		t.Assert(stat.OK(), true)
+		sess2, stat := cli.Dial(":9191")
+		t.Assert(stat.OK(), true)
+
+		l1 := cronlocker.NewLocker(sess1, "host1")
+		l2 := cronlocker.NewLocker(sess2, "host2")
+
+		ok, err := l1.Lock("job", time.Second)
+		t.Assert(err, nil)
+		t.Assert(ok, true)
+		ok, err = l2.Lock("job", time.Second)
+		t.Assert(err, nil)
+		t.Assert(ok, false)
+		ok, _ = l2.Renew("job", time.Second)
+		t.Assert(ok, false)
+		ok, _ = l1.Renew("job", time.Second)
+		t.Assert(ok, true)
+		t.Assert(l1.Unlock("job"), nil)
+		ok, _ = l2.Lock("job", 100*time.Millisecond)
+		t.Assert(ok, true)
+		time.Sleep(200 * time.Millisecond)
+		ok, _ = l1.Lock("job", time.Second)
+		t.Assert(ok, true)
+	})
+}
@@ -0,0 +1,67 @@
+package cronlocker
+
+import (
+	"fmt"
+	"github.com/osgochina/donkeygo/drpc"
+	"github.com/osgochina/donkeygo/os/dcron"
+	"os"
+	"time"
+)
+
+// Locker 通过drpc调用协调者实现的dcron分布式锁
+type Locker struct {
+	sess  drpc.Session // 与协调者的会话
+	owner string       // 当前进程的持有者标识
+}
+
+var _ dcron.Locker = (*Locker)(nil)
+
+// NewLocker 创建分布式锁，sess为连接到协调者的会话，
+// owner为当前进程的持有者标识，默认使用"主机名:进程id"
+func NewLocker(sess drpc.Session, owner ...string) *Locker {
+	l := &Locker{
+		sess: sess,
+	}
+	if len(owner) > 0 && owner[0] != "" {
+		l.owner = owner[0]
+	} else {
+		hostname, _ := os.Hostname()
+		l.owner = fmt.Sprintf("%s:%d", hostname, os.Getpid())
+	}
+	return l
+}
+
+// Owner 获取当前进程的持有者标识
+func (that *Locker) Owner() string {
+	return that.owner
+}
+
+// Lock 获取名为key的租约
+func (that *Locker) Lock(key string, ttl time.Duration) (bool, error) {
+	return that.call("/lock", key, ttl)
+}
+
+// Renew 续约名为key的租约
+func (that *Locker) Renew(key string, ttl time.Duration) (bool, error) {
+	return that.call("/renew", key, ttl)
+}
+
+// Unlock 释放名为key的租约
+func (that *Locker) Unlock(key string) error {
+	_, err := that.call("/unlock", key, 0)
+	return err
+}
+
+// 调用协调者的租约服务
+func (that *Locker) call(method string, key string, ttl time.Duration) (bool, error) {
+	var ok bool
+	args := &LockArgs{
+		Key:   key,
+		Owner: that.owner,
+		TTL:   int64(ttl / time.Millisecond),
+	}
+	if stat := that.sess.Call(lockerServicePrefix+method, args, &ok).Status(); !stat.OK() {
+		return false, stat.Cause()
+	}
+	return ok, nil
+}
@@ -0,0 +1,331 @@
+// Package ipfilter 按照ip黑白名单拒绝会话，限制全局和单个ip的并发会话数，并临时封禁链接过于频繁或者认证失败过多的ip
+package ipfilter
+
+import (
+	"fmt"
+	"github.com/osgochina/donkeygo/container/dtype"
+	"github.com/osgochina/donkeygo/container/dvar"
+	"github.com/osgochina/donkeygo/drpc"
+	"github.com/osgochina/donkeygo/net/dcidr"
+	"github.com/osgochina/donkeygo/os/dcfg"
+	"github.com/osgochina/donkeygo/os/dlog"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// 插件记录在会话的数据key，值为计入会话数的ip
+	ipFilterSwapKey = "ipFilterSwapKey"
+)
+
+// Config 插件配置，可以通过dcfg加载并热更新
+type Config struct {
+	Allow            []string      // 允许的网段，为空时允许所有不在Deny中的地址
+	Deny             []string      // 拒绝的网段，优先于Allow
+	MaxSessions      int           // 最大并发会话数，为0时不限制
+	MaxSessionsPerIP int           // 单个ip的最大并发会话数，为0时不限制
+	ConnectRate      int           // 单个ip在RateWindow内最多建立的链接数，超过后封禁，为0时不限制
+	MaxAuthFailures  int           // 单个ip在RateWindow内最多认证失败的次数，超过后封禁，为0时不限制
+	RateWindow       time.Duration `default:"1m"`  // 统计链接频率和认证失败次数的时间窗口
+	BanDuration      time.Duration `default:"10m"` // 封禁的时长
+}
+
+// Stats 插件的统计信息
+type Stats struct {
+	Accepted int64 // 接受的会话数
+	Denied   int64 // 被黑白名单拒绝的会话数
+	Limited  int64 // 超过并发会话数被拒绝的会话数
+	Banned   int64 // 因为ip被封禁而拒绝的会话数
+	Bans     int64 // 封禁ip的次数
+	Sessions int   // 当前的会话数
+}
+
+// IPFilter ip过滤插件
+type IPFilter struct {
+	mu        sync.Mutex
+	config    Config
+	allow     *dcidr.Set
+	deny      *dcidr.Set
+	sessions  int                   // 当前的会话数
+	perIP     map[string]int        // 每个ip当前的会话数
+	counters  map[string]*ipCounter // 每个ip在时间窗口内的计数
+	bans      map[string]time.Time  // 被封禁的ip和解封时间
+	lastSweep time.Time             // 上次清理过期计数的时间
+	accepted  *dtype.Int64
+	denied    *dtype.Int64
+	limited   *dtype.Int64
+	banned    *dtype.Int64
+	banCount  *dtype.Int64
+}
+
+// 单个ip在时间窗口内的计数
+type ipCounter struct {
+	start        time.Time
+	connects     int
+	authFailures int
+}
+
+var (
+	_ drpc.AfterAcceptPlugin     = (*IPFilter)(nil)
+	_ drpc.AfterDisconnectPlugin = (*IPFilter)(nil)
+)
+
+// New 创建ip过滤插件
+func New(config Config) (*IPFilter, error) {
+	f := &IPFilter{
+		perIP:    make(map[string]int),
+		counters: make(map[string]*ipCounter),
+		bans:     make(map[string]time.Time),
+		accepted: dtype.NewInt64(),
+		denied:   dtype.NewInt64(),
+		limited:  dtype.NewInt64(),
+		banned:   dtype.NewInt64(),
+		banCount: dtype.NewInt64(),
+	}
+	if err := f.SetConfig(config); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// NewFromConfig 从配置<cfg>的<pattern>加载插件配置，配置文件变更后自动重新加载
+func NewFromConfig(cfg *dcfg.Config, pattern string) (*IPFilter, error) {
+	var config Config
+	if err := cfg.BindStruct(pattern, &config); err != nil {
+		return nil, err
+	}
+	f, err := New(config)
+	if err != nil {
+		return nil, err
+	}
+	cfg.OnChange(pattern, func(old, new *dvar.Var) {
+		var config Config
+		if err := cfg.BindStruct(pattern, &config); err != nil {
+			dlog.Errorf("[IPFilter] reload config failed, keep using the previous configuration: %s", err.Error())
+			return
+		}
+		if err := f.SetConfig(config); err != nil {
+			dlog.Errorf("[IPFilter] reload config failed, keep using the previous configuration: %s", err.Error())
+			return
+		}
+		dlog.Infof("[IPFilter] config reloaded")
+	})
+	return f, nil
+}
+
+// Name 插件名称
+func (that *IPFilter) Name() string {
+	return "ip-filter"
+}
+
+// SetConfig 更新插件配置，网段格式错误时返回错误并保留之前的配置
+func (that *IPFilter) SetConfig(config Config) error {
+	allow, err := dcidr.NewSet(config.Allow...)
+	if err != nil {
+		return err
+	}
+	deny, err := dcidr.NewSet(config.Deny...)
+	if err != nil {
+		return err
+	}
+	if config.RateWindow <= 0 {
+		config.RateWindow = time.Minute
+	}
+	if config.BanDuration <= 0 {
+		config.BanDuration = 10 * time.Minute
+	}
+	that.mu.Lock()
+	that.config = config
+	that.allow = allow
+	that.deny = deny
+	that.mu.Unlock()
+	return nil
+}
+
+// AfterAccept 接收到链接后检查黑白名单、封禁状态和并发会话数
+func (that *IPFilter) AfterAccept(sess drpc.EarlySession) *drpc.Status {
+	ip := remoteIP(sess.RemoteAddr())
+	now := time.Now()
+
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	that.sweep(now)
+
+	if !that.allowed(ip) {
+		that.denied.Add(1)
+		dlog.Warningf("[IPFilter] deny session from %s: not allowed by acl", ip)
+		return drpc.NewStatus(drpc.CodeUnauthorized, "ip not allowed", ip)
+	}
+	if until, ok := that.bans[ip]; ok && now.Before(until) {
+		that.banned.Add(1)
+		dlog.Warningf("[IPFilter] deny session from %s: banned until %s", ip, until.Format(time.RFC3339))
+		return drpc.NewStatus(drpc.CodeUnauthorized, "ip banned", ip)
+	}
+	if that.config.ConnectRate > 0 {
+		counter := that.counter(ip, now)
+		counter.connects++
+		if counter.connects > that.config.ConnectRate {
+			that.ban(ip, now, fmt.Sprintf("more than %d connections in %s", that.config.ConnectRate, that.config.RateWindow))
+			that.banned.Add(1)
+			return drpc.NewStatus(drpc.CodeUnauthorized, "ip banned", ip)
+		}
+	}
+	if that.config.MaxSessions > 0 && that.sessions >= that.config.MaxSessions {
+		that.limited.Add(1)
+		dlog.Warningf("[IPFilter] deny session from %s: too many sessions (%d)", ip, that.sessions)
+		return drpc.NewStatus(drpc.CodeUnauthorized, "too many sessions", ip)
+	}
+	if that.config.MaxSessionsPerIP > 0 && that.perIP[ip] >= that.config.MaxSessionsPerIP {
+		that.limited.Add(1)
+		dlog.Warningf("[IPFilter] deny session from %s: too many sessions from this ip (%d)", ip, that.perIP[ip])
+		return drpc.NewStatus(drpc.CodeUnauthorized, "too many sessions from this ip", ip)
+	}
+	that.sessions++
+	that.perIP[ip]++
+	that.accepted.Add(1)
+	sess.Swap().Set(ipFilterSwapKey, ip)
+	return nil
+}
+
+// AfterDisconnect 会话断开后减少会话数
+func (that *IPFilter) AfterDisconnect(sess drpc.BaseSession) *drpc.Status {
+	v := sess.Swap().Remove(ipFilterSwapKey)
+	if v == nil {
+		return nil
+	}
+	ip := v.(string)
+	that.mu.Lock()
+	that.sessions--
+	if that.perIP[ip]--; that.perIP[ip] <= 0 {
+		delete(that.perIP, ip)
+	}
+	that.mu.Unlock()
+	return nil
+}
+
+// ReportAuthFailure 报告<ip>认证失败，时间窗口内失败次数超过MaxAuthFailures时封禁该ip，
+// 通常在认证插件中调用，<ip>可以使用会话的RemoteAddr或者RealIP
+func (that *IPFilter) ReportAuthFailure(ip string) {
+	now := time.Now()
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if that.config.MaxAuthFailures <= 0 {
+		return
+	}
+	counter := that.counter(ip, now)
+	counter.authFailures++
+	if counter.authFailures > that.config.MaxAuthFailures {
+		that.ban(ip, now, fmt.Sprintf("more than %d auth failures in %s", that.config.MaxAuthFailures, that.config.RateWindow))
+	}
+}
+
+// Ban 封禁<ip>，<duration>小于等于0时使用配置的BanDuration
+func (that *IPFilter) Ban(ip string, duration ...time.Duration) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	d := that.config.BanDuration
+	if len(duration) > 0 && duration[0] > 0 {
+		d = duration[0]
+	}
+	that.bans[ip] = time.Now().Add(d)
+	that.banCount.Add(1)
+	dlog.Noticef("[IPFilter] ban %s for %s: banned manually", ip, d)
+}
+
+// Unban 解除<ip>的封禁
+func (that *IPFilter) Unban(ip string) {
+	that.mu.Lock()
+	delete(that.bans, ip)
+	delete(that.counters, ip)
+	that.mu.Unlock()
+}
+
+// IsAllowed 判断<ip>是否被黑白名单允许
+func (that *IPFilter) IsAllowed(ip string) bool {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	return that.allowed(ip)
+}
+
+// IsBanned 判断<ip>是否被封禁
+func (that *IPFilter) IsBanned(ip string) bool {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	until, ok := that.bans[ip]
+	return ok && time.Now().Before(until)
+}
+
+// Stats 获取插件的统计信息
+func (that *IPFilter) Stats() Stats {
+	that.mu.Lock()
+	sessions := that.sessions
+	that.mu.Unlock()
+	return Stats{
+		Accepted: that.accepted.Val(),
+		Denied:   that.denied.Val(),
+		Limited:  that.limited.Val(),
+		Banned:   that.banned.Val(),
+		Bans:     that.banCount.Val(),
+		Sessions: sessions,
+	}
+}
+
+// 判断ip是否被黑白名单允许，拒绝列表优先
+func (that *IPFilter) allowed(ip string) bool {
+	if that.deny.Contains(ip) {
+		return false
+	}
+	return that.allow.Len() == 0 || that.allow.Contains(ip)
+}
+
+// 获取ip在当前时间窗口内的计数，时间窗口过期后重新计数
+func (that *IPFilter) counter(ip string, now time.Time) *ipCounter {
+	counter, ok := that.counters[ip]
+	if !ok || now.Sub(counter.start) >= that.config.RateWindow {
+		counter = &ipCounter{start: now}
+		that.counters[ip] = counter
+	}
+	return counter
+}
+
+// 封禁ip并清除它的计数
+func (that *IPFilter) ban(ip string, now time.Time, reason string) {
+	that.bans[ip] = now.Add(that.config.BanDuration)
+	delete(that.counters, ip)
+	that.banCount.Add(1)
+	dlog.Noticef("[IPFilter] ban %s for %s: %s", ip, that.config.BanDuration, reason)
+}
+
+// 每个时间窗口清理一次过期的计数和封禁
+func (that *IPFilter) sweep(now time.Time) {
+	if now.Sub(that.lastSweep) < that.config.RateWindow {
+		return
+	}
+	that.lastSweep = now
+	for ip, counter := range that.counters {
+		if now.Sub(counter.start) >= that.config.RateWindow {
+			delete(that.counters, ip)
+		}
+	}
+	for ip, until := range that.bans {
+		if !now.Before(until) {
+			delete(that.bans, ip)
+		}
+	}
+}
+
+// 获取远端地址中的ip
+func remoteIP(addr net.Addr) string {
+	switch v := addr.(type) {
+	case *net.TCPAddr:
+		return v.IP.String()
+	case *net.UDPAddr:
+		return v.IP.String()
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
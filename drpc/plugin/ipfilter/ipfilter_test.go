@@ -0,0 +1,155 @@
+package ipfilter_test
+
+import (
+	"github.com/osgochina/donkeygo/drpc"
+	"github.com/osgochina/donkeygo/drpc/plugin/ipfilter"
+	"github.com/osgochina/donkeygo/os/dcfg"
+	"github.com/osgochina/donkeygo/os/dfile"
+	"github.com/osgochina/donkeygo/os/dtime"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"testing"
+	"time"
+)
+
+type Home struct {
+	drpc.CallCtx
+}
+
+func (that *Home) Test(arg *string) (string, *drpc.Status) {
+	return *arg, nil
+}
+
+// 启动服务端，返回关闭函数
+func startServer(port uint16, filter *ipfilter.IPFilter) func() {
+	srv := drpc.NewEndpoint(drpc.EndpointConfig{ListenPort: port, Network: "tcp"}, filter)
+	srv.RouteCall(new(Home))
+	go srv.ListenAndServe()
+	time.Sleep(500 * time.Millisecond)
+	return func() { _ = srv.Close() }
+}
+
+// 建立会话并调用一次，返回调用是否成功
+func call(cli drpc.Endpoint, addr string) (drpc.Session, bool) {
+	sess, stat := cli.Dial(addr)
+	if !stat.OK() {
+		return nil, false
+	}
+	var result string
+	stat = sess.Call("/home/test", "hello", &result).Status()
+	return sess, stat.OK() && result == "hello"
+}
+
+func TestIPFilter_ACL(t *testing.T) {
+	filter, err := ipfilter.New(ipfilter.Config{Deny: []string{"127.0.0.0/8"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := startServer(9291, filter)
+	defer stop()
+	cli := drpc.NewEndpoint(drpc.EndpointConfig{Network: "tcp"})
+	defer cli.Close()
+
+	dtest.C(t, func(t *dtest.T) {
+		_, ok := call(cli, "127.0.0.1:9291")
+		t.Assert(ok, false)
+		t.Assert(filter.Stats().Denied, 1)
+		t.Assert(filter.Stats().Sessions, 0)
+
+		t.Assert(filter.SetConfig(ipfilter.Config{Allow: []string{"127.0.0.1", "10.0.0.0/8"}}), nil)
+		t.Assert(filter.IsAllowed("10.1.1.1"), true)
+		t.Assert(filter.IsAllowed("192.168.1.1"), false)
+		sess, ok := call(cli, "127.0.0.1:9291")
+		t.Assert(ok, true)
+		t.Assert(filter.Stats().Accepted, 1)
+		t.Assert(filter.Stats().Sessions, 1)
+		_ = sess.Close()
+		time.Sleep(200 * time.Millisecond)
+		t.Assert(filter.Stats().Sessions, 0)
+
+		// 网段格式错误时保留之前的配置
+		t.AssertNE(filter.SetConfig(ipfilter.Config{Allow: []string{"bad"}}), nil)
+		t.Assert(filter.IsAllowed("10.1.1.1"), true)
+	})
+}
+
+func TestIPFilter_Limit(t *testing.T) {
+	filter, err := ipfilter.New(ipfilter.Config{MaxSessionsPerIP: 1, ConnectRate: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := startServer(9292, filter)
+	defer stop()
+	cli := drpc.NewEndpoint(drpc.EndpointConfig{Network: "tcp"})
+	defer cli.Close()
+
+	dtest.C(t, func(t *dtest.T) {
+		sess, ok := call(cli, "127.0.0.1:9292")
+		t.Assert(ok, true)
+		// 超过单个ip的并发会话数
+		_, ok = call(cli, "127.0.0.1:9292")
+		t.Assert(ok, false)
+		t.Assert(filter.Stats().Limited, 1)
+		_ = sess.Close()
+		time.Sleep(200 * time.Millisecond)
+
+		// 第三个链接仍然在频率限制内，第四个链接触发封禁
+		sess, ok = call(cli, "127.0.0.1:9292")
+		t.Assert(ok, true)
+		_ = sess.Close()
+		time.Sleep(200 * time.Millisecond)
+		_, ok = call(cli, "127.0.0.1:9292")
+		t.Assert(ok, false)
+		t.Assert(filter.IsBanned("127.0.0.1"), true)
+		t.Assert(filter.Stats().Bans, 1)
+
+		filter.Unban("127.0.0.1")
+		sess, ok = call(cli, "127.0.0.1:9292")
+		t.Assert(ok, true)
+		_ = sess.Close()
+	})
+}
+
+func TestIPFilter_AuthFailure(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		filter, err := ipfilter.New(ipfilter.Config{MaxAuthFailures: 2, BanDuration: 100 * time.Millisecond})
+		t.Assert(err, nil)
+		filter.ReportAuthFailure("10.0.0.1")
+		filter.ReportAuthFailure("10.0.0.1")
+		t.Assert(filter.IsBanned("10.0.0.1"), false)
+		filter.ReportAuthFailure("10.0.0.1")
+		t.Assert(filter.IsBanned("10.0.0.1"), true)
+		time.Sleep(150 * time.Millisecond)
+		t.Assert(filter.IsBanned("10.0.0.1"), false)
+
+		filter.Ban("10.0.0.2", time.Minute)
+		t.Assert(filter.IsBanned("10.0.0.2"), true)
+		t.Assert(filter.Stats().Bans, 2)
+	})
+}
+
+func TestIPFilter_Reload(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		dirPath := dfile.TempDir(dtime.TimestampNanoStr())
+		path := dfile.Join(dirPath, "config.toml")
+		t.Assert(dfile.Mkdir(dirPath), nil)
+		defer dfile.Remove(dirPath)
+		t.Assert(dfile.PutContents(path, "[ipfilter]\n    deny = [\"10.0.0.0/8\"]\n"), nil)
+
+		c := dcfg.New()
+		t.Assert(c.SetPath(dirPath), nil)
+		filter, err := ipfilter.NewFromConfig(c, "ipfilter")
+		t.Assert(err, nil)
+		t.Assert(filter.IsAllowed("10.1.1.1"), false)
+		t.Assert(filter.IsAllowed("192.168.1.1"), true)
+
+		t.Assert(dfile.PutContents(path, "[ipfilter]\n    deny = [\"192.168.0.0/16\"]\n"), nil)
+		time.Sleep(300 * time.Millisecond)
+		t.Assert(filter.IsAllowed("10.1.1.1"), true)
+		t.Assert(filter.IsAllowed("192.168.1.1"), false)
+
+		// 配置错误时保留之前的配置
+		t.Assert(dfile.PutContents(path, "[ipfilter]\n    deny = [\"bad\"]\n"), nil)
+		time.Sleep(300 * time.Millisecond)
+		t.Assert(filter.IsAllowed("192.168.1.1"), false)
+	})
+}
@@ -1,6 +1,6 @@
 module github.com/osgochina/donkeygo
 
-go 1.15
+go 1.18
 
 require (
 	github.com/fsnotify/fsnotify v1.4.9
@@ -9,3 +9,19 @@ require (
 	go.opentelemetry.io/otel/trace v0.19.0
 	golang.org/x/sys v0.0.0-20210415045647-66c3f260301c
 )
+
+require (
+	github.com/BurntSushi/toml v0.3.1 // indirect
+	github.com/clbanning/mxj v1.8.5-0.20200714211355-ff02cfb8ea28 // indirect
+	github.com/gomodule/redigo v2.0.0+incompatible // indirect
+	github.com/gorilla/websocket v1.4.1 // indirect
+	github.com/grokify/html-strip-tags-go v0.0.0-20190921062105-daaa06bf1aaf // indirect
+	github.com/mattn/go-runewidth v0.0.10 // indirect
+	github.com/olekukonko/tablewriter v0.0.5 // indirect
+	github.com/rivo/uniseg v0.1.0 // indirect
+	go.opentelemetry.io/otel v0.19.0 // indirect
+	go.opentelemetry.io/otel/metric v0.19.0 // indirect
+	golang.org/x/net v0.0.0-20201031054903-ff519b6c9102 // indirect
+	golang.org/x/text v0.3.4 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)
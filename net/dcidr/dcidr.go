@@ -0,0 +1,168 @@
+// Package dcidr 提供ipv4和ipv6通用的CIDR网段工具，
+// 包括包含判断、地址范围遍历、子网拆分以及用于黑白名单的快速ip范围查找集合。
+package dcidr
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+)
+
+const maxSubnets = 1 << 16 // 一次拆分的最大子网数量
+
+// Cidr 一个ipv4或ipv6网段
+type Cidr struct {
+	ipNet *net.IPNet
+	first uint128 // 网段的第一个地址
+	last  uint128 // 网段的最后一个地址
+	v4    bool    // 是否是ipv4网段
+}
+
+// Parse 解析CIDR格式的网段，也可以传入单个ip地址，此时网段只包含该地址
+// Eg: 192.168.1.0/24, 2001:db8::/32, 10.0.0.1
+func Parse(cidr string) (*Cidr, error) {
+	cidr = strings.TrimSpace(cidr)
+	if !strings.Contains(cidr, "/") {
+		ip := net.ParseIP(cidr)
+		if ip == nil {
+			return nil, errors.New(fmt.Sprintf(`invalid ip address "%s"`, cidr))
+		}
+		if ip.To4() != nil {
+			cidr += "/32"
+		} else {
+			cidr += "/128"
+		}
+	}
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	return newCidr(ipNet), nil
+}
+
+// MustParse 解析CIDR格式的网段，解析失败时panic
+func MustParse(cidr string) *Cidr {
+	c, err := Parse(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Contains 判断<ip>是否在网段<cidr>中，网段格式错误时返回false
+func Contains(cidr string, ip string) bool {
+	c, err := Parse(cidr)
+	if err != nil {
+		return false
+	}
+	return c.Contains(ip)
+}
+
+// Range 返回网段<cidr>的第一个和最后一个地址
+func Range(cidr string) (first, last string, err error) {
+	c, err := Parse(cidr)
+	if err != nil {
+		return "", "", err
+	}
+	first, last = c.Range()
+	return first, last, nil
+}
+
+func newCidr(ipNet *net.IPNet) *Cidr {
+	ones, bits := ipNet.Mask.Size()
+	first := ipToUint128(ipNet.IP)
+	return &Cidr{
+		ipNet: ipNet,
+		first: first,
+		last:  first.or(hostMask(bits - ones)),
+		v4:    bits == 8*net.IPv4len,
+	}
+}
+
+// String 返回CIDR格式的网段
+func (that *Cidr) String() string {
+	return that.ipNet.String()
+}
+
+// IPNet 返回标准库的网段对象
+func (that *Cidr) IPNet() *net.IPNet {
+	return that.ipNet
+}
+
+// Prefix 返回网段的前缀长度
+func (that *Cidr) Prefix() int {
+	ones, _ := that.ipNet.Mask.Size()
+	return ones
+}
+
+// IsIPv4 判断是否是ipv4网段
+func (that *Cidr) IsIPv4() bool {
+	return that.v4
+}
+
+// Contains 判断<ip>是否在网段中
+func (that *Cidr) Contains(ip string) bool {
+	return that.ContainsIP(net.ParseIP(ip))
+}
+
+// ContainsIP 判断<ip>是否在网段中
+func (that *Cidr) ContainsIP(ip net.IP) bool {
+	if ip == nil || (ip.To4() != nil) != that.v4 {
+		return false
+	}
+	return that.ipNet.Contains(ip)
+}
+
+// ContainsCidr 判断网段<other>是否完全包含在当前网段中
+func (that *Cidr) ContainsCidr(other *Cidr) bool {
+	return that.v4 == other.v4 && that.first.cmp(other.first) <= 0 && that.last.cmp(other.last) >= 0
+}
+
+// Range 返回网段的第一个和最后一个地址
+func (that *Cidr) Range() (first, last string) {
+	return that.first.ip(that.v4).String(), that.last.ip(that.v4).String()
+}
+
+// Size 返回网段中的地址数量
+func (that *Cidr) Size() *big.Int {
+	_, bits := that.ipNet.Mask.Size()
+	return new(big.Int).Lsh(big.NewInt(1), uint(bits-that.Prefix()))
+}
+
+// Each 按照顺序遍历网段中的所有地址，<f>返回false时停止遍历
+func (that *Cidr) Each(f func(ip string) bool) {
+	for current := that.first; ; current = current.add1() {
+		if !f(current.ip(that.v4).String()) {
+			return
+		}
+		if current == that.last {
+			return
+		}
+	}
+}
+
+// Subnets 把网段拆分成前缀长度为<prefix>的子网
+// Eg: 192.168.0.0/23 拆分为 /24 -> 192.168.0.0/24, 192.168.1.0/24
+func (that *Cidr) Subnets(prefix int) ([]*Cidr, error) {
+	ones, bits := that.ipNet.Mask.Size()
+	if prefix < ones || prefix > bits {
+		return nil, errors.New(fmt.Sprintf(`invalid prefix %d for "%s", should be between %d and %d`, prefix, that.String(), ones, bits))
+	}
+	if prefix-ones > 16 {
+		return nil, errors.New(fmt.Sprintf(`too many subnets, at most %d subnets can be split at a time`, maxSubnets))
+	}
+	var (
+		count   = 1 << uint(prefix-ones)
+		step    = hostMask(bits - prefix).add1()
+		mask    = net.CIDRMask(prefix, bits)
+		subnets = make([]*Cidr, 0, count)
+		current = that.first
+	)
+	for i := 0; i < count; i++ {
+		subnets = append(subnets, newCidr(&net.IPNet{IP: current.ip(that.v4), Mask: mask}))
+		current = current.add(step)
+	}
+	return subnets, nil
+}
@@ -0,0 +1,157 @@
+package dcidr
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Set 用于黑白名单的ip范围集合，并发安全，
+// 内部把所有网段合并成有序且不重叠的地址范围，查找时使用二分查找，
+// ipv4和ipv6的地址范围分开保存，ipv4地址只匹配ipv4的范围，ipv6地址只匹配ipv6的范围
+type Set struct {
+	mu sync.RWMutex
+	v4 []ipRange
+	v6 []ipRange
+}
+
+// 一段连续的地址范围
+type ipRange struct {
+	first, last uint128
+	v4          bool // 是否是ipv4的地址范围
+}
+
+// NewSet 创建ip范围集合，<items>可以是CIDR网段、单个ip地址或者用-连接的地址范围
+// Eg: 192.168.1.0/24, 10.0.0.1, 10.0.1.1-10.0.1.100, 2001:db8::/32
+func NewSet(items ...string) (*Set, error) {
+	s := &Set{}
+	if err := s.Add(items...); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Add 添加网段、单个ip地址或者地址范围到集合中
+func (that *Set) Add(items ...string) error {
+	ranges := make([]ipRange, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		r, err := parseRange(item)
+		if err != nil {
+			return err
+		}
+		ranges = append(ranges, r)
+	}
+	that.mu.Lock()
+	that.add(ranges...)
+	that.mu.Unlock()
+	return nil
+}
+
+// AddCidr 添加网段到集合中
+func (that *Set) AddCidr(cidr *Cidr) {
+	that.mu.Lock()
+	that.add(ipRange{first: cidr.first, last: cidr.last, v4: cidr.v4})
+	that.mu.Unlock()
+}
+
+// 按照地址族添加地址范围并合并，调用时需要持有锁
+func (that *Set) add(ranges ...ipRange) {
+	for _, r := range ranges {
+		if r.v4 {
+			that.v4 = append(that.v4, r)
+		} else {
+			that.v6 = append(that.v6, r)
+		}
+	}
+	that.v4 = mergeRanges(that.v4)
+	that.v6 = mergeRanges(that.v6)
+}
+
+// Contains 判断<ip>是否在集合中
+func (that *Set) Contains(ip string) bool {
+	return that.ContainsIP(net.ParseIP(ip))
+}
+
+// ContainsIP 判断<ip>是否在集合中
+func (that *Set) ContainsIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	value := ipToUint128(ip)
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	ranges := that.v6
+	if ip.To4() != nil {
+		ranges = that.v4
+	}
+	// 找到第一个结束地址不小于value的范围
+	i := sort.Search(len(ranges), func(i int) bool {
+		return ranges[i].last.cmp(value) >= 0
+	})
+	return i < len(ranges) && ranges[i].first.cmp(value) <= 0
+}
+
+// Len 返回合并后的地址范围数量
+func (that *Set) Len() int {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	return len(that.v4) + len(that.v6)
+}
+
+// Clear 清空集合
+func (that *Set) Clear() {
+	that.mu.Lock()
+	that.v4 = nil
+	that.v6 = nil
+	that.mu.Unlock()
+}
+
+// 解析网段、单个ip地址或者地址范围
+func parseRange(item string) (ipRange, error) {
+	if i := strings.IndexByte(item, '-'); i > 0 {
+		first := net.ParseIP(strings.TrimSpace(item[:i]))
+		last := net.ParseIP(strings.TrimSpace(item[i+1:]))
+		if first == nil || last == nil || (first.To4() != nil) != (last.To4() != nil) {
+			return ipRange{}, errors.New(fmt.Sprintf(`invalid ip range "%s"`, item))
+		}
+		r := ipRange{first: ipToUint128(first), last: ipToUint128(last), v4: first.To4() != nil}
+		if r.first.cmp(r.last) > 0 {
+			return ipRange{}, errors.New(fmt.Sprintf(`invalid ip range "%s", start is greater than end`, item))
+		}
+		return r, nil
+	}
+	c, err := Parse(item)
+	if err != nil {
+		return ipRange{}, err
+	}
+	return ipRange{first: c.first, last: c.last, v4: c.v4}, nil
+}
+
+// 排序并合并重叠或者相邻的地址范围
+func mergeRanges(ranges []ipRange) []ipRange {
+	if len(ranges) < 2 {
+		return ranges
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].first.cmp(ranges[j].first) < 0
+	})
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if last.last.isMax() || r.first.cmp(last.last.add1()) <= 0 {
+			if r.last.cmp(last.last) > 0 {
+				last.last = r.last
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
@@ -0,0 +1,126 @@
+package dcidr_test
+
+import (
+	"github.com/osgochina/donkeygo/net/dcidr"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"testing"
+)
+
+func TestCidr(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		c, err := dcidr.Parse("192.168.1.10/24")
+		t.Assert(err, nil)
+		t.Assert(c.String(), "192.168.1.0/24")
+		t.Assert(c.Prefix(), 24)
+		t.Assert(c.IsIPv4(), true)
+		t.Assert(c.Size().Int64(), 256)
+		t.Assert(c.Contains("192.168.1.255"), true)
+		t.Assert(c.Contains("192.168.2.1"), false)
+		t.Assert(c.Contains("::ffff:192.168.1.1"), true)
+		t.Assert(c.Contains("2001:db8::1"), false)
+		first, last := c.Range()
+		t.Assert(first, "192.168.1.0")
+		t.Assert(last, "192.168.1.255")
+		t.Assert(c.ContainsCidr(dcidr.MustParse("192.168.1.128/25")), true)
+		t.Assert(c.ContainsCidr(dcidr.MustParse("192.168.0.0/16")), false)
+
+		_, err = dcidr.Parse("192.168.1.1/33")
+		t.AssertNE(err, nil)
+		t.Assert(dcidr.Contains("10.0.0.1", "10.0.0.1"), true)
+		t.Assert(dcidr.Contains("bad", "10.0.0.1"), false)
+	})
+	dtest.C(t, func(t *dtest.T) {
+		first, last, err := dcidr.Range("2001:db8::/32")
+		t.Assert(err, nil)
+		t.Assert(first, "2001:db8::")
+		t.Assert(last, "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff")
+		t.Assert(dcidr.Contains("2001:db8::/32", "2001:db8:1::1"), true)
+		t.Assert(dcidr.MustParse("::/0").Size().String(), "340282366920938463463374607431768211456")
+		first, last, err = dcidr.Range("0.0.0.0/0")
+		t.Assert(err, nil)
+		t.Assert(first, "0.0.0.0")
+		t.Assert(last, "255.255.255.255")
+	})
+}
+
+func TestCidr_Each(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var ips []string
+		dcidr.MustParse("10.0.0.254/31").Each(func(ip string) bool {
+			ips = append(ips, ip)
+			return true
+		})
+		t.Assert(ips, []string{"10.0.0.254", "10.0.0.255"})
+
+		ips = ips[:0]
+		dcidr.MustParse("2001:db8::/120").Each(func(ip string) bool {
+			ips = append(ips, ip)
+			return len(ips) < 3
+		})
+		t.Assert(ips, []string{"2001:db8::", "2001:db8::1", "2001:db8::2"})
+
+		ips = ips[:0]
+		dcidr.MustParse("255.255.255.255").Each(func(ip string) bool {
+			ips = append(ips, ip)
+			return true
+		})
+		t.Assert(ips, []string{"255.255.255.255"})
+	})
+}
+
+func TestCidr_Subnets(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		subnets, err := dcidr.MustParse("192.168.0.0/23").Subnets(25)
+		t.Assert(err, nil)
+		t.Assert(len(subnets), 4)
+		t.Assert(subnets[0].String(), "192.168.0.0/25")
+		t.Assert(subnets[3].String(), "192.168.1.128/25")
+
+		subnets, err = dcidr.MustParse("2001:db8::/32").Subnets(34)
+		t.Assert(err, nil)
+		t.Assert(subnets[1].String(), "2001:db8:4000::/34")
+
+		_, err = dcidr.MustParse("192.168.0.0/23").Subnets(22)
+		t.AssertNE(err, nil)
+		_, err = dcidr.MustParse("2001:db8::/32").Subnets(64)
+		t.AssertNE(err, nil)
+	})
+}
+
+func TestSet(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		s, err := dcidr.NewSet("192.168.1.0/24", "192.168.2.0/24", "10.0.0.1", "10.0.1.1-10.0.1.100", "2001:db8::/32")
+		t.Assert(err, nil)
+		// 相邻的网段被合并
+		t.Assert(s.Len(), 4)
+		t.Assert(s.Contains("192.168.2.100"), true)
+		t.Assert(s.Contains("192.168.3.1"), false)
+		t.Assert(s.Contains("10.0.0.1"), true)
+		t.Assert(s.Contains("10.0.0.2"), false)
+		t.Assert(s.Contains("10.0.1.50"), true)
+		t.Assert(s.Contains("10.0.1.101"), false)
+		t.Assert(s.Contains("2001:db8:ffff::1"), true)
+		t.Assert(s.Contains("2001:db9::1"), false)
+		t.Assert(s.Contains("bad"), false)
+
+		t.Assert(s.Add("192.168.0.0/16"), nil)
+		t.Assert(s.Len(), 4)
+		t.Assert(s.Contains("192.168.200.1"), true)
+		// ipv6的网段不匹配ipv4地址
+		s.AddCidr(dcidr.MustParse("::/0"))
+		t.Assert(s.Len(), 4)
+		t.Assert(s.Contains("2001:db9::1"), true)
+		t.Assert(s.Contains("8.8.8.8"), false)
+		t.Assert(s.Contains("::ffff:8.8.8.8"), false)
+		t.Assert(s.Add("0.0.0.0/0"), nil)
+		t.Assert(s.Len(), 2)
+		t.Assert(s.Contains("8.8.8.8"), true)
+		s.Clear()
+		t.Assert(s.Contains("8.8.8.8"), false)
+
+		_, err = dcidr.NewSet("10.0.0.10-10.0.0.1")
+		t.AssertNE(err, nil)
+		_, err = dcidr.NewSet("10.0.0.1-::1")
+		t.AssertNE(err, nil)
+	})
+}
@@ -0,0 +1,75 @@
+package dcidr
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"net"
+)
+
+// 128位的无符号整数，ipv4地址按照ipv4映射的ipv6地址存储
+type uint128 struct {
+	hi, lo uint64
+}
+
+// 把ip地址转换成128位的整数
+func ipToUint128(ip net.IP) uint128 {
+	ip = ip.To16()
+	return uint128{hi: binary.BigEndian.Uint64(ip[:8]), lo: binary.BigEndian.Uint64(ip[8:])}
+}
+
+// 返回低<n>位为1的掩码
+func hostMask(n int) uint128 {
+	switch {
+	case n <= 0:
+		return uint128{}
+	case n < 64:
+		return uint128{lo: 1<<uint(n) - 1}
+	case n < 128:
+		return uint128{hi: 1<<uint(n-64) - 1, lo: ^uint64(0)}
+	default:
+		return uint128{hi: ^uint64(0), lo: ^uint64(0)}
+	}
+}
+
+// 转换成ip地址，<v4>为true时返回4字节的ipv4地址
+func (u uint128) ip(v4 bool) net.IP {
+	ip := make(net.IP, net.IPv6len)
+	binary.BigEndian.PutUint64(ip[:8], u.hi)
+	binary.BigEndian.PutUint64(ip[8:], u.lo)
+	if v4 {
+		return ip.To4()
+	}
+	return ip
+}
+
+func (u uint128) cmp(v uint128) int {
+	switch {
+	case u.hi < v.hi:
+		return -1
+	case u.hi > v.hi:
+		return 1
+	case u.lo < v.lo:
+		return -1
+	case u.lo > v.lo:
+		return 1
+	}
+	return 0
+}
+
+func (u uint128) or(v uint128) uint128 {
+	return uint128{hi: u.hi | v.hi, lo: u.lo | v.lo}
+}
+
+func (u uint128) add(v uint128) uint128 {
+	lo, carry := bits.Add64(u.lo, v.lo, 0)
+	hi, _ := bits.Add64(u.hi, v.hi, carry)
+	return uint128{hi: hi, lo: lo}
+}
+
+func (u uint128) add1() uint128 {
+	return u.add(uint128{lo: 1})
+}
+
+func (u uint128) isMax() bool {
+	return u.hi == ^uint64(0) && u.lo == ^uint64(0)
+}
@@ -1,10 +1,115 @@
 package dipv6
 
 import (
+	"encoding/binary"
+	"fmt"
 	"github.com/osgochina/donkeygo/text/dregex"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
 )
 
 // Validate 检查传入的ip地址是否是ipv6地址
 func Validate(ip string) bool {
 	return dregex.IsMatchString(`^([\da-fA-F]{1,4}:){7}[\da-fA-F]{1,4}$|^:((:[\da-fA-F]{1,4}){1,6}|:)$|^[\da-fA-F]{1,4}:((:[\da-fA-F]{1,4}){1,5}|:)$|^([\da-fA-F]{1,4}:){2}((:[\da-fA-F]{1,4}){1,4}|:)$|^([\da-fA-F]{1,4}:){3}((:[\da-fA-F]{1,4}){1,3}|:)$|^([\da-fA-F]{1,4}:){4}((:[\da-fA-F]{1,4}){1,2}|:)$|^([\da-fA-F]{1,4}:){5}:([\da-fA-F]{1,4})?$|^([\da-fA-F]{1,4}:){6}:$`, ip)
 }
+
+// Parse 解析ipv6地址，支持压缩格式和内嵌ipv4的格式，例如::ffff:192.168.1.1，
+// 不是ipv6地址时返回nil
+func Parse(ip string) net.IP {
+	// 去掉地址上的区域标识，例如fe80::1%eth0
+	if i := strings.IndexByte(ip, '%'); i >= 0 {
+		ip = ip[:i]
+	}
+	if !strings.Contains(ip, ":") {
+		return nil
+	}
+	return net.ParseIP(ip)
+}
+
+// Canonical 返回ipv6地址按照RFC 5952规范格式化后的字符串，
+// 字母小写，并且压缩最长的连续0，解析失败时返回空字符串
+// Eg: 2001:0DB8:0000:0000:0000:0000:0000:0001 -> 2001:db8::1
+func Canonical(ip string) string {
+	netIp := Parse(ip)
+	if netIp == nil {
+		return ""
+	}
+	return format(netIp)
+}
+
+// Expand 返回完整格式的ipv6地址，解析失败时返回空字符串
+// Eg: 2001:db8::1 -> 2001:0db8:0000:0000:0000:0000:0000:0001
+func Expand(ip string) string {
+	netIp := Parse(ip)
+	if netIp == nil {
+		return ""
+	}
+	groups := make([]string, 8)
+	for i := 0; i < 8; i++ {
+		groups[i] = fmt.Sprintf("%04x", binary.BigEndian.Uint16(netIp[i*2:]))
+	}
+	return strings.Join(groups, ":")
+}
+
+// Ip2long 把ipv6地址转换成128位的整数，<hi>为高64位，<lo>为低64位，解析失败时返回0
+func Ip2long(ip string) (hi, lo uint64) {
+	netIp := Parse(ip)
+	if netIp == nil {
+		return 0, 0
+	}
+	return binary.BigEndian.Uint64(netIp[:8]), binary.BigEndian.Uint64(netIp[8:])
+}
+
+// Long2ip 把128位的整数转换成ipv6地址，<hi>为高64位，<lo>为低64位
+func Long2ip(hi, lo uint64) string {
+	netIp := make(net.IP, net.IPv6len)
+	binary.BigEndian.PutUint64(netIp[:8], hi)
+	binary.BigEndian.PutUint64(netIp[8:], lo)
+	return format(netIp)
+}
+
+// Ip2BigInt 把ipv6地址转换成big.Int，解析失败时返回nil
+func Ip2BigInt(ip string) *big.Int {
+	netIp := Parse(ip)
+	if netIp == nil {
+		return nil
+	}
+	return new(big.Int).SetBytes(netIp)
+}
+
+// BigInt2ip 把big.Int转换成ipv6地址，超出128位时返回空字符串
+func BigInt2ip(i *big.Int) string {
+	if i == nil || i.Sign() < 0 || i.BitLen() > 128 {
+		return ""
+	}
+	netIp := make(net.IP, net.IPv6len)
+	b := i.Bytes()
+	copy(netIp[net.IPv6len-len(b):], b)
+	return format(netIp)
+}
+
+// ParseAddress 把连起来的ip端口转换成分开的ip和端口
+// Eg: [2001:db8::1]:80 -> 2001:db8::1, 80
+func ParseAddress(address string) (string, int) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || Parse(host) == nil {
+		return "", 0
+	}
+	i, err := strconv.Atoi(port)
+	if err != nil {
+		return "", 0
+	}
+	return host, i
+}
+
+// 按照RFC 5952格式化16字节的ip地址
+func format(ip net.IP) string {
+	ip = ip.To16()
+	// ipv4映射地址需要保留::ffff:前缀，标准库会直接返回ipv4格式
+	if ip.To4() != nil {
+		return "::ffff:" + ip.To4().String()
+	}
+	return ip.String()
+}
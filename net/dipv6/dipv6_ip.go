@@ -0,0 +1,153 @@
+package dipv6
+
+import (
+	"errors"
+	"net"
+	"strings"
+)
+
+var (
+	ulaNet       = mustParseCIDR("fc00::/7")  // 唯一本地地址
+	linkLocalNet = mustParseCIDR("fe80::/10") // 链路本地地址
+)
+
+// IsLoopback 判断ip地址是否是ipv6的环回地址::1
+func IsLoopback(ip string) bool {
+	netIp := Parse(ip)
+	return netIp != nil && netIp.To4() == nil && netIp.IsLoopback()
+}
+
+// IsLinkLocal 判断ip地址是否是链路本地地址fe80::/10
+func IsLinkLocal(ip string) bool {
+	netIp := Parse(ip)
+	return netIp != nil && linkLocalNet.Contains(netIp)
+}
+
+// IsULA 判断ip地址是否是唯一本地地址fc00::/7
+func IsULA(ip string) bool {
+	netIp := Parse(ip)
+	return netIp != nil && ulaNet.Contains(netIp)
+}
+
+// IsPrivate ULA的别名，唯一本地地址是ipv6的私有地址
+func IsPrivate(ip string) bool {
+	return IsULA(ip)
+}
+
+// IsIntranet 判断ip地址是否是内网ip
+//
+// Loopback: ::1
+// ULA: fc00::/7
+// LinkLocal: fe80::/10
+func IsIntranet(ip string) bool {
+	return IsLoopback(ip) || IsULA(ip) || IsLinkLocal(ip)
+}
+
+// GetIpArray 获取当前网卡的ipv6地址列表
+func GetIpArray() (ips []string, err error) {
+	interfaceAddr, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, err
+	}
+	for _, address := range interfaceAddr {
+		ipNet, isValidIpNet := address.(*net.IPNet)
+		if isValidIpNet && !ipNet.IP.IsLoopback() {
+			if ipNet.IP.To4() == nil && ipNet.IP.To16() != nil {
+				ips = append(ips, ipNet.IP.String())
+			}
+		}
+	}
+	return ips, nil
+}
+
+// GetInterfaceIpMap 获取所有启用的网卡上的ipv6地址，键为网卡名称
+func GetInterfaceIpMap() (map[string][]string, error) {
+	interFaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	ipMap := make(map[string][]string)
+	for _, interFace := range interFaces {
+		if interFace.Flags&net.FlagUp == 0 {
+			continue
+		}
+		addresses, err := interFace.Addrs()
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addresses {
+			if ip := addrIp(addr); ip != nil && ip.To4() == nil {
+				ipMap[interFace.Name] = append(ipMap[interFace.Name], ip.String())
+			}
+		}
+	}
+	return ipMap, nil
+}
+
+// GetIntranetIp 获取当前计算机上的ipv6内网地址
+func GetIntranetIp() (ip string, err error) {
+	ips, err := GetIntranetIpArray()
+	if err != nil {
+		return "", err
+	}
+	if len(ips) == 0 {
+		return "", errors.New("no intranet ipv6 found")
+	}
+	return ips[0], nil
+}
+
+// GetIntranetIpArray 返回当前计算机上所有ipv6内网地址的列表，不包括环回地址
+func GetIntranetIpArray() (ips []string, err error) {
+	interFaces, e := net.Interfaces()
+	if e != nil {
+		return ips, e
+	}
+	for _, interFace := range interFaces {
+		if interFace.Flags&net.FlagUp == 0 {
+			// interface down
+			continue
+		}
+		if interFace.Flags&net.FlagLoopback != 0 {
+			// loopback interface
+			continue
+		}
+		// ignore warden bridge
+		if strings.HasPrefix(interFace.Name, "w-") {
+			continue
+		}
+		addresses, e := interFace.Addrs()
+		if e != nil {
+			return ips, e
+		}
+		for _, addr := range addresses {
+			ip := addrIp(addr)
+			if ip == nil || ip.IsLoopback() || ip.To4() != nil {
+				continue
+			}
+			ipStr := ip.String()
+			if IsIntranet(ipStr) {
+				ips = append(ips, ipStr)
+			}
+		}
+	}
+	return ips, nil
+}
+
+// 获取网卡地址中的ip
+func addrIp(addr net.Addr) net.IP {
+	switch v := addr.(type) {
+	case *net.IPNet:
+		return v.IP
+	case *net.IPAddr:
+		return v.IP
+	}
+	return nil
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return ipNet
+}
@@ -0,0 +1,59 @@
+package dipv6_test
+
+import (
+	"fmt"
+	"github.com/osgochina/donkeygo/net/dipv6"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"math/big"
+	"testing"
+)
+
+func TestIP(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		t.Assert(dipv6.Validate("2001:db8::1"), true)
+		t.Assert(dipv6.Validate("192.168.1.1"), false)
+		t.Assert(dipv6.Parse("192.168.1.1"), nil)
+		t.AssertNE(dipv6.Parse("fe80::1%eth0"), nil)
+
+		t.Assert(dipv6.Canonical("2001:0DB8:0000:0000:0000:0000:0000:0001"), "2001:db8::1")
+		t.Assert(dipv6.Canonical("2001:db8:0:0:1:0:0:1"), "2001:db8::1:0:0:1")
+		t.Assert(dipv6.Canonical("::FFFF:192.168.1.1"), "::ffff:192.168.1.1")
+		t.Assert(dipv6.Canonical("bad"), "")
+		t.Assert(dipv6.Expand("2001:db8::1"), "2001:0db8:0000:0000:0000:0000:0000:0001")
+
+		hi, lo := dipv6.Ip2long("2001:db8::1")
+		t.Assert(hi, uint64(0x20010db800000000))
+		t.Assert(lo, uint64(1))
+		t.Assert(dipv6.Long2ip(hi, lo), "2001:db8::1")
+
+		i := dipv6.Ip2BigInt("::ff")
+		t.Assert(i.Int64(), 255)
+		t.Assert(dipv6.BigInt2ip(big.NewInt(256)), "::100")
+		t.Assert(dipv6.BigInt2ip(new(big.Int).Lsh(big.NewInt(1), 128)), "")
+
+		host, port := dipv6.ParseAddress("[2001:db8::1]:80")
+		t.Assert(host, "2001:db8::1")
+		t.Assert(port, 80)
+		host, port = dipv6.ParseAddress("192.168.1.1:80")
+		t.Assert(host, "")
+		t.Assert(port, 0)
+	})
+}
+
+func TestIntranet(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		t.Assert(dipv6.IsLoopback("::1"), true)
+		t.Assert(dipv6.IsLoopback("127.0.0.1"), false)
+		t.Assert(dipv6.IsLinkLocal("fe80::1"), true)
+		t.Assert(dipv6.IsLinkLocal("fec0::1"), false)
+		t.Assert(dipv6.IsULA("fd00::1"), true)
+		t.Assert(dipv6.IsPrivate("fc12::1"), true)
+		t.Assert(dipv6.IsULA("2001:db8::1"), false)
+		t.Assert(dipv6.IsIntranet("::1"), true)
+		t.Assert(dipv6.IsIntranet("2001:db8::1"), false)
+
+		fmt.Println(dipv6.GetIpArray())
+		fmt.Println(dipv6.GetInterfaceIpMap())
+		fmt.Println(dipv6.GetIntranetIpArray())
+	})
+}
@@ -0,0 +1,195 @@
+package dtcp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// 消息分帧的模式
+const (
+	PkgModeLength    = iota // 数据前加上固定字节数的长度头
+	PkgModeDelimiter        // 数据以分隔符结尾
+	PkgModeFixed            // 每个消息都是固定的长度
+)
+
+const (
+	pkgHeaderSizeDefault  = 2                // 默认的长度头字节数
+	pkgHeaderSizeMax      = 4                // 长度头的最大字节数
+	pkgMaxDataSizeDefault = 64 * 1024 * 1024 // 默认的最大消息长度
+	pkgDelimiterDefault   = "\n"             // 默认的分隔符
+)
+
+// PkgOption 消息分帧的选项
+type PkgOption struct {
+	Mode        int    // 分帧模式，默认为PkgModeLength
+	HeaderSize  int    // 长度头的字节数，可选1/2/3/4，默认为2
+	MaxDataSize int    // 消息的最大长度，默认为64MB，不能超过长度头能表示的最大值
+	Delimiter   []byte // 分隔符，默认为\n
+	FixedSize   int    // 固定长度模式下每个消息的长度
+	Retry       Retry  // 读写失败时的重试配置
+}
+
+// SendPkg 按照分帧模式发送一个消息
+func (that *Conn) SendPkg(data []byte, option ...PkgOption) error {
+	pkgOption, err := getPkgOption(option...)
+	if err != nil {
+		return err
+	}
+	if len(data) > pkgOption.MaxDataSize {
+		return errors.New(fmt.Sprintf(`data size %d exceeds max pkg size %d`, len(data), pkgOption.MaxDataSize))
+	}
+	var buffer []byte
+	switch pkgOption.Mode {
+	case PkgModeDelimiter:
+		if bytes.Contains(data, pkgOption.Delimiter) {
+			return errors.New(fmt.Sprintf(`data contains the delimiter "%s"`, pkgOption.Delimiter))
+		}
+		buffer = make([]byte, 0, len(data)+len(pkgOption.Delimiter))
+		buffer = append(buffer, data...)
+		buffer = append(buffer, pkgOption.Delimiter...)
+	case PkgModeFixed:
+		// 不足固定长度时使用0补齐
+		buffer = make([]byte, pkgOption.FixedSize)
+		copy(buffer, data)
+	default:
+		length := make([]byte, 4)
+		binary.BigEndian.PutUint32(length, uint32(len(data)))
+		buffer = make([]byte, 0, pkgOption.HeaderSize+len(data))
+		buffer = append(buffer, length[4-pkgOption.HeaderSize:]...)
+		buffer = append(buffer, data...)
+	}
+	if pkgOption.Retry.Count > 0 {
+		return that.Send(buffer, pkgOption.Retry)
+	}
+	return that.Send(buffer)
+}
+
+// SendPkgWithTimeout 在指定的时间内按照分帧模式发送一个消息
+func (that *Conn) SendPkgWithTimeout(data []byte, timeout time.Duration, option ...PkgOption) (err error) {
+	if err = that.SetSendDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	defer that.SetSendDeadline(time.Time{})
+	return that.SendPkg(data, option...)
+}
+
+// RecvPkg 按照分帧模式读取一个消息
+func (that *Conn) RecvPkg(option ...PkgOption) ([]byte, error) {
+	pkgOption, err := getPkgOption(option...)
+	if err != nil {
+		return nil, err
+	}
+	switch pkgOption.Mode {
+	case PkgModeDelimiter:
+		return that.recvPkgDelimiter(pkgOption)
+	case PkgModeFixed:
+		return that.recvFull(pkgOption.FixedSize)
+	default:
+		header, err := that.recvFull(pkgOption.HeaderSize)
+		if err != nil {
+			return nil, err
+		}
+		length := make([]byte, 4)
+		copy(length[4-pkgOption.HeaderSize:], header)
+		size := int(binary.BigEndian.Uint32(length))
+		if size > pkgOption.MaxDataSize {
+			return nil, errors.New(fmt.Sprintf(`pkg size %d exceeds max pkg size %d`, size, pkgOption.MaxDataSize))
+		}
+		if size == 0 {
+			return []byte{}, nil
+		}
+		return that.recvFull(size)
+	}
+}
+
+// RecvPkgWithTimeout 在指定的时间内按照分帧模式读取一个消息
+func (that *Conn) RecvPkgWithTimeout(timeout time.Duration, option ...PkgOption) (data []byte, err error) {
+	if err = that.SetRecvDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	defer that.SetRecvDeadline(time.Time{})
+	return that.RecvPkg(option...)
+}
+
+// SendRecvPkg 按照分帧模式发送一个消息并读取一个回复
+func (that *Conn) SendRecvPkg(data []byte, option ...PkgOption) ([]byte, error) {
+	if err := that.SendPkg(data, option...); err != nil {
+		return nil, err
+	}
+	return that.RecvPkg(option...)
+}
+
+// 读取指定长度的数据
+func (that *Conn) recvFull(length int) ([]byte, error) {
+	buffer := make([]byte, length)
+	n, err := io.ReadFull(that.reader, buffer)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return buffer[:n], err
+}
+
+// 读取到分隔符为止的数据，返回的数据不包含分隔符
+func (that *Conn) recvPkgDelimiter(option PkgOption) ([]byte, error) {
+	var (
+		data      = make([]byte, 0)
+		delimiter = option.Delimiter
+	)
+	for {
+		b, err := that.reader.ReadByte()
+		if err != nil {
+			return data, err
+		}
+		data = append(data, b)
+		if bytes.HasSuffix(data, delimiter) {
+			return data[:len(data)-len(delimiter)], nil
+		}
+		if len(data) > option.MaxDataSize+len(delimiter) {
+			return nil, errors.New(fmt.Sprintf(`pkg size exceeds max pkg size %d`, option.MaxDataSize))
+		}
+	}
+}
+
+// 检查分帧选项并设置默认值
+func getPkgOption(option ...PkgOption) (PkgOption, error) {
+	pkgOption := PkgOption{}
+	if len(option) > 0 {
+		pkgOption = option[0]
+	}
+	switch pkgOption.Mode {
+	case PkgModeLength:
+		if pkgOption.HeaderSize == 0 {
+			pkgOption.HeaderSize = pkgHeaderSizeDefault
+		}
+		if pkgOption.HeaderSize < 0 || pkgOption.HeaderSize > pkgHeaderSizeMax {
+			return pkgOption, errors.New(fmt.Sprintf(`invalid header size %d, should be between 1 and %d`, pkgOption.HeaderSize, pkgHeaderSizeMax))
+		}
+		// 长度头能表示的最大长度
+		maxSize := uint64(1)<<(8*uint(pkgOption.HeaderSize)) - 1
+		if pkgOption.MaxDataSize <= 0 {
+			pkgOption.MaxDataSize = pkgMaxDataSizeDefault
+		}
+		if uint64(pkgOption.MaxDataSize) > maxSize {
+			pkgOption.MaxDataSize = int(maxSize)
+		}
+	case PkgModeDelimiter:
+		if len(pkgOption.Delimiter) == 0 {
+			pkgOption.Delimiter = []byte(pkgDelimiterDefault)
+		}
+		if pkgOption.MaxDataSize <= 0 {
+			pkgOption.MaxDataSize = pkgMaxDataSizeDefault
+		}
+	case PkgModeFixed:
+		if pkgOption.FixedSize <= 0 {
+			return pkgOption, errors.New(fmt.Sprintf(`invalid fixed size %d`, pkgOption.FixedSize))
+		}
+		pkgOption.MaxDataSize = pkgOption.FixedSize
+	default:
+		return pkgOption, errors.New(fmt.Sprintf(`invalid pkg mode %d`, pkgOption.Mode))
+	}
+	return pkgOption, nil
+}
@@ -49,3 +49,23 @@ func isTimeout(err error) bool {
 	}
 	return false
 }
+
+// SendPkg 创建链接并按照分帧模式发送一个消息
+func SendPkg(addr string, data []byte, option ...PkgOption) error {
+	conn, err := NewConn(addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.SendPkg(data, option...)
+}
+
+// SendRecvPkg 创建链接并按照分帧模式发送一个消息，然后读取一个回复
+func SendRecvPkg(addr string, data []byte, option ...PkgOption) ([]byte, error) {
+	conn, err := NewConn(addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.SendRecvPkg(data, option...)
+}
@@ -0,0 +1,241 @@
+package dtcp
+
+import (
+	"errors"
+	"github.com/osgochina/donkeygo/container/dmap"
+	"github.com/osgochina/donkeygo/container/dpool"
+	"github.com/osgochina/donkeygo/container/dtype"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	dDefaultPoolMaxIdle = 10               // 默认的最大空闲链接数
+	dDefaultPoolIdleTTL = 10 * time.Second // 默认的空闲链接过期时间
+)
+
+var (
+	ErrPoolExhausted = errors.New("connection pool exhausted") // 链接数已经达到MaxActive
+	ErrPoolClosed    = errors.New("connection pool is closed") // 链接池已关闭
+)
+
+var (
+	// addressPoolMap 用于地址到它的默认链接池的映射
+	addressPoolMap = dmap.NewStrAnyMap(true)
+)
+
+// PoolOption 链接池的选项
+type PoolOption struct {
+	MaxIdle   int           // 最大空闲链接数，默认为10
+	MaxActive int           // 最大链接数，包括使用中和空闲的链接，为0时不限制
+	IdleTTL   time.Duration // 空闲链接的过期时间，默认为10秒，小于0时链接用完立即关闭
+	Timeout   time.Duration // 建立链接的超时时间，默认为30秒
+}
+
+// PoolStats 链接池的统计信息
+type PoolStats struct {
+	Total     int64 // 当前的链接数，包括使用中和空闲的链接
+	Idle      int   // 空闲的链接数
+	Hits      int64 // 从池中获取到可用链接的次数
+	Misses    int64 // 池中没有可用链接需要新建链接的次数
+	Created   int64 // 新建的链接数
+	Discarded int64 // 因为出错、探活失败、过期或者空闲链接过多被关闭的链接数
+}
+
+// ConnPool tcp链接池，同一个地址的链接复用，获取链接时会检查链接是否可用
+type ConnPool struct {
+	addr      string
+	option    PoolOption
+	mu        sync.Mutex   // 保证检查空闲链接数和放回链接是原子的
+	pool      *dpool.Pool  // 空闲的链接
+	closed    *dtype.Bool  // 链接池是否已关闭
+	total     *dtype.Int64 // 当前的链接数
+	hits      *dtype.Int64
+	misses    *dtype.Int64
+	created   *dtype.Int64
+	discarded *dtype.Int64
+}
+
+// PoolConn 从链接池中获取的tcp链接，Close时放回链接池，读写出错的链接不会再放回链接池，
+// 每次Get都返回新的PoolConn，重复Close不会把链接重复放回链接池
+type PoolConn struct {
+	*Conn
+	pool   *ConnPool
+	broken *dtype.Bool // 链接是否出现过读写错误
+	closed *dtype.Bool // 是否已经放回链接池
+}
+
+// 记录读写错误的net.Conn，超时错误不影响链接的复用
+type poolNetConn struct {
+	net.Conn
+	broken *dtype.Bool
+}
+
+// NewConnPool 创建一个链接到<addr>的链接池
+func NewConnPool(addr string, option ...PoolOption) *ConnPool {
+	p := &ConnPool{
+		addr:      addr,
+		closed:    dtype.NewBool(),
+		total:     dtype.NewInt64(),
+		hits:      dtype.NewInt64(),
+		misses:    dtype.NewInt64(),
+		created:   dtype.NewInt64(),
+		discarded: dtype.NewInt64(),
+	}
+	if len(option) > 0 {
+		p.option = option[0]
+	}
+	if p.option.MaxIdle <= 0 {
+		p.option.MaxIdle = dDefaultPoolMaxIdle
+	}
+	if p.option.IdleTTL == 0 {
+		p.option.IdleTTL = dDefaultPoolIdleTTL
+	}
+	if p.option.Timeout <= 0 {
+		p.option.Timeout = dDefaultConnTimeout
+	}
+	p.pool = dpool.New(p.option.IdleTTL, nil, func(v interface{}) {
+		p.discard(v.(*PoolConn))
+	})
+	return p
+}
+
+// NewPoolConn 从<addr>的默认链接池中获取一个链接
+func NewPoolConn(addr string, timeout ...time.Duration) (*PoolConn, error) {
+	return GetConnPool(addr, timeout...).Get()
+}
+
+// GetConnPool 获取<addr>的默认链接池，不存在时创建
+func GetConnPool(addr string, timeout ...time.Duration) *ConnPool {
+	return addressPoolMap.GetOrSetFuncLock(addr, func() interface{} {
+		option := PoolOption{}
+		if len(timeout) > 0 {
+			option.Timeout = timeout[0]
+		}
+		return NewConnPool(addr, option)
+	}).(*ConnPool)
+}
+
+// Get 获取一个链接，优先复用可用的空闲链接，没有可用的空闲链接时新建链接，
+// 链接数达到MaxActive时返回ErrPoolExhausted
+func (that *ConnPool) Get() (*PoolConn, error) {
+	if that.closed.Val() {
+		return nil, ErrPoolClosed
+	}
+	for {
+		v, err := that.pool.Get()
+		if err != nil {
+			break
+		}
+		conn := v.(*PoolConn)
+		if conn.probe() == nil {
+			that.hits.Add(1)
+			return &PoolConn{Conn: conn.Conn, pool: that, broken: conn.broken, closed: dtype.NewBool()}, nil
+		}
+		that.discard(conn)
+	}
+	that.misses.Add(1)
+	if that.option.MaxActive > 0 && that.total.Add(1) > int64(that.option.MaxActive) {
+		that.total.Add(-1)
+		return nil, ErrPoolExhausted
+	} else if that.option.MaxActive <= 0 {
+		that.total.Add(1)
+	}
+	netConn, err := NewNetConn(that.addr, that.option.Timeout)
+	if err != nil {
+		that.total.Add(-1)
+		return nil, err
+	}
+	that.created.Add(1)
+	broken := dtype.NewBool()
+	return &PoolConn{
+		Conn:   NewConnByNetConn(&poolNetConn{Conn: netConn, broken: broken}),
+		pool:   that,
+		broken: broken,
+		closed: dtype.NewBool(),
+	}, nil
+}
+
+// 把链接放回链接池，链接出错或者空闲链接过多时关闭链接
+func (that *ConnPool) put(conn *PoolConn) error {
+	that.mu.Lock()
+	if conn.broken.Val() || that.closed.Val() || that.pool.Size() >= that.option.MaxIdle {
+		that.mu.Unlock()
+		return that.discard(conn)
+	}
+	err := that.pool.Put(conn)
+	that.mu.Unlock()
+	if err != nil {
+		return that.discard(conn)
+	}
+	return nil
+}
+
+// 关闭链接
+func (that *ConnPool) discard(conn *PoolConn) error {
+	that.total.Add(-1)
+	that.discarded.Add(1)
+	return conn.Conn.Close()
+}
+
+// Stats 获取链接池的统计信息
+func (that *ConnPool) Stats() PoolStats {
+	return PoolStats{
+		Total:     that.total.Val(),
+		Idle:      that.pool.Size(),
+		Hits:      that.hits.Val(),
+		Misses:    that.misses.Val(),
+		Created:   that.created.Val(),
+		Discarded: that.discarded.Val(),
+	}
+}
+
+// Close 关闭链接池和所有空闲的链接，使用中的链接在Close时关闭
+func (that *ConnPool) Close() {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if !that.closed.Cas(false, true) {
+		return
+	}
+	that.pool.Clear()
+	that.pool.Close()
+}
+
+// Close 把链接放回链接池，重复调用时不做任何操作
+func (that *PoolConn) Close() error {
+	if !that.closed.Cas(false, true) {
+		return nil
+	}
+	return that.pool.put(that)
+}
+
+// 检查空闲链接是否可用，链接已经被对端关闭或者有未读取的数据时返回错误
+func (that *PoolConn) probe() error {
+	if that.broken.Val() {
+		return errors.New("connection is broken")
+	}
+	if that.reader.Buffered() > 0 {
+		return errors.New("unexpected data on idle connection")
+	}
+	if conn, ok := that.Conn.Conn.(*poolNetConn); ok {
+		return probeNetConn(conn.Conn)
+	}
+	return nil
+}
+
+func (that *poolNetConn) Read(b []byte) (int, error) {
+	n, err := that.Conn.Read(b)
+	if err != nil && !isTimeout(err) {
+		that.broken.Set(true)
+	}
+	return n, err
+}
+
+func (that *poolNetConn) Write(b []byte) (int, error) {
+	n, err := that.Conn.Write(b)
+	if err != nil && !isTimeout(err) {
+		that.broken.Set(true)
+	}
+	return n, err
+}
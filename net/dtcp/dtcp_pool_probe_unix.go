@@ -0,0 +1,46 @@
+//go:build !windows
+// +build !windows
+
+package dtcp
+
+import (
+	"errors"
+	"io"
+	"net"
+	"syscall"
+)
+
+// 不阻塞地检查链接是否已经被对端关闭或者有未读取的数据
+func probeNetConn(conn net.Conn) error {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return nil
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var (
+		n       int
+		buf     = make([]byte, 1)
+		readErr error
+	)
+	// 链接的文件描述符是非阻塞的，没有数据时直接返回EAGAIN
+	err = raw.Read(func(fd uintptr) bool {
+		n, _, readErr = syscall.Recvfrom(int(fd), buf, syscall.MSG_PEEK)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	switch {
+	case readErr == syscall.EAGAIN || readErr == syscall.EWOULDBLOCK:
+		return nil
+	case readErr != nil:
+		return readErr
+	case n == 0:
+		return io.EOF
+	default:
+		return errors.New("unexpected data on idle connection")
+	}
+}
@@ -0,0 +1,11 @@
+//go:build windows
+// +build windows
+
+package dtcp
+
+import "net"
+
+// windows下不探测链接状态，链接失效时在第一次读写出错后丢弃
+func probeNetConn(conn net.Conn) error {
+	return nil
+}
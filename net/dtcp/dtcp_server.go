@@ -0,0 +1,264 @@
+package dtcp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/osgochina/donkeygo/container/dmap"
+	"github.com/osgochina/donkeygo/container/dtype"
+	"github.com/osgochina/donkeygo/net/inherit"
+	"github.com/osgochina/donkeygo/os/dlog"
+	"github.com/osgochina/donkeygo/util/dconv"
+	"net"
+	"sync"
+)
+
+const (
+	dDefaultServer = "default"
+)
+
+// Server TCP服务器
+type Server struct {
+	mu        sync.Mutex
+	listen    net.Listener       // 监听句柄
+	address   string             // 监听的地址
+	handler   func(*Conn)        // 每个链接的处理函数
+	tlsConfig *tls.Config        // TLS配置，为空时不加密
+	maxConns  int                // 最大链接数，为0时不限制
+	conns     map[*Conn]struct{} // 当前活跃的链接
+	wg        sync.WaitGroup     // 等待所有链接的处理函数结束
+	closed    *dtype.Bool        // 服务是否已关闭
+}
+
+var (
+	// serverMapping 用于实例名到它的TCP服务器映射。
+	serverMapping = dmap.NewStrAnyMap(true)
+)
+
+// GetServer 获取一个TCP服务器对象
+func GetServer(name ...interface{}) *Server {
+	serverName := dDefaultServer
+	if len(name) > 0 && name[0] != "" {
+		serverName = dconv.String(name[0])
+	}
+	if s := serverMapping.Get(serverName); s != nil {
+		return s.(*Server)
+	}
+	s := NewServer("", nil)
+	serverMapping.Set(serverName, s)
+	return s
+}
+
+// NewServer 创建一个TCP服务器对象
+func NewServer(address string, handler func(*Conn), name ...string) *Server {
+	s := &Server{
+		address: address,
+		handler: handler,
+		conns:   make(map[*Conn]struct{}),
+		closed:  dtype.NewBool(),
+	}
+	if len(name) > 0 && name[0] != "" {
+		serverMapping.Set(name[0], s)
+	}
+	return s
+}
+
+// NewServerTLS 创建一个TLS加密的TCP服务器对象
+func NewServerTLS(address string, tlsConfig *tls.Config, handler func(*Conn), name ...string) *Server {
+	s := NewServer(address, handler, name...)
+	s.SetTLSConfig(tlsConfig)
+	return s
+}
+
+// NewServerKeyCrt 通过证书文件创建一个TLS加密的TCP服务器对象
+func NewServerKeyCrt(address, crtFile, keyFile string, handler func(*Conn), name ...string) (*Server, error) {
+	s := NewServer(address, handler, name...)
+	if err := s.SetTLSKeyCrt(crtFile, keyFile); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetAddress 设置要监听的本地地址
+func (that *Server) SetAddress(address string) {
+	that.address = address
+}
+
+// SetHandler 设置每个链接的处理函数，处理函数返回后链接会被关闭
+func (that *Server) SetHandler(handler func(*Conn)) {
+	that.handler = handler
+}
+
+// SetTLSConfig 设置TLS配置
+func (that *Server) SetTLSConfig(tlsConfig *tls.Config) {
+	that.tlsConfig = tlsConfig
+}
+
+// SetTLSKeyCrt 通过证书文件设置TLS配置
+func (that *Server) SetTLSKeyCrt(crtFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(crtFile, keyFile)
+	if err != nil {
+		return err
+	}
+	that.tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	return nil
+}
+
+// SetMaxConns 设置最大链接数，超过后新的链接会被直接关闭，为0时不限制
+func (that *Server) SetMaxConns(max int) {
+	that.mu.Lock()
+	that.maxConns = max
+	that.mu.Unlock()
+}
+
+// GetConnCount 获取当前的链接数
+func (that *Server) GetConnCount() int {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	return len(that.conns)
+}
+
+// GetListenedAddress 获取实际监听的地址，没有启动时返回空字符串
+func (that *Server) GetListenedAddress() string {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if that.listen == nil {
+		return ""
+	}
+	return that.listen.Addr().String()
+}
+
+// GetListenedPort 获取实际监听的端口，没有启动时返回-1
+func (that *Server) GetListenedPort() int {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if that.listen == nil {
+		return -1
+	}
+	if addr, ok := that.listen.Addr().(*net.TCPAddr); ok {
+		return addr.Port
+	}
+	return -1
+}
+
+// Run 启动该TCP服务器，阻塞直到服务关闭，
+// 监听句柄通过inherit创建，平滑重启时子进程会继承父进程的监听句柄
+func (that *Server) Run() error {
+	if that.handler == nil {
+		err := errors.New("start running failed: socket handler not defined")
+		dlog.Error(err)
+		return err
+	}
+	listen, err := inherit.Listen("tcp", that.address)
+	if err != nil {
+		dlog.Error(err)
+		return err
+	}
+	if that.tlsConfig != nil {
+		listen = tls.NewListener(listen, that.tlsConfig)
+	}
+	that.mu.Lock()
+	if that.closed.Val() {
+		that.mu.Unlock()
+		_ = listen.Close()
+		return errors.New(fmt.Sprintf(`server "%s" is closed`, that.address))
+	}
+	that.listen = listen
+	that.mu.Unlock()
+	for {
+		conn, err := listen.Accept()
+		if err != nil {
+			if that.closed.Val() {
+				return nil
+			}
+			// 临时错误时继续接收新的链接
+			if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+				dlog.Error(err)
+				continue
+			}
+			dlog.Error(err)
+			return err
+		}
+		c := NewConnByNetConn(conn)
+		if !that.addConn(c) {
+			if !that.closed.Val() {
+				dlog.Warningf(`too many connections, reject connection from %s`, conn.RemoteAddr().String())
+			}
+			_ = conn.Close()
+			continue
+		}
+		go that.serve(c)
+	}
+}
+
+// 记录新的链接，超过最大链接数或者服务已关闭时返回false
+func (that *Server) addConn(conn *Conn) bool {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if that.closed.Val() || (that.maxConns > 0 && len(that.conns) >= that.maxConns) {
+		return false
+	}
+	that.conns[conn] = struct{}{}
+	that.wg.Add(1)
+	return true
+}
+
+// 调用处理函数处理链接，处理函数返回后关闭链接
+func (that *Server) serve(conn *Conn) {
+	defer func() {
+		if e := recover(); e != nil {
+			dlog.Errorf(`tcp handler panic: %v`, e)
+		}
+		_ = conn.Close()
+		that.mu.Lock()
+		delete(that.conns, conn)
+		that.mu.Unlock()
+		that.wg.Done()
+	}()
+	that.handler(conn)
+}
+
+// Close 关闭该TCP服务，停止接收新的链接并关闭所有活跃的链接
+func (that *Server) Close() error {
+	err := that.closeListener()
+	that.closeConns()
+	return err
+}
+
+// Shutdown 平滑关闭该TCP服务，停止接收新的链接后等待所有链接的处理函数结束，
+// <ctx>结束时关闭剩余的链接
+func (that *Server) Shutdown(ctx context.Context) error {
+	err := that.closeListener()
+	done := make(chan struct{})
+	go func() {
+		that.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		that.closeConns()
+		return ctx.Err()
+	}
+}
+
+// 关闭监听句柄
+func (that *Server) closeListener() error {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if !that.closed.Cas(false, true) || that.listen == nil {
+		return nil
+	}
+	return that.listen.Close()
+}
+
+// 关闭所有活跃的链接
+func (that *Server) closeConns() {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	for conn := range that.conns {
+		_ = conn.Close()
+	}
+}
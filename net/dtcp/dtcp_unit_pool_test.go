@@ -0,0 +1,156 @@
+package dtcp_test
+
+import (
+	"github.com/osgochina/donkeygo/net/dtcp"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"testing"
+	"time"
+)
+
+func Test_Pool_Reuse(t *testing.T) {
+	s := echoPkgServer()
+	addr := startServer(s)
+	defer s.Close()
+	dtest.C(t, func(t *dtest.T) {
+		pool := dtcp.NewConnPool(addr)
+		defer pool.Close()
+		for i := 0; i < 5; i++ {
+			conn, err := pool.Get()
+			t.Assert(err, nil)
+			result, err := conn.SendRecvPkg([]byte("ping"))
+			t.Assert(err, nil)
+			t.Assert(string(result), "> ping")
+			t.Assert(conn.Close(), nil)
+		}
+		stats := pool.Stats()
+		t.Assert(stats.Created, 1)
+		t.Assert(stats.Misses, 1)
+		t.Assert(stats.Hits, 4)
+		t.Assert(stats.Total, 1)
+		t.Assert(stats.Idle, 1)
+	})
+	dtest.C(t, func(t *dtest.T) {
+		conn, err := dtcp.NewPoolConn(addr)
+		t.Assert(err, nil)
+		t.Assert(conn.Close(), nil)
+		t.Assert(dtcp.GetConnPool(addr).Stats().Idle, 1)
+	})
+}
+
+func Test_Pool_Limit(t *testing.T) {
+	s := echoPkgServer()
+	addr := startServer(s)
+	defer s.Close()
+	dtest.C(t, func(t *dtest.T) {
+		pool := dtcp.NewConnPool(addr, dtcp.PoolOption{MaxIdle: 1, MaxActive: 2})
+		defer pool.Close()
+		conn1, err := pool.Get()
+		t.Assert(err, nil)
+		conn2, err := pool.Get()
+		t.Assert(err, nil)
+		_, err = pool.Get()
+		t.Assert(err, dtcp.ErrPoolExhausted)
+
+		// 超过最大空闲链接数的链接被关闭
+		t.Assert(conn1.Close(), nil)
+		t.Assert(conn2.Close(), nil)
+		stats := pool.Stats()
+		t.Assert(stats.Idle, 1)
+		t.Assert(stats.Total, 1)
+		t.Assert(stats.Discarded, 1)
+	})
+}
+
+func Test_Pool_Discard(t *testing.T) {
+	s := dtcp.NewServer("127.0.0.1:0", func(conn *dtcp.Conn) {
+		data, err := conn.RecvPkg()
+		if err != nil {
+			return
+		}
+		_ = conn.SendPkg(data)
+		// 处理完一个请求后关闭链接
+	})
+	addr := startServer(s)
+	defer s.Close()
+	dtest.C(t, func(t *dtest.T) {
+		pool := dtcp.NewConnPool(addr)
+		defer pool.Close()
+		conn, err := pool.Get()
+		t.Assert(err, nil)
+		result, err := conn.SendRecvPkg([]byte("1"))
+		t.Assert(err, nil)
+		t.Assert(string(result), "1")
+		t.Assert(conn.Close(), nil)
+		time.Sleep(50 * time.Millisecond)
+
+		// 对端已关闭的空闲链接在获取时被探测并丢弃
+		conn, err = pool.Get()
+		t.Assert(err, nil)
+		t.Assert(pool.Stats().Discarded, 1)
+		t.Assert(pool.Stats().Created, 2)
+
+		// 读写出错的链接不再放回链接池
+		result, err = conn.SendRecvPkg([]byte("2"))
+		t.Assert(err, nil)
+		t.Assert(string(result), "2")
+		_, err = conn.Recv(1)
+		t.AssertNE(err, nil)
+		t.Assert(conn.Close(), nil)
+		t.Assert(pool.Stats().Idle, 0)
+		t.Assert(pool.Stats().Total, 0)
+	})
+}
+
+func Test_Pool_IdleTTL(t *testing.T) {
+	s := echoPkgServer()
+	addr := startServer(s)
+	defer s.Close()
+	dtest.C(t, func(t *dtest.T) {
+		pool := dtcp.NewConnPool(addr, dtcp.PoolOption{IdleTTL: 100 * time.Millisecond})
+		defer pool.Close()
+		conn, err := pool.Get()
+		t.Assert(err, nil)
+		t.Assert(conn.Close(), nil)
+		time.Sleep(200 * time.Millisecond)
+		conn, err = pool.Get()
+		t.Assert(err, nil)
+		t.Assert(conn.Close(), nil)
+		stats := pool.Stats()
+		t.Assert(stats.Created, 2)
+		t.Assert(stats.Discarded, 1)
+	})
+}
+
+func Test_Pool_DoubleClose(t *testing.T) {
+	s := echoPkgServer()
+	addr := startServer(s)
+	defer s.Close()
+	dtest.C(t, func(t *dtest.T) {
+		pool := dtcp.NewConnPool(addr)
+		defer pool.Close()
+		conn1, err := pool.Get()
+		t.Assert(err, nil)
+		t.Assert(conn1.Close(), nil)
+		t.Assert(conn1.Close(), nil)
+		t.Assert(pool.Stats().Idle, 1)
+
+		// 重复Close没有把链接重复放回链接池，两次获取的不是同一个链接
+		conn2, err := pool.Get()
+		t.Assert(err, nil)
+		conn3, err := pool.Get()
+		t.Assert(err, nil)
+		t.Assert(pool.Stats().Created, 2)
+		// 已经放回过的旧链接对象再次Close不影响复用后的链接
+		t.Assert(conn1.Close(), nil)
+		t.Assert(pool.Stats().Idle, 0)
+		result, err := conn2.SendRecvPkg([]byte("2"))
+		t.Assert(err, nil)
+		t.Assert(string(result), "> 2")
+		result, err = conn3.SendRecvPkg([]byte("3"))
+		t.Assert(err, nil)
+		t.Assert(string(result), "> 3")
+		t.Assert(conn2.Close(), nil)
+		t.Assert(conn3.Close(), nil)
+		t.Assert(pool.Stats().Idle, 2)
+	})
+}
@@ -0,0 +1,152 @@
+package dtcp_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/osgochina/donkeygo/net/dtcp"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"testing"
+	"time"
+)
+
+// 启动服务并等待监听成功，返回监听的地址
+func startServer(s *dtcp.Server) string {
+	go s.Run()
+	for i := 0; i < 100 && s.GetListenedPort() <= 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return fmt.Sprintf("127.0.0.1:%d", s.GetListenedPort())
+}
+
+func echoPkgServer(option ...dtcp.PkgOption) *dtcp.Server {
+	return dtcp.NewServer("127.0.0.1:0", func(conn *dtcp.Conn) {
+		for {
+			data, err := conn.RecvPkg(option...)
+			if err != nil {
+				return
+			}
+			if err = conn.SendPkg(append([]byte("> "), data...), option...); err != nil {
+				return
+			}
+		}
+	})
+}
+
+func Test_Server_Pkg(t *testing.T) {
+	options := []dtcp.PkgOption{
+		{},
+		{HeaderSize: 1},
+		{HeaderSize: 4},
+		{Mode: dtcp.PkgModeDelimiter},
+		{Mode: dtcp.PkgModeDelimiter, Delimiter: []byte("\r\n")},
+	}
+	for _, option := range options {
+		s := echoPkgServer(option)
+		addr := startServer(s)
+		dtest.C(t, func(t *dtest.T) {
+			conn, err := dtcp.NewConn(addr)
+			t.Assert(err, nil)
+			defer conn.Close()
+			for i := 0; i < 10; i++ {
+				result, err := conn.SendRecvPkg([]byte(fmt.Sprintf("hello %d", i)), option)
+				t.Assert(err, nil)
+				t.Assert(string(result), fmt.Sprintf("> hello %d", i))
+			}
+			result, err := conn.SendRecvPkg([]byte{}, option)
+			t.Assert(err, nil)
+			t.Assert(string(result), "> ")
+			t.Assert(s.Close(), nil)
+		})
+	}
+}
+
+func Test_Server_PkgFixed(t *testing.T) {
+	option := dtcp.PkgOption{Mode: dtcp.PkgModeFixed, FixedSize: 8}
+	s := dtcp.NewServer("127.0.0.1:0", func(conn *dtcp.Conn) {
+		data, err := conn.RecvPkg(option)
+		if err == nil {
+			_ = conn.SendPkg(data[:4], option)
+		}
+	})
+	addr := startServer(s)
+	defer s.Close()
+	dtest.C(t, func(t *dtest.T) {
+		result, err := dtcp.SendRecvPkg(addr, []byte("12345678"), option)
+		t.Assert(err, nil)
+		t.Assert(result, []byte{'1', '2', '3', '4', 0, 0, 0, 0})
+
+		conn, err := dtcp.NewConn(addr)
+		t.Assert(err, nil)
+		defer conn.Close()
+		t.AssertNE(conn.SendPkg([]byte("123456789"), option), nil)
+	})
+}
+
+func Test_Server_PkgLimit(t *testing.T) {
+	s := echoPkgServer()
+	addr := startServer(s)
+	defer s.Close()
+	dtest.C(t, func(t *dtest.T) {
+		conn, err := dtcp.NewConn(addr)
+		t.Assert(err, nil)
+		defer conn.Close()
+		t.AssertNE(conn.SendPkg(make([]byte, 256), dtcp.PkgOption{HeaderSize: 1}), nil)
+		t.AssertNE(conn.SendPkg([]byte("a\nb"), dtcp.PkgOption{Mode: dtcp.PkgModeDelimiter}), nil)
+		t.AssertNE(conn.SendPkg([]byte("a"), dtcp.PkgOption{HeaderSize: 5}), nil)
+		// 对端发送的消息超过最大长度
+		t.Assert(conn.SendPkg(make([]byte, 100)), nil)
+		_, err = conn.RecvPkg(dtcp.PkgOption{MaxDataSize: 10})
+		t.AssertNE(err, nil)
+	})
+}
+
+func Test_Server_MaxConns(t *testing.T) {
+	s := echoPkgServer()
+	s.SetMaxConns(1)
+	addr := startServer(s)
+	defer s.Close()
+	dtest.C(t, func(t *dtest.T) {
+		conn1, err := dtcp.NewConn(addr)
+		t.Assert(err, nil)
+		defer conn1.Close()
+		result, err := conn1.SendRecvPkg([]byte("1"))
+		t.Assert(err, nil)
+		t.Assert(string(result), "> 1")
+		t.Assert(s.GetConnCount(), 1)
+
+		// 超过最大链接数的链接会被关闭
+		conn2, err := dtcp.NewConn(addr)
+		t.Assert(err, nil)
+		defer conn2.Close()
+		_, err = conn2.SendRecvPkg([]byte("2"))
+		t.AssertNE(err, nil)
+	})
+}
+
+func Test_Server_Shutdown(t *testing.T) {
+	s := dtcp.NewServer("127.0.0.1:0", func(conn *dtcp.Conn) {
+		data, err := conn.RecvPkg()
+		if err != nil {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+		_ = conn.SendPkg(data)
+	}, "shutdown")
+	addr := startServer(s)
+	dtest.C(t, func(t *dtest.T) {
+		t.Assert(dtcp.GetServer("shutdown") == s, true)
+		conn, err := dtcp.NewConn(addr)
+		t.Assert(err, nil)
+		defer conn.Close()
+		t.Assert(conn.SendPkg([]byte("graceful")), nil)
+		time.Sleep(20 * time.Millisecond)
+
+		// 等待正在处理的链接完成
+		t.Assert(s.Shutdown(context.Background()), nil)
+		result, err := conn.RecvPkg()
+		t.Assert(err, nil)
+		t.Assert(string(result), "graceful")
+		_, err = dtcp.NewConn(addr, time.Second)
+		t.AssertNE(err, nil)
+	})
+}
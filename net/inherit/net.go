@@ -36,6 +36,12 @@ func SetInherited() error {
 	return globalInheritNet.SetInherited()
 }
 
+// Files 获取当前进程正在使用的监听句柄文件，以及子进程继承这些句柄需要设置的环境变量，
+// 子进程需要把这些文件从句柄3开始依次传入
+func Files() ([]*os.File, map[string]string, error) {
+	return globalInheritNet.Files()
+}
+
 // AddInheritedFunc 添加继承保存方法
 func AddInheritedFunc(fn func([]*os.File, map[string]string)) {
 	globalInheritNet.AddInherited = fn
@@ -213,21 +219,29 @@ func (that *inheritNet) Append(ln net.Listener) error {
 }
 
 func (that *inheritNet) SetInherited() error {
-	//获取所有正在使用的句柄
-	listeners, err := that.activeListeners()
+	files, envs, err := that.Files()
 	if err != nil {
 		return err
 	}
+	that.AddInherited(files, envs)
+	return nil
+}
+
+// Files 获取所有正在使用的句柄的文件，以及传递句柄数量的环境变量
+func (that *inheritNet) Files() ([]*os.File, map[string]string, error) {
+	listeners, err := that.activeListeners()
+	if err != nil {
+		return nil, nil, err
+	}
 	var files = make([]*os.File, 0, len(listeners))
 	for _, l := range listeners {
 		f, e := l.(filer).File()
 		if e != nil {
-			return e
+			return nil, nil, e
 		}
 		files = append(files, f)
 	}
-	that.AddInherited(files, map[string]string{envCountKey: strconv.Itoa(len(listeners))})
-	return nil
+	return files, map[string]string{envCountKey: strconv.Itoa(len(listeners))}, nil
 }
 
 // 获取当前进程正在使用的监听句柄
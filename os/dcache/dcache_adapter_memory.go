@@ -3,6 +3,7 @@ package dcache
 import (
 	"context"
 	"github.com/osgochina/donkeygo/container/dlist"
+	"github.com/osgochina/donkeygo/container/dlru"
 	"github.com/osgochina/donkeygo/container/dset"
 	"github.com/osgochina/donkeygo/container/dtype"
 	"github.com/osgochina/donkeygo/os/dtime"
@@ -17,19 +18,27 @@ const defaultMaxExpire = 9223372036854
 // 内存缓存的实现
 type adapterMemory struct {
 
-	// 是否启动容量限制，cap>0，则表示启用，缓存最多存在cap个item，多余的按照过期时间淘汰
+	// 是否启动容量限制，cap>0，则表示启用，缓存最多存在cap个item，多余的按照淘汰策略淘汰
 	cap         int
 	data        *adapterMemoryData        //数据存放
 	expireTimes *adapterMemoryExpireTimes // 到期时间的map，用于快速索引和删除
 	expireSets  *adapterMemoryExpireSets  // 相同过期时间的 时间=>key值 映射
-	lru         *adapterMemoryLru         // lru淘汰算法管理器
+	lru         *adapterMemoryLru         // 容量淘汰管理器
 	lruGetList  *dlist.List               // 每次获取缓存，则把缓存的key丢到队列，更新lru管理器中的key排列顺序
 	eventList   *dlist.List               // 内部数据同步的异步事件列表
 	closed      *dtype.Bool               // 当前cache是否被关闭
 }
 
-//创建内存缓存对象
+//创建内存缓存对象，lruCap大于0时启用容量限制，并使用LRU淘汰算法
 func newAdapterMemory(lruCap ...int) *adapterMemory {
+	if len(lruCap) > 0 {
+		return newAdapterMemoryWithPolicy(dlru.PolicyLRU, lruCap[0])
+	}
+	return newAdapterMemoryWithPolicy(dlru.PolicyLRU, 0)
+}
+
+//创建内存缓存对象，capacity大于0时启用容量限制，并使用policy淘汰算法
+func newAdapterMemoryWithPolicy(policy dlru.Policy, capacity int) *adapterMemory {
 	c := &adapterMemory{
 		data:        newAdapterMemoryData(),
 		expireTimes: newAdapterMemoryExpireTimes(),
@@ -38,9 +47,9 @@ func newAdapterMemory(lruCap ...int) *adapterMemory {
 		eventList:   dlist.New(true),
 		closed:      dtype.NewBool(),
 	}
-	if len(lruCap) > 0 {
-		c.cap = lruCap[0]
-		c.lru = newMemCacheLru(c)
+	if capacity > 0 {
+		c.cap = capacity
+		c.lru = newMemCacheLru(c, policy)
 	}
 	return c
 }
@@ -2,6 +2,7 @@ package dcache
 
 import (
 	"context"
+	"github.com/osgochina/donkeygo/container/dlru"
 	"github.com/osgochina/donkeygo/container/dvar"
 	"github.com/osgochina/donkeygo/os/dtimer"
 	"github.com/osgochina/donkeygo/util/dconv"
@@ -14,9 +15,19 @@ type Cache struct {
 	ctx     context.Context // 上下文
 }
 
-// New 创建一个缓存对象，默认使用内存缓存
+// New 创建一个缓存对象，默认使用内存缓存，lruCap大于0时限制缓存的数量，超出的部分按照LRU算法淘汰
 func New(lruCap ...int) *Cache {
-	memAdapter := newAdapterMemory(lruCap...)
+	return newMemoryCache(newAdapterMemory(lruCap...))
+}
+
+// NewWithPolicy 创建一个使用内存缓存的缓存对象，capacity大于0时限制缓存的数量，超出的部分按照policy指定的淘汰算法淘汰，
+// 支持dlru.PolicyLRU、dlru.PolicyLFU、dlru.PolicyARC和dlru.Policy2Q
+func NewWithPolicy(policy dlru.Policy, capacity int) *Cache {
+	return newMemoryCache(newAdapterMemoryWithPolicy(policy, capacity))
+}
+
+// 使用内存缓存适配器创建缓存对象
+func newMemoryCache(memAdapter *adapterMemory) *Cache {
 	c := &Cache{
 		adapter: memAdapter,
 	}
@@ -0,0 +1,41 @@
+package dcache
+
+import (
+	"context"
+	"github.com/osgochina/donkeygo/container/dlru"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"testing"
+)
+
+func TestCache_Policy(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			ctx   = context.Background()
+			cache = newAdapterMemoryWithPolicy(dlru.PolicyLFU, 2)
+		)
+		// 直接执行定时任务中的同步方法，不需要等待定时器
+		flush := func() {
+			cache.syncEventAndClearExpired()
+			cache.lru.SyncAndClear()
+		}
+		t.AssertNil(cache.Set(ctx, 1, 1, 0))
+		t.AssertNil(cache.Set(ctx, 2, 2, 0))
+		for i := 0; i < 3; i++ {
+			_, _ = cache.Get(ctx, 1)
+		}
+		_, _ = cache.Get(ctx, 2)
+		flush()
+		// key 2虽然最近被访问过，但是访问次数比key 1少，所以被淘汰
+		t.AssertNil(cache.Set(ctx, 3, 3, 0))
+		flush()
+		n, _ := cache.Size(ctx)
+		t.Assert(n, 2)
+		v, _ := cache.Get(ctx, 1)
+		t.Assert(v, 1)
+		v, _ = cache.Get(ctx, 2)
+		t.Assert(v, nil)
+		v, _ = cache.Get(ctx, 3)
+		t.Assert(v, 3)
+		t.AssertNil(cache.Close(ctx))
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/osgochina/donkeygo/container/darray"
 	"github.com/osgochina/donkeygo/container/dmap"
+	"github.com/osgochina/donkeygo/container/dtype"
 	"github.com/osgochina/donkeygo/internal/intlog"
 	"github.com/osgochina/donkeygo/os/dcmd"
 )
@@ -14,6 +15,13 @@ type Config struct {
 	searchPaths   *darray.StrArray //配置文件的搜索路径
 	jsonMap       *dmap.StrAnyMap  //把配置文件转换成json对象后存储在这里
 	violenceCheck bool             //
+	changeFuncs   *darray.Array    //配置变更的回调
+	errorFuncs    *darray.Array    //配置重新加载失败的回调
+	lookupFuncs   *darray.Array    //获取配置项时报告其来源的回调
+	sources       *darray.Array    //配置来源，按顺序合并成默认配置
+	origins       *dtype.Interface //合并后每个配置项的来源
+	secretKey     []byte           //解密配置值使用的密钥
+	secretPaths   *dmap.StrAnyMap  //每个配置文件中值包含占位符的配置项路径
 }
 
 const (
@@ -11,11 +11,11 @@ import (
 	"github.com/gogf/gf/util/gmode"
 	"github.com/osgochina/donkeygo/container/darray"
 	"github.com/osgochina/donkeygo/container/dmap"
+	"github.com/osgochina/donkeygo/container/dtype"
 	"github.com/osgochina/donkeygo/errors/derror"
 	"github.com/osgochina/donkeygo/internal/intlog"
 	"github.com/osgochina/donkeygo/os/dcmd"
 	"github.com/osgochina/donkeygo/os/dfile"
-	"github.com/osgochina/donkeygo/os/dfsnotify"
 	"github.com/osgochina/donkeygo/os/dlog"
 	"github.com/osgochina/donkeygo/text/dstr"
 )
@@ -37,6 +37,12 @@ func New(file ...string) *Config {
 		defaultName: name,
 		searchPaths: darray.NewStrArray(true),
 		jsonMap:     dmap.NewStrAnyMap(true),
+		changeFuncs: darray.New(true),
+		errorFuncs:  darray.New(true),
+		lookupFuncs: darray.New(true),
+		sources:     darray.New(true),
+		origins:     dtype.NewInterface(),
+		secretPaths: dmap.NewStrAnyMap(true),
 	}
 	customPath := dcmd.GetOptWithEnv(fmt.Sprintf("%s.path", cmdEnvKey)).String()
 	if len(customPath) > 0 {
@@ -313,45 +319,8 @@ func (that *Config) getJson(file ...string) *gjson.Json {
 	}
 
 	r := that.jsonMap.GetOrSetFuncLock(name, func() interface{} {
-		var (
-			err      error
-			content  string
-			filePath string
-		)
-		isFromConfigContent := true
-		if content = GetContent(name); content == "" {
-			//内存中不存在
-			isFromConfigContent = false
-			//获取配置文件真实地址
-			filePath, err = that.GetFilePath(name)
-			if err != nil && errorPrint() {
-				dlog.Error(err)
-			}
-			if filePath == "" {
-				return nil
-			}
-			// 先从资源池中获取文件内容
-			if file := gres.Get(filePath); file != nil {
-				content = string(file.Content())
-			} else {
-				//直接获取原始文件内容
-				content = dfile.GetContents(filePath)
-			}
-		}
-
-		var (
-			j *gjson.Json
-		)
-		//获取配置文件格式
-		dataType := dfile.ExtName(name)
-		// 判断gjson是否支持该配置文件类型,并且载入文件到内存
-		if gjson.IsValidDataType(dataType) && !isFromConfigContent {
-			j, err = gjson.LoadContentType(dataType, content, true)
-		} else {
-			j, err = gjson.LoadContent(content, true)
-		}
+		j, filePath, err := that.loadJson(name)
 		if err != nil {
-			//如果
 			if errorPrint() {
 				if filePath != "" {
 					dlog.Criticalf(`[dcfg] load config file "%s" failed: %s`, filePath, err.Error())
@@ -361,18 +330,12 @@ func (that *Config) getJson(file ...string) *gjson.Json {
 			}
 			return nil
 		}
-		//设置数据分层的暴力检查
-		j.SetViolenceCheck(that.violenceCheck)
-
+		if j == nil {
+			return nil
+		}
 		//文件不存在资源池，添加文件变更事件，实时更新配置文件
 		if filePath != "" && !gres.Contains(filePath) {
-			//文件有任何变更，则从内存中删除该文件
-			_, err = dfsnotify.Add(filePath, func(event *dfsnotify.Event) {
-				that.jsonMap.Remove(name)
-			})
-			if err != nil && errorPrint() {
-				dlog.Error(err)
-			}
+			that.watchFile(name, filePath)
 		}
 		return j
 	})
@@ -381,3 +344,60 @@ func (that *Config) getJson(file ...string) *gjson.Json {
 	}
 	return nil
 }
+
+// 加载配置并解析其中的占位符，配置不存在时返回的json对象为nil
+func (that *Config) loadJson(name string) (j *gjson.Json, filePath string, err error) {
+	if that.useSources(name) {
+		j, err = that.loadSources()
+	} else {
+		j, filePath, err = that.loadFile(name)
+	}
+	if err != nil || j == nil {
+		return j, filePath, err
+	}
+	secrets, err := that.resolveSecrets(j.Value())
+	if err != nil {
+		return nil, filePath, err
+	}
+	that.secretPaths.Set(name, secrets)
+	return j, filePath, nil
+}
+
+// 读取并解析配置文件，配置文件不存在时返回的json对象为nil
+func (that *Config) loadFile(name string) (j *gjson.Json, filePath string, err error) {
+	var content string
+	isFromConfigContent := true
+	if content = GetContent(name); content == "" {
+		//内存中不存在
+		isFromConfigContent = false
+		//获取配置文件真实地址
+		filePath, err = that.GetFilePath(name)
+		if err != nil && errorPrint() {
+			dlog.Error(err)
+		}
+		if filePath == "" {
+			return nil, "", nil
+		}
+		// 先从资源池中获取文件内容
+		if file := gres.Get(filePath); file != nil {
+			content = string(file.Content())
+		} else {
+			//直接获取原始文件内容
+			content = dfile.GetContents(filePath)
+		}
+	}
+	//获取配置文件格式
+	dataType := dfile.ExtName(name)
+	// 判断gjson是否支持该配置文件类型,并且载入文件到内存
+	if gjson.IsValidDataType(dataType) && !isFromConfigContent {
+		j, err = gjson.LoadContentType(dataType, content, true)
+	} else {
+		j, err = gjson.LoadContent(content, true)
+	}
+	if err != nil {
+		return nil, filePath, err
+	}
+	//设置数据分层的暴力检查
+	j.SetViolenceCheck(that.violenceCheck)
+	return j, filePath, nil
+}
@@ -18,7 +18,7 @@ func (that *Config) Set(pattern string, value interface{}) error {
 
 // Get 获取配置文件的选项
 func (that *Config) Get(pattern string, def ...interface{}) interface{} {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.Get(pattern, def...)
 	}
 	return nil
@@ -26,7 +26,7 @@ func (that *Config) Get(pattern string, def ...interface{}) interface{} {
 
 // GetVar 返回`pattern`对应的Var对象
 func (that *Config) GetVar(pattern string, def ...interface{}) *dvar.Var {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return dvar.New(j.GetVar(pattern, def...).Val())
 	}
 	return dvar.New(nil)
@@ -42,7 +42,7 @@ func (that *Config) Contains(pattern string) bool {
 
 // GetMap 获取map格式的配置项
 func (that *Config) GetMap(pattern string, def ...interface{}) map[string]interface{} {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetMap(pattern, def...)
 	}
 	return nil
@@ -50,7 +50,7 @@ func (that *Config) GetMap(pattern string, def ...interface{}) map[string]interf
 
 // GetMapStrStr 获取 map[string]string格式的配置项
 func (that *Config) GetMapStrStr(pattern string, def ...interface{}) map[string]string {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetMapStrStr(pattern, def...)
 	}
 	return nil
@@ -58,7 +58,7 @@ func (that *Config) GetMapStrStr(pattern string, def ...interface{}) map[string]
 
 // GetArray 获取数组格式的配置项
 func (that *Config) GetArray(pattern string, def ...interface{}) []interface{} {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetArray(pattern, def...)
 	}
 	return nil
@@ -66,7 +66,7 @@ func (that *Config) GetArray(pattern string, def ...interface{}) []interface{} {
 
 // GetBytes 获取配置项的字节数组类型
 func (that *Config) GetBytes(pattern string, def ...interface{}) []byte {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetBytes(pattern, def...)
 	}
 	return nil
@@ -74,7 +74,7 @@ func (that *Config) GetBytes(pattern string, def ...interface{}) []byte {
 
 // GetString 获取字符串类型的配置项
 func (that *Config) GetString(pattern string, def ...interface{}) string {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetString(pattern, def...)
 	}
 	return ""
@@ -82,7 +82,7 @@ func (that *Config) GetString(pattern string, def ...interface{}) string {
 
 // GetStrings 获取字符串数组配置项
 func (that *Config) GetStrings(pattern string, def ...interface{}) []string {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetStrings(pattern, def...)
 	}
 	return nil
@@ -90,14 +90,14 @@ func (that *Config) GetStrings(pattern string, def ...interface{}) []string {
 
 // GetInterfaces 获取数组格式的配置项
 func (that *Config) GetInterfaces(pattern string, def ...interface{}) []interface{} {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetInterfaces(pattern, def...)
 	}
 	return nil
 }
 
 func (that *Config) GetBool(pattern string, def ...interface{}) bool {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetBool(pattern, def...)
 	}
 	return false
@@ -105,7 +105,7 @@ func (that *Config) GetBool(pattern string, def ...interface{}) bool {
 
 // GetFloat32 retrieves the value by specified `pattern` and converts it to float32.
 func (that *Config) GetFloat32(pattern string, def ...interface{}) float32 {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetFloat32(pattern, def...)
 	}
 	return 0
@@ -113,7 +113,7 @@ func (that *Config) GetFloat32(pattern string, def ...interface{}) float32 {
 
 // GetFloat64 retrieves the value by specified `pattern` and converts it to float64.
 func (that *Config) GetFloat64(pattern string, def ...interface{}) float64 {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetFloat64(pattern, def...)
 	}
 	return 0
@@ -121,7 +121,7 @@ func (that *Config) GetFloat64(pattern string, def ...interface{}) float64 {
 
 // GetFloats retrieves the value by specified `pattern` and converts it to []float64.
 func (that *Config) GetFloats(pattern string, def ...interface{}) []float64 {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetFloats(pattern, def...)
 	}
 	return nil
@@ -129,7 +129,7 @@ func (that *Config) GetFloats(pattern string, def ...interface{}) []float64 {
 
 // GetInt retrieves the value by specified `pattern` and converts it to int.
 func (that *Config) GetInt(pattern string, def ...interface{}) int {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetInt(pattern, def...)
 	}
 	return 0
@@ -137,7 +137,7 @@ func (that *Config) GetInt(pattern string, def ...interface{}) int {
 
 // GetInt8 retrieves the value by specified `pattern` and converts it to int8.
 func (that *Config) GetInt8(pattern string, def ...interface{}) int8 {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetInt8(pattern, def...)
 	}
 	return 0
@@ -145,7 +145,7 @@ func (that *Config) GetInt8(pattern string, def ...interface{}) int8 {
 
 // GetInt16 retrieves the value by specified `pattern` and converts it to int16.
 func (that *Config) GetInt16(pattern string, def ...interface{}) int16 {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetInt16(pattern, def...)
 	}
 	return 0
@@ -153,7 +153,7 @@ func (that *Config) GetInt16(pattern string, def ...interface{}) int16 {
 
 // GetInt32 retrieves the value by specified `pattern` and converts it to int32.
 func (that *Config) GetInt32(pattern string, def ...interface{}) int32 {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetInt32(pattern, def...)
 	}
 	return 0
@@ -161,7 +161,7 @@ func (that *Config) GetInt32(pattern string, def ...interface{}) int32 {
 
 // GetInt64 retrieves the value by specified `pattern` and converts it to int64.
 func (that *Config) GetInt64(pattern string, def ...interface{}) int64 {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetInt64(pattern, def...)
 	}
 	return 0
@@ -169,7 +169,7 @@ func (that *Config) GetInt64(pattern string, def ...interface{}) int64 {
 
 // GetInts retrieves the value by specified `pattern` and converts it to []int.
 func (that *Config) GetInts(pattern string, def ...interface{}) []int {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetInts(pattern, def...)
 	}
 	return nil
@@ -177,7 +177,7 @@ func (that *Config) GetInts(pattern string, def ...interface{}) []int {
 
 // GetUint retrieves the value by specified `pattern` and converts it to uint.
 func (that *Config) GetUint(pattern string, def ...interface{}) uint {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetUint(pattern, def...)
 	}
 	return 0
@@ -185,7 +185,7 @@ func (that *Config) GetUint(pattern string, def ...interface{}) uint {
 
 // GetUint8 retrieves the value by specified `pattern` and converts it to uint8.
 func (that *Config) GetUint8(pattern string, def ...interface{}) uint8 {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetUint8(pattern, def...)
 	}
 	return 0
@@ -193,7 +193,7 @@ func (that *Config) GetUint8(pattern string, def ...interface{}) uint8 {
 
 // GetUint16 retrieves the value by specified `pattern` and converts it to uint16.
 func (that *Config) GetUint16(pattern string, def ...interface{}) uint16 {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetUint16(pattern, def...)
 	}
 	return 0
@@ -201,7 +201,7 @@ func (that *Config) GetUint16(pattern string, def ...interface{}) uint16 {
 
 // GetUint32 retrieves the value by specified `pattern` and converts it to uint32.
 func (that *Config) GetUint32(pattern string, def ...interface{}) uint32 {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetUint32(pattern, def...)
 	}
 	return 0
@@ -209,7 +209,7 @@ func (that *Config) GetUint32(pattern string, def ...interface{}) uint32 {
 
 // GetUint64 retrieves the value by specified `pattern` and converts it to uint64.
 func (that *Config) GetUint64(pattern string, def ...interface{}) uint64 {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetUint64(pattern, def...)
 	}
 	return 0
@@ -217,7 +217,7 @@ func (that *Config) GetUint64(pattern string, def ...interface{}) uint64 {
 
 // GetTime retrieves the value by specified `pattern` and converts it to time.Time.
 func (that *Config) GetTime(pattern string, format ...string) time.Time {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetTime(pattern, format...)
 	}
 	return time.Time{}
@@ -225,7 +225,7 @@ func (that *Config) GetTime(pattern string, format ...string) time.Time {
 
 // GetDuration retrieves the value by specified `pattern` and converts it to time.Duration.
 func (that *Config) GetDuration(pattern string, def ...interface{}) time.Duration {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetDuration(pattern, def...)
 	}
 	return 0
@@ -233,7 +233,7 @@ func (that *Config) GetDuration(pattern string, def ...interface{}) time.Duratio
 
 // Getdtime retrieves the value by specified `pattern` and converts it to *dtime.Time.
 func (that *Config) GetGTime(pattern string, format ...string) *gtime.Time {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetGTime(pattern, format...)
 	}
 	return nil
@@ -242,7 +242,7 @@ func (that *Config) GetGTime(pattern string, format ...string) *gtime.Time {
 // GetJson gets the value by specified `pattern`,
 // and converts it to a un-concurrent-safe Json object.
 func (that *Config) GetJson(pattern string, def ...interface{}) *gjson.Json {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetJson(pattern, def...)
 	}
 	return nil
@@ -251,7 +251,7 @@ func (that *Config) GetJson(pattern string, def ...interface{}) *gjson.Json {
 // GetJsons gets the value by specified `pattern`,
 // and converts it to a slice of un-concurrent-safe Json object.
 func (that *Config) GetJsons(pattern string, def ...interface{}) []*gjson.Json {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetJsons(pattern, def...)
 	}
 	return nil
@@ -260,7 +260,7 @@ func (that *Config) GetJsons(pattern string, def ...interface{}) []*gjson.Json {
 // GetJsonMap gets the value by specified `pattern`,
 // and converts it to a map of un-concurrent-safe Json object.
 func (that *Config) GetJsonMap(pattern string, def ...interface{}) map[string]*gjson.Json {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetJsonMap(pattern, def...)
 	}
 	return nil
@@ -269,7 +269,7 @@ func (that *Config) GetJsonMap(pattern string, def ...interface{}) map[string]*g
 // GetStruct retrieves the value by specified `pattern` and converts it to specified object
 // `pointer`. The `pointer` should be the pointer to an object.
 func (that *Config) GetStruct(pattern string, pointer interface{}, mapping ...map[string]string) error {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetStruct(pattern, pointer, mapping...)
 	}
 	return errors.New("configuration not found")
@@ -278,7 +278,7 @@ func (that *Config) GetStruct(pattern string, pointer interface{}, mapping ...ma
 // GetStructDeep does GetStruct recursively.
 // Deprecated, use GetStruct instead.
 func (that *Config) GetStructDeep(pattern string, pointer interface{}, mapping ...map[string]string) error {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetStructDeep(pattern, pointer, mapping...)
 	}
 	return errors.New("configuration not found")
@@ -286,7 +286,7 @@ func (that *Config) GetStructDeep(pattern string, pointer interface{}, mapping .
 
 // GetStructs 将任何片转换为给定的结构片。
 func (that *Config) GetStructs(pattern string, pointer interface{}, mapping ...map[string]string) error {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetStructs(pattern, pointer, mapping...)
 	}
 	return errors.New("configuration not found")
@@ -295,7 +295,7 @@ func (that *Config) GetStructs(pattern string, pointer interface{}, mapping ...m
 // GetStructsDeep 递归地将任何片转换为给定的结构片。
 // Deprecated, use GetStructs instead.
 func (that *Config) GetStructsDeep(pattern string, pointer interface{}, mapping ...map[string]string) error {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetStructsDeep(pattern, pointer, mapping...)
 	}
 	return errors.New("configuration not found")
@@ -304,7 +304,7 @@ func (that *Config) GetStructsDeep(pattern string, pointer interface{}, mapping
 // GetMapToMap 根据指定的“模式”检索值并将其转换为指定的映射变量。
 // See gconv.MapToMap.
 func (that *Config) GetMapToMap(pattern string, pointer interface{}, mapping ...map[string]string) error {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetMapToMap(pattern, pointer, mapping...)
 	}
 	return errors.New("configuration not found")
@@ -313,7 +313,7 @@ func (that *Config) GetMapToMap(pattern string, pointer interface{}, mapping ...
 // GetMapToMaps 根据指定的“模式”检索值，并将其转换为指定的映射片变量。
 // See gconv.MapToMaps.
 func (that *Config) GetMapToMaps(pattern string, pointer interface{}, mapping ...map[string]string) error {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetMapToMaps(pattern, pointer, mapping...)
 	}
 	return errors.New("configuration not found")
@@ -322,7 +322,7 @@ func (that *Config) GetMapToMaps(pattern string, pointer interface{}, mapping ..
 // GetMapToMapsDeep 根据指定的“模式”检索值，并将其递归转换为指定的映射片变量。
 // See gconv.MapToMapsDeep.
 func (that *Config) GetMapToMapsDeep(pattern string, pointer interface{}, mapping ...map[string]string) error {
-	if j := that.getJson(); j != nil {
+	if j := that.lookup(pattern); j != nil {
 		return j.GetMapToMapsDeep(pattern, pointer, mapping...)
 	}
 	return errors.New("configuration not found")
@@ -416,9 +416,13 @@ func (that *Config) Clear() {
 	that.jsonMap.Clear()
 }
 
-// Dump 打印当前Json对象，更具手动可读性。
+// Dump 打印当前Json对象，更具手动可读性。值包含占位符的配置项会被隐藏。
 func (that *Config) Dump() {
 	if j := that.getJson(); j != nil {
+		if v := that.secretPaths.Get(that.defaultName); v != nil && len(v.(map[string]struct{})) > 0 {
+			gjson.New(maskSecrets(j.Value(), v.(map[string]struct{}))).Dump()
+			return
+		}
 		j.Dump()
 	}
 }
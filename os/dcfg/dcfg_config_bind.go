@@ -0,0 +1,146 @@
+package dcfg
+
+import (
+	"errors"
+	"fmt"
+	"github.com/osgochina/donkeygo/internal/utils"
+	"github.com/osgochina/donkeygo/util/dconv"
+	"reflect"
+	"strings"
+)
+
+const (
+	bindTagDefault  = "default"  // 配置项不存在时使用的默认值
+	bindTagRequired = "required" // 值为"true"时配置项必须存在
+)
+
+// BindStruct 把<pattern>对应的配置绑定到结构体，<pattern>为"."时绑定整个配置文件，绑定规则与dconv.Struct相同。
+// 属性可以使用default标签设置配置项不存在时的默认值，使用required:"true"标签要求配置项必须存在，
+// 嵌套的结构体属性同样支持这两个标签。
+func (that *Config) BindStruct(pattern string, pointer interface{}) error {
+	j := that.getJson()
+	if j == nil {
+		return errors.New("configuration not found")
+	}
+	data := make(map[string]interface{})
+	if v := j.Get(pattern); v != nil {
+		m, ok := copyConfigValue(v).(map[string]interface{})
+		if !ok {
+			return errors.New(fmt.Sprintf(`config "%s" is not a map`, pattern))
+		}
+		data = m
+	}
+	t := reflect.TypeOf(pointer)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return errors.New(fmt.Sprintf(`pointer should be type of *struct, but got: %v`, t))
+	}
+	prefix := pattern
+	if prefix == "." {
+		prefix = ""
+	}
+	if err := applyBindTags(t.Elem(), data, prefix); err != nil {
+		return err
+	}
+	return dconv.Struct(data, pointer)
+}
+
+// 按照结构体的default和required标签补充默认值并检查必须的配置项
+func applyBindTags(t reflect.Type, data map[string]interface{}, prefix string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		// 匿名嵌入的结构体与外层共用同一层配置
+		if field.Anonymous && fieldType.Kind() == reflect.Struct {
+			if err := applyBindTags(fieldType, data, prefix); err != nil {
+				return err
+			}
+			continue
+		}
+		name := bindFieldName(field)
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		key, found := findConfigKey(data, name, field.Name)
+		if fieldType.Kind() == reflect.Struct && fieldType.PkgPath() != "time" {
+			sub, ok := data[key].(map[string]interface{})
+			if !found {
+				sub = make(map[string]interface{})
+			}
+			if ok || !found {
+				if err := applyBindTags(fieldType, sub, path); err != nil {
+					return err
+				}
+				if !found && len(sub) > 0 {
+					data[name] = sub
+					found = true
+				}
+			}
+		}
+		if !found {
+			if def, ok := field.Tag.Lookup(bindTagDefault); ok {
+				data[name] = def
+				found = true
+			}
+		}
+		if !found && field.Tag.Get(bindTagRequired) == "true" {
+			return errors.New(fmt.Sprintf(`required config "%s" not set`, path))
+		}
+	}
+	return nil
+}
+
+// 获取属性对应的配置项名字，优先使用dconv支持的标签
+func bindFieldName(field reflect.StructField) string {
+	for _, tag := range dconv.StructTagPriority {
+		if v := field.Tag.Get(tag); v != "" {
+			if name := strings.Split(v, ",")[0]; name != "" && name != "-" {
+				return name
+			}
+		}
+	}
+	return field.Name
+}
+
+// 使用与dconv相同的模糊匹配规则查找配置项，忽略大小写和符号
+func findConfigKey(data map[string]interface{}, names ...string) (string, bool) {
+	for _, name := range names {
+		if _, ok := data[name]; ok {
+			return name, true
+		}
+	}
+	for key := range data {
+		for _, name := range names {
+			if utils.EqualFoldWithoutChars(key, name) {
+				return key, true
+			}
+		}
+	}
+	return "", false
+}
+
+// 深拷贝配置值，避免补充默认值时修改缓存中的配置
+func copyConfigValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = copyConfigValue(item)
+		}
+		return m
+	case []interface{}:
+		array := make([]interface{}, len(v))
+		for i, item := range v {
+			array[i] = copyConfigValue(item)
+		}
+		return array
+	default:
+		return v
+	}
+}
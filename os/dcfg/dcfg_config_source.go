@@ -0,0 +1,144 @@
+package dcfg
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gogf/gf/encoding/gjson"
+	"github.com/osgochina/donkeygo/container/dvar"
+	"github.com/osgochina/donkeygo/os/dfile"
+	"strings"
+)
+
+// AddSource 添加配置来源，添加了配置来源后默认配置由所有来源按顺序深度合并而成，后添加的来源优先级更高，
+// 如 默认值 -> 配置文件 -> 环境覆盖配置文件 -> 环境变量 -> 命令行参数 -> 远程配置
+func (that *Config) AddSource(sources ...Source) *Config {
+	for _, s := range sources {
+		if fs, ok := s.(*fileSource); ok && fs.config == nil {
+			fs.config = that
+		}
+		that.sources.Append(s)
+	}
+	that.jsonMap.Remove(that.defaultName)
+	return that
+}
+
+// Reload 重新加载默认配置，可以用于主动刷新远程配置来源，加载失败时继续使用之前的配置
+func (that *Config) Reload() error {
+	if that.jsonMap.Get(that.defaultName) == nil {
+		if that.getJson() == nil {
+			return errors.New("configuration not found")
+		}
+		return nil
+	}
+	return that.reload(that.defaultName)
+}
+
+// GetWithSource 获取配置项的值，同时返回提供该值的配置来源的名字，没有使用配置来源时返回空字符串
+func (that *Config) GetWithSource(pattern string, def ...interface{}) (*dvar.Var, string) {
+	return that.GetVar(pattern, def...), that.source(that.getJson(), pattern)
+}
+
+// OnLookup 设置获取配置项的回调，通过Get、GetVar、GetString等方法获取配置项时，
+// 回调参数为配置项和提供该值的配置来源的名字，没有使用配置来源或者配置项不存在时来源为空字符串
+func (that *Config) OnLookup(f func(pattern string, source string)) {
+	that.lookupFuncs.Append(f)
+}
+
+// 获取默认配置，并向OnLookup设置的回调报告配置项的来源
+func (that *Config) lookup(pattern string) *gjson.Json {
+	j := that.getJson()
+	if that.lookupFuncs.Len() == 0 {
+		return j
+	}
+	source := that.source(j, pattern)
+	for _, f := range that.lookupFuncs.Slice() {
+		f.(func(pattern string, source string))(pattern, source)
+	}
+	return j
+}
+
+// 获取配置项的来源
+func (that *Config) source(j *gjson.Json, pattern string) string {
+	if j == nil || that.sources.Len() == 0 || !j.Contains(pattern) {
+		return ""
+	}
+	origins, _ := that.origins.Val().(map[string]string)
+	segments := strings.Split(strings.ToLower(pattern), ".")
+	// 数组中的元素等没有单独记录的配置项，使用最近的上级配置项的来源
+	for i := len(segments); i > 0; i-- {
+		if name, ok := origins[strings.Join(segments[:i], ".")]; ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// 是否使用配置来源合并默认配置
+func (that *Config) useSources(name string) bool {
+	return name == that.defaultName && that.sources.Len() > 0
+}
+
+// 加载并合并所有配置来源
+func (that *Config) loadSources() (*gjson.Json, error) {
+	var (
+		data    = make(map[string]interface{})
+		origins = make(map[string]string)
+	)
+	for _, v := range that.sources.Slice() {
+		s := v.(Source)
+		m, err := s.Load()
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf(`[dcfg] load source "%s" failed: %s`, s.Name(), err.Error()))
+		}
+		mergeSource(data, m, "", s.Name(), origins)
+		// 配置文件来源变更时重新加载
+		if fs, ok := s.(*fileSource); ok {
+			if path := fs.realPath(); path != "" && dfile.Exists(path) {
+				that.watchFile(that.defaultName, path)
+			}
+		}
+	}
+	j := gjson.New(data, true)
+	j.SetViolenceCheck(that.violenceCheck)
+	that.origins.Set(origins)
+	return j, nil
+}
+
+// 把配置来源的内容深度合并到已有的配置中，键名不区分大小写，同时记录每个配置项的来源
+func mergeSource(dst, src map[string]interface{}, prefix string, name string, origins map[string]string) {
+	for k, v := range src {
+		key := k
+		for existing := range dst {
+			if strings.EqualFold(existing, k) {
+				key = existing
+				break
+			}
+		}
+		path := strings.ToLower(key)
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		origins[path] = name
+		if srcMap, ok := v.(map[string]interface{}); ok {
+			dstMap, ok := dst[key].(map[string]interface{})
+			if !ok {
+				removeOrigins(origins, path)
+				dstMap = make(map[string]interface{})
+				dst[key] = dstMap
+			}
+			mergeSource(dstMap, srcMap, path, name, origins)
+			continue
+		}
+		removeOrigins(origins, path)
+		dst[key] = copyConfigValue(v)
+	}
+}
+
+// 配置项被覆盖后，删除其下级配置项的来源记录
+func removeOrigins(origins map[string]string, path string) {
+	for k := range origins {
+		if strings.HasPrefix(k, path+".") {
+			delete(origins, k)
+		}
+	}
+}
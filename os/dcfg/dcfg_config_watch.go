@@ -0,0 +1,103 @@
+package dcfg
+
+import (
+	"fmt"
+	"github.com/gogf/gf/encoding/gjson"
+	"github.com/osgochina/donkeygo/container/dvar"
+	"github.com/osgochina/donkeygo/os/dfile"
+	"github.com/osgochina/donkeygo/os/dfsnotify"
+	"github.com/osgochina/donkeygo/os/dlog"
+	"reflect"
+	"time"
+)
+
+const (
+	reloadDebounce = 100 * time.Millisecond // 配置文件变更后重新加载的防抖时间，合并编辑器保存时产生的多个事件
+)
+
+// 配置变更的回调
+type changeFunc struct {
+	file    string // 订阅的配置文件名，为空时表示回调时的默认配置文件
+	pattern string
+	f       func(old, new *dvar.Var)
+}
+
+// OnChange 订阅配置项的变更，配置文件重新加载成功并且<pattern>对应的值发生变化后回调，
+// 回调参数为变更前后的值，<pattern>为"."时订阅整个配置文件，<file>指定订阅的配置文件名，默认为默认配置文件
+func (that *Config) OnChange(pattern string, f func(old, new *dvar.Var), file ...string) {
+	item := &changeFunc{pattern: pattern, f: f}
+	if len(file) > 0 {
+		item.file = file[0]
+	}
+	that.changeFuncs.Append(item)
+}
+
+// OnReloadError 设置配置文件重新加载失败的回调，重新加载失败时继续使用之前的配置，
+// 回调参数file为配置文件路径，使用配置来源时为配置名
+func (that *Config) OnReloadError(f func(file string, err error)) {
+	that.errorFuncs.Append(f)
+}
+
+// 监听配置文件的变更，同一个配置文件只会监听一次
+func (that *Config) watchFile(name, filePath string) {
+	_, err := dfsnotify.AddWithOption(filePath, func(event *dfsnotify.Event) {
+		_ = that.reload(name)
+	}, dfsnotify.CallbackOption{
+		Name:     fmt.Sprintf("dcfg:%p:%s", that, filePath),
+		Debounce: reloadDebounce,
+		Rewatch:  true,
+	})
+	if err != nil && errorPrint() {
+		dlog.Error(err)
+	}
+}
+
+// 重新加载配置文件，解析失败时保留之前的配置，配置文件被删除时清除缓存
+func (that *Config) reload(name string) error {
+	v := that.jsonMap.Get(name)
+	if v == nil {
+		// 配置还未加载或者缓存已被清除，下次获取时会重新加载
+		return nil
+	}
+	j, filePath, err := that.loadJson(name)
+	if err != nil {
+		// 使用配置来源时没有单一的配置文件路径
+		if filePath == "" {
+			filePath = name
+		}
+		if errorPrint() {
+			dlog.Errorf(`[dcfg] reload config "%s" failed, keep using the previous configuration: %s`, filePath, err.Error())
+		}
+		for _, f := range that.errorFuncs.Slice() {
+			f.(func(file string, err error))(filePath, err)
+		}
+		return err
+	}
+	if j == nil || (filePath != "" && !dfile.Exists(filePath)) {
+		that.jsonMap.Remove(name)
+		return nil
+	}
+	that.jsonMap.Set(name, j)
+	that.notifyChange(name, v.(*gjson.Json), j)
+	return nil
+}
+
+// 比较配置文件<name>的新旧配置，回调订阅了该文件并且值发生变化的订阅
+func (that *Config) notifyChange(name string, old, new *gjson.Json) {
+	for _, v := range that.changeFuncs.Slice() {
+		item := v.(*changeFunc)
+		file := item.file
+		if file == "" {
+			file = that.defaultName
+		}
+		if file != name {
+			continue
+		}
+		oldValue := old.Get(item.pattern)
+		newValue := new.Get(item.pattern)
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		item.f(dvar.New(oldValue), dvar.New(newValue))
+	}
+}
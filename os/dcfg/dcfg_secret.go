@@ -0,0 +1,324 @@
+package dcfg
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/osgochina/donkeygo/os/dcmd"
+	"github.com/osgochina/donkeygo/os/denv"
+	"github.com/osgochina/donkeygo/os/dfile"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	secretKeyKey     = "dk.dcfg.secret.key"     // 通过命令行或环境变量传入解密密钥使用的key，值为base64编码
+	secretKeyFileKey = "dk.dcfg.secret.keyfile" // 通过命令行或环境变量传入解密密钥文件路径使用的key
+	secretMask       = "******"                 // 输出配置时替换敏感值使用的内容
+)
+
+var (
+	// 配置值中的占位符，支持 ${env:NAME}、${file:/path} 和 ${ENC:密文}
+	placeholderRegex = regexp.MustCompile(`\$\{(env|file|ENC):([^}]*)\}`)
+	// 加密值的占位符
+	encryptedRegex = regexp.MustCompile(`\$\{ENC:([^}]*)\}`)
+)
+
+// SetSecretKey 设置解密${ENC:...}占位符使用的AES密钥，长度为16、24或32字节，
+// 未设置时从命令行参数或环境变量dk.dcfg.secret.key(base64编码)以及dk.dcfg.secret.keyfile指定的文件中读取
+func (that *Config) SetSecretKey(key []byte) {
+	that.secretKey = key
+	that.Clear()
+}
+
+// 获取解密密钥
+func (that *Config) getSecretKey() ([]byte, error) {
+	if len(that.secretKey) > 0 {
+		return that.secretKey, nil
+	}
+	if v := dcmd.GetOptWithEnv(secretKeyKey).String(); v != "" {
+		return ParseSecretKey(v)
+	}
+	if path := dcmd.GetOptWithEnv(secretKeyFileKey).String(); path != "" {
+		return ReadSecretKeyFile(path)
+	}
+	return nil, errors.New("secret key not set")
+}
+
+// ParseSecretKey 解析base64编码的密钥
+func ParseSecretKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, errors.New("invalid secret key: not base64 encoded")
+	}
+	if err = checkSecretKey(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ReadSecretKeyFile 从文件中读取密钥，文件内容为base64编码的密钥或者原始密钥
+func ReadSecretKeyFile(path string) ([]byte, error) {
+	if !dfile.Exists(path) {
+		return nil, errors.New(fmt.Sprintf(`secret key file "%s" does not exist`, path))
+	}
+	content := dfile.GetBytes(path)
+	if key, err := ParseSecretKey(string(content)); err == nil {
+		return key, nil
+	}
+	if err := checkSecretKey(content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// GenerateSecretKey 生成32字节的随机密钥
+func GenerateSecretKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// 检查密钥长度
+func checkSecretKey(key []byte) error {
+	switch len(key) {
+	case 16, 24, 32:
+		return nil
+	default:
+		return errors.New(fmt.Sprintf("invalid secret key size %d, should be 16, 24 or 32", len(key)))
+	}
+}
+
+// EncryptValue 使用AES-GCM加密配置值，返回可以直接写入配置文件的${ENC:...}占位符
+func EncryptValue(value string, key []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	data := gcm.Seal(nonce, nonce, []byte(value), nil)
+	return "${ENC:" + base64.StdEncoding.EncodeToString(data) + "}", nil
+}
+
+// DecryptValue 解密${ENC:...}占位符或者其中的密文
+func DecryptValue(value string, key []byte) (string, error) {
+	if match := encryptedRegex.FindStringSubmatch(value); len(match) == 2 {
+		value = match[1]
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(data) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted value")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("decrypt value failed: wrong key or corrupted data")
+	}
+	return string(plain), nil
+}
+
+// RotateFile 使用新的密钥重新加密文件中所有的${ENC:...}占位符，返回重新加密的数量
+func RotateFile(path string, oldKey, newKey []byte) (int, error) {
+	if !dfile.Exists(path) {
+		return 0, errors.New(fmt.Sprintf(`file "%s" does not exist`, path))
+	}
+	var (
+		err     error
+		count   int
+		content = encryptedRegex.ReplaceAllStringFunc(dfile.GetContents(path), func(s string) string {
+			if err != nil {
+				return s
+			}
+			var plain, encrypted string
+			if plain, err = DecryptValue(s, oldKey); err != nil {
+				return s
+			}
+			if encrypted, err = EncryptValue(plain, newKey); err != nil {
+				return s
+			}
+			count++
+			return encrypted
+		})
+	)
+	if err != nil {
+		return 0, err
+	}
+	if err = writeFileAtomic(path, []byte(content)); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// 先写入同目录下的临时文件并同步到磁盘，再改名替换原文件，避免写入中断导致配置文件损坏
+func writeFileAtomic(path string, content []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := file.Name()
+	defer os.Remove(tmpPath)
+	if _, err = file.Write(content); err == nil {
+		if err = file.Chmod(info.Mode().Perm()); err == nil {
+			err = file.Sync()
+		}
+	}
+	if e := file.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// 创建AES-GCM加密对象
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if err := checkSecretKey(key); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// 解析配置中的占位符，返回包含占位符的配置项路径，这些配置项输出时需要隐藏
+func (that *Config) resolveSecrets(data interface{}) (secrets map[string]struct{}, err error) {
+	secrets = make(map[string]struct{})
+	var walk func(value interface{}, path string) (interface{}, error)
+	walk = func(value interface{}, path string) (interface{}, error) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for k, item := range v {
+				subPath := k
+				if path != "" {
+					subPath = path + "." + k
+				}
+				result, err := walk(item, subPath)
+				if err != nil {
+					return nil, err
+				}
+				v[k] = result
+			}
+		case []interface{}:
+			for i, item := range v {
+				result, err := walk(item, path+"."+strconv.Itoa(i))
+				if err != nil {
+					return nil, err
+				}
+				v[i] = result
+			}
+		case string:
+			if !strings.Contains(v, "${") {
+				return v, nil
+			}
+			return that.resolvePlaceholders(v, path, secrets)
+		}
+		return value, nil
+	}
+	_, err = walk(data, "")
+	return secrets, err
+}
+
+// 替换字符串中的占位符，错误信息中只包含配置项路径，不会包含配置值
+func (that *Config) resolvePlaceholders(value string, path string, secrets map[string]struct{}) (string, error) {
+	var (
+		err      error
+		resolved bool
+	)
+	result := placeholderRegex.ReplaceAllStringFunc(value, func(s string) string {
+		if err != nil {
+			return s
+		}
+		resolved = true
+		match := placeholderRegex.FindStringSubmatch(s)
+		switch match[1] {
+		case "env":
+			if !denv.Contains(match[2]) {
+				err = errors.New(fmt.Sprintf(`resolve config "%s" failed: environment variable "%s" not set`, path, match[2]))
+				return s
+			}
+			return denv.Get(match[2])
+		case "file":
+			if !dfile.Exists(match[2]) {
+				err = errors.New(fmt.Sprintf(`resolve config "%s" failed: file "%s" does not exist`, path, match[2]))
+				return s
+			}
+			return strings.TrimRight(dfile.GetContents(match[2]), "\r\n")
+		default:
+			key, e := that.getSecretKey()
+			if e != nil {
+				err = errors.New(fmt.Sprintf(`resolve config "%s" failed: %s`, path, e.Error()))
+				return s
+			}
+			plain, e := DecryptValue(match[2], key)
+			if e != nil {
+				err = errors.New(fmt.Sprintf(`resolve config "%s" failed: %s`, path, e.Error()))
+				return s
+			}
+			return plain
+		}
+	})
+	if err != nil {
+		return result, err
+	}
+	// 占位符的值都来自配置文件之外，环境变量中同样可能是密码等敏感信息，输出时统一隐藏
+	if resolved {
+		secrets[path] = struct{}{}
+	}
+	return result, nil
+}
+
+// 复制配置并隐藏敏感的配置项
+func maskSecrets(data interface{}, secrets map[string]struct{}) interface{} {
+	data = copyConfigValue(data)
+	for path := range secrets {
+		var (
+			keys    = strings.Split(path, ".")
+			current = data
+		)
+		for i, key := range keys {
+			last := i == len(keys)-1
+			switch v := current.(type) {
+			case map[string]interface{}:
+				if last {
+					v[key] = secretMask
+				} else {
+					current = v[key]
+				}
+			case []interface{}:
+				index, err := strconv.Atoi(key)
+				if err != nil || index >= len(v) {
+					break
+				}
+				if last {
+					v[index] = secretMask
+				} else {
+					current = v[index]
+				}
+			}
+		}
+	}
+	return data
+}
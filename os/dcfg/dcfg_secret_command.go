@@ -0,0 +1,106 @@
+package dcfg
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/osgochina/donkeygo/os/dcmd"
+)
+
+// SecretCommand 创建管理加密配置值的命令，可以通过AddCommand添加到应用的命令树中，包含以下子命令：
+//
+//	genkey                               生成新的base64编码的密钥
+//	encrypt <value>                      加密配置值，输出${ENC:...}占位符
+//	rotate --new-key <key> <file>...     使用新的密钥重新加密配置文件中的所有加密值
+//
+// 当前密钥通过--key、--keyfile参数或者DK_DCFG_SECRET_KEY、DK_DCFG_SECRET_KEYFILE环境变量传入
+func SecretCommand() *dcmd.Command {
+	keyFlags := []*dcmd.Flag{
+		{Name: "k,key", Usage: "base64 encoded secret key", Env: "DK_DCFG_SECRET_KEY", Global: true},
+		{Name: "keyfile", Usage: "path of the secret key file", Env: "DK_DCFG_SECRET_KEYFILE", Global: true},
+	}
+	cmd := &dcmd.Command{
+		Name:  "secret",
+		Usage: "manage encrypted config values",
+		Flags: keyFlags,
+	}
+	cmd.AddCommand(
+		&dcmd.Command{
+			Name:  "genkey",
+			Usage: "generate a new secret key",
+			Run: func(ctx *dcmd.Context) error {
+				key, err := GenerateSecretKey()
+				if err != nil {
+					return err
+				}
+				_, err = fmt.Fprintln(ctx.Command.Output(), base64.StdEncoding.EncodeToString(key))
+				return err
+			},
+		},
+		&dcmd.Command{
+			Name:      "encrypt",
+			Usage:     "encrypt a config value",
+			Arguments: "<value>",
+			Run: func(ctx *dcmd.Context) error {
+				if len(ctx.GetArgAll()) != 1 {
+					return &dcmd.UsageError{Command: ctx.Command, Err: errors.New("exactly one value is required")}
+				}
+				key, err := secretKeyFromContext(ctx, "key", "keyfile")
+				if err != nil {
+					return err
+				}
+				encrypted, err := EncryptValue(ctx.GetArg(0), key)
+				if err != nil {
+					return err
+				}
+				_, err = fmt.Fprintln(ctx.Command.Output(), encrypted)
+				return err
+			},
+		},
+		&dcmd.Command{
+			Name:      "rotate",
+			Usage:     "re-encrypt config files under a new key",
+			Arguments: "<file>...",
+			Flags: []*dcmd.Flag{
+				{Name: "new-key", Usage: "base64 encoded new secret key"},
+				{Name: "new-keyfile", Usage: "path of the new secret key file"},
+			},
+			Run: func(ctx *dcmd.Context) error {
+				if len(ctx.GetArgAll()) == 0 {
+					return &dcmd.UsageError{Command: ctx.Command, Err: errors.New("at least one file is required")}
+				}
+				oldKey, err := secretKeyFromContext(ctx, "key", "keyfile")
+				if err != nil {
+					return err
+				}
+				newKey, err := secretKeyFromContext(ctx, "new-key", "new-keyfile")
+				if err != nil {
+					return err
+				}
+				for _, file := range ctx.GetArgAll() {
+					count, err := RotateFile(file, oldKey, newKey)
+					if err != nil {
+						return errors.New(fmt.Sprintf(`rotate "%s" failed: %s`, file, err.Error()))
+					}
+					_, _ = fmt.Fprintf(ctx.Command.Output(), "%s: %d value(s) re-encrypted\n", file, count)
+				}
+				return nil
+			},
+		},
+	)
+	return cmd
+}
+
+// 从命令参数中获取密钥
+func secretKeyFromContext(ctx *dcmd.Context, keyFlag, fileFlag string) ([]byte, error) {
+	if ctx.ContainsFlag(keyFlag) {
+		return ParseSecretKey(ctx.GetFlag(keyFlag).String())
+	}
+	if ctx.ContainsFlag(fileFlag) {
+		return ReadSecretKeyFile(ctx.GetFlag(fileFlag).String())
+	}
+	return nil, &dcmd.UsageError{
+		Command: ctx.Command,
+		Err:     errors.New(fmt.Sprintf(`flag "%s" or "%s" is required`, keyFlag, fileFlag)),
+	}
+}
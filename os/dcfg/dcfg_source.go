@@ -0,0 +1,275 @@
+package dcfg
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gogf/gf/encoding/gjson"
+	"github.com/osgochina/donkeygo/os/dcmd"
+	"github.com/osgochina/donkeygo/os/denv"
+	"github.com/osgochina/donkeygo/os/dfile"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	envNestingSeparator = "__"             // 环境变量名中表示层级的分隔符
+	defaultFetchTimeout = 10 * time.Second // 远程配置默认的请求超时时间
+)
+
+// Source 配置来源，多个配置来源按照添加的顺序深度合并，后添加的覆盖先添加的
+type Source interface {
+	// Name 配置来源的名字，用于报告配置项由哪一层提供
+	Name() string
+	// Load 加载配置内容
+	Load() (map[string]interface{}, error)
+}
+
+// RemoteClient 远程配置服务的客户端，如drpc提供的配置服务或者HTTP接口
+type RemoteClient interface {
+	// Fetch 获取配置内容及其格式，格式为空时自动检测
+	Fetch() (content []byte, dataType string, err error)
+}
+
+// 固定内容的配置来源
+type mapSource struct {
+	name string
+	data map[string]interface{}
+}
+
+// NewMapSource 创建固定内容的配置来源，一般作为最底层的默认配置
+func NewMapSource(name string, data map[string]interface{}) Source {
+	return &mapSource{name: name, data: data}
+}
+
+// Name 配置来源的名字
+func (that *mapSource) Name() string {
+	return that.name
+}
+
+// Load 加载配置内容
+func (that *mapSource) Load() (map[string]interface{}, error) {
+	return copyConfigValue(that.data).(map[string]interface{}), nil
+}
+
+// 配置文件来源
+type fileSource struct {
+	path     string
+	optional bool
+	config   *Config // 添加到的配置对象，相对路径在其搜索路径中查找
+}
+
+// NewFileSource 创建配置文件来源，相对路径会在配置对象的搜索路径中查找，optional为true时文件不存在不会报错
+func NewFileSource(path string, optional ...bool) Source {
+	s := &fileSource{path: path}
+	if len(optional) > 0 {
+		s.optional = optional[0]
+	}
+	return s
+}
+
+// NewFileSources 创建基础配置文件和环境覆盖配置文件两个来源，
+// 如file为config.toml，env为prod时，覆盖文件为config.prod.toml，覆盖文件可以不存在
+func NewFileSources(file string, env string) []Source {
+	sources := []Source{NewFileSource(file)}
+	if env != "" {
+		ext := filepath.Ext(file)
+		sources = append(sources, NewFileSource(strings.TrimSuffix(file, ext)+"."+env+ext, true))
+	}
+	return sources
+}
+
+// Name 配置来源的名字
+func (that *fileSource) Name() string {
+	return "file:" + that.path
+}
+
+// 获取配置文件的真实路径
+func (that *fileSource) realPath() string {
+	if path := dfile.RealPath(that.path); path != "" {
+		return path
+	}
+	if that.config != nil && !filepath.IsAbs(that.path) {
+		path, _ := that.config.GetFilePath(that.path)
+		return path
+	}
+	return ""
+}
+
+// Load 加载配置内容
+func (that *fileSource) Load() (map[string]interface{}, error) {
+	path := that.realPath()
+	if path == "" {
+		if that.optional {
+			return nil, nil
+		}
+		return nil, errors.New(fmt.Sprintf(`config file "%s" not found`, that.path))
+	}
+	j, err := gjson.LoadContentType(dfile.ExtName(path), dfile.GetContents(path), true)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf(`load config file "%s" failed: %s`, path, err.Error()))
+	}
+	return j.Map(), nil
+}
+
+// 环境变量来源
+type envSource struct {
+	prefix string
+}
+
+// NewEnvSource 创建环境变量来源，只读取以<prefix>_开头的环境变量，
+// 变量名去掉前缀后转为小写，并且使用"__"表示层级，如APP_SERVER__PORT对应server.port，
+// <prefix>不能为空，避免把所有的环境变量都导入到配置中
+func NewEnvSource(prefix string) Source {
+	return &envSource{prefix: prefix}
+}
+
+// Name 配置来源的名字
+func (that *envSource) Name() string {
+	return "env:" + that.prefix
+}
+
+// Load 加载配置内容
+func (that *envSource) Load() (map[string]interface{}, error) {
+	if that.prefix == "" {
+		return nil, errors.New("env source requires a prefix")
+	}
+	data := make(map[string]interface{})
+	prefix := strings.ToUpper(that.prefix) + "_"
+	for k, v := range denv.Map() {
+		if !strings.HasPrefix(strings.ToUpper(k), prefix) || len(k) == len(prefix) {
+			continue
+		}
+		setNestedValue(data, strings.Split(strings.ToLower(k[len(prefix):]), envNestingSeparator), v)
+	}
+	return data, nil
+}
+
+// 命令行参数来源
+type cmdSource struct {
+	prefix string
+}
+
+// NewCmdSource 创建命令行参数来源，参数名使用"."表示层级，如--server.port=80，
+// 设置了<prefix>时只读取以<prefix>.开头的参数并去掉该前缀
+func NewCmdSource(prefix ...string) Source {
+	s := &cmdSource{}
+	if len(prefix) > 0 && prefix[0] != "" {
+		s.prefix = prefix[0] + "."
+	}
+	return s
+}
+
+// Name 配置来源的名字
+func (that *cmdSource) Name() string {
+	return "cmd"
+}
+
+// Load 加载配置内容
+func (that *cmdSource) Load() (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	for k, v := range dcmd.GetOptAll() {
+		if !strings.HasPrefix(k, that.prefix) || len(k) == len(that.prefix) {
+			continue
+		}
+		setNestedValue(data, strings.Split(k[len(that.prefix):], "."), v)
+	}
+	return data, nil
+}
+
+// 远程配置来源
+type remoteSource struct {
+	name   string
+	client RemoteClient
+}
+
+// NewRemoteSource 使用远程配置服务的客户端创建配置来源
+func NewRemoteSource(name string, client RemoteClient) Source {
+	return &remoteSource{name: name, client: client}
+}
+
+// Name 配置来源的名字
+func (that *remoteSource) Name() string {
+	return that.name
+}
+
+// Load 加载配置内容
+func (that *remoteSource) Load() (map[string]interface{}, error) {
+	content, dataType, err := that.client.Fetch()
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf(`fetch remote config "%s" failed: %s`, that.name, err.Error()))
+	}
+	var j *gjson.Json
+	if dataType != "" {
+		j, err = gjson.LoadContentType(dataType, content, true)
+	} else {
+		j, err = gjson.LoadContent(content, true)
+	}
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf(`load remote config "%s" failed: %s`, that.name, err.Error()))
+	}
+	return j.Map(), nil
+}
+
+// HTTP配置接口的客户端
+type httpClient struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPSource 创建HTTP接口的远程配置来源，配置格式根据url的后缀或者响应的Content-Type检测
+func NewHTTPSource(url string, timeout ...time.Duration) Source {
+	client := &httpClient{url: url, client: &http.Client{Timeout: defaultFetchTimeout}}
+	if len(timeout) > 0 {
+		client.client.Timeout = timeout[0]
+	}
+	return NewRemoteSource("http:"+url, client)
+}
+
+// Fetch 请求配置接口
+func (that *httpClient) Fetch() ([]byte, string, error) {
+	resp, err := that.client.Get(that.url)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", errors.New(fmt.Sprintf(`unexpected status code %d`, resp.StatusCode))
+	}
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	dataType := dfile.ExtName(strings.SplitN(that.url, "?", 2)[0])
+	if !gjson.IsValidDataType(dataType) {
+		dataType = ""
+		for _, t := range supportedFileTypes {
+			if strings.Contains(resp.Header.Get("Content-Type"), t) {
+				dataType = t
+				break
+			}
+		}
+	}
+	return content, dataType, nil
+}
+
+// 按照层级设置配置值
+func setNestedValue(data map[string]interface{}, keys []string, value interface{}) {
+	for i, key := range keys {
+		if key == "" {
+			return
+		}
+		if i == len(keys)-1 {
+			data[key] = value
+			return
+		}
+		sub, ok := data[key].(map[string]interface{})
+		if !ok {
+			sub = make(map[string]interface{})
+			data[key] = sub
+		}
+		data = sub
+	}
+}
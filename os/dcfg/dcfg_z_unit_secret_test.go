@@ -0,0 +1,131 @@
+package dcfg_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"github.com/osgochina/donkeygo/os/dcfg"
+	"github.com/osgochina/donkeygo/os/dcmd"
+	"github.com/osgochina/donkeygo/os/dfile"
+	"github.com/osgochina/donkeygo/os/dtime"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_Secret_Resolve(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		key, err := dcfg.GenerateSecretKey()
+		t.Assert(err, nil)
+		encrypted, err := dcfg.EncryptValue("p@ssw0rd", key)
+		t.Assert(err, nil)
+		t.Assert(strings.HasPrefix(encrypted, "${ENC:"), true)
+		plain, err := dcfg.DecryptValue(encrypted, key)
+		t.Assert(err, nil)
+		t.Assert(plain, "p@ssw0rd")
+
+		dirPath := dfile.TempDir(dtime.TimestampNanoStr())
+		t.Assert(dfile.Mkdir(dirPath), nil)
+		defer dfile.Remove(dirPath)
+		tokenPath := dfile.Join(dirPath, "token")
+		t.Assert(dfile.PutContents(tokenPath, "file-token\n"), nil)
+		os.Setenv("DCFG_TEST_USER", "admin")
+		defer os.Unsetenv("DCFG_TEST_USER")
+		t.Assert(dfile.PutContents(dfile.Join(dirPath, "config.toml"), `
+[db]
+    dsn      = "${env:DCFG_TEST_USER}:${ENC:`+encrypted[6:len(encrypted)-1]+`}@tcp(127.0.0.1)"
+    password = "`+encrypted+`"
+    token    = "${file:`+tokenPath+`}"
+    hosts    = ["${env:DCFG_TEST_USER}"]
+`), nil)
+
+		c := dcfg.New()
+		t.Assert(c.SetPath(dirPath), nil)
+		// 没有密钥时加载失败
+		t.Assert(c.Get("db"), nil)
+
+		c.SetSecretKey(key)
+		t.Assert(c.GetString("db.dsn"), "admin:p@ssw0rd@tcp(127.0.0.1)")
+		t.Assert(c.GetString("db.password"), "p@ssw0rd")
+		t.Assert(c.GetString("db.token"), "file-token")
+		t.Assert(c.GetStrings("db.hosts"), []string{"admin"})
+
+		// 输出配置时所有占位符解析出的值都会被隐藏，包括来自环境变量的值
+		r, w, err := os.Pipe()
+		t.Assert(err, nil)
+		stdout := os.Stdout
+		os.Stdout = w
+		c.Dump()
+		os.Stdout = stdout
+		w.Close()
+		dump, _ := ioutil.ReadAll(r)
+		t.Assert(strings.Contains(string(dump), "admin"), false)
+		t.Assert(strings.Contains(string(dump), "p@ssw0rd"), false)
+		t.Assert(strings.Contains(string(dump), "file-token"), false)
+		t.Assert(strings.Count(string(dump), "******"), 4)
+
+		other, _ := dcfg.GenerateSecretKey()
+		c.SetSecretKey(other)
+		t.Assert(c.Get("db"), nil)
+
+		os.Setenv("DK_DCFG_SECRET_KEY", base64.StdEncoding.EncodeToString(key))
+		defer os.Unsetenv("DK_DCFG_SECRET_KEY")
+		c = dcfg.New()
+		t.Assert(c.SetPath(dirPath), nil)
+		t.Assert(c.GetString("db.password"), "p@ssw0rd")
+	})
+}
+
+func Test_Secret_Command(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			output = bytes.NewBuffer(nil)
+			app    = &dcmd.Command{Name: "app"}
+		)
+		app.SetOutput(output)
+		app.AddCommand(dcfg.SecretCommand())
+
+		t.Assert(app.ExecuteWithArgs([]string{"app", "secret", "genkey"}), nil)
+		oldKey := strings.TrimSpace(output.String())
+		output.Reset()
+		t.Assert(app.ExecuteWithArgs([]string{"app", "secret", "genkey"}), nil)
+		newKey := strings.TrimSpace(output.String())
+		output.Reset()
+
+		t.Assert(app.ExecuteWithArgs([]string{"app", "secret", "encrypt", "--key", oldKey, "secret"}), nil)
+		encrypted := strings.TrimSpace(output.String())
+		output.Reset()
+		key, err := dcfg.ParseSecretKey(oldKey)
+		t.Assert(err, nil)
+		plain, err := dcfg.DecryptValue(encrypted, key)
+		t.Assert(err, nil)
+		t.Assert(plain, "secret")
+
+		path := dfile.TempDir(dtime.TimestampNanoStr() + ".toml")
+		t.Assert(dfile.PutContents(path, "a = \""+encrypted+"\"\nb = \""+encrypted+"\"\n"), nil)
+		defer dfile.Remove(path)
+		keyFile := dfile.TempDir(dtime.TimestampNanoStr() + ".key")
+		t.Assert(dfile.PutContents(keyFile, newKey), nil)
+		defer dfile.Remove(keyFile)
+
+		err = app.ExecuteWithArgs([]string{"app", "secret", "rotate", "-k", oldKey, "--new-keyfile", keyFile, path})
+		t.Assert(err, nil)
+		t.Assert(output.String(), path+": 2 value(s) re-encrypted\n")
+		// 重新加密时先写入临时文件再改名，不会留下临时文件
+		files, err := ioutil.ReadDir(dfile.Dir(path))
+		t.Assert(err, nil)
+		for _, f := range files {
+			t.Assert(strings.HasPrefix(f.Name(), "."+dfile.Basename(path)+".tmp"), false)
+		}
+		key, _ = dcfg.ParseSecretKey(newKey)
+		for _, line := range strings.Split(strings.TrimSpace(dfile.GetContents(path)), "\n") {
+			plain, err = dcfg.DecryptValue(strings.Trim(strings.SplitN(line, " = ", 2)[1], `"`), key)
+			t.Assert(err, nil)
+			t.Assert(plain, "secret")
+		}
+
+		err = app.ExecuteWithArgs([]string{"app", "secret", "encrypt", "secret"})
+		t.AssertNE(err, nil)
+	})
+}
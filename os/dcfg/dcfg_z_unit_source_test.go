@@ -0,0 +1,119 @@
+package dcfg_test
+
+import (
+	"github.com/osgochina/donkeygo/os/dcfg"
+	"github.com/osgochina/donkeygo/os/dcmd"
+	"github.com/osgochina/donkeygo/os/dfile"
+	"github.com/osgochina/donkeygo/os/dtime"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func Test_Source_Layers(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		dirPath := dfile.TempDir(dtime.TimestampNanoStr())
+		t.Assert(dfile.Mkdir(dirPath), nil)
+		defer dfile.Remove(dirPath)
+		t.Assert(dfile.PutContents(dfile.Join(dirPath, "app.toml"), `
+name = "app"
+[server]
+    host = "127.0.0.1"
+    port = 80
+    tags = ["a", "b"]
+[db]
+    user = "root"
+`), nil)
+		t.Assert(dfile.PutContents(dfile.Join(dirPath, "app.prod.toml"), `
+[server]
+    port = 8080
+`), nil)
+		os.Setenv("DCFGTEST_DB__USER", "admin")
+		os.Setenv("DCFGTEST_DEBUG", "true")
+		defer os.Unsetenv("DCFGTEST_DB__USER")
+		defer os.Unsetenv("DCFGTEST_DEBUG")
+		dcmd.Init("app", "--cfg.server.host=0.0.0.0")
+		defer dcmd.Init(os.Args...)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"db": {"password": "secret"}}`))
+		}))
+		defer server.Close()
+
+		c := dcfg.New()
+		t.Assert(c.SetPath(dirPath), nil)
+		c.AddSource(dcfg.NewMapSource("defaults", map[string]interface{}{
+			"server": map[string]interface{}{"timeout": "3s", "port": 1},
+			"debug":  false,
+		}))
+		c.AddSource(dcfg.NewFileSources("app.toml", "prod")...)
+		c.AddSource(
+			dcfg.NewEnvSource("DCFGTEST"),
+			dcfg.NewCmdSource("cfg"),
+			dcfg.NewHTTPSource(server.URL),
+		)
+
+		t.Assert(c.GetString("name"), "app")
+		t.Assert(c.GetInt("server.port"), 8080)
+		t.Assert(c.GetString("server.host"), "0.0.0.0")
+		t.Assert(c.GetDuration("server.timeout").String(), "3s")
+		t.Assert(c.GetBool("debug"), true)
+		t.Assert(c.GetString("db.user"), "admin")
+		t.Assert(c.GetString("db.password"), "secret")
+
+		check := func(pattern, value, source string) {
+			v, s := c.GetWithSource(pattern)
+			t.Assert(v.String(), value)
+			t.Assert(s, source)
+		}
+		check("name", "app", "file:app.toml")
+		check("server.port", "8080", "file:app.prod.toml")
+		check("server.timeout", "3s", "defaults")
+		check("server.host", "0.0.0.0", "cmd")
+		check("server.tags.1", "b", "file:app.toml")
+		check("debug", "true", "env:DCFGTEST")
+		check("db.password", "secret", "http:"+server.URL)
+		check("server", c.GetVar("server").String(), "cmd")
+		check("none", "", "")
+
+		// 通过Get等方法获取配置项时报告来源
+		sources := make(map[string]string)
+		c.OnLookup(func(pattern string, source string) {
+			sources[pattern] = source
+		})
+		t.Assert(c.GetInt("server.port"), 8080)
+		t.Assert(c.Get("db.user"), "admin")
+		t.Assert(c.GetVar("server.timeout").String(), "3s")
+		t.Assert(c.GetString("none"), "")
+		t.Assert(sources, map[string]string{
+			"server.port":    "file:app.prod.toml",
+			"db.user":        "env:DCFGTEST",
+			"server.timeout": "defaults",
+			"none":           "",
+		})
+	})
+}
+
+func Test_Source_Error(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		c := dcfg.New()
+		c.AddSource(dcfg.NewFileSource("not-exist.toml"))
+		t.Assert(c.Get("name"), nil)
+		t.AssertNE(c.Reload(), nil)
+
+		c = dcfg.New()
+		c.AddSource(dcfg.NewFileSource("not-exist.toml", true))
+		c.AddSource(dcfg.NewMapSource("defaults", map[string]interface{}{"name": "app"}))
+		t.Assert(c.Get("name"), "app")
+		t.Assert(c.Reload(), nil)
+
+		// 环境变量来源必须设置前缀
+		c = dcfg.New()
+		c.AddSource(dcfg.NewEnvSource(""))
+		t.Assert(c.Get("path"), nil)
+		t.AssertNE(c.Reload(), nil)
+	})
+}
@@ -0,0 +1,149 @@
+package dcfg_test
+
+import (
+	"github.com/osgochina/donkeygo/container/dtype"
+	"github.com/osgochina/donkeygo/container/dvar"
+	"github.com/osgochina/donkeygo/os/dcfg"
+	"github.com/osgochina/donkeygo/os/dfile"
+	"github.com/osgochina/donkeygo/os/dtime"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"testing"
+	"time"
+)
+
+func Test_BindStruct(t *testing.T) {
+	type Redis struct {
+		Addr     string `required:"true"`
+		Db       int    `default:"1"`
+		Password string
+	}
+	type Server struct {
+		Name    string        `json:"server_name" required:"true"`
+		Port    int           `default:"8080"`
+		Timeout time.Duration `default:"3s"`
+		Redis   Redis
+	}
+	dtest.C(t, func(t *dtest.T) {
+		dirPath := dfile.TempDir(dtime.TimestampNanoStr())
+		t.Assert(dfile.Mkdir(dirPath), nil)
+		defer dfile.Remove(dirPath)
+		t.Assert(dfile.PutContents(dfile.Join(dirPath, "config.toml"), `
+[server]
+    server_name = "demo"
+    [server.redis]
+        addr = "127.0.0.1:6379"
+[bad]
+    port = 80
+`), nil)
+
+		c := dcfg.New()
+		t.Assert(c.SetPath(dirPath), nil)
+		var server Server
+		t.Assert(c.BindStruct("server", &server), nil)
+		t.Assert(server.Name, "demo")
+		t.Assert(server.Port, 8080)
+		t.Assert(server.Timeout, 3*time.Second)
+		t.Assert(server.Redis.Addr, "127.0.0.1:6379")
+		t.Assert(server.Redis.Db, 1)
+		// 默认值不会修改缓存中的配置
+		t.Assert(c.Contains("server.port"), false)
+
+		err := c.BindStruct("bad", &server)
+		t.Assert(err.Error(), `required config "bad.server_name" not set`)
+
+		var redis Redis
+		t.AssertNE(c.BindStruct("server", redis), nil)
+		t.Assert(c.BindStruct("server.redis", &redis), nil)
+		t.Assert(redis.Addr, "127.0.0.1:6379")
+	})
+}
+
+func Test_OnChange(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		dirPath := dfile.TempDir(dtime.TimestampNanoStr())
+		path := dfile.Join(dirPath, "config.toml")
+		t.Assert(dfile.Mkdir(dirPath), nil)
+		defer dfile.Remove(dirPath)
+		t.Assert(dfile.PutContents(path, "v1 = 1\nv2 = 2"), nil)
+
+		c := dcfg.New()
+		t.Assert(c.SetPath(dirPath), nil)
+		var (
+			oldValue = dtype.NewInterface()
+			newValue = dtype.NewInterface()
+			count    = dtype.NewInt()
+			errCount = dtype.NewInt()
+		)
+		c.OnChange("v1", func(old, new *dvar.Var) {
+			count.Add(1)
+			oldValue.Set(old.Int())
+			newValue.Set(new.Int())
+		})
+		c.OnReloadError(func(file string, err error) {
+			t.Assert(file, path)
+			errCount.Add(1)
+		})
+		t.Assert(c.GetInt("v1"), 1)
+
+		t.Assert(dfile.PutContents(path, "v1 = 10\nv2 = 2"), nil)
+		time.Sleep(300 * time.Millisecond)
+		t.Assert(count.Val(), 1)
+		t.Assert(oldValue.Val(), 1)
+		t.Assert(newValue.Val(), 10)
+		t.Assert(c.GetInt("v1"), 10)
+
+		// 其他配置项变更不会回调
+		t.Assert(dfile.PutContents(path, "v1 = 10\nv2 = 20"), nil)
+		time.Sleep(300 * time.Millisecond)
+		t.Assert(count.Val(), 1)
+		t.Assert(c.GetInt("v2"), 20)
+
+		// 解析失败时保留之前的配置
+		t.Assert(dfile.PutContents(path, "v1 = = 100"), nil)
+		time.Sleep(300 * time.Millisecond)
+		t.Assert(errCount.Val(), 1)
+		t.Assert(count.Val(), 1)
+		t.Assert(c.GetInt("v1"), 10)
+
+		t.Assert(dfile.PutContents(path, "v1 = 100"), nil)
+		time.Sleep(300 * time.Millisecond)
+		t.Assert(count.Val(), 2)
+		t.Assert(oldValue.Val(), 10)
+		t.Assert(c.GetInt("v1"), 100)
+	})
+	// 非默认配置文件的变更同样会通知订阅了该文件的回调
+	dtest.C(t, func(t *dtest.T) {
+		dirPath := dfile.TempDir(dtime.TimestampNanoStr())
+		t.Assert(dfile.Mkdir(dirPath), nil)
+		defer dfile.Remove(dirPath)
+		t.Assert(dfile.PutContents(dfile.Join(dirPath, "a.toml"), "v = 1"), nil)
+		t.Assert(dfile.PutContents(dfile.Join(dirPath, "b.toml"), "v = 2"), nil)
+
+		c := dcfg.New()
+		t.Assert(c.SetPath(dirPath), nil)
+		var (
+			countA = dtype.NewInt()
+			countB = dtype.NewInt()
+		)
+		c.OnChange("v", func(old, new *dvar.Var) {
+			countA.Add(1)
+		}, "a.toml")
+		c.OnChange("v", func(old, new *dvar.Var) {
+			countB.Add(1)
+		}, "b.toml")
+		c.SetFileName("a.toml")
+		t.Assert(c.GetInt("v"), 1)
+		c.SetFileName("b.toml")
+		t.Assert(c.GetInt("v"), 2)
+
+		t.Assert(dfile.PutContents(dfile.Join(dirPath, "a.toml"), "v = 10"), nil)
+		time.Sleep(300 * time.Millisecond)
+		t.Assert(countA.Val(), 1)
+		t.Assert(countB.Val(), 0)
+
+		t.Assert(dfile.PutContents(dfile.Join(dirPath, "b.toml"), "v = 20"), nil)
+		time.Sleep(300 * time.Millisecond)
+		t.Assert(countA.Val(), 1)
+		t.Assert(countB.Val(), 1)
+	})
+}
@@ -0,0 +1,347 @@
+package dcmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/osgochina/donkeygo/container/dvar"
+	"github.com/osgochina/donkeygo/os/denv"
+	"github.com/osgochina/donkeygo/util/dconv"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	helpOptionName = "h,help" // 自动添加的帮助参数
+)
+
+// ConfigGetter 读取配置的接口，参数没有从命令行和环境变量传入时从配置中读取，dcfg.Config实现了该接口
+type ConfigGetter interface {
+	// Contains 判断配置项是否存在
+	Contains(pattern string) bool
+	// GetVar 获取配置项的值
+	GetVar(pattern string, def ...interface{}) *dvar.Var
+}
+
+// Command 命令树中的一个命令，子命令通过AddCommand添加
+type Command struct {
+	Name        string                   // 命令名
+	Aliases     []string                 // 命令的别名
+	Usage       string                   // 简短说明，显示在父命令的帮助信息中
+	Description string                   // 详细说明，显示在当前命令的帮助信息中
+	Arguments   string                   // 位置参数说明，如 "<file>..."
+	Flags       []*Flag                  // 命令支持的参数
+	Run         func(ctx *Context) error // 命令的执行方法，为空时打印帮助信息
+	parent      *Command                 // 父命令
+	commands    []*Command               // 子命令列表
+	config      ConfigGetter             // 配置读取对象
+	output      io.Writer                // 帮助信息的输出
+}
+
+// UsageError 命令使用方式错误，如未知的参数、缺少必须的参数等
+type UsageError struct {
+	Command *Command // 出错的命令
+	Err     error    // 错误原因
+}
+
+// Error 实现error接口
+func (that *UsageError) Error() string {
+	return that.Err.Error()
+}
+
+// AddCommand 添加子命令
+func (that *Command) AddCommand(commands ...*Command) *Command {
+	for _, cmd := range commands {
+		cmd.parent = that
+		that.commands = append(that.commands, cmd)
+	}
+	return that
+}
+
+// Commands 获取子命令列表
+func (that *Command) Commands() []*Command {
+	return that.commands
+}
+
+// Parent 获取父命令
+func (that *Command) Parent() *Command {
+	return that.parent
+}
+
+// Root 获取根命令
+func (that *Command) Root() *Command {
+	root := that
+	for root.parent != nil {
+		root = root.parent
+	}
+	return root
+}
+
+// FullName 获取从根命令开始的完整命令名，如 "app server start"
+func (that *Command) FullName() string {
+	if that.parent == nil {
+		return that.Name
+	}
+	return that.parent.FullName() + " " + that.Name
+}
+
+// SetConfig 设置参数读取的配置对象，子命令会继承父命令的配置
+func (that *Command) SetConfig(config ConfigGetter) {
+	that.config = config
+}
+
+// SetOutput 设置帮助信息的输出，默认为标准输出
+func (that *Command) SetOutput(w io.Writer) {
+	that.output = w
+}
+
+// 获取配置对象，当前命令没有设置则使用父命令的
+func (that *Command) getConfig() ConfigGetter {
+	for cmd := that; cmd != nil; cmd = cmd.parent {
+		if cmd.config != nil {
+			return cmd.config
+		}
+	}
+	return nil
+}
+
+// Output 获取命令的输出，当前命令没有设置则使用父命令的，默认为标准输出
+func (that *Command) Output() io.Writer {
+	for cmd := that; cmd != nil; cmd = cmd.parent {
+		if cmd.output != nil {
+			return cmd.output
+		}
+	}
+	return os.Stdout
+}
+
+// 通过名字或别名查找子命令
+func (that *Command) findCommand(name string) *Command {
+	for _, cmd := range that.commands {
+		if cmd.Name == name {
+			return cmd
+		}
+		for _, alias := range cmd.Aliases {
+			if alias == name {
+				return cmd
+			}
+		}
+	}
+	return nil
+}
+
+// 当前命令可用的所有参数，包括父命令中的全局参数
+func (that *Command) allFlags() []*Flag {
+	flags := make([]*Flag, 0, len(that.Flags))
+	flags = append(flags, that.Flags...)
+	for cmd := that.parent; cmd != nil; cmd = cmd.parent {
+		for _, flag := range cmd.Flags {
+			if flag.Global {
+				flags = append(flags, flag)
+			}
+		}
+	}
+	return flags
+}
+
+// 构造Parser需要的参数定义
+func (that *Command) supportedOptions() map[string]bool {
+	options := map[string]bool{
+		helpOptionName: false,
+	}
+	for _, flag := range that.allFlags() {
+		options[flag.Name] = flag.needArgument()
+	}
+	return options
+}
+
+// 命令在命令树中的深度，根命令为0
+func (that *Command) depth() int {
+	depth := 0
+	for cmd := that.parent; cmd != nil; cmd = cmd.parent {
+		depth++
+	}
+	return depth
+}
+
+// Execute 使用os.Args执行命令，使用方式错误时会输出错误和帮助信息
+func (that *Command) Execute() error {
+	err := that.ExecuteWithArgs(os.Args)
+	if e, ok := err.(*UsageError); ok {
+		_, _ = fmt.Fprintf(e.Command.Output(), "Error: %s\n\n%s", e.Err.Error(), e.Command.Help())
+	}
+	return err
+}
+
+// ExecuteWithArgs 使用指定的参数执行命令，args[0]为程序名
+func (that *Command) ExecuteWithArgs(args []string) error {
+	// 根据位置参数逐层查找子命令
+	cmd := that
+	for {
+		parser, _ := ParseWithArgs(args, cmd.supportedOptions())
+		next := parser.GetArg(cmd.depth() + 1)
+		if next == "" {
+			break
+		}
+		child := cmd.findCommand(next)
+		if child == nil {
+			break
+		}
+		cmd = child
+	}
+	parser, err := ParseWithArgs(args, cmd.supportedOptions(), true)
+	if err != nil {
+		return &UsageError{Command: cmd, Err: err}
+	}
+	if parser.ContainsOpt("help") {
+		_, _ = fmt.Fprint(cmd.Output(), cmd.Help())
+		return nil
+	}
+	ctx := &Context{
+		Command: cmd,
+		Parser:  parser,
+		values:  make(map[string]interface{}),
+	}
+	if all := parser.GetArgAll(); len(all) > cmd.depth()+1 {
+		ctx.args = all[cmd.depth()+1:]
+	}
+	if cmd.Run == nil {
+		if len(cmd.commands) > 0 && len(ctx.args) > 0 {
+			return &UsageError{Command: cmd, Err: errors.New(fmt.Sprintf(`unknown command "%s"`, ctx.args[0]))}
+		}
+		_, _ = fmt.Fprint(cmd.Output(), cmd.Help())
+		return nil
+	}
+	for _, flag := range cmd.allFlags() {
+		value, found, err := cmd.resolveFlag(flag, parser)
+		if err != nil {
+			return &UsageError{Command: cmd, Err: err}
+		}
+		if !found {
+			if flag.Required {
+				return &UsageError{Command: cmd, Err: errors.New(fmt.Sprintf(`required flag "%s" not set`, flag.name()))}
+			}
+			continue
+		}
+		for _, name := range flag.names() {
+			ctx.values[name] = value
+		}
+	}
+	return cmd.Run(ctx)
+}
+
+// 按照 命令行 -> 环境变量 -> 配置 -> 默认值 的顺序获取参数值
+func (that *Command) resolveFlag(flag *Flag, parser *Parser) (value interface{}, found bool, err error) {
+	for _, name := range flag.names() {
+		if parser.ContainsOpt(name) {
+			value, err = flag.parse(parser.GetOpt(name))
+			return value, err == nil, err
+		}
+	}
+	if flag.Env != "" {
+		if denv.Contains(flag.Env) {
+			value, err = flag.parse(denv.Get(flag.Env))
+			return value, err == nil, err
+		}
+	}
+	if config := that.getConfig(); config != nil && flag.Config != "" && config.Contains(flag.Config) {
+		value, err = flag.parse(config.GetVar(flag.Config).String())
+		return value, err == nil, err
+	}
+	if flag.Default != nil {
+		value, err = flag.parseDefault()
+		return value, err == nil, err
+	}
+	return nil, false, nil
+}
+
+// Context 命令执行时的上下文
+type Context struct {
+	Command *Command               // 正在执行的命令
+	Parser  *Parser                // 命令行的解析结果
+	args    []string               // 去掉命令路径后的位置参数
+	values  map[string]interface{} // 解析后的参数值
+}
+
+// GetArg 获取位置参数，不包含程序名和命令路径
+func (that *Context) GetArg(index int, def ...string) string {
+	if index < len(that.args) {
+		return that.args[index]
+	}
+	if len(def) > 0 {
+		return def[0]
+	}
+	return ""
+}
+
+// GetArgAll 获取全部位置参数，不包含程序名和命令路径
+func (that *Context) GetArgAll() []string {
+	return that.args
+}
+
+// GetFlag 获取参数值，可以使用参数的任意名字
+func (that *Context) GetFlag(name string) *dvar.Var {
+	return dvar.New(that.values[name])
+}
+
+// ContainsFlag 判断参数是否有值
+func (that *Context) ContainsFlag(name string) bool {
+	_, ok := that.values[name]
+	return ok
+}
+
+// Bind 把参数值绑定到结构体，参数名与属性名的映射规则与dconv.Struct相同
+func (that *Context) Bind(pointer interface{}) error {
+	params := make(map[string]interface{}, len(that.values))
+	for _, flag := range that.Command.allFlags() {
+		if value, ok := that.values[flag.name()]; ok {
+			params[flag.name()] = value
+		}
+	}
+	return dconv.Struct(params, pointer)
+}
+
+// Help 生成命令的帮助信息
+func (that *Command) Help() string {
+	var b strings.Builder
+	usage := that.FullName()
+	if len(that.allFlags()) > 0 {
+		usage += " [flags]"
+	}
+	if len(that.commands) > 0 {
+		usage += " <command>"
+	}
+	if that.Arguments != "" {
+		usage += " " + that.Arguments
+	}
+	b.WriteString("Usage: " + usage + "\n")
+	if that.Description != "" {
+		b.WriteString("\n" + that.Description + "\n")
+	} else if that.Usage != "" {
+		b.WriteString("\n" + that.Usage + "\n")
+	}
+	if len(that.commands) > 0 {
+		b.WriteString("\nCommands:\n")
+		width := 0
+		for _, cmd := range that.commands {
+			if len(cmd.Name) > width {
+				width = len(cmd.Name)
+			}
+		}
+		for _, cmd := range that.commands {
+			b.WriteString(fmt.Sprintf("  %-*s  %s\n", width, cmd.Name, cmd.Usage))
+		}
+	}
+	flags := append(that.allFlags(), &Flag{Name: helpOptionName, Type: FlagBool, Usage: "show help"})
+	b.WriteString("\nFlags:\n")
+	width := 0
+	for _, flag := range flags {
+		if len(flag.display()) > width {
+			width = len(flag.display())
+		}
+	}
+	for _, flag := range flags {
+		b.WriteString(fmt.Sprintf("  %-*s  %s\n", width, flag.display(), flag.description()))
+	}
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package dcmd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 生成脚本中函数名可以使用的字符
+var completionFuncNameRegex = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// Completion 生成shell自动补全脚本，shell支持bash和zsh
+func (that *Command) Completion(shell string) (string, error) {
+	switch shell {
+	case "bash":
+		return that.Root().bashCompletion(), nil
+	case "zsh":
+		return "autoload -U +X bashcompinit && bashcompinit\n" + that.Root().bashCompletion(), nil
+	default:
+		return "", errors.New(fmt.Sprintf(`unsupported shell "%s"`, shell))
+	}
+}
+
+// 生成bash自动补全脚本，根据已输入的子命令路径给出候选的子命令和参数
+func (that *Command) bashCompletion() string {
+	funcName := "_" + completionFuncNameRegex.ReplaceAllString(that.Name, "_") + "_completion"
+	var b strings.Builder
+	b.WriteString(funcName + "() {\n")
+	b.WriteString("    local cur path i opts\n")
+	b.WriteString("    cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    path=\"\"\n")
+	b.WriteString("    for ((i=1; i<COMP_CWORD; i++)); do\n")
+	b.WriteString("        case \"${COMP_WORDS[i]}\" in\n")
+	b.WriteString("            -*) ;;\n")
+	b.WriteString("            *) path=\"${path} ${COMP_WORDS[i]}\" ;;\n")
+	b.WriteString("        esac\n")
+	b.WriteString("    done\n")
+	b.WriteString("    case \"${path# }\" in\n")
+	that.writeBashCases(&b, "")
+	b.WriteString("        *) opts=\"\" ;;\n")
+	b.WriteString("    esac\n")
+	b.WriteString("    COMPREPLY=( $(compgen -W \"${opts}\" -- \"${cur}\") )\n")
+	b.WriteString("}\n")
+	b.WriteString(fmt.Sprintf("complete -F %s %s\n", funcName, that.Name))
+	return b.String()
+}
+
+// 递归写入每个子命令路径对应的候选项
+func (that *Command) writeBashCases(b *strings.Builder, path string) {
+	var words []string
+	for _, cmd := range that.commands {
+		words = append(words, cmd.Name)
+		words = append(words, cmd.Aliases...)
+	}
+	for _, flag := range that.allFlags() {
+		for _, name := range flag.names() {
+			if len(name) > 1 {
+				words = append(words, "--"+name)
+			}
+		}
+	}
+	words = append(words, "--help")
+	b.WriteString(fmt.Sprintf("        \"%s\") opts=\"%s\" ;;\n", path, strings.Join(words, " ")))
+	for _, cmd := range that.commands {
+		names := append([]string{cmd.Name}, cmd.Aliases...)
+		for _, name := range names {
+			cmd.writeBashCases(b, strings.TrimSpace(path+" "+name))
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package dcmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/osgochina/donkeygo/text/dstr"
+	"github.com/osgochina/donkeygo/util/dconv"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FlagType 参数值的类型
+type FlagType int
+
+const (
+	FlagString   FlagType = iota // 字符串
+	FlagBool                     // 布尔值，不需要跟随参数值
+	FlagInt                      // 整数
+	FlagFloat                    // 浮点数
+	FlagDuration                 // 时间间隔，如 1m30s
+	FlagStrings                  // 使用逗号分隔的字符串列表
+)
+
+// Flag 命令的参数定义
+type Flag struct {
+	Name     string      // 参数名，使用逗号分隔别名，如 "p,port"，最长的名字作为主名字
+	Usage    string      // 参数说明
+	Type     FlagType    // 参数值类型
+	Default  interface{} // 默认值
+	Required bool        // 是否必须传入
+	Enum     []string    // 可选值列表，为空则不限制
+	Env      string      // 命令行没有传入时读取的环境变量名
+	Config   string      // 命令行和环境变量都没有时读取的配置项
+	Global   bool        // 是否对所有子命令生效
+}
+
+// 所有名字，包括别名
+func (that *Flag) names() []string {
+	return dstr.SplitAndTrim(that.Name, ",")
+}
+
+// 主名字，取最长的名字
+func (that *Flag) name() string {
+	name := ""
+	for _, v := range that.names() {
+		if len(v) > len(name) {
+			name = v
+		}
+	}
+	return name
+}
+
+// 是否需要跟随参数值
+func (that *Flag) needArgument() bool {
+	return that.Type != FlagBool
+}
+
+// 把字符串转换为参数类型对应的值，并校验可选值
+func (that *Flag) parse(value string) (interface{}, error) {
+	if len(that.Enum) > 0 {
+		values := []string{value}
+		if that.Type == FlagStrings {
+			values = dstr.SplitAndTrim(value, ",")
+		}
+		for _, v := range values {
+			if !that.inEnum(v) {
+				return nil, errors.New(fmt.Sprintf(`invalid value "%s" for flag "%s", allowed: %s`, v, that.name(), strings.Join(that.Enum, ",")))
+			}
+		}
+	}
+	switch that.Type {
+	case FlagBool:
+		if value == "" {
+			return true, nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf(`invalid bool value "%s" for flag "%s"`, value, that.name()))
+		}
+		return b, nil
+	case FlagInt:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf(`invalid int value "%s" for flag "%s"`, value, that.name()))
+		}
+		return i, nil
+	case FlagFloat:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf(`invalid float value "%s" for flag "%s"`, value, that.name()))
+		}
+		return f, nil
+	case FlagDuration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf(`invalid duration value "%s" for flag "%s"`, value, that.name()))
+		}
+		return d, nil
+	case FlagStrings:
+		return dstr.SplitAndTrim(value, ","), nil
+	default:
+		return value, nil
+	}
+}
+
+// 默认值先转换为字符串，再和命令行传入的值一样经过可选值校验和类型转换
+func (that *Flag) parseDefault() (interface{}, error) {
+	if v, ok := that.Default.([]string); ok {
+		return that.parse(strings.Join(v, ","))
+	}
+	return that.parse(dconv.String(that.Default))
+}
+
+// 判断值是否在可选值列表中
+func (that *Flag) inEnum(value string) bool {
+	for _, v := range that.Enum {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// 帮助信息中参数名部分，如 "-p, --port int"
+func (that *Flag) display() string {
+	var parts []string
+	for _, v := range that.names() {
+		if len(v) == 1 {
+			parts = append(parts, "-"+v)
+		} else {
+			parts = append(parts, "--"+v)
+		}
+	}
+	s := strings.Join(parts, ", ")
+	switch that.Type {
+	case FlagString:
+		s += " string"
+	case FlagInt:
+		s += " int"
+	case FlagFloat:
+		s += " float"
+	case FlagDuration:
+		s += " duration"
+	case FlagStrings:
+		s += " strings"
+	}
+	return s
+}
+
+// 帮助信息中参数说明部分，包含默认值、可选值等
+func (that *Flag) description() string {
+	s := that.Usage
+	var extra []string
+	if that.Required {
+		extra = append(extra, "required")
+	}
+	if len(that.Enum) > 0 {
+		extra = append(extra, "one of: "+strings.Join(that.Enum, "|"))
+	}
+	if that.Default != nil {
+		extra = append(extra, fmt.Sprintf("default: %v", that.Default))
+	}
+	if that.Env != "" {
+		extra = append(extra, "env: "+that.Env)
+	}
+	if len(extra) > 0 {
+		s += " (" + strings.Join(extra, ", ") + ")"
+	}
+	return s
+}
@@ -0,0 +1,248 @@
+package dcmd_test
+
+import (
+	"bytes"
+	"github.com/osgochina/donkeygo/container/dvar"
+	"github.com/osgochina/donkeygo/os/dcmd"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testConfig map[string]interface{}
+
+func (that testConfig) Contains(pattern string) bool {
+	_, ok := that[pattern]
+	return ok
+}
+
+func (that testConfig) GetVar(pattern string, def ...interface{}) *dvar.Var {
+	if v, ok := that[pattern]; ok {
+		return dvar.New(v)
+	}
+	if len(def) > 0 {
+		return dvar.New(def[0])
+	}
+	return dvar.New(nil)
+}
+
+func newTestApp(output *bytes.Buffer, result *map[string]interface{}) *dcmd.Command {
+	app := &dcmd.Command{
+		Name:  "app",
+		Usage: "test application",
+		Flags: []*dcmd.Flag{
+			{Name: "v,verbose", Type: dcmd.FlagBool, Usage: "verbose output", Global: true},
+		},
+	}
+	app.SetOutput(output)
+	server := &dcmd.Command{
+		Name:  "server",
+		Usage: "server commands",
+	}
+	start := &dcmd.Command{
+		Name:      "start",
+		Aliases:   []string{"run"},
+		Usage:     "start the server",
+		Arguments: "<name>",
+		Flags: []*dcmd.Flag{
+			{Name: "p,port", Type: dcmd.FlagInt, Default: int64(8080), Usage: "listen port"},
+			{Name: "mode", Type: dcmd.FlagString, Enum: []string{"dev", "prod"}, Required: true, Env: "DCMD_TEST_MODE", Config: "server.mode"},
+			{Name: "timeout", Type: dcmd.FlagDuration, Default: time.Second},
+			{Name: "tags", Type: dcmd.FlagStrings},
+		},
+		Run: func(ctx *dcmd.Context) error {
+			(*result)["name"] = ctx.GetArg(0)
+			(*result)["port"] = ctx.GetFlag("p").Int()
+			(*result)["mode"] = ctx.GetFlag("mode").String()
+			(*result)["verbose"] = ctx.GetFlag("verbose").Bool()
+			(*result)["tags"] = ctx.GetFlag("tags").Strings()
+			var opts struct {
+				Port    int
+				Mode    string
+				Timeout time.Duration
+				Verbose bool
+			}
+			if err := ctx.Bind(&opts); err != nil {
+				return err
+			}
+			(*result)["bind"] = opts
+			return nil
+		},
+	}
+	server.AddCommand(start)
+	app.AddCommand(server)
+	return app
+}
+
+func Test_Command_Execute(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			output = bytes.NewBuffer(nil)
+			result = make(map[string]interface{})
+			app    = newTestApp(output, &result)
+		)
+		err := app.ExecuteWithArgs([]string{"app", "server", "start", "-v", "--port", "9000", "--mode=prod", "--tags", "a,b", "web"})
+		t.Assert(err, nil)
+		t.Assert(result["name"], "web")
+		t.Assert(result["port"], 9000)
+		t.Assert(result["mode"], "prod")
+		t.Assert(result["verbose"], true)
+		t.Assert(result["tags"], []string{"a", "b"})
+
+		err = app.ExecuteWithArgs([]string{"app", "server", "run", "--mode", "dev"})
+		t.Assert(err, nil)
+		t.Assert(result["port"], 8080)
+		t.Assert(result["verbose"], false)
+		t.Assert(result["name"], "")
+	})
+}
+
+func Test_Command_Bind(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			output = bytes.NewBuffer(nil)
+			result = make(map[string]interface{})
+			app    = newTestApp(output, &result)
+		)
+		err := app.ExecuteWithArgs([]string{"app", "server", "start", "--mode", "dev", "--timeout", "3s", "-p", "81", "-v"})
+		t.Assert(err, nil)
+		opts := result["bind"].(struct {
+			Port    int
+			Mode    string
+			Timeout time.Duration
+			Verbose bool
+		})
+		t.Assert(opts.Port, 81)
+		t.Assert(opts.Mode, "dev")
+		t.Assert(opts.Timeout, 3*time.Second)
+		t.Assert(opts.Verbose, true)
+	})
+}
+
+func Test_Command_Fallback(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			output = bytes.NewBuffer(nil)
+			result = make(map[string]interface{})
+			app    = newTestApp(output, &result)
+		)
+		app.SetConfig(testConfig{"server.mode": "dev"})
+		err := app.ExecuteWithArgs([]string{"app", "server", "start"})
+		t.Assert(err, nil)
+		t.Assert(result["mode"], "dev")
+
+		os.Setenv("DCMD_TEST_MODE", "prod")
+		defer os.Unsetenv("DCMD_TEST_MODE")
+		err = app.ExecuteWithArgs([]string{"app", "server", "start"})
+		t.Assert(err, nil)
+		t.Assert(result["mode"], "prod")
+	})
+}
+
+func Test_Command_UsageError(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			output = bytes.NewBuffer(nil)
+			result = make(map[string]interface{})
+			app    = newTestApp(output, &result)
+		)
+		err := app.ExecuteWithArgs([]string{"app", "server", "start"})
+		t.AssertNE(err, nil)
+		usageErr, ok := err.(*dcmd.UsageError)
+		t.Assert(ok, true)
+		t.Assert(usageErr.Command.FullName(), "app server start")
+		t.Assert(err.Error(), `required flag "mode" not set`)
+
+		err = app.ExecuteWithArgs([]string{"app", "server", "start", "--mode", "test"})
+		t.Assert(err.Error(), `invalid value "test" for flag "mode", allowed: dev,prod`)
+
+		err = app.ExecuteWithArgs([]string{"app", "server", "start", "--mode", "dev", "--port", "abc"})
+		t.Assert(err.Error(), `invalid int value "abc" for flag "port"`)
+
+		err = app.ExecuteWithArgs([]string{"app", "server", "start", "--mode", "dev", "--unknown"})
+		t.AssertNE(err, nil)
+
+		err = app.ExecuteWithArgs([]string{"app", "server", "stop"})
+		t.Assert(err.Error(), `unknown command "stop"`)
+	})
+	// 默认值同样需要经过可选值校验和类型转换
+	dtest.C(t, func(t *dtest.T) {
+		var values map[string]interface{}
+		app := &dcmd.Command{
+			Name: "app",
+			Flags: []*dcmd.Flag{
+				{Name: "level", Type: dcmd.FlagString, Enum: []string{"debug", "info"}, Default: "trace"},
+			},
+			Run: func(ctx *dcmd.Context) error {
+				return nil
+			},
+		}
+		err := app.ExecuteWithArgs([]string{"app"})
+		t.Assert(err.Error(), `invalid value "trace" for flag "level", allowed: debug,info`)
+
+		app.Flags = []*dcmd.Flag{
+			{Name: "port", Type: dcmd.FlagInt, Default: "8080"},
+			{Name: "timeout", Type: dcmd.FlagDuration, Default: "2s"},
+			{Name: "tags", Type: dcmd.FlagStrings, Default: []string{"a", "b"}},
+		}
+		app.Run = func(ctx *dcmd.Context) error {
+			values = map[string]interface{}{
+				"port":    ctx.GetFlag("port").Val(),
+				"timeout": ctx.GetFlag("timeout").Val(),
+				"tags":    ctx.GetFlag("tags").Val(),
+			}
+			return nil
+		}
+		err = app.ExecuteWithArgs([]string{"app"})
+		t.Assert(err, nil)
+		t.Assert(values["port"], int64(8080))
+		t.Assert(values["timeout"], 2*time.Second)
+		t.Assert(values["tags"], []string{"a", "b"})
+	})
+}
+
+func Test_Command_Help(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			output = bytes.NewBuffer(nil)
+			result = make(map[string]interface{})
+			app    = newTestApp(output, &result)
+		)
+		t.Assert(app.ExecuteWithArgs([]string{"app", "server", "start", "--help"}), nil)
+		help := output.String()
+		t.Assert(strings.HasPrefix(help, "Usage: app server start [flags] <name>"), true)
+		t.Assert(strings.Contains(help, "-p, --port int"), true)
+		t.Assert(strings.Contains(help, "default: 8080"), true)
+		t.Assert(strings.Contains(help, "required, one of: dev|prod"), true)
+		t.Assert(strings.Contains(help, "-v, --verbose"), true)
+		t.Assert(len(result), 0)
+
+		output.Reset()
+		t.Assert(app.ExecuteWithArgs([]string{"app"}), nil)
+		t.Assert(strings.Contains(output.String(), "server  server commands"), true)
+	})
+}
+
+func Test_Command_Completion(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			output = bytes.NewBuffer(nil)
+			result = make(map[string]interface{})
+			app    = newTestApp(output, &result)
+		)
+		script, err := app.Completion("bash")
+		t.Assert(err, nil)
+		t.Assert(strings.Contains(script, "complete -F _app_completion app"), true)
+		t.Assert(strings.Contains(script, `"server") opts="start run --verbose --help" ;;`), true)
+		t.Assert(strings.Contains(script, `"server start") opts="--port --mode --timeout --tags --verbose --help" ;;`), true)
+
+		script, err = app.Completion("zsh")
+		t.Assert(err, nil)
+		t.Assert(strings.HasPrefix(script, "autoload -U +X bashcompinit"), true)
+
+		_, err = app.Completion("cmd")
+		t.AssertNE(err, nil)
+	})
+}
@@ -44,6 +44,31 @@ func Add(pattern string, job func(), name ...string) (*Entry, error) {
 	return defaultCron.Add(pattern, job, name...)
 }
 
+// SetLocker 设置分布式锁，保证多个进程中的同名任务只有一个在运行
+func SetLocker(locker Locker, ttl ...time.Duration) {
+	defaultCron.SetLocker(locker, ttl...)
+}
+
+// AddJob 添加一个支持上下文和返回错误的定时任务到处理器
+func AddJob(pattern string, job JobFunc, name ...string) (*Entry, error) {
+	return defaultCron.AddJob(pattern, job, name...)
+}
+
+// OnStart 设置任务开始运行时的钩子方法
+func OnStart(f func(entry *Entry)) {
+	defaultCron.OnStart(f)
+}
+
+// OnSuccess 设置任务运行成功后的钩子方法
+func OnSuccess(f func(entry *Entry, run JobRun)) {
+	defaultCron.OnSuccess(f)
+}
+
+// OnError 设置任务运行失败后的钩子方法
+func OnError(f func(entry *Entry, run JobRun)) {
+	defaultCron.OnError(f)
+}
+
 // AddSingleton 添加一个并发限制的定时任务到处理器
 func AddSingleton(pattern string, job func(), name ...string) (*Entry, error) {
 	return defaultCron.AddSingleton(pattern, job, name...)
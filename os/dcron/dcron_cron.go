@@ -1,6 +1,7 @@
 package dcron
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/osgochina/donkeygo/container/darray"
@@ -11,30 +12,42 @@ import (
 	"github.com/osgochina/donkeygo/util/dconv"
 	"reflect"
 	"runtime"
+	"sync"
 	"time"
 )
 
 type Cron struct {
-	idGen    *dtype.Int64    // id生成器
-	status   *dtype.Int      // 状态
-	entries  *dmap.StrAnyMap // 任务列表
-	logPath  *dtype.String   //日志存储路径
-	logLevel *dtype.Int      //日志级别
+	idGen    *dtype.Int64                   // id生成器
+	status   *dtype.Int                     // 状态
+	entries  *dmap.StrAnyMap                // 任务列表
+	logPath  *dtype.String                  //日志存储路径
+	logLevel *dtype.Int                     //日志级别
+	ctx      context.Context                // 任务运行的上下文，关闭定时任务器时会被取消
+	cancel   context.CancelFunc             // 取消任务上下文
+	hooksMu  sync.RWMutex                   // 钩子方法的读写锁
+	onStart  func(entry *Entry)             // 任务开始运行的钩子
+	onOk     func(entry *Entry, run JobRun) // 任务运行成功的钩子
+	onErr    func(entry *Entry, run JobRun) // 任务运行失败的钩子
+	locker   *dtype.Interface               // 分布式锁配置
 }
 
 // NewCron 创建Cron对象
 func NewCron() *Cron {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Cron{
 		idGen:    dtype.NewInt64(),
 		status:   dtype.NewInt(StatusRunning),
 		entries:  dmap.NewStrAnyMap(true),
 		logPath:  dtype.NewString(),
 		logLevel: dtype.NewInt(dlog.LevelProd),
+		ctx:      ctx,
+		cancel:   cancel,
+		locker:   dtype.NewInterface(),
 	}
 }
 
-//添加定时任务
-func (that *Cron) addEntry(pattern string, job func(), singleton bool, name ...string) (*Entry, error) {
+// 添加定时任务
+func (that *Cron) addEntry(pattern string, job func(), jobCtx JobFunc, jobName string, singleton bool, name ...string) (*Entry, error) {
 	schedule, err := newSchedule(pattern)
 	if err != nil {
 		return nil, err
@@ -42,15 +55,21 @@ func (that *Cron) addEntry(pattern string, job func(), singleton bool, name ...s
 	entry := &Entry{
 		cron:     that,
 		schedule: schedule,
-		jobName:  runtime.FuncForPC(reflect.ValueOf(job).Pointer()).Name(),
+		jobName:  jobName,
 		times:    dtype.NewInt(defaultTimes),
+		timeout:  dtype.NewInt64(),
+		history:  newJobHistory(defaultHistorySize),
+		jobCtx:   jobCtx,
 		Job:      job,
 		Time:     time.Now(),
 	}
 	if len(name) > 0 {
 		entry.Name = name[0]
+		entry.lockKey = entry.Name
 	} else {
+		// 自动生成的任务名与添加顺序有关，不同进程中可能不一致，所以租约名使用规则和方法名
 		entry.Name = "dcron-" + dconv.String(that.idGen.Add(1))
+		entry.lockKey = pattern + "@" + jobName
 	}
 
 	// 定时任务每秒检查一次，看看是否要执行
@@ -80,6 +99,63 @@ func (that *Cron) GetLogLevel() int {
 	return that.logLevel.Val()
 }
 
+// SetLocker 设置分布式锁，设置后任务运行前需要先获取以任务名命名的租约，
+// 多个进程中同名的任务只有持有租约的进程会运行，未指定任务名的任务使用规则和方法名作为租约名。
+// ttl为租约时长，最小为1秒，任务运行期间会自动续约
+func (that *Cron) SetLocker(locker Locker, ttl ...time.Duration) {
+	if locker == nil {
+		that.locker.Set((*cronLocker)(nil))
+		return
+	}
+	l := &cronLocker{
+		locker: locker,
+		ttl:    defaultLockTTL,
+	}
+	if len(ttl) > 0 && ttl[0] > 0 {
+		l.ttl = ttl[0]
+	}
+	if l.ttl < minLockTTL {
+		l.ttl = minLockTTL
+	}
+	that.locker.Set(l)
+}
+
+// 获取分布式锁配置
+func (that *Cron) getLocker() *cronLocker {
+	if v := that.locker.Val(); v != nil {
+		return v.(*cronLocker)
+	}
+	return nil
+}
+
+// OnStart 设置任务开始运行时的钩子方法
+func (that *Cron) OnStart(f func(entry *Entry)) {
+	that.hooksMu.Lock()
+	defer that.hooksMu.Unlock()
+	that.onStart = f
+}
+
+// OnSuccess 设置任务运行成功后的钩子方法
+func (that *Cron) OnSuccess(f func(entry *Entry, run JobRun)) {
+	that.hooksMu.Lock()
+	defer that.hooksMu.Unlock()
+	that.onOk = f
+}
+
+// OnError 设置任务运行失败后的钩子方法，任务返回错误或者panic都视为失败
+func (that *Cron) OnError(f func(entry *Entry, run JobRun)) {
+	that.hooksMu.Lock()
+	defer that.hooksMu.Unlock()
+	that.onErr = f
+}
+
+// 获取当前设置的钩子方法
+func (that *Cron) hooks() (onStart func(*Entry), onOk func(*Entry, JobRun), onErr func(*Entry, JobRun)) {
+	that.hooksMu.RLock()
+	defer that.hooksMu.RUnlock()
+	return that.onStart, that.onOk, that.onErr
+}
+
 // Add 添加定时任务
 func (that *Cron) Add(pattern string, job func(), name ...string) (*Entry, error) {
 	if len(name) > 0 {
@@ -87,7 +163,17 @@ func (that *Cron) Add(pattern string, job func(), name ...string) (*Entry, error
 			return nil, errors.New(fmt.Sprintf(`cron job "%s" already exists`, name[0]))
 		}
 	}
-	return that.addEntry(pattern, job, false, name...)
+	return that.addEntry(pattern, job, nil, funcName(job), false, name...)
+}
+
+// AddJob 添加支持上下文和返回错误的定时任务
+func (that *Cron) AddJob(pattern string, job JobFunc, name ...string) (*Entry, error) {
+	if len(name) > 0 {
+		if that.Search(name[0]) != nil {
+			return nil, errors.New(fmt.Sprintf(`cron job "%s" already exists`, name[0]))
+		}
+	}
+	return that.addEntry(pattern, func() { _ = job(that.ctx) }, job, funcName(job), false, name...)
 }
 
 // AddSingleton 添加单例模式的定时任务
@@ -197,9 +283,10 @@ func (that *Cron) Remove(name string) {
 	}
 }
 
-// Close 关闭定时任务器
+// Close 关闭定时任务器，正在运行的任务的ctx会被取消
 func (that *Cron) Close() {
 	that.status.Set(StatusClosed)
+	that.cancel()
 }
 
 // Size 有多少个定时任务在处理器中
@@ -230,3 +317,8 @@ func (that *Cron) Entries() []*Entry {
 	})
 	return entries
 }
+
+// 获取方法名
+func funcName(f interface{}) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
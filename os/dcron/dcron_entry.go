@@ -1,7 +1,9 @@
 package dcron
 
 import (
+	"context"
 	"github.com/osgochina/donkeygo/container/dtype"
+	"github.com/osgochina/donkeygo/errors/derror"
 	"github.com/osgochina/donkeygo/os/dlog"
 	"github.com/osgochina/donkeygo/os/dtimer"
 	"time"
@@ -14,6 +16,10 @@ type Entry struct {
 	schedule *cronSchedule // cron规则
 	jobName  string        // 任务的方法名
 	times    *dtype.Int    // 任务可以运行的次数
+	timeout  *dtype.Int64  // 任务单次运行的超时时间，0表示不限制
+	history  *jobHistory   // 任务的运行记录
+	jobCtx   JobFunc       // 支持上下文和返回错误的任务方法，通过AddJob添加时设置，优先于Job执行
+	lockKey  string        // 分布式锁的租约名，未指定任务名时由规则和方法名生成，保证多个进程中相同的任务使用相同的租约
 	Name     string        // 任务的自定义名字
 	Job      func()        //任务处理方法地址
 	Time     time.Time     // 任务建立时间
@@ -32,6 +38,27 @@ func (that *Entry) check() {
 		case StatusReady:
 			fallthrough
 		case StatusRunning:
+			locker := that.cron.getLocker()
+			if locker != nil {
+				ok, err := locker.locker.Lock(that.lockKey, locker.ttl)
+				if err != nil {
+					dlog.Path(path).Level(level).Errorf("[dcron] %s(%s) %s lock failed: %v", that.Name, that.schedule.pattern, that.jobName, err)
+				}
+				if !ok {
+					return
+				}
+				// 任务运行结束后释放租约，租约时长只用于进程崩溃时的兜底，
+				// 运行很快的任务至少持有租约到本秒结束，避免其他节点在同一秒重复运行
+				tickEnd := time.Now().Truncate(time.Second).Add(time.Second)
+				defer func() {
+					if d := time.Until(tickEnd); d > 0 {
+						time.Sleep(d)
+					}
+					if err := locker.locker.Unlock(that.lockKey); err != nil {
+						dlog.Path(path).Level(level).Errorf("[dcron] %s(%s) %s unlock failed: %v", that.Name, that.schedule.pattern, that.jobName, err)
+					}
+				}()
+			}
 			times := that.times.Add(-1)
 			if times <= 0 {
 				if that.entry.SetStatus(StatusClosed) == StatusClosed || times < 0 {
@@ -42,21 +69,103 @@ func (that *Entry) check() {
 				that.times.Set(defaultTimes)
 			}
 			dlog.Path(path).Level(level).Debugf("[dcron] %s(%s) %s start", that.Name, that.schedule.pattern, that.jobName)
+			onStart, onOk, onErr := that.cron.hooks()
+			run := JobRun{Start: time.Now()}
 			defer func() {
 				if err := recover(); err != nil {
-					dlog.Path(path).Level(level).Errorf("[dcron] %s(%s) %s end with error: %v", that.Name, that.schedule.pattern, that.jobName, err)
+					if e, ok := err.(error); ok {
+						run.Err = derror.Wrap(e, "panic")
+					} else {
+						run.Err = derror.Newf("panic: %v", err)
+					}
+				}
+				run.End = time.Now()
+				run.Duration = run.End.Sub(run.Start)
+				that.history.add(run)
+				if run.Err != nil {
+					dlog.Path(path).Level(level).Errorf("[dcron] %s(%s) %s end with error: %v", that.Name, that.schedule.pattern, that.jobName, run.Err)
+					if onErr != nil {
+						onErr(that, run)
+					}
 				} else {
 					dlog.Path(path).Level(level).Debugf("[dcron] %s(%s) %s end", that.Name, that.schedule.pattern, that.jobName)
+					if onOk != nil {
+						onOk(that, run)
+					}
 				}
 				if that.entry.Status() == StatusClosed {
 					that.Close()
 				}
 			}()
-			that.Job()
+			if onStart != nil {
+				onStart(that)
+			}
+			ctx, cancel := that.context()
+			defer cancel()
+			if locker != nil {
+				go that.renewLock(ctx, cancel, locker)
+			}
+			if that.jobCtx != nil {
+				run.Err = that.jobCtx(ctx)
+			} else {
+				that.Job()
+			}
+		}
+	}
+}
+
+// 生成任务单次运行的上下文
+func (that *Entry) context() (context.Context, context.CancelFunc) {
+	if timeout := that.Timeout(); timeout > 0 {
+		return context.WithTimeout(that.cron.ctx, timeout)
+	}
+	return context.WithCancel(that.cron.ctx)
+}
+
+// 任务运行期间定时续约，续约失败则取消任务的ctx
+func (that *Entry) renewLock(ctx context.Context, cancel context.CancelFunc, locker *cronLocker) {
+	ticker := time.NewTicker(locker.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ok, err := locker.locker.Renew(that.lockKey, locker.ttl)
+			if err != nil || !ok {
+				dlog.Path(that.cron.GetLogPath()).Level(that.cron.GetLogLevel()).Errorf("[dcron] %s(%s) %s lease lost: %v", that.Name, that.schedule.pattern, that.jobName, err)
+				cancel()
+				return
+			}
 		}
 	}
 }
 
+// SetTimeout 设置任务单次运行的超时时间，超时后任务的ctx会被取消
+func (that *Entry) SetTimeout(timeout time.Duration) {
+	that.timeout.Set(int64(timeout))
+}
+
+// Timeout 获取任务单次运行的超时时间
+func (that *Entry) Timeout() time.Duration {
+	return time.Duration(that.timeout.Val())
+}
+
+// SetHistorySize 设置任务保留的运行记录条数
+func (that *Entry) SetHistorySize(size int) {
+	that.history.setSize(size)
+}
+
+// History 按时间从旧到新获取任务的运行记录
+func (that *Entry) History() []JobRun {
+	return that.history.list()
+}
+
+// LastRun 获取任务最后一次的运行记录
+func (that *Entry) LastRun() (JobRun, bool) {
+	return that.history.last()
+}
+
 // IsSingleton 判断任务是否是单例模式
 func (that *Entry) IsSingleton() bool {
 	return that.entry.IsSingleton()
@@ -96,6 +205,9 @@ func (that *Entry) Stop() {
 func (that *Entry) Close() {
 	that.cron.entries.Remove(that.Name)
 	that.entry.Close()
+	if locker := that.cron.getLocker(); locker != nil {
+		_ = locker.locker.Unlock(that.lockKey)
+	}
 }
 
 // Next 获取下一次运行的时间
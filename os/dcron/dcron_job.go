@@ -0,0 +1,82 @@
+package dcron
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHistorySize = 10 // 每个任务默认保留的运行记录条数
+)
+
+// JobFunc 定时任务的执行方法，ctx会在任务超时或定时任务器关闭时被取消
+type JobFunc func(ctx context.Context) error
+
+// JobRun 定时任务的一次运行记录
+type JobRun struct {
+	Start    time.Time     // 开始时间
+	End      time.Time     // 结束时间
+	Duration time.Duration // 运行耗时
+	Err      error         // 运行错误，panic也会被转换为错误
+}
+
+// 任务的运行记录，只保留最近的size条
+type jobHistory struct {
+	mu   sync.RWMutex
+	size int
+	runs []JobRun
+}
+
+func newJobHistory(size int) *jobHistory {
+	return &jobHistory{
+		size: size,
+		runs: make([]JobRun, 0, size),
+	}
+}
+
+// 添加一条运行记录，超出限制的旧记录会被丢弃
+func (that *jobHistory) add(run JobRun) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if that.size <= 0 {
+		return
+	}
+	if len(that.runs) >= that.size {
+		copy(that.runs, that.runs[len(that.runs)-that.size+1:])
+		that.runs = that.runs[:that.size-1]
+	}
+	that.runs = append(that.runs, run)
+}
+
+// 设置保留的记录条数
+func (that *jobHistory) setSize(size int) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if size < 0 {
+		size = 0
+	}
+	if len(that.runs) > size {
+		that.runs = append(that.runs[:0], that.runs[len(that.runs)-size:]...)
+	}
+	that.size = size
+}
+
+// 按时间从旧到新返回运行记录的副本
+func (that *jobHistory) list() []JobRun {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	runs := make([]JobRun, len(that.runs))
+	copy(runs, that.runs)
+	return runs
+}
+
+// 最后一次运行记录
+func (that *jobHistory) last() (JobRun, bool) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	if len(that.runs) == 0 {
+		return JobRun{}, false
+	}
+	return that.runs[len(that.runs)-1], true
+}
@@ -0,0 +1,27 @@
+package dcron
+
+import (
+	"time"
+)
+
+const (
+	defaultLockTTL = 30 * time.Second // 默认的租约时长
+	minLockTTL     = time.Second      // 最小的租约时长，任务每秒检查一次，租约时长也用于计算续约间隔
+)
+
+// Locker 分布式锁，用于保证多个进程中的同名任务在同一时间只有一个在运行。
+// 持有租约的进程可以重复调用Lock续约，租约过期后其他进程才能获取。
+type Locker interface {
+	// Lock 获取名为key的租约，租约时长为ttl，成功返回true
+	Lock(key string, ttl time.Duration) (bool, error)
+	// Renew 续约名为key的租约，租约已经不属于自己则返回false
+	Renew(key string, ttl time.Duration) (bool, error)
+	// Unlock 释放名为key的租约
+	Unlock(key string) error
+}
+
+// 分布式锁配置
+type cronLocker struct {
+	locker Locker        // 锁的实现
+	ttl    time.Duration // 租约时长
+}
@@ -0,0 +1,134 @@
+//go:build !windows
+// +build !windows
+
+package dcron
+
+import (
+	"fmt"
+	"github.com/osgochina/donkeygo/os/dfile"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// FileLocker 基于共享目录中文件锁(flock)实现的分布式锁，
+// 每个租约对应目录下的一个文件，文件内容记录持有者和过期时间。
+type FileLocker struct {
+	dir   string // 租约文件存放的目录
+	owner string // 当前进程的持有者标识
+}
+
+var _ Locker = (*FileLocker)(nil)
+
+// NewFileLocker 创建文件锁，dir需要是多个进程都能访问到的共享路径，
+// owner为当前进程的持有者标识，默认使用"主机名:进程id"
+func NewFileLocker(dir string, owner ...string) (*FileLocker, error) {
+	if err := dfile.Mkdir(dir); err != nil {
+		return nil, err
+	}
+	l := &FileLocker{
+		dir: dir,
+	}
+	if len(owner) > 0 && owner[0] != "" {
+		l.owner = owner[0]
+	} else {
+		hostname, _ := os.Hostname()
+		l.owner = fmt.Sprintf("%s:%d", hostname, os.Getpid())
+	}
+	return l, nil
+}
+
+// Owner 获取当前进程的持有者标识
+func (that *FileLocker) Owner() string {
+	return that.owner
+}
+
+// Lock 获取名为key的租约，租约空闲、已过期或者本来就属于自己时获取成功
+func (that *FileLocker) Lock(key string, ttl time.Duration) (bool, error) {
+	return that.update(key, func(owner string, expire time.Time) (string, time.Time, bool) {
+		if owner == "" || owner == that.owner || time.Now().After(expire) {
+			return that.owner, time.Now().Add(ttl), true
+		}
+		return owner, expire, false
+	})
+}
+
+// Renew 续约名为key的租约，租约不属于自己时返回false
+func (that *FileLocker) Renew(key string, ttl time.Duration) (bool, error) {
+	return that.update(key, func(owner string, expire time.Time) (string, time.Time, bool) {
+		if owner == that.owner {
+			return that.owner, time.Now().Add(ttl), true
+		}
+		return owner, expire, false
+	})
+}
+
+// Unlock 释放名为key的租约，租约不属于自己时什么都不做
+func (that *FileLocker) Unlock(key string) error {
+	_, err := that.update(key, func(owner string, expire time.Time) (string, time.Time, bool) {
+		if owner == that.owner {
+			return "", time.Time{}, true
+		}
+		return owner, expire, false
+	})
+	return err
+}
+
+// 在文件锁的保护下读取租约，f返回true时把新的租约写回文件
+func (that *FileLocker) update(key string, f func(owner string, expire time.Time) (string, time.Time, bool)) (bool, error) {
+	file, err := dfile.OpenWithFlagPerm(that.path(key), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	if err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		return false, err
+	}
+	defer syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		return false, err
+	}
+	owner, expire := parseLease(string(content))
+	newOwner, newExpire, ok := f(owner, expire)
+	if !ok {
+		return false, nil
+	}
+	lease := ""
+	if newOwner != "" {
+		lease = fmt.Sprintf("%s\n%d", newOwner, newExpire.UnixNano())
+	}
+	if err = file.Truncate(0); err != nil {
+		return false, err
+	}
+	if _, err = file.WriteAt([]byte(lease), 0); err != nil {
+		return false, err
+	}
+	if err = file.Sync(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// 租约文件的路径
+func (that *FileLocker) path(key string) string {
+	key = strings.NewReplacer("/", "_", "\\", "_").Replace(key)
+	return dfile.Join(that.dir, key+".lock")
+}
+
+// 解析租约文件内容，格式为"持有者\n过期时间纳秒"
+func parseLease(content string) (owner string, expire time.Time) {
+	array := strings.SplitN(content, "\n", 2)
+	if len(array) != 2 {
+		return "", time.Time{}
+	}
+	nano, err := strconv.ParseInt(strings.TrimSpace(array[1]), 10, 64)
+	if err != nil {
+		return "", time.Time{}
+	}
+	return array[0], time.Unix(0, nano)
+}
@@ -0,0 +1,134 @@
+package dcron_test
+
+import (
+	"context"
+	"errors"
+	"github.com/osgochina/donkeygo/container/darray"
+	"github.com/osgochina/donkeygo/os/dcron"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"testing"
+	"time"
+)
+
+func TestCron_AddJob_History(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			cron    = dcron.NewCron()
+			started = darray.New(true)
+			success = darray.New(true)
+			failed  = darray.New(true)
+		)
+		cron.OnStart(func(entry *dcron.Entry) {
+			started.Append(entry.Name)
+		})
+		cron.OnSuccess(func(entry *dcron.Entry, run dcron.JobRun) {
+			success.Append(entry.Name)
+		})
+		cron.OnError(func(entry *dcron.Entry, run dcron.JobRun) {
+			failed.Append(run.Err.Error())
+		})
+		_, err := cron.AddJob("* * * * * *", func(ctx context.Context) error {
+			return nil
+		}, "ok")
+		t.Assert(err, nil)
+		entry, err := cron.AddJob("* * * * * *", func(ctx context.Context) error {
+			return errors.New("failed")
+		}, "fail")
+		t.Assert(err, nil)
+		_, err = cron.AddJob("* * * * * *", func(ctx context.Context) error {
+			return nil
+		}, "fail")
+		t.AssertNE(err, nil)
+		// 通过AddJob添加的任务依然可以通过Job字段调用
+		var job func() = entry.Job
+		t.Assert(job != nil, true)
+
+		time.Sleep(1300 * time.Millisecond)
+		t.Assert(started.Len(), 2)
+		t.Assert(success.Slice(), []interface{}{"ok"})
+		t.Assert(failed.Slice(), []interface{}{"failed"})
+
+		history := entry.History()
+		t.Assert(len(history), 1)
+		t.Assert(history[0].Err.Error(), "failed")
+		t.Assert(history[0].End.Sub(history[0].Start), history[0].Duration)
+		last, ok := entry.LastRun()
+		t.Assert(ok, true)
+		t.Assert(last.Err.Error(), "failed")
+		cron.Close()
+	})
+}
+
+func TestCron_AddJob_HistorySize(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		cron := dcron.NewCron()
+		entry, err := cron.AddJob("* * * * * *", func(ctx context.Context) error {
+			return nil
+		})
+		t.Assert(err, nil)
+		entry.SetHistorySize(2)
+		_, ok := entry.LastRun()
+		t.Assert(ok, false)
+		time.Sleep(3300 * time.Millisecond)
+		t.Assert(len(entry.History()), 2)
+		entry.SetHistorySize(1)
+		t.Assert(len(entry.History()), 1)
+		cron.Close()
+	})
+}
+
+func TestCron_AddJob_Timeout(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		cron := dcron.NewCron()
+		entry, err := cron.AddJob("* * * * * *", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		t.Assert(err, nil)
+		entry.SetTimeout(100 * time.Millisecond)
+		t.Assert(entry.Timeout(), 100*time.Millisecond)
+		time.Sleep(1300 * time.Millisecond)
+		last, ok := entry.LastRun()
+		t.Assert(ok, true)
+		t.Assert(last.Err, context.DeadlineExceeded)
+		cron.Close()
+	})
+}
+
+func TestCron_AddJob_Panic(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		cron := dcron.NewCron()
+		entry, err := cron.AddJob("* * * * * *", func(ctx context.Context) error {
+			panic("boom")
+		})
+		t.Assert(err, nil)
+		time.Sleep(1300 * time.Millisecond)
+		last, ok := entry.LastRun()
+		t.Assert(ok, true)
+		t.Assert(last.Err.Error(), "panic: boom")
+		cron.Close()
+	})
+}
+
+func TestCron_Close_CancelJob(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			cron   = dcron.NewCron()
+			result = make(chan error, 1)
+		)
+		_, err := cron.AddJob("* * * * * *", func(ctx context.Context) error {
+			<-ctx.Done()
+			result <- ctx.Err()
+			return nil
+		})
+		t.Assert(err, nil)
+		time.Sleep(1300 * time.Millisecond)
+		cron.Close()
+		select {
+		case err := <-result:
+			t.Assert(err, context.Canceled)
+		case <-time.After(time.Second):
+			t.Error("job was not cancelled")
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package dcron_test
+
+import (
+	"github.com/osgochina/donkeygo/container/darray"
+	"github.com/osgochina/donkeygo/os/dcron"
+	"github.com/osgochina/donkeygo/os/dfile"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"testing"
+	"time"
+)
+
+func TestFileLocker(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		dir := dfile.TempDir("dcron_file_locker_test")
+		defer dfile.Remove(dir)
+		l1, err := dcron.NewFileLocker(dir, "host1")
+		t.Assert(err, nil)
+		l2, err := dcron.NewFileLocker(dir, "host2")
+		t.Assert(err, nil)
+
+		ok, err := l1.Lock("job", time.Second)
+		t.Assert(err, nil)
+		t.Assert(ok, true)
+		ok, _ = l1.Lock("job", time.Second)
+		t.Assert(ok, true)
+		ok, _ = l2.Lock("job", time.Second)
+		t.Assert(ok, false)
+		ok, _ = l2.Renew("job", time.Second)
+		t.Assert(ok, false)
+		ok, _ = l1.Renew("job", time.Second)
+		t.Assert(ok, true)
+
+		t.Assert(l2.Unlock("job"), nil)
+		ok, _ = l2.Lock("job", time.Second)
+		t.Assert(ok, false)
+		t.Assert(l1.Unlock("job"), nil)
+		ok, _ = l2.Lock("job", 100*time.Millisecond)
+		t.Assert(ok, true)
+
+		time.Sleep(200 * time.Millisecond)
+		ok, _ = l1.Lock("job", time.Second)
+		t.Assert(ok, true)
+	})
+}
+
+func TestCron_SetLocker(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			dir   = dfile.TempDir("dcron_set_locker_test")
+			array = darray.New(true)
+			crons = make([]*dcron.Cron, 3)
+		)
+		defer dfile.Remove(dir)
+		for i := range crons {
+			locker, err := dcron.NewFileLocker(dir, "host"+string(rune('a'+i)))
+			t.Assert(err, nil)
+			crons[i] = dcron.NewCron()
+			crons[i].SetLocker(locker, 5*time.Second)
+			_, err = crons[i].Add("* * * * * *", func() {
+				array.Append(1)
+			}, "job")
+			t.Assert(err, nil)
+		}
+		time.Sleep(2300 * time.Millisecond)
+		t.Assert(array.Len(), 2)
+		for _, cron := range crons {
+			cron.Close()
+		}
+	})
+}
+
+func TestCron_SetLocker_Unnamed(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			dir   = dfile.TempDir("dcron_set_locker_unnamed_test")
+			array = darray.New(true)
+			crons = make([]*dcron.Cron, 2)
+			job   = func() {
+				array.Append(1)
+			}
+		)
+		defer dfile.Remove(dir)
+		for i := range crons {
+			locker, err := dcron.NewFileLocker(dir, "host"+string(rune('a'+i)))
+			t.Assert(err, nil)
+			crons[i] = dcron.NewCron()
+			crons[i].SetLocker(locker, 5*time.Second)
+			// 两个进程中任务的添加顺序不同，自动生成的任务名也不同，但是仍然使用相同的租约
+			if i == 0 {
+				_, err = crons[i].Add("0 0 0 1 1 *", func() {})
+				t.Assert(err, nil)
+			}
+			_, err = crons[i].Add("* * * * * *", job)
+			t.Assert(err, nil)
+		}
+		time.Sleep(2300 * time.Millisecond)
+		t.Assert(array.Len(), 2)
+		for _, cron := range crons {
+			cron.Close()
+		}
+	})
+}
+
+func TestCron_SetLocker_Release(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			dir   = dfile.TempDir("dcron_locker_release_test")
+			array = darray.New(true)
+		)
+		defer dfile.Remove(dir)
+		l1, err := dcron.NewFileLocker(dir, "host1")
+		t.Assert(err, nil)
+		l2, err := dcron.NewFileLocker(dir, "host2")
+		t.Assert(err, nil)
+		cron := dcron.NewCron()
+		defer cron.Close()
+		cron.SetLocker(l1, time.Minute)
+		_, err = cron.Add("* * * * * *", func() {
+			array.Append(1)
+		}, "job")
+		t.Assert(err, nil)
+		time.Sleep(1500 * time.Millisecond)
+		cron.Stop()
+		time.Sleep(1100 * time.Millisecond)
+		t.AssertGT(array.Len(), 0)
+		// 任务运行结束后租约被释放，其他节点不需要等待租约过期
+		ok, err := l2.Lock("job", time.Second)
+		t.Assert(err, nil)
+		t.Assert(ok, true)
+	})
+}
@@ -2,7 +2,7 @@ package denv
 
 import (
 	"github.com/osgochina/donkeygo/container/dvar"
-	"github.com/osgochina/donkeygo/os/dcmd"
+	"github.com/osgochina/donkeygo/internal/command"
 	"os"
 	"strings"
 )
@@ -86,7 +86,7 @@ func GetWithCmd(key string, def ...interface{}) *dvar.Var {
 		value = v
 	} else {
 		cmdKey := strings.ToLower(strings.Replace(key, "_", ".", -1))
-		if v := dcmd.GetOpt(cmdKey); v != "" {
+		if v := command.GetOpt(cmdKey); v != "" {
 			value = v
 		}
 	}
@@ -18,11 +18,13 @@ import (
 
 // Watcher 监听文件变化
 type Watcher struct {
-	watcher   *fsnotify.Watcher // 监视器
+	watcher   *fsnotify.Watcher // 监视器，为空时只使用轮询监听
+	poller    *poller           // 轮询监听，inotify不可用或者监听数量耗尽时使用
 	events    *dqueue.Queue     // 事件
 	cache     *dcache.Cache     // 缓存
 	nameSet   *dset.StrSet      //
 	callbacks *dmap.StrAnyMap   // 回调函数
+	lostPaths *dmap.StrStrMap   // 被删除或改名后等待重新创建的路径 => 为此临时监听的文件夹
 	closeChan chan struct{}     //
 }
 
@@ -30,10 +32,17 @@ type Watcher struct {
 type Callback struct {
 	Id        int
 	Func      func(event *Event)
+	BatchFunc func(events []*Event)
 	Path      string
 	name      string
 	elem      *dlist.Element
 	recursive bool
+	rewatch   bool
+	include   []string
+	exclude   []string
+	debounce  time.Duration
+	watcher   *Watcher
+	pending   *pendingEvents // 防抖窗口内等待回调的事件
 }
 
 // Event 事件接头体
@@ -67,25 +76,44 @@ var (
 	callbackIdGenerator = dtype.NewInt()
 )
 
+// New 创建监视器，inotify不可用时自动使用轮询方式监听
 func New() (*Watcher, error) {
-	w := &Watcher{
-		cache:     dcache.New(),
-		events:    dqueue.New(),
-		nameSet:   dset.NewStrSet(true),
-		closeChan: make(chan struct{}),
-		callbacks: dmap.NewStrAnyMap(true),
-	}
+	w := newWatcher(defaultPollInterval)
 	if watcher, err := fsnotify.NewWatcher(); err == nil {
 		w.watcher = watcher
 	} else {
-		intlog.Printf(context.TODO(), "New watcher failed: %v", err)
-		return nil, err
+		intlog.Printf(context.TODO(), "New watcher failed, fallback to polling: %v", err)
 	}
 	w.watchLoop()
 	w.eventLoop()
 	return w, nil
 }
 
+// NewPoller 创建只使用轮询方式监听的监视器，interval为扫描间隔，默认为1秒
+func NewPoller(interval ...time.Duration) *Watcher {
+	pollInterval := defaultPollInterval
+	if len(interval) > 0 {
+		pollInterval = interval[0]
+	}
+	w := newWatcher(pollInterval)
+	w.watchLoop()
+	w.eventLoop()
+	return w
+}
+
+// 创建监视器对象
+func newWatcher(pollInterval time.Duration) *Watcher {
+	return &Watcher{
+		cache:     dcache.New(),
+		events:    dqueue.New(),
+		poller:    newPoller(pollInterval),
+		nameSet:   dset.NewStrSet(true),
+		closeChan: make(chan struct{}),
+		callbacks: dmap.NewStrAnyMap(true),
+		lostPaths: dmap.NewStrStrMap(true),
+	}
+}
+
 // 获取默认的监视器
 func getDefaultWatcher() (*Watcher, error) {
 	mu.Lock()
@@ -121,6 +149,15 @@ func AddOnce(name, path string, callbackFunc func(event *Event), recursive ...bo
 	return w.AddOnce(name, path, callbackFunc, recursive...)
 }
 
+// AddWithOption 使用选项添加路径<path>的监听
+func AddWithOption(path string, callbackFunc func(event *Event), option CallbackOption) (callback *Callback, err error) {
+	w, err := getDefaultWatcher()
+	if err != nil {
+		return nil, err
+	}
+	return w.AddWithOption(path, callbackFunc, option)
+}
+
 // Remove 移除监听
 func Remove(path string) error {
 	w, err := getDefaultWatcher()
@@ -2,36 +2,55 @@ package dfsnotify
 
 import (
 	"context"
+	"github.com/fsnotify/fsnotify"
 	"github.com/osgochina/donkeygo/container/dlist"
 	"github.com/osgochina/donkeygo/internal/intlog"
 )
 
 //监听事件
 func (that *Watcher) watchLoop() {
+	var (
+		events <-chan fsnotify.Event
+		errors <-chan error
+	)
+	// 只使用轮询监听时，nil通道永远不会被选中
+	if that.watcher != nil {
+		events = that.watcher.Events
+		errors = that.watcher.Errors
+	}
 	go func() {
 		for {
 			select {
 			//收到关闭信号，结束循环
 			case <-that.closeChan:
 				return
-			case ev := <-that.watcher.Events:
-				//防止事件并发发送过快，在自定义时间内同样的事件只发送一次
-				_, _ = that.cache.SetIfNotExist(ev.String(), func() (interface{}, error) {
-					that.events.Push(&Event{
-						event:   ev,
-						Path:    ev.Name,
-						Op:      Op(ev.Op),
-						Watcher: that,
-					})
-					return struct{}{}, nil
-				}, repeatEventFilterDuration)
-			case err := <-that.watcher.Errors:
+			case ev := <-events:
+				that.pushEvent(ev)
+			case ev := <-that.poller.events:
+				that.pushEvent(ev)
+			case err := <-errors:
+				intlog.Error(context.TODO(), err)
+			case err := <-that.poller.errors:
 				intlog.Error(context.TODO(), err)
 			}
 		}
 	}()
 }
 
+// 把底层监视器的事件加入事件队列
+func (that *Watcher) pushEvent(ev fsnotify.Event) {
+	//防止事件并发发送过快，在自定义时间内同样的事件只发送一次
+	_, _ = that.cache.SetIfNotExist(ev.String(), func() (interface{}, error) {
+		that.events.Push(&Event{
+			event:   ev,
+			Path:    ev.Name,
+			Op:      Op(ev.Op),
+			Watcher: that,
+		})
+		return struct{}{}, nil
+	}, repeatEventFilterDuration)
+}
+
 // 事件循环
 func (that *Watcher) eventLoop() {
 	go func() {
@@ -41,38 +60,47 @@ func (that *Watcher) eventLoop() {
 				//获取该路径上注册的所有回调方法
 				callbacks := that.getCallbacks(event.Path)
 				if len(callbacks) == 0 {
-					_ = that.watcher.Remove(event.Path)
+					// 为等待重新创建的文件临时监听的文件夹不能移除
+					if !that.isLostDir(event.Path) {
+						_ = that.watchRemove(event.Path)
+					}
 					continue
 				}
 				switch {
 				case event.IsRemove():
 					if fileExists(event.Path) {
 						// 如果该名字的文件还存在，重新把改名字的文件添加到监听中
-						if err := that.watcher.Add(event.Path); err != nil {
+						if err := that.watchAdd(event.Path); err != nil {
 							intlog.Error(context.TODO(), err)
 						} else {
 							intlog.Printf(context.TODO(), "fake remove event, watcher re-adds monitor for: %s", event.Path)
 						}
 						// 如果该名字的文件还存在，就不能认为它是删除，把事件变成改名
 						event.Op = RENAME
+					} else {
+						that.rewatchLost(event.Path)
 					}
 				case event.IsRename():
 					if fileExists(event.Path) {
 						//如果该名字的文件还存在,则再次加入监听
-						if err := that.watcher.Add(event.Path); err != nil {
+						if err := that.watchAdd(event.Path); err != nil {
 							intlog.Error(context.TODO(), err)
 						} else {
 							intlog.Printf(context.TODO(), "fake rename event, watcher re-adds monitor for: %s", event.Path)
 						}
 						// 如果该名字的文件还存在，就不能认为它是改名了，把事件更改为修改权限
 						event.Op = CHMOD
+					} else {
+						that.rewatchLost(event.Path)
 					}
 				case event.IsCreate():
+					// 等待重新创建的文件已经出现，移除临时的文件夹监听
+					that.releaseLost(event.Path)
 					if fileIsDir(event.Path) {
 						// 如果被创建的是一个文件夹，则把该文件夹递归的添加到监听该文件夹的所有事件
 						for _, subPath := range fileAllDirs(event.Path) {
 							if fileIsDir(subPath) {
-								if err := that.watcher.Add(subPath); err != nil {
+								if err := that.watchAdd(subPath); err != nil {
 									intlog.Error(context.TODO(), err)
 								} else {
 									intlog.Printf(context.TODO(), "folder creation event, watcher adds monitor for: %s", subPath)
@@ -81,29 +109,15 @@ func (that *Watcher) eventLoop() {
 						}
 					} else {
 						// 如果它是一个文件，则把该文件路径添加到监听列表中
-						if err := that.watcher.Add(event.Path); err != nil {
+						if err := that.watchAdd(event.Path); err != nil {
 							intlog.Error(context.TODO(), err)
 						} else {
 							intlog.Printf(context.TODO(), "file creation event, watcher adds monitor for: %s", event.Path)
 						}
 					}
 				}
-				//开启协程执行要回调的方法
-				for _, v := range callbacks {
-					go func(callback *Callback) {
-						defer func() {
-							if err := recover(); err != nil {
-								switch err {
-								// 如果是回调方法主动退出，则删除该回调
-								case callbackExitEventPanicStr:
-									that.RemoveCallback(callback.Id)
-								default:
-									panic(err)
-								}
-							}
-						}()
-						callback.Func(event)
-					}(v)
+				for _, callback := range callbacks {
+					callback.dispatch(event)
 				}
 			} else {
 				break
@@ -112,6 +126,75 @@ func (that *Watcher) eventLoop() {
 	}()
 }
 
+// 开启协程执行回调方法，回调方法调用Exit时删除该回调
+func (that *Watcher) invoke(callback *Callback, f func()) {
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				switch err {
+				// 如果是回调方法主动退出，则删除该回调
+				case callbackExitEventPanicStr:
+					that.RemoveCallback(callback.Id)
+				default:
+					panic(err)
+				}
+			}
+		}()
+		f()
+	}()
+}
+
+// 监听的文件被删除或改名后，如果有回调需要自动恢复监听，临时监听其所在的文件夹，等待同名文件重新创建
+func (that *Watcher) rewatchLost(path string) {
+	v := that.callbacks.Get(path)
+	if v == nil {
+		return
+	}
+	rewatch := false
+	for _, c := range v.(*dlist.List).FrontAll() {
+		if c.(*Callback).rewatch {
+			rewatch = true
+			break
+		}
+	}
+	if !rewatch {
+		return
+	}
+	// 改名后的文件仍然以原路径被监听，先移除旧的监听，重新创建后才能以新的文件重新监听
+	_ = that.watchRemove(path)
+	dirPath := fileDir(path)
+	if err := that.watchAdd(dirPath); err != nil {
+		intlog.Error(context.TODO(), err)
+		return
+	}
+	that.lostPaths.Set(path, dirPath)
+	intlog.Printf(context.TODO(), "watcher waits for re-creation of: %s", path)
+}
+
+// 路径已经重新创建或者不再需要监听，移除为其临时监听的文件夹
+func (that *Watcher) releaseLost(path string) {
+	if !that.lostPaths.Contains(path) {
+		return
+	}
+	dirPath := that.lostPaths.Remove(path)
+	if that.isLostDir(dirPath) || !that.checkPathCanBeRemoved(dirPath) {
+		return
+	}
+	if err := that.watchRemove(dirPath); err != nil {
+		intlog.Error(context.TODO(), err)
+	}
+}
+
+// 判断文件夹是否正在为等待重新创建的文件临时监听
+func (that *Watcher) isLostDir(dirPath string) bool {
+	found := false
+	that.lostPaths.Iterator(func(k string, v string) bool {
+		found = v == dirPath
+		return !found
+	})
+	return found
+}
+
 // 获取改文件的回调方法
 func (that *Watcher) getCallbacks(path string) (callbacks []*Callback) {
 
@@ -0,0 +1,148 @@
+package dfsnotify
+
+import (
+	"github.com/fsnotify/fsnotify"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBatchDuration = 100 * time.Millisecond // 设置了批量回调但没有设置防抖时间时使用的时间窗口
+)
+
+// CallbackOption 添加监听时的选项
+type CallbackOption struct {
+	Name         string                // 监听的唯一名称，相同名称只会添加一次
+	NonRecursive bool                  // 监听文件夹时不递归监听子文件夹
+	Debounce     time.Duration         // 防抖时间窗口，窗口内没有新事件后才触发回调，同一路径的多个事件合并为一个
+	Include      []string              // 只处理文件名或路径匹配的事件，使用filepath.Match的glob语法
+	Exclude      []string              // 忽略文件名或路径匹配的事件，优先于Include
+	BatchFunc    func(events []*Event) // 批量回调，时间窗口内合并后的事件一次性回调，未设置Debounce时窗口为100毫秒
+	Rewatch      bool                  // 监听的文件被删除或改名后，在其所在文件夹等待同名文件重新创建并自动恢复监听
+}
+
+// 防抖窗口内等待回调的事件
+type pendingEvents struct {
+	mu     sync.Mutex
+	events []*pendingEvent // 合并后的事件，按路径首次出现的顺序排列
+	timer  *time.Timer
+}
+
+// 合并后等待回调的单个事件
+type pendingEvent struct {
+	event *Event
+	first Op // 窗口内该路径的第一个事件
+}
+
+// 判断路径是否满足回调的过滤条件
+func (that *Callback) match(path string) bool {
+	for _, pattern := range that.exclude {
+		if globMatch(pattern, path) {
+			return false
+		}
+	}
+	if len(that.include) == 0 {
+		return true
+	}
+	for _, pattern := range that.include {
+		if globMatch(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// 使用glob匹配文件名或者完整路径
+func globMatch(pattern, path string) bool {
+	if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+		return true
+	}
+	ok, _ := filepath.Match(pattern, path)
+	return ok
+}
+
+// 把事件分发给回调，没有设置防抖时立即回调，否则合并到等待队列中
+func (that *Callback) dispatch(event *Event) {
+	if !that.match(event.Path) {
+		return
+	}
+	if that.debounce <= 0 {
+		if that.Func != nil {
+			that.watcher.invoke(that, func() {
+				that.Func(event)
+			})
+		}
+		return
+	}
+	pending := that.pending
+	pending.mu.Lock()
+	defer pending.mu.Unlock()
+	merged := false
+	for _, p := range pending.events {
+		if p.event.Path == event.Path {
+			p.event.Op |= event.Op
+			merged = true
+			break
+		}
+	}
+	if !merged {
+		// 同一个事件对象会分发给多个回调，合并时需要使用副本
+		e := *event
+		pending.events = append(pending.events, &pendingEvent{event: &e, first: event.Op})
+	}
+	if pending.timer == nil {
+		pending.timer = time.AfterFunc(that.debounce, that.flush)
+	} else {
+		pending.timer.Reset(that.debounce)
+	}
+}
+
+// 防抖窗口结束，回调合并后的事件
+// 窗口内先删除或改名、结束时文件又存在的路径(如编辑器的改名替换保存)合并为写入事件，
+// 窗口内创建又被删除的临时文件不会回调
+func (that *Callback) flush() {
+	that.pending.mu.Lock()
+	pending := that.pending.events
+	that.pending.events = nil
+	that.pending.timer = nil
+	that.pending.mu.Unlock()
+	events := make([]*Event, 0, len(pending))
+	for _, p := range pending {
+		exists := fileExists(p.event.Path)
+		if !exists && p.first&CREATE == CREATE {
+			continue
+		}
+		if exists && p.event.Op&(REMOVE|RENAME) != 0 {
+			p.event.Op = p.event.Op&^(REMOVE|RENAME) | WRITE
+		}
+		p.event.event.Op = fsnotify.Op(p.event.Op)
+		events = append(events, p.event)
+	}
+	if len(events) == 0 {
+		return
+	}
+	if that.BatchFunc != nil {
+		that.watcher.invoke(that, func() {
+			that.BatchFunc(events)
+		})
+	}
+	if that.Func != nil {
+		that.watcher.invoke(that, func() {
+			for _, event := range events {
+				that.Func(event)
+			}
+		})
+	}
+}
+
+// 停止防抖定时器并丢弃等待中的事件
+func (that *Callback) stop() {
+	that.pending.mu.Lock()
+	defer that.pending.mu.Unlock()
+	if that.pending.timer != nil {
+		that.pending.timer.Stop()
+		that.pending.timer = nil
+	}
+	that.pending.events = nil
+}
@@ -0,0 +1,193 @@
+package dfsnotify
+
+import (
+	"github.com/fsnotify/fsnotify"
+	"github.com/osgochina/donkeygo/container/dtype"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPollInterval = time.Second // 轮询监听默认的扫描间隔
+)
+
+// 轮询方式的监听后端，定时扫描文件状态并与上一次的快照比较生成事件，
+// 用于不支持inotify或者inotify监听数量耗尽的场景，事件语义与fsnotify保持一致：
+// 监听文件时报告文件自身的变化，监听文件夹时报告文件夹下一级文件的变化
+type poller struct {
+	mu        sync.Mutex
+	interval  time.Duration
+	paths     map[string]map[string]os.FileInfo // 监听的路径 => 路径及其子文件的快照
+	events    chan fsnotify.Event
+	errors    chan error
+	started   *dtype.Bool
+	closeChan chan struct{}
+}
+
+// 创建轮询监听后端，扫描协程在第一次添加监听时启动
+func newPoller(interval time.Duration) *poller {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	return &poller{
+		interval:  interval,
+		paths:     make(map[string]map[string]os.FileInfo),
+		events:    make(chan fsnotify.Event, 1024),
+		errors:    make(chan error, 1),
+		started:   dtype.NewBool(),
+		closeChan: make(chan struct{}),
+	}
+}
+
+// Add 添加路径的轮询监听
+func (that *poller) Add(path string) error {
+	snapshot, err := pollSnapshot(path)
+	if err != nil {
+		return err
+	}
+	that.mu.Lock()
+	that.paths[path] = snapshot
+	that.mu.Unlock()
+	if that.started.Cas(false, true) {
+		go that.loop()
+	}
+	return nil
+}
+
+// Remove 移除路径的轮询监听，返回该路径是否被监听
+func (that *poller) Remove(path string) bool {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if _, ok := that.paths[path]; !ok {
+		return false
+	}
+	delete(that.paths, path)
+	return true
+}
+
+// Contains 判断路径是否被轮询监听
+func (that *poller) Contains(path string) bool {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	_, ok := that.paths[path]
+	return ok
+}
+
+// Close 停止轮询
+func (that *poller) Close() {
+	close(that.closeChan)
+}
+
+// 定时扫描所有监听的路径
+func (that *poller) loop() {
+	ticker := time.NewTicker(that.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-that.closeChan:
+			return
+		case <-ticker.C:
+			that.scan()
+		}
+	}
+}
+
+// 扫描一次所有监听的路径，比较快照并发送事件
+func (that *poller) scan() {
+	that.mu.Lock()
+	paths := make([]string, 0, len(that.paths))
+	for path := range that.paths {
+		paths = append(paths, path)
+	}
+	that.mu.Unlock()
+	for _, path := range paths {
+		current, err := pollSnapshot(path)
+		that.mu.Lock()
+		previous, ok := that.paths[path]
+		if !ok {
+			// 扫描期间被移除了监听
+			that.mu.Unlock()
+			continue
+		}
+		switch {
+		case err == nil:
+			that.paths[path] = current
+		case os.IsNotExist(err):
+			// 监听的路径被删除后，与inotify一样自动移除该路径的监听
+			delete(that.paths, path)
+		default:
+			// 其他错误（例如没有权限）保留上一次的快照，等待下一次扫描
+			that.mu.Unlock()
+			that.sendError(err)
+			continue
+		}
+		that.mu.Unlock()
+		for _, ev := range pollDiff(path, previous, current) {
+			select {
+			case that.events <- ev:
+			case <-that.closeChan:
+				return
+			}
+		}
+	}
+}
+
+// 发送扫描时的错误，错误通道已满时丢弃，避免阻塞扫描
+func (that *poller) sendError(err error) {
+	select {
+	case that.errors <- err:
+	default:
+	}
+}
+
+// 获取路径的快照，文件夹会包含下一级的文件
+func pollSnapshot(path string) (map[string]os.FileInfo, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := map[string]os.FileInfo{path: info}
+	if !info.IsDir() {
+		return snapshot, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	infos, err := file.Readdir(-1)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range infos {
+		snapshot[filepath.Join(path, v.Name())] = v
+	}
+	return snapshot, nil
+}
+
+// 比较两次快照的差异，生成对应的事件
+func pollDiff(path string, previous, current map[string]os.FileInfo) []fsnotify.Event {
+	var events []fsnotify.Event
+	for name, old := range previous {
+		info, ok := current[name]
+		if !ok {
+			events = append(events, fsnotify.Event{Name: name, Op: fsnotify.Remove})
+			continue
+		}
+		// 文件夹的修改时间随子文件变化，不作为写入事件
+		if !info.IsDir() && (!info.ModTime().Equal(old.ModTime()) || info.Size() != old.Size()) {
+			events = append(events, fsnotify.Event{Name: name, Op: fsnotify.Write})
+		}
+		if info.Mode() != old.Mode() {
+			events = append(events, fsnotify.Event{Name: name, Op: fsnotify.Chmod})
+		}
+	}
+	for name := range current {
+		if _, ok := previous[name]; !ok && name != path {
+			events = append(events, fsnotify.Event{Name: name, Op: fsnotify.Create})
+		}
+	}
+	return events
+}
@@ -17,18 +17,30 @@ func (that *Watcher) Add(path string, callbackFunc func(event *Event), recursive
 
 // AddOnce 使用唯一的<name>添加path的监控,相同name多次监控，只会成功一次
 func (that *Watcher) AddOnce(name, path string, callbackFunc func(event *Event), recursive ...bool) (callback *Callback, err error) {
-	that.nameSet.AddIfNotExistFuncLock(name, func() bool {
+	option := CallbackOption{Name: name}
+	if len(recursive) > 0 {
+		option.NonRecursive = !recursive[0]
+	}
+	return that.AddWithOption(path, callbackFunc, option)
+}
+
+// AddWithOption 使用选项添加path的监控，选项中设置了Name时与AddOnce一样相同name只会成功一次
+func (that *Watcher) AddWithOption(path string, callbackFunc func(event *Event), option CallbackOption) (callback *Callback, err error) {
+	if callbackFunc == nil && option.BatchFunc == nil {
+		return nil, errors.New("callback function can not be nil")
+	}
+	that.nameSet.AddIfNotExistFuncLock(option.Name, func() bool {
 		// 添加监听路径
-		callback, err = that.addWithCallbackFunc(name, path, callbackFunc, recursive...)
+		callback, err = that.addWithCallbackFunc(path, callbackFunc, option)
 		if err != nil {
 			return false
 		}
 		// 如果传入的路径是文件夹，并且需要递归监听
-		if fileIsDir(path) && (len(recursive) == 0 || recursive[0]) {
+		if fileIsDir(path) && !option.NonRecursive {
 			// 获取当前文件夹下的所有文件夹地址
 			for _, subPath := range fileAllDirs(path) {
 				if fileIsDir(subPath) {
-					if err := that.watcher.Add(subPath); err != nil {
+					if err := that.watchAdd(subPath); err != nil {
 						intlog.Error(context.TODO(), err)
 					} else {
 						intlog.Printf(context.TODO(), "watcher adds monitor for: %s", subPath)
@@ -37,7 +49,7 @@ func (that *Watcher) AddOnce(name, path string, callbackFunc func(event *Event),
 			}
 		}
 
-		if name == "" {
+		if option.Name == "" {
 			return false
 		}
 		return true
@@ -47,7 +59,7 @@ func (that *Watcher) AddOnce(name, path string, callbackFunc func(event *Event),
 
 // 将路径添加到底层监视器，创建并返回回调对象
 // 需要注意的是，如果它多次调用相同的' path '，最新的路径将覆盖之前的路径。
-func (that *Watcher) addWithCallbackFunc(name, path string, callbackFunc func(event *Event), recursive ...bool) (callback *Callback, err error) {
+func (that *Watcher) addWithCallbackFunc(path string, callbackFunc func(event *Event), option CallbackOption) (callback *Callback, err error) {
 
 	//判断要监听的路径是否存在
 	if t := fileRealPath(path); t == "" {
@@ -58,12 +70,20 @@ func (that *Watcher) addWithCallbackFunc(name, path string, callbackFunc func(ev
 	callback = &Callback{
 		Id:        callbackIdGenerator.Add(1),
 		Func:      callbackFunc,
+		BatchFunc: option.BatchFunc,
 		Path:      path,
-		name:      name,
-		recursive: true,
+		name:      option.Name,
+		recursive: !option.NonRecursive,
+		rewatch:   option.Rewatch,
+		include:   option.Include,
+		exclude:   option.Exclude,
+		debounce:  option.Debounce,
+		watcher:   that,
+		pending:   &pendingEvents{},
 	}
-	if len(recursive) > 0 {
-		callback.recursive = recursive[0]
+	// 批量回调需要在时间窗口内收集事件
+	if callback.BatchFunc != nil && callback.debounce <= 0 {
+		callback.debounce = defaultBatchDuration
 	}
 	//把callback方法加入到list中
 	that.callbacks.LockFunc(func(m map[string]interface{}) {
@@ -77,7 +97,7 @@ func (that *Watcher) addWithCallbackFunc(name, path string, callbackFunc func(ev
 		callback.elem = list.PushBack(callback)
 	})
 	// 添加path的监听
-	if err = that.watcher.Add(path); err != nil {
+	if err = that.watchAdd(path); err != nil {
 		intlog.Error(context.TODO(), err)
 	} else {
 		intlog.Printf(context.TODO(), "watcher adds monitor for: %s", path)
@@ -88,11 +108,34 @@ func (that *Watcher) addWithCallbackFunc(name, path string, callbackFunc func(ev
 	return
 }
 
+// 把路径添加到底层监视器，inotify添加失败时(如监听数量耗尽)使用轮询方式监听
+func (that *Watcher) watchAdd(path string) error {
+	if that.watcher != nil {
+		err := that.watcher.Add(path)
+		if err == nil {
+			return nil
+		}
+		intlog.Printf(context.TODO(), "watcher adds monitor for %s failed, fallback to polling: %v", path, err)
+	}
+	return that.poller.Add(path)
+}
+
+// 从底层监视器中移除路径
+func (that *Watcher) watchRemove(path string) error {
+	if that.poller.Remove(path) || that.watcher == nil {
+		return nil
+	}
+	return that.watcher.Remove(path)
+}
+
 // Close 关闭监控器
 func (that *Watcher) Close() {
 	that.events.Close()
-	if err := that.watcher.Close(); err != nil {
-		intlog.Error(context.TODO(), err)
+	that.poller.Close()
+	if that.watcher != nil {
+		if err := that.watcher.Close(); err != nil {
+			intlog.Error(context.TODO(), err)
+		}
 	}
 	close(that.closeChan)
 }
@@ -111,6 +154,7 @@ func (that *Watcher) RemoveCallback(callbackId int) {
 		if callback.name != "" {
 			that.nameSet.Remove(callback.name)
 		}
+		callback.stop()
 	}
 }
 
@@ -121,6 +165,7 @@ func (that *Watcher) Remove(path string) error {
 		list := r.(*dlist.List)
 		for {
 			if r := list.PopFront(); r != nil {
+				r.(*Callback).stop()
 				callbackIdMap.Remove(r.(*Callback).Id)
 			} else {
 				break
@@ -131,14 +176,15 @@ func (that *Watcher) Remove(path string) error {
 	if subPaths, err := fileScanDir(path, "*", true); err == nil && len(subPaths) > 0 {
 		for _, subPath := range subPaths {
 			if that.checkPathCanBeRemoved(subPath) {
-				if err := that.watcher.Remove(subPath); err != nil {
+				if err := that.watchRemove(subPath); err != nil {
 					intlog.Error(context.TODO(), err)
 				}
 			}
 		}
 	}
 	// Lastly remove the monitor of the path from underlying monitor.
-	return that.watcher.Remove(path)
+	that.releaseLost(path)
+	return that.watchRemove(path)
 }
 
 // 判断path是否可以移除监听
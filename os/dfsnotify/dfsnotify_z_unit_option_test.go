@@ -0,0 +1,163 @@
+package dfsnotify_test
+
+import (
+	"github.com/osgochina/donkeygo/container/darray"
+	"github.com/osgochina/donkeygo/container/dtype"
+	"github.com/osgochina/donkeygo/os/dfile"
+	"github.com/osgochina/donkeygo/os/dfsnotify"
+	"github.com/osgochina/donkeygo/os/dtime"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"testing"
+	"time"
+)
+
+func TestWatcher_Debounce(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		path := dfile.TempDir(dtime.TimestampNanoStr())
+		t.Assert(dfile.PutContents(path, "0"), nil)
+		defer dfile.Remove(path)
+
+		count := dtype.NewInt()
+		ops := darray.New(true)
+		_, err := dfsnotify.AddWithOption(path, func(event *dfsnotify.Event) {
+			count.Add(1)
+			ops.Append(event.Op)
+		}, dfsnotify.CallbackOption{Debounce: 200 * time.Millisecond})
+		t.Assert(err, nil)
+
+		for i := 1; i <= 5; i++ {
+			t.Assert(dfile.PutContents(path, dtime.TimestampNanoStr()), nil)
+			time.Sleep(20 * time.Millisecond)
+		}
+		time.Sleep(100 * time.Millisecond)
+		t.Assert(count.Val(), 0)
+		time.Sleep(300 * time.Millisecond)
+		t.Assert(count.Val(), 1)
+		t.Assert(ops.Slice()[0].(dfsnotify.Op)&dfsnotify.WRITE, dfsnotify.WRITE)
+	})
+}
+
+func TestWatcher_Filter(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		dirPath := dfile.TempDir(dtime.TimestampNanoStr())
+		t.Assert(dfile.Mkdir(dirPath), nil)
+		defer dfile.Remove(dirPath)
+
+		paths := darray.NewStrArray(true)
+		_, err := dfsnotify.AddWithOption(dirPath, func(event *dfsnotify.Event) {
+			paths.Append(event.Path)
+		}, dfsnotify.CallbackOption{
+			Include: []string{"*.yaml", "*.toml"},
+			Exclude: []string{"skip.*"},
+		})
+		t.Assert(err, nil)
+
+		t.Assert(dfile.PutContents(dfile.Join(dirPath, "a.yaml"), "a"), nil)
+		t.Assert(dfile.PutContents(dfile.Join(dirPath, "b.txt"), "b"), nil)
+		t.Assert(dfile.PutContents(dfile.Join(dirPath, "skip.toml"), "c"), nil)
+		time.Sleep(100 * time.Millisecond)
+		t.AssertGT(paths.Len(), 0)
+		for _, v := range paths.Slice() {
+			t.Assert(v, dfile.Join(dirPath, "a.yaml"))
+		}
+	})
+}
+
+func TestWatcher_Batch(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		dirPath := dfile.TempDir(dtime.TimestampNanoStr())
+		t.Assert(dfile.Mkdir(dirPath), nil)
+		defer dfile.Remove(dirPath)
+
+		batches := darray.New(true)
+		_, err := dfsnotify.AddWithOption(dirPath, nil, dfsnotify.CallbackOption{
+			Debounce: 100 * time.Millisecond,
+			BatchFunc: func(events []*dfsnotify.Event) {
+				batches.Append(events)
+			},
+		})
+		t.Assert(err, nil)
+
+		t.Assert(dfile.PutContents(dfile.Join(dirPath, "1"), "1"), nil)
+		t.Assert(dfile.PutContents(dfile.Join(dirPath, "2"), "2"), nil)
+		t.Assert(dfile.PutContents(dfile.Join(dirPath, "1"), "11"), nil)
+		// 窗口内创建又删除的临时文件不会回调
+		// fsnotify在读取事件时文件已经不存在会丢弃创建事件，稍等片刻再删除
+		t.Assert(dfile.PutContents(dfile.Join(dirPath, "tmp"), "tmp"), nil)
+		time.Sleep(20 * time.Millisecond)
+		t.Assert(dfile.Remove(dfile.Join(dirPath, "tmp")), nil)
+		time.Sleep(300 * time.Millisecond)
+		t.Assert(batches.Len(), 1)
+		events := batches.Slice()[0].([]*dfsnotify.Event)
+		t.Assert(len(events), 2)
+		t.Assert(events[0].Path, dfile.Join(dirPath, "1"))
+		t.Assert(events[1].Path, dfile.Join(dirPath, "2"))
+	})
+}
+
+func TestWatcher_Rewatch(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			dirPath = dfile.TempDir(dtime.TimestampNanoStr())
+			path    = dfile.Join(dirPath, "config.yaml")
+			backup  = dfile.Join(dirPath, "config.yaml~")
+		)
+		t.Assert(dfile.Mkdir(dirPath), nil)
+		t.Assert(dfile.PutContents(path, "1"), nil)
+		defer dfile.Remove(dirPath)
+
+		value := dtype.NewString()
+		count := dtype.NewInt()
+		_, err := dfsnotify.AddWithOption(path, func(event *dfsnotify.Event) {
+			count.Add(1)
+			value.Set(dfile.GetContents(path))
+		}, dfsnotify.CallbackOption{Debounce: 100 * time.Millisecond, Rewatch: true})
+		t.Assert(err, nil)
+
+		// 模拟编辑器保存：原文件改名为备份文件，再写入新文件
+		t.Assert(dfile.Rename(path, backup), nil)
+		time.Sleep(20 * time.Millisecond)
+		t.Assert(dfile.PutContents(path, "2"), nil)
+		time.Sleep(300 * time.Millisecond)
+		t.Assert(count.Val(), 1)
+		t.Assert(value.Val(), "2")
+
+		// 新文件恢复了监听
+		t.Assert(dfile.PutContents(path, "3"), nil)
+		time.Sleep(300 * time.Millisecond)
+		t.Assert(count.Val(), 2)
+		t.Assert(value.Val(), "3")
+	})
+}
+
+func TestWatcher_Poller(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		dirPath := dfile.TempDir(dtime.TimestampNanoStr())
+		t.Assert(dfile.Mkdir(dirPath), nil)
+		defer dfile.Remove(dirPath)
+
+		watcher := dfsnotify.NewPoller(20 * time.Millisecond)
+		defer watcher.Close()
+		ops := darray.New(true)
+		_, err := watcher.Add(dirPath, func(event *dfsnotify.Event) {
+			ops.Append(event.Op)
+		})
+		t.Assert(err, nil)
+
+		path := dfile.Join(dirPath, "1")
+		t.Assert(dfile.PutContents(path, "1"), nil)
+		time.Sleep(100 * time.Millisecond)
+		t.Assert(ops.Len(), 1)
+		t.Assert(ops.Slice()[0], dfsnotify.CREATE)
+
+		t.Assert(dfile.PutContents(path, "22"), nil)
+		time.Sleep(100 * time.Millisecond)
+		t.Assert(ops.Len(), 2)
+		t.Assert(ops.Slice()[1], dfsnotify.WRITE)
+
+		t.Assert(dfile.Remove(path), nil)
+		time.Sleep(100 * time.Millisecond)
+		t.Assert(ops.Len(), 3)
+		t.Assert(ops.Slice()[2], dfsnotify.REMOVE)
+	})
+}
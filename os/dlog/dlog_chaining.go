@@ -85,3 +85,18 @@ func Line(long ...bool) *Logger {
 func Async(enabled ...bool) *Logger {
 	return logger.Async(enabled...)
 }
+
+// Format 设置日志输出格式
+func Format(format string) *Logger {
+	return logger.Format(format)
+}
+
+// With 携带键值对
+func With(keyValues ...interface{}) *Logger {
+	return logger.With(keyValues...)
+}
+
+// Fields 携带map中的键值对
+func Fields(fields map[string]interface{}) *Logger {
+	return logger.Fields(fields)
+}
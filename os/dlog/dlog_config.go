@@ -1,6 +1,9 @@
 package dlog
 
-import "io"
+import (
+	"io"
+	"time"
+)
 
 // SetConfig 设置配置信息
 func SetConfig(config Config) error {
@@ -72,6 +75,26 @@ func SetPrefix(prefix string) {
 	logger.SetPrefix(prefix)
 }
 
+// SetFormat 设置日志输出格式
+func SetFormat(format string) {
+	logger.SetFormat(format)
+}
+
+// AddSink 添加日志输出端
+func AddSink(handler Handler, level ...int) {
+	logger.AddSink(handler, level...)
+}
+
+// Use 添加日志中间件
+func Use(middlewares ...Middleware) {
+	logger.Use(middlewares...)
+}
+
+// SetSampling 设置日志采样
+func SetSampling(initial, thereafter int, interval time.Duration, by ...string) {
+	logger.SetSampling(initial, thereafter, interval, by...)
+}
+
 // SetFlags 设置日志扩展标识
 func SetFlags(flags int) {
 	logger.SetFlags(flags)
@@ -17,6 +17,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -41,17 +42,21 @@ const (
 
 // Logger 日志对象
 type Logger struct {
-	ctx    context.Context //日志对象上下文
-	init   *dtype.Bool     // 是否初始化
-	parent *Logger         //
-	config Config          // 日志配置
+	ctx      context.Context //日志对象上下文
+	init     *dtype.Bool     // 是否初始化
+	parent   *Logger         //
+	config   Config          // 日志配置
+	fields   []Field         // 通过With和Fields添加的键值对
+	sampler  *sampler        // 日志采样器，没有开启采样时为nil
+	rotating *sync.WaitGroup // 后台执行中的旋转后任务，包括压缩、清理备份和旋转回调
 }
 
 // New 创建日志对象
 func New() *Logger {
 	return &Logger{
-		init:   dtype.NewBool(),
-		config: DefaultConfig(),
+		init:     dtype.NewBool(),
+		config:   DefaultConfig(),
+		rotating: &sync.WaitGroup{},
 	}
 }
 
@@ -67,6 +72,9 @@ func (that *Logger) Clone() *Logger {
 	logger := New()
 	logger.ctx = that.ctx
 	logger.config = that.config
+	logger.fields = that.fields
+	logger.sampler = that.sampler
+	logger.rotating = that.rotating
 	logger.parent = that
 	return logger
 }
@@ -81,7 +89,7 @@ func (that *Logger) getFilePath(now time.Time) string {
 }
 
 // 打印日志
-func (that *Logger) print(std io.Writer, lead string, values ...interface{}) {
+func (that *Logger) print(std io.Writer, level int, stack string, values ...interface{}) {
 
 	p := that
 	if p.parent != nil {
@@ -91,62 +99,36 @@ func (that *Logger) print(std io.Writer, lead string, values ...interface{}) {
 	// 判断日志处理器是否初始化
 	if !p.init.Val() && p.init.Cas(false, true) {
 		//判断是否需要旋转日志
-		if p.config.RotateSize > 0 || p.config.RotateExpire > 0 {
+		if p.config.RotateSize > 0 || p.config.RotateExpire > 0 || p.config.RotatePeriod != "" {
 			dtimer.AddOnce(p.config.RotateCheckInterval, p.rotateChecksTimely)
 			intlog.Printf(context.TODO(), "logger rotation initialized: every %s", p.config.RotateCheckInterval.String())
 		}
 	}
-	var (
-		now    = time.Now()
-		buffer = bytes.NewBuffer(nil)
-	)
-	//是否需要打印头信息
-	if that.config.HeaderPrint {
-		timeFormat := ""
-		if that.config.Flags&FTimeDate > 0 {
-			timeFormat += "2006-01-02 "
-		}
-		if that.config.Flags&FTimeTime > 0 {
-			timeFormat += "15:04:05 "
-		}
-		if that.config.Flags&FTimeMilli > 0 {
-			timeFormat += "15:04:05.000 "
-		}
-		if len(timeFormat) > 0 {
-			buffer.WriteString(now.Format(timeFormat))
-		}
-		//日志级别的标识
-		if len(lead) > 0 {
-			buffer.WriteString(lead)
-			if len(values) > 0 {
-				buffer.WriteByte(' ')
-			}
+	entry := &Entry{
+		Time:        time.Now(),
+		Level:       level,
+		LevelPrefix: that.config.LevelPrefixes[level],
+		Prefix:      that.config.Prefix,
+		Message:     joinValues(values),
+		Stack:       stack,
+		hasValues:   len(values) > 0 || stack != "",
+	}
+	if len(that.fields) > 0 {
+		entry.Fields = make([]Field, len(that.fields))
+		copy(entry.Fields, that.fields)
+	}
+	if that.config.HeaderPrint && that.config.Flags&(FFileLong|FFileShort|FCallerFn) > 0 {
+		callerFnName, path, line := ddebug.CallerWithFilter(pathFilterKey, that.config.StSkip)
+		if that.config.Flags&FCallerFn > 0 {
+			entry.CallerFn = callerFnName
 		}
-		// 判断日志打印信息
-		if that.config.Flags&(FFileLong|FFileShort|FCallerFn) > 0 {
-			callerPath := ""
-			callerFnName, path, line := ddebug.CallerWithFilter(pathFilterKey, that.config.StSkip)
-			//打印栈堆
-			if that.config.Flags&FCallerFn > 0 {
-				buffer.WriteString(fmt.Sprintf(`[%s] `, callerFnName))
-			}
-			//打印详细文件名和行号
-			if that.config.Flags&FFileLong > 0 {
-				callerPath = fmt.Sprintf(`%s:%d: `, path, line)
-			}
-			//打印端文件名和行号
-			if that.config.Flags&FFileShort > 0 {
-				callerPath = fmt.Sprintf(`%s:%d: `, dfile.Basename(path), line)
-			}
-			buffer.WriteString(callerPath)
+		if that.config.Flags&FFileLong > 0 {
+			entry.Caller = fmt.Sprintf(`%s:%d`, path, line)
 		}
-
-		//日志前缀
-		if len(that.config.Prefix) > 0 {
-			buffer.WriteString(that.config.Prefix + " ")
+		if that.config.Flags&FFileShort > 0 {
+			entry.Caller = fmt.Sprintf(`%s:%d`, dfile.Basename(path), line)
 		}
 	}
-
 	if that.ctx != nil {
 		// Tracing values.
 		//spanCtx := trace.SpanContextFromContext(that.ctx)
@@ -154,22 +136,47 @@ func (that *Logger) print(std io.Writer, lead string, values ...interface{}) {
 		//	buffer.WriteString(fmt.Sprintf("{TraceID:%s} ", traceId.String()))
 		//}
 		// Context values.
-		if len(that.config.CtxKeys) > 0 {
-			ctxStr := ""
-			for _, key := range that.config.CtxKeys {
-				if v := that.ctx.Value(key); v != nil {
-					if ctxStr != "" {
-						ctxStr += ", "
-					}
-					ctxStr += fmt.Sprintf("%s: %+v", key, v)
-				}
-			}
-			if ctxStr != "" {
-				buffer.WriteString(fmt.Sprintf("{%s} ", ctxStr))
+		for _, key := range that.config.CtxKeys {
+			if v := that.ctx.Value(key); v != nil {
+				entry.CtxValues = append(entry.CtxValues, Field{Key: fmt.Sprintf("%v", key), Value: v})
 			}
 		}
 	}
-	// 将值转换为字符串
+	// 经过中间件处理后再格式化并输出
+	if err := that.handler(std).Handle(entry); err != nil {
+		intlog.Error(context.TODO(), err)
+	}
+}
+
+// 格式化日志并输出到默认的输出端和添加的各个输出端
+func (that *Logger) output(std io.Writer, entry *Entry) {
+	var buffer *bytes.Buffer
+	switch that.config.Format {
+	case FormatJson:
+		buffer = that.formatJson(entry)
+	case FormatLogfmt:
+		buffer = that.formatLogfmt(entry)
+	default:
+		buffer = that.formatText(entry)
+	}
+	entry.Content = buffer.Bytes()
+
+	if that.config.Flags&FAsync > 0 {
+		err := asyncPool.Add(func() {
+			that.printToWriter(entry.Time, std, buffer)
+			that.printToSinks(entry)
+		})
+		if err != nil {
+			intlog.Error(context.TODO(), err)
+		}
+	} else {
+		that.printToWriter(entry.Time, std, buffer)
+		that.printToSinks(entry)
+	}
+}
+
+// 把日志内容转换为字符串
+func joinValues(values []interface{}) string {
 	var (
 		tempStr  = ""
 		valueStr = ""
@@ -179,7 +186,7 @@ func (that *Logger) print(std io.Writer, lead string, values ...interface{}) {
 		if len(valueStr) > 0 {
 			if valueStr[len(valueStr)-1] == '\n' {
 				// Remove one blank line(\n\n).
-				if tempStr[0] == '\n' {
+				if len(tempStr) > 0 && tempStr[0] == '\n' {
 					valueStr += tempStr[1:]
 				} else {
 					valueStr += tempStr
@@ -191,18 +198,7 @@ func (that *Logger) print(std io.Writer, lead string, values ...interface{}) {
 			valueStr = tempStr
 		}
 	}
-	buffer.WriteString(valueStr + "\n")
-
-	if that.config.Flags&FAsync > 0 {
-		err := asyncPool.Add(func() {
-			that.printToWriter(now, std, buffer)
-		})
-		if err != nil {
-			intlog.Error(context.TODO(), err)
-		}
-	} else {
-		that.printToWriter(now, std, buffer)
-	}
+	return valueStr
 }
 
 //打印到writer
@@ -238,6 +234,10 @@ func (that *Logger) printToFile(now time.Time, buffer *bytes.Buffer) {
 	dmlock.Lock(memoryLockKey)
 	defer dmlock.Unlock(memoryLockKey)
 
+	//如果日志文件最后写入的时间在上一个旋转周期，则备份该日志文件
+	if that.config.RotatePeriod != "" {
+		that.rotateFileByPeriod(now, logFilePath)
+	}
 	//如果日志文件的容量超出了设置的大小，则备份该日志文件，并重新生成新文件
 	if that.config.RotateSize > 0 {
 		if dfile.Size(logFilePath) > that.config.RotateSize {
@@ -273,19 +273,18 @@ func (that *Logger) getFilePointer(path string) *dfpool.File {
 }
 
 //打印日志到标准输出
-func (that *Logger) printStd(lead string, value ...interface{}) {
-	that.print(os.Stdout, lead, value...)
+func (that *Logger) printStd(level int, value ...interface{}) {
+	that.print(os.Stdout, level, "", value...)
 }
 
 // 打印错误日志
-func (that *Logger) printErr(lead string, value ...interface{}) {
+func (that *Logger) printErr(level int, value ...interface{}) {
+	stack := ""
 	if that.config.StStatus == 1 {
-		if s := that.GetStack(); s != "" {
-			value = append(value, "\nStack:\n"+s)
-		}
+		stack = that.GetStack()
 	}
 	// In matter of sequence, do not use stderr here, but use the same stdout.
-	that.print(os.Stdout, lead, value...)
+	that.print(os.Stdout, level, stack, value...)
 }
 
 // 格式化
@@ -4,7 +4,9 @@ import (
 	"context"
 	"github.com/osgochina/donkeygo/internal/intlog"
 	"github.com/osgochina/donkeygo/os/dfile"
+	"github.com/osgochina/donkeygo/util/dconv"
 	"io"
+	"sort"
 )
 
 // Ctx 日志的上下文
@@ -216,3 +218,67 @@ func (that *Logger) Async(enabled ...bool) *Logger {
 	}
 	return logger
 }
+
+// Format 设置日志的输出格式
+func (that *Logger) Format(format string) *Logger {
+	logger := (*Logger)(nil)
+	if that.parent == nil {
+		logger = that.Clone()
+	} else {
+		logger = that
+	}
+	logger.SetFormat(format)
+	return logger
+}
+
+// With 返回携带键值对的新日志对象，参数按key、value成对传入，缺少value的key其值为nil，
+// 键值对会出现在之后打印的每条日志中，原日志对象不受影响
+func (that *Logger) With(keyValues ...interface{}) *Logger {
+	fields := make([]Field, 0, (len(keyValues)+1)/2)
+	for i := 0; i < len(keyValues); i += 2 {
+		f := Field{Key: dconv.String(keyValues[i])}
+		if i+1 < len(keyValues) {
+			f.Value = keyValues[i+1]
+		}
+		fields = append(fields, f)
+	}
+	return that.withFields(fields)
+}
+
+// Fields 返回携带map中所有键值对的新日志对象，键值对按照key排序
+func (that *Logger) Fields(fields map[string]interface{}) *Logger {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	list := make([]Field, 0, len(keys))
+	for _, k := range keys {
+		list = append(list, Field{Key: k, Value: fields[k]})
+	}
+	return that.withFields(list)
+}
+
+// 复制日志对象并追加键值对，相同key的值会被覆盖
+func (that *Logger) withFields(fields []Field) *Logger {
+	logger := that.Clone()
+	if that.parent != nil {
+		logger.parent = that.parent
+	}
+	logger.fields = make([]Field, len(that.fields), len(that.fields)+len(fields))
+	copy(logger.fields, that.fields)
+	for _, f := range fields {
+		replaced := false
+		for i := range logger.fields {
+			if logger.fields[i].Key == f.Key {
+				logger.fields[i].Value = f.Value
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			logger.fields = append(logger.fields, f)
+		}
+	}
+	return logger
+}
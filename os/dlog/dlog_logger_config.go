@@ -14,6 +14,9 @@ import (
 	"time"
 )
 
+// RotateHookFunc 日志旋转后的回调，参数为日志文件和备份文件的路径，备份文件开启压缩时为压缩后的路径
+type RotateHookFunc func(file, backup string)
+
 // Config 日志的配置信息
 type Config struct {
 	Writer               io.Writer      `json:"-"`                    // 定制 io.Writer.
@@ -35,6 +38,18 @@ type Config struct {
 	RotateBackupLimit    int            `json:"rotateBackupLimit"`    // 最大旋转备份的文件数量，默认是0，意味着没有备份.
 	RotateBackupExpire   time.Duration  `json:"rotateBackupExpire"`   // 旋转文件的最大过期时间，默认是0，意味着不过期。
 	RotateBackupCompress int            `json:"rotateBackupCompress"` // 使用gzip算法压缩旋转文件的级别。默认值是0，表示没有压缩。
+	RotatePeriod         string         `json:"rotatePeriod"`         // 按日历周期旋转日志，hourly在每个整点，daily在每天零点，默认为空不按周期旋转
+	RotatePattern        string         `json:"rotatePattern"`        // 按周期旋转的备份文件名格式(strftime)，hourly默认%Y%m%d%H，daily默认%Y%m%d
+	RotateTimezone       string         `json:"rotateTimezone"`       // 计算旋转周期使用的时区，如Asia/Shanghai，默认为本地时区
+	RotateBackupMaxBytes int64          `json:"rotateBackupMaxBytes"` // 备份文件的最大总字节数，超过后删除最旧的备份，默认是0，表示不限制
+	RotateHook           RotateHookFunc `json:"-"`                    // 日志旋转并压缩完成后的回调
+	Format               string         `json:"format"`               // 日志的输出格式，text、json或logfmt，默认是text
+	SampleInitial        int            `json:"sampleInitial"`        // 采样周期内每种日志最先输出的条数，为0时不采样
+	SampleThereafter     int            `json:"sampleThereafter"`     // 超过SampleInitial后每SampleThereafter条输出一条，为0时全部丢弃
+	SampleInterval       time.Duration  `json:"sampleInterval"`       // 采样周期，默认是1秒
+	SampleBy             string         `json:"sampleBy"`             // 采样的分组方式，message按日志级别和消息模板分组，caller按调用位置分组，默认是message
+	Sinks                []Sink         `json:"-"`                    // 除默认输出外的其他输出端
+	Middlewares          []Middleware   `json:"-"`                    // 日志输出前依次经过的中间件
 }
 
 // DefaultConfig 生成默认配置信息
@@ -61,6 +76,7 @@ func DefaultConfig() Config {
 // SetConfig 设置日志的配置信息
 func (that *Logger) SetConfig(config Config) error {
 	that.config = config
+	that.sampler = newSampler(config)
 	if that.config.Path != "" {
 		if err := that.SetPath(config.Path); err != nil {
 			intlog.Error(context.TODO(), err)
@@ -96,6 +112,24 @@ func (that *Logger) SetConfigWithMap(m map[string]interface{}) error {
 			return errors.New(fmt.Sprintf(`invalid rotate size: %v`, rotateSizeValue))
 		}
 	}
+	// 备份文件的最大总大小
+	maxBytesKey, maxBytesValue := dutil.MapPossibleItemByKey(m, "RotateBackupMaxBytes")
+	if maxBytesValue != nil {
+		maxBytes := dfile.StrToSize(dconv.String(maxBytesValue))
+		if maxBytes < 0 {
+			return errors.New(fmt.Sprintf(`invalid rotate backup max bytes: %v`, maxBytesValue))
+		}
+		m[maxBytesKey] = maxBytes
+	}
+	// 按日历周期旋转
+	periodKey, periodValue := dutil.MapPossibleItemByKey(m, "RotatePeriod")
+	if periodValue != nil {
+		period := strings.ToLower(dconv.String(periodValue))
+		if _, ok := defaultRotatePatterns[period]; !ok && period != "" {
+			return errors.New(fmt.Sprintf(`invalid rotate period: %v`, periodValue))
+		}
+		m[periodKey] = period
+	}
 	err := dconv.Struct(m, &that.config)
 	if err != nil {
 		return err
@@ -211,3 +245,18 @@ func (that *Logger) SetHeaderPrint(enabled bool) {
 func (that *Logger) SetPrefix(prefix string) {
 	that.config.Prefix = prefix
 }
+
+// SetFormat 设置日志的输出格式，支持FormatText、FormatJson和FormatLogfmt
+func (that *Logger) SetFormat(format string) {
+	that.config.Format = format
+}
+
+// GetFormat 获取日志的输出格式
+func (that *Logger) GetFormat() string {
+	return that.config.Format
+}
+
+// SetRotateHook 设置日志旋转后的回调
+func (that *Logger) SetRotateHook(hook RotateHookFunc) {
+	that.config.RotateHook = hook
+}
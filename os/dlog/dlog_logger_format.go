@@ -0,0 +1,221 @@
+package dlog
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/osgochina/donkeygo/internal/json"
+	"github.com/osgochina/donkeygo/util/dconv"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatText   = "text"   // 文本格式，默认的日志格式
+	FormatJson   = "json"   // 每条日志输出为一行json
+	FormatLogfmt = "logfmt" // 每条日志输出为一行key=value
+)
+
+const (
+	reservedKeyPrefix = "fields." // 与固定字段重名的键值对在结构化格式中添加的前缀
+)
+
+var (
+	// 结构化格式中的固定字段，键值对不能覆盖
+	reservedKeys = map[string]struct{}{
+		"time": {}, "level": {}, "func": {}, "caller": {}, "prefix": {}, "msg": {}, "stack": {},
+	}
+)
+
+// Field 日志中的键值对
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Entry 一条日志记录，先经过中间件处理，再格式化后交给各个输出端
+type Entry struct {
+	Time        time.Time
+	Level       int     // 日志级别，Print等没有级别的日志为0
+	LevelPrefix string  // 日志级别的前缀，如INFO
+	CallerFn    string  // 调用者的函数名
+	Caller      string  // 调用者的文件名和行号
+	Prefix      string  // 日志前缀
+	CtxValues   []Field // 上下文中的值
+	Fields      []Field // 通过With和Fields添加的键值对
+	Message     string  // 日志内容
+	Stack       string  // 栈堆信息
+	Content     []byte  // 按照日志格式格式化后的内容，在中间件中为空
+	hasValues   bool    // 是否传入了日志内容
+}
+
+// 格式化为文本，键值对以key=value的格式追加在日志内容后面
+func (that *Logger) formatText(entry *Entry) *bytes.Buffer {
+	buffer := bytes.NewBuffer(nil)
+	//是否需要打印头信息
+	if that.config.HeaderPrint {
+		timeFormat := ""
+		if that.config.Flags&FTimeDate > 0 {
+			timeFormat += "2006-01-02 "
+		}
+		if that.config.Flags&FTimeTime > 0 {
+			timeFormat += "15:04:05 "
+		}
+		if that.config.Flags&FTimeMilli > 0 {
+			timeFormat += "15:04:05.000 "
+		}
+		if len(timeFormat) > 0 {
+			buffer.WriteString(entry.Time.Format(timeFormat))
+		}
+		//日志级别的标识
+		if len(entry.LevelPrefix) > 0 {
+			buffer.WriteString("[" + entry.LevelPrefix + "]")
+			if entry.hasValues {
+				buffer.WriteByte(' ')
+			}
+		}
+		if entry.CallerFn != "" {
+			buffer.WriteString(fmt.Sprintf(`[%s] `, entry.CallerFn))
+		}
+		if entry.Caller != "" {
+			buffer.WriteString(entry.Caller + ": ")
+		}
+		//日志前缀
+		if len(entry.Prefix) > 0 {
+			buffer.WriteString(entry.Prefix + " ")
+		}
+	}
+	if len(entry.CtxValues) > 0 {
+		ctxStr := ""
+		for _, f := range entry.CtxValues {
+			if ctxStr != "" {
+				ctxStr += ", "
+			}
+			ctxStr += fmt.Sprintf("%s: %+v", f.Key, f.Value)
+		}
+		buffer.WriteString(fmt.Sprintf("{%s} ", ctxStr))
+	}
+	buffer.WriteString(joinStack(joinFields(entry.Message, entry.Fields), entry.Stack) + "\n")
+	return buffer
+}
+
+// 把键值对以key=value的格式追加在日志内容后面
+func joinFields(message string, fields []Field) string {
+	for _, f := range fields {
+		if message != "" {
+			message += " "
+		}
+		message += f.Key + "=" + logfmtValue(f.Value)
+	}
+	return message
+}
+
+// 把栈堆信息追加在日志内容后面
+func joinStack(message string, stack string) string {
+	if stack == "" {
+		return message
+	}
+	return joinValues([]interface{}{message, "\nStack:\n" + stack})
+}
+
+// 格式化为一行json，时间、级别、调用者、上下文和栈堆都是独立的字段
+func (that *Logger) formatJson(entry *Entry) *bytes.Buffer {
+	buffer := bytes.NewBuffer(nil)
+	buffer.WriteByte('{')
+	write := func(key string, value interface{}) {
+		if buffer.Len() > 1 {
+			buffer.WriteByte(',')
+		}
+		buffer.Write(jsonValue(key))
+		buffer.WriteByte(':')
+		buffer.Write(jsonValue(value))
+	}
+	for _, f := range that.headerFields(entry) {
+		write(f.Key, f.Value)
+	}
+	for _, f := range entry.Fields {
+		write(fieldKey(f.Key), f.Value)
+	}
+	write("msg", entry.Message)
+	if entry.Stack != "" {
+		write("stack", entry.Stack)
+	}
+	buffer.WriteString("}\n")
+	return buffer
+}
+
+// 格式化为一行logfmt
+func (that *Logger) formatLogfmt(entry *Entry) *bytes.Buffer {
+	buffer := bytes.NewBuffer(nil)
+	write := func(key string, value interface{}) {
+		if buffer.Len() > 0 {
+			buffer.WriteByte(' ')
+		}
+		buffer.WriteString(key + "=" + logfmtValue(value))
+	}
+	for _, f := range that.headerFields(entry) {
+		write(f.Key, f.Value)
+	}
+	for _, f := range entry.Fields {
+		write(fieldKey(f.Key), f.Value)
+	}
+	write("msg", entry.Message)
+	if entry.Stack != "" {
+		write("stack", entry.Stack)
+	}
+	buffer.WriteString("\n")
+	return buffer
+}
+
+// 结构化格式中日志内容之前的固定字段
+func (that *Logger) headerFields(entry *Entry) []Field {
+	fields := make([]Field, 0, 5+len(entry.CtxValues))
+	if that.config.HeaderPrint {
+		fields = append(fields, Field{Key: "time", Value: entry.Time.Format(time.RFC3339Nano)})
+		if entry.LevelPrefix != "" {
+			fields = append(fields, Field{Key: "level", Value: entry.LevelPrefix})
+		}
+		if entry.CallerFn != "" {
+			fields = append(fields, Field{Key: "func", Value: entry.CallerFn})
+		}
+		if entry.Caller != "" {
+			fields = append(fields, Field{Key: "caller", Value: entry.Caller})
+		}
+		if entry.Prefix != "" {
+			fields = append(fields, Field{Key: "prefix", Value: entry.Prefix})
+		}
+	}
+	for _, f := range entry.CtxValues {
+		fields = append(fields, Field{Key: fieldKey(f.Key), Value: f.Value})
+	}
+	return fields
+}
+
+// 与固定字段重名的键添加前缀，避免覆盖时间、级别和日志内容等字段
+func fieldKey(key string) string {
+	if _, ok := reservedKeys[key]; ok {
+		return reservedKeyPrefix + key
+	}
+	return key
+}
+
+// 把值编码为json，不能编码的值使用其字符串形式
+func jsonValue(value interface{}) []byte {
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		b, _ = json.Marshal(dconv.String(value))
+	}
+	return b
+}
+
+// 把值转换为logfmt格式，包含空格、等号或引号的值使用双引号包裹
+func logfmtValue(value interface{}) string {
+	s := dconv.String(value)
+	if s == "" || strings.ContainsAny(s, " =\"\t\r\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
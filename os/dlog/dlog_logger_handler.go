@@ -0,0 +1,78 @@
+package dlog
+
+import (
+	"context"
+	"github.com/osgochina/donkeygo/internal/intlog"
+	"io"
+)
+
+// Handler 日志处理器，输出端和中间件都通过它处理日志记录
+type Handler interface {
+	Handle(entry *Entry) error
+}
+
+// HandlerFunc 函数形式的日志处理器
+type HandlerFunc func(entry *Entry) error
+
+// Handle 处理日志记录
+func (f HandlerFunc) Handle(entry *Entry) error {
+	return f(entry)
+}
+
+// Middleware 日志中间件，在日志格式化之前执行，可以修改日志记录，
+// 不调用next时该条日志会被丢弃
+type Middleware func(next Handler) Handler
+
+// Sink 日志输出端
+type Sink struct {
+	Handler Handler // 输出端的处理器，收到的日志记录中Content为格式化后的内容
+	Level   int     // 输出端的日志级别，为0时输出所有级别的日志
+}
+
+// AddSink 添加日志输出端，可以传入该输出端单独的日志级别，默认输出所有级别的日志，
+// 输出端在默认的标准输出、日志文件和Writer之外额外输出日志
+func (that *Logger) AddSink(handler Handler, level ...int) {
+	sink := Sink{Handler: handler}
+	if len(level) > 0 {
+		sink.Level = level[0]
+	}
+	sinks := make([]Sink, len(that.config.Sinks), len(that.config.Sinks)+1)
+	copy(sinks, that.config.Sinks)
+	that.config.Sinks = append(sinks, sink)
+}
+
+// GetSinks 获取添加的日志输出端
+func (that *Logger) GetSinks() []Sink {
+	return that.config.Sinks
+}
+
+// Use 添加日志中间件，中间件按照添加的顺序执行
+func (that *Logger) Use(middlewares ...Middleware) {
+	list := make([]Middleware, len(that.config.Middlewares), len(that.config.Middlewares)+len(middlewares))
+	copy(list, that.config.Middlewares)
+	that.config.Middlewares = append(list, middlewares...)
+}
+
+// 生成经过所有中间件的处理器
+func (that *Logger) handler(std io.Writer) Handler {
+	var h Handler = HandlerFunc(func(entry *Entry) error {
+		that.output(std, entry)
+		return nil
+	})
+	for i := len(that.config.Middlewares) - 1; i >= 0; i-- {
+		h = that.config.Middlewares[i](h)
+	}
+	return h
+}
+
+// 把日志记录交给日志级别匹配的输出端
+func (that *Logger) printToSinks(entry *Entry) {
+	for _, sink := range that.config.Sinks {
+		if entry.Level != 0 && sink.Level != 0 && sink.Level&entry.Level == 0 {
+			continue
+		}
+		if err := sink.Handler.Handle(entry); err != nil {
+			intlog.Error(context.TODO(), err)
+		}
+	}
+}
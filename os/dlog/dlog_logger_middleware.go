@@ -0,0 +1,42 @@
+package dlog
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+const redactMask = "******" // 脱敏后的值
+
+// RedactMiddleware 脱敏中间件，把键名与<keys>相同(不区分大小写)的键值对和上下文值替换为"******"
+func RedactMiddleware(keys ...string) Middleware {
+	redact := func(fields []Field) {
+		for i := range fields {
+			for _, key := range keys {
+				if strings.EqualFold(fields[i].Key, key) {
+					fields[i].Value = redactMask
+					break
+				}
+			}
+		}
+	}
+	return func(next Handler) Handler {
+		return HandlerFunc(func(entry *Entry) error {
+			redact(entry.Fields)
+			redact(entry.CtxValues)
+			return next.Handle(entry)
+		})
+	}
+}
+
+// SampleMiddleware 采样中间件，每<n>条日志只输出第一条，<n>小于等于1时输出所有日志
+func SampleMiddleware(n int) Middleware {
+	var counter uint64
+	return func(next Handler) Handler {
+		return HandlerFunc(func(entry *Entry) error {
+			if n > 1 && (atomic.AddUint64(&counter, 1)-1)%uint64(n) != 0 {
+				return nil
+			}
+			return next.Handle(entry)
+		})
+	}
+}
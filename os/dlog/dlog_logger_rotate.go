@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"github.com/osgochina/donkeygo/container/darray"
-	"github.com/osgochina/donkeygo/encoding/dcompress"
 	"github.com/osgochina/donkeygo/internal/intlog"
 	"github.com/osgochina/donkeygo/os/dfile"
 	"github.com/osgochina/donkeygo/os/dmlock"
@@ -19,26 +18,26 @@ func (that *Logger) rotateFileBySize(now time.Time) {
 	if that.config.RotateSize <= 0 {
 		return
 	}
-	if err := that.doRotateFile(that.getFilePath(now)); err != nil {
+	if err := that.doRotateFile(that.getFilePath(now), ""); err != nil {
 		// panic(err)
 		intlog.Error(context.TODO(), err)
 	}
 }
 
-//备份日志文件
-func (that *Logger) doRotateFile(filePath string) error {
+//备份日志文件，<backupPath>为空时使用时间戳生成备份文件名
+func (that *Logger) doRotateFile(filePath string, backupPath string) error {
 	memoryLockKey := "dlog.doRotateFile:" + filePath
 	if !dmlock.TryLock(memoryLockKey) {
 		return nil
 	}
 	defer dmlock.Unlock(memoryLockKey)
 
-	//最大备份数，如果为0，则表示不备份，把该文件删除
+	//最大备份数，如果为0，则表示不备份，按大小和按周期旋转时都直接把该文件删除
 	if that.config.RotateBackupLimit == 0 {
 		if err := dfile.Remove(filePath); err != nil {
 			return err
 		}
-		intlog.Printf(context.TODO(), `%d size exceeds, no backups set, remove original logging file: %s`, that.config.RotateSize, filePath)
+		intlog.Printf(context.TODO(), `rotation with no backups set, remove original logging file: %s`, filePath)
 		return nil
 	}
 	// 为备份做准备，留下原始原件的信息
@@ -46,9 +45,9 @@ func (that *Logger) doRotateFile(filePath string) error {
 		dirPath     = dfile.Dir(filePath)
 		fileName    = dfile.Name(filePath)
 		fileExtName = dfile.ExtName(filePath)
-		newFilePath = ""
+		newFilePath = backupPath
 	)
-	for {
+	for newFilePath == "" {
 		var (
 			now   = dtime.Now()
 			micro = now.Microsecond() % 1000
@@ -68,16 +67,16 @@ func (that *Logger) doRotateFile(filePath string) error {
 				fileName, now.Format("YmdHisu"), micro, fileExtName,
 			),
 		)
-		if !dfile.Exists(newFilePath) {
-			break
-		} else {
+		if dfile.Exists(newFilePath) {
 			intlog.Printf(context.TODO(), `rotation file exists, continue: %s`, newFilePath)
+			newFilePath = ""
 		}
 	}
 	//把老的文件改名
 	if err := dfile.Rename(filePath, newFilePath); err != nil {
 		return err
 	}
+	that.afterRotate(filePath, newFilePath)
 	return nil
 }
 
@@ -86,6 +85,16 @@ func (that *Logger) rotateChecksTimely() {
 	//每次执行完毕，重新把定时方法加入
 	defer dtimer.AddOnce(that.config.RotateCheckInterval, that.rotateChecksTimely)
 
+	//按周期旋转时，即使没有写入日志也在周期结束后备份
+	if that.config.RotatePeriod != "" && that.config.Path != "" {
+		now := time.Now()
+		filePath := that.getFilePath(now)
+		memoryLockKey := "dlog.printToFile:" + filePath
+		dmlock.Lock(memoryLockKey)
+		that.rotateFileByPeriod(now, filePath)
+		dmlock.Unlock(memoryLockKey)
+		that.pruneBackups(filePath)
+	}
 	//旋转文件备份未启动
 	if that.config.RotateSize <= 0 && that.config.RotateExpire == 0 {
 		intlog.Printf(context.TODO(),
@@ -130,7 +139,7 @@ func (that *Logger) rotateChecksTimely() {
 					`%v - %v = %v > %v, rotation expire logging file: %s`,
 					now, mtime, subDuration, that.config.RotateExpire, file,
 				)
-				if err := that.doRotateFile(file); err != nil {
+				if err := that.doRotateFile(file, ""); err != nil {
 					intlog.Error(context.TODO(), err)
 				}
 			}
@@ -160,13 +169,7 @@ func (that *Logger) rotateChecksTimely() {
 		}
 		if needCompressFileArray.Len() > 0 {
 			needCompressFileArray.Iterator(func(_ int, path string) bool {
-				err := dcompress.GzipFile(path, path+".gz")
-				if err == nil {
-					intlog.Printf(context.TODO(), `compressed done, remove original logging file: %s`, path)
-					if err = dfile.Remove(path); err != nil {
-						intlog.Print(context.TODO(), err)
-					}
-				} else {
+				if _, err := compressBackup(path, that.config.RotateBackupCompress); err != nil {
 					intlog.Print(context.TODO(), err)
 				}
 				return true
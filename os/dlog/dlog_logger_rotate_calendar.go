@@ -0,0 +1,285 @@
+package dlog
+
+import (
+	"context"
+	"fmt"
+	"github.com/osgochina/donkeygo/encoding/dcompress"
+	"github.com/osgochina/donkeygo/internal/intlog"
+	"github.com/osgochina/donkeygo/os/dfile"
+	"github.com/osgochina/donkeygo/os/dmlock"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	RotateHourly = "hourly" // 每小时整点旋转日志
+	RotateDaily  = "daily"  // 每天零点旋转日志
+)
+
+// 旋转周期默认的备份文件名格式
+var defaultRotatePatterns = map[string]string{
+	RotateHourly: "%Y%m%d%H",
+	RotateDaily:  "%Y%m%d",
+}
+
+// 时区缓存，避免每次写日志都加载时区文件
+var rotateLocations sync.Map
+
+// 按照日历周期旋转日志文件，文件最后修改时间所在的周期早于当前周期时，把文件按照其所在周期的开始时间重命名
+func (that *Logger) rotateFileByPeriod(now time.Time, filePath string) {
+	if !dfile.Exists(filePath) {
+		return
+	}
+	loc := that.rotateLocation()
+	start := rotatePeriodStart(dfile.MTime(filePath).In(loc), that.config.RotatePeriod)
+	if start.IsZero() || !start.Before(rotatePeriodStart(now.In(loc), that.config.RotatePeriod)) {
+		return
+	}
+	var (
+		dirPath     = dfile.Dir(filePath)
+		fileName    = dfile.Name(filePath)
+		fileExtName = dfile.ExtName(filePath)
+		base        = fmt.Sprintf(`%s.%s`, fileName, strftime(that.rotatePattern(), start))
+		backupPath  = dfile.Join(dirPath, base+"."+fileExtName)
+	)
+	// 同一个周期内可能因为大小等原因已经备份过，文件名追加序号
+	for i := 1; dfile.Exists(backupPath) || dfile.Exists(backupPath+".gz"); i++ {
+		backupPath = dfile.Join(dirPath, fmt.Sprintf(`%s.%d.%s`, base, i, fileExtName))
+	}
+	if err := that.doRotateFile(filePath, backupPath); err != nil {
+		intlog.Error(context.TODO(), err)
+	}
+}
+
+// 获取旋转周期使用的时区
+func (that *Logger) rotateLocation() *time.Location {
+	name := that.config.RotateTimezone
+	if name == "" {
+		return time.Local
+	}
+	if v, ok := rotateLocations.Load(name); ok {
+		return v.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		intlog.Error(context.TODO(), err)
+		loc = time.Local
+	}
+	rotateLocations.Store(name, loc)
+	return loc
+}
+
+// 获取备份文件名的strftime格式
+func (that *Logger) rotatePattern() string {
+	if that.config.RotatePattern != "" {
+		return that.config.RotatePattern
+	}
+	return defaultRotatePatterns[that.config.RotatePeriod]
+}
+
+// 获取时间所在旋转周期的开始时间，周期无效时返回零值
+func rotatePeriodStart(t time.Time, period string) time.Time {
+	switch period {
+	case RotateHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case RotateDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+// 旋转完成后在后台压缩备份文件、按总大小清理备份并调用旋转回调，不阻塞写日志的goroutine
+func (that *Logger) afterRotate(filePath, backupPath string) {
+	config := that.config
+	if config.RotateBackupCompress <= 0 && config.RotatePeriod == "" && config.RotateBackupMaxBytes <= 0 && config.RotateHook == nil {
+		return
+	}
+	that.rotating.Add(1)
+	go func() {
+		defer that.rotating.Done()
+		if config.RotateBackupCompress > 0 {
+			if path, err := compressBackup(backupPath, config.RotateBackupCompress); err != nil {
+				intlog.Error(context.TODO(), err)
+			} else {
+				backupPath = path
+			}
+		}
+		that.pruneBackups(filePath)
+		if config.RotateHook != nil {
+			config.RotateHook(filePath, backupPath)
+		}
+	}()
+}
+
+// WaitRotate 等待旋转后在后台执行的压缩、清理备份和旋转回调完成，用于测试或者程序退出前
+func (that *Logger) WaitRotate() {
+	that.rotating.Wait()
+}
+
+// 压缩备份文件，返回压缩后的文件路径
+func compressBackup(path string, level int) (string, error) {
+	memoryLockKey := "dlog.compressBackup:" + path
+	if !dmlock.TryLock(memoryLockKey) {
+		return path, nil
+	}
+	defer dmlock.Unlock(memoryLockKey)
+	if !dfile.Exists(path) {
+		return path, nil
+	}
+	if err := dcompress.GzipFile(path, path+".gz", level); err != nil {
+		return path, err
+	}
+	intlog.Printf(context.TODO(), `compressed done, remove original logging file: %s`, path)
+	if err := dfile.Remove(path); err != nil {
+		return path + ".gz", err
+	}
+	return path + ".gz", nil
+}
+
+// 按照备份数量、过期时间和总大小清理日志文件最旧的备份
+func (that *Logger) pruneBackups(filePath string) {
+	if that.config.RotatePeriod == "" && that.config.RotateBackupMaxBytes <= 0 {
+		return
+	}
+	memoryLockKey := "dlog.pruneBackups:" + filePath
+	if !dmlock.TryLock(memoryLockKey) {
+		return
+	}
+	defer dmlock.Unlock(memoryLockKey)
+
+	var (
+		now     = time.Now()
+		backups = that.backupFiles(filePath)
+		total   = int64(0)
+		remain  = make([]string, 0, len(backups))
+	)
+	for i, path := range backups {
+		var reason string
+		switch {
+		case that.config.RotateBackupLimit > 0 && len(backups)-i > that.config.RotateBackupLimit:
+			reason = "exceeded backup limit"
+		case that.config.RotateBackupExpire > 0 && now.Sub(dfile.MTime(path)) > that.config.RotateBackupExpire:
+			reason = "expired"
+		}
+		if reason == "" {
+			remain = append(remain, path)
+			total += dfile.Size(path)
+			continue
+		}
+		intlog.Printf(context.TODO(), `remove %s backup file: %s`, reason, path)
+		if err := dfile.Remove(path); err != nil {
+			intlog.Error(context.TODO(), err)
+		}
+	}
+	if that.config.RotateBackupMaxBytes <= 0 {
+		return
+	}
+	for _, path := range remain {
+		if total <= that.config.RotateBackupMaxBytes {
+			break
+		}
+		size := dfile.Size(path)
+		intlog.Printf(context.TODO(), `remove backup file exceeding total size %d: %s`, that.config.RotateBackupMaxBytes, path)
+		if err := dfile.Remove(path); err != nil {
+			intlog.Error(context.TODO(), err)
+			continue
+		}
+		total -= size
+	}
+}
+
+// 获取日志文件的所有备份文件，包括按大小和按周期旋转的备份，按修改时间从旧到新排序
+func (that *Logger) backupFiles(filePath string) []string {
+	var (
+		fileName    = dfile.Name(filePath)
+		fileExtName = dfile.ExtName(filePath)
+		pattern     = `\d{20}`
+	)
+	if p := that.rotatePattern(); p != "" {
+		pattern += `|` + strftimeRegex(p) + `(\.\d+)?`
+	}
+	re, err := regexp.Compile(fmt.Sprintf(
+		`^%s\.(%s)\.%s(\.gz)?$`,
+		regexp.QuoteMeta(fileName), pattern, regexp.QuoteMeta(fileExtName),
+	))
+	if err != nil {
+		intlog.Error(context.TODO(), err)
+		return nil
+	}
+	files, _ := dfile.ScanDirFile(dfile.Dir(filePath), fileName+".*")
+	backups := make([]string, 0, len(files))
+	for _, file := range files {
+		if re.MatchString(dfile.Basename(file)) {
+			backups = append(backups, file)
+		}
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		return dfile.MTimestampMilli(backups[i]) < dfile.MTimestampMilli(backups[j])
+	})
+	return backups
+}
+
+// 按照strftime格式格式化时间，支持%Y %y %m %d %H %M %S %j %%
+func strftime(pattern string, t time.Time) string {
+	var buffer strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' || i == len(pattern)-1 {
+			buffer.WriteByte(pattern[i])
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case 'Y':
+			buffer.WriteString(strconv.Itoa(t.Year()))
+		case 'y':
+			buffer.WriteString(fmt.Sprintf("%02d", t.Year()%100))
+		case 'm':
+			buffer.WriteString(fmt.Sprintf("%02d", int(t.Month())))
+		case 'd':
+			buffer.WriteString(fmt.Sprintf("%02d", t.Day()))
+		case 'H':
+			buffer.WriteString(fmt.Sprintf("%02d", t.Hour()))
+		case 'M':
+			buffer.WriteString(fmt.Sprintf("%02d", t.Minute()))
+		case 'S':
+			buffer.WriteString(fmt.Sprintf("%02d", t.Second()))
+		case 'j':
+			buffer.WriteString(fmt.Sprintf("%03d", t.YearDay()))
+		case '%':
+			buffer.WriteByte('%')
+		default:
+			buffer.WriteByte('%')
+			buffer.WriteByte(pattern[i])
+		}
+	}
+	return buffer.String()
+}
+
+// 把strftime格式转换为匹配格式化结果的正则表达式
+func strftimeRegex(pattern string) string {
+	var buffer strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' || i == len(pattern)-1 {
+			buffer.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case 'Y':
+			buffer.WriteString(`\d{4}`)
+		case 'y', 'm', 'd', 'H', 'M', 'S':
+			buffer.WriteString(`\d{2}`)
+		case 'j':
+			buffer.WriteString(`\d{3}`)
+		case '%':
+			buffer.WriteString(`%`)
+		default:
+			buffer.WriteString(regexp.QuoteMeta(pattern[i-1 : i+1]))
+		}
+	}
+	return buffer.String()
+}
@@ -0,0 +1,211 @@
+package dlog
+
+import (
+	"fmt"
+	"github.com/osgochina/donkeygo/os/dtimer"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SampleByMessage = "message" // 按照日志级别和消息模板分组采样，默认的分组方式
+	SampleByCaller  = "caller"  // 按照调用日志方法的代码位置分组采样
+)
+
+const (
+	defaultSampleInterval = time.Second // 默认的采样周期
+	sampleCallerDepth     = 8           // 查找调用位置时最多回溯的栈堆层数
+	fnvOffset64           = 14695981039346656037
+	fnvPrime64            = 1099511628211
+)
+
+// 日志采样器，每个周期内每种日志先输出SampleInitial条，之后每SampleThereafter条输出一条
+type sampler struct {
+	mu         sync.Mutex
+	initial    uint64
+	thereafter uint64
+	interval   int64
+	byCaller   bool
+	lastSweep  int64
+	counters   map[uint64]*sampleCounter
+}
+
+// 每种日志在当前周期内的计数
+type sampleCounter struct {
+	start      int64  // 当前周期的开始时间
+	count      uint64 // 当前周期内的日志条数
+	suppressed uint64 // 当前周期内被丢弃的条数
+	level      int
+	template   string // 消息模板或者调用位置，用于输出汇总信息
+}
+
+// 被丢弃的日志汇总
+type sampleSummary struct {
+	level      int
+	interval   int64
+	suppressed uint64
+	template   string
+}
+
+// 根据配置创建采样器，没有开启采样时返回nil
+func newSampler(config Config) *sampler {
+	if config.SampleInitial <= 0 {
+		return nil
+	}
+	s := &sampler{
+		initial:  uint64(config.SampleInitial),
+		interval: int64(config.SampleInterval),
+		byCaller: strings.EqualFold(config.SampleBy, SampleByCaller),
+		counters: make(map[uint64]*sampleCounter),
+	}
+	if config.SampleThereafter > 0 {
+		s.thereafter = uint64(config.SampleThereafter)
+	}
+	if s.interval <= 0 {
+		s.interval = int64(defaultSampleInterval)
+	}
+	return s
+}
+
+// SetSampling 设置日志采样，每个周期<interval>内每种日志先输出<initial>条，之后每<thereafter>条输出一条，
+// <thereafter>为0时丢弃之后的所有日志，<by>为分组方式，<initial>为0时关闭采样。
+// 周期结束后会输出一条日志汇总被丢弃的条数
+func (that *Logger) SetSampling(initial, thereafter int, interval time.Duration, by ...string) {
+	that.config.SampleInitial = initial
+	that.config.SampleThereafter = thereafter
+	that.config.SampleInterval = interval
+	that.config.SampleBy = SampleByMessage
+	if len(by) > 0 && by[0] != "" {
+		that.config.SampleBy = by[0]
+	}
+	that.sampler = newSampler(that.config)
+}
+
+// 判断日志是否需要输出，日志被丢弃时不会分配内存
+func (that *Logger) sample(level int, format string, values []interface{}) bool {
+	s := that.sampler
+	if s == nil {
+		return true
+	}
+	var (
+		key      = uint64(fnvOffset64)
+		template = format
+		pc       uintptr
+	)
+	if s.byCaller {
+		pc = sampleCaller()
+		key = (key ^ uint64(pc)) * fnvPrime64
+	} else {
+		if template == "" && len(values) > 0 {
+			template, _ = values[0].(string)
+		}
+		key = (key ^ uint64(level)) * fnvPrime64
+		for i := 0; i < len(template); i++ {
+			key = (key ^ uint64(template[i])) * fnvPrime64
+		}
+	}
+	allowed, summary := s.allow(that, key, level, template, pc)
+	if summary != nil {
+		that.printSampleSummary(summary)
+	}
+	return allowed
+}
+
+// 输出被丢弃的日志汇总
+func (that *Logger) printSampleSummary(summary *sampleSummary) {
+	that.print(os.Stdout, summary.level, "", fmt.Sprintf(
+		`sampling suppressed %d message(s) in %s: %s`,
+		summary.suppressed, time.Duration(summary.interval).String(), summary.template,
+	))
+}
+
+// 计数并判断是否输出，该种日志上一个周期已经结束但汇总还没有输出时同时返回其汇总
+func (that *sampler) allow(logger *Logger, key uint64, level int, template string, pc uintptr) (bool, *sampleSummary) {
+	var (
+		now     = time.Now().UnixNano()
+		summary *sampleSummary
+	)
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	// 定期删除已经结束并且没有丢弃日志的周期，有丢弃日志的周期由定时器输出汇总后删除
+	if now-that.lastSweep >= that.interval {
+		that.lastSweep = now
+		for k, c := range that.counters {
+			if c.suppressed == 0 && now-c.start >= that.interval {
+				delete(that.counters, k)
+			}
+		}
+	}
+	c, ok := that.counters[key]
+	if !ok {
+		c = &sampleCounter{start: now, level: level, template: template}
+		if pc != 0 {
+			c.template = callerLocation(pc)
+		}
+		that.counters[key] = c
+	} else if now-c.start >= that.interval {
+		// 周期已经结束，定时器还没有输出汇总时在这里输出，并开始新的周期
+		if c.suppressed > 0 {
+			summary = &sampleSummary{level: c.level, interval: that.interval, suppressed: c.suppressed, template: c.template}
+		}
+		c.start, c.count, c.suppressed = now, 0, 0
+	}
+	c.count++
+	if c.count <= that.initial {
+		return true, summary
+	}
+	if that.thereafter > 0 && (c.count-that.initial)%that.thereafter == 0 {
+		return true, summary
+	}
+	c.suppressed++
+	if c.suppressed == 1 {
+		// 周期内第一次丢弃日志时设置定时器，周期结束时输出汇总
+		start := c.start
+		dtimer.SetTimeout(time.Duration(start+that.interval-now), func() {
+			if summary := that.flush(key, start); summary != nil {
+				logger.printSampleSummary(summary)
+			}
+		})
+	}
+	return false, summary
+}
+
+// 周期结束，返回被丢弃的日志汇总并删除计数，该周期的汇总已经输出时返回nil
+func (that *sampler) flush(key uint64, start int64) *sampleSummary {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	c, ok := that.counters[key]
+	if !ok || c.start != start || c.suppressed == 0 {
+		return nil
+	}
+	delete(that.counters, key)
+	return &sampleSummary{level: c.level, interval: that.interval, suppressed: c.suppressed, template: c.template}
+}
+
+// 获取调用日志方法的代码位置，跳过dlog包内部的调用
+func sampleCaller() uintptr {
+	var pcs [sampleCallerDepth]uintptr
+	n := runtime.Callers(3, pcs[:])
+	for _, pc := range pcs[:n] {
+		if fn := runtime.FuncForPC(pc - 1); fn != nil {
+			file, _ := fn.FileLine(pc - 1)
+			if strings.Contains(file, pathFilterKey) && !strings.HasSuffix(file, "_test.go") {
+				continue
+			}
+		}
+		return pc
+	}
+	return 0
+}
+
+// 获取代码位置的文件名和行号
+func callerLocation(pc uintptr) string {
+	if fn := runtime.FuncForPC(pc - 1); fn != nil {
+		file, line := fn.FileLine(pc - 1)
+		return fmt.Sprintf(`%s:%d`, file, line)
+	}
+	return "unknown"
+}
@@ -0,0 +1,112 @@
+package dlog
+
+import (
+	"context"
+	"github.com/osgochina/donkeygo/internal/intlog"
+	"sync"
+	"sync/atomic"
+)
+
+// 缓冲区满时的丢弃策略
+const (
+	DropNewest = iota // 丢弃新的日志，默认的策略
+	DropOldest        // 丢弃缓冲区中最旧的日志
+	DropNone          // 不丢弃，阻塞等待缓冲区有空位
+)
+
+const defaultAsyncSinkSize = 1024 // 默认的缓冲区大小
+
+// AsyncSink 有界的异步缓冲输出端，日志先写入缓冲区，由后台goroutine交给下一个处理器，
+// 缓冲区满时按照丢弃策略处理，避免慢速的输出端阻塞业务
+type AsyncSink struct {
+	next    Handler
+	policy  int
+	queue   chan *Entry
+	dropped int64
+	closed  int32
+	mu      sync.RWMutex // 保证Close之后不会再写入队列
+	done    chan struct{}
+}
+
+// NewAsyncSink 创建异步缓冲输出端，<size>为缓冲区能容纳的日志条数，小于等于0时使用默认值1024，
+// <policy>为缓冲区满时的丢弃策略，默认为DropNewest
+func NewAsyncSink(next Handler, size int, policy ...int) *AsyncSink {
+	if size <= 0 {
+		size = defaultAsyncSinkSize
+	}
+	s := &AsyncSink{
+		next:  next,
+		queue: make(chan *Entry, size),
+		done:  make(chan struct{}),
+	}
+	if len(policy) > 0 {
+		s.policy = policy[0]
+	}
+	go s.loop()
+	return s
+}
+
+// Handle 把日志写入缓冲区
+func (that *AsyncSink) Handle(entry *Entry) error {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	if atomic.LoadInt32(&that.closed) == 1 {
+		atomic.AddInt64(&that.dropped, 1)
+		return nil
+	}
+	switch that.policy {
+	case DropNone:
+		that.queue <- entry
+	case DropOldest:
+		for {
+			select {
+			case that.queue <- entry:
+				return nil
+			default:
+			}
+			select {
+			case <-that.queue:
+				atomic.AddInt64(&that.dropped, 1)
+			default:
+			}
+		}
+	default:
+		select {
+		case that.queue <- entry:
+		default:
+			atomic.AddInt64(&that.dropped, 1)
+		}
+	}
+	return nil
+}
+
+// Dropped 获取被丢弃的日志条数
+func (that *AsyncSink) Dropped() int64 {
+	return atomic.LoadInt64(&that.dropped)
+}
+
+// Len 获取缓冲区中等待输出的日志条数
+func (that *AsyncSink) Len() int {
+	return len(that.queue)
+}
+
+// Close 停止接收新的日志，并等待缓冲区中的日志全部输出
+func (that *AsyncSink) Close() {
+	if !atomic.CompareAndSwapInt32(&that.closed, 0, 1) {
+		return
+	}
+	that.mu.Lock()
+	close(that.queue)
+	that.mu.Unlock()
+	<-that.done
+}
+
+// 后台输出日志
+func (that *AsyncSink) loop() {
+	defer close(that.done)
+	for entry := range that.queue {
+		if err := that.next.Handle(entry); err != nil {
+			intlog.Error(context.TODO(), err)
+		}
+	}
+}
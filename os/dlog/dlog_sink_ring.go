@@ -0,0 +1,93 @@
+package dlog
+
+import (
+	"io"
+	"sync"
+)
+
+// RingSink 容量有限的内存环形缓冲输出端，只保留最近的日志，总大小超过限制时丢弃最旧的日志，
+// 一般用于程序崩溃时输出最近的日志
+type RingSink struct {
+	mu      sync.Mutex
+	maxSize int
+	size    int
+	lines   [][]byte
+	head    int // 最旧的日志在lines中的位置
+}
+
+// NewRingSink 创建内存环形缓冲输出端，<maxSize>为保留日志的最大字节数
+func NewRingSink(maxSize int) *RingSink {
+	return &RingSink{maxSize: maxSize}
+}
+
+// Handle 保存日志，单条超过最大字节数的日志只保留末尾部分
+func (that *RingSink) Handle(entry *Entry) error {
+	if that.maxSize <= 0 || len(entry.Content) == 0 {
+		return nil
+	}
+	line := entry.Content
+	if len(line) > that.maxSize {
+		line = line[len(line)-that.maxSize:]
+	}
+	line = append([]byte(nil), line...)
+
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	for that.size+len(line) > that.maxSize {
+		that.size -= len(that.lines[that.head])
+		that.lines[that.head] = nil
+		that.head++
+	}
+	// 丢弃的日志超过一半时整理切片，避免占用的内存持续增长
+	if that.head > 0 && that.head >= len(that.lines)/2 {
+		that.lines = append(that.lines[:0], that.lines[that.head:]...)
+		that.head = 0
+	}
+	that.lines = append(that.lines, line)
+	that.size += len(line)
+	return nil
+}
+
+// Bytes 按照时间顺序返回保存的所有日志
+func (that *RingSink) Bytes() []byte {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	b := make([]byte, 0, that.size)
+	for _, line := range that.lines[that.head:] {
+		b = append(b, line...)
+	}
+	return b
+}
+
+// Lines 按照时间顺序返回保存的每条日志
+func (that *RingSink) Lines() []string {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	lines := make([]string, 0, len(that.lines)-that.head)
+	for _, line := range that.lines[that.head:] {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// Size 获取保存的日志的总字节数
+func (that *RingSink) Size() int {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	return that.size
+}
+
+// WriteTo 把保存的日志写入<writer>，用于崩溃时转储
+func (that *RingSink) WriteTo(writer io.Writer) (int64, error) {
+	n, err := writer.Write(that.Bytes())
+	return int64(n), err
+}
+
+// Reset 清空保存的日志
+func (that *RingSink) Reset() {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	that.lines = nil
+	that.head = 0
+	that.size = 0
+}
@@ -0,0 +1,174 @@
+package dlog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// syslog的设施
+const (
+	SyslogKern   = 0
+	SyslogUser   = 1
+	SyslogDaemon = 3
+	SyslogAuth   = 4
+	SyslogSyslog = 5
+	SyslogLocal0 = 16
+	SyslogLocal1 = 17
+	SyslogLocal2 = 18
+	SyslogLocal3 = 19
+	SyslogLocal4 = 20
+	SyslogLocal5 = 21
+	SyslogLocal6 = 22
+	SyslogLocal7 = 23
+)
+
+const (
+	syslogMaxApp   = 48              // RFC 5424中APP-NAME的最大长度
+	syslogMaxHost  = 255             // RFC 5424中HOSTNAME的最大长度
+	syslogTimeout  = 3 * time.Second // 连接超时时间
+	syslogNilValue = "-"             // 空字段使用的值
+)
+
+// 日志级别对应的syslog严重程度
+var syslogSeverities = map[int]int{
+	LevelFatal:    0, // Emergency
+	LevelPanic:    1, // Alert
+	LevelCritical: 2, // Critical
+	LevelError:    3, // Error
+	LevelWarning:  4, // Warning
+	LevelNotice:   5, // Notice
+	LevelInfo:     6, // Informational
+	LevelDebug:    7, // Debug
+}
+
+// SyslogSink 通过UDP或TCP按照RFC 5424格式发送日志到syslog服务，
+// TCP连接使用RFC 6587的octet-counting分帧，连接断开后下次发送时自动重连
+type SyslogSink struct {
+	mu       sync.Mutex
+	network  string
+	address  string
+	facility int
+	appName  string
+	hostname string
+	procId   string
+	conn     net.Conn
+}
+
+// NewSyslogSink 创建syslog输出端，<network>为udp或tcp，<appName>为空时使用当前进程名
+func NewSyslogSink(network, address string, facility int, appName ...string) (*SyslogSink, error) {
+	switch network {
+	case "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6":
+	default:
+		return nil, errors.New(fmt.Sprintf(`unsupported syslog network "%s"`, network))
+	}
+	if facility < 0 || facility > 23 {
+		return nil, errors.New(fmt.Sprintf(`invalid syslog facility %d`, facility))
+	}
+	s := &SyslogSink{
+		network:  network,
+		address:  address,
+		facility: facility,
+		appName:  filepath.Base(os.Args[0]),
+		procId:   strconv.Itoa(os.Getpid()),
+	}
+	if len(appName) > 0 && appName[0] != "" {
+		s.appName = appName[0]
+	}
+	s.appName = syslogHeaderValue(s.appName, syslogMaxApp)
+	hostname, _ := os.Hostname()
+	s.hostname = syslogHeaderValue(hostname, syslogMaxHost)
+	return s, nil
+}
+
+// Handle 发送日志
+func (that *SyslogSink) Handle(entry *Entry) error {
+	msg := that.format(entry)
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if that.conn == nil {
+		if err := that.connect(); err != nil {
+			return err
+		}
+	}
+	if _, err := that.conn.Write(msg); err != nil {
+		// 连接可能已经被服务端关闭，重连后重试一次
+		_ = that.conn.Close()
+		that.conn = nil
+		if err = that.connect(); err != nil {
+			return err
+		}
+		_, err = that.conn.Write(msg)
+		return err
+	}
+	return nil
+}
+
+// Close 关闭连接
+func (that *SyslogSink) Close() error {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	if that.conn == nil {
+		return nil
+	}
+	err := that.conn.Close()
+	that.conn = nil
+	return err
+}
+
+// 建立连接
+func (that *SyslogSink) connect() error {
+	conn, err := net.DialTimeout(that.network, that.address, syslogTimeout)
+	if err != nil {
+		return err
+	}
+	that.conn = conn
+	return nil
+}
+
+// 按照RFC 5424格式化日志: <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func (that *SyslogSink) format(entry *Entry) []byte {
+	severity, ok := syslogSeverities[entry.Level]
+	if !ok {
+		severity = syslogSeverities[LevelInfo]
+	}
+	buffer := bytes.NewBuffer(nil)
+	buffer.WriteString(fmt.Sprintf(
+		"<%d>1 %s %s %s %s %s %s ",
+		that.facility*8+severity,
+		entry.Time.Format("2006-01-02T15:04:05.000000Z07:00"),
+		that.hostname,
+		that.appName,
+		that.procId,
+		syslogNilValue,
+		syslogNilValue,
+	))
+	// syslog头部已经包含了时间和级别，MSG只使用日志内容、上下文中的值、键值对和栈堆，不使用格式化后的内容
+	message := joinFields(joinFields(entry.Message, entry.CtxValues), entry.Fields)
+	buffer.WriteString(strings.TrimRight(joinStack(message, entry.Stack), "\r\n"))
+	if that.network[:3] == "tcp" {
+		return append([]byte(strconv.Itoa(buffer.Len())+" "), buffer.Bytes()...)
+	}
+	return buffer.Bytes()
+}
+
+// 头部字段只能包含可打印的ASCII字符，为空时使用"-"
+func syslogHeaderValue(s string, max int) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(b) < max; i++ {
+		if s[i] > 32 && s[i] < 127 {
+			b = append(b, s[i])
+		}
+	}
+	if len(b) == 0 {
+		return syslogNilValue
+	}
+	return string(b)
+}
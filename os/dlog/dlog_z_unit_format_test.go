@@ -0,0 +1,120 @@
+package dlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/osgochina/donkeygo/internal/json"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"github.com/osgochina/donkeygo/text/dstr"
+	"strings"
+	"testing"
+)
+
+func Test_Format_Json(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		w := bytes.NewBuffer(nil)
+		l := NewWithWriter(w)
+		l.SetFormat(FormatJson)
+		l.SetFlags(FTimeStd | FFileShort)
+		l.SetPrefix("app")
+		l.SetCtxKeys("TraceId")
+		ctx := context.WithValue(context.Background(), "TraceId", "abc")
+		l.Ctx(ctx).With("user", 1, "ok", true, "err", errors.New("boom")).Info("hello", "world")
+
+		m := make(map[string]interface{})
+		t.Assert(json.Unmarshal(w.Bytes(), &m), nil)
+		t.Assert(m["level"], "INFO")
+		t.Assert(m["msg"], "hello world")
+		t.Assert(m["prefix"], "app")
+		t.Assert(m["TraceId"], "abc")
+		t.Assert(m["user"], 1)
+		t.Assert(m["ok"], true)
+		t.Assert(m["err"], "boom")
+		t.Assert(strings.Contains(m["caller"].(string), ".go:"), true)
+		t.AssertNE(m["time"], nil)
+		t.Assert(m["stack"], nil)
+		t.Assert(strings.Count(w.String(), "\n"), 1)
+	})
+	// 错误日志的栈堆是独立的字段
+	dtest.C(t, func(t *dtest.T) {
+		w := bytes.NewBuffer(nil)
+		l := NewWithWriter(w)
+		l.SetFormat(FormatJson)
+		l.Error("failed")
+
+		m := make(map[string]interface{})
+		t.Assert(json.Unmarshal(w.Bytes(), &m), nil)
+		t.Assert(m["msg"], "failed")
+		t.Assert(m["level"], "ERRO")
+		t.AssertNE(m["stack"], nil)
+	})
+	// 与固定字段重名的键值对添加前缀，不会覆盖固定字段
+	dtest.C(t, func(t *dtest.T) {
+		w := bytes.NewBuffer(nil)
+		l := NewWithWriter(w)
+		l.SetFormat(FormatJson)
+		l.With("time", "t", "level", "l", "msg", "m").Info("hello")
+
+		m := make(map[string]interface{})
+		t.Assert(json.Unmarshal(w.Bytes(), &m), nil)
+		t.AssertNE(m["time"], "t")
+		t.Assert(m["level"], "INFO")
+		t.Assert(m["msg"], "hello")
+		t.Assert(m["fields.time"], "t")
+		t.Assert(m["fields.level"], "l")
+		t.Assert(m["fields.msg"], "m")
+		t.Assert(strings.Count(w.String(), `"msg"`), 1)
+	})
+}
+
+func Test_Format_Logfmt(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		w := bytes.NewBuffer(nil)
+		l := NewWithWriter(w)
+		l.SetFormat(FormatLogfmt)
+		l.Fields(map[string]interface{}{"b": "x y", "a": 1}).Info("hello world")
+
+		s := w.String()
+		t.Assert(strings.HasPrefix(s, "time="), true)
+		t.Assert(dstr.Contains(s, ` level=INFO a=1 b="x y" msg="hello world"`+"\n"), true)
+	})
+}
+
+func Test_Format_Text(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		w := bytes.NewBuffer(nil)
+		l := NewWithWriter(w)
+		l.SetHeaderPrint(false)
+		l.With("a", 1, "b", "x y").Info("hello")
+		t.Assert(w.String(), "hello a=1 b=\"x y\"\n")
+
+		w.Reset()
+		l.Info("hello")
+		t.Assert(w.String(), "hello\n")
+	})
+}
+
+func Test_With(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		w := bytes.NewBuffer(nil)
+		l := NewWithWriter(w)
+		l.SetHeaderPrint(false)
+		base := l.With("a", 1)
+		child1 := base.With("b", 2)
+		child2 := base.With("a", 3, "c")
+
+		base.Print("m")
+		child1.Print("m")
+		child2.Print("m")
+		t.Assert(w.String(), "m a=1\nm a=1 b=2\nm a=3 c=\"\"\n")
+	})
+	dtest.C(t, func(t *dtest.T) {
+		w := bytes.NewBuffer(nil)
+		Format(FormatJson).To(w).With("k", "v").Print("m")
+		m := make(map[string]interface{})
+		t.Assert(json.Unmarshal(w.Bytes(), &m), nil)
+		t.Assert(m["k"], "v")
+		t.Assert(m["msg"], "m")
+	})
+}
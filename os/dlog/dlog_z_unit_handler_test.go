@@ -0,0 +1,264 @@
+package dlog
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 记录收到的日志的处理器
+type recordHandler struct {
+	mu      sync.Mutex
+	entries []*Entry
+}
+
+func (h *recordHandler) Handle(entry *Entry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, entry)
+	return nil
+}
+
+func (h *recordHandler) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.entries)
+}
+
+func Test_Sink_Level(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		w := bytes.NewBuffer(nil)
+		l := NewWithWriter(w)
+		all := &recordHandler{}
+		errs := &recordHandler{}
+		l.AddSink(all)
+		l.AddSink(errs, LevelError|LevelCritical)
+
+		l.Info("info")
+		l.Stack(false).Error("error")
+		l.Print("print")
+
+		t.Assert(strings.Count(w.String(), "\n"), 3)
+		t.Assert(all.Len(), 3)
+		t.Assert(errs.Len(), 2)
+		t.Assert(errs.entries[0].Level, LevelError)
+		t.Assert(errs.entries[0].Message, "error")
+		t.Assert(strings.Contains(string(errs.entries[0].Content), "[ERRO] error"), true)
+		t.Assert(errs.entries[1].Level, 0)
+	})
+	// 子日志对象添加的输出端不影响父对象
+	dtest.C(t, func(t *dtest.T) {
+		l := NewWithWriter(bytes.NewBuffer(nil))
+		h := &recordHandler{}
+		child := l.With("a", 1)
+		child.AddSink(h)
+		l.Info("parent")
+		child.Info("child")
+		t.Assert(h.Len(), 1)
+		t.Assert(len(l.GetSinks()), 0)
+	})
+}
+
+func Test_Middleware(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		w := bytes.NewBuffer(nil)
+		l := NewWithWriter(w)
+		l.SetHeaderPrint(false)
+		l.Use(RedactMiddleware("password"))
+		l.With("user", "john", "Password", "123456").Info("login")
+		t.Assert(w.String(), "login user=john Password=******\n")
+	})
+	dtest.C(t, func(t *dtest.T) {
+		w := bytes.NewBuffer(nil)
+		l := NewWithWriter(w)
+		h := &recordHandler{}
+		l.AddSink(h)
+		l.Use(SampleMiddleware(3))
+		for i := 0; i < 10; i++ {
+			l.Info(i)
+		}
+		t.Assert(strings.Count(w.String(), "\n"), 4)
+		t.Assert(h.Len(), 4)
+	})
+	// 中间件按照添加的顺序执行
+	dtest.C(t, func(t *dtest.T) {
+		w := bytes.NewBuffer(nil)
+		l := NewWithWriter(w)
+		l.SetHeaderPrint(false)
+		order := ""
+		mark := func(s string) Middleware {
+			return func(next Handler) Handler {
+				return HandlerFunc(func(entry *Entry) error {
+					order += s
+					entry.Message += s
+					return next.Handle(entry)
+				})
+			}
+		}
+		l.Use(mark("1"), mark("2"))
+		l.Print("m")
+		t.Assert(order, "12")
+		t.Assert(w.String(), "m12\n")
+	})
+}
+
+func Test_AsyncSink(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			release = make(chan struct{})
+			h       = &recordHandler{}
+		)
+		blocking := HandlerFunc(func(entry *Entry) error {
+			<-release
+			return h.Handle(entry)
+		})
+		s := NewAsyncSink(blocking, 2)
+		t.Assert(s.Handle(&Entry{Message: "m"}), nil)
+		time.Sleep(50 * time.Millisecond)
+		for i := 1; i < 10; i++ {
+			t.Assert(s.Handle(&Entry{Message: "m"}), nil)
+		}
+		// 第一条被后台取出阻塞，缓冲区中还有两条
+		t.Assert(s.Dropped(), 7)
+		close(release)
+		s.Close()
+		t.Assert(h.Len(), 3)
+		t.Assert(s.Handle(&Entry{}), nil)
+		t.Assert(s.Dropped(), 8)
+	})
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			release = make(chan struct{})
+			h       = &recordHandler{}
+		)
+		blocking := HandlerFunc(func(entry *Entry) error {
+			<-release
+			return h.Handle(entry)
+		})
+		s := NewAsyncSink(blocking, 2, DropOldest)
+		s.Handle(&Entry{Message: "0"})
+		time.Sleep(50 * time.Millisecond)
+		for i := 1; i < 10; i++ {
+			s.Handle(&Entry{Message: string(rune('0' + i))})
+		}
+		close(release)
+		s.Close()
+		t.Assert(h.Len(), 3)
+		t.Assert(h.entries[0].Message, "0")
+		t.Assert(h.entries[1].Message, "8")
+		t.Assert(h.entries[2].Message, "9")
+		t.Assert(s.Dropped(), 7)
+	})
+}
+
+func Test_RingSink(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		s := NewRingSink(10)
+		l := NewWithWriter(bytes.NewBuffer(nil))
+		l.SetHeaderPrint(false)
+		l.AddSink(s)
+		for _, v := range []string{"aaa", "bbb", "ccc", "ddd"} {
+			l.Print(v)
+		}
+		t.Assert(s.Lines(), []string{"ccc\n", "ddd\n"})
+		t.Assert(s.Size(), 8)
+
+		w := bytes.NewBuffer(nil)
+		n, err := s.WriteTo(w)
+		t.Assert(err, nil)
+		t.Assert(n, 8)
+		t.Assert(w.String(), "ccc\nddd\n")
+
+		l.Print("0123456789abc")
+		t.Assert(s.Lines(), []string{"456789abc\n"})
+
+		s.Reset()
+		t.Assert(s.Size(), 0)
+		t.Assert(len(s.Bytes()), 0)
+	})
+}
+
+func Test_SyslogSink(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		t.Assert(err, nil)
+		defer conn.Close()
+
+		s, err := NewSyslogSink("udp", conn.LocalAddr().String(), SyslogLocal0, "myapp")
+		t.Assert(err, nil)
+		defer s.Close()
+		// 使用默认的日志格式，syslog消息中不会重复包含时间和级别
+		l := NewWithWriter(bytes.NewBuffer(nil))
+		l.AddSink(s)
+		l.With("disk", "sda").Warning("disk full")
+
+		buf := make([]byte, 1024)
+		_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		t.Assert(err, nil)
+		msg := string(buf[:n])
+		// local0(16)*8 + warning(4)
+		t.Assert(strings.HasPrefix(msg, "<132>1 "), true)
+		t.Assert(strings.Contains(msg, " myapp "), true)
+		t.Assert(strings.HasSuffix(msg, " - - disk full disk=sda"), true)
+		t.Assert(strings.Contains(msg, "WARN"), false)
+	})
+	dtest.C(t, func(t *dtest.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		t.Assert(err, nil)
+		defer ln.Close()
+		received := make(chan string, 2)
+		go func() {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer c.Close()
+			r := bufio.NewReader(c)
+			for i := 0; i < 2; i++ {
+				var length int
+				if _, err := fmt.Fscan(r, &length); err != nil {
+					return
+				}
+				// 跳过长度后面的空格
+				if _, err := r.ReadByte(); err != nil {
+					return
+				}
+				b := make([]byte, length)
+				if _, err := io.ReadFull(r, b); err != nil {
+					return
+				}
+				received <- string(b)
+			}
+		}()
+
+		s, err := NewSyslogSink("tcp", ln.Addr().String(), SyslogUser, "app")
+		t.Assert(err, nil)
+		defer s.Close()
+		l := NewWithWriter(bytes.NewBuffer(nil))
+		l.SetFlags(0)
+		l.AddSink(s)
+		l.Error("e1")
+		l.Info("i2")
+
+		msg1 := <-received
+		msg2 := <-received
+		t.Assert(strings.HasPrefix(msg1, "<11>1 "), true)
+		t.Assert(strings.Contains(msg1, " - - e1 \nStack:\n"), true)
+		t.Assert(strings.HasPrefix(msg2, "<14>1 "), true)
+		t.Assert(strings.HasSuffix(msg2, " - - i2"), true)
+	})
+	dtest.C(t, func(t *dtest.T) {
+		_, err := NewSyslogSink("unix", "/tmp/x", SyslogUser)
+		t.AssertNE(err, nil)
+		_, err = NewSyslogSink("udp", "127.0.0.1:514", 24)
+		t.AssertNE(err, nil)
+	})
+}
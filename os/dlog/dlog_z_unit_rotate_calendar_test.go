@@ -0,0 +1,180 @@
+package dlog
+
+import (
+	"github.com/osgochina/donkeygo/os/dfile"
+	"github.com/osgochina/donkeygo/os/dtime"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Strftime(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		tm := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+		t.Assert(strftime("%Y%m%d%H", tm), "2026030405")
+		t.Assert(strftime("%y-%m-%d_%H%M%S.%j%%%q", tm), "26-03-04_050607.063%%q")
+		re := regexp.MustCompile("^" + strftimeRegex("%Y-%m-%d.%H") + "$")
+		t.Assert(re.MatchString(strftime("%Y-%m-%d.%H", tm)), true)
+		t.Assert(re.MatchString("2026-03-04x05"), false)
+	})
+}
+
+func Test_Rotate_Period(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			p     = dfile.TempDir(dtime.TimestampNanoStr())
+			l     = New()
+			hooks [][2]string
+		)
+		err := l.SetConfigWithMap(map[string]interface{}{
+			"Path":              p,
+			"File":              "access.log",
+			"StdoutPrint":       false,
+			"RotatePeriod":      "Hourly",
+			"RotateTimezone":    "UTC",
+			"RotateBackupLimit": 10,
+		})
+		t.Assert(err, nil)
+		defer dfile.Remove(p)
+		l.SetRotateHook(func(file, backup string) {
+			hooks = append(hooks, [2]string{file, backup})
+		})
+
+		file := dfile.Join(p, "access.log")
+		l.Print("old")
+		last := time.Now().Add(-2 * time.Hour).UTC()
+		t.Assert(os.Chtimes(file, last, last), nil)
+		l.Print("new")
+
+		backup := dfile.Join(p, "access."+last.Format("2006010215")+".log")
+		t.Assert(strings.Contains(dfile.GetContents(file), "old"), false)
+		t.Assert(strings.Contains(dfile.GetContents(file), "new"), true)
+		t.Assert(strings.Contains(dfile.GetContents(backup), "old"), true)
+		l.WaitRotate()
+		t.Assert(hooks, [][2]string{{file, backup}})
+
+		// 同一周期内不重复旋转
+		l.Print("again")
+		t.Assert(strings.Count(dfile.GetContents(file), "\n"), 2)
+	})
+}
+
+func Test_Rotate_Period_Timezone_Compress(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			p     = dfile.TempDir(dtime.TimestampNanoStr())
+			l     = New()
+			hooks []string
+		)
+		err := l.SetConfigWithMap(map[string]interface{}{
+			"Path":                 p,
+			"File":                 "app.log",
+			"StdoutPrint":          false,
+			"RotatePeriod":         "daily",
+			"RotatePattern":        "%Y-%m-%d",
+			"RotateTimezone":       "Asia/Shanghai",
+			"RotateBackupCompress": 9,
+			"RotateBackupLimit":    10,
+		})
+		t.Assert(err, nil)
+		defer dfile.Remove(p)
+		l.SetRotateHook(func(file, backup string) {
+			hooks = append(hooks, backup)
+		})
+
+		file := dfile.Join(p, "app.log")
+		l.Print("old")
+		// UTC 2020-01-02 23:30 是上海时间 2020-01-03 07:30
+		last := time.Date(2020, 1, 2, 23, 30, 0, 0, time.UTC)
+		t.Assert(os.Chtimes(file, last, last), nil)
+		l.Print("new")
+
+		l.WaitRotate()
+		t.Assert(hooks, []string{dfile.Join(p, "app.2020-01-03.log.gz")})
+		t.Assert(dfile.Exists(dfile.Join(p, "app.2020-01-03.log.gz")), true)
+		t.Assert(dfile.Exists(dfile.Join(p, "app.2020-01-03.log")), false)
+	})
+}
+
+func Test_Rotate_BackupMaxBytes(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		p := dfile.TempDir(dtime.TimestampNanoStr())
+		l := New()
+		err := l.SetConfigWithMap(map[string]interface{}{
+			"Path":                 p,
+			"File":                 "access.log",
+			"StdoutPrint":          false,
+			"RotatePeriod":         "daily",
+			"RotateBackupMaxBytes": "25",
+			"RotateBackupLimit":    10,
+		})
+		t.Assert(err, nil)
+		t.Assert(l.config.RotateBackupMaxBytes, 25)
+		defer dfile.Remove(p)
+
+		// 已有的备份，按时间从旧到新，与当前日志无关的文件不受影响
+		now := time.Now()
+		for i, name := range []string{"access.20200101.log", "access.20200102.log", "access.20200103.log", "access.other.log"} {
+			path := dfile.Join(p, name)
+			t.Assert(dfile.PutContents(path, "0123456789"), nil)
+			mtime := now.Add(time.Duration(i-100) * time.Hour)
+			t.Assert(os.Chtimes(path, mtime, mtime), nil)
+		}
+		file := dfile.Join(p, "access.log")
+		t.Assert(dfile.PutContents(file, "01234\n"), nil)
+		last := now.Add(-48 * time.Hour)
+		t.Assert(os.Chtimes(file, last, last), nil)
+		l.Print("new")
+		l.WaitRotate()
+		t.Assert(dfile.Exists(dfile.Join(p, "access.20200101.log")), false)
+		t.Assert(dfile.Exists(dfile.Join(p, "access.20200102.log")), false)
+		t.Assert(dfile.Exists(dfile.Join(p, "access.20200103.log")), true)
+		t.Assert(dfile.Exists(dfile.Join(p, "access."+last.Format("20060102")+".log")), true)
+		t.Assert(dfile.Exists(dfile.Join(p, "access.other.log")), true)
+	})
+}
+
+func Test_Rotate_Period_NoBackup(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			p     = dfile.TempDir(dtime.TimestampNanoStr())
+			l     = New()
+			hooks = 0
+		)
+		err := l.SetConfigWithMap(map[string]interface{}{
+			"Path":         p,
+			"File":         "access.log",
+			"StdoutPrint":  false,
+			"RotatePeriod": "hourly",
+		})
+		t.Assert(err, nil)
+		defer dfile.Remove(p)
+		l.SetRotateHook(func(file, backup string) {
+			hooks++
+		})
+
+		// 没有设置备份数量时与按大小旋转一样直接删除日志文件，不保留备份
+		file := dfile.Join(p, "access.log")
+		l.Print("old")
+		last := time.Now().Add(-2 * time.Hour)
+		t.Assert(os.Chtimes(file, last, last), nil)
+		l.Print("new")
+		l.WaitRotate()
+		t.Assert(strings.Contains(dfile.GetContents(file), "old"), false)
+		t.Assert(strings.Contains(dfile.GetContents(file), "new"), true)
+		files, _ := dfile.ScanDirFile(p, "*")
+		t.Assert(files, []string{file})
+		t.Assert(hooks, 0)
+	})
+}
+
+func Test_Rotate_Period_InvalidConfig(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		l := New()
+		t.AssertNE(l.SetConfigWithMap(map[string]interface{}{"RotatePeriod": "weekly"}), nil)
+		t.AssertNE(l.SetConfigWithMap(map[string]interface{}{"RotateBackupMaxBytes": "abc"}), nil)
+	})
+}
@@ -0,0 +1,147 @@
+package dlog
+
+import (
+	"bytes"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_Sampling(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		w := bytes.NewBuffer(nil)
+		l := NewWithWriter(w)
+		l.SetFlags(0)
+		l.SetSampling(3, 5, time.Hour)
+		for i := 0; i < 20; i++ {
+			l.Infof("request %d failed", i)
+		}
+		// 前3条，之后第5、10、15条
+		t.Assert(strings.Count(w.String(), "\n"), 6)
+		t.Assert(strings.Contains(w.String(), "request 2 failed"), true)
+		t.Assert(strings.Contains(w.String(), "request 3 failed"), false)
+		t.Assert(strings.Contains(w.String(), "request 7 failed"), true)
+
+		// 不同级别和不同消息模板单独计数
+		w.Reset()
+		l.Warningf("request %d failed", 0)
+		l.Info("other")
+		t.Assert(strings.Count(w.String(), "\n"), 2)
+	})
+	// 周期结束时由定时器输出汇总，不需要等待下一条日志
+	dtest.C(t, func(t *dtest.T) {
+		w := &syncBuffer{}
+		l := NewWithWriter(w)
+		l.SetFlags(0)
+		l.SetSampling(1, 0, 100*time.Millisecond)
+		for i := 0; i < 10; i++ {
+			l.Info("hot path")
+		}
+		t.Assert(w.String(), "[INFO] hot path\n")
+		time.Sleep(300 * time.Millisecond)
+		t.Assert(w.String(), "[INFO] hot path\n[INFO] sampling suppressed 9 message(s) in 100ms: hot path\n")
+
+		// 汇总已经输出，新的周期重新计数
+		w.Reset()
+		l.Info("hot path")
+		t.Assert(w.String(), "[INFO] hot path\n")
+	})
+	// 子日志对象共享计数
+	dtest.C(t, func(t *dtest.T) {
+		w := bytes.NewBuffer(nil)
+		l := NewWithWriter(w)
+		l.SetSampling(2, 0, time.Hour)
+		for i := 0; i < 5; i++ {
+			l.With("i", i).Error("failed")
+		}
+		t.Assert(strings.Count(w.String(), "[ERRO]"), 2)
+	})
+}
+
+func Test_Sampling_ByCaller(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		w := bytes.NewBuffer(nil)
+		l := NewWithWriter(w)
+		l.SetFlags(0)
+		l.SetSampling(1, 0, time.Hour, SampleByCaller)
+		for i := 0; i < 3; i++ {
+			l.Infof("a%d", i)
+			l.Infof("b%d", i)
+		}
+		t.Assert(w.String(), "[INFO] a0\n[INFO] b0\n")
+	})
+}
+
+func Test_Sampling_Config(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		w := bytes.NewBuffer(nil)
+		l := NewWithWriter(w)
+		err := l.SetConfigWithMap(map[string]interface{}{
+			"sampleInitial":    2,
+			"sampleThereafter": 0,
+			"sampleInterval":   "1h",
+			"sampleBy":         "caller",
+		})
+		t.Assert(err, nil)
+		t.Assert(l.GetWriter(), nil)
+		t.Assert(l.config.SampleInterval, time.Hour)
+		l.SetWriter(w)
+		for i := 0; i < 5; i++ {
+			l.Print(i)
+		}
+		t.Assert(strings.Count(w.String(), "\n"), 2)
+
+		// 关闭采样
+		l.SetSampling(0, 0, 0)
+		w.Reset()
+		for i := 0; i < 5; i++ {
+			l.Print(i)
+		}
+		t.Assert(strings.Count(w.String(), "\n"), 5)
+	})
+}
+
+func Test_Sampling_NoAlloc(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		l := NewWithWriter(bytes.NewBuffer(nil))
+		l.SetSampling(1, 0, time.Hour)
+		l.Infof("dropped %d", 1)
+		allocs := testing.AllocsPerRun(100, func() {
+			l.Infof("dropped %d", 1)
+		})
+		t.Assert(allocs, 0)
+
+		l.SetSampling(1, 0, time.Hour, SampleByCaller)
+		l.Info("dropped")
+		allocs = testing.AllocsPerRun(100, func() {
+			l.Info("dropped")
+		})
+		t.Assert(allocs, 0)
+	})
+}
+
+// 并发安全的缓冲区，汇总由定时器协程写入
+type syncBuffer struct {
+	mu     sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buffer.String()
+}
+
+func (b *syncBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buffer.Reset()
+}
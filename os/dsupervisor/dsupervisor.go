@@ -0,0 +1,185 @@
+// Package dsupervisor 主从进程模式的进程管理器。
+// 主进程负责监听端口，通过dfork启动多个工作进程，工作进程通过net/inherit继承主进程的监听句柄，
+// 工作进程异常退出时主进程会按退避时间重启它，并支持逐个替换工作进程的滚动重启。
+package dsupervisor
+
+import (
+	"github.com/osgochina/donkeygo/container/dtype"
+	"github.com/osgochina/donkeygo/net/inherit"
+	"github.com/osgochina/donkeygo/os/dlog"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	envWorkerID        = "DSUPERVISOR_WORKER_ID" // 传递给工作进程的编号
+	defaultMinBackoff  = 100 * time.Millisecond  // 默认最小重启间隔
+	defaultMaxBackoff  = 30 * time.Second        // 默认最大重启间隔
+	defaultReadyDelay  = time.Second             // 默认工作进程启动后多久视为就绪
+	defaultStopTimeout = 15 * time.Second        // 默认停止工作进程的超时时间
+)
+
+const (
+	StatusRunning  = "running"  // 工作进程运行中
+	StatusStopping = "stopping" // 工作进程停止中
+	StatusExited   = "exited"   // 工作进程已退出
+)
+
+// Options 进程管理器的选项
+type Options struct {
+	Method      string        // 工作进程执行的方法名，需要先通过dfork.AddMethod注册
+	Args        []string      // 传递给工作进程的额外参数
+	Workers     int           // 工作进程数量，默认为1
+	MinBackoff  time.Duration // 工作进程异常退出后的最小重启间隔，连续失败时间隔翻倍
+	MaxBackoff  time.Duration // 最大重启间隔
+	ReadyDelay  time.Duration // 滚动重启时，新进程启动后存活多久视为就绪
+	StopTimeout time.Duration // 停止工作进程时等待的超时时间，超时后强制结束
+	AdminSocket string        // 管理接口的unix socket路径，为空则不开启
+}
+
+// Supervisor 进程管理器
+type Supervisor struct {
+	options   Options
+	mu        sync.RWMutex
+	slots     []*slot       // 工作进程槽位
+	files     []*os.File    // 需要工作进程继承的监听句柄
+	envs      []string      // 传递给工作进程的环境变量
+	stopping  *dtype.Bool   // 是否正在停止
+	done      chan struct{} // 停止完成后关闭
+	reloadMu  sync.Mutex    // 滚动重启互斥锁
+	admin     net.Listener  // 管理接口的监听
+	logger    *dlog.Logger
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// WorkerStatus 工作进程的状态
+type WorkerStatus struct {
+	ID        int       `json:"id"`        // 工作进程编号
+	Pid       int       `json:"pid"`       // 进程id
+	Status    string    `json:"status"`    // 状态
+	Restarts  int       `json:"restarts"`  // 异常重启次数
+	StartedAt time.Time `json:"startedAt"` // 启动时间
+}
+
+// New 创建进程管理器
+func New(options Options) *Supervisor {
+	if options.Workers <= 0 {
+		options.Workers = 1
+	}
+	if options.MinBackoff <= 0 {
+		options.MinBackoff = defaultMinBackoff
+	}
+	if options.MaxBackoff < options.MinBackoff {
+		options.MaxBackoff = defaultMaxBackoff
+	}
+	if options.ReadyDelay <= 0 {
+		options.ReadyDelay = defaultReadyDelay
+	}
+	if options.StopTimeout <= 0 {
+		options.StopTimeout = defaultStopTimeout
+	}
+	s := &Supervisor{
+		options:  options,
+		stopping: dtype.NewBool(),
+		done:     make(chan struct{}),
+		logger:   dlog.New(),
+	}
+	for i := 0; i < options.Workers; i++ {
+		s.slots = append(s.slots, &slot{id: i, backoff: options.MinBackoff})
+	}
+	return s
+}
+
+// IsWorker 判断当前进程是否是由进程管理器启动的工作进程
+func IsWorker() bool {
+	_, ok := os.LookupEnv(envWorkerID)
+	return ok
+}
+
+// WorkerID 获取当前工作进程的编号，不是工作进程时返回-1
+func WorkerID() int {
+	if v, ok := os.LookupEnv(envWorkerID); ok {
+		if id, err := strconv.Atoi(v); err == nil {
+			return id
+		}
+	}
+	return -1
+}
+
+// SetLogger 设置日志对象
+func (that *Supervisor) SetLogger(logger *dlog.Logger) {
+	that.logger = logger
+}
+
+// Listen 在主进程中监听地址，工作进程中使用inherit.Listen监听相同的地址即可继承该句柄
+func (that *Supervisor) Listen(network, addr string) (net.Listener, error) {
+	return inherit.Listen(network, addr)
+}
+
+// Start 启动所有工作进程和管理接口，不阻塞
+func (that *Supervisor) Start() error {
+	var err error
+	that.startOnce.Do(func() {
+		files, envs, e := inherit.Files()
+		if e != nil {
+			err = e
+			return
+		}
+		that.files = files
+		for k, v := range envs {
+			that.envs = append(that.envs, k+"="+v)
+		}
+		if that.options.AdminSocket != "" {
+			if err = that.serveAdmin(that.options.AdminSocket); err != nil {
+				return
+			}
+		}
+		for _, s := range that.slots {
+			if _, e = that.startWorker(s); e != nil {
+				that.logger.Errorf("[dsupervisor] start worker %d failed: %s", s.id, e.Error())
+				that.scheduleRestart(s, nil)
+			}
+		}
+	})
+	return err
+}
+
+// Run 启动进程管理器并处理信号，直到进程管理器停止
+func (that *Supervisor) Run() error {
+	if err := that.Start(); err != nil {
+		return err
+	}
+	that.handleSignal()
+	<-that.done
+	return nil
+}
+
+// Status 获取所有工作进程的状态
+func (that *Supervisor) Status() []WorkerStatus {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	list := make([]WorkerStatus, 0, len(that.slots))
+	for _, s := range that.slots {
+		status := WorkerStatus{
+			ID:       s.id,
+			Status:   StatusExited,
+			Restarts: s.restarts,
+		}
+		if w := s.worker; w != nil {
+			status.Pid = w.pid
+			status.Status = w.status
+			status.StartedAt = w.startedAt
+		}
+		list = append(list, status)
+	}
+	return list
+}
+
+// Done 进程管理器停止后关闭的通道
+func (that *Supervisor) Done() <-chan struct{} {
+	return that.done
+}
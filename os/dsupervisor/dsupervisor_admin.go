@@ -0,0 +1,66 @@
+package dsupervisor
+
+import (
+	"github.com/osgochina/donkeygo/internal/json"
+	"github.com/osgochina/donkeygo/os/dfile"
+	"net"
+	"net/http"
+	"os"
+)
+
+// 开启基于unix socket的管理接口，可以使用 curl --unix-socket <path> http://localhost/status 访问：
+// GET  /status 获取所有工作进程的状态；
+// POST /reload 滚动重启所有工作进程；
+// POST /stop   停止所有工作进程并退出。
+func (that *Supervisor) serveAdmin(path string) error {
+	if dfile.Exists(path) {
+		_ = dfile.Remove(path)
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	that.admin = ln
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		that.writeJson(w, http.StatusOK, map[string]interface{}{
+			"pid":     os.Getpid(),
+			"workers": that.Status(),
+		})
+	})
+	mux.HandleFunc("/reload", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			that.writeJson(w, http.StatusMethodNotAllowed, map[string]interface{}{"error": "method not allowed"})
+			return
+		}
+		if err := that.Reload(); err != nil {
+			that.writeJson(w, http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+			return
+		}
+		that.writeJson(w, http.StatusOK, map[string]interface{}{"workers": that.Status()})
+	})
+	mux.HandleFunc("/stop", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			that.writeJson(w, http.StatusMethodNotAllowed, map[string]interface{}{"error": "method not allowed"})
+			return
+		}
+		that.writeJson(w, http.StatusAccepted, map[string]interface{}{"stopping": true})
+		go that.Stop()
+	})
+	go func() {
+		_ = http.Serve(ln, mux)
+	}()
+	return nil
+}
+
+// 输出json格式的响应
+func (that *Supervisor) writeJson(w http.ResponseWriter, code int, data interface{}) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(b)
+}
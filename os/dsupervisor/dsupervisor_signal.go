@@ -0,0 +1,40 @@
+package dsupervisor
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// 处理主进程收到的信号：
+// SIGINT、SIGTERM 停止所有工作进程后退出；
+// SIGHUP、SIGUSR2 滚动重启所有工作进程；
+// SIGUSR1、SIGQUIT 转发给所有工作进程。
+func (that *Supervisor) handleSignal() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGQUIT)
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case sig := <-ch:
+				that.logger.Infof("[dsupervisor] received signal: %s", sig.String())
+				switch sig {
+				case syscall.SIGINT, syscall.SIGTERM:
+					that.Stop()
+					return
+				case syscall.SIGHUP, syscall.SIGUSR2:
+					go func() {
+						if err := that.Reload(); err != nil {
+							that.logger.Errorf("[dsupervisor] rolling restart failed: %s", err.Error())
+						}
+					}()
+				default:
+					that.Signal(sig)
+				}
+			case <-that.done:
+				return
+			}
+		}
+	}()
+}
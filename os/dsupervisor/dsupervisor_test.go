@@ -0,0 +1,150 @@
+package dsupervisor_test
+
+import (
+	"context"
+	"github.com/osgochina/donkeygo/internal/json"
+	"github.com/osgochina/donkeygo/os/dfile"
+	"github.com/osgochina/donkeygo/os/dfork"
+	"github.com/osgochina/donkeygo/os/dsupervisor"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func init() {
+	dfork.AddMethod("dsupervisor-worker", func() {
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, syscall.SIGTERM)
+		<-ch
+		os.Exit(0)
+	})
+	dfork.AddMethod("dsupervisor-reload-crash", func() {
+		if os.Getenv("DSUPERVISOR_TEST_CRASH") != "" {
+			time.Sleep(50 * time.Millisecond)
+			os.Exit(1)
+		}
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, syscall.SIGTERM)
+		<-ch
+		os.Exit(0)
+	})
+	dfork.AddMethod("dsupervisor-crash", func() {
+		time.Sleep(50 * time.Millisecond)
+		os.Exit(1)
+	})
+	if dfork.Run() {
+		os.Exit(0)
+	}
+}
+
+func TestSupervisor_Reload(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		socket := dfile.Join(dfile.TempDir(), "dsupervisor_test.sock")
+		s := dsupervisor.New(dsupervisor.Options{
+			Method:      "dsupervisor-worker",
+			Workers:     2,
+			ReadyDelay:  200 * time.Millisecond,
+			AdminSocket: socket,
+		})
+		_, err := s.Listen("tcp", "127.0.0.1:0")
+		t.Assert(err, nil)
+		t.Assert(s.Start(), nil)
+		time.Sleep(200 * time.Millisecond)
+
+		status := s.Status()
+		t.Assert(len(status), 2)
+		pids := make([]int, 0)
+		for _, v := range status {
+			t.Assert(v.Status, dsupervisor.StatusRunning)
+			t.AssertGT(v.Pid, 0)
+			pids = append(pids, v.Pid)
+		}
+
+		client := &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return net.Dial("unix", socket)
+				},
+			},
+		}
+		resp, err := client.Get("http://localhost/status")
+		t.Assert(err, nil)
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		var result struct {
+			Pid     int
+			Workers []dsupervisor.WorkerStatus
+		}
+		t.Assert(json.Unmarshal(body, &result), nil)
+		t.Assert(result.Pid, os.Getpid())
+		t.Assert(len(result.Workers), 2)
+
+		t.Assert(s.Reload(), nil)
+		for i, v := range s.Status() {
+			t.Assert(v.Status, dsupervisor.StatusRunning)
+			t.AssertNE(v.Pid, pids[i])
+			t.Assert(v.Restarts, 0)
+		}
+
+		s.Stop()
+		<-s.Done()
+		for _, v := range s.Status() {
+			t.Assert(v.Status, dsupervisor.StatusExited)
+		}
+	})
+}
+
+func TestSupervisor_ReloadRollback(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		s := dsupervisor.New(dsupervisor.Options{
+			Method:     "dsupervisor-reload-crash",
+			ReadyDelay: 200 * time.Millisecond,
+			MinBackoff: 20 * time.Millisecond,
+		})
+		t.Assert(s.Start(), nil)
+		time.Sleep(100 * time.Millisecond)
+		pid := s.Status()[0].Pid
+
+		// 新进程就绪前退出，恢复旧进程，不计入异常重启
+		t.Assert(os.Setenv("DSUPERVISOR_TEST_CRASH", "1"), nil)
+		defer os.Unsetenv("DSUPERVISOR_TEST_CRASH")
+		t.AssertNE(s.Reload(), nil)
+		time.Sleep(100 * time.Millisecond)
+		status := s.Status()
+		t.Assert(status[0].Pid, pid)
+		t.Assert(status[0].Status, dsupervisor.StatusRunning)
+		t.Assert(status[0].Restarts, 0)
+
+		s.Stop()
+		<-s.Done()
+	})
+}
+
+func TestSupervisor_Restart(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		s := dsupervisor.New(dsupervisor.Options{
+			Method:     "dsupervisor-crash",
+			MinBackoff: 20 * time.Millisecond,
+			MaxBackoff: 100 * time.Millisecond,
+		})
+		t.Assert(s.Start(), nil)
+		time.Sleep(time.Second)
+		status := s.Status()
+		t.Assert(len(status), 1)
+		t.AssertGT(status[0].Restarts, 2)
+		s.Stop()
+	})
+}
+
+func TestWorkerID(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		t.Assert(dsupervisor.IsWorker(), false)
+		t.Assert(dsupervisor.WorkerID(), -1)
+	})
+}
@@ -0,0 +1,222 @@
+package dsupervisor
+
+import (
+	"errors"
+	"fmt"
+	"github.com/osgochina/donkeygo/os/dfork"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// 工作进程槽位，槽位中的工作进程退出后会在该槽位重启
+type slot struct {
+	id       int           // 槽位编号，也是工作进程编号
+	worker   *worker       // 当前的工作进程
+	restarts int           // 异常重启次数
+	backoff  time.Duration // 下一次重启的等待时间
+}
+
+// 工作进程
+type worker struct {
+	slot      *slot
+	pid       int
+	status    string
+	startedAt time.Time
+	process   *os.Process
+	retired   bool          // 是否已被替换，被替换的进程退出后不会重启
+	reloading bool          // 是否在滚动重启中等待就绪，就绪前退出由Reload恢复旧进程，不会重启
+	exited    chan struct{} // 进程退出后关闭
+}
+
+// 在槽位中启动一个新的工作进程，替换槽位中原有的工作进程
+func (that *Supervisor) startWorker(s *slot, reloading ...bool) (*worker, error) {
+	cmd := dfork.Command(append([]string{that.options.Method}, that.options.Args...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = that.files
+	cmd.Env = append(os.Environ(), that.envs...)
+	cmd.Env = append(cmd.Env, envWorkerID+"="+strconv.Itoa(s.id))
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	w := &worker{
+		slot:      s,
+		pid:       cmd.Process.Pid,
+		status:    StatusRunning,
+		startedAt: time.Now(),
+		process:   cmd.Process,
+		exited:    make(chan struct{}),
+		reloading: len(reloading) > 0 && reloading[0],
+	}
+	that.mu.Lock()
+	if s.worker != nil {
+		s.worker.retired = true
+	}
+	s.worker = w
+	that.mu.Unlock()
+	that.logger.Infof("[dsupervisor] worker %d started, pid: %d", s.id, w.pid)
+
+	go func() {
+		err := cmd.Wait()
+		that.onExit(w, err)
+	}()
+	return w, nil
+}
+
+// 工作进程退出后的处理，不是主动停止的进程会按照退避时间重启
+func (that *Supervisor) onExit(w *worker, err error) {
+	that.mu.Lock()
+	w.status = StatusExited
+	close(w.exited)
+	if that.stopping.Val() || w.retired || w.reloading {
+		that.mu.Unlock()
+		that.logger.Infof("[dsupervisor] worker %d exited, pid: %d", w.slot.id, w.pid)
+		return
+	}
+	that.mu.Unlock()
+	if err != nil {
+		that.logger.Errorf("[dsupervisor] worker %d crashed, pid: %d, error: %s", w.slot.id, w.pid, err.Error())
+	} else {
+		that.logger.Errorf("[dsupervisor] worker %d exited unexpectedly, pid: %d", w.slot.id, w.pid)
+	}
+	that.scheduleRestart(w.slot, w)
+}
+
+// 按照退避时间重启槽位中的工作进程，运行时间超过最大退避时间的进程退出后重新计算退避时间
+func (that *Supervisor) scheduleRestart(s *slot, old *worker) {
+	that.mu.Lock()
+	if old != nil && time.Since(old.startedAt) > that.options.MaxBackoff {
+		s.backoff = that.options.MinBackoff
+	}
+	backoff := s.backoff
+	s.backoff *= 2
+	if s.backoff > that.options.MaxBackoff {
+		s.backoff = that.options.MaxBackoff
+	}
+	s.restarts++
+	that.mu.Unlock()
+
+	that.logger.Infof("[dsupervisor] worker %d will restart in %s", s.id, backoff)
+	time.AfterFunc(backoff, func() {
+		that.mu.RLock()
+		current := s.worker
+		that.mu.RUnlock()
+		// 进程管理器已停止或者槽位中的进程已经被替换
+		if that.stopping.Val() || current != old {
+			return
+		}
+		if _, err := that.startWorker(s); err != nil {
+			that.logger.Errorf("[dsupervisor] restart worker %d failed: %s", s.id, err.Error())
+			that.scheduleRestart(s, old)
+		}
+	})
+}
+
+// 停止工作进程，超时后强制结束
+func (that *Supervisor) stopWorker(w *worker) {
+	that.mu.Lock()
+	if w.status == StatusExited {
+		that.mu.Unlock()
+		return
+	}
+	w.status = StatusStopping
+	that.mu.Unlock()
+	_ = w.process.Signal(syscall.SIGTERM)
+	select {
+	case <-w.exited:
+	case <-time.After(that.options.StopTimeout):
+		that.logger.Errorf("[dsupervisor] worker %d stop timeout, kill it, pid: %d", w.slot.id, w.pid)
+		_ = w.process.Kill()
+		<-w.exited
+	}
+}
+
+// Reload 滚动重启所有工作进程，每次只替换一个，新进程就绪后再停止旧进程
+func (that *Supervisor) Reload() error {
+	that.reloadMu.Lock()
+	defer that.reloadMu.Unlock()
+	that.logger.Infof("[dsupervisor] rolling restart workers...")
+	for _, s := range that.slots {
+		if that.stopping.Val() {
+			return errors.New("supervisor is stopping")
+		}
+		that.mu.RLock()
+		old := s.worker
+		that.mu.RUnlock()
+
+		w, err := that.startWorker(s, true)
+		if err != nil {
+			return err
+		}
+		// 等待新进程就绪，就绪前退出则恢复旧进程，旧进程也已经退出时按照退避时间重启
+		select {
+		case <-w.exited:
+		case <-time.After(that.options.ReadyDelay):
+		}
+		that.mu.Lock()
+		w.reloading = false
+		if w.status == StatusExited {
+			restore := old != nil && old.status != StatusExited
+			if restore {
+				old.retired = false
+				s.worker = old
+			}
+			that.mu.Unlock()
+			if !restore {
+				that.scheduleRestart(s, w)
+			}
+			return errors.New(fmt.Sprintf("worker %d exited before ready", s.id))
+		}
+		that.mu.Unlock()
+		if old != nil {
+			that.stopWorker(old)
+		}
+	}
+	that.logger.Infof("[dsupervisor] rolling restart finished")
+	return nil
+}
+
+// Stop 停止所有工作进程和管理接口
+func (that *Supervisor) Stop() {
+	that.stopOnce.Do(func() {
+		that.stopping.Set(true)
+		that.logger.Infof("[dsupervisor] stopping workers...")
+		that.mu.RLock()
+		workers := make([]*worker, 0, len(that.slots))
+		for _, s := range that.slots {
+			if s.worker != nil {
+				workers = append(workers, s.worker)
+			}
+		}
+		that.mu.RUnlock()
+		done := make(chan struct{}, len(workers))
+		for _, w := range workers {
+			go func(w *worker) {
+				that.stopWorker(w)
+				done <- struct{}{}
+			}(w)
+		}
+		for range workers {
+			<-done
+		}
+		if that.admin != nil {
+			_ = that.admin.Close()
+		}
+		that.logger.Infof("[dsupervisor] all workers stopped")
+		close(that.done)
+	})
+}
+
+// Signal 向所有工作进程发送信号
+func (that *Supervisor) Signal(sig os.Signal) {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+	for _, s := range that.slots {
+		if s.worker != nil && s.worker.status != StatusExited {
+			_ = s.worker.process.Signal(sig)
+		}
+	}
+}
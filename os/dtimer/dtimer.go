@@ -12,6 +12,7 @@ import (
 type Timer struct {
 	mu      sync.RWMutex
 	queue   *priorityQueue // 基于堆结构的优先队列
+	wheel   *timingWheel   // 分层时间轮，时间轮模式下使用
 	status  *dtype.Int     // 当前定时器的状态
 	ticks   *dtype.Int64   // 定时器进行的间隔数
 	options TimerOptions   // 定时器选项
@@ -19,6 +20,7 @@ type Timer struct {
 
 type TimerOptions struct {
 	Interval time.Duration // Interval 定时器的触发间隔
+	Mode     int           // Mode 定时器的调度模式，默认使用优先队列
 }
 
 const (
@@ -32,6 +34,11 @@ const (
 	commandEnvKeyForInterval = "dk.dtimer.interval" //环境变量中的参数
 )
 
+const (
+	ModeQueue = 0 // 使用基于堆的优先队列调度任务，适合任务数量不多的场景
+	ModeWheel = 1 // 使用分层时间轮调度任务，添加和取消任务都是O(1)，适合海量任务的场景
+)
+
 var (
 	defaultTimer    = New()
 	defaultInterval = dcmd.GetOptWithEnv(commandEnvKeyForInterval, defaultTimerInterval).Duration() * time.Millisecond
@@ -1,19 +1,22 @@
 package dtimer
 
 import (
+	"container/list"
 	"github.com/osgochina/donkeygo/container/dtype"
 	"math"
 )
 
 // Entry 定时器要执行的任务
 type Entry struct {
-	job       JobFunc      // 要执行的func
-	timer     *Timer       // 定时器对象
-	ticks     int64        // 任务执行间隔，当前执行ticks+ticks等于下一次需要执行的ticks
-	times     *dtype.Int   // job func要执行的次数
-	status    *dtype.Int   // 任务状态
-	singleton *dtype.Bool  // 是否是一次性任务
-	nextTicks *dtype.Int64 // 该任务下一次执行的时间
+	job       JobFunc       // 要执行的func
+	timer     *Timer        // 定时器对象
+	ticks     int64         // 任务执行间隔，当前执行ticks+ticks等于下一次需要执行的ticks
+	times     *dtype.Int    // job func要执行的次数
+	status    *dtype.Int    // 任务状态
+	singleton *dtype.Bool   // 是否是一次性任务
+	nextTicks *dtype.Int64  // 该任务下一次执行的时间
+	wheelSlot *wheelSlot    // 时间轮模式下任务所在的槽，由时间轮的锁保护
+	wheelElem *list.Element // 任务在槽中的节点
 }
 
 type JobFunc = func()
@@ -29,7 +32,7 @@ func (that *Entry) Run() {
 	leftRunningTimes := that.times.Add(-1)
 	// 检查执行次数，如果到了设置的值，则关闭该任务
 	if leftRunningTimes < 0 {
-		that.status.Set(StatusClosed)
+		that.Close()
 		return
 	}
 	// 不限制执行次数
@@ -87,7 +90,11 @@ func (that *Entry) doCheckAndRunByTicks(currentTimerTicks int64) {
 
 // SetStatus 修改任务状态
 func (that *Entry) SetStatus(status int) int {
-	return that.status.Set(status)
+	old := that.status.Set(status)
+	if status == StatusClosed {
+		that.removeFromWheel()
+	}
+	return old
 }
 
 // Start 启动任务，把任务状态修改为准备执行
@@ -100,9 +107,17 @@ func (that *Entry) Stop() {
 	that.status.Set(StatusStopped)
 }
 
-// Close 关闭该任务
+// Close 关闭该任务，时间轮模式下会立即把任务从时间轮中移除
 func (that *Entry) Close() {
 	that.status.Set(StatusClosed)
+	that.removeFromWheel()
+}
+
+// 时间轮模式下把任务从所在的槽中移除，避免长时间延迟的任务关闭后依然占用内存
+func (that *Entry) removeFromWheel() {
+	if that.timer != nil && that.timer.wheel != nil {
+		that.timer.wheel.Remove(that)
+	}
 }
 
 // Reset 重置任务运行节拍器
@@ -14,9 +14,15 @@ func New(options ...TimerOptions) *Timer {
 	}
 	if len(options) > 0 {
 		t.options = options[0]
+		if t.options.Interval <= 0 {
+			t.options.Interval = defaultInterval
+		}
 	} else {
 		t.options = DefaultOptions()
 	}
+	if t.options.Mode == ModeWheel {
+		t.wheel = newTimingWheel()
+	}
 	go t.loop()
 	return t
 }
@@ -125,6 +131,15 @@ func (that *Timer) createEntry(interval time.Duration, job JobFunc, singleton bo
 		singleton: dtype.NewBool(singleton),
 		nextTicks: dtype.NewInt64(nextTicks),
 	}
-	that.queue.Push(entry, nextTicks)
+	that.push(entry, nextTicks)
 	return entry
 }
+
+// 把任务放入调度器，等待到达ticks节拍时执行
+func (that *Timer) push(entry *Entry, ticks int64) {
+	if that.wheel != nil {
+		that.wheel.Push(entry, ticks)
+	} else {
+		that.queue.Push(entry, ticks)
+	}
+}
@@ -20,6 +20,11 @@ func (that *Timer) loop() {
 				case StatusRunning:
 					// 定时器进行中，定时器触发成功，则刻度前进1
 					currentTimerTicks = that.ticks.Add(1)
+					// 时间轮模式下每个节拍都需要推进时间轮
+					if that.wheel != nil {
+						that.proceedWheel(currentTimerTicks)
+						break
+					}
 					// 如果当前的刻度满足执行条件，则执行
 					if currentTimerTicks >= that.queue.LatestPriority() {
 						that.proceed(currentTimerTicks)
@@ -62,3 +67,19 @@ func (that *Timer) proceed(currentTimerTicks int64) {
 		}
 	}
 }
+
+// 推进时间轮并执行到期的任务
+func (that *Timer) proceedWheel(currentTimerTicks int64) {
+	for _, entry := range that.wheel.Advance(currentTimerTicks) {
+		// 任务被重置过节拍，还没到执行时间，重新放入时间轮
+		if jobNextTicks := entry.nextTicks.Val(); currentTimerTicks < jobNextTicks {
+			that.wheel.Push(entry, jobNextTicks)
+			continue
+		}
+		entry.doCheckAndRunByTicks(currentTimerTicks)
+		// 关闭的任务直接丢弃，其他任务等待下次执行
+		if entry.Status() != StatusClosed {
+			that.wheel.Push(entry, entry.nextTicks.Val())
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package dtimer
+
+import (
+	"container/list"
+	"sync"
+)
+
+const (
+	wheelRootBits  = 8                     // 第一层时间轮的位数
+	wheelLevelBits = 6                     // 其他层时间轮的位数
+	wheelLevels    = 5                     // 时间轮的层数，一共能覆盖 2^32 个节拍
+	wheelRootSize  = 1 << wheelRootBits    // 第一层时间轮的槽数
+	wheelLevelSize = 1 << wheelLevelBits   // 其他层时间轮的槽数
+	wheelRootMask  = wheelRootSize - 1     // 第一层时间轮的槽位掩码
+	wheelLevelMask = wheelLevelSize - 1    // 其他层时间轮的槽位掩码
+	wheelMaxTicks  = int64(1)<<32 - 1      // 时间轮能覆盖的最大节拍跨度
+	wheelRootSpan  = int64(wheelRootSize)  // 第一层时间轮覆盖的节拍跨度
+	wheelLevelSpan = int64(wheelLevelSize) // 其他层时间轮每层放大的倍数
+)
+
+// 分层时间轮，添加任务的时间复杂度为O(1)。
+// 第一层每个槽代表一个节拍，之后每层的一个槽代表上一层转一圈的节拍数，
+// 当下层时间轮转完一圈时，把上层对应槽中的任务重新分配到下层。
+type timingWheel struct {
+	mu      sync.Mutex
+	current int64                     // 时间轮已经推进到的节拍
+	levels  [wheelLevels][]*wheelSlot // 每一层的槽
+}
+
+// 时间轮的槽，保存到期节拍落在该槽中的任务，使用链表保存，关闭任务时可以O(1)地从槽中移除
+type wheelSlot struct {
+	entries *list.List
+}
+
+// 创建时间轮
+func newTimingWheel() *timingWheel {
+	w := &timingWheel{}
+	for i := range w.levels {
+		size := wheelLevelSize
+		if i == 0 {
+			size = wheelRootSize
+		}
+		w.levels[i] = make([]*wheelSlot, size)
+		for j := range w.levels[i] {
+			w.levels[i][j] = &wheelSlot{entries: list.New()}
+		}
+	}
+	return w
+}
+
+// Push 添加任务到时间轮，ticks为任务的到期节拍，已经关闭的任务不会被添加
+func (that *timingWheel) Push(entry *Entry, ticks int64) {
+	that.mu.Lock()
+	// 任务先修改状态再从时间轮移除，在锁内检查状态，避免关闭的任务被重新放入槽中
+	if entry.status == nil || entry.status.Val() != StatusClosed {
+		// 当前节拍的槽已经处理过了，已经到期的任务放到下一个节拍执行
+		that.add(entry, ticks, that.current+1)
+	}
+	that.mu.Unlock()
+}
+
+// Remove 把任务从所在的槽中移除，任务关闭时调用
+func (that *timingWheel) Remove(entry *Entry) {
+	that.mu.Lock()
+	that.unlink(entry)
+	that.mu.Unlock()
+}
+
+// 把任务放入槽中
+func (that *timingWheel) link(entry *Entry, slot *wheelSlot) {
+	entry.wheelSlot, entry.wheelElem = slot, slot.entries.PushBack(entry)
+}
+
+// 把任务从所在的槽中移除
+func (that *timingWheel) unlink(entry *Entry) {
+	if entry.wheelSlot != nil {
+		entry.wheelSlot.entries.Remove(entry.wheelElem)
+		entry.wheelSlot, entry.wheelElem = nil, nil
+	}
+}
+
+// 根据到期节拍与当前节拍的差值，计算任务应该所在的层和槽，earliest为任务最早可以放入的节拍
+func (that *timingWheel) add(entry *Entry, ticks int64, earliest int64) {
+	if ticks < earliest {
+		ticks = earliest
+	}
+	delta := ticks - that.current
+	if delta > wheelMaxTicks {
+		ticks = that.current + wheelMaxTicks
+		delta = wheelMaxTicks
+	}
+	if delta < wheelRootSpan {
+		that.link(entry, that.levels[0][ticks&wheelRootMask])
+		return
+	}
+	span := wheelRootSpan
+	shift := uint(wheelRootBits)
+	for level := 1; level < wheelLevels; level++ {
+		span *= wheelLevelSpan
+		if delta < span || level == wheelLevels-1 {
+			that.link(entry, that.levels[level][(ticks>>shift)&wheelLevelMask])
+			return
+		}
+		shift += wheelLevelBits
+	}
+}
+
+// Advance 把时间轮推进到指定的节拍，并返回推进过程中到期的任务
+func (that *timingWheel) Advance(ticks int64) []*Entry {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	var expired []*Entry
+	for that.current < ticks {
+		that.current++
+		index := that.current & wheelRootMask
+		if index == 0 {
+			that.cascade()
+		}
+		expired = append(expired, that.take(that.levels[0][index])...)
+	}
+	return expired
+}
+
+// 第一层时间轮转完一圈，逐层把上层当前槽中的任务重新分配到下层
+func (that *timingWheel) cascade() {
+	shift := uint(wheelRootBits)
+	for level := 1; level < wheelLevels; level++ {
+		index := (that.current >> shift) & wheelLevelMask
+		for _, entry := range that.take(that.levels[level][index]) {
+			// 重新分配发生在处理当前节拍的槽之前，到期的任务可以放入当前节拍的槽
+			that.add(entry, entry.nextTicks.Val(), that.current)
+		}
+		// 当前层没有转完一圈，则不需要继续处理更上层
+		if index != 0 {
+			break
+		}
+		shift += wheelLevelBits
+	}
+}
+
+// Len 时间轮中的任务数量
+func (that *timingWheel) Len() int {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+	n := 0
+	for _, level := range that.levels {
+		for _, slot := range level {
+			n += slot.entries.Len()
+		}
+	}
+	return n
+}
+
+// 取出槽中的所有任务，并清空该槽
+func (that *timingWheel) take(slot *wheelSlot) []*Entry {
+	if slot.entries.Len() == 0 {
+		return nil
+	}
+	entries := make([]*Entry, 0, slot.entries.Len())
+	for elem := slot.entries.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*Entry)
+		entry.wheelSlot, entry.wheelElem = nil, nil
+		entries = append(entries, entry)
+	}
+	slot.entries.Init()
+	return entries
+}
@@ -0,0 +1,37 @@
+package dtimer
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	wheelTimer = New(TimerOptions{
+		Interval: defaultInterval,
+		Mode:     ModeWheel,
+	})
+)
+
+func Benchmark_Wheel_Add(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		wheelTimer.Add(time.Hour, func() {
+
+		})
+	}
+}
+
+func Benchmark_Queue_AddClose(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		timer.Add(time.Hour, func() {
+
+		}).Close()
+	}
+}
+
+func Benchmark_Wheel_AddClose(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		wheelTimer.Add(time.Hour, func() {
+
+		}).Close()
+	}
+}
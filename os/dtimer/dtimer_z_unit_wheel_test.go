@@ -0,0 +1,116 @@
+package dtimer
+
+import (
+	"github.com/osgochina/donkeygo/container/darray"
+	"github.com/osgochina/donkeygo/container/dtype"
+	"github.com/osgochina/donkeygo/test/dtest"
+	"testing"
+	"time"
+)
+
+func TestTimingWheel_Advance(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			wheel   = newTimingWheel()
+			entries = make(map[int64]*Entry)
+		)
+		for _, ticks := range []int64{1, 255, 256, 257, 16383, 16384, 16385, 1 << 20, 1<<26 + 3} {
+			entry := &Entry{nextTicks: dtype.NewInt64(ticks)}
+			entries[ticks] = entry
+			wheel.Push(entry, ticks)
+		}
+		t.Assert(wheel.Len(), len(entries))
+		var last int64
+		for _, ticks := range []int64{1, 255, 256, 257, 16383, 16384, 16385, 1 << 20, 1<<26 + 3} {
+			if ticks > last+1 {
+				t.Assert(len(wheel.Advance(ticks-1)), 0)
+			}
+			expired := wheel.Advance(ticks)
+			t.Assert(len(expired), 1)
+			t.Assert(expired[0] == entries[ticks], true)
+			last = ticks
+		}
+		t.Assert(wheel.Len(), 0)
+	})
+}
+
+func TestTimer_Wheel_Proceed(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		array := darray.New(true)
+		timer := New(TimerOptions{
+			Interval: time.Hour,
+			Mode:     ModeWheel,
+		})
+		timer.Add(1000*time.Hour, func() {
+			array.Append(1)
+		})
+		timer.proceedWheel(1000)
+		time.Sleep(10 * time.Millisecond)
+		t.Assert(array.Len(), 1)
+		timer.proceedWheel(1999)
+		time.Sleep(10 * time.Millisecond)
+		t.Assert(array.Len(), 1)
+		timer.proceedWheel(2000)
+		time.Sleep(10 * time.Millisecond)
+		t.Assert(array.Len(), 2)
+	})
+}
+
+func TestTimer_Wheel_Entry(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		var (
+			array = darray.New(true)
+			timer = New(TimerOptions{
+				Interval: 10 * time.Millisecond,
+				Mode:     ModeWheel,
+			})
+		)
+		entry1 := timer.Add(200*time.Millisecond, func() {
+			array.Append(1)
+		})
+		entry2 := timer.AddOnce(200*time.Millisecond, func() {
+			array.Append(2)
+		})
+		timer.AddTimes(200*time.Millisecond, 2, func() {
+			array.Append(3)
+		})
+		time.Sleep(250 * time.Millisecond)
+		t.Assert(array.Len(), 3)
+		entry1.Stop()
+		time.Sleep(250 * time.Millisecond)
+		t.Assert(array.Len(), 4)
+		t.Assert(entry2.Status(), StatusClosed)
+		entry1.Start()
+		entry1.Reset()
+		time.Sleep(100 * time.Millisecond)
+		t.Assert(array.Len(), 4)
+		time.Sleep(150 * time.Millisecond)
+		t.Assert(array.Len(), 5)
+		entry1.Close()
+		time.Sleep(250 * time.Millisecond)
+		t.Assert(array.Len(), 5)
+		timer.Close()
+	})
+}
+
+func TestTimer_Wheel_CloseUnlink(t *testing.T) {
+	dtest.C(t, func(t *dtest.T) {
+		timer := New(TimerOptions{
+			Interval: 10 * time.Millisecond,
+			Mode:     ModeWheel,
+		})
+		defer timer.Close()
+		entries := make([]*Entry, 0, 100)
+		for i := 0; i < 100; i++ {
+			entries = append(entries, timer.Add(time.Hour, func() {}))
+		}
+		t.Assert(timer.wheel.Len(), 100)
+		for _, entry := range entries[:60] {
+			entry.Close()
+		}
+		// 关闭的任务立即从时间轮中移除
+		t.Assert(timer.wheel.Len(), 40)
+		entries[60].SetStatus(StatusClosed)
+		t.Assert(timer.wheel.Len(), 39)
+	})
+}